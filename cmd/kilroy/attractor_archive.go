@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/danshapiro/kilroy/internal/attractor/engine"
+)
+
+func attractorArchive(args []string) {
+	os.Exit(runAttractorArchive(args, os.Stdout, os.Stderr))
+}
+
+func runAttractorArchive(args []string, stdout io.Writer, stderr io.Writer) int {
+	var logsRoot string
+	var outPath string
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--logs-root":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(stderr, "--logs-root requires a value")
+				return 1
+			}
+			logsRoot = args[i]
+		case "--out":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(stderr, "--out requires a value")
+				return 1
+			}
+			outPath = args[i]
+		default:
+			fmt.Fprintf(stderr, "unknown arg: %s\n", args[i])
+			return 1
+		}
+	}
+	if strings.TrimSpace(logsRoot) == "" || strings.TrimSpace(outPath) == "" {
+		fmt.Fprintln(stderr, "--logs-root and --out are required")
+		return 1
+	}
+
+	snapshot, err := loadSnapshot(logsRoot)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if snapshot.IsRunning() {
+		fmt.Fprintf(stderr, "run is still running (pid=%d); stop it or wait for it to finish before archiving\n", snapshot.PID)
+		return 1
+	}
+	if err := engine.ArchiveLogsRoot(logsRoot, outPath); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "archive=%s\n", outPath)
+	return 0
+}
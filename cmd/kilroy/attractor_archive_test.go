@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danshapiro/kilroy/internal/attractor/engine"
+)
+
+func TestAttractorArchive_ThenReportFromArchive(t *testing.T) {
+	logs := t.TempDir()
+	_ = os.WriteFile(filepath.Join(logs, "final.json"), []byte(`{"status":"fail","run_id":"arch1","failure_reason":"verify failed"}`), 0o644)
+	_ = os.WriteFile(filepath.Join(logs, "progress.ndjson"), []byte(`{"event":"run_failed","run_id":"arch1"}`+"\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(logs, "live.json"), []byte(`{"event":"run_failed"}`), 0o644)
+	_ = os.WriteFile(filepath.Join(logs, "run.pid"), []byte("999999"), 0o644)
+	_ = os.MkdirAll(filepath.Join(logs, "implement"), 0o755)
+	_ = os.WriteFile(filepath.Join(logs, "implement", "status.json"), []byte(`{"status":"success"}`), 0o644)
+	_ = os.MkdirAll(filepath.Join(logs, "verify"), 0o755)
+	_ = os.WriteFile(filepath.Join(logs, "verify", "status.json"), []byte(`{"status":"fail","failure_reason":"verify failed"}`), 0o644)
+	_ = os.MkdirAll(filepath.Join(logs, "worktree"), 0o755)
+	_ = os.WriteFile(filepath.Join(logs, "worktree", "README.md"), []byte("repo file\n"), 0o644)
+
+	archive := filepath.Join(t.TempDir(), "run.tar.gz")
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorArchive([]string{"--logs-root", logs, "--out", archive}, &stdout, &stderr); code != 0 {
+		t.Fatalf("archive exit code %d; stderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "archive="+archive) {
+		t.Fatalf("archive output: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := runAttractorReport([]string{"--archive", archive}, &stdout, &stderr); code != 0 {
+		t.Fatalf("report exit code %d; stderr: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{
+		"state=fail",
+		"run_id=arch1",
+		"logs_root=" + archive,
+		"failure_reason=verify failed",
+		"stage=implement status=success",
+		"stage=verify status=fail",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("report missing %q:\n%s", want, out)
+		}
+	}
+
+	// Live-process files and the worktree are left out of the archive.
+	extracted := t.TempDir()
+	if err := engine.ExtractLogsArchive(archive, extracted); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"run.pid", "live.json", "worktree"} {
+		if _, err := os.Stat(filepath.Join(extracted, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be excluded from archive (err=%v)", name, err)
+		}
+	}
+}
+
+func TestAttractorReport_RequiresExactlyOneSource(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorReport(nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code %d want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "exactly one of --logs-root or --archive") {
+		t.Fatalf("stderr: %s", stderr.String())
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/danshapiro/kilroy/internal/attractor/engine"
+)
+
+func attractorGraph(args []string) {
+	os.Exit(runAttractorGraph(args, os.Stdout, os.Stderr))
+}
+
+func runAttractorGraph(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) < 1 {
+		fmt.Fprintln(stderr, "usage: kilroy attractor graph render <graph.dot> [--format svg|png|dot] [--output <file>]")
+		return 1
+	}
+	switch args[0] {
+	case "render":
+		return runAttractorGraphRender(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown graph subcommand: %s\n", args[0])
+		return 1
+	}
+}
+
+// runAttractorGraphRender prepares a graph the way a run would and writes it
+// out: as normalized DOT, or as an image drawn by Graphviz with conditions,
+// weights, and retry targets shown on the edges.
+func runAttractorGraphRender(args []string, stdout io.Writer, stderr io.Writer) int {
+	var graphPath string
+	var outPath string
+	format := "svg"
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--format":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(stderr, "--format requires a value")
+				return 1
+			}
+			format = strings.ToLower(strings.TrimSpace(args[i]))
+		case "--output", "-o":
+			i++
+			if i >= len(args) {
+				fmt.Fprintf(stderr, "%s requires a value\n", args[i-1])
+				return 1
+			}
+			outPath = args[i]
+		default:
+			if graphPath != "" || strings.HasPrefix(args[i], "-") {
+				fmt.Fprintf(stderr, "unknown arg: %s\n", args[i])
+				return 1
+			}
+			graphPath = args[i]
+		}
+	}
+	if graphPath == "" {
+		fmt.Fprintln(stderr, "graph file is required")
+		return 1
+	}
+	switch format {
+	case "svg", "png", "dot":
+	default:
+		fmt.Fprintf(stderr, "invalid --format %q (want svg|png|dot)\n", format)
+		return 1
+	}
+
+	dotSource, err := os.ReadFile(graphPath)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	g, diags, err := engine.PrepareWithOptions(dotSource, engine.PrepareOptions{GraphPath: graphPath})
+	if err != nil {
+		for _, d := range diags {
+			fmt.Fprintf(stderr, "%s: %s (%s)\n", d.Severity, d.Message, d.Rule)
+		}
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	var out []byte
+	if format == "dot" {
+		out = engine.RenderDOT(g, engine.RenderOptions{})
+	} else {
+		bin, err := exec.LookPath("dot")
+		if err != nil {
+			fmt.Fprintf(stderr, "--format %s needs the Graphviz dot binary on PATH (install Graphviz, or use --format dot)\n", format)
+			return 1
+		}
+		cmd := exec.Command(bin, "-T"+format)
+		cmd.Stdin = bytes.NewReader(engine.RenderDOT(g, engine.RenderOptions{Annotate: true}))
+		var errBuf bytes.Buffer
+		cmd.Stderr = &errBuf
+		out, err = cmd.Output()
+		if err != nil {
+			fmt.Fprintf(stderr, "dot -T%s: %v\n%s", format, err, errBuf.String())
+			return 1
+		}
+	}
+
+	if outPath == "" {
+		if _, err := stdout.Write(out); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+	if err := os.WriteFile(outPath, out, 0o644); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
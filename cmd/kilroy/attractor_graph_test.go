@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const graphRenderTestDOT = `digraph G {
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  t [shape=parallelogram, tool_command="echo hi"]
+  start -> t
+  t -> exit [condition="outcome=success"]
+}`
+
+func TestRunAttractorGraphRender_DOTFormat(t *testing.T) {
+	p := writeValidateGraph(t, graphRenderTestDOT)
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorGraph([]string{"render", p, "--format", "dot"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d; stderr: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.HasPrefix(out, "digraph G {") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if !strings.Contains(out, `t -> exit [condition="outcome=success"];`) {
+		t.Fatalf("expected un-annotated edge in dot output: %s", out)
+	}
+}
+
+func TestRunAttractorGraphRender_SVGUsesGraphviz(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake dot binary is a shell script")
+	}
+	bin := t.TempDir()
+	// The fake dot echoes its flag and input so the test can inspect both.
+	script := "#!/bin/sh\necho \"args=$*\"\ncat\n"
+	if err := os.WriteFile(filepath.Join(bin, "dot"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	p := writeValidateGraph(t, graphRenderTestDOT)
+	outPath := filepath.Join(t.TempDir(), "g.svg")
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorGraph([]string{"render", p, "--output", outPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d; stderr: %s", code, stderr.String())
+	}
+	b, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	if !strings.HasPrefix(out, "args=-Tsvg\n") {
+		t.Fatalf("expected dot -Tsvg, got: %s", out)
+	}
+	if !strings.Contains(out, `label="if outcome=success"`) {
+		t.Fatalf("expected condition in edge label: %s", out)
+	}
+}
+
+func TestRunAttractorGraphRender_MissingGraphvizIsClearError(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	p := writeValidateGraph(t, graphRenderTestDOT)
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorGraph([]string{"render", p, "--format", "png"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "Graphviz dot binary") {
+		t.Fatalf("stderr: %s", stderr.String())
+	}
+}
+
+func TestRunAttractorGraphRender_RejectsUnknownFormat(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorGraph([]string{"render", "g.dot", "--format", "pdf"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), `invalid --format "pdf"`) {
+		t.Fatalf("stderr: %s", stderr.String())
+	}
+}
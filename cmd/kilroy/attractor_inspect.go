@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+// inspectReport is `attractor inspect --json` output: the checkpoint state
+// resume would restore, plus the recorded outcome of one node. Context is
+// the checkpoint's context, i.e. as of CurrentNode; earlier nodes' contexts
+// are not kept.
+type inspectReport struct {
+	CheckpointPath string         `json:"checkpoint_path"`
+	Timestamp      time.Time      `json:"timestamp"`
+	CurrentNode    string         `json:"current_node"`
+	GitCommitSHA   string         `json:"git_commit_sha,omitempty"`
+	CompletedNodes []string       `json:"completed_nodes"`
+	NodeRetries    map[string]int `json:"node_retries"`
+	Context        map[string]any `json:"context"`
+
+	Node    string           `json:"node"`
+	Retries int              `json:"retries"`
+	Outcome *runtime.Outcome `json:"outcome,omitempty"`
+}
+
+func attractorInspect(args []string) {
+	os.Exit(runAttractorInspect(args, os.Stdout, os.Stderr))
+}
+
+func runAttractorInspect(args []string, stdout io.Writer, stderr io.Writer) int {
+	var logsRoot string
+	var nodeID string
+	var asJSON bool
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--logs-root":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(stderr, "--logs-root requires a value")
+				return 1
+			}
+			logsRoot = args[i]
+		case "--node":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(stderr, "--node requires a value")
+				return 1
+			}
+			nodeID = strings.TrimSpace(args[i])
+		case "--json":
+			asJSON = true
+		default:
+			fmt.Fprintf(stderr, "unknown arg: %s\n", args[i])
+			return 1
+		}
+	}
+	if logsRoot == "" {
+		fmt.Fprintln(stderr, "--logs-root is required")
+		return 1
+	}
+
+	report, err := loadInspectReport(logsRoot, nodeID)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+	printInspectReport(report, stdout)
+	return 0
+}
+
+// loadInspectReport reads checkpoint.json and the status.json of nodeID, or
+// of the checkpoint's current node when nodeID is empty. A node that never
+// completed is an error.
+func loadInspectReport(logsRoot, nodeID string) (*inspectReport, error) {
+	cpPath := filepath.Join(logsRoot, "checkpoint.json")
+	cp, err := runtime.LoadCheckpoint(cpPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no checkpoint in %s (the run has not completed a node)", logsRoot)
+		}
+		return nil, fmt.Errorf("read %s: %w", cpPath, err)
+	}
+	if nodeID == "" {
+		nodeID = cp.CurrentNode
+	}
+	if !slices.Contains(cp.CompletedNodes, nodeID) {
+		return nil, fmt.Errorf("node %q has not completed (completed: %s)", nodeID, strings.Join(cp.CompletedNodes, ", "))
+	}
+
+	report := &inspectReport{
+		CheckpointPath: cpPath,
+		Timestamp:      cp.Timestamp,
+		CurrentNode:    cp.CurrentNode,
+		GitCommitSHA:   cp.GitCommitSHA,
+		CompletedNodes: cp.CompletedNodes,
+		NodeRetries:    cp.NodeRetries,
+		Context:        cp.ContextValues,
+		Node:           nodeID,
+		Retries:        cp.NodeRetries[nodeID],
+	}
+	statusPath := filepath.Join(logsRoot, nodeID, "status.json")
+	b, err := os.ReadFile(statusPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		out, err := runtime.DecodeOutcomeJSON(b)
+		if err != nil {
+			return nil, fmt.Errorf("decode %s: %w", statusPath, err)
+		}
+		report.Outcome = &out
+	}
+	return report, nil
+}
+
+func printInspectReport(r *inspectReport, w io.Writer) {
+	fmt.Fprintf(w, "checkpoint=%s\n", r.CheckpointPath)
+	if !r.Timestamp.IsZero() {
+		fmt.Fprintf(w, "timestamp=%s\n", r.Timestamp.UTC().Format(time.RFC3339Nano))
+	}
+	fmt.Fprintf(w, "current_node=%s\n", r.CurrentNode)
+	fmt.Fprintf(w, "git_commit_sha=%s\n", r.GitCommitSHA)
+	fmt.Fprintf(w, "completed_nodes=%s\n", strings.Join(r.CompletedNodes, ","))
+	fmt.Fprintf(w, "node=%s\n", r.Node)
+	fmt.Fprintf(w, "retries=%d\n", r.Retries)
+	if o := r.Outcome; o != nil {
+		fmt.Fprintf(w, "outcome=%s\n", o.Status)
+		if o.PreferredLabel != "" {
+			fmt.Fprintf(w, "preferred_label=%s\n", o.PreferredLabel)
+		}
+		if o.FailureReason != "" {
+			fmt.Fprintf(w, "failure_reason=%s\n", o.FailureReason)
+		}
+		if o.Notes != "" {
+			fmt.Fprintf(w, "notes=%s\n", o.Notes)
+		}
+		printInspectValues(w, "context_update.", o.ContextUpdates)
+	}
+	if r.Node != r.CurrentNode {
+		fmt.Fprintf(w, "# context below is as of %s, not %s\n", r.CurrentNode, r.Node)
+	}
+	printInspectValues(w, "context.", r.Context)
+}
+
+// printInspectValues prints one prefix+key=value line per entry in key order,
+// with values JSON-encoded so strings, numbers, and nested values are
+// unambiguous.
+func printInspectValues(w io.Writer, prefix string, values map[string]any) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, err := json.Marshal(values[k])
+		if err != nil {
+			v = []byte(fmt.Sprint(values[k]))
+		}
+		fmt.Fprintf(w, "%s%s=%s\n", prefix, k, v)
+	}
+}
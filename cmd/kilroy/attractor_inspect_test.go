@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeInspectFixture(t *testing.T) string {
+	t.Helper()
+	logs := t.TempDir()
+	_ = os.WriteFile(filepath.Join(logs, "checkpoint.json"), []byte(`{
+  "timestamp": "2026-02-10T04:00:00Z",
+  "current_node": "verify",
+  "completed_nodes": ["start", "build", "verify"],
+  "node_retries": {"build": 2},
+  "context": {"outcome": "fail", "graph.goal": "ship it", "attempts": 3},
+  "git_commit_sha": "abc123"
+}`), 0o644)
+	for node, status := range map[string]string{
+		"build":  `{"status":"success","context_updates":{"built":true}}`,
+		"verify": `{"status":"fail","failure_reason":"tests failed"}`,
+	} {
+		_ = os.MkdirAll(filepath.Join(logs, node), 0o755)
+		_ = os.WriteFile(filepath.Join(logs, node, "status.json"), []byte(status), 0o644)
+	}
+	return logs
+}
+
+func TestRunAttractorInspect_DefaultsToCurrentNode(t *testing.T) {
+	logs := writeInspectFixture(t)
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorInspect([]string{"--logs-root", logs}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d; stderr: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{
+		"node=verify\n",
+		"git_commit_sha=abc123\n",
+		"completed_nodes=start,build,verify\n",
+		"outcome=fail\n",
+		"failure_reason=tests failed\n",
+		"context.attempts=3\n",
+		`context.graph.goal="ship it"` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "context.attempts") > strings.Index(out, "context.outcome") {
+		t.Fatalf("context keys not sorted:\n%s", out)
+	}
+}
+
+func TestRunAttractorInspect_NodeJSON(t *testing.T) {
+	logs := writeInspectFixture(t)
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorInspect([]string{"--logs-root", logs, "--node", "build", "--json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d; stderr: %s", code, stderr.String())
+	}
+	var got inspectReport
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v\n%s", err, stdout.String())
+	}
+	if got.Node != "build" || got.Retries != 2 || got.CurrentNode != "verify" {
+		t.Fatalf("report=%+v", got)
+	}
+	if got.Outcome == nil || got.Outcome.Status != "success" || got.Outcome.ContextUpdates["built"] != true {
+		t.Fatalf("outcome=%+v", got.Outcome)
+	}
+	if got.Context["graph.goal"] != "ship it" {
+		t.Fatalf("context=%v", got.Context)
+	}
+}
+
+func TestRunAttractorInspect_RejectsUnknownNodeAndMissingCheckpoint(t *testing.T) {
+	logs := writeInspectFixture(t)
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorInspect([]string{"--logs-root", logs, "--node", "deploy"}, &stdout, &stderr); code == 0 || !strings.Contains(stderr.String(), `"deploy" has not completed`) {
+		t.Fatalf("code=%d stderr=%s", code, stderr.String())
+	}
+	stderr.Reset()
+	if code := runAttractorInspect([]string{"--logs-root", t.TempDir()}, &stdout, &stderr); code == 0 || !strings.Contains(stderr.String(), "no checkpoint") {
+		t.Fatalf("code=%d stderr=%s", code, stderr.String())
+	}
+}
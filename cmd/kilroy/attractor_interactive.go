@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/danshapiro/kilroy/internal/attractor/engine"
+)
+
+// interactiveInterviewer returns the interviewer for `attractor run
+// --interactive`, or nil (auto-approve) when interactive mode was not requested
+// or cannot work: detached runs and runs whose stdin is not a terminal have no
+// one to answer, so the flag is dropped with a note on stderr.
+func interactiveInterviewer(interactive, detach bool, stdin, stdout *os.File, stderr io.Writer) engine.Interviewer {
+	if !interactive {
+		return nil
+	}
+	if detach {
+		fmt.Fprintln(stderr, "--interactive is disabled with --detach (no controlling terminal); human gates will auto-approve")
+		return nil
+	}
+	if !stdinIsTerminal(stdin) {
+		fmt.Fprintln(stderr, "--interactive is disabled: stdin is not a terminal; human gates will auto-approve")
+		return nil
+	}
+	return &engine.StdinInterviewer{In: stdin, Out: stdout}
+}
+
+func stdinIsTerminal(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
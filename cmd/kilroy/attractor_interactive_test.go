@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestInteractiveInterviewer_DisabledWithoutTerminal(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var stderr bytes.Buffer
+	if got := interactiveInterviewer(false, false, f, os.Stdout, &stderr); got != nil || stderr.Len() != 0 {
+		t.Fatalf("not requested: got %v, stderr=%q", got, stderr.String())
+	}
+	if got := interactiveInterviewer(true, true, f, os.Stdout, &stderr); got != nil || !strings.Contains(stderr.String(), "--detach") {
+		t.Fatalf("detach: got %v, stderr=%q", got, stderr.String())
+	}
+	stderr.Reset()
+	if got := interactiveInterviewer(true, false, f, os.Stdout, &stderr); got != nil || !strings.Contains(stderr.String(), "not a terminal") {
+		t.Fatalf("non-terminal stdin: got %v, stderr=%q", got, stderr.String())
+	}
+}
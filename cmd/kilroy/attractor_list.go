@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runstate"
+)
+
+// runMarkerFiles identify a directory as a run logs root; anything else under
+// the scanned root is skipped.
+var runMarkerFiles = []string{"final.json", "live.json", "progress.ndjson", "run.pid", "manifest.json", "checkpoint.json"}
+
+func attractorList(args []string) {
+	os.Exit(runAttractorList(args, os.Stdout, os.Stderr))
+}
+
+func runAttractorList(args []string, stdout io.Writer, stderr io.Writer) int {
+	var logsRoot string
+	var asJSON bool
+	var stateFilter string
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--logs-root":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(stderr, "--logs-root requires a value")
+				return 1
+			}
+			logsRoot = args[i]
+		case "--json":
+			asJSON = true
+		case "--state":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(stderr, "--state requires a value")
+				return 1
+			}
+			stateFilter = strings.ToLower(strings.TrimSpace(args[i]))
+			switch runstate.State(stateFilter) {
+			case runstate.StateRunning, runstate.StateSuccess, runstate.StateFail, runstate.StateCanceled, runstate.StateOrphaned, runstate.StateUnknown:
+			default:
+				fmt.Fprintf(stderr, "invalid --state %q (want running|success|fail|canceled|orphaned|unknown)\n", args[i])
+				return 1
+			}
+		default:
+			fmt.Fprintf(stderr, "unknown arg: %s\n", args[i])
+			return 1
+		}
+	}
+	if logsRoot == "" {
+		fmt.Fprintln(stderr, "--logs-root is required")
+		return 1
+	}
+
+	snapshots, err := listRunSnapshots(logsRoot, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if stateFilter != "" {
+		kept := snapshots[:0]
+		for _, s := range snapshots {
+			if s.State.String() == stateFilter {
+				kept = append(kept, s)
+			}
+		}
+		snapshots = kept
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(snapshots); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN_ID\tSTATE\tNODE\tLAST_EVENT_AT\tPID_ALIVE\tGRAPH")
+	for _, s := range snapshots {
+		lastAt := "-"
+		if !s.LastEventAt.IsZero() {
+			lastAt = s.LastEventAt.UTC().Format(time.RFC3339)
+		}
+		node := s.CurrentNodeID
+		if node == "" {
+			node = "-"
+		}
+		graph := s.GraphName
+		if graph == "" {
+			graph = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%t\t%s\n", s.RunID, s.State, node, lastAt, s.PIDAlive, graph)
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// listRunSnapshots loads a snapshot for every run directory directly under
+// root, most recent activity first. Directories without run artifacts are
+// skipped; runs whose artifacts cannot be read are reported on stderr and
+// skipped so one damaged run does not hide the rest.
+func listRunSnapshots(root string, stderr io.Writer) ([]*runstate.Snapshot, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := []*runstate.Snapshot{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		if !isRunLogsRoot(dir) {
+			continue
+		}
+		s, err := runstate.LoadSnapshot(dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "skipping %s: %v\n", dir, err)
+			continue
+		}
+		if s.RunID == "" {
+			s.RunID = e.Name()
+		}
+		snapshots = append(snapshots, s)
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		a, b := snapshots[i], snapshots[j]
+		if !a.LastEventAt.Equal(b.LastEventAt) {
+			return a.LastEventAt.After(b.LastEventAt)
+		}
+		return a.RunID < b.RunID
+	})
+	return snapshots, nil
+}
+
+func isRunLogsRoot(dir string) bool {
+	for _, name := range runMarkerFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runstate"
+)
+
+func writeListFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	write := func(run, name, content string) {
+		dir := filepath.Join(root, run)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("done", "final.json", `{"status":"success","run_id":"run-done"}`)
+	write("active", "live.json", `{"event":"stage_attempt_start","node_id":"impl","run_id":"run-active","ts":"2026-01-02T03:04:05Z"}`)
+	write("active", "run.pid", strconv.Itoa(os.Getpid()))
+	write("active", "manifest.json", `{"run_id":"run-active","graph_name":"pipeline"}`)
+	write("broken", "final.json", `{not json`)
+	write("not-a-run", "notes.txt", "hello")
+	return root
+}
+
+func TestAttractorList_PrintsTableAndSkipsNonRuns(t *testing.T) {
+	root := writeListFixture(t)
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorList([]string{"--logs-root", root}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d; stderr: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "RUN_ID") {
+		t.Fatalf("unexpected table:\n%s", out)
+	}
+	if f := strings.Fields(lines[1]); strings.Join(f, " ") != "run-active running impl 2026-01-02T03:04:05Z true pipeline" {
+		t.Fatalf("active row: %q", lines[1])
+	}
+	if f := strings.Fields(lines[2]); f[0] != "run-done" || f[1] != "success" {
+		t.Fatalf("done row: %q", lines[2])
+	}
+	if strings.Contains(out, "not-a-run") || !strings.Contains(stderr.String(), "broken") {
+		t.Fatalf("stdout:\n%s\nstderr:\n%s", out, stderr.String())
+	}
+}
+
+func TestAttractorList_JSONWithStateFilter(t *testing.T) {
+	root := writeListFixture(t)
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorList([]string{"--logs-root", root, "--state", "running", "--json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d; stderr: %s", code, stderr.String())
+	}
+	var got []runstate.Snapshot
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v\n%s", err, stdout.String())
+	}
+	if len(got) != 1 || got[0].RunID != "run-active" || !got[0].PIDAlive {
+		t.Fatalf("snapshots: %+v", got)
+	}
+
+	if code := runAttractorList([]string{"--logs-root", root, "--state", "paused"}, &stdout, &stderr); code == 0 {
+		t.Fatal("expected invalid --state to fail")
+	}
+}
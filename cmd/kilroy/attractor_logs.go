@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+func attractorLogs(args []string) {
+	os.Exit(runAttractorLogs(args, os.Stdout, os.Stderr))
+}
+
+func runAttractorLogs(args []string, stdout io.Writer, stderr io.Writer) int {
+	var logsRoot string
+	var follow bool
+	var asJSON bool
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--logs-root":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(stderr, "--logs-root requires a value")
+				return 1
+			}
+			logsRoot = args[i]
+		case "--follow", "-f":
+			follow = true
+		case "--json":
+			asJSON = true
+		default:
+			fmt.Fprintf(stderr, "unknown arg: %s\n", args[i])
+			return 1
+		}
+	}
+	if logsRoot == "" {
+		fmt.Fprintln(stderr, "--logs-root is required")
+		return 1
+	}
+	if st, err := os.Stat(logsRoot); err != nil || !st.IsDir() {
+		fmt.Fprintf(stderr, "logs root not found: %s\n", logsRoot)
+		return 1
+	}
+
+	emit := func(line string) {
+		if asJSON {
+			fmt.Fprintln(stdout, line)
+			return
+		}
+		fmt.Fprintln(stdout, formatLogLine(line))
+	}
+	offset, err := emitProgressLog(logsRoot, emit)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if !follow {
+		return 0
+	}
+	// Same end condition and exit code as `attractor status --follow`; the
+	// dead-process note goes to stderr to keep --json output parseable.
+	return followProgress(logsRoot, offset, emit, stderr)
+}
+
+// formatLogLine renders one progress event as "ts node event", followed by
+// the status and the failure reason or message when the event has them.
+// Lines that are not JSON objects are returned unchanged.
+func formatLogLine(line string) string {
+	var ev map[string]any
+	if err := json.Unmarshal([]byte(line), &ev); err != nil {
+		return line
+	}
+	ts := evStr(ev, "ts")
+	if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+		ts = t.UTC().Format(time.RFC3339)
+	}
+	if ts == "" {
+		ts = "-"
+	}
+	node := evStr(ev, "node_id")
+	if node == "" {
+		node = "-"
+	}
+	out := fmt.Sprintf("%s %s %s", ts, node, evStr(ev, "event"))
+	if status := evStr(ev, "status"); status != "" {
+		out += " status=" + status
+	}
+	if reason := evStr(ev, "failure_reason"); reason != "" {
+		out += " " + reason
+	} else if msg := evStr(ev, "message"); msg != "" {
+		out += " " + msg
+	}
+	return out
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer guards a bytes.Buffer read by the test while the follow loop
+// writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRunAttractorLogs_PrintsFormattedEvents(t *testing.T) {
+	logs := t.TempDir()
+	lines := strings.Join([]string{
+		`{"ts":"2026-02-10T04:00:25.123Z","event":"stage_attempt_start","node_id":"build","attempt":1,"max":3}`,
+		`{"ts":"2026-02-10T04:00:40Z","event":"stage_attempt_end","node_id":"build","status":"fail","failure_reason":"exit status 2"}`,
+		`{"ts":"2026-02-10T04:00:41Z","event":"warning","message":"disk low"}`,
+	}, "\n") + "\n"
+	_ = os.WriteFile(filepath.Join(logs, "progress.ndjson"), []byte(lines), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorLogs([]string{"--logs-root", logs}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d; stderr: %s", code, stderr.String())
+	}
+	want := "2026-02-10T04:00:25Z build stage_attempt_start\n" +
+		"2026-02-10T04:00:40Z build stage_attempt_end status=fail exit status 2\n" +
+		"2026-02-10T04:00:41Z - warning disk low\n"
+	if stdout.String() != want {
+		t.Fatalf("output mismatch\n got: %q\nwant: %q", stdout.String(), want)
+	}
+}
+
+func TestRunAttractorLogs_JSONPrintsRawLines(t *testing.T) {
+	logs := t.TempDir()
+	line := `{"ts":"2026-02-10T04:00:25Z","event":"warning","message":"test warning"}`
+	_ = os.WriteFile(filepath.Join(logs, "progress.ndjson"), []byte(line+"\n"), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorLogs([]string{"--logs-root", logs, "--json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d; stderr: %s", code, stderr.String())
+	}
+	if stdout.String() != line+"\n" {
+		t.Fatalf("expected raw line, got %q", stdout.String())
+	}
+}
+
+func TestRunAttractorLogs_IncludesRotatedFilesOldestFirst(t *testing.T) {
+	logs := t.TempDir()
+	path := filepath.Join(logs, "progress.ndjson")
+	_ = os.WriteFile(path+".2", []byte(`{"event":"one"}`+"\n"), 0o644)
+	_ = os.WriteFile(path+".1", []byte(`{"event":"two"}`+"\n"), 0o644)
+	_ = os.WriteFile(path, []byte(`{"event":"three"}`+"\n"), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorLogs([]string{"--logs-root", logs, "--json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d; stderr: %s", code, stderr.String())
+	}
+	want := `{"event":"one"}` + "\n" + `{"event":"two"}` + "\n" + `{"event":"three"}` + "\n"
+	if stdout.String() != want {
+		t.Fatalf("output mismatch\n got: %q\nwant: %q", stdout.String(), want)
+	}
+}
+
+func TestEmitProgressLines_DrainsRolledFileAfterRotation(t *testing.T) {
+	logs := t.TempDir()
+	path := filepath.Join(logs, "progress.ndjson")
+	first := `{"event":"a","message":"long enough to outgrow the fresh file"}` + "\n"
+	_ = os.WriteFile(path, []byte(first), 0o644)
+
+	var got []string
+	emit := func(line string) { got = append(got, line) }
+	offset, err := emitProgressLines(path, 0, emit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The engine appends one more line, then rotates and starts a new file.
+	_ = os.WriteFile(path+".1", []byte(first+`{"event":"b"}`+"\n"), 0o644)
+	_ = os.WriteFile(path, []byte(`{"event":"c"}`+"\n"), 0o644)
+	if _, err := emitProgressLines(path, offset, emit); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{strings.TrimSpace(first), `{"event":"b"}`, `{"event":"c"}`}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("lines=%v want %v", got, want)
+	}
+}
+
+func TestRunAttractorLogs_FollowStreamsUntilFinal(t *testing.T) {
+	old := followPollInterval
+	followPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { followPollInterval = old })
+
+	logs := t.TempDir()
+	ndjson := filepath.Join(logs, "progress.ndjson")
+	_ = os.WriteFile(ndjson, []byte(`{"ts":"2026-02-10T04:00:25Z","event":"stage_attempt_start","node_id":"a"}`+"\n"), 0o644)
+
+	var stdout, stderr syncBuffer
+	done := make(chan int, 1)
+	go func() {
+		done <- runAttractorLogs([]string{"--logs-root", logs, "--follow"}, &stdout, &stderr)
+	}()
+
+	waitFor := func(substr string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !strings.Contains(stdout.String(), substr) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %q; output: %s", substr, stdout.String())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	waitFor("a stage_attempt_start")
+
+	f, err := os.OpenFile(ndjson, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A partial line must not be printed until its newline arrives.
+	_, _ = f.WriteString(`{"ts":"2026-02-10T04:00:30Z","event":"stage_attempt_end",`)
+	time.Sleep(50 * time.Millisecond)
+	if strings.Contains(stdout.String(), "stage_attempt_end") {
+		t.Fatalf("partial line was printed: %s", stdout.String())
+	}
+	_, _ = f.WriteString(`"node_id":"a","status":"success"}` + "\n")
+	_ = f.Close()
+	waitFor("a stage_attempt_end status=success")
+
+	_ = os.WriteFile(filepath.Join(logs, "final.json"), []byte(`{"status":"success"}`), 0o644)
+	select {
+	case code := <-done:
+		if code != 0 {
+			t.Fatalf("exit code %d; stderr: %s", code, stderr.String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("follow did not exit after final.json appeared")
+	}
+}
+
+func TestRunAttractorLogs_FollowExitsOnDeadPID(t *testing.T) {
+	logs := t.TempDir()
+	_ = os.WriteFile(filepath.Join(logs, "progress.ndjson"), []byte(`{"ts":"2026-02-10T04:00:25Z","event":"run_start"}`+"\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(logs, "run.pid"), []byte("999999999"), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	// Like status --follow, a run whose process died without final.json exits 1.
+	if code := runAttractorLogs([]string{"--logs-root", logs, "-f", "--json"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code %d, want 1; stderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "run_start") {
+		t.Fatalf("expected events before exit: %s", stdout.String())
+	}
+	if strings.Contains(stdout.String(), "no longer alive") || !strings.Contains(stderr.String(), "pid 999999999") {
+		t.Fatalf("dead-process note belongs on stderr; stdout: %s stderr: %s", stdout.String(), stderr.String())
+	}
+}
+
+func TestRunAttractorLogs_RequiresLogsRoot(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorLogs(nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "--logs-root is required") {
+		t.Fatalf("stderr: %s", stderr.String())
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/danshapiro/kilroy/internal/attractor/engine"
+	"github.com/danshapiro/kilroy/internal/attractor/validate"
+)
+
+// runAttractorDryRun prepares the graph the way `attractor run` would and
+// prints the nodes that may execute, their attributes, and edge conditions
+// without starting a run. inputs are the --set values, checked against the
+// graph's requires attribute. It exits non-zero when Prepare reports errors.
+func runAttractorDryRun(graphPath string, inputs map[string]any, stdout io.Writer, stderr io.Writer) int {
+	dotSource, err := os.ReadFile(graphPath)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if inputs == nil {
+		inputs = map[string]any{}
+	}
+	g, diags, err := engine.PrepareWithOptions(dotSource, engine.PrepareOptions{GraphPath: graphPath, Inputs: inputs})
+	if err != nil {
+		for _, d := range diags {
+			fmt.Fprintf(stderr, "%s: %s (%s)\n", d.Severity, d.Message, d.Rule)
+		}
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	plan := engine.BuildExecutionPlan(g)
+	fmt.Fprintf(stdout, "dry_run=true\ngraph=%s\nstart=%s\n", graphPath, plan.StartNodeID)
+	for _, n := range plan.Nodes {
+		fmt.Fprintf(stdout, "node=%s type=%s", n.ID, n.Type)
+		if cmd := n.Attrs["tool_command"]; cmd != "" {
+			fmt.Fprintf(stdout, " tool_command=%q", cmd)
+		}
+		fmt.Fprintln(stdout)
+		keys := make([]string, 0, len(n.Attrs))
+		for k := range n.Attrs {
+			if k != "tool_command" {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(stdout, "  %s=%q\n", k, n.Attrs[k])
+		}
+	}
+	for _, e := range plan.Edges {
+		fmt.Fprintf(stdout, "edge=%s->%s", e.From, e.To)
+		if e.Condition != "" {
+			fmt.Fprintf(stdout, " condition=%q", e.Condition)
+		}
+		if e.Label != "" {
+			fmt.Fprintf(stdout, " label=%q", e.Label)
+		}
+		fmt.Fprintln(stdout)
+	}
+	for _, id := range plan.Unreachable {
+		fmt.Fprintf(stdout, "unreachable=%s\n", id)
+	}
+	code := 0
+	for _, d := range diags {
+		fmt.Fprintf(stdout, "%s: %s (%s)\n", d.Severity, d.Message, d.Rule)
+		if d.Severity == validate.SeverityError {
+			code = 1
+		}
+	}
+	return code
+}
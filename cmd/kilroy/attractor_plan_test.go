@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAttractorDryRun_PrintsPlanWithoutRunning(t *testing.T) {
+	dir := t.TempDir()
+	graph := filepath.Join(dir, "g.dot")
+	_ = os.WriteFile(graph, []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  build [shape=parallelogram, tool_command="touch ran.txt", max_retries=2]
+  fix [shape=parallelogram, tool_command="true"]
+  start -> build
+  build -> exit [condition="outcome=success"]
+  build -> fix [condition="outcome=fail", label="retry"]
+  fix -> build
+}
+`), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorDryRun(graph, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d; stderr: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{
+		"dry_run=true\n",
+		"start=start\n",
+		`node=build type=tool tool_command="touch ran.txt"`,
+		`  max_retries="2"`,
+		`edge=build->fix condition="outcome=fail" label="retry"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "node=start ") > strings.Index(out, "node=build ") || strings.Index(out, "node=build ") > strings.Index(out, "node=exit ") {
+		t.Fatalf("nodes not in reachability order:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ran.txt")); err == nil {
+		t.Fatalf("dry run executed a tool command")
+	}
+}
+
+func TestAttractorDryRun_ErrorDiagnosticsExitNonZero(t *testing.T) {
+	graph := filepath.Join(t.TempDir(), "g.dot")
+	_ = os.WriteFile(graph, []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  a [shape=parallelogram, tool_command="true"]
+  start -> a
+}
+`), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorDryRun(graph, nil, &stdout, &stderr); code == 0 {
+		t.Fatalf("expected non-zero exit for graph without exit node; stdout: %s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "(terminal_node)") {
+		t.Fatalf("expected terminal_node diagnostic on stderr, got: %s", stderr.String())
+	}
+}
+
+func TestAttractorDryRun_ReportsMissingRequiredInputs(t *testing.T) {
+	graph := filepath.Join(t.TempDir(), "g.dot")
+	_ = os.WriteFile(graph, []byte(`
+digraph G {
+  graph [requires="branch,ticket"]
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  push [shape=parallelogram, tool_command="echo {{branch}} {{ticket}}"]
+  start -> push -> exit
+}
+`), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorDryRun(graph, map[string]any{"branch": "main"}, &stdout, &stderr); code == 0 {
+		t.Fatalf("expected non-zero exit with ticket unset; stdout: %s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), `"ticket"`) || strings.Contains(stderr.String(), `"branch"`) {
+		t.Fatalf("expected only ticket reported missing, got: %s", stderr.String())
+	}
+
+	stderr.Reset()
+	if code := runAttractorDryRun(graph, map[string]any{"branch": "main", "ticket": "KIL-1"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d with all inputs set; stderr: %s", code, stderr.String())
+	}
+}
+
+func TestParseSetFlags(t *testing.T) {
+	got, specs, err := parseSetFlags([]string{"ticket=KIL-1", " branch =feature/a=b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["branch"] != "feature/a=b" || got["ticket"] != "KIL-1" {
+		t.Fatalf("inputs: %v", got)
+	}
+	if strings.Join(specs, " ") != "branch=feature/a=b ticket=KIL-1" {
+		t.Fatalf("canonical specs: %v", specs)
+	}
+	for _, bad := range [][]string{{"novalue"}, {"=x"}, {"a=1", "a=2"}} {
+		if _, _, err := parseSetFlags(bad); err == nil {
+			t.Fatalf("expected error for %v", bad)
+		}
+	}
+}
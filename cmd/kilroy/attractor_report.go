@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danshapiro/kilroy/internal/attractor/engine"
+	"github.com/danshapiro/kilroy/internal/attractor/runstate"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+type stageReport struct {
+	NodeID        string `json:"node_id"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+type runReport struct {
+	*runstate.Snapshot
+	Stages []stageReport `json:"stages"`
+}
+
+func attractorReport(args []string) {
+	os.Exit(runAttractorReport(args, os.Stdout, os.Stderr))
+}
+
+func runAttractorReport(args []string, stdout io.Writer, stderr io.Writer) int {
+	var logsRoot string
+	var archivePath string
+	var asJSON bool
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--logs-root":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(stderr, "--logs-root requires a value")
+				return 1
+			}
+			logsRoot = args[i]
+		case "--archive":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(stderr, "--archive requires a value")
+				return 1
+			}
+			archivePath = args[i]
+		case "--json":
+			asJSON = true
+		default:
+			fmt.Fprintf(stderr, "unknown arg: %s\n", args[i])
+			return 1
+		}
+	}
+	if (logsRoot == "") == (archivePath == "") {
+		fmt.Fprintln(stderr, "exactly one of --logs-root or --archive is required")
+		return 1
+	}
+
+	root := logsRoot
+	if archivePath != "" {
+		tmp, err := os.MkdirTemp("", "kilroy-report-*")
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer func() { _ = os.RemoveAll(tmp) }()
+		if err := engine.ExtractLogsArchive(archivePath, tmp); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		root = tmp
+	}
+
+	report, err := loadRunReport(root)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if archivePath != "" {
+		report.LogsRoot = archivePath
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(stdout, "state=%s\n", report.State)
+	fmt.Fprintf(stdout, "run_id=%s\n", report.RunID)
+	fmt.Fprintf(stdout, "logs_root=%s\n", report.LogsRoot)
+	if report.FailureReason != "" {
+		fmt.Fprintf(stdout, "failure_reason=%s\n", report.FailureReason)
+	}
+	for _, s := range report.Stages {
+		fmt.Fprintf(stdout, "stage=%s status=%s\n", s.NodeID, s.Status)
+	}
+	return 0
+}
+
+// loadRunReport combines the run snapshot with the outcome of every stage that
+// wrote a status.json, ordered by node id.
+func loadRunReport(logsRoot string) (*runReport, error) {
+	snapshot, err := loadSnapshot(logsRoot)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(logsRoot)
+	if err != nil {
+		return nil, err
+	}
+	report := &runReport{Snapshot: snapshot, Stages: []stageReport{}}
+	for _, ent := range entries {
+		if !ent.IsDir() {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(logsRoot, ent.Name(), "status.json"))
+		if err != nil {
+			continue
+		}
+		out, err := runtime.DecodeOutcomeJSON(b)
+		if err != nil {
+			return nil, fmt.Errorf("decode %s/status.json: %w", ent.Name(), err)
+		}
+		report.Stages = append(report.Stages, stageReport{
+			NodeID:        ent.Name(),
+			Status:        string(out.Status),
+			FailureReason: strings.TrimSpace(out.FailureReason),
+		})
+	}
+	sort.Slice(report.Stages, func(i, j int) bool { return report.Stages[i].NodeID < report.Stages[j].NodeID })
+	return report, nil
+}
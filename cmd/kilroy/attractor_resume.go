@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+// checkResumable refuses `attractor resume --logs-root` for a run that is still
+// live or that already finished successfully at its checkpointed commit.
+// Failed and interrupted runs resume from checkpoint.json.
+func checkResumable(logsRoot string) error {
+	snapshot, err := loadSnapshot(logsRoot)
+	if err != nil {
+		return err
+	}
+	if snapshot.IsRunning() {
+		return fmt.Errorf("run is still running (pid=%d); stop it before resuming", snapshot.PID)
+	}
+	if !snapshot.Succeeded() {
+		return nil
+	}
+	var final runtime.FinalOutcome
+	if b, err := os.ReadFile(filepath.Join(logsRoot, "final.json")); err != nil || json.Unmarshal(b, &final) != nil {
+		return nil
+	}
+	cp, err := runtime.LoadCheckpoint(filepath.Join(logsRoot, "checkpoint.json"))
+	if err != nil {
+		return nil
+	}
+	if sha := strings.TrimSpace(final.FinalGitCommitSHA); sha != "" && sha == strings.TrimSpace(cp.GitCommitSHA) {
+		return fmt.Errorf("run already completed successfully at %s; nothing to resume", sha)
+	}
+	return nil
+}
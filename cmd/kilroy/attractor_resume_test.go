@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func writeResumeFixture(t *testing.T, finalStatus runtime.FinalStatus, finalSHA, checkpointSHA string) string {
+	t.Helper()
+	logs := t.TempDir()
+	cp := runtime.NewCheckpoint()
+	cp.GitCommitSHA = checkpointSHA
+	if err := cp.Save(filepath.Join(logs, "checkpoint.json")); err != nil {
+		t.Fatal(err)
+	}
+	if finalStatus != "" {
+		final := runtime.FinalOutcome{Status: finalStatus, RunID: "r1", FinalGitCommitSHA: finalSHA}
+		if err := final.Save(filepath.Join(logs, "final.json")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return logs
+}
+
+func TestCheckResumable(t *testing.T) {
+	if err := checkResumable(writeResumeFixture(t, runtime.FinalSuccess, "abc", "abc")); err == nil || !strings.Contains(err.Error(), "already completed") {
+		t.Fatalf("completed run: expected refusal, got %v", err)
+	}
+	if err := checkResumable(writeResumeFixture(t, runtime.FinalSuccess, "def", "abc")); err != nil {
+		t.Fatalf("rewound checkpoint should resume: %v", err)
+	}
+	if err := checkResumable(writeResumeFixture(t, runtime.FinalFail, "abc", "abc")); err != nil {
+		t.Fatalf("failed run should resume: %v", err)
+	}
+
+	live := writeResumeFixture(t, "", "", "abc")
+	if err := os.WriteFile(filepath.Join(live, "run.pid"), []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkResumable(live); err == nil || !strings.Contains(err.Error(), "still running") {
+		t.Fatalf("live run: expected refusal, got %v", err)
+	}
+}
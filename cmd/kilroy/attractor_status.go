@@ -5,6 +5,8 @@ import (
 	"io"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/danshapiro/kilroy/internal/attractor/runstate"
 )
@@ -26,7 +28,7 @@ func runAttractorStatus(args []string, stdout io.Writer, stderr io.Writer) int {
 	var watch bool
 	var latest bool
 	var useCXDB bool
-	intervalSec := 2
+	interval := 2 * time.Second
 
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
@@ -55,12 +57,12 @@ func runAttractorStatus(args []string, stdout io.Writer, stderr io.Writer) int {
 				fmt.Fprintln(stderr, "--interval requires a value")
 				return 1
 			}
-			n, err := strconv.Atoi(args[i])
-			if err != nil || n <= 0 {
-				fmt.Fprintln(stderr, "--interval must be a positive integer")
+			d, err := parseWatchInterval(args[i])
+			if err != nil {
+				fmt.Fprintln(stderr, err)
 				return 1
 			}
-			intervalSec = n
+			interval = d
 		default:
 			fmt.Fprintf(stderr, "unknown arg: %s\n", args[i])
 			return 1
@@ -105,9 +107,26 @@ func runAttractorStatus(args []string, stdout io.Writer, stderr io.Writer) int {
 	}
 
 	if watch {
-		return runWatchStatus(logsRoot, stdout, stderr, asJSON, intervalSec)
+		return runWatchStatus(logsRoot, stdout, stderr, asJSON, interval)
 	}
 
 	// Default: one-shot snapshot.
 	return printSnapshot(logsRoot, stdout, stderr, asJSON)
 }
+
+// parseWatchInterval accepts a Go duration ("500ms", "1s") or a bare number of
+// seconds.
+func parseWatchInterval(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	if n, err := strconv.Atoi(raw); err == nil {
+		if n <= 0 {
+			return 0, fmt.Errorf("--interval must be positive")
+		}
+		return time.Duration(n) * time.Second, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("--interval must be a positive duration (for example 1s or 500ms) or number of seconds")
+	}
+	return d, nil
+}
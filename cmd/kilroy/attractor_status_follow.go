@@ -1,7 +1,7 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,107 +13,122 @@ import (
 	"time"
 
 	"github.com/danshapiro/kilroy/internal/attractor/procutil"
+	"github.com/danshapiro/kilroy/internal/attractor/runstate"
 )
 
+// followPollInterval is how often a follower checks progress.ndjson for new
+// lines.
+var followPollInterval = 250 * time.Millisecond
+
 // runFollowProgress tails progress.ndjson with formatted output until the run
 // completes (final.json appears) or the process dies (PID no longer alive).
 // When raw is true, events are printed as-is (NDJSON passthrough).
 func runFollowProgress(logsRoot string, w io.Writer, raw bool) int {
-	ndjsonPath := filepath.Join(logsRoot, "progress.ndjson")
-	finalPath := filepath.Join(logsRoot, "final.json")
-	pidPath := filepath.Join(logsRoot, "run.pid")
-
-	// Check if already finished.
-	if isTerminal(finalPath) {
-		// Catch up on all events, then exit.
-		printAllEvents(ndjsonPath, w, raw)
-		printFinalSummary(finalPath, w)
-		return 0
+	emit := func(line string) { printEvent(w, line, raw) }
+	offset, err := emitProgressLog(logsRoot, emit)
+	if err != nil {
+		fmt.Fprintln(w, err)
+		return 1
 	}
+	if code := followProgress(logsRoot, offset, emit, w); code != 0 {
+		return code
+	}
+	printFinalSummary(filepath.Join(logsRoot, "final.json"), w)
+	return 0
+}
 
-	// Open ndjson for tailing. File may not exist yet if the run just started.
-	var offset int64
-	offset, _ = printAllEvents(ndjsonPath, w, raw)
-
-	ticker := time.NewTicker(250 * time.Millisecond)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		// Read new events.
-		offset = tailEvents(ndjsonPath, offset, w, raw)
-
-		// Check for terminal state.
-		if isTerminal(finalPath) {
-			// Drain any remaining events.
-			tailEvents(ndjsonPath, offset, w, raw)
-			printFinalSummary(finalPath, w)
-			return 0
+// emitProgressLog calls emit for every line of the run's progress log, oldest
+// roll (progress.ndjson.N ... .1) first, and returns the offset just past the
+// last complete line of progress.ndjson.
+func emitProgressLog(logsRoot string, emit func(string)) (int64, error) {
+	paths := runstate.ProgressLogPaths(logsRoot)
+	for _, p := range paths[:len(paths)-1] {
+		if _, err := emitProgressLines(p, 0, emit); err != nil {
+			return 0, err
 		}
+	}
+	return emitProgressLines(filepath.Join(logsRoot, "progress.ndjson"), 0, emit)
+}
 
-		// Check if PID is still alive.
-		if pid := readPID(pidPath); pid > 0 && !procutil.PIDAlive(pid) {
-			// Drain any remaining events.
-			tailEvents(ndjsonPath, offset, w, raw)
-			fmt.Fprintf(w, "\nrun process (pid %d) is no longer alive\n", pid)
+// followProgress calls emit for the lines appended to progress.ndjson after
+// offset until the run completes (final.json appears) or its process dies.
+// The lines written before either are still emitted. It returns 0 for a
+// completed run and 1, after saying so on msgs, when the process died first
+// or the log could not be read.
+func followProgress(logsRoot string, offset int64, emit func(string), msgs io.Writer) int {
+	ndjsonPath := filepath.Join(logsRoot, "progress.ndjson")
+	finalPath := filepath.Join(logsRoot, "final.json")
+	pidPath := filepath.Join(logsRoot, "run.pid")
+	for {
+		// Check for the end condition before reading so the last lines written
+		// before final.json (or before the process exited) are still drained.
+		done := isTerminal(finalPath)
+		deadPID := 0
+		if !done {
+			if pid := readPID(pidPath); pid > 0 && !procutil.PIDAlive(pid) {
+				deadPID = pid
+			}
+		}
+		var err error
+		offset, err = emitProgressLines(ndjsonPath, offset, emit)
+		if err != nil {
+			fmt.Fprintln(msgs, err)
 			return 1
 		}
+		if deadPID > 0 {
+			fmt.Fprintf(msgs, "\nrun process (pid %d) is no longer alive\n", deadPID)
+			return 1
+		}
+		if done {
+			return 0
+		}
+		time.Sleep(followPollInterval)
 	}
-	return 0
 }
 
-// printAllEvents reads all existing events from ndjson and prints them.
-// Returns the file offset after reading and any error.
-func printAllEvents(ndjsonPath string, w io.Writer, raw bool) (int64, error) {
-	f, err := os.Open(ndjsonPath)
+// emitProgressLines calls emit for each complete, non-empty line in path
+// after offset and returns the offset just past the last complete line. A
+// trailing line without a newline is still being written and is left for the
+// next call. A missing file is treated as empty.
+func emitProgressLines(path string, offset int64, emit func(string)) (int64, error) {
+	f, err := os.Open(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return 0, nil
+			return offset, nil
 		}
-		return 0, err
+		return offset, err
 	}
 	defer f.Close()
 
-	scanner := bufio.NewScanner(f)
-	scanner.Buffer(make([]byte, 0, 64*1024), 2*1024*1024)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.TrimSpace(line) == "" {
-			continue
+	if st, err := f.Stat(); err == nil && st.Size() < offset {
+		// The file was rotated, replaced or truncated. Finish the lines that
+		// moved to the newest roll, then start over.
+		if rolled, err := os.Stat(path + ".1"); err == nil && rolled.Size() >= offset {
+			if _, err := emitProgressLines(path+".1", offset, emit); err != nil {
+				return offset, err
+			}
 		}
-		printEvent(w, line, raw)
+		offset = 0
 	}
-
-	offset, _ := f.Seek(0, io.SeekCurrent)
-	return offset, scanner.Err()
-}
-
-// tailEvents reads new events from ndjsonPath starting at the given offset.
-// Returns the new offset.
-func tailEvents(ndjsonPath string, offset int64, w io.Writer, raw bool) int64 {
-	f, err := os.Open(ndjsonPath)
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+	b, err := io.ReadAll(f)
 	if err != nil {
-		return offset
+		return offset, err
 	}
-	defer f.Close()
-
-	if offset > 0 {
-		if _, err := f.Seek(offset, io.SeekStart); err != nil {
-			return offset
-		}
+	end := bytes.LastIndexByte(b, '\n')
+	if end < 0 {
+		return offset, nil
 	}
-
-	scanner := bufio.NewScanner(f)
-	scanner.Buffer(make([]byte, 0, 64*1024), 2*1024*1024)
-	for scanner.Scan() {
-		line := scanner.Text()
+	for _, line := range strings.Split(string(b[:end]), "\n") {
+		line = strings.TrimRight(line, "\r")
 		if strings.TrimSpace(line) == "" {
 			continue
 		}
-		printEvent(w, line, raw)
+		emit(line)
 	}
-
-	newOffset, _ := f.Seek(0, io.SeekCurrent)
-	return newOffset
+	return offset + int64(end) + 1, nil
 }
 
 func printEvent(w io.Writer, line string, raw bool) {
@@ -184,11 +199,17 @@ func formatProgressEvent(ev map[string]any) string {
 		}
 		return line
 
-	case "branch_heartbeat":
+	case "branch_heartbeat", "stall_heartbeat":
 		// Keep default follow output focused on meaningful progress; heartbeats
 		// are still available with --raw.
 		return ""
 
+	case "stall_warning":
+		return fmt.Sprintf("%s | %-24s | %s | idle=%sms/%sms",
+			ts, event, nodeID,
+			evVal(ev, "idle_ms"),
+			evVal(ev, "timeout_ms"))
+
 	case "branch_stale_warning":
 		return fmt.Sprintf("%s | %-24s | %s | idle=%sms | last=%s",
 			ts, event,
@@ -350,31 +371,41 @@ func latestRunLogsRoot() (string, error) {
 	return filepath.Join(runsDir, dirs[0].name), nil
 }
 
-// runWatchStatus polls the snapshot every interval and reprints it with
-// screen clearing. Exits when the run reaches a terminal state.
-func runWatchStatus(logsRoot string, stdout io.Writer, stderr io.Writer, asJSON bool, intervalSec int) int {
-	if intervalSec <= 0 {
-		intervalSec = 2
+// runWatchStatus reloads the snapshot every interval and reprints it with
+// screen clearing. It exits 0 when the run succeeds and 1 when it fails, its
+// process dies without writing final.json, or its logs root disappears.
+func runWatchStatus(logsRoot string, stdout io.Writer, stderr io.Writer, asJSON bool, interval time.Duration) int {
+	if interval <= 0 {
+		interval = 2 * time.Second
 	}
-	interval := time.Duration(intervalSec) * time.Second
 
 	for {
+		if _, err := os.Stat(logsRoot); err != nil {
+			fmt.Fprintf(stderr, "run logs root is no longer available: %v\n", err)
+			return 1
+		}
+		snapshot, err := loadSnapshot(logsRoot)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+
 		// Clear screen (ANSI escape).
 		fmt.Fprint(stdout, "\033[2J\033[H")
-
-		code := printSnapshot(logsRoot, stdout, stderr, asJSON)
-		if code != 0 {
+		if code := renderSnapshot(snapshot, stdout, stderr, asJSON); code != 0 {
 			return code
 		}
 
-		fmt.Fprintf(stdout, "\nrefreshing every %ds (ctrl-c to stop)\n", intervalSec)
-
-		// Check if terminal.
-		finalPath := filepath.Join(logsRoot, "final.json")
-		if isTerminal(finalPath) {
+		switch {
+		case snapshot.Succeeded():
 			return 0
+		case snapshot.IsTerminal():
+			return 1
+		case snapshot.State == runstate.StateOrphaned:
+			fmt.Fprintln(stderr, snapshot.StateReason)
+			return 1
 		}
-
+		fmt.Fprintf(stdout, "\nrefreshing every %s (ctrl-c to stop)\n", interval)
 		time.Sleep(interval)
 	}
 }
@@ -387,7 +418,10 @@ func printSnapshot(logsRoot string, stdout io.Writer, stderr io.Writer, asJSON b
 		fmt.Fprintln(stderr, err)
 		return 1
 	}
+	return renderSnapshot(snapshot, stdout, stderr, asJSON)
+}
 
+func renderSnapshot(snapshot *runstate.Snapshot, stdout io.Writer, stderr io.Writer, asJSON bool) int {
 	if asJSON {
 		enc := json.NewEncoder(stdout)
 		enc.SetIndent("", "  ")
@@ -399,7 +433,13 @@ func printSnapshot(logsRoot string, stdout io.Writer, stderr io.Writer, asJSON b
 	}
 
 	fmt.Fprintf(stdout, "state=%s\n", snapshot.State)
+	if snapshot.StateReason != "" {
+		fmt.Fprintf(stdout, "state_reason=%s\n", snapshot.StateReason)
+	}
 	fmt.Fprintf(stdout, "run_id=%s\n", snapshot.RunID)
+	if snapshot.GraphName != "" || snapshot.GraphPath != "" {
+		fmt.Fprintf(stdout, "graph=%s path=%s sha256=%s\n", snapshot.GraphName, snapshot.GraphPath, snapshot.GraphSHA256)
+	}
 	fmt.Fprintf(stdout, "node=%s\n", snapshot.CurrentNodeID)
 	fmt.Fprintf(stdout, "event=%s\n", snapshot.LastEvent)
 	fmt.Fprintf(stdout, "pid=%d\n", snapshot.PID)
@@ -407,6 +447,24 @@ func printSnapshot(logsRoot string, stdout io.Writer, stderr io.Writer, asJSON b
 	if !snapshot.LastEventAt.IsZero() {
 		fmt.Fprintf(stdout, "last_event_at=%s\n", snapshot.LastEventAt.UTC().Format(time.RFC3339Nano))
 	}
+	if snapshot.Elapsed > 0 {
+		fmt.Fprintf(stdout, "elapsed=%s\n", snapshot.Elapsed.Round(time.Second))
+	}
+	if snapshot.TotalNodes > 0 {
+		fmt.Fprintf(stdout, "progress=%d/%d (%.0f%%)\n", snapshot.CompletedNodes, snapshot.TotalNodes, snapshot.Progress*100)
+	}
+	if snapshot.Attempts > 0 {
+		fmt.Fprintf(stdout, "attempts=%d\n", snapshot.Attempts)
+		fmt.Fprintf(stdout, "retries=%d\n", snapshot.Retries)
+	}
+	if snapshot.TotalTokens > 0 {
+		fmt.Fprintf(stdout, "tokens=%d (in %d, out %d)\n", snapshot.TotalTokens, snapshot.InputTokens, snapshot.OutputTokens)
+	}
+	if snapshot.StallTimeoutMS > 0 {
+		idle := time.Duration(snapshot.StallIdleMS) * time.Millisecond
+		limit := time.Duration(snapshot.StallTimeoutMS) * time.Millisecond
+		fmt.Fprintf(stdout, "stall_idle=%s/%s (%s remaining)\n", idle.Round(time.Second), limit.Round(time.Second), max(limit-idle, 0).Round(time.Second))
+	}
 	if snapshot.FailureReason != "" {
 		fmt.Fprintf(stdout, "failure_reason=%s\n", snapshot.FailureReason)
 	}
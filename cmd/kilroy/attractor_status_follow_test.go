@@ -193,6 +193,14 @@ func TestFormatProgressEvent_AllEventTypes(t *testing.T) {
 			},
 			contains: []string{"branch_stale_warning", "impl_a", "idle=301000ms", "last=stage_attempt_start"},
 		},
+		{
+			name: "stall_warning",
+			event: map[string]any{
+				"ts": "2026-02-10T04:02:50Z", "event": "stall_warning",
+				"node_id": "impl", "idle_ms": float64(240000), "timeout_ms": float64(300000),
+			},
+			contains: []string{"stall_warning", "impl", "idle=240000ms/300000ms"},
+		},
 		{
 			name: "loop_restart",
 			event: map[string]any{
@@ -226,6 +234,19 @@ func TestFormatProgressEvent_BranchHeartbeatSuppressed(t *testing.T) {
 	}
 }
 
+func TestFormatProgressEvent_StallHeartbeatSuppressed(t *testing.T) {
+	got := formatProgressEvent(map[string]any{
+		"ts":         "2026-02-10T04:02:00Z",
+		"event":      "stall_heartbeat",
+		"node_id":    "impl",
+		"idle_ms":    float64(1000),
+		"timeout_ms": float64(300000),
+	})
+	if strings.TrimSpace(got) != "" {
+		t.Fatalf("expected suppressed output for stall_heartbeat, got: %q", got)
+	}
+}
+
 func TestLatestRunLogsRoot_FindsMostRecent(t *testing.T) {
 	tmp := t.TempDir()
 	runsDir := filepath.Join(tmp, "kilroy", "attractor", "runs")
@@ -294,3 +315,72 @@ func TestRunAttractorStatus_LatestAndLogsRootMutuallyExclusive(t *testing.T) {
 		t.Fatalf("expected mutual exclusion error: %s", stderr.String())
 	}
 }
+
+func TestRunAttractorStatus_WatchExitCodesFollowFinalState(t *testing.T) {
+	for status, want := range map[string]int{"success": 0, "fail": 1} {
+		logs := t.TempDir()
+		_ = os.WriteFile(filepath.Join(logs, "final.json"), []byte(`{"status":"`+status+`","run_id":"r1"}`), 0o644)
+
+		var stdout, stderr bytes.Buffer
+		code := runAttractorStatus([]string{"--watch", "--interval", "10ms", "--logs-root", logs}, &stdout, &stderr)
+		if code != want {
+			t.Fatalf("status %s: exit code %d, want %d; stderr: %s", status, code, want, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), "state="+status) {
+			t.Fatalf("status %s: expected rendered snapshot: %s", status, stdout.String())
+		}
+	}
+}
+
+func TestRunAttractorStatus_WatchStopsWhenRunDisappears(t *testing.T) {
+	logs := filepath.Join(t.TempDir(), "run")
+	_ = os.MkdirAll(logs, 0o755)
+	_ = os.WriteFile(filepath.Join(logs, "live.json"), []byte(`{"event":"stage_attempt_start","node_id":"impl"}`), 0o644)
+
+	done := make(chan int, 1)
+	var stdout, stderr syncBuffer
+	go func() {
+		done <- runAttractorStatus([]string{"--watch", "--interval", "10ms", "--logs-root", logs}, &stdout, &stderr)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	_ = os.RemoveAll(logs)
+
+	select {
+	case code := <-done:
+		if code != 1 {
+			t.Fatalf("exit code %d, want 1", code)
+		}
+		if !strings.Contains(stderr.String(), "no longer available") {
+			t.Fatalf("stderr: %s", stderr.String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not exit after the logs root was removed")
+	}
+}
+
+func TestRunAttractorStatus_WatchExitsOnDeadPID(t *testing.T) {
+	logs := t.TempDir()
+	_ = os.WriteFile(filepath.Join(logs, "run.pid"), []byte("999999999"), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorStatus([]string{"--watch", "--interval", "1", "--logs-root", logs}, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "no longer alive") {
+		t.Fatalf("stderr: %s", stderr.String())
+	}
+}
+
+func TestParseWatchInterval(t *testing.T) {
+	for raw, want := range map[string]time.Duration{"1s": time.Second, "250ms": 250 * time.Millisecond, "3": 3 * time.Second} {
+		got, err := parseWatchInterval(raw)
+		if err != nil || got != want {
+			t.Fatalf("parseWatchInterval(%q) = %v, %v; want %v", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"0", "-1s", "soon"} {
+		if _, err := parseWatchInterval(raw); err == nil {
+			t.Fatalf("parseWatchInterval(%q) should fail", raw)
+		}
+	}
+}
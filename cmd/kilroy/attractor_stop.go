@@ -32,6 +32,7 @@ func runAttractorStop(args []string, stdout io.Writer, stderr io.Writer) int {
 	var logsRoot string
 	grace := 5 * time.Second
 	force := false
+	all := false
 
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
@@ -56,6 +57,8 @@ func runAttractorStop(args []string, stdout io.Writer, stderr io.Writer) int {
 			grace = time.Duration(ms) * time.Millisecond
 		case "--force":
 			force = true
+		case "--all":
+			all = true
 		default:
 			fmt.Fprintf(stderr, "unknown arg: %s\n", args[i])
 			return 1
@@ -66,28 +69,82 @@ func runAttractorStop(args []string, stdout io.Writer, stderr io.Writer) int {
 		fmt.Fprintln(stderr, "--logs-root is required")
 		return 1
 	}
+	if all {
+		return runAttractorStopAll(logsRoot, grace, force, stdout, stderr)
+	}
 
 	snapshot, err := runstate.LoadSnapshot(logsRoot)
 	if err != nil {
 		fmt.Fprintln(stderr, err)
 		return 1
 	}
-	if snapshot.State != runstate.StateRunning {
+	if !snapshot.IsRunning() {
 		fmt.Fprintf(stderr, "run state is %q (expected %q); refusing to stop\n", snapshot.State, runstate.StateRunning)
 		return 1
 	}
-	if snapshot.PID <= 0 {
-		fmt.Fprintln(stderr, "run pid is not available (run.pid missing or invalid)")
+	pid, mode, err := stopRun(logsRoot, snapshot, grace, force, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
 		return 1
 	}
-	if !snapshot.PIDAlive {
-		fmt.Fprintf(stderr, "pid %d is not running\n", snapshot.PID)
+	fmt.Fprintf(stdout, "pid=%d\nstopped=%s\n", pid, mode)
+	return 0
+}
+
+// runAttractorStopAll stops every running run directly under root (for
+// example a --batch logs root), one at a time, and prints one result line per
+// run. Terminal runs are skipped; runs whose process cannot be verified as
+// their attractor are refused, as with a single stop. It exits non-zero when
+// any running run could not be stopped.
+func runAttractorStopAll(root string, grace time.Duration, force bool, stdout io.Writer, stderr io.Writer) int {
+	snapshots, err := listRunSnapshots(root, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
 		return 1
 	}
+	code := 0
+	for _, listed := range snapshots {
+		// Reload: listRunSnapshots fills a missing run id with the directory
+		// name, which must not be used to verify the process identity.
+		snapshot, err := runstate.LoadSnapshot(listed.LogsRoot)
+		if err != nil {
+			fmt.Fprintf(stdout, "run_id=%s result=refused reason=%q\n", listed.RunID, err.Error())
+			code = 1
+			continue
+		}
+		switch {
+		case snapshot.IsTerminal():
+			fmt.Fprintf(stdout, "run_id=%s result=skipped reason=%q\n", listed.RunID, "state="+snapshot.State.String())
+			continue
+		case !snapshot.IsRunning():
+			fmt.Fprintf(stdout, "run_id=%s result=skipped reason=%q\n", listed.RunID, "not running")
+			continue
+		}
+		pid, mode, err := stopRun(snapshot.LogsRoot, snapshot, grace, force, stderr)
+		if err != nil {
+			fmt.Fprintf(stdout, "run_id=%s result=refused reason=%q\n", listed.RunID, err.Error())
+			code = 1
+			continue
+		}
+		fmt.Fprintf(stdout, "run_id=%s result=stopped pid=%d stopped=%s\n", listed.RunID, pid, mode)
+	}
+	return code
+}
+
+// stopRun stops the running run described by snapshot: it verifies the pid
+// belongs to this run's attractor process, sends SIGTERM, escalates to SIGKILL
+// after grace when force is set, and records a terminal outcome. It returns the
+// signaled pid and "graceful" or "forced". Warnings go to stderr.
+func stopRun(logsRoot string, snapshot *runstate.Snapshot, grace time.Duration, force bool, stderr io.Writer) (int, string, error) {
+	if snapshot.PID <= 0 {
+		return 0, "", errors.New("run pid is not available (run.pid missing or invalid)")
+	}
+	if !snapshot.PIDAlive {
+		return 0, "", fmt.Errorf("pid %d is not running", snapshot.PID)
+	}
 	verified, err := verifyAttractorRunPID(snapshot.PID, logsRoot, snapshot.RunID)
 	if err != nil {
-		fmt.Fprintln(stderr, err)
-		return 1
+		return 0, "", err
 	}
 	runID := resolveExpectedRunID(snapshot.RunID, logsRoot)
 	if err := writeStopRequest(logsRoot, runID, verified.PID, grace, force); err != nil {
@@ -96,42 +153,34 @@ func runAttractorStop(args []string, stdout io.Writer, stderr io.Writer) int {
 
 	proc, err := os.FindProcess(verified.PID)
 	if err != nil {
-		fmt.Fprintf(stderr, "find pid %d: %v\n", verified.PID, err)
-		return 1
+		return 0, "", fmt.Errorf("find pid %d: %v", verified.PID, err)
 	}
 	// Best-effort identity check immediately before signaling. A small race
 	// remains without pidfd-based signaling, but start-time verification greatly
 	// reduces accidental PID-reuse targeting.
 	if err := verifyProcessIdentity(verified); err != nil {
-		fmt.Fprintln(stderr, err)
-		return 1
+		return 0, "", err
 	}
 	if err := proc.Signal(syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
-		fmt.Fprintf(stderr, "send SIGTERM to pid %d: %v\n", verified.PID, err)
-		return 1
+		return 0, "", fmt.Errorf("send SIGTERM to pid %d: %v", verified.PID, err)
 	}
 
 	if waitForPIDExit(verified, grace) {
 		if err := ensureTerminalOutcomeAfterStop(logsRoot, runID, "stopped_by_operator"); err != nil {
-			fmt.Fprintf(stderr, "stopped pid %d but could not persist final outcome: %v\n", verified.PID, err)
-			return 1
+			return 0, "", fmt.Errorf("stopped pid %d but could not persist final outcome: %v", verified.PID, err)
 		}
-		fmt.Fprintf(stdout, "pid=%d\nstopped=graceful\n", verified.PID)
-		return 0
+		return verified.PID, "graceful", nil
 	}
 
 	if !force {
-		fmt.Fprintf(stderr, "pid %d did not exit within %s\n", verified.PID, grace)
-		return 1
+		return 0, "", fmt.Errorf("pid %d did not exit within %s", verified.PID, grace)
 	}
 	if err := verifyProcessIdentity(verified); err != nil {
-		fmt.Fprintln(stderr, err)
-		return 1
+		return 0, "", err
 	}
 
 	if err := proc.Signal(syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
-		fmt.Fprintf(stderr, "send SIGKILL to pid %d: %v\n", verified.PID, err)
-		return 1
+		return 0, "", fmt.Errorf("send SIGKILL to pid %d: %v", verified.PID, err)
 	}
 	forceWait := grace
 	if forceWait < time.Second {
@@ -144,15 +193,12 @@ func runAttractorStop(args []string, stdout io.Writer, stderr io.Writer) int {
 		forceWait = 10 * time.Second
 	}
 	if !waitForPIDExit(verified, forceWait) {
-		fmt.Fprintf(stderr, "pid %d did not exit after SIGKILL\n", verified.PID)
-		return 1
+		return 0, "", fmt.Errorf("pid %d did not exit after SIGKILL", verified.PID)
 	}
 	if err := ensureTerminalOutcomeAfterStop(logsRoot, runID, "stopped_by_operator_forced"); err != nil {
-		fmt.Fprintf(stderr, "stopped pid %d but could not persist final outcome: %v\n", verified.PID, err)
-		return 1
+		return 0, "", fmt.Errorf("stopped pid %d but could not persist final outcome: %v", verified.PID, err)
 	}
-	fmt.Fprintf(stdout, "pid=%d\nstopped=forced\n", verified.PID)
-	return 0
+	return verified.PID, "forced", nil
 }
 
 type stopRequest struct {
@@ -184,7 +230,7 @@ func ensureTerminalOutcomeAfterStop(logsRoot, runID, failureReason string) error
 
 	out := runtime.FinalOutcome{
 		Timestamp:     time.Now().UTC(),
-		Status:        runtime.FinalFail,
+		Status:        runtime.FinalCanceled,
 		RunID:         strings.TrimSpace(runID),
 		FailureReason: strings.TrimSpace(failureReason),
 	}
@@ -351,7 +397,7 @@ func readManifestRunID(logsRoot string) (string, error) {
 }
 
 func captureVerifiedProcess(pid int) (verifiedProcess, error) {
-	if !procutil.ProcFSAvailable() {
+	if !procutil.PIDStartTimeSupported() {
 		return verifiedProcess{PID: pid}, nil
 	}
 	start, err := procutil.ReadPIDStartTime(pid)
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danshapiro/kilroy/internal/attractor/engine"
+	"github.com/danshapiro/kilroy/internal/attractor/validate"
+)
+
+func attractorValidate(args []string) {
+	os.Exit(runAttractorValidate(args, os.Stdout, os.Stderr))
+}
+
+// runAttractorValidate prepares a graph (parse, transforms, lint) without
+// running it and prints the diagnostics grouped by severity. It exits non-zero
+// when the graph cannot be parsed or any diagnostic is an error.
+func runAttractorValidate(args []string, stdout io.Writer, stderr io.Writer) int {
+	var graphPath string
+	var asJSON bool
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--graph":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(stderr, "--graph requires a value")
+				return 1
+			}
+			graphPath = args[i]
+		case "--json":
+			asJSON = true
+		default:
+			if graphPath != "" || strings.HasPrefix(args[i], "-") {
+				fmt.Fprintf(stderr, "unknown arg: %s\n", args[i])
+				return 1
+			}
+			graphPath = args[i]
+		}
+	}
+	if graphPath == "" {
+		fmt.Fprintln(stderr, "--graph is required")
+		return 1
+	}
+	dotSource, err := os.ReadFile(graphPath)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	_, diags, err := engine.PrepareWithOptions(dotSource, engine.PrepareOptions{GraphPath: graphPath})
+	if err != nil && len(diags) == 0 {
+		// Parse and transform failures carry no diagnostics.
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	errCount := 0
+	for _, d := range diags {
+		if d.Severity == validate.SeverityError {
+			errCount++
+		}
+	}
+	code := 0
+	if errCount > 0 {
+		code = 1
+	}
+
+	if asJSON {
+		if diags == nil {
+			diags = []validate.Diagnostic{}
+		}
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(diags); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return code
+	}
+
+	for _, sev := range []validate.Severity{validate.SeverityError, validate.SeverityWarning, validate.SeverityInfo} {
+		var group []validate.Diagnostic
+		for _, d := range diags {
+			if d.Severity == sev {
+				group = append(group, d)
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+		fmt.Fprintf(stdout, "%s (%d):\n", sev, len(group))
+		for _, d := range group {
+			fmt.Fprintf(stdout, "  %s: %s%s\n", d.Rule, d.Message, diagnosticLocation(d))
+			if d.Fix != "" {
+				fmt.Fprintf(stdout, "    fix: %s\n", d.Fix)
+			}
+		}
+	}
+	if errCount > 0 {
+		fmt.Fprintf(stdout, "invalid: %s (%d error(s))\n", filepath.Base(graphPath), errCount)
+	} else {
+		fmt.Fprintf(stdout, "ok: %s\n", filepath.Base(graphPath))
+	}
+	return code
+}
+
+func diagnosticLocation(d validate.Diagnostic) string {
+	var at string
+	if pos := d.Pos(); pos.IsValid() {
+		at = " at " + pos.String()
+	}
+	switch {
+	case d.EdgeFrom != "" || d.EdgeTo != "":
+		return fmt.Sprintf(" [edge %s -> %s%s]", d.EdgeFrom, d.EdgeTo, at)
+	case d.NodeID != "":
+		return fmt.Sprintf(" [node %s%s]", d.NodeID, at)
+	case at != "":
+		return " [" + strings.TrimPrefix(at, " ") + "]"
+	}
+	return ""
+}
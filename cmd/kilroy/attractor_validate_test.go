@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danshapiro/kilroy/internal/attractor/validate"
+)
+
+func writeValidateGraph(t *testing.T, src string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "g.dot")
+	if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+const validateOKGraph = `digraph G {
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  t [shape=parallelogram, tool_command="echo hi"]
+  start -> t -> exit
+}`
+
+func TestRunAttractorValidate_OKGraph(t *testing.T) {
+	p := writeValidateGraph(t, validateOKGraph)
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorValidate([]string{p}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d; stdout: %s stderr: %s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "ok: g.dot") {
+		t.Fatalf("stdout: %s", stdout.String())
+	}
+}
+
+func TestRunAttractorValidate_ResolvesIncludesNextToTheGraph(t *testing.T) {
+	p := writeValidateGraph(t, `digraph G {
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  sub [include="sub.dot"]
+  start -> sub -> exit
+}`)
+	if err := os.WriteFile(filepath.Join(filepath.Dir(p), "sub.dot"), []byte(validateOKGraph), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The working directory (this package) does not hold sub.dot.
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorValidate([]string{p}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d; stdout: %s stderr: %s", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestRunAttractorValidate_GroupsErrorsAndFails(t *testing.T) {
+	p := writeValidateGraph(t, `digraph G {
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  t [shape=parallelogram, tool_command="echo hi"]
+  orphan [shape=parallelogram, tool_command="echo"]
+  start -> t -> exit
+  t -> missing
+}`)
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorValidate([]string{"--graph", p}, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code %d, want 1; stdout: %s", code, stdout.String())
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "ERROR (") {
+		t.Fatalf("expected an ERROR group: %s", out)
+	}
+	if !strings.Contains(out, "invalid: g.dot") {
+		t.Fatalf("expected invalid summary: %s", out)
+	}
+	if strings.Index(out, "ERROR (") > strings.Index(out, "invalid: g.dot") {
+		t.Fatalf("summary should follow the diagnostics: %s", out)
+	}
+	if !strings.Contains(out, "[edge t -> missing at 7:3]") {
+		t.Fatalf("expected the edge diagnostic to carry its source line: %s", out)
+	}
+}
+
+func TestRunAttractorValidate_JSON(t *testing.T) {
+	p := writeValidateGraph(t, validateOKGraph)
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorValidate([]string{"--json", p}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d; stderr: %s", code, stderr.String())
+	}
+	var diags []validate.Diagnostic
+	if err := json.Unmarshal(stdout.Bytes(), &diags); err != nil {
+		t.Fatalf("output is not a diagnostics array: %v\n%s", err, stdout.String())
+	}
+	if !strings.HasPrefix(strings.TrimSpace(stdout.String()), "[") {
+		t.Fatalf("expected a JSON array, got %s", stdout.String())
+	}
+}
+
+func TestRunAttractorValidate_ParseErrorFails(t *testing.T) {
+	p := writeValidateGraph(t, `digraph G {`)
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorValidate([]string{p}, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code %d, want 1", code)
+	}
+	if stderr.Len() == 0 {
+		t.Fatal("expected parse error on stderr")
+	}
+}
+
+func TestRunAttractorValidate_RejectsExtraArgs(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorValidate([]string{"a.dot", "b.dot"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "unknown arg: b.dot") {
+		t.Fatalf("stderr: %s", stderr.String())
+	}
+}
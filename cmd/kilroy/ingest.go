@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime/debug"
@@ -16,6 +17,11 @@ import (
 
 var osExecutable = os.Executable
 var readBuildInfo = debug.ReadBuildInfo
+var ingestStdin io.Reader = os.Stdin
+var ingestRun = ingest.Run
+
+// defaultIngestCLIModel is the --model default for the cli backend.
+const defaultIngestCLIModel = "claude-sonnet-4-5"
 
 type ingestOptions struct {
 	requirements string
@@ -24,18 +30,35 @@ type ingestOptions struct {
 	skillPath    string
 	repoPath     string
 	validate     bool
+	autoRepair   bool
 	maxTurns     int
+	backend      string
+
+	// Batch mode: ingest every requirements file in batchDir, with at most
+	// concurrency at once. outputPath, when set, is the output directory.
+	batchDir    string
+	concurrency int
+
+	// logPrefix, when set, prefixes this ingest's progress and warning
+	// lines on stderr, so concurrent batch ingests can be told apart.
+	logPrefix string
 }
 
 func parseIngestArgs(args []string) (*ingestOptions, error) {
 	opts := &ingestOptions{
-		model:    "claude-sonnet-4-5",
 		validate: true,
 	}
 
 	var positional []string
+	var requirementsFile string
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
+		case "--requirements-file":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("--requirements-file requires a value")
+			}
+			requirementsFile = args[i]
 		case "--output", "-o":
 			i++
 			if i >= len(args) {
@@ -70,20 +93,89 @@ func parseIngestArgs(args []string) (*ingestOptions, error) {
 				return nil, fmt.Errorf("--max-turns must be a positive integer")
 			}
 			opts.maxTurns = n
+		case "--backend":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("--backend requires a value")
+			}
+			switch b := strings.ToLower(strings.TrimSpace(args[i])); b {
+			case ingest.BackendCLI, ingest.BackendAPI:
+				opts.backend = b
+			default:
+				return nil, fmt.Errorf("--backend must be %s or %s", ingest.BackendCLI, ingest.BackendAPI)
+			}
+		case "--batch":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("--batch requires a value")
+			}
+			opts.batchDir = args[i]
+		case "--concurrency":
+			i++
+			if i >= len(args) {
+				return nil, fmt.Errorf("--concurrency requires a value")
+			}
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("--concurrency must be a positive integer")
+			}
+			opts.concurrency = n
 		case "--no-validate":
 			opts.validate = false
+		case "--auto-repair":
+			opts.autoRepair = true
 		default:
-			if strings.HasPrefix(args[i], "-") {
+			if strings.HasPrefix(args[i], "-") && args[i] != "-" {
 				return nil, fmt.Errorf("unknown flag: %s", args[i])
 			}
 			positional = append(positional, args[i])
 		}
 	}
 
-	if len(positional) == 0 {
-		return nil, fmt.Errorf("requirements text is required (positional argument)")
+	switch {
+	case opts.batchDir != "" && (requirementsFile != "" || len(positional) > 0):
+		return nil, fmt.Errorf("--batch reads requirements from the directory; it cannot be combined with --requirements-file or positional requirements")
+	case opts.batchDir != "":
+		if opts.concurrency == 0 {
+			opts.concurrency = defaultIngestBatchConcurrency
+		}
+	case opts.concurrency != 0:
+		return nil, fmt.Errorf("--concurrency requires --batch")
+	case requirementsFile != "" && len(positional) > 0:
+		return nil, fmt.Errorf("--requirements-file and positional requirements text are mutually exclusive")
+	case requirementsFile != "":
+		b, err := os.ReadFile(requirementsFile)
+		if err != nil {
+			return nil, fmt.Errorf("read --requirements-file: %w", err)
+		}
+		opts.requirements = string(b)
+	case len(positional) == 1 && positional[0] == "-":
+		b, err := io.ReadAll(ingestStdin)
+		if err != nil {
+			return nil, fmt.Errorf("read requirements from stdin: %w", err)
+		}
+		opts.requirements = string(b)
+	case len(positional) > 0:
+		for _, p := range positional {
+			if p == "-" {
+				return nil, fmt.Errorf("\"-\" (read requirements from stdin) must be the only positional argument")
+			}
+		}
+		opts.requirements = strings.Join(positional, " ")
+	default:
+		return nil, fmt.Errorf("requirements text is required (positional argument, --requirements-file, or - for stdin)")
+	}
+	if opts.batchDir == "" && strings.TrimSpace(opts.requirements) == "" {
+		return nil, fmt.Errorf("requirements text is empty")
+	}
+	// The default is a Claude model for the claude binary; an API endpoint
+	// serves its own model IDs, so the api backend must name one.
+	if opts.model == "" {
+		if opts.backend == ingest.BackendAPI {
+			return nil, fmt.Errorf("--backend %s requires --model (a model the endpoint serves)", ingest.BackendAPI)
+		}
+		opts.model = defaultIngestCLIModel
 	}
-	opts.requirements = strings.Join(positional, " ")
 
 	if opts.repoPath == "" {
 		cwd, err := os.Getwd()
@@ -105,16 +197,28 @@ func attractorIngest(args []string) {
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		fmt.Fprintln(os.Stderr)
-		fmt.Fprintln(os.Stderr, "usage: kilroy attractor ingest [flags] <requirements>")
-		fmt.Fprintln(os.Stderr, "  --output, -o    Output .dot file path (default: stdout)")
-		fmt.Fprintln(os.Stderr, "  --model         LLM model (default: claude-sonnet-4-5)")
+		fmt.Fprintln(os.Stderr, "usage: kilroy attractor ingest [flags] (<requirements> | - | --requirements-file <path> | --batch <dir>)")
+		fmt.Fprintln(os.Stderr, "  --requirements-file  Read requirements from a file (use - as the positional argument for stdin)")
+		fmt.Fprintln(os.Stderr, "  --batch         Ingest every *.txt and *.md file in a directory, writing <name>.dot for each")
+		fmt.Fprintln(os.Stderr, "  --concurrency   Batch files ingested at once (default: 4)")
+		fmt.Fprintln(os.Stderr, "  --output, -o    Output .dot file path (default: stdout); with --batch, the output directory (default: the batch directory)")
+		fmt.Fprintln(os.Stderr, "  --model         LLM model (default: claude-sonnet-4-5; required with --backend api)")
 		fmt.Fprintln(os.Stderr, "  --skill         Path to skill .md file (default: repo/binary auto-detect)")
 		fmt.Fprintln(os.Stderr, "  --repo          Repository root (default: cwd)")
 		fmt.Fprintln(os.Stderr, "  --max-turns     Max agentic turns for Claude (default: 15)")
+		fmt.Fprintln(os.Stderr, "  --backend       cli (claude binary, default) or api (OpenAI-compatible API)")
 		fmt.Fprintln(os.Stderr, "  --no-validate   Skip .dot validation")
+		fmt.Fprintln(os.Stderr, "  --auto-repair   Fix common DOT mistakes when validation fails")
 		os.Exit(1)
 	}
 
+	if opts.batchDir != "" {
+		if runIngestBatch(opts, os.Stdout, os.Stderr) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
 	dotContent, err := runIngest(opts)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -143,7 +247,7 @@ func resolveDefaultIngestSkillPath(repoPath string) string {
 
 func defaultIngestSkillCandidates(repoPath string) []string {
 	seen := map[string]bool{}
-	out := make([]string, 0, 6)
+	out := make([]string, 0, 12)
 	add := func(p string) {
 		p = strings.TrimSpace(p)
 		if p == "" {
@@ -160,12 +264,16 @@ func defaultIngestSkillCandidates(repoPath string) []string {
 		seen[abs] = true
 		out = append(out, abs)
 	}
-	skillPathUnder := func(base string) string {
-		return filepath.Join(base, "skills", "english-to-dotfile", "SKILL.md")
+	// Each location may hold the skill as SKILL.md or gzipped as SKILL.md.gz;
+	// the plain file wins when both exist.
+	addSkillUnder := func(base string) {
+		p := filepath.Join(base, "skills", "english-to-dotfile", "SKILL.md")
+		add(p)
+		add(p + ".gz")
 	}
 
 	if strings.TrimSpace(repoPath) != "" {
-		add(skillPathUnder(repoPath))
+		addSkillUnder(repoPath)
 	}
 
 	if exePath, err := osExecutable(); err == nil {
@@ -175,14 +283,14 @@ func defaultIngestSkillCandidates(repoPath string) []string {
 				exePath = resolved
 			}
 			exeDir := filepath.Dir(exePath)
-			add(skillPathUnder(exeDir))
-			add(skillPathUnder(filepath.Dir(exeDir)))
-			add(skillPathUnder(filepath.Join(filepath.Dir(exeDir), "share", "kilroy")))
+			addSkillUnder(exeDir)
+			addSkillUnder(filepath.Dir(exeDir))
+			addSkillUnder(filepath.Join(filepath.Dir(exeDir), "share", "kilroy"))
 		}
 	}
 
 	for _, moduleDir := range moduleCacheCandidateRootsForInstalledBinary() {
-		add(skillPathUnder(moduleDir))
+		addSkillUnder(moduleDir)
 	}
 
 	return out
@@ -280,20 +388,30 @@ func runIngest(opts *ingestOptions) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
 	defer cancel()
 
-	result, err := ingest.Run(ctx, ingest.Options{
+	var progress func(string)
+	if opts.logPrefix != "" {
+		progress = func(line string) { fmt.Fprintf(os.Stderr, "%s%s\n", opts.logPrefix, line) }
+	}
+	result, err := ingestRun(ctx, ingest.Options{
+		Progress:     progress,
 		Requirements: opts.requirements,
 		SkillPath:    opts.skillPath,
 		Model:        opts.model,
 		RepoPath:     opts.repoPath,
 		Validate:     opts.validate,
+		AutoRepair:   opts.autoRepair,
 		MaxTurns:     opts.maxTurns,
+		Backend:      opts.backend,
+		// Batch ingests run side by side; an interactive claude session
+		// would fight the others for the terminal.
+		NonInteractive: opts.batchDir != "",
 	})
 	if err != nil {
 		return "", err
 	}
 
 	for _, w := range result.Warnings {
-		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+		fmt.Fprintf(os.Stderr, "%swarning: %s\n", opts.logPrefix, w)
 	}
 
 	return result.DotContent, nil
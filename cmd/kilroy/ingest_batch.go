@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const defaultIngestBatchConcurrency = 4
+
+// ingestBatchResult is the outcome of ingesting one requirements file.
+type ingestBatchResult struct {
+	File   string
+	Output string
+	Err    error
+}
+
+// listIngestBatchFiles returns the *.txt and *.md files directly in dir,
+// sorted by name.
+func listIngestBatchFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".txt", ".md":
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// runIngestBatch ingests every requirements file in opts.batchDir, at most
+// opts.concurrency at once, writing <name>.dot for each into the output
+// directory (opts.outputPath, else the batch directory). A file that fails
+// does not stop the others. It prints one line per file and a summary to
+// stdout and returns the number of files that failed.
+func runIngestBatch(opts *ingestOptions, stdout, stderr io.Writer) int {
+	files, err := listIngestBatchFiles(opts.batchDir)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(stderr, "no *.txt or *.md requirements files in %s\n", opts.batchDir)
+		return 1
+	}
+	outDir := opts.outputPath
+	if outDir == "" {
+		outDir = opts.batchDir
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	results := make([]ingestBatchResult, len(files))
+	claimed := map[string]string{}
+	concurrency := opts.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, file := range files {
+		name := filepath.Base(file)
+		stem := strings.TrimSuffix(name, filepath.Ext(name))
+		results[i] = ingestBatchResult{File: file, Output: filepath.Join(outDir, stem+".dot")}
+		// foo.txt and foo.md would both write foo.dot; the first one wins.
+		if prev, ok := claimed[results[i].Output]; ok {
+			results[i].Err = fmt.Errorf("output %s is already written by %s", results[i].Output, filepath.Base(prev))
+			continue
+		}
+		claimed[results[i].Output] = file
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i].Err = ingestBatchFile(opts, results[i].File, results[i].Output)
+		}(i)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Fprintf(stdout, "file=%s status=fail error=%q\n", r.File, r.Err.Error())
+			continue
+		}
+		fmt.Fprintf(stdout, "file=%s status=ok output=%s\n", r.File, r.Output)
+	}
+	fmt.Fprintf(stdout, "batch_total=%d\n", len(results))
+	fmt.Fprintf(stdout, "batch_succeeded=%d\n", len(results)-failed)
+	fmt.Fprintf(stdout, "batch_failed=%d\n", failed)
+	return failed
+}
+
+// ingestBatchFile ingests one requirements file with the batch's shared
+// flags and writes the resulting graph to output.
+func ingestBatchFile(opts *ingestOptions, file, output string) error {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(b)) == "" {
+		return fmt.Errorf("requirements file is empty")
+	}
+	one := *opts
+	one.requirements = string(b)
+	one.logPrefix = "[" + filepath.Base(file) + "] "
+	dotContent, err := runIngest(&one)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(output, []byte(dotContent), 0o644)
+}
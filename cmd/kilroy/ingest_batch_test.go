@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/ingest"
+)
+
+func stubIngestRun(t *testing.T, fn func(context.Context, ingest.Options) (*ingest.Result, error)) {
+	t.Helper()
+	old := ingestRun
+	ingestRun = fn
+	t.Cleanup(func() { ingestRun = old })
+}
+
+func TestRunIngestBatch_WritesOutputsAndIsolatesFailures(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"alpha.txt":  "alpha",
+		"beta.md":    "fail",
+		"gamma.md":   "gamma",
+		"notes.json": "ignored",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var mu sync.Mutex
+	var seen []ingest.Options
+	stubIngestRun(t, func(_ context.Context, o ingest.Options) (*ingest.Result, error) {
+		mu.Lock()
+		seen = append(seen, o)
+		mu.Unlock()
+		if o.Requirements == "fail" {
+			return nil, errors.New("claude exited 1")
+		}
+		return &ingest.Result{DotContent: "digraph " + o.Requirements + " {}"}, nil
+	})
+
+	outDir := filepath.Join(t.TempDir(), "out")
+	opts := &ingestOptions{batchDir: dir, outputPath: outDir, concurrency: 2, skillPath: "skill.md", model: "m", maxTurns: 7}
+	var stdout, stderr bytes.Buffer
+	if failed := runIngestBatch(opts, &stdout, &stderr); failed != 1 {
+		t.Fatalf("failed = %d, want 1\nstdout:\n%s", failed, stdout.String())
+	}
+	for _, name := range []string{"alpha", "gamma"} {
+		b, err := os.ReadFile(filepath.Join(outDir, name+".dot"))
+		if err != nil || string(b) != "digraph "+name+" {}" {
+			t.Fatalf("%s.dot = %q, %v", name, b, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "beta.dot")); !os.IsNotExist(err) {
+		t.Fatalf("beta.dot should not exist: %v", err)
+	}
+	if len(seen) != 3 {
+		t.Fatalf("ingest ran %d times, want 3", len(seen))
+	}
+	for _, o := range seen {
+		if o.Model != "m" || o.MaxTurns != 7 || o.SkillPath != "skill.md" {
+			t.Fatalf("per-file options lost batch flags: %+v", o)
+		}
+		if !o.NonInteractive {
+			t.Fatalf("batch ingest must run claude non-interactively: %+v", o)
+		}
+	}
+	out := stdout.String()
+	if !containsAll(out, "alpha.txt status=ok", `beta.md status=fail error="claude exited 1"`, "gamma.md status=ok",
+		"batch_total=3", "batch_succeeded=2", "batch_failed=1") {
+		t.Fatalf("summary:\n%s", out)
+	}
+}
+
+func TestRunIngestBatch_BoundsConcurrency(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var mu sync.Mutex
+	running, peak := 0, 0
+	stubIngestRun(t, func(_ context.Context, o ingest.Options) (*ingest.Result, error) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return &ingest.Result{DotContent: "digraph G {}"}, nil
+	})
+	var stdout, stderr bytes.Buffer
+	if failed := runIngestBatch(&ingestOptions{batchDir: dir, concurrency: 2, skillPath: "skill.md"}, &stdout, &stderr); failed != 0 {
+		t.Fatalf("failed = %d\n%s", failed, stdout.String())
+	}
+	if peak != 2 {
+		t.Fatalf("peak concurrency = %d, want 2", peak)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "e.dot")); err != nil {
+		t.Fatalf("output not written next to the inputs: %v", err)
+	}
+}
+
+func TestRunIngestBatch_DuplicateOutputNameFails(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"spec.md", "spec.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stubIngestRun(t, func(_ context.Context, o ingest.Options) (*ingest.Result, error) {
+		return &ingest.Result{DotContent: o.Requirements}, nil
+	})
+	var stdout, stderr bytes.Buffer
+	if failed := runIngestBatch(&ingestOptions{batchDir: dir, concurrency: 4, skillPath: "skill.md"}, &stdout, &stderr); failed != 1 {
+		t.Fatalf("failed = %d, want 1\n%s", failed, stdout.String())
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "spec.dot")); string(b) != "spec.md" {
+		t.Fatalf("spec.dot = %q, want the first file's graph", b)
+	}
+	if !strings.Contains(stdout.String(), "spec.txt status=fail") {
+		t.Fatalf("summary:\n%s", stdout.String())
+	}
+}
@@ -84,11 +84,63 @@ func TestParseIngestArgs(t *testing.T) {
 			args:    []string{"--max-turns", "abc", "Build a solitaire game"},
 			wantErr: true,
 		},
+		{
+			name: "backend flag",
+			args: []string{"--backend", "API", "--model", "gpt-5.2", "Build a solitaire game"},
+			check: func(t *testing.T, o *ingestOptions) {
+				if o.backend != "api" || o.model != "gpt-5.2" {
+					t.Errorf("backend = %q, model = %q, want api and gpt-5.2", o.backend, o.model)
+				}
+			},
+		},
+		{
+			name:    "api backend without model",
+			args:    []string{"--backend", "api", "Build a solitaire game"},
+			wantErr: true,
+		},
+		{
+			name: "auto-repair flag",
+			args: []string{"--auto-repair", "Build a solitaire game"},
+			check: func(t *testing.T, o *ingestOptions) {
+				if !o.autoRepair || !o.validate {
+					t.Errorf("autoRepair = %v, validate = %v, want both true", o.autoRepair, o.validate)
+				}
+			},
+		},
+		{
+			name:    "backend invalid",
+			args:    []string{"--backend", "grpc", "Build a solitaire game"},
+			wantErr: true,
+		},
 		{
 			name:    "max-turns zero",
 			args:    []string{"--max-turns", "0", "Build a solitaire game"},
 			wantErr: true,
 		},
+		{
+			name: "batch defaults concurrency",
+			args: []string{"--batch", "specs", "--max-turns", "5"},
+			check: func(t *testing.T, o *ingestOptions) {
+				if o.batchDir != "specs" || o.concurrency != defaultIngestBatchConcurrency || o.maxTurns != 5 {
+					t.Errorf("batchDir = %q, concurrency = %d, maxTurns = %d", o.batchDir, o.concurrency, o.maxTurns)
+				}
+			},
+		},
+		{
+			name:    "batch with positional requirements",
+			args:    []string{"--batch", "specs", "Build a solitaire game"},
+			wantErr: true,
+		},
+		{
+			name:    "concurrency without batch",
+			args:    []string{"--concurrency", "2", "Build a solitaire game"},
+			wantErr: true,
+		},
+		{
+			name:    "concurrency zero",
+			args:    []string{"--batch", "specs", "--concurrency", "0"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -104,6 +156,49 @@ func TestParseIngestArgs(t *testing.T) {
 	}
 }
 
+func TestParseIngestArgs_RequirementsFromFileAndStdin(t *testing.T) {
+	spec := "# Game\n\nBuild a solitaire game.\n\n- use `$HOME` & \"quotes\"\n"
+	path := filepath.Join(t.TempDir(), "spec.md")
+	if err := os.WriteFile(path, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := parseIngestArgs([]string{"--requirements-file", path, "-o", "out.dot"})
+	if err != nil {
+		t.Fatalf("parseIngestArgs(--requirements-file): %v", err)
+	}
+	if opts.requirements != spec {
+		t.Fatalf("requirements from file = %q, want %q", opts.requirements, spec)
+	}
+
+	old := ingestStdin
+	t.Cleanup(func() { ingestStdin = old })
+	ingestStdin = strings.NewReader(spec)
+	opts, err = parseIngestArgs([]string{"--model", "m", "-"})
+	if err != nil {
+		t.Fatalf("parseIngestArgs(-): %v", err)
+	}
+	if opts.requirements != spec {
+		t.Fatalf("requirements from stdin = %q, want %q", opts.requirements, spec)
+	}
+
+	for name, args := range map[string][]string{
+		"file and positional":  {"--requirements-file", path, "Build a game"},
+		"stdin and positional": {"-", "Build a game"},
+		"missing file":         {"--requirements-file", filepath.Join(t.TempDir(), "nope.md")},
+		"file flag no value":   {"--requirements-file"},
+	} {
+		if _, err := parseIngestArgs(args); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	ingestStdin = strings.NewReader("  \n")
+	if _, err := parseIngestArgs([]string{"-"}); err == nil || !strings.Contains(err.Error(), "empty") {
+		t.Fatalf("expected empty requirements error, got %v", err)
+	}
+}
+
 func TestResolveDefaultIngestSkillPath_UsesBinaryRelativeDefaults(t *testing.T) {
 	tmp := t.TempDir()
 	binaryPath := filepath.Join(tmp, "bin", "kilroy")
@@ -166,6 +261,33 @@ func TestResolveDefaultIngestSkillPath_PrefersRepoSkill(t *testing.T) {
 	}
 }
 
+func TestResolveDefaultIngestSkillPath_FindsGzippedSkill(t *testing.T) {
+	tmp := t.TempDir()
+	repo := filepath.Join(tmp, "repo")
+	gzSkill := filepath.Join(repo, "skills", "english-to-dotfile", "SKILL.md.gz")
+	if err := os.MkdirAll(filepath.Dir(gzSkill), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(gzSkill, []byte("gz"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := osExecutable
+	osExecutable = func() (string, error) { return filepath.Join(tmp, "bin", "kilroy"), nil }
+	t.Cleanup(func() { osExecutable = old })
+
+	if got := resolveDefaultIngestSkillPath(repo); canonicalPath(got) != canonicalPath(gzSkill) {
+		t.Fatalf("resolveDefaultIngestSkillPath() = %q, want %q", got, gzSkill)
+	}
+
+	plain := strings.TrimSuffix(gzSkill, ".gz")
+	if err := os.WriteFile(plain, []byte("# plain"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := resolveDefaultIngestSkillPath(repo); canonicalPath(got) != canonicalPath(plain) {
+		t.Fatalf("resolveDefaultIngestSkillPath() = %q, want the plain %q", got, plain)
+	}
+}
+
 func TestResolveDefaultIngestSkillPath_UsesGoInstallModuleCacheFallback(t *testing.T) {
 	tmp := t.TempDir()
 	moduleDir := filepath.Join(tmp, "pkg", "mod", "github.com", "danshapiro", "kilroy@v1.2.3")
@@ -10,8 +10,10 @@ import (
 	"os/signal"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/danshapiro/kilroy/internal/attractor/engine"
 	"github.com/danshapiro/kilroy/internal/providerspec"
@@ -66,14 +68,22 @@ func main() {
 func usage() {
 	fmt.Fprintln(os.Stderr, "usage:")
 	fmt.Fprintln(os.Stderr, "  kilroy --version")
-	fmt.Fprintln(os.Stderr, "  kilroy attractor run [--detach] [--allow-test-shim] [--confirm-stale-build] [--no-cxdb] [--force-model <provider=model>] --graph <file.dot> --config <run.yaml> [--run-id <id>] [--logs-root <dir>]")
+	fmt.Fprintln(os.Stderr, "  kilroy attractor run [--detach] [--interactive] [--allow-test-shim] [--confirm-stale-build] [--no-cxdb] [--require-pinned-catalog] [--no-network] [--force-model <provider=model>] [--set <key=value>] --graph <file.dot> --config <run.yaml> [--run-id <id>] [--logs-root <dir>] [--progress-addr <[host]:port>] [--summary-json] [--overwrite]")
+	fmt.Fprintln(os.Stderr, "  kilroy attractor run --dry-run --graph <file.dot> [--config <run.yaml>] [--set <key=value>]")
+	fmt.Fprintln(os.Stderr, "  kilroy attractor run --batch <inputs.jsonl> --config <run.yaml> [--graph <default.dot>] [--logs-root <dir>] [--concurrency <n>] [--allow-test-shim] [--no-cxdb] [--require-pinned-catalog] [--no-network] [--force-model <provider=model>] [--set <key=value>]")
 	fmt.Fprintln(os.Stderr, "  kilroy attractor resume --logs-root <dir>")
 	fmt.Fprintln(os.Stderr, "  kilroy attractor resume --cxdb <http_base_url> --context-id <id>")
 	fmt.Fprintln(os.Stderr, "  kilroy attractor resume --run-branch <attractor/run/...> [--repo <path>]")
-	fmt.Fprintln(os.Stderr, "  kilroy attractor status [--logs-root <dir> | --latest] [--json] [--follow|-f] [--cxdb] [--raw] [--watch] [--interval <sec>]")
-	fmt.Fprintln(os.Stderr, "  kilroy attractor stop --logs-root <dir> [--grace-ms <ms>] [--force]")
-	fmt.Fprintln(os.Stderr, "  kilroy attractor validate --graph <file.dot>")
-	fmt.Fprintln(os.Stderr, "  kilroy attractor ingest [--output <file.dot>] [--model <model>] [--skill <skill.md>] [--repo <path>] [--max-turns <n>] <requirements>")
+	fmt.Fprintln(os.Stderr, "  kilroy attractor status [--logs-root <dir> | --latest] [--json] [--follow|-f] [--cxdb] [--raw] [--watch] [--interval <duration>]")
+	fmt.Fprintln(os.Stderr, "  kilroy attractor stop --logs-root <dir> [--all] [--grace-ms <ms>] [--force]")
+	fmt.Fprintln(os.Stderr, "  kilroy attractor list --logs-root <dir> [--state running|success|fail|canceled|orphaned|unknown] [--json]")
+	fmt.Fprintln(os.Stderr, "  kilroy attractor logs --logs-root <dir> [--follow|-f] [--json]")
+	fmt.Fprintln(os.Stderr, "  kilroy attractor inspect --logs-root <dir> [--node <id>] [--json]")
+	fmt.Fprintln(os.Stderr, "  kilroy attractor archive --logs-root <dir> --out <run.tar.gz>")
+	fmt.Fprintln(os.Stderr, "  kilroy attractor report (--logs-root <dir> | --archive <run.tar.gz>) [--json]")
+	fmt.Fprintln(os.Stderr, "  kilroy attractor validate [--json] (--graph <file.dot> | <file.dot>)")
+	fmt.Fprintln(os.Stderr, "  kilroy attractor graph render <file.dot> [--format svg|png|dot] [--output <file>]")
+	fmt.Fprintln(os.Stderr, "  kilroy attractor ingest [--output <file.dot>] [--model <model>] [--skill <skill.md>] [--repo <path>] [--max-turns <n>] [--backend cli|api] [--auto-repair] (<requirements> | - | --requirements-file <path> | --batch <dir> [--concurrency <n>])")
 	fmt.Fprintln(os.Stderr, "  kilroy attractor serve [--addr <host:port>]")
 }
 
@@ -91,8 +101,20 @@ func attractor(args []string) {
 		attractorStatus(args[1:])
 	case "stop":
 		attractorStop(args[1:])
+	case "list":
+		attractorList(args[1:])
+	case "logs":
+		attractorLogs(args[1:])
+	case "inspect":
+		attractorInspect(args[1:])
+	case "archive":
+		attractorArchive(args[1:])
+	case "report":
+		attractorReport(args[1:])
 	case "validate":
 		attractorValidate(args[1:])
+	case "graph":
+		attractorGraph(args[1:])
 	case "ingest":
 		attractorIngest(args[1:])
 	case "serve":
@@ -112,19 +134,41 @@ func attractorRun(args []string) {
 	var allowTestShim bool
 	var confirmStaleBuild bool
 	var noCXDB bool
+	var requirePinnedCatalog bool
+	var noNetwork bool
 	var skipCLIHeadlessWarning bool
 	var forceModelSpecs []string
+	var setSpecs []string
+	var batchPath string
+	var batchConcurrency int
+	var dryRun bool
+	var interactive bool
+	var progressAddr string
+	var summaryJSON bool
+	var overwrite bool
 
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
 		case "--detach":
 			detach = true
+		case "--interactive":
+			interactive = true
+		case "--dry-run":
+			dryRun = true
+		case "--summary-json":
+			summaryJSON = true
+		case "--overwrite":
+			overwrite = true
 		case "--allow-test-shim":
 			allowTestShim = true
 		case "--confirm-stale-build":
 			confirmStaleBuild = true
 		case "--no-cxdb":
 			noCXDB = true
+		case "--require-pinned-catalog":
+			requirePinnedCatalog = true
+		case "--no-network":
+			noNetwork = true
 		case skipCLIHeadlessWarningFlag:
 			skipCLIHeadlessWarning = true
 		case "--force-model":
@@ -134,6 +178,13 @@ func attractorRun(args []string) {
 				os.Exit(1)
 			}
 			forceModelSpecs = append(forceModelSpecs, args[i])
+		case "--set":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(os.Stderr, "--set requires a value in the form key=value")
+				os.Exit(1)
+			}
+			setSpecs = append(setSpecs, args[i])
 		case "--graph":
 			i++
 			if i >= len(args) {
@@ -141,6 +192,25 @@ func attractorRun(args []string) {
 				os.Exit(1)
 			}
 			graphPath = args[i]
+		case "--batch":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(os.Stderr, "--batch requires a value")
+				os.Exit(1)
+			}
+			batchPath = args[i]
+		case "--concurrency":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(os.Stderr, "--concurrency requires a value")
+				os.Exit(1)
+			}
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "invalid --concurrency value: %q (want integer >= 1)\n", args[i])
+				os.Exit(1)
+			}
+			batchConcurrency = n
 		case "--config":
 			i++
 			if i >= len(args) {
@@ -162,13 +232,52 @@ func attractorRun(args []string) {
 				os.Exit(1)
 			}
 			logsRoot = args[i]
+		case "--progress-addr":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(os.Stderr, "--progress-addr requires a value")
+				os.Exit(1)
+			}
+			progressAddr = args[i]
 		default:
 			fmt.Fprintf(os.Stderr, "unknown arg: %s\n", args[i])
 			os.Exit(1)
 		}
 	}
 
-	if graphPath == "" || configPath == "" {
+	inputs, canonicalSetSpecs, err := parseSetFlags(setSpecs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if summaryJSON && (dryRun || batchPath != "" || detach) {
+		fmt.Fprintln(os.Stderr, "--summary-json cannot be combined with --dry-run, --batch or --detach")
+		os.Exit(1)
+	}
+
+	if dryRun {
+		if batchPath != "" || detach {
+			fmt.Fprintln(os.Stderr, "--dry-run cannot be combined with --batch or --detach")
+			os.Exit(1)
+		}
+		if graphPath == "" {
+			usage()
+			os.Exit(1)
+		}
+		os.Exit(runAttractorDryRun(graphPath, inputs, os.Stdout, os.Stderr))
+	}
+
+	if batchPath != "" {
+		if detach || runID != "" || progressAddr != "" {
+			fmt.Fprintln(os.Stderr, "--batch cannot be combined with --detach, --run-id or --progress-addr")
+			os.Exit(1)
+		}
+		if configPath == "" {
+			usage()
+			os.Exit(1)
+		}
+	} else if graphPath == "" || configPath == "" {
 		usage()
 		os.Exit(1)
 	}
@@ -181,7 +290,27 @@ func attractorRun(args []string) {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	if err := checkRunIDReuse(runID, logsRoot, overwrite); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
+	if batchPath != "" {
+		if interactive {
+			fmt.Fprintln(os.Stderr, "--interactive is ignored with --batch; human gates will auto-approve")
+		}
+		attractorRunBatch(batchPath, graphPath, configPath, logsRoot, batchConcurrency, engine.RunOptions{
+			AllowTestShim:        allowTestShim,
+			RequirePinnedCatalog: requirePinnedCatalog,
+			DisableCXDB:          noCXDB,
+			NoNetwork:            noNetwork,
+			ForceModels:          forceModels,
+			InitialContext:       inputs,
+		}, skipCLIHeadlessWarning)
+		return
+	}
+
+	interviewer := interactiveInterviewer(interactive, detach, os.Stdin, os.Stdout, os.Stderr)
 	if detach {
 		cfg, err := engine.LoadRunConfigFile(configPath)
 		if err != nil {
@@ -236,16 +365,31 @@ func attractorRun(args []string) {
 		if noCXDB {
 			childArgs = append(childArgs, "--no-cxdb")
 		}
+		if requirePinnedCatalog {
+			childArgs = append(childArgs, "--require-pinned-catalog")
+		}
+		if noNetwork {
+			childArgs = append(childArgs, "--no-network")
+		}
 		childArgs = append(childArgs, skipCLIHeadlessWarningFlag)
 		for _, spec := range canonicalForceSpecs {
 			childArgs = append(childArgs, "--force-model", spec)
 		}
+		for _, spec := range canonicalSetSpecs {
+			childArgs = append(childArgs, "--set", spec)
+		}
+		if progressAddr != "" {
+			childArgs = append(childArgs, "--progress-addr", progressAddr)
+		}
 
 		if err := launchDetached(childArgs, logsRoot); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		fmt.Printf("detached=true\nlogs_root=%s\npid_file=%s\n", logsRoot, filepath.Join(logsRoot, "run.pid"))
+		if progressAddr != "" {
+			fmt.Printf("progress_url=http://%s\n", progressListenAddr(progressAddr))
+		}
 		os.Exit(0)
 	}
 
@@ -266,15 +410,34 @@ func attractorRun(args []string) {
 		}
 	}
 
+	var progress *progressServer
+	if progressAddr != "" {
+		progress, err = startProgressServer(progressAddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "progress server: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "progress server at %s (/snapshot, /events)\n", progress.URL())
+	}
+
 	// Default: no deadline. CLI runs (especially with provider CLIs) can take hours.
 	ctx, cleanupSignalCtx := signalCancelContext()
 
-	res, err := engine.RunWithConfig(ctx, dotSource, cfg, engine.RunOptions{
-		RunID:         runID,
-		LogsRoot:      logsRoot,
-		AllowTestShim: allowTestShim,
-		DisableCXDB:   noCXDB,
-		ForceModels:   forceModels,
+	graphAbsPath := graphPath
+	if abs, err := filepath.Abs(graphPath); err == nil {
+		graphAbsPath = abs
+	}
+	runOpts := engine.RunOptions{
+		RunID:                runID,
+		LogsRoot:             logsRoot,
+		GraphPath:            graphAbsPath,
+		AllowTestShim:        allowTestShim,
+		RequirePinnedCatalog: requirePinnedCatalog,
+		DisableCXDB:          noCXDB,
+		NoNetwork:            noNetwork,
+		ForceModels:          forceModels,
+		Interviewer:          interviewer,
+		InitialContext:       inputs,
 		OnCXDBStartup: func(info *engine.CXDBStartupInfo) {
 			if info == nil {
 				return
@@ -288,10 +451,46 @@ func attractorRun(args []string) {
 			}
 			fmt.Fprintf(os.Stderr, "CXDB UI available at %s\n", info.UIURL)
 		},
-	})
+	}
+	// The engine reports its run ID and logs root once it starts, so the
+	// summary can name them even when the run then fails. The run ID is
+	// chosen up front so a run that fails preflight still has one.
+	if summaryJSON && runOpts.RunID == "" {
+		id, err := engine.NewRunID()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		runOpts.RunID = id
+	}
+	readyRunID, readyLogsRoot := runOpts.RunID, runOpts.LogsRoot
+	runOpts.OnEngineReady = func(e *engine.Engine) {
+		readyRunID, readyLogsRoot = e.Options.RunID, e.LogsRoot
+		if progress != nil {
+			progress.SetLogsRoot(e.LogsRoot)
+		}
+	}
+	if progress != nil {
+		runOpts.ProgressSink = progress.Send
+	}
+	started := time.Now()
+	res, err := engine.RunWithConfig(ctx, dotSource, cfg, runOpts)
 	cleanupSignalCtx()
+	if progress != nil {
+		_ = progress.Close()
+	}
+	emitSummary := func() {
+		if !summaryJSON {
+			return
+		}
+		summary := buildRunSummary(res, err, readyRunID, readyLogsRoot, time.Since(started))
+		if werr := writeRunSummary(os.Stdout, summary); werr != nil {
+			fmt.Fprintf(os.Stderr, "write run summary: %v\n", werr)
+		}
+	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		emitSummary()
 		os.Exit(1)
 	}
 	fmt.Printf("run_id=%s\n", res.RunID)
@@ -305,6 +504,7 @@ func attractorRun(args []string) {
 	for _, w := range res.Warnings {
 		fmt.Fprintf(os.Stderr, "WARNING: %s\n", w)
 	}
+	emitSummary()
 
 	if string(res.FinalStatus) == "success" {
 		os.Exit(0)
@@ -349,6 +549,37 @@ func parseForceModelFlags(specs []string) (map[string]string, []string, error) {
 	return overrides, canonicalSpecs, nil
 }
 
+// parseSetFlags turns repeated --set key=value flags into run inputs (the
+// run's initial context) and their canonical, key-sorted form for relaunching
+// a detached child. Values are kept verbatim, including further '=' signs.
+func parseSetFlags(specs []string) (map[string]any, []string, error) {
+	if len(specs) == 0 {
+		return nil, nil, nil
+	}
+	inputs := map[string]any{}
+	for _, raw := range specs {
+		key, value, ok := strings.Cut(raw, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, nil, fmt.Errorf("--set %q is invalid; expected key=value", raw)
+		}
+		if prev, exists := inputs[key]; exists {
+			return nil, nil, fmt.Errorf("--set key %q specified multiple times (%q then %q)", key, prev, value)
+		}
+		inputs[key] = value
+	}
+	keys := make([]string, 0, len(inputs))
+	for k := range inputs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	canonicalSpecs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		canonicalSpecs = append(canonicalSpecs, fmt.Sprintf("%s=%s", k, inputs[k]))
+	}
+	return inputs, canonicalSpecs, nil
+}
+
 func normalizeRunProviderKey(provider string) string {
 	return providerspec.CanonicalProviderKey(provider)
 }
@@ -399,46 +630,6 @@ func supportedForceModelProvidersCSV() string {
 	return strings.Join(keys, ", ")
 }
 
-func attractorValidate(args []string) {
-	var graphPath string
-	for i := 0; i < len(args); i++ {
-		switch args[i] {
-		case "--graph":
-			i++
-			if i >= len(args) {
-				fmt.Fprintln(os.Stderr, "--graph requires a value")
-				os.Exit(1)
-			}
-			graphPath = args[i]
-		default:
-			fmt.Fprintf(os.Stderr, "unknown arg: %s\n", args[i])
-			os.Exit(1)
-		}
-	}
-	if graphPath == "" {
-		usage()
-		os.Exit(1)
-	}
-	dotSource, err := os.ReadFile(graphPath)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
-	_, diags, err := engine.Prepare(dotSource)
-	if err != nil {
-		for _, d := range diags {
-			fmt.Fprintf(os.Stderr, "%s: %s (%s)\n", d.Severity, d.Message, d.Rule)
-		}
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
-	fmt.Printf("ok: %s\n", filepath.Base(graphPath))
-	for _, d := range diags {
-		fmt.Printf("%s: %s (%s)\n", d.Severity, d.Message, d.Rule)
-	}
-	os.Exit(0)
-}
-
 func attractorResume(args []string) {
 	var logsRoot string
 	var cxdbBaseURL string
@@ -491,6 +682,12 @@ func attractorResume(args []string) {
 		usage()
 		os.Exit(1)
 	}
+	if logsRoot != "" {
+		if err := checkResumable(logsRoot); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
 	// Default: no deadline. Resume may replay long stages or rehydrate large artifacts.
 	ctx, cleanupSignalCtx := signalCancelContext()
 	var (
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -66,3 +68,30 @@ func TestAttractorStatus_TerminalFinalIgnoresMalformedPID(t *testing.T) {
 		t.Fatalf("unexpected output: %s", out)
 	}
 }
+
+func TestRunAttractorStatus_JSONSerializesSnapshot(t *testing.T) {
+	logs := t.TempDir()
+	_ = os.WriteFile(filepath.Join(logs, "final.json"), []byte(`{"status":"fail","run_id":"r1","failure_reason":"tests failed"}`), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorStatus([]string{"--logs-root", logs, "--json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d; stderr: %s", code, stderr.String())
+	}
+	var got map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("output is not a JSON object: %v\n%s", err, stdout.String())
+	}
+	want := map[string]any{
+		"logs_root":      logs,
+		"run_id":         "r1",
+		"state":          "fail",
+		"failure_reason": "tests failed",
+		"pid":            float64(0),
+		"pid_alive":      false,
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("%s=%#v want %#v (output: %s)", k, got[k], v, stdout.String())
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
 	"os/exec"
@@ -70,8 +71,8 @@ func TestAttractorStop_KillsVerifiedAttractorProcessFromRunPID(t *testing.T) {
 	if err := json.Unmarshal(finalBytes, &final); err != nil {
 		t.Fatalf("decode final.json: %v", err)
 	}
-	if strings.TrimSpace(anyToString(final["status"])) != "fail" {
-		t.Fatalf("expected final status fail after stop, got: %v", final["status"])
+	if strings.TrimSpace(anyToString(final["status"])) != "canceled" {
+		t.Fatalf("expected final status canceled after stop, got: %v", final["status"])
 	}
 	if strings.TrimSpace(anyToString(final["failure_reason"])) == "" {
 		t.Fatalf("expected failure_reason in final.json after stop: %v", final)
@@ -253,7 +254,9 @@ func TestAttractorStop_ErrorsWhenNoPID(t *testing.T) {
 }
 
 func TestVerifyProcessIdentity_DetectsChangedStartTime(t *testing.T) {
-	requireProcFS(t)
+	if !procutil.PIDStartTimeSupported() {
+		t.Skip("process start time is not available on this platform")
+	}
 	start, err := procutil.ReadPIDStartTime(os.Getpid())
 	if err != nil {
 		t.Fatalf("ReadPIDStartTime: %v", err)
@@ -276,3 +279,71 @@ digraph G {
 }`), 0o644)
 	return path
 }
+
+func TestAttractorStopAll_SkipsTerminalAndRefusesUnverifiedRuns(t *testing.T) {
+	requireProcFS(t)
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("requires sleep binary")
+	}
+	root := t.TempDir()
+	mkRun := func(name string, files map[string]string) {
+		dir := filepath.Join(root, name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for f, body := range files {
+			_ = os.WriteFile(filepath.Join(dir, f), []byte(body), 0o644)
+		}
+	}
+
+	proc := exec.Command("sleep", "60")
+	if err := proc.Start(); err != nil {
+		t.Fatalf("start sleep: %v", err)
+	}
+	t.Cleanup(func() {
+		if proc.Process != nil {
+			_ = proc.Process.Kill()
+		}
+	})
+	mkRun("done", map[string]string{"final.json": `{"status":"success","run_id":"done"}`})
+	mkRun("stale", map[string]string{"live.json": `{"event":"stage_attempt_start","node_id":"a"}`})
+	mkRun("impostor", map[string]string{"run.pid": strconv.Itoa(proc.Process.Pid)})
+	_ = os.MkdirAll(filepath.Join(root, "not-a-run"), 0o755)
+
+	var stdout, stderr bytes.Buffer
+	code := runAttractorStop([]string{"--all", "--logs-root", root, "--grace-ms", "100"}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("exit code %d, want 1; stdout: %s stderr: %s", code, stdout.String(), stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{
+		`run_id=done result=skipped reason="state=success"`,
+		`run_id=stale result=skipped reason="not running"`,
+		`run_id=impostor result=refused reason="refusing to signal pid`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "not-a-run") {
+		t.Fatalf("directory without run artifacts should be ignored:\n%s", out)
+	}
+	if !procutil.PIDAlive(proc.Process.Pid) {
+		t.Fatal("unverified process must not be signaled")
+	}
+}
+
+func TestAttractorStopAll_SucceedsWhenNothingIsRunning(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "r1")
+	_ = os.MkdirAll(dir, 0o755)
+	_ = os.WriteFile(filepath.Join(dir, "final.json"), []byte(`{"status":"fail","run_id":"r1"}`), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	if code := runAttractorStop([]string{"--logs-root", root, "--all"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d; stdout: %s stderr: %s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `run_id=r1 result=skipped reason="state=fail"`) {
+		t.Fatalf("stdout: %s", stdout.String())
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runstate"
+	"github.com/danshapiro/kilroy/internal/server"
+)
+
+// progressServerShutdownTimeout bounds how long a finished run waits for
+// progress server clients to disconnect.
+const progressServerShutdownTimeout = 5 * time.Second
+
+// progressServer is the optional `attractor run --progress-addr` endpoint.
+// GET /snapshot returns the run's runstate.Snapshot as JSON and GET /events
+// streams progress events as SSE, replaying earlier events to late joiners.
+type progressServer struct {
+	httpSrv     *http.Server
+	listener    net.Listener
+	broadcaster *server.Broadcaster
+	served      chan struct{}
+
+	mu       sync.Mutex
+	logsRoot string
+}
+
+// progressListenAddr binds a bare ":PORT" to localhost; an explicit host is
+// used as given.
+func progressListenAddr(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if strings.HasPrefix(addr, ":") {
+		return "127.0.0.1" + addr
+	}
+	return addr
+}
+
+// startProgressServer listens on addr and serves until Close. Send is the
+// engine progress sink; SetLogsRoot enables /snapshot once the run's logs
+// root is known.
+func startProgressServer(addr string) (*progressServer, error) {
+	ln, err := net.Listen("tcp", progressListenAddr(addr))
+	if err != nil {
+		return nil, err
+	}
+	ps := &progressServer{
+		listener:    ln,
+		broadcaster: server.NewBroadcaster(),
+		served:      make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /snapshot", ps.handleSnapshot)
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		server.WriteSSE(w, r, ps.broadcaster)
+	})
+	ps.httpSrv = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		defer close(ps.served)
+		_ = ps.httpSrv.Serve(ln)
+	}()
+	return ps, nil
+}
+
+// URL is the server's base URL.
+func (ps *progressServer) URL() string {
+	return "http://" + ps.listener.Addr().String()
+}
+
+// Send is the engine progress sink.
+func (ps *progressServer) Send(ev map[string]any) {
+	ps.broadcaster.Send(ev)
+}
+
+func (ps *progressServer) SetLogsRoot(logsRoot string) {
+	ps.mu.Lock()
+	ps.logsRoot = logsRoot
+	ps.mu.Unlock()
+}
+
+func (ps *progressServer) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	ps.mu.Lock()
+	logsRoot := ps.logsRoot
+	ps.mu.Unlock()
+	if logsRoot == "" {
+		http.Error(w, "run has not started", http.StatusServiceUnavailable)
+		return
+	}
+	snap, err := runstate.LoadSnapshot(logsRoot)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(snap)
+}
+
+// Close ends every event stream with a final "done" event and shuts the
+// server down, waiting briefly for clients to disconnect.
+func (ps *progressServer) Close() error {
+	ps.broadcaster.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), progressServerShutdownTimeout)
+	defer cancel()
+	err := ps.httpSrv.Shutdown(ctx)
+	<-ps.served
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ps.httpSrv.Close()
+	}
+	return err
+}
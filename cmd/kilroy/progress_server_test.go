@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressListenAddr_BarePortBindsLocalhost(t *testing.T) {
+	cases := map[string]string{
+		":8090":         "127.0.0.1:8090",
+		" :0 ":          "127.0.0.1:0",
+		"0.0.0.0:8090":  "0.0.0.0:8090",
+		"localhost:123": "localhost:123",
+	}
+	for in, want := range cases {
+		if got := progressListenAddr(in); got != want {
+			t.Fatalf("progressListenAddr(%q)=%q want %q", in, got, want)
+		}
+	}
+}
+
+func TestProgressServer_SnapshotAndEventStream(t *testing.T) {
+	logs := t.TempDir()
+	_ = os.WriteFile(filepath.Join(logs, "progress.ndjson"),
+		[]byte(`{"ts":"2026-01-01T00:00:05Z","run_id":"r1","event":"stage_attempt_start","node_id":"build"}`+"\n"), 0o644)
+
+	ps, err := startProgressServer(":0")
+	if err != nil {
+		t.Fatalf("startProgressServer: %v", err)
+	}
+	if !strings.HasPrefix(ps.URL(), "http://127.0.0.1:") {
+		t.Fatalf("URL=%q, want localhost", ps.URL())
+	}
+
+	resp, err := http.Get(ps.URL() + "/snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("snapshot before start: status %d", resp.StatusCode)
+	}
+
+	ps.SetLogsRoot(logs)
+	resp, err = http.Get(ps.URL() + "/snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap map[string]any
+	err = json.NewDecoder(resp.Body).Decode(&snap)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode snapshot: %v", err)
+	}
+	if snap["run_id"] != "r1" || snap["current_node_id"] != "build" {
+		t.Fatalf("snapshot=%v", snap)
+	}
+
+	ps.Send(map[string]any{"event": "stage_attempt_start", "node_id": "build"})
+	resp, err = http.Get(ps.URL() + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content-type=%q", ct)
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		ps.Send(map[string]any{"event": "stage_attempt_end", "node_id": "build"})
+		closed <- ps.Close()
+	}()
+
+	var lines []string
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		if line := sc.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := <-closed; err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	got := strings.Join(lines, "\n")
+	for _, want := range []string{`"event":"stage_attempt_start"`, `"event":"stage_attempt_end"`, "event: done"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("stream missing %q:\n%s", want, got)
+		}
+	}
+	if _, err := http.Get(ps.URL() + "/snapshot"); err == nil {
+		t.Fatal("server still accepting connections after Close")
+	}
+}
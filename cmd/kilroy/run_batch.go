@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/danshapiro/kilroy/internal/attractor/engine"
+)
+
+// attractorRunBatch executes every line of a JSON-lines inputs file as its own
+// run and exits non-zero when any run did not succeed.
+func attractorRunBatch(batchPath, defaultGraphPath, configPath, logsRoot string, concurrency int, overrides engine.RunOptions, skipCLIHeadlessWarning bool) {
+	inputs, err := engine.LoadBatchInputs(batchPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	cfg, err := engine.LoadRunConfigFile(configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if !skipCLIHeadlessWarning && runConfigUsesCLIProviders(cfg) {
+		if !confirmCLIHeadlessWarning(os.Stdin, os.Stderr) {
+			fmt.Fprintln(os.Stderr, "preflight aborted: declined provider CLI headless-risk warning")
+			os.Exit(1)
+		}
+	}
+
+	ctx, cleanupSignalCtx := signalCancelContext()
+	summary, err := engine.RunBatch(ctx, cfg, inputs, engine.BatchOptions{
+		LogsRoot:         logsRoot,
+		DefaultGraphPath: defaultGraphPath,
+		Concurrency:      concurrency,
+		Overrides:        overrides,
+	})
+	cleanupSignalCtx()
+	if summary != nil {
+		for _, r := range summary.Runs {
+			fmt.Printf("run_id=%s status=%s logs_root=%s\n", r.RunID, r.Status, r.LogsRoot)
+			if r.Error != "" {
+				fmt.Fprintf(os.Stderr, "run %s: %s\n", r.RunID, r.Error)
+			}
+		}
+		fmt.Printf("batch_total=%d\n", summary.Total)
+		fmt.Printf("batch_succeeded=%d\n", summary.Succeeded)
+		fmt.Printf("batch_failed=%d\n", summary.Failed)
+		fmt.Printf("batch_canceled=%d\n", summary.Canceled)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if summary.OK() {
+		os.Exit(0)
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAttractorRunBatch_ExitsNonZeroWhenAnyRunFails(t *testing.T) {
+	cxdbSrv := newCXDBTestServer(t)
+	bin := buildKilroyBinary(t)
+	repo := initTestRepo(t)
+	catalog := writePinnedCatalog(t)
+	cfg := writeRunConfig(t, repo, cxdbSrv.URL(), cxdbSrv.BinaryAddr(), catalog)
+
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "ok.dot"), []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  start -> exit
+}
+`), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "fail.dot"), []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  t [shape=parallelogram, tool_command="exit 1"]
+  start -> t
+}
+`), 0o644)
+	batch := filepath.Join(dir, "inputs.jsonl")
+	_ = os.WriteFile(batch, []byte(`{"run_id":"batch-ok"}
+{"run_id":"batch-fail","graph":"fail.dot"}
+`), 0o644)
+
+	logsRoot := filepath.Join(t.TempDir(), "batch")
+	code, out := runKilroy(t, bin, "attractor", "run", "--batch", batch, "--graph", filepath.Join(dir, "ok.dot"), "--config", cfg, "--logs-root", logsRoot, "--concurrency", "2")
+	if code != 1 {
+		t.Fatalf("exit code: got %d want 1\n%s", code, out)
+	}
+	for _, want := range []string{"run_id=batch-ok status=success", "run_id=batch-fail status=fail", "batch_total=2", "batch_failed=1"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q:\n%s", want, out)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(logsRoot, "batch-ok", "final.json")); err != nil {
+		t.Fatalf("expected per-run final.json under logs root: %v", err)
+	}
+}
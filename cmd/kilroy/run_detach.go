@@ -1,12 +1,19 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+
+	"github.com/danshapiro/kilroy/internal/attractor/gitutil"
+	"github.com/danshapiro/kilroy/internal/attractor/procutil"
+	"github.com/danshapiro/kilroy/internal/attractor/runstate"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
 )
 
 var detachedExecCommand = exec.Command
@@ -85,3 +92,66 @@ func defaultDetachedLogsRoot(runID string) (string, error) {
 	}
 	return filepath.Join(stateHome, "kilroy", "attractor", "runs", runID), nil
 }
+
+// checkRunIDReuse refuses to start a run with an explicit --run-id whose logs
+// root (logsRoot, or the default for runID when empty) is still in use by a
+// live run, or already holds a finished run, since the new run would
+// overwrite its artifacts. With overwrite a finished run's logs root is
+// cleared instead (its worktree is removed from the repo first), so nothing
+// of the old run is mixed into the new one or mistaken for its result.
+func checkRunIDReuse(runID, logsRoot string, overwrite bool) error {
+	if strings.TrimSpace(runID) == "" {
+		return nil
+	}
+	if strings.TrimSpace(logsRoot) == "" {
+		root, err := defaultDetachedLogsRoot(runID)
+		if err != nil {
+			return err
+		}
+		logsRoot = root
+	}
+	if pid := liveRunPID(logsRoot); pid > 0 {
+		return fmt.Errorf("run id %q is still running (pid %d) in %s; stop it first, or omit --run-id to generate a new one", runID, pid, logsRoot)
+	}
+	finalPath := filepath.Join(logsRoot, "final.json")
+	b, err := os.ReadFile(finalPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if overwrite {
+		if m, err := runstate.LoadManifest(logsRoot); err == nil && m.RepoPath != "" && m.Worktree != "" {
+			_ = gitutil.RemoveWorktree(m.RepoPath, m.Worktree)
+		}
+		return os.RemoveAll(logsRoot)
+	}
+	status := "unknown"
+	var final runtime.FinalOutcome
+	if json.Unmarshal(b, &final) == nil && final.Status != "" {
+		status = string(final.Status)
+	}
+	return fmt.Errorf("run id %q already has a finished run (status %s) in %s; pass --overwrite to reuse it, or omit --run-id to generate a new one", runID, status, logsRoot)
+}
+
+// liveRunPID returns the pid in logsRoot's run.pid when that process is alive,
+// is not this one, and, where manifest.json recorded its start time, is still
+// the same process; otherwise 0. A detached child finds its own pid there,
+// written by the launcher before the child checks its run id.
+func liveRunPID(logsRoot string) int {
+	b, err := os.ReadFile(filepath.Join(logsRoot, "run.pid"))
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil || pid <= 0 || pid == os.Getpid() || !procutil.PIDAlive(pid) {
+		return 0
+	}
+	if m, err := runstate.LoadManifest(logsRoot); err == nil && m.PID == pid && m.PIDStartTime > 0 {
+		if start, err := procutil.ReadPIDStartTime(pid); err == nil && start != m.PIDStartTime {
+			return 0
+		}
+	}
+	return pid
+}
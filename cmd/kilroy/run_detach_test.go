@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -82,3 +83,83 @@ func TestDetachedExecutablePath_NormalizesRelativeOSExecutable(t *testing.T) {
 		t.Fatalf("detachedExecutablePath must return absolute path, got %q", path)
 	}
 }
+
+func TestCheckRunIDReuse(t *testing.T) {
+	logsRoot := t.TempDir()
+	if err := checkRunIDReuse("r1", logsRoot, false); err != nil {
+		t.Fatalf("empty logs root: %v", err)
+	}
+	finalPath := filepath.Join(logsRoot, "final.json")
+	if err := os.WriteFile(finalPath, []byte(`{"status":"success","run_id":"r1"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkRunIDReuse("", logsRoot, false); err != nil {
+		t.Fatalf("generated run ids are not checked: %v", err)
+	}
+	err := checkRunIDReuse("r1", logsRoot, false)
+	if err == nil || !strings.Contains(err.Error(), "status success") || !strings.Contains(err.Error(), "--overwrite") {
+		t.Fatalf("err = %v, want a refusal naming the status and --overwrite", err)
+	}
+	if err := os.WriteFile(filepath.Join(logsRoot, "progress.ndjson"), []byte(`{"event":"stage_attempt_end"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkRunIDReuse("r1", logsRoot, true); err != nil {
+		t.Fatalf("overwrite: %v", err)
+	}
+	for _, name := range []string{"final.json", "progress.ndjson"} {
+		if _, err := os.Stat(filepath.Join(logsRoot, name)); !os.IsNotExist(err) {
+			t.Fatalf("%s not removed on overwrite: %v", name, err)
+		}
+	}
+}
+
+func TestCheckRunIDReuse_RefusesLiveRun(t *testing.T) {
+	logsRoot := t.TempDir()
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cmd.Process.Kill(); _ = cmd.Wait() }()
+	if err := os.WriteFile(filepath.Join(logsRoot, "run.pid"), []byte(strconv.Itoa(cmd.Process.Pid)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(logsRoot, "final.json"), []byte(`{"status":"success","run_id":"r1"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, overwrite := range []bool{false, true} {
+		err := checkRunIDReuse("r1", logsRoot, overwrite)
+		if err == nil || !strings.Contains(err.Error(), "still running") {
+			t.Fatalf("overwrite=%v: err = %v, want a live-run refusal", overwrite, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(logsRoot, "final.json")); err != nil {
+		t.Fatalf("live run's logs root was modified: %v", err)
+	}
+}
+
+func TestCheckRunIDReuse_DetachedChildSeesItsOwnPID(t *testing.T) {
+	logsRoot := t.TempDir()
+	if err := os.WriteFile(filepath.Join(logsRoot, "run.pid"), []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkRunIDReuse("r1", logsRoot, false); err != nil {
+		t.Fatalf("run.pid naming this process: %v", err)
+	}
+}
+
+func TestCheckRunIDReuse_DefaultLogsRoot(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	root, err := defaultDetachedLogsRoot("r2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "final.json"), []byte(`{"status":"fail"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkRunIDReuse("r2", "", false); err == nil || !strings.Contains(err.Error(), root) {
+		t.Fatalf("err = %v, want a refusal naming %s", err, root)
+	}
+}
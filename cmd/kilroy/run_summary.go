@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/engine"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+// runSummary is the one-line JSON `attractor run --summary-json` writes to
+// stdout when the run ends, after everything else, so scripts can read the
+// result from the last line without parsing logs.
+type runSummary struct {
+	RunID         string `json:"run_id"`
+	Status        string `json:"status"`
+	DurationMS    int64  `json:"duration_ms"`
+	FailureReason string `json:"failure_reason,omitempty"`
+	LogsRoot      string `json:"logs_root"`
+}
+
+// buildRunSummary describes a finished run. runID and logsRoot are the
+// values the engine reported when it started (empty when it never did) and
+// are overridden by res when the run returned one. final.json, when the run
+// wrote it, is authoritative for the status and failure reason, so a
+// canceled run reports canceled rather than fail.
+func buildRunSummary(res *engine.Result, runErr error, runID, logsRoot string, duration time.Duration) runSummary {
+	s := runSummary{
+		RunID:      runID,
+		LogsRoot:   logsRoot,
+		Status:     string(runtime.FinalFail),
+		DurationMS: duration.Milliseconds(),
+	}
+	if res != nil {
+		if res.RunID != "" {
+			s.RunID = res.RunID
+		}
+		if res.LogsRoot != "" {
+			s.LogsRoot = res.LogsRoot
+		}
+		if res.FinalStatus != "" {
+			s.Status = string(res.FinalStatus)
+		}
+	}
+	if runErr != nil {
+		s.FailureReason = runErr.Error()
+	}
+	if s.LogsRoot != "" {
+		if b, err := os.ReadFile(filepath.Join(s.LogsRoot, "final.json")); err == nil {
+			var final runtime.FinalOutcome
+			if json.Unmarshal(b, &final) == nil && final.Status != "" {
+				s.Status = string(final.Status)
+				if reason := strings.TrimSpace(final.FailureReason); reason != "" {
+					s.FailureReason = reason
+				}
+			}
+		}
+	}
+	if s.Status == string(runtime.FinalSuccess) {
+		s.FailureReason = ""
+	}
+	return s
+}
+
+// writeRunSummary writes s as one JSON line in a single write. os.Stdout is
+// unbuffered, so the line is out before the process exits.
+func writeRunSummary(w io.Writer, s runSummary) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
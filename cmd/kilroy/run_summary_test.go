@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/engine"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestBuildRunSummary_PrefersFinalJSON(t *testing.T) {
+	logsRoot := t.TempDir()
+	final := runtime.FinalOutcome{Status: runtime.FinalCanceled, RunID: "r1", FailureReason: "canceled by signal"}
+	if err := final.Save(filepath.Join(logsRoot, "final.json")); err != nil {
+		t.Fatal(err)
+	}
+	got := buildRunSummary(nil, errors.New("context canceled"), "r1", logsRoot, 1500*time.Millisecond)
+	want := runSummary{RunID: "r1", Status: "canceled", DurationMS: 1500, FailureReason: "canceled by signal", LogsRoot: logsRoot}
+	if got != want {
+		t.Fatalf("summary = %+v, want %+v", got, want)
+	}
+}
+
+func TestBuildRunSummary_NoEngine(t *testing.T) {
+	got := buildRunSummary(nil, errors.New("preflight: provider openai unavailable"), "r2", "", time.Second)
+	if got.Status != "fail" || got.RunID != "r2" || got.FailureReason != "preflight: provider openai unavailable" || got.LogsRoot != "" {
+		t.Fatalf("summary = %+v", got)
+	}
+	res := &engine.Result{RunID: "r3", LogsRoot: t.TempDir(), FinalStatus: runtime.FinalSuccess}
+	if got := buildRunSummary(res, nil, "", "", 0); got.Status != "success" || got.RunID != "r3" || got.FailureReason != "" {
+		t.Fatalf("summary = %+v", got)
+	}
+}
+
+func TestAttractorRun_SummaryJSONIsLastStdoutLine(t *testing.T) {
+	cxdbSrv := newCXDBTestServer(t)
+	bin := buildKilroyBinary(t)
+	repo := initTestRepo(t)
+	catalog := writePinnedCatalog(t)
+	cfg := writeRunConfig(t, repo, cxdbSrv.URL(), cxdbSrv.BinaryAddr(), catalog)
+
+	for _, tc := range []struct {
+		name       string
+		graph      string
+		wantStatus string
+	}{
+		{"success", `digraph G { start [shape=Mdiamond]; exit [shape=Msquare]; start -> exit }`, "success"},
+		{"fail", `digraph G {
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  t [shape=parallelogram, tool_command="exit 1"]
+  start -> t
+}`, "fail"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			graph := filepath.Join(t.TempDir(), tc.name+".dot")
+			_ = os.WriteFile(graph, []byte(tc.graph), 0o644)
+			logsRoot := filepath.Join(t.TempDir(), "logs")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+			defer cancel()
+			cmd := exec.CommandContext(ctx, bin, "attractor", "run", "--graph", graph, "--config", cfg,
+				"--run-id", "summary-"+tc.name, "--logs-root", logsRoot, "--summary-json")
+			var stdout, stderr bytes.Buffer
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+			_ = cmd.Run()
+
+			lines := strings.Split(strings.TrimRight(stdout.String(), "\n"), "\n")
+			var s runSummary
+			if err := json.Unmarshal([]byte(lines[len(lines)-1]), &s); err != nil {
+				t.Fatalf("last stdout line is not a summary: %v\nstdout:\n%s\nstderr:\n%s", err, stdout.String(), stderr.String())
+			}
+			if s.Status != tc.wantStatus || s.RunID != "summary-"+tc.name || s.LogsRoot != logsRoot {
+				t.Fatalf("summary = %+v", s)
+			}
+			if tc.wantStatus == "fail" && s.FailureReason == "" {
+				t.Fatalf("failed run summary has no failure reason: %+v", s)
+			}
+			if strings.Contains(stderr.String(), `"run_id"`) {
+				t.Fatalf("summary leaked to stderr:\n%s", stderr.String())
+			}
+		})
+	}
+}
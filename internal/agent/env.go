@@ -1,6 +1,15 @@
 package agent
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
+
+// ErrCommandTimeout is the error ExecCommand returns when its timeoutMS
+// elapses. The ExecResult returned with it is still meaningful: TimedOut is
+// set, ExitCode is 124, and Stdout/Stderr hold whatever the command wrote
+// before its process group was killed. It wraps context.DeadlineExceeded.
+var ErrCommandTimeout = fmt.Errorf("command timed out: %w", context.DeadlineExceeded)
 
 type ExecResult struct {
 	Stdout     string `json:"stdout"`
@@ -27,9 +36,14 @@ type ExecutionEnvironment interface {
 	EditFile(path string, oldString string, newString string, replaceAll bool) (string, error)
 	FileExists(path string) bool
 
-	Glob(pattern string, basePath string) ([]string, error)
+	// Glob returns at most maxResults matches (unlimited when <= 0) and
+	// reports whether more were found.
+	Glob(pattern string, basePath string, maxResults int) ([]string, bool, error)
 	Grep(pattern string, path string, globFilter string, caseInsensitive bool, maxResults int) (string, error)
 	ListDirectory(path string, depth int) ([]DirEntry, error)
 
+	// ExecCommand runs command through the shell. When timeoutMS elapses it
+	// returns ErrCommandTimeout together with the partial output; when ctx is
+	// done first it returns ctx.Err(), likewise with TimedOut set.
 	ExecCommand(ctx context.Context, command string, timeoutMS int, workingDir string, envVars map[string]string) (ExecResult, error)
 }
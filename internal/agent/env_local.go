@@ -3,13 +3,23 @@ package agent
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
@@ -19,6 +29,22 @@ type LocalExecutionEnvironment struct {
 	RootDir      string
 	BaseEnv      map[string]string
 	StripEnvKeys []string
+	// AllowEnvKeys lets the listed keys through even when their names look
+	// like secrets (see IsSensitiveEnvKey), whether they come from
+	// BaseEnv/envVars or the inherited process environment.
+	AllowEnvKeys []string
+	// DenyEnvPatterns are case-insensitive name substrings that mark an
+	// environment variable as a secret to drop. Nil means
+	// DefaultDenyEnvPatterns; append to that slice to add patterns rather than
+	// replace them.
+	DenyEnvPatterns []string
+
+	// Shell is the interpreter prefix ExecCommand runs commands with; the
+	// command string is appended as the final argument (e.g. ["bash", "-lc"]).
+	// When empty, bash -lc is used if bash is on PATH, falling back to sh -c
+	// (cmd /c on Windows, where a cmd shell receives the command verbatim as
+	// cmd /s /c "<command>" instead).
+	Shell []string
 }
 
 func NewLocalExecutionEnvironmentWithPolicy(rootDir string, baseEnv map[string]string, stripKeys []string) *LocalExecutionEnvironment {
@@ -61,7 +87,40 @@ func (e *LocalExecutionEnvironment) Platform() string {
 	}
 }
 
-func (e *LocalExecutionEnvironment) OSVersion() string { return runtime.GOOS + "/" + runtime.GOARCH }
+// OSVersion describes the host OS release, e.g. "Ubuntu 22.04.4 LTS
+// (linux/amd64)". It is detected once per process and falls back to
+// GOOS/GOARCH when the release cannot be determined.
+func (e *LocalExecutionEnvironment) OSVersion() string { return localOSVersion() }
+
+var localOSVersion = sync.OnceValue(func() string {
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	if v := strings.TrimSpace(detectOSVersion()); v != "" {
+		return v + " (" + platform + ")"
+	}
+	return platform
+})
+
+// osReleaseName returns the release name from /etc/os-release content:
+// PRETTY_NAME, else NAME and VERSION_ID.
+func osReleaseName(content string) string {
+	fields := map[string]string{}
+	for _, line := range strings.Split(content, "\n") {
+		k, v, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok || strings.HasPrefix(k, "#") {
+			continue
+		}
+		if uq, err := strconv.Unquote(v); err == nil {
+			v = uq
+		} else {
+			v = strings.Trim(v, `"'`)
+		}
+		fields[k] = strings.TrimSpace(v)
+	}
+	if v := fields["PRETTY_NAME"]; v != "" {
+		return v
+	}
+	return strings.TrimSpace(fields["NAME"] + " " + fields["VERSION_ID"])
+}
 
 func (e *LocalExecutionEnvironment) ReadFile(path string, offsetLine *int, limitLines *int) (string, error) {
 	abs := e.resolve(path)
@@ -77,19 +136,30 @@ func (e *LocalExecutionEnvironment) ReadFile(path string, offsetLine *int, limit
 	lines := strings.Split(s, "\n")
 
 	start := 1
+	last := len(lines)
 	if offsetLine != nil && *offsetLine > 0 {
 		start = *offsetLine
+	} else if offsetLine != nil && *offsetLine < 0 {
+		// Tail mode: -N starts N lines before the end. A trailing newline does
+		// not count as an extra (empty) last line.
+		if last > 1 && lines[last-1] == "" {
+			last--
+		}
+		start = last + *offsetLine + 1
+		if start < 1 {
+			start = 1
+		}
 	}
 	limit := 2000
 	if limitLines != nil && *limitLines > 0 {
 		limit = *limitLines
 	}
-	if start > len(lines) {
+	if start > last {
 		return "", nil
 	}
 	end := start - 1 + limit
-	if end > len(lines) {
-		end = len(lines)
+	if end > last {
+		end = last
 	}
 	var out strings.Builder
 	for i := start; i <= end; i++ {
@@ -98,17 +168,102 @@ func (e *LocalExecutionEnvironment) ReadFile(path string, offsetLine *int, limit
 	return out.String(), nil
 }
 
+// ReadFiles reads each path with ReadFile's line window, numbering, and binary
+// detection. Results and errors are keyed by the path as given; each file is
+// numbered independently.
+func (e *LocalExecutionEnvironment) ReadFiles(paths []string, offsetLine *int, limitLines *int) (map[string]string, map[string]error) {
+	out := map[string]string{}
+	errs := map[string]error{}
+	for _, p := range paths {
+		if _, done := out[p]; done {
+			continue
+		}
+		if _, done := errs[p]; done {
+			continue
+		}
+		content, err := e.ReadFile(p, offsetLine, limitLines)
+		if err != nil {
+			errs[p] = err
+			continue
+		}
+		out[p] = content
+	}
+	return out, errs
+}
+
+// maxReadFileBytes bounds a single ReadFileBytes call.
+const maxReadFileBytes = 10 << 20
+
+// ReadFileBytes returns up to length raw bytes starting at byte offset, without
+// line numbering or binary rejection, for inspecting binary files. Reads past
+// EOF return only the bytes that exist.
+func (e *LocalExecutionEnvironment) ReadFileBytes(path string, offset int64, length int64) ([]byte, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("offset must be >= 0, got %d", offset)
+	}
+	if length <= 0 {
+		return nil, fmt.Errorf("length must be > 0, got %d", length)
+	}
+	if length > maxReadFileBytes {
+		return nil, fmt.Errorf("length %d exceeds the %d byte limit per read", length, maxReadFileBytes)
+	}
+	f, err := os.Open(e.resolve(path))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	buf := make([]byte, length)
+	n, err := f.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// FileChecksum returns the hex digest of path's contents using algo ("sha256"
+// or "md5"). The file is streamed through the hasher, so binary files of any
+// size are accepted.
+func (e *LocalExecutionEnvironment) FileChecksum(path string, algo string) (string, error) {
+	var h hash.Hash
+	switch strings.ToLower(strings.TrimSpace(algo)) {
+	case "sha256":
+		h = sha256.New()
+	case "md5":
+		h = md5.New()
+	default:
+		return "", fmt.Errorf("unsupported checksum algorithm %q (want sha256 or md5)", algo)
+	}
+	f, err := os.Open(e.resolve(path))
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteFile writes content to path. Overwriting a file whose line breaks are
+// all CRLF keeps them CRLF when content has only LF line breaks, as a
+// rewrite of ReadFile's (LF) view of it would.
 func (e *LocalExecutionEnvironment) WriteFile(path string, content string) (string, error) {
 	abs := e.resolve(path)
 	if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
 		return "", err
 	}
+	if prev, err := os.ReadFile(abs); err == nil && usesCRLF(prev) && !strings.Contains(content, "\r\n") {
+		content = strings.ReplaceAll(content, "\n", "\r\n")
+	}
 	if err := os.WriteFile(abs, []byte(content), 0o644); err != nil {
 		return "", err
 	}
 	return fmt.Sprintf("wrote %d bytes to %s", len(content), path), nil
 }
 
+// EditFile replaces oldString with newString in path. A file whose line
+// breaks are all CRLF is edited in ReadFile's LF view and written back with
+// CRLF, so strings copied from ReadFile output match either way.
 func (e *LocalExecutionEnvironment) EditFile(path string, oldString string, newString string, replaceAll bool) (string, error) {
 	abs := e.resolve(path)
 	b, err := os.ReadFile(abs)
@@ -116,6 +271,12 @@ func (e *LocalExecutionEnvironment) EditFile(path string, oldString string, newS
 		return "", err
 	}
 	s := string(b)
+	crlf := usesCRLF(b)
+	if crlf {
+		s = strings.ReplaceAll(s, "\r\n", "\n")
+		oldString = strings.ReplaceAll(oldString, "\r\n", "\n")
+		newString = strings.ReplaceAll(newString, "\r\n", "\n")
+	}
 	if !strings.Contains(s, oldString) {
 		return "", fmt.Errorf("old_string not found in %s", path)
 	}
@@ -129,18 +290,132 @@ func (e *LocalExecutionEnvironment) EditFile(path string, oldString string, newS
 		s = strings.Replace(s, oldString, newString, 1)
 		n = 1
 	}
+	if crlf {
+		s = strings.ReplaceAll(s, "\n", "\r\n")
+	}
 	if err := os.WriteFile(abs, []byte(s), 0o644); err != nil {
 		return "", err
 	}
 	return fmt.Sprintf("edited %s: %d replacement(s)", path, n), nil
 }
 
+// usesCRLF reports whether b is text with at least one line break and every
+// line break is CRLF. Files with mixed line breaks are edited as they are.
+func usesCRLF(b []byte) bool {
+	if bytes.IndexByte(b, 0) >= 0 {
+		return false
+	}
+	n := bytes.Count(b, []byte("\n"))
+	return n > 0 && bytes.Count(b, []byte("\r\n")) == n
+}
+
+// MoveFile renames oldPath to newPath, creating parent directories as needed.
+// Like os.Rename, an existing file at newPath is replaced. Files that cannot be
+// renamed across devices are copied and the source removed.
+func (e *LocalExecutionEnvironment) MoveFile(oldPath string, newPath string) (string, error) {
+	if strings.TrimSpace(oldPath) == "" || strings.TrimSpace(newPath) == "" {
+		return "", fmt.Errorf("move requires both a source and a destination path")
+	}
+	src := e.resolve(oldPath)
+	dst := e.resolve(newPath)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		if !errors.Is(err, syscall.EXDEV) {
+			return "", err
+		}
+		if err := copyRegularFile(src, dst); err != nil {
+			return "", err
+		}
+		if err := os.Remove(src); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("moved %s to %s", oldPath, newPath), nil
+}
+
+// DeleteFile removes a file or an empty directory.
+func (e *LocalExecutionEnvironment) DeleteFile(path string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", fmt.Errorf("delete requires a path")
+	}
+	abs := e.resolve(path)
+	if filepath.Clean(abs) == filepath.Clean(e.RootDir) {
+		return "", fmt.Errorf("refusing to delete the working directory")
+	}
+	if err := os.Remove(abs); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("deleted %s", path), nil
+}
+
+func copyRegularFile(src string, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("cannot move %s across devices: not a regular file", src)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
 func (e *LocalExecutionEnvironment) FileExists(path string) bool {
 	_, err := os.Stat(e.resolve(path))
 	return err == nil
 }
 
 func (e *LocalExecutionEnvironment) ListDirectory(path string, depth int) ([]DirEntry, error) {
+	return e.ListDirectoryFiltered(path, depth, DirFilter{})
+}
+
+// DirFilter narrows ListDirectoryFiltered. Patterns are doublestar globs
+// matched against each entry's slash-separated name relative to the listed
+// directory (for example "**/*.go" or "vendor").
+type DirFilter struct {
+	// Include keeps only entries matching at least one pattern; empty keeps
+	// all. Directories are still descended into when they do not match.
+	Include []string
+	// Exclude drops matching entries; excluded directories are not descended
+	// into.
+	Exclude []string
+	// OmitDirs and OmitFiles drop directory or non-directory entries from the
+	// result. Directories are still descended into when omitted.
+	OmitDirs  bool
+	OmitFiles bool
+}
+
+func matchAnyGlob(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := doublestar.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ListDirectoryFiltered is ListDirectory with include/exclude globs and
+// entry-type filtering applied during the walk.
+func (e *LocalExecutionEnvironment) ListDirectoryFiltered(path string, depth int, filter DirFilter) ([]DirEntry, error) {
+	for _, p := range append(append([]string{}, filter.Include...), filter.Exclude...) {
+		if !doublestar.ValidatePattern(p) {
+			return nil, fmt.Errorf("invalid glob pattern %q", p)
+		}
+	}
 	if depth <= 0 {
 		depth = 1
 	}
@@ -160,13 +435,23 @@ func (e *LocalExecutionEnvironment) ListDirectory(path string, depth int) ([]Dir
 			if relPrefix != "" {
 				relName = filepath.Join(relPrefix, name)
 			}
-			de := DirEntry{Name: relName, IsDir: ent.IsDir()}
-			if !ent.IsDir() {
-				if info, err := ent.Info(); err == nil {
-					de.Size = info.Size()
+			slashName := filepath.ToSlash(relName)
+			if matchAnyGlob(filter.Exclude, slashName) {
+				continue
+			}
+			keep := len(filter.Include) == 0 || matchAnyGlob(filter.Include, slashName)
+			if ent.IsDir() && filter.OmitDirs || !ent.IsDir() && filter.OmitFiles {
+				keep = false
+			}
+			if keep {
+				de := DirEntry{Name: relName, IsDir: ent.IsDir()}
+				if !ent.IsDir() {
+					if info, err := ent.Info(); err == nil {
+						de.Size = info.Size()
+					}
 				}
+				out = append(out, de)
 			}
-			out = append(out, de)
 			if ent.IsDir() && d > 1 {
 				if err := walk(filepath.Join(absDir, name), relName, d-1); err != nil {
 					return err
@@ -182,7 +467,14 @@ func (e *LocalExecutionEnvironment) ListDirectory(path string, depth int) ([]Dir
 	return out, nil
 }
 
-func (e *LocalExecutionEnvironment) Glob(pattern string, basePath string) ([]string, error) {
+// errGlobCapReached stops a glob walk once maxResults+1 matches are collected.
+var errGlobCapReached = errors.New("glob result cap reached")
+
+// Glob returns the paths under basePath matching pattern, most recently
+// modified first, then lexically. When maxResults > 0 the walk stops after
+// maxResults matches and truncated reports whether more existed; the ordering
+// then applies to the collected subset only. maxResults <= 0 means unlimited.
+func (e *LocalExecutionEnvironment) Glob(pattern string, basePath string, maxResults int) ([]string, bool, error) {
 	base := strings.TrimSpace(basePath)
 	if base == "" {
 		base = e.RootDir
@@ -190,33 +482,59 @@ func (e *LocalExecutionEnvironment) Glob(pattern string, basePath string) ([]str
 	if !filepath.IsAbs(base) {
 		base = filepath.Join(e.RootDir, base)
 	}
-	matches, err := doublestar.Glob(os.DirFS(base), pattern)
-	if err != nil {
-		return nil, err
+	var matches []string
+	truncated := false
+	err := doublestar.GlobWalk(os.DirFS(base), pattern, func(path string, _ fs.DirEntry) error {
+		if maxResults > 0 && len(matches) == maxResults {
+			truncated = true
+			return errGlobCapReached
+		}
+		matches = append(matches, path)
+		return nil
+	})
+	if err != nil && !errors.Is(err, errGlobCapReached) {
+		return nil, false, err
 	}
-	abs := make([]string, 0, len(matches))
+	// Stat each match once up front; the comparator runs O(n log n) times.
+	type globMatch struct {
+		path    string
+		modTime time.Time
+		statOK  bool
+	}
+	sorted := make([]globMatch, 0, len(matches))
 	for _, m := range matches {
-		abs = append(abs, filepath.Join(base, m))
+		gm := globMatch{path: filepath.Join(base, m)}
+		if fi, err := os.Stat(gm.path); err == nil {
+			gm.modTime, gm.statOK = fi.ModTime(), true
+		}
+		sorted = append(sorted, gm)
 	}
-	sort.SliceStable(abs, func(i, j int) bool {
-		fi, _ := os.Stat(abs[i])
-		fj, _ := os.Stat(abs[j])
-		if fi == nil || fj == nil {
-			return abs[i] < abs[j]
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if !a.statOK || !b.statOK {
+			return a.path < b.path
 		}
-		if fi.ModTime() != fj.ModTime() {
-			return fi.ModTime().After(fj.ModTime())
+		if !a.modTime.Equal(b.modTime) {
+			return a.modTime.After(b.modTime)
 		}
-		return abs[i] < abs[j]
+		return a.path < b.path
 	})
-	return abs, nil
+	abs := make([]string, 0, len(sorted))
+	for _, gm := range sorted {
+		abs = append(abs, gm.path)
+	}
+	return abs, truncated, nil
 }
 
 func (e *LocalExecutionEnvironment) Grep(pattern string, path string, globFilter string, caseInsensitive bool, maxResults int) (string, error) {
-	rg, err := exec.LookPath("rg")
-	if err != nil {
-		return "", fmt.Errorf("rg not found in PATH")
-	}
+	return e.GrepWithOptions(context.Background(), pattern, path, globFilter, caseInsensitive, maxResults, GrepOptions{})
+}
+
+// GrepWithOptions is Grep bounded by ctx and opts.Timeout, with rg's file
+// filtering overridable through opts. Running out of time is ErrGrepTimeout.
+// Matches come from GrepStructuredWithOptions (rg, or the pure-Go walk without
+// it) and are printed in rg's path:line:text form.
+func (e *LocalExecutionEnvironment) GrepWithOptions(ctx context.Context, pattern string, path string, globFilter string, caseInsensitive bool, maxResults int, opts GrepOptions) (string, error) {
 	dir := strings.TrimSpace(path)
 	if dir == "" {
 		dir = e.RootDir
@@ -224,34 +542,15 @@ func (e *LocalExecutionEnvironment) Grep(pattern string, path string, globFilter
 	if !filepath.IsAbs(dir) {
 		dir = filepath.Join(e.RootDir, dir)
 	}
-
-	args := []string{"--no-heading", "--line-number", "--color", "never"}
-	if caseInsensitive {
-		args = append(args, "-i")
-	}
-	if strings.TrimSpace(globFilter) != "" {
-		args = append(args, "-g", globFilter)
-	}
-	args = append(args, pattern, dir)
-
-	ctx := context.Background()
-	if maxResults <= 0 {
-		maxResults = 100
-	}
-	res, err := e.ExecCommand(ctx, rg+" "+shellEscapeArgs(args...), 10_000, e.RootDir, nil)
-	if err == nil {
-		// Best-effort cap: keep first maxResults lines.
-		lines := strings.Split(res.Stdout, "\n")
-		if len(lines) > maxResults {
-			lines = lines[:maxResults]
-		}
-		return strings.Join(lines, "\n"), nil
-	}
-	// Exit code 1 means "no matches" for rg.
-	if res.ExitCode == 1 {
-		return "", nil
+	// Both rg and the pure-Go walk yield at most maxResults matches, which are
+	// then printed with their context, so context lines and group separators
+	// never count against the cap.
+	matches, err := e.GrepStructuredWithOptions(ctx, pattern, dir, globFilter, caseInsensitive, maxResults, opts)
+	if err != nil {
+		return "", err
 	}
-	return res.Stdout + res.Stderr, err
+	fi, statErr := os.Stat(dir)
+	return formatGrepMatches(matches, statErr != nil || fi.IsDir(), opts.Context), nil
 }
 
 func (e *LocalExecutionEnvironment) ExecCommand(ctx context.Context, command string, timeoutMS int, workingDir string, envVars map[string]string) (ExecResult, error) {
@@ -267,7 +566,8 @@ func (e *LocalExecutionEnvironment) ExecCommand(ctx context.Context, command str
 	}
 
 	start := time.Now()
-	cmd := exec.Command("bash", "-lc", command)
+	shell := e.shell()
+	cmd := shellCommand(shell, command)
 	cmd.Dir = dir
 	setSysProcAttr(cmd)
 	mergedEnv := map[string]string{}
@@ -277,9 +577,11 @@ func (e *LocalExecutionEnvironment) ExecCommand(ctx context.Context, command str
 	for k, v := range envVars {
 		mergedEnv[k] = v
 	}
-	cmd.Env = filteredEnv(mergedEnv, e.StripEnvKeys)
+	cmd.Env = filteredEnv(mergedEnv, e.StripEnvKeys, e.AllowEnvKeys, e.DenyEnvPatterns)
 
-	var stdout, stderr bytes.Buffer
+	// Locked buffers: after a kill, Wait may still not have returned (a
+	// grandchild can hold the pipes open) while the partial output is read.
+	var stdout, stderr lockedBuffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
@@ -300,22 +602,11 @@ func (e *LocalExecutionEnvironment) ExecCommand(ctx context.Context, command str
 		waitErr = err
 	case <-time.After(time.Duration(timeoutMS) * time.Millisecond):
 		timedOut = true
-		waitErr = context.DeadlineExceeded
+		waitErr = ErrCommandTimeout
 	}
 
 	if timedOut {
-		terminateProcessGroup(cmd.Process.Pid)
-		select {
-		case <-done:
-			// exited on SIGTERM
-		case <-time.After(2 * time.Second):
-			killProcessGroup(cmd.Process.Pid)
-			// Best-effort: wait a bit for Wait() to return so we don't leak the goroutine.
-			select {
-			case <-done:
-			case <-time.After(2 * time.Second):
-			}
-		}
+		stopProcessGroup(ctx, cmd.Process.Pid, done)
 	}
 
 	exitCode := 0
@@ -338,6 +629,70 @@ func (e *LocalExecutionEnvironment) ExecCommand(ctx context.Context, command str
 	}, waitErr
 }
 
+// execTerminateGrace is how long a timed-out command gets to exit after
+// SIGTERM before its process group is killed; execKillWait bounds the wait for
+// it to be reaped after SIGKILL.
+const (
+	execTerminateGrace = 2 * time.Second
+	execKillWait       = 2 * time.Second
+)
+
+// stopProcessGroup tears down a timed-out command: SIGTERM, then SIGKILL after
+// execTerminateGrace. Once ctx is done nobody is waiting for a graceful exit,
+// so it escalates to SIGKILL immediately rather than sitting out the grace
+// period; this keeps a canceled run's teardown prompt. done receives the
+// result of cmd.Wait.
+func stopProcessGroup(ctx context.Context, pid int, done <-chan error) {
+	if ctx.Err() == nil {
+		terminateProcessGroup(pid)
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+		case <-time.After(execTerminateGrace):
+		}
+	}
+	killProcessGroup(pid)
+	// Best-effort: wait a bit for Wait() to return so we don't leak the goroutine.
+	select {
+	case <-done:
+	case <-time.After(execKillWait):
+	}
+}
+
+// lockedBuffer is a bytes.Buffer safe for one writer and concurrent readers.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// shell returns a fresh copy of the configured shell prefix, or the platform
+// default when none is configured.
+func (e *LocalExecutionEnvironment) shell() []string {
+	var out []string
+	for _, part := range e.Shell {
+		if strings.TrimSpace(part) != "" {
+			out = append(out, part)
+		}
+	}
+	if len(out) == 0 {
+		return defaultShell()
+	}
+	return out
+}
+
 func (e *LocalExecutionEnvironment) resolve(path string) string {
 	p := strings.TrimSpace(path)
 	if p == "" {
@@ -349,7 +704,34 @@ func (e *LocalExecutionEnvironment) resolve(path string) string {
 	return filepath.Join(e.RootDir, p)
 }
 
-func filteredEnv(extra map[string]string, stripKeys []string) []string {
+// DefaultDenyEnvPatterns are the name substrings that mark an environment
+// variable as a credential when LocalExecutionEnvironment.DenyEnvPatterns is
+// unset.
+var DefaultDenyEnvPatterns = []string{"API_KEY", "SECRET", "TOKEN", "PASSWORD", "CREDENTIAL"}
+
+// IsSensitiveEnvKey reports whether an environment variable name looks like it
+// holds a credential. Such keys are dropped from tool environments unless
+// explicitly allowed.
+func IsSensitiveEnvKey(k string) bool {
+	return matchesEnvPattern(k, DefaultDenyEnvPatterns)
+}
+
+func matchesEnvPattern(k string, patterns []string) bool {
+	uk := strings.ToUpper(k)
+	for _, p := range patterns {
+		if p = strings.ToUpper(strings.TrimSpace(p)); p != "" && strings.Contains(uk, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// filteredEnv builds a tool environment from the inherited process environment
+// plus extra. Keys in stripKeys are always removed. Keys matching denyPatterns
+// (DefaultDenyEnvPatterns when nil) are removed too, except keys listed in
+// allowKeys, whether inherited or in extra. The result is sorted by key, with extra values replacing
+// inherited ones, so the same inputs always give the same slice.
+func filteredEnv(extra map[string]string, stripKeys []string, allowKeys []string, denyPatterns []string) []string {
 	stripped := map[string]bool{}
 	for _, k := range stripKeys {
 		k = strings.TrimSpace(k)
@@ -365,72 +747,68 @@ func filteredEnv(extra map[string]string, stripKeys []string) []string {
 		}
 		return stripped[strings.ToUpper(k)]
 	}
-	deny := func(k string) bool {
-		uk := strings.ToUpper(k)
-		if strings.Contains(uk, "API_KEY") || strings.Contains(uk, "SECRET") || strings.Contains(uk, "TOKEN") || strings.Contains(uk, "PASSWORD") || strings.Contains(uk, "CREDENTIAL") {
-			return true
-		}
-		return false
+	if denyPatterns == nil {
+		denyPatterns = DefaultDenyEnvPatterns
+	}
+	deny := func(k string) bool { return matchesEnvPattern(k, denyPatterns) }
+	explicit := map[string]bool{}
+	for _, k := range allowKeys {
+		explicit[strings.TrimSpace(k)] = true
 	}
-	allow := map[string]bool{
-		"PATH":       true,
-		"HOME":       true,
-		"USER":       true,
-		"SHELL":      true,
-		"LANG":       true,
-		"TERM":       true,
-		"TMPDIR":     true,
-		"GOPATH":     true,
-		"GOMODCACHE": true,
-	}
-	out := []string{}
+	byKey := map[string]string{}
 	for _, kv := range os.Environ() {
 		k, _, ok := strings.Cut(kv, "=")
 		if !ok {
 			continue
 		}
-		if isStripped(k) {
-			continue
-		}
-		if allow[k] && !deny(k) {
-			out = append(out, kv)
-			continue
-		}
-		if deny(k) {
+		if isStripped(k) || deny(k) && !explicit[k] {
 			continue
 		}
 		// Keep non-sensitive env vars by default.
-		out = append(out, kv)
+		byKey[k] = kv
 	}
 	for k, v := range extra {
 		if isStripped(k) {
 			continue
 		}
-		if deny(k) {
+		if deny(k) && !explicit[k] {
 			continue
 		}
-		out = append(out, k+"="+v)
+		byKey[k] = k + "=" + v
+	}
+	keys := make([]string, 0, len(byKey))
+	for k := range byKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, byKey[k])
 	}
 	return out
 }
 
+// shellEscapeArgs joins args into one command line for the platform shell.
 func shellEscapeArgs(args ...string) string {
 	var b strings.Builder
 	for i, a := range args {
 		if i > 0 {
 			b.WriteByte(' ')
 		}
-		b.WriteString(shellEscape(a))
+		b.WriteString(shellQuote(a))
 	}
 	return b.String()
 }
 
-func shellEscape(s string) string {
+// ShellEscape quotes s as a single bash word. Strings with no characters the
+// shell would interpret are returned unchanged.
+func ShellEscape(s string) string {
 	if s == "" {
 		return "''"
 	}
 	if strings.IndexFunc(s, func(r rune) bool {
-		return r == ' ' || r == '\t' || r == '\n' || r == '"' || r == '\'' || r == '\\' || r == '$' || r == '`' || r == '!' || r == '(' || r == ')' || r == ';' || r == '|' || r == '&' || r == '<' || r == '>' || r == '*'
+		return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '"' || r == '\'' || r == '\\' || r == '$' || r == '`' || r == '!' || r == '(' || r == ')' || r == ';' || r == '|' || r == '&' || r == '<' || r == '>' || r == '*' ||
+			r == '?' || r == '[' || r == ']' || r == '{' || r == '}' || r == '~' || r == '#'
 	}) == -1 {
 		return s
 	}
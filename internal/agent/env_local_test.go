@@ -1,8 +1,16 @@
 package agent
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -31,6 +39,26 @@ func TestLocalExecutionEnvironment_ExecCommand_TimesOutAndKillsProcessGroup(t *t
 	}
 }
 
+func TestLocalExecutionEnvironment_ExecCommand_TimeoutKeepsPartialOutput(t *testing.T) {
+	env := NewLocalExecutionEnvironment(t.TempDir())
+	// A plain shell keeps login-profile startup time out of the timeout.
+	env.Shell = []string{"sh", "-c"}
+	res, err := env.ExecCommand(context.Background(), "echo partial-out; echo partial-err >&2; sleep 30", 500, "", nil)
+
+	if !errors.Is(err, ErrCommandTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err: got %v want ErrCommandTimeout", err)
+	}
+	if !res.TimedOut || res.ExitCode != 124 {
+		t.Fatalf("expected timed_out=true exit_code=124, got %+v", res)
+	}
+	if !strings.Contains(res.Stdout, "partial-out") {
+		t.Fatalf("stdout: got %q want the output written before the timeout", res.Stdout)
+	}
+	if !strings.Contains(res.Stderr, "partial-err") {
+		t.Fatalf("stderr: got %q want the output written before the timeout", res.Stderr)
+	}
+}
+
 func TestLocalExecutionEnvironment_ExecCommand_ContextCancel_KillsProcessGroup(t *testing.T) {
 	env := NewLocalExecutionEnvironment(t.TempDir())
 	ctx, cancel := context.WithCancel(context.Background())
@@ -63,10 +91,64 @@ func TestLocalExecutionEnvironment_ExecCommand_ContextCancel_KillsProcessGroup(t
 	}
 }
 
+func TestLocalExecutionEnvironment_ExecCommand_CanceledContextSkipsTerminateGrace(t *testing.T) {
+	env := NewLocalExecutionEnvironment(t.TempDir())
+	env.Shell = []string{"sh", "-c"}
+	// Ignored signals are inherited, so sleep survives SIGTERM and only
+	// SIGKILL ends the command.
+	const cmd = "trap '' TERM; echo ready; sleep 30"
+
+	t.Run("canceled before timeout", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(300*time.Millisecond, cancel)
+		start := time.Now()
+		res, err := env.ExecCommand(ctx, cmd, 30_000, "", nil)
+		if !errors.Is(err, context.Canceled) || !res.TimedOut {
+			t.Fatalf("err=%v res=%+v", err, res)
+		}
+		if d := time.Since(start); d >= 300*time.Millisecond+execTerminateGrace {
+			t.Fatalf("teardown waited out the SIGTERM grace period; took %s", d)
+		}
+	})
+
+	t.Run("canceled during grace", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(600*time.Millisecond, cancel)
+		start := time.Now()
+		res, err := env.ExecCommand(ctx, cmd, 300, "", nil)
+		if !errors.Is(err, ErrCommandTimeout) || !strings.Contains(res.Stdout, "ready") {
+			t.Fatalf("err=%v res=%+v", err, res)
+		}
+		if d := time.Since(start); d >= 300*time.Millisecond+execTerminateGrace {
+			t.Fatalf("cancel did not cut the SIGTERM grace period short; took %s", d)
+		}
+	})
+}
+
+func TestOSReleaseName(t *testing.T) {
+	cases := map[string]string{
+		"NAME=\"Ubuntu\"\nVERSION_ID=\"22.04\"\nPRETTY_NAME=\"Ubuntu 22.04.4 LTS\"\n": "Ubuntu 22.04.4 LTS",
+		"# comment\nNAME='Alpine Linux'\nVERSION_ID=3.19.1\n":                         "Alpine Linux 3.19.1",
+		"": "",
+	}
+	for in, want := range cases {
+		if got := osReleaseName(in); got != want {
+			t.Fatalf("osReleaseName(%q)=%q want %q", in, got, want)
+		}
+	}
+}
+
+func TestLocalExecutionEnvironment_OSVersion_IncludesPlatform(t *testing.T) {
+	got := NewLocalExecutionEnvironment(t.TempDir()).OSVersion()
+	if !strings.Contains(got, runtime.GOOS+"/"+runtime.GOARCH) {
+		t.Fatalf("OSVersion()=%q want it to include %s/%s", got, runtime.GOOS, runtime.GOARCH)
+	}
+}
+
 func TestFilteredEnv_ExcludesSensitiveVars(t *testing.T) {
 	t.Setenv("MY_API_KEY", "secret")
 	t.Setenv("MY_SECRET", "secret2")
-	env := filteredEnv(nil, nil)
+	env := filteredEnv(nil, nil, nil, nil)
 	for _, kv := range env {
 		if strings.HasPrefix(kv, "MY_API_KEY=") || strings.HasPrefix(kv, "MY_SECRET=") {
 			t.Fatalf("sensitive env var leaked: %q", kv)
@@ -84,6 +166,72 @@ func TestFilteredEnv_ExcludesSensitiveVars(t *testing.T) {
 	}
 }
 
+func TestFilteredEnv_AllowKeysPassExplicitSensitiveExtras(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "from-parent")
+	t.Setenv("NPM_TOKEN", "not-listed")
+	env := filteredEnv(map[string]string{"DEPLOY_TOKEN": "d", "OTHER_SECRET": "o"}, nil, []string{"DEPLOY_TOKEN", "VAULT_TOKEN"}, nil)
+	joined := "\n" + strings.Join(env, "\n") + "\n"
+	if !strings.Contains(joined, "\nDEPLOY_TOKEN=d\n") {
+		t.Fatalf("explicitly allowed extra was stripped: %v", env)
+	}
+	if !strings.Contains(joined, "\nVAULT_TOKEN=from-parent\n") {
+		t.Fatalf("explicitly allowed inherited var was stripped: %v", env)
+	}
+	if strings.Contains(joined, "OTHER_SECRET=") || strings.Contains(joined, "NPM_TOKEN=") {
+		t.Fatalf("sensitive var leaked: %v", env)
+	}
+}
+
+func TestFilteredEnv_CustomDenyPatterns(t *testing.T) {
+	t.Setenv("CORP_VAULT_ADDR", "v")
+	t.Setenv("BUILD_TOKEN", "b")
+	t.Setenv("DOCKER_HOST", "unix:///var/run/docker.sock")
+	patterns := append(append([]string{}, DefaultDenyEnvPatterns...), "vault")
+	env := filteredEnv(map[string]string{"VAULT_ROLE": "r"}, nil, nil, patterns)
+	joined := "\n" + strings.Join(env, "\n") + "\n"
+	if strings.Contains(joined, "CORP_VAULT_ADDR=") || strings.Contains(joined, "VAULT_ROLE=") {
+		t.Fatalf("custom deny pattern not applied: %v", env)
+	}
+	if strings.Contains(joined, "BUILD_TOKEN=") {
+		t.Fatalf("default pattern dropped when extending: %v", env)
+	}
+	if !strings.Contains(joined, "\nDOCKER_HOST=unix:///var/run/docker.sock\n") {
+		t.Fatalf("non-sensitive var was stripped: %v", env)
+	}
+
+	env = filteredEnv(nil, nil, nil, []string{"VAULT"})
+	joined = "\n" + strings.Join(env, "\n") + "\n"
+	if !strings.Contains(joined, "\nBUILD_TOKEN=b\n") {
+		t.Fatalf("replacing the patterns should drop the defaults: %v", env)
+	}
+}
+
+func TestFilteredEnv_SortedAndStableAcrossCalls(t *testing.T) {
+	t.Setenv("KILROY_TEST_SHARED", "inherited")
+	extra := map[string]string{"ZZ_LAST": "z", "AA_FIRST": "a", "KILROY_TEST_SHARED": "extra", "MM_MIDDLE": "m"}
+	first := filteredEnv(extra, []string{"HOME"}, nil, nil)
+	for i := 0; i < 20; i++ {
+		if got := filteredEnv(extra, []string{"HOME"}, nil, nil); !reflect.DeepEqual(got, first) {
+			t.Fatalf("call %d returned a different env:\n%v\nwant\n%v", i, got, first)
+		}
+	}
+	keys := make([]string, 0, len(first))
+	for _, kv := range first {
+		k, _, _ := strings.Cut(kv, "=")
+		keys = append(keys, k)
+	}
+	if !sort.StringsAreSorted(keys) {
+		t.Fatalf("env keys not sorted: %v", keys)
+	}
+	joined := "\n" + strings.Join(first, "\n") + "\n"
+	if strings.Count(joined, "\nKILROY_TEST_SHARED=") != 1 || !strings.Contains(joined, "\nKILROY_TEST_SHARED=extra\n") {
+		t.Fatalf("extra should replace the inherited value exactly once: %v", first)
+	}
+	if strings.Contains(joined, "\nHOME=") {
+		t.Fatalf("stripped key present: %v", first)
+	}
+}
+
 func TestLocalExecutionEnvironment_ReadWriteEditFile(t *testing.T) {
 	dir := t.TempDir()
 	env := NewLocalExecutionEnvironment(dir)
@@ -106,6 +254,349 @@ func TestLocalExecutionEnvironment_ReadWriteEditFile(t *testing.T) {
 	}
 }
 
+func TestLocalExecutionEnvironment_EditFile_CRLFFileMatchesReadFileView(t *testing.T) {
+	dir := t.TempDir()
+	env := NewLocalExecutionEnvironment(dir)
+	path := filepath.Join(dir, "win.txt")
+	if err := os.WriteFile(path, []byte("one\r\ntwo\r\nthree\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	view, err := env.ReadFile("win.txt", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(view, "\r") {
+		t.Fatalf("ReadFile view kept CR: %q", view)
+	}
+	// An old_string spanning lines, as copied from the LF view.
+	if _, err := env.EditFile("win.txt", "one\ntwo", "uno\ndos\nextra", false); err != nil {
+		t.Fatalf("EditFile: %v", err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "uno\r\ndos\r\nextra\r\nthree\r\n" {
+		t.Fatalf("after edit: %q", b)
+	}
+	if _, err := env.WriteFile("win.txt", "rewritten\nfile\n"); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "rewritten\r\nfile\r\n" {
+		t.Fatalf("after write: %q", b)
+	}
+
+	// LF and mixed files are edited and written byte for byte.
+	mixed := filepath.Join(dir, "mixed.txt")
+	if err := os.WriteFile(mixed, []byte("a\r\nb\nc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.EditFile("mixed.txt", "b\nc", "B\nC", false); err != nil {
+		t.Fatalf("EditFile mixed: %v", err)
+	}
+	if b, _ := os.ReadFile(mixed); string(b) != "a\r\nB\nC\n" {
+		t.Fatalf("mixed after edit: %q", b)
+	}
+	if _, err := env.WriteFile("new.txt", "x\ny\n"); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "new.txt")); string(b) != "x\ny\n" {
+		t.Fatalf("new file: %q", b)
+	}
+}
+
+func TestLocalExecutionEnvironment_ReadFile_NegativeOffsetTailsFile(t *testing.T) {
+	dir := t.TempDir()
+	env := NewLocalExecutionEnvironment(dir)
+	var content strings.Builder
+	for i := 1; i <= 10; i++ {
+		content.WriteString(fmt.Sprintf("line%d\n", i))
+	}
+	if _, err := env.WriteFile("log.txt", content.String()); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		offset int
+		limit  int
+		want   string
+	}{
+		{name: "last_three", offset: -3, want: "   8 | line8\n   9 | line9\n  10 | line10\n"},
+		{name: "tail_with_limit", offset: -3, limit: 1, want: "   8 | line8\n"},
+		{name: "beyond_start_clamps", offset: -50, limit: 2, want: "   1 | line1\n   2 | line2\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			offset := tc.offset
+			var limit *int
+			if tc.limit > 0 {
+				limit = &tc.limit
+			}
+			got, err := env.ReadFile("log.txt", &offset, limit)
+			if err != nil {
+				t.Fatalf("ReadFile: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("ReadFile offset=%d:\n got %q\nwant %q", tc.offset, got, tc.want)
+			}
+		})
+	}
+
+	_ = os.WriteFile(filepath.Join(dir, "bin.dat"), []byte("a\x00b\n"), 0o644)
+	offset := -1
+	if _, err := env.ReadFile("bin.dat", &offset, nil); err == nil {
+		t.Fatalf("expected binary file rejection in tail mode")
+	}
+}
+
+func TestLocalExecutionEnvironment_ReadFiles(t *testing.T) {
+	dir := t.TempDir()
+	env := NewLocalExecutionEnvironment(dir)
+	_, _ = env.WriteFile("a.txt", "a1\na2\na3\n")
+	_, _ = env.WriteFile("b.txt", "b1\nb2\n")
+	_ = os.WriteFile(filepath.Join(dir, "bin"), []byte{0x00, 0x01}, 0o644)
+
+	offset, limit := 2, 1
+	got, errs := env.ReadFiles([]string{"a.txt", "b.txt", "bin", "missing.txt"}, &offset, &limit)
+	if got["a.txt"] != "   2 | a2\n" || got["b.txt"] != "   2 | b2\n" {
+		t.Fatalf("contents=%q", got)
+	}
+	if len(errs) != 2 || errs["bin"] == nil || errs["missing.txt"] == nil {
+		t.Fatalf("errs=%v want errors for bin and missing.txt", errs)
+	}
+	if _, ok := got["bin"]; ok {
+		t.Fatal("binary file should not have content")
+	}
+}
+
+func TestLocalExecutionEnvironment_FileChecksum(t *testing.T) {
+	dir := t.TempDir()
+	env := NewLocalExecutionEnvironment(dir)
+	_ = os.WriteFile(filepath.Join(dir, "bin"), []byte("abc\x00"), 0o644)
+
+	got, err := env.FileChecksum("bin", "sha256")
+	if err != nil {
+		t.Fatalf("FileChecksum sha256: %v", err)
+	}
+	if got != "dc1114cd074914bd872cc1f9a23ec910ea2203bc79779ab2e17da25782a624fc" {
+		t.Fatalf("sha256=%q", got)
+	}
+	got, err = env.FileChecksum("bin", "MD5")
+	if err != nil {
+		t.Fatalf("FileChecksum md5: %v", err)
+	}
+	if got != "147a664a2ca9410911e61986d3f0d52a" {
+		t.Fatalf("md5=%q", got)
+	}
+	if _, err := env.FileChecksum("bin", "crc32"); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Fatalf("expected unsupported algorithm error, got %v", err)
+	}
+	if _, err := env.FileChecksum("missing", "sha256"); err == nil {
+		t.Fatal("expected error for a missing file")
+	}
+}
+
+func TestLocalExecutionEnvironment_ReadFileBytes(t *testing.T) {
+	dir := t.TempDir()
+	env := NewLocalExecutionEnvironment(dir)
+	data := []byte{0x7f, 'E', 'L', 'F', 0x00, 0x01, 0x02, 0x03}
+	if err := os.WriteFile(filepath.Join(dir, "bin"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := env.ReadFileBytes("bin", 0, 4)
+	if err != nil {
+		t.Fatalf("ReadFileBytes: %v", err)
+	}
+	if !bytes.Equal(got, data[:4]) {
+		t.Fatalf("header: got %x want %x", got, data[:4])
+	}
+	got, err = env.ReadFileBytes("bin", 6, 100)
+	if err != nil {
+		t.Fatalf("ReadFileBytes past EOF: %v", err)
+	}
+	if !bytes.Equal(got, data[6:]) {
+		t.Fatalf("tail: got %x want %x", got, data[6:])
+	}
+	if _, err := env.ReadFileBytes("bin", 0, maxReadFileBytes+1); err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Fatalf("expected length limit error, got %v", err)
+	}
+	if _, err := env.ReadFileBytes("bin", -1, 4); err == nil {
+		t.Fatal("expected error for negative offset")
+	}
+}
+
+func TestLocalExecutionEnvironment_MoveAndDeleteFile(t *testing.T) {
+	dir := t.TempDir()
+	env := NewLocalExecutionEnvironment(dir)
+	if _, err := env.WriteFile("a.txt", "hello\n"); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	msg, err := env.MoveFile("a.txt", "sub/b.txt")
+	if err != nil {
+		t.Fatalf("MoveFile: %v", err)
+	}
+	if msg != "moved a.txt to sub/b.txt" {
+		t.Fatalf("MoveFile message: %q", msg)
+	}
+	if env.FileExists("a.txt") {
+		t.Fatal("source should be gone after move")
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "sub", "b.txt")); string(b) != "hello\n" {
+		t.Fatalf("moved content: %q", string(b))
+	}
+
+	if err := copyRegularFile(filepath.Join(dir, "sub", "b.txt"), filepath.Join(dir, "c.txt")); err != nil {
+		t.Fatalf("copyRegularFile: %v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "c.txt")); string(b) != "hello\n" {
+		t.Fatalf("copied content: %q", string(b))
+	}
+
+	msg, err = env.DeleteFile("sub/b.txt")
+	if err != nil {
+		t.Fatalf("DeleteFile: %v", err)
+	}
+	if msg != "deleted sub/b.txt" || env.FileExists("sub/b.txt") {
+		t.Fatalf("DeleteFile: msg=%q exists=%v", msg, env.FileExists("sub/b.txt"))
+	}
+	if _, err := env.DeleteFile(""); err == nil {
+		t.Fatal("expected error deleting the working directory")
+	}
+	if _, err := env.DeleteFile("missing.txt"); err == nil {
+		t.Fatal("expected error deleting a missing file")
+	}
+}
+
+func TestLocalExecutionEnvironment_Glob_MaxResults(t *testing.T) {
+	dir := t.TempDir()
+	env := NewLocalExecutionEnvironment(dir)
+	now := time.Now()
+	for i, name := range []string{"a.go", "b.go", "c.go", "d.txt"} {
+		if _, err := env.WriteFile(name, "x"); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		mt := now.Add(time.Duration(i) * time.Minute)
+		_ = os.Chtimes(filepath.Join(dir, name), mt, mt)
+	}
+
+	all, truncated, err := env.Glob("*.go", "", 0)
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	if truncated || len(all) != 3 || filepath.Base(all[0]) != "c.go" {
+		t.Fatalf("unlimited glob=%v truncated=%t", all, truncated)
+	}
+
+	got, truncated, err := env.Glob("*.go", "", 2)
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	if !truncated || len(got) != 2 {
+		t.Fatalf("capped glob=%v truncated=%t want 2 results, truncated", got, truncated)
+	}
+	// Newest first within the collected subset (a.go, b.go).
+	if filepath.Base(got[0]) != "b.go" || filepath.Base(got[1]) != "a.go" {
+		t.Fatalf("capped glob order=%v", got)
+	}
+
+	got, truncated, err = env.Glob("*.go", "", 3)
+	if err != nil || truncated || len(got) != 3 {
+		t.Fatalf("exact-cap glob=%v truncated=%t err=%v", got, truncated, err)
+	}
+}
+
+func TestLocalExecutionEnvironment_Glob_EqualModTimesSortLexically(t *testing.T) {
+	dir := t.TempDir()
+	env := NewLocalExecutionEnvironment(dir)
+	older := time.Now().Add(-time.Hour)
+	newer := time.Now()
+	for name, mt := range map[string]time.Time{"b.go": older, "a.go": older, "d.go": newer, "c.go": newer} {
+		if _, err := env.WriteFile(name, "x"); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		_ = os.Chtimes(filepath.Join(dir, name), mt, mt)
+	}
+	got, _, err := env.Glob("*.go", "", 0)
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	var names []string
+	for _, p := range got {
+		names = append(names, filepath.Base(p))
+	}
+	if strings.Join(names, ",") != "c.go,d.go,a.go,b.go" {
+		t.Fatalf("glob order=%v want c.go,d.go,a.go,b.go", names)
+	}
+}
+
+// BenchmarkLocalExecutionEnvironment_Glob sorts a few thousand matches spread
+// over a nested tree with distinct modification times.
+func BenchmarkLocalExecutionEnvironment_Glob(b *testing.B) {
+	dir := b.TempDir()
+	now := time.Now()
+	for i := 0; i < 4000; i++ {
+		sub := filepath.Join(dir, fmt.Sprintf("d%02d", i%40), fmt.Sprintf("e%d", i%7))
+		if err := os.MkdirAll(sub, 0o755); err != nil {
+			b.Fatal(err)
+		}
+		p := filepath.Join(sub, fmt.Sprintf("f%04d.go", i))
+		if err := os.WriteFile(p, []byte("package x\n"), 0o644); err != nil {
+			b.Fatal(err)
+		}
+		mt := now.Add(-time.Duration(i*7919%4000) * time.Second)
+		_ = os.Chtimes(p, mt, mt)
+	}
+	env := NewLocalExecutionEnvironment(dir)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		got, _, err := env.Glob("**/*.go", "", 0)
+		if err != nil || len(got) != 4000 {
+			b.Fatalf("Glob: %d matches, err=%v", len(got), err)
+		}
+	}
+}
+
+func TestLocalExecutionEnvironment_ListDirectoryFiltered(t *testing.T) {
+	dir := t.TempDir()
+	env := NewLocalExecutionEnvironment(dir)
+	for _, p := range []string{"main.go", "README.md", "pkg/a.go", "pkg/a_test.go", "vendor/x/x.go"} {
+		if _, err := env.WriteFile(p, "x"); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	names := func(ents []DirEntry) []string {
+		var out []string
+		for _, e := range ents {
+			out = append(out, filepath.ToSlash(e.Name))
+		}
+		return out
+	}
+
+	got, err := env.ListDirectoryFiltered("", 3, DirFilter{Include: []string{"**/*.go"}, Exclude: []string{"vendor", "**/*_test.go"}})
+	if err != nil {
+		t.Fatalf("ListDirectoryFiltered: %v", err)
+	}
+	if want := []string{"main.go", "pkg/a.go"}; strings.Join(names(got), ",") != strings.Join(want, ",") {
+		t.Fatalf("entries=%v want %v", names(got), want)
+	}
+	for _, e := range got {
+		if e.Size != 1 {
+			t.Fatalf("size not populated: %+v", e)
+		}
+	}
+
+	got, err = env.ListDirectoryFiltered("", 3, DirFilter{OmitFiles: true})
+	if err != nil {
+		t.Fatalf("ListDirectoryFiltered: %v", err)
+	}
+	if want := []string{"pkg", "vendor", "vendor/x"}; strings.Join(names(got), ",") != strings.Join(want, ",") {
+		t.Fatalf("dirs=%v want %v", names(got), want)
+	}
+
+	if _, err := env.ListDirectoryFiltered("", 1, DirFilter{Include: []string{"["}}); err == nil {
+		t.Fatal("expected error for an invalid pattern")
+	}
+}
+
 func TestLocalExecutionEnvironment_ListDirectory_Depth(t *testing.T) {
 	dir := t.TempDir()
 	env := NewLocalExecutionEnvironment(dir)
@@ -188,3 +679,36 @@ func TestLocalExecutionEnvironment_ExecCommand_StripsConfiguredEnvKeys(t *testin
 		t.Fatalf("CLAUDECODE leaked into child process: %q", got)
 	}
 }
+
+func TestLocalExecutionEnvironment_ExecCommand_UsesConfiguredShell(t *testing.T) {
+	env := NewLocalExecutionEnvironment(t.TempDir())
+	env.Shell = []string{"sh", "-c"}
+	res, err := env.ExecCommand(context.Background(), `echo "$0"`, 5000, "", nil)
+	if err != nil {
+		t.Fatalf("ExecCommand: %v (res=%+v)", err, res)
+	}
+	if got := strings.TrimSpace(res.Stdout); got != "sh" {
+		t.Fatalf("expected command to run under sh, $0=%q", got)
+	}
+}
+
+func TestLocalExecutionEnvironment_ExecCommand_FallsBackToShWithoutBash(t *testing.T) {
+	shPath, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	binDir := t.TempDir()
+	if err := os.Symlink(shPath, filepath.Join(binDir, "sh")); err != nil {
+		t.Fatalf("symlink sh: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	env := NewLocalExecutionEnvironment(t.TempDir())
+	res, err := env.ExecCommand(context.Background(), `echo "$0"`, 5000, "", nil)
+	if err != nil {
+		t.Fatalf("ExecCommand: %v (res=%+v)", err, res)
+	}
+	if got := strings.TrimSpace(res.Stdout); got != "sh" {
+		t.Fatalf("expected sh fallback when bash is absent, $0=%q", got)
+	}
+}
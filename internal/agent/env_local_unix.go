@@ -3,7 +3,10 @@
 package agent
 
 import (
+	"os"
 	"os/exec"
+	"runtime"
+	"strings"
 	"syscall"
 )
 
@@ -24,3 +27,46 @@ func killProcessGroup(pid int) {
 	}
 	_ = syscall.Kill(-pid, syscall.SIGKILL)
 }
+
+// defaultShell prefers bash (login shell, so profile PATH tweaks apply) and
+// falls back to POSIX sh on minimal images that do not ship bash.
+func defaultShell() []string {
+	if _, err := exec.LookPath("bash"); err == nil {
+		return []string{"bash", "-lc"}
+	}
+	return []string{"sh", "-c"}
+}
+
+// shellCommand runs command as the final argument of the shell prefix.
+func shellCommand(shell []string, command string) *exec.Cmd {
+	return exec.Command(shell[0], append(shell[1:], command)...)
+}
+
+// shellQuote quotes s as one word for the POSIX shell.
+func shellQuote(s string) string {
+	return ShellEscape(s)
+}
+
+// detectOSVersion reads /etc/os-release on Linux and asks sw_vers on macOS;
+// it returns "" elsewhere or when detection fails.
+func detectOSVersion() string {
+	switch runtime.GOOS {
+	case "linux":
+		for _, p := range []string{"/etc/os-release", "/usr/lib/os-release"} {
+			if b, err := os.ReadFile(p); err == nil {
+				return osReleaseName(string(b))
+			}
+		}
+	case "darwin":
+		name, err := exec.Command("sw_vers", "-productName").Output()
+		if err != nil {
+			return ""
+		}
+		version, err := exec.Command("sw_vers", "-productVersion").Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(strings.TrimSpace(string(name)) + " " + strings.TrimSpace(string(version)))
+	}
+	return ""
+}
@@ -4,11 +4,15 @@ package agent
 
 import (
 	"os/exec"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"syscall"
 )
 
 func setSysProcAttr(cmd *exec.Cmd) {
 	// No process-group setup needed on Windows; taskkill /T handles tree kill.
+	// shellCommand may already have set CmdLine, so leave SysProcAttr alone.
 }
 
 func terminateProcessGroup(pid int) {
@@ -24,3 +28,53 @@ func killProcessGroup(pid int) {
 	}
 	_ = exec.Command("taskkill", "/F", "/T", "/PID", strconv.Itoa(pid)).Run()
 }
+
+func defaultShell() []string {
+	return []string{"cmd", "/c"}
+}
+
+// shellCommand runs command through the shell prefix. cmd.exe does not parse
+// its command line with the argv rules exec.Command quotes for, so for cmd the
+// raw line is passed as `cmd /s /c "<command>"`: /s makes cmd strip exactly
+// the outer quotes and run the rest verbatim. Other shells (PowerShell, Git
+// Bash) get the command as an ordinary argument.
+func shellCommand(shell []string, command string) *exec.Cmd {
+	if !isCmdShell(shell[0]) {
+		return exec.Command(shell[0], append(shell[1:], command)...)
+	}
+	parts := []string{syscall.EscapeArg(shell[0])}
+	hasS := false
+	for _, a := range shell[1:] {
+		if strings.EqualFold(a, "/s") {
+			hasS = true
+		}
+	}
+	if !hasS {
+		parts = append(parts, "/s")
+	}
+	parts = append(parts, shell[1:]...)
+	cmd := exec.Command(shell[0])
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: strings.Join(parts, " ") + ` "` + command + `"`}
+	return cmd
+}
+
+func isCmdShell(exe string) bool {
+	base := strings.ToLower(filepath.Base(exe))
+	return base == "cmd" || base == "cmd.exe"
+}
+
+// shellQuote quotes s as one argument: double quotes with the backslash and
+// quote escaping Windows programs parse their command line with.
+func shellQuote(s string) string {
+	return syscall.EscapeArg(s)
+}
+
+// detectOSVersion returns the `ver` banner, e.g.
+// "Microsoft Windows [Version 10.0.22631.3007]", or "" when it fails.
+func detectOSVersion() string {
+	out, err := exec.Command("cmd", "/c", "ver").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLocalExecutionEnvironment_ExecCommand_CmdPassesQuotedCommandVerbatim(t *testing.T) {
+	env := NewLocalExecutionEnvironment(t.TempDir())
+	res, err := env.ExecCommand(context.Background(), `echo "a b" & echo done`, 10_000, "", nil)
+	if err != nil {
+		t.Fatalf("ExecCommand: %v (res=%+v)", err, res)
+	}
+	if !strings.Contains(res.Stdout, `"a b"`) || !strings.Contains(res.Stdout, "done") {
+		t.Fatalf("stdout: got %q", res.Stdout)
+	}
+}
+
+func TestLocalExecutionEnvironment_ExecCommand_CmdTimeoutKillsTree(t *testing.T) {
+	env := NewLocalExecutionEnvironment(t.TempDir())
+	res, err := env.ExecCommand(context.Background(), "echo started & ping -n 30 127.0.0.1 >nul", 500, "", nil)
+	if !errors.Is(err, ErrCommandTimeout) || !res.TimedOut || res.ExitCode != 124 {
+		t.Fatalf("err=%v res=%+v", err, res)
+	}
+	if !strings.Contains(res.Stdout, "started") {
+		t.Fatalf("stdout: got %q want partial output", res.Stdout)
+	}
+}
@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// gitignoreRule is one pattern line from a .gitignore file in dir.
+type gitignoreRule struct {
+	dir      string
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// gitignoreMatcher evaluates .gitignore files the way rg does by default: only
+// inside a git repository, reading every .gitignore from the repository top
+// down to the file's directory, with later (deeper) rules winning. Global
+// excludes, .git/info/exclude, and .ignore files are not read.
+type gitignoreMatcher struct {
+	top   string
+	rules map[string][]gitignoreRule
+}
+
+// newGitignoreMatcher returns nil when root is not inside a git repository.
+func newGitignoreMatcher(root string) *gitignoreMatcher {
+	dir := root
+	if fi, err := os.Stat(root); err == nil && !fi.IsDir() {
+		dir = filepath.Dir(root)
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return &gitignoreMatcher{top: dir, rules: map[string][]gitignoreRule{}}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+}
+
+// Ignored reports whether path (absolute, below the repository top) is
+// ignored. Callers walking a tree skip ignored directories, so a file below an
+// ignored directory never needs its own rule.
+func (m *gitignoreMatcher) Ignored(path string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	ignored := false
+	for _, r := range m.rulesFor(filepath.Dir(path)) {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		rel, err := filepath.Rel(r.dir, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		target := filepath.ToSlash(rel)
+		if !r.anchored {
+			target = filepath.Base(path)
+		}
+		if ok, _ := doublestar.Match(r.pattern, target); ok {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// rulesFor returns the rules that apply to entries of dir, parents first.
+func (m *gitignoreMatcher) rulesFor(dir string) []gitignoreRule {
+	if rules, ok := m.rules[dir]; ok {
+		return rules
+	}
+	var inherited []gitignoreRule
+	if dir != m.top {
+		if rel, err := filepath.Rel(m.top, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			inherited = m.rulesFor(filepath.Dir(dir))
+		}
+	}
+	own := readGitignore(dir)
+	rules := make([]gitignoreRule, 0, len(inherited)+len(own))
+	rules = append(rules, inherited...)
+	rules = append(rules, own...)
+	m.rules[dir] = rules
+	return rules
+}
+
+func readGitignore(dir string) []gitignoreRule {
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+
+	var rules []gitignoreRule
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r := gitignoreRule{dir: dir}
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		// A separator anywhere but the end anchors the pattern to dir.
+		if strings.Contains(line, "/") {
+			r.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		r.pattern = line
+		rules = append(rules, r)
+	}
+	return rules
+}
@@ -0,0 +1,393 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// GrepMatch is one matching line reported by GrepStructured. Line and Column
+// are 1-based; Column is a byte offset into the line, matching rg --column.
+type GrepMatch struct {
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Text   string `json:"text"`
+}
+
+// GrepOptions relax rg's default filtering and change how the pattern is
+// matched. The zero value matches rg's defaults: a regex matched line by line,
+// with hidden files and directories, files ignored by .gitignore, and binary
+// files (a NUL byte in the first chunk) skipped.
+type GrepOptions struct {
+	Hidden   bool // rg --hidden: search hidden files and directories
+	NoIgnore bool // rg --no-ignore: do not honor .gitignore
+	Text     bool // rg --text: search binary files as text
+
+	FixedString bool // rg -F: match the pattern literally
+	Multiline   bool // rg -U: let matches span lines (\n in the pattern)
+
+	// Context is rg -C: lines shown before and after each match in Grep's
+	// text output. GrepStructured results hold matches only.
+	Context int
+
+	// Timeout bounds the search. Zero means the time left before the
+	// caller's context deadline, or defaultGrepTimeout without one.
+	Timeout time.Duration
+}
+
+// defaultGrepTimeout bounds a search whose caller set neither a context
+// deadline nor GrepOptions.Timeout.
+const defaultGrepTimeout = 10 * time.Second
+
+// ErrGrepTimeout is returned, wrapped with the time allowed, when a search
+// runs out of time. No partial results are returned with it.
+var ErrGrepTimeout = errors.New("grep timed out")
+
+// timeout is how long a search started under ctx may run.
+func (o GrepOptions) timeout(ctx context.Context) time.Duration {
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		return max(time.Until(deadline), time.Millisecond)
+	}
+	return defaultGrepTimeout
+}
+
+// grepTimeoutError turns a search stopped by its deadline into ErrGrepTimeout;
+// other errors, including cancellation, are returned as they are.
+func grepTimeoutError(err error, timeout time.Duration) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s; narrow the path or glob filter", ErrGrepTimeout, timeout)
+	}
+	return err
+}
+
+func (o GrepOptions) rgArgs() []string {
+	var args []string
+	if o.Hidden {
+		args = append(args, "--hidden")
+	}
+	if o.NoIgnore {
+		args = append(args, "--no-ignore")
+	}
+	if o.Text {
+		args = append(args, "--text")
+	}
+	if o.FixedString {
+		args = append(args, "-F")
+	}
+	if o.Multiline {
+		args = append(args, "-U")
+	}
+	if o.Context > 0 {
+		args = append(args, "-C", strconv.Itoa(o.Context))
+	}
+	return args
+}
+
+// GrepStructured is Grep with parsed results: one GrepMatch per matching line,
+// positioned at the first match on that line. With GrepOptions.Multiline a
+// match spanning lines is one GrepMatch whose Text holds all of them. It uses rg --json when rg is on
+// PATH and a pure-Go regexp walk otherwise.
+func (e *LocalExecutionEnvironment) GrepStructured(pattern string, path string, globFilter string, caseInsensitive bool, maxResults int) ([]GrepMatch, error) {
+	return e.GrepStructuredWithOptions(context.Background(), pattern, path, globFilter, caseInsensitive, maxResults, GrepOptions{})
+}
+
+// GrepStructuredWithOptions is GrepStructured bounded by ctx and opts.Timeout,
+// with rg's file filtering overridable through opts, in both the rg and
+// pure-Go paths.
+func (e *LocalExecutionEnvironment) GrepStructuredWithOptions(ctx context.Context, pattern string, path string, globFilter string, caseInsensitive bool, maxResults int, opts GrepOptions) ([]GrepMatch, error) {
+	dir := strings.TrimSpace(path)
+	if dir == "" {
+		dir = e.RootDir
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(e.RootDir, dir)
+	}
+	if maxResults <= 0 {
+		maxResults = 100
+	}
+	timeout := opts.timeout(ctx)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rg, err := exec.LookPath("rg")
+	if err != nil {
+		matches, err := grepWalk(ctx, pattern, dir, globFilter, caseInsensitive, maxResults, opts)
+		return matches, grepTimeoutError(err, timeout)
+	}
+	args := append([]string{"--json"}, opts.rgArgs()...)
+	if caseInsensitive {
+		args = append(args, "-i")
+	}
+	if strings.TrimSpace(globFilter) != "" {
+		args = append(args, "-g", globFilter)
+	}
+	args = append(args, pattern, dir)
+	res, err := e.ExecCommand(ctx, shellEscapeArgs(append([]string{rg}, args...)...), int(timeout.Milliseconds()), e.RootDir, nil)
+	if err != nil {
+		if res.TimedOut {
+			return nil, grepTimeoutError(err, timeout)
+		}
+		// Exit code 1 means "no matches" for rg.
+		if res.ExitCode == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("rg: %w: %s", err, strings.TrimSpace(res.Stderr))
+	}
+	return parseRipgrepJSON(res.Stdout, maxResults)
+}
+
+// formatGrepMatches renders matches as rg --no-heading --line-number does:
+// one path:line:text line per matching line, or line:text when a single file
+// was searched. With contextLines > 0 the lines around each match are read
+// back from its file and printed as path-line-text, with -- between groups
+// of lines that are not adjacent.
+func formatGrepMatches(matches []GrepMatch, withPath bool, contextLines int) string {
+	var b strings.Builder
+	writeLine := func(path string, sep byte, n int, text string) {
+		if withPath {
+			b.WriteString(path)
+			b.WriteByte(sep)
+		}
+		fmt.Fprintf(&b, "%d%c%s\n", n, sep, text)
+	}
+	if contextLines <= 0 {
+		for _, m := range matches {
+			for i, text := range strings.Split(m.Text, "\n") {
+				writeLine(m.Path, ':', m.Line+i, text)
+			}
+		}
+		return b.String()
+	}
+
+	type span struct{ from, to int }
+	for i := 0; i < len(matches); {
+		path := matches[i].Path
+		j := i
+		for j < len(matches) && matches[j].Path == path {
+			j++
+		}
+		var lines []string
+		if data, err := os.ReadFile(path); err == nil {
+			lines = strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+			if n := len(lines); n > 0 && lines[n-1] == "" {
+				lines = lines[:n-1]
+			}
+		}
+		matched := map[int]string{}
+		var spans []span
+		for _, m := range matches[i:j] {
+			texts := strings.Split(m.Text, "\n")
+			for k, text := range texts {
+				matched[m.Line+k] = text
+			}
+			last := m.Line + len(texts) - 1
+			s := span{from: max(m.Line-contextLines, 1), to: max(min(last+contextLines, len(lines)), last)}
+			if k := len(spans) - 1; k >= 0 && s.from <= spans[k].to+1 {
+				spans[k].to = max(spans[k].to, s.to)
+				continue
+			}
+			spans = append(spans, s)
+		}
+		for _, s := range spans {
+			if b.Len() > 0 {
+				b.WriteString("--\n")
+			}
+			for n := s.from; n <= s.to; n++ {
+				if text, ok := matched[n]; ok {
+					writeLine(path, ':', n, text)
+				} else if n <= len(lines) {
+					writeLine(path, '-', n, lines[n-1])
+				}
+			}
+		}
+		i = j
+	}
+	return b.String()
+}
+
+type rgJSONText struct {
+	Text string `json:"text"`
+}
+
+type rgJSONEvent struct {
+	Type string `json:"type"`
+	Data struct {
+		Path       rgJSONText `json:"path"`
+		Lines      rgJSONText `json:"lines"`
+		LineNumber int        `json:"line_number"`
+		Submatches []struct {
+			Start int `json:"start"`
+		} `json:"submatches"`
+	} `json:"data"`
+}
+
+// parseRipgrepJSON converts rg --json output into matches, ignoring the
+// begin/end/summary events.
+func parseRipgrepJSON(out string, maxResults int) ([]GrepMatch, error) {
+	var matches []GrepMatch
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var ev rgJSONEvent
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			return nil, fmt.Errorf("parse rg json: %w", err)
+		}
+		if ev.Type != "match" {
+			continue
+		}
+		col := 1
+		if len(ev.Data.Submatches) > 0 {
+			col = ev.Data.Submatches[0].Start + 1
+		}
+		matches = append(matches, GrepMatch{
+			Path:   ev.Data.Path.Text,
+			Line:   ev.Data.LineNumber,
+			Column: col,
+			Text:   strings.TrimRight(ev.Data.Lines.Text, "\r\n"),
+		})
+		if len(matches) >= maxResults {
+			break
+		}
+	}
+	return matches, nil
+}
+
+// grepWalk is the pure-Go fallback for GrepStructured. Unless opts say
+// otherwise it follows rg's defaults: hidden entries, .gitignore'd paths (inside
+// a git repository), and binary files are skipped. .git itself is always
+// skipped. The glob filter matches the path relative to root, or the base name
+// when the glob has no separator. The walk stops with ctx's error once ctx is
+// done.
+func grepWalk(ctx context.Context, pattern string, root string, globFilter string, caseInsensitive bool, maxResults int, opts GrepOptions) ([]GrepMatch, error) {
+	if opts.FixedString {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.Multiline {
+		// As in rg -U, ^ and $ still anchor at line boundaries.
+		pattern = "(?m)" + pattern
+	}
+	if caseInsensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	globFilter = strings.TrimSpace(globFilter)
+	var ignore *gitignoreMatcher
+	if !opts.NoIgnore {
+		ignore = newGitignoreMatcher(root)
+	}
+
+	var matches []GrepMatch
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if p != root {
+			hidden := strings.HasPrefix(d.Name(), ".")
+			if d.IsDir() && d.Name() == ".git" || hidden && !opts.Hidden || ignore.Ignored(p, d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if globFilter != "" && p != root {
+			rel, _ := filepath.Rel(root, p)
+			target := filepath.ToSlash(rel)
+			if !strings.Contains(globFilter, "/") {
+				target = d.Name()
+			}
+			if ok, _ := doublestar.Match(globFilter, target); !ok {
+				return nil
+			}
+		}
+		b, err := os.ReadFile(p)
+		if err != nil || !opts.Text && bytes.IndexByte(b[:min(len(b), 8000)], 0) >= 0 {
+			return nil
+		}
+		if opts.Multiline {
+			for _, m := range grepMultiline(p, string(b), re) {
+				matches = append(matches, m)
+				if len(matches) >= maxResults {
+					return fs.SkipAll
+				}
+			}
+			return nil
+		}
+		sc := bufio.NewScanner(bytes.NewReader(b))
+		sc.Buffer(make([]byte, 0, 64*1024), len(b)+1)
+		for n := 1; sc.Scan(); n++ {
+			text := strings.TrimRight(sc.Text(), "\r")
+			loc := re.FindStringIndex(text)
+			if loc == nil {
+				continue
+			}
+			matches = append(matches, GrepMatch{Path: p, Line: n, Column: loc[0] + 1, Text: text})
+			if len(matches) >= maxResults {
+				return fs.SkipAll
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// grepMultiline matches re against a whole file. Each match is reported at
+// the line it starts on, with Text holding every line it touches; a match
+// that starts on a line already covered by the previous one is folded into
+// it, as rg does.
+func grepMultiline(path string, text string, re *regexp.Regexp) []GrepMatch {
+	var out []GrepMatch
+	line, pos, coveredTo := 1, 0, 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		line += strings.Count(text[pos:loc[0]], "\n")
+		pos = loc[0]
+		end := loc[1]
+		if end > loc[0] && text[end-1] == '\n' {
+			// A trailing newline ends the last matched line; it does not
+			// pull in the next one.
+			end--
+		}
+		if line <= coveredTo {
+			continue
+		}
+		start := strings.LastIndexByte(text[:loc[0]], '\n') + 1
+		stop := len(text)
+		if k := strings.IndexByte(text[end:], '\n'); k >= 0 {
+			stop = end + k
+		}
+		block := strings.TrimRight(strings.ReplaceAll(text[start:stop], "\r\n", "\n"), "\r")
+		out = append(out, GrepMatch{Path: path, Line: line, Column: loc[0] - start + 1, Text: block})
+		coveredTo = line + strings.Count(block, "\n")
+	}
+	return out
+}
@@ -0,0 +1,382 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalExecutionEnvironment_GrepStructured_ReturnsPositions(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\nfunc Hello() {}\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "b.txt"), []byte("say hello\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "bin.dat"), []byte("hello\x00world\n"), 0o644)
+
+	env := NewLocalExecutionEnvironment(dir)
+	got, err := env.GrepStructured("hello", "", "*.go", true, 0)
+	if err != nil {
+		t.Fatalf("GrepStructured: %v", err)
+	}
+	want := []GrepMatch{{Path: filepath.Join(dir, "a.go"), Line: 3, Column: 6, Text: "func Hello() {}"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("matches: got %+v want %+v", got, want)
+	}
+
+	got, err = env.GrepStructured("hello", "", "", false, 0)
+	if err != nil {
+		t.Fatalf("GrepStructured: %v", err)
+	}
+	if len(got) != 1 || got[0].Path != filepath.Join(dir, "b.txt") || got[0].Column != 5 {
+		t.Fatalf("expected only the text-file match (binary skipped), got %+v", got)
+	}
+
+	got, err = env.GrepStructured("nomatch", "", "", false, 0)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("expected no matches, got %+v err=%v", got, err)
+	}
+}
+
+func TestParseRipgrepJSON(t *testing.T) {
+	out := `{"type":"begin","data":{"path":{"text":"/r/a.go"}}}
+{"type":"match","data":{"path":{"text":"/r/a.go"},"lines":{"text":"func Hello() {}\n"},"line_number":3,"absolute_offset":11,"submatches":[{"match":{"text":"Hello"},"start":5,"end":10}]}}
+{"type":"match","data":{"path":{"text":"/r/a.go"},"lines":{"text":"// Hello again\n"},"line_number":7,"absolute_offset":40,"submatches":[{"match":{"text":"Hello"},"start":3,"end":8}]}}
+{"type":"end","data":{"path":{"text":"/r/a.go"}}}
+{"type":"summary","data":{}}
+`
+	got, err := parseRipgrepJSON(out, 100)
+	if err != nil {
+		t.Fatalf("parseRipgrepJSON: %v", err)
+	}
+	want := []GrepMatch{
+		{Path: "/r/a.go", Line: 3, Column: 6, Text: "func Hello() {}"},
+		{Path: "/r/a.go", Line: 7, Column: 4, Text: "// Hello again"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("matches: got %+v want %+v", got, want)
+	}
+
+	got, err = parseRipgrepJSON(out, 1)
+	if err != nil || len(got) != 1 {
+		t.Fatalf("expected maxResults cap of 1, got %+v err=%v", got, err)
+	}
+}
+
+// writeGrepFixture builds a small git-like tree exercising rg's default
+// filters: .gitignore (nested and negated), hidden entries, and binary files.
+func writeGrepFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		".git/HEAD":         "needle in git internals\n",
+		".gitignore":        "build/\n*.log\n!keep.log\n",
+		"src/main.go":       "package main // needle\n",
+		"src/.gitignore":    "gen_*.go\n",
+		"src/gen_api.go":    "needle generated\n",
+		"build/out.txt":     "needle built\n",
+		"debug.log":         "needle logged\n",
+		"keep.log":          "needle kept\n",
+		".hidden/conf.txt":  "needle hidden dir\n",
+		".env":              "needle hidden file\n",
+		"docs/readme.md":    "no match here\n",
+		"assets/blob.bin":   "needle\x00binary\n",
+		"nested/deep/x.txt": "needle deep\n",
+	}
+	for rel, body := range files {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func grepRelPaths(t *testing.T, root string, matches []GrepMatch) []string {
+	t.Helper()
+	var out []string
+	for _, m := range matches {
+		rel, err := filepath.Rel(root, m.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, filepath.ToSlash(rel))
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestGrepWalk_HonorsGitignoreHiddenAndBinary(t *testing.T) {
+	root := writeGrepFixture(t)
+
+	got, err := grepWalk(context.Background(), "needle", root, "", false, 100, GrepOptions{})
+	if err != nil {
+		t.Fatalf("grepWalk: %v", err)
+	}
+	want := []string{"keep.log", "nested/deep/x.txt", "src/main.go"}
+	if paths := grepRelPaths(t, root, got); !reflect.DeepEqual(paths, want) {
+		t.Fatalf("default paths=%v want %v", paths, want)
+	}
+
+	got, err = grepWalk(context.Background(), "needle", root, "", false, 100, GrepOptions{Hidden: true, NoIgnore: true, Text: true})
+	if err != nil {
+		t.Fatalf("grepWalk: %v", err)
+	}
+	want = []string{".env", ".hidden/conf.txt", "assets/blob.bin", "build/out.txt", "debug.log", "keep.log", "nested/deep/x.txt", "src/gen_api.go", "src/main.go"}
+	if paths := grepRelPaths(t, root, got); !reflect.DeepEqual(paths, want) {
+		t.Fatalf("overridden paths=%v want %v", paths, want)
+	}
+}
+
+func TestGrepWalk_IgnoresGitignoreOutsideRepository(t *testing.T) {
+	root := writeGrepFixture(t)
+	if err := os.RemoveAll(filepath.Join(root, ".git")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := grepWalk(context.Background(), "needle", root, "", false, 100, GrepOptions{})
+	if err != nil {
+		t.Fatalf("grepWalk: %v", err)
+	}
+	paths := grepRelPaths(t, root, got)
+	if !reflect.DeepEqual(paths, []string{"build/out.txt", "debug.log", "keep.log", "nested/deep/x.txt", "src/gen_api.go", "src/main.go"}) {
+		t.Fatalf("paths=%v want .gitignore not applied outside a git repository", paths)
+	}
+}
+
+func TestGrepStructured_FallbackMatchesRipgrepOutputShape(t *testing.T) {
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("requires rg")
+	}
+	root := writeGrepFixture(t)
+	env := NewLocalExecutionEnvironment(root)
+	for _, opts := range []GrepOptions{{}, {Hidden: true}, {NoIgnore: true}, {Text: true}} {
+		viaRG, err := env.GrepStructuredWithOptions(context.Background(), "needle", "", "", false, 100, opts)
+		if err != nil {
+			t.Fatalf("rg %+v: %v", opts, err)
+		}
+		viaWalk, err := grepWalk(context.Background(), "needle", root, "", false, 100, opts)
+		if err != nil {
+			t.Fatalf("grepWalk %+v: %v", opts, err)
+		}
+		sortMatches := func(ms []GrepMatch) {
+			sort.Slice(ms, func(i, j int) bool { return ms[i].Path < ms[j].Path })
+		}
+		sortMatches(viaRG)
+		sortMatches(viaWalk)
+		if opts.Hidden {
+			// rg --hidden also searches .git; the fallback never does.
+			filtered := viaRG[:0]
+			for _, m := range viaRG {
+				if !strings.Contains(filepath.ToSlash(m.Path), "/.git/") {
+					filtered = append(filtered, m)
+				}
+			}
+			viaRG = filtered
+		}
+		if !reflect.DeepEqual(viaRG, viaWalk) {
+			t.Fatalf("opts %+v:\nrg:   %+v\nwalk: %+v", opts, viaRG, viaWalk)
+		}
+	}
+}
+
+func TestLocalExecutionEnvironment_GrepFallbackFormatsLikeRipgrep(t *testing.T) {
+	root := writeGrepFixture(t)
+	t.Setenv("PATH", t.TempDir())
+	env := NewLocalExecutionEnvironment(root)
+
+	out, err := env.Grep("needle", "src", "", false, 0)
+	if err != nil {
+		t.Fatalf("Grep: %v", err)
+	}
+	if want := filepath.Join(root, "src", "main.go") + ":1:package main // needle\n"; out != want {
+		t.Fatalf("output=%q want %q", out, want)
+	}
+	out, err = env.Grep("needle", "src/main.go", "", false, 0)
+	if err != nil {
+		t.Fatalf("Grep file: %v", err)
+	}
+	if out != "1:package main // needle\n" {
+		t.Fatalf("single-file output=%q", out)
+	}
+}
+
+func TestGrepWithOptions_TimeoutIsAnError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake rg is a shell script")
+	}
+	// A fake rg that never finishes stands in for a search over a huge tree.
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "rg"), []byte("#!/bin/sh\necho partial.go:1:match\nexec sleep 5\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	env := NewLocalExecutionEnvironment(t.TempDir())
+	env.Shell = []string{"sh", "-c"}
+
+	start := time.Now()
+	out, err := env.GrepWithOptions(context.Background(), "match", "", "", false, 10, GrepOptions{Timeout: 300 * time.Millisecond})
+	if !errors.Is(err, ErrGrepTimeout) {
+		t.Fatalf("err = %v, want ErrGrepTimeout", err)
+	}
+	if out != "" {
+		t.Fatalf("partial output returned with the timeout: %q", out)
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Fatalf("grep ran %s past a 300ms timeout", elapsed)
+	}
+
+	// A caller deadline bounds the search when no timeout is set.
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if _, err := env.GrepStructuredWithOptions(ctx, "match", "", "", false, 10, GrepOptions{}); !errors.Is(err, ErrGrepTimeout) {
+		t.Fatalf("structured err = %v, want ErrGrepTimeout", err)
+	}
+}
+
+func TestGrepWalk_StopsWhenContextDone(t *testing.T) {
+	root := t.TempDir()
+	_ = os.WriteFile(filepath.Join(root, "a.txt"), []byte("needle\n"), 0o644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := grepWalk(ctx, "needle", root, "", false, 100, GrepOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	_, err := grepWalk(expired, "needle", root, "", false, 100, GrepOptions{})
+	if err := grepTimeoutError(err, time.Second); !errors.Is(err, ErrGrepTimeout) {
+		t.Fatalf("err = %v, want ErrGrepTimeout", err)
+	}
+}
+
+func TestGrepOptions_Timeout(t *testing.T) {
+	if got := (GrepOptions{}).timeout(context.Background()); got != defaultGrepTimeout {
+		t.Fatalf("default timeout = %s", got)
+	}
+	if got := (GrepOptions{Timeout: time.Minute}).timeout(context.Background()); got != time.Minute {
+		t.Fatalf("explicit timeout = %s", got)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if got := (GrepOptions{}).timeout(ctx); got <= 20*time.Second || got > 30*time.Second {
+		t.Fatalf("deadline-derived timeout = %s", got)
+	}
+}
+
+func TestGrepOptions_RgArgs(t *testing.T) {
+	got := GrepOptions{FixedString: true, Multiline: true, Context: 2}.rgArgs()
+	if want := []string{"-F", "-U", "-C", "2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("rgArgs = %v, want %v", got, want)
+	}
+}
+
+func TestGrepWalk_FixedStringAndMultiline(t *testing.T) {
+	root := t.TempDir()
+	src := "package a\n\nfunc A() (int, error) {\n\treturn 1, nil\n}\n\nfunc B() {\n\treturn\n}\n"
+	_ = os.WriteFile(filepath.Join(root, "a.go"), []byte(src), 0o644)
+	path := filepath.Join(root, "a.go")
+
+	got, err := grepWalk(context.Background(), "(int, error)", root, "", false, 100, GrepOptions{FixedString: true})
+	if err != nil {
+		t.Fatalf("fixed string: %v", err)
+	}
+	if want := []GrepMatch{{Path: path, Line: 3, Column: 10, Text: "func A() (int, error) {"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("fixed string: got %+v want %+v", got, want)
+	}
+
+	got, err = grepWalk(context.Background(), `\{\n\treturn\b`, root, "", false, 100, GrepOptions{Multiline: true})
+	if err != nil {
+		t.Fatalf("multiline: %v", err)
+	}
+	want := []GrepMatch{
+		{Path: path, Line: 3, Column: 23, Text: "func A() (int, error) {\n\treturn 1, nil"},
+		{Path: path, Line: 7, Column: 10, Text: "func B() {\n\treturn"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("multiline: got %+v want %+v", got, want)
+	}
+	got, err = grepWalk(context.Background(), `\{\n\treturn`, root, "", false, 100, GrepOptions{})
+	if err != nil || len(got) != 0 {
+		t.Fatalf("without multiline a pattern spanning lines should not match: %+v, %v", got, err)
+	}
+}
+
+func TestLocalExecutionEnvironment_GrepFallbackContextLines(t *testing.T) {
+	root := t.TempDir()
+	var lines []string
+	for i := 1; i <= 12; i++ {
+		text := fmt.Sprintf("line %d", i)
+		if i == 3 || i == 5 || i == 11 {
+			text += " needle"
+		}
+		lines = append(lines, text)
+	}
+	_ = os.WriteFile(filepath.Join(root, "f.txt"), []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o644)
+	t.Setenv("PATH", t.TempDir())
+	env := NewLocalExecutionEnvironment(root)
+
+	out, err := env.GrepWithOptions(context.Background(), "needle", "f.txt", "", false, 0, GrepOptions{Context: 1})
+	if err != nil {
+		t.Fatalf("Grep: %v", err)
+	}
+	want := "2-line 2\n3:line 3 needle\n4-line 4\n5:line 5 needle\n6-line 6\n--\n10-line 10\n11:line 11 needle\n12-line 12\n"
+	if out != want {
+		t.Fatalf("output=%q\nwant  %q", out, want)
+	}
+
+	out, err = env.GrepWithOptions(context.Background(), "3 needle\r?\nline 4", "", "", false, 0, GrepOptions{Multiline: true})
+	if err != nil {
+		t.Fatalf("Grep multiline: %v", err)
+	}
+	f := filepath.Join(root, "f.txt")
+	if want := f + ":3:line 3 needle\n" + f + ":4:line 4\n"; out != want {
+		t.Fatalf("multiline output=%q want %q", out, want)
+	}
+}
+
+func TestGrepWithOptions_RipgrepCapCountsMatchesNotContextLines(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake rg is a shell script")
+	}
+	root := t.TempDir()
+	f := filepath.Join(root, "f.txt")
+	_ = os.WriteFile(f, []byte("a\nneedle 1\nb\nc\nd\nneedle 2\ne\nneedle 3\n"), 0o644)
+
+	// A fake rg replays rg --json output, context events included.
+	var events strings.Builder
+	for _, ev := range []struct {
+		typ  string
+		line int
+		text string
+	}{{"context", 1, "a"}, {"match", 2, "needle 1"}, {"context", 3, "b"}, {"context", 5, "d"}, {"match", 6, "needle 2"}, {"context", 7, "e"}, {"match", 8, "needle 3"}} {
+		fmt.Fprintf(&events, `{"type":%q,"data":{"path":{"text":%q},"lines":{"text":"%s\n"},"line_number":%d,"submatches":[{"start":0}]}}`+"\n", ev.typ, f, ev.text, ev.line)
+	}
+	replay := filepath.Join(t.TempDir(), "rg.json")
+	_ = os.WriteFile(replay, []byte(events.String()), 0o644)
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "rg"), []byte("#!/bin/sh\ncat "+replay+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	env := NewLocalExecutionEnvironment(root)
+	env.Shell = []string{"sh", "-c"}
+
+	out, err := env.GrepWithOptions(context.Background(), "needle", "f.txt", "", false, 2, GrepOptions{Context: 1})
+	if err != nil {
+		t.Fatalf("Grep: %v", err)
+	}
+	if want := "1-a\n2:needle 1\n3-b\n--\n5-d\n6:needle 2\n7-e\n"; out != want {
+		t.Fatalf("output=%q\nwant  %q", out, want)
+	}
+}
@@ -208,7 +208,7 @@ func envInfoFromEnv(env ExecutionEnvironment) EnvironmentInfo {
 func defReadFile() llm.ToolDefinition {
 	return llm.ToolDefinition{
 		Name:        "read_file",
-		Description: "Read a file from the filesystem. Returns line-numbered content.",
+		Description: "Read a file from the filesystem. Returns line-numbered content. A negative offset reads from the end (e.g. -100 returns the last 100 lines).",
 		Parameters: map[string]any{
 			"type":                 "object",
 			"additionalProperties": false,
@@ -308,7 +308,7 @@ func defShell() llm.ToolDefinition {
 func defGrep() llm.ToolDefinition {
 	return llm.ToolDefinition{
 		Name:        "grep",
-		Description: "Search file contents using regex patterns.",
+		Description: "Search file contents using regex patterns. Set fixed_string to match the pattern literally, multiline to let matches span lines, and context_lines to show lines around each match.",
 		Parameters: map[string]any{
 			"type":                 "object",
 			"additionalProperties": false,
@@ -318,6 +318,12 @@ func defGrep() llm.ToolDefinition {
 				"glob_filter":      map[string]any{"type": "string"},
 				"case_insensitive": map[string]any{"type": "boolean"},
 				"max_results":      map[string]any{"type": "integer"},
+				"hidden":           map[string]any{"type": "boolean"},
+				"no_ignore":        map[string]any{"type": "boolean"},
+				"text":             map[string]any{"type": "boolean"},
+				"fixed_string":     map[string]any{"type": "boolean"},
+				"multiline":        map[string]any{"type": "boolean"},
+				"context_lines":    map[string]any{"type": "integer"},
 			},
 			"required": []string{"pattern"},
 		},
@@ -332,8 +338,9 @@ func defGlob() llm.ToolDefinition {
 			"type":                 "object",
 			"additionalProperties": false,
 			"properties": map[string]any{
-				"pattern": map[string]any{"type": "string"},
-				"path":    map[string]any{"type": "string"},
+				"pattern":     map[string]any{"type": "string"},
+				"path":        map[string]any{"type": "string"},
+				"max_results": map[string]any{"type": "integer"},
 			},
 			"required": []string{"pattern"},
 		},
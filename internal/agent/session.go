@@ -37,6 +37,10 @@ type SessionConfig struct {
 	// Use this for provider-specific parameters (e.g., Cerebras clear_thinking).
 	ProviderOptions map[string]any
 
+	// RequestMetadata is attached to every LLM request as metadata (provider
+	// cost-attribution tags). Adapters without a metadata field drop it.
+	RequestMetadata map[string]string
+
 	// ToolCallFilter, when non-nil, is invoked before each tool call is executed.
 	// It receives the tool name, call ID, and arguments JSON. If it returns a
 	// non-empty string, the tool call is skipped and the returned string is used
@@ -51,6 +55,11 @@ type SessionConfig struct {
 	// Nil means use llm.DefaultRetryPolicy().
 	LLMRetryPolicy *llm.RetryPolicy
 	LLMSleep       llm.SleepFunc
+
+	// LLMCallTimeout bounds each LLM request (each retry gets a fresh
+	// deadline). A call that runs past it fails with a timeout error. Zero
+	// means no per-call limit.
+	LLMCallTimeout time.Duration
 }
 
 // ErrTurnLimit indicates the session exceeded its configured MaxTurns budget.
@@ -476,13 +485,21 @@ func (s *Session) processOneInput(ctx context.Context, input string) (string, er
 		if len(s.cfg.ProviderOptions) > 0 {
 			req.ProviderOptions = s.cfg.ProviderOptions
 		}
+		if len(s.cfg.RequestMetadata) > 0 {
+			req.Metadata = s.cfg.RequestMetadata
+		}
 
 		policy := llm.DefaultRetryPolicy()
 		if s.cfg.LLMRetryPolicy != nil {
 			policy = *s.cfg.LLMRetryPolicy
 		}
 		resp, err := llm.Retry(ctx, policy, s.cfg.LLMSleep, nil, func() (llm.Response, error) {
-			return s.client.Complete(ctx, req)
+			if s.cfg.LLMCallTimeout <= 0 {
+				return s.client.Complete(ctx, req)
+			}
+			callCtx, cancel := context.WithTimeout(ctx, s.cfg.LLMCallTimeout)
+			defer cancel()
+			return s.client.Complete(callCtx, req)
 		})
 		if err != nil {
 			s.emit(EventError, map[string]any{"error": err.Error()})
@@ -512,7 +529,7 @@ func (s *Session) processOneInput(ctx context.Context, input string) (string, er
 		if strings.TrimSpace(txt) != "" {
 			s.emit(EventAssistantTextDelta, map[string]any{"delta": txt})
 		}
-		s.emit(EventAssistantTextEnd, map[string]any{"text": txt})
+		s.emit(EventAssistantTextEnd, map[string]any{"text": txt, "usage": resp.Usage})
 
 		calls := resp.ToolCalls()
 		if len(calls) == 0 {
@@ -817,7 +834,6 @@ func registerCoreTools(reg *ToolRegistry, s *Session) error {
 	if err := reg.Register(RegisteredTool{
 		Definition: defGrep(),
 		Exec: func(ctx context.Context, env ExecutionEnvironment, args map[string]any) (any, error) {
-			_ = ctx
 			pat := argStr(args, "pattern")
 			path := argStr(args, "path")
 			glob := argStr(args, "glob_filter")
@@ -829,6 +845,20 @@ func registerCoreTools(reg *ToolRegistry, s *Session) error {
 			if v, ok := args["max_results"].(float64); ok && int(v) > 0 {
 				maxRes = int(v)
 			}
+			var opts GrepOptions
+			opts.Hidden, _ = args["hidden"].(bool)
+			opts.NoIgnore, _ = args["no_ignore"].(bool)
+			opts.Text, _ = args["text"].(bool)
+			opts.FixedString, _ = args["fixed_string"].(bool)
+			opts.Multiline, _ = args["multiline"].(bool)
+			if v, ok := args["context_lines"].(float64); ok && int(v) > 0 {
+				opts.Context = int(v)
+			}
+			if ge, ok := env.(interface {
+				GrepWithOptions(context.Context, string, string, string, bool, int, GrepOptions) (string, error)
+			}); ok {
+				return ge.GrepWithOptions(ctx, pat, path, glob, ci, maxRes, opts)
+			}
 			return env.Grep(pat, path, glob, ci, maxRes)
 		},
 	}); err != nil {
@@ -842,11 +872,19 @@ func registerCoreTools(reg *ToolRegistry, s *Session) error {
 			_ = ctx
 			pat := argStr(args, "pattern")
 			path := argStr(args, "path")
-			matches, err := env.Glob(pat, path)
+			maxRes := 1000
+			if v, ok := args["max_results"].(float64); ok && int(v) > 0 {
+				maxRes = int(v)
+			}
+			matches, truncated, err := env.Glob(pat, path, maxRes)
 			if err != nil {
 				return "", err
 			}
-			return strings.Join(matches, "\n"), nil
+			out := strings.Join(matches, "\n")
+			if truncated {
+				out += fmt.Sprintf("\n[results truncated at %d matches; narrow the pattern or path]", maxRes)
+			}
+			return out, nil
 		},
 	}); err != nil {
 		return err
@@ -3,6 +3,7 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -1008,6 +1009,54 @@ func TestSession_LLMError_EmitsErrorEvent(t *testing.T) {
 	}
 }
 
+// deadlineAdapter blocks until the request context ends and records whether
+// it carried a deadline.
+type deadlineAdapter struct {
+	name        string
+	hadDeadline bool
+}
+
+func (a *deadlineAdapter) Name() string { return a.name }
+func (a *deadlineAdapter) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	_ = req
+	_, a.hadDeadline = ctx.Deadline()
+	<-ctx.Done()
+	return llm.Response{}, llm.WrapContextError(a.name, ctx.Err())
+}
+func (a *deadlineAdapter) Stream(ctx context.Context, req llm.Request) (llm.Stream, error) {
+	_ = ctx
+	_ = req
+	return nil, errors.New("not implemented")
+}
+
+func TestSession_LLMCallTimeout_BoundsEachRequest(t *testing.T) {
+	dir := t.TempDir()
+	c := llm.NewClient()
+	a := &deadlineAdapter{name: "openai"}
+	c.Register(a)
+
+	sess, err := NewSession(c, NewOpenAIProfile("gpt-5.2"), NewLocalExecutionEnvironment(dir), SessionConfig{
+		LLMCallTimeout: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = sess.ProcessInput(ctx, "hi")
+	sess.Close()
+	var rte *llm.RequestTimeoutError
+	if !errors.As(err, &rte) {
+		t.Fatalf("err = %T %v, want RequestTimeoutError", err, err)
+	}
+	if !a.hadDeadline {
+		t.Fatal("LLM request context had no deadline")
+	}
+	if ctx.Err() != nil {
+		t.Fatal("session waited for the outer context instead of the per-call timeout")
+	}
+}
+
 func TestSession_LLMTransientErrors_RetryWithBackoff(t *testing.T) {
 	dir := t.TempDir()
 	c := llm.NewClient()
@@ -1327,8 +1376,8 @@ func (e *captureEnv) EditFile(path string, oldString string, newString string, r
 	return "", fmt.Errorf("not implemented")
 }
 func (e *captureEnv) FileExists(path string) bool { return false }
-func (e *captureEnv) Glob(pattern string, basePath string) ([]string, error) {
-	return nil, fmt.Errorf("not implemented")
+func (e *captureEnv) Glob(pattern string, basePath string, maxResults int) ([]string, bool, error) {
+	return nil, false, fmt.Errorf("not implemented")
 }
 func (e *captureEnv) Grep(pattern string, path string, globFilter string, caseInsensitive bool, maxResults int) (string, error) {
 	return "", fmt.Errorf("not implemented")
@@ -1370,8 +1419,8 @@ func (e *timeoutEnv) EditFile(path string, oldString string, newString string, r
 	return "", fmt.Errorf("not implemented")
 }
 func (e *timeoutEnv) FileExists(path string) bool { return false }
-func (e *timeoutEnv) Glob(pattern string, basePath string) ([]string, error) {
-	return nil, fmt.Errorf("not implemented")
+func (e *timeoutEnv) Glob(pattern string, basePath string, maxResults int) ([]string, bool, error) {
+	return nil, false, fmt.Errorf("not implemented")
 }
 func (e *timeoutEnv) Grep(pattern string, path string, globFilter string, caseInsensitive bool, maxResults int) (string, error) {
 	return "", fmt.Errorf("not implemented")
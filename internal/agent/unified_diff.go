@@ -0,0 +1,373 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ApplyPatch applies a unified diff (as produced by diff -u or git diff) to
+// files under RootDir. Every hunk is checked against the current file contents
+// before anything is written, so a mismatch leaves the tree untouched and
+// reports the first hunk that failed. Hunks may apply at an offset from their
+// header line numbers, but context must match exactly. Differing old and new
+// paths rename the file only for git renames (a/ b/ prefixes or rename
+// headers), and never onto an existing file; otherwise the new path is
+// patched in place.
+func (e *LocalExecutionEnvironment) ApplyPatch(diff string) (string, error) {
+	files, err := parseUnifiedDiff(diff)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("patch contains no file diffs")
+	}
+
+	// Results are staged per path and written only
+	// after every hunk has applied.
+	type staged struct {
+		content []byte
+		delete  bool
+	}
+	results := map[string]*staged{}
+	var order []string
+	stage := func(path string, s *staged) {
+		if _, ok := results[path]; !ok {
+			order = append(order, path)
+		}
+		results[path] = s
+	}
+	current := func(path string) ([]byte, bool, error) {
+		if s, ok := results[path]; ok {
+			return s.content, !s.delete, nil
+		}
+		b, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, false, nil
+		}
+		return b, err == nil, err
+	}
+
+	hunks := 0
+	for _, fd := range files {
+		name := fd.displayName()
+		if fd.oldPath != "" && fd.newPath != "" && fd.oldPath != fd.newPath && !fd.rename {
+			// Plain diff -u output (--- foo.orig / +++ foo) names a scratch
+			// copy on the old side: patch the new path in place.
+			fd.oldPath = fd.newPath
+		}
+		var src string
+		if fd.oldPath != "" {
+			p, err := safeJoin(e.RootDir, fd.oldPath)
+			if err != nil {
+				return "", err
+			}
+			b, ok, err := current(p)
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				return "", fmt.Errorf("%s: file does not exist", fd.oldPath)
+			}
+			src = string(b)
+		} else if fd.newPath != "" {
+			p, err := safeJoin(e.RootDir, fd.newPath)
+			if err != nil {
+				return "", err
+			}
+			if _, ok, err := current(p); err != nil {
+				return "", err
+			} else if ok {
+				return "", fmt.Errorf("%s: file already exists", fd.newPath)
+			}
+		}
+
+		out, err := applyUnifiedHunks(name, src, fd.hunks)
+		if err != nil {
+			return "", err
+		}
+		hunks += len(fd.hunks)
+
+		if fd.newPath == "" {
+			p, _ := safeJoin(e.RootDir, fd.oldPath)
+			if out != "" {
+				return "", fmt.Errorf("%s: deletion leaves %d byte(s) unaccounted for", fd.oldPath, len(out))
+			}
+			stage(p, &staged{delete: true})
+			continue
+		}
+		dst, err := safeJoin(e.RootDir, fd.newPath)
+		if err != nil {
+			return "", err
+		}
+		if fd.oldPath != "" && fd.oldPath != fd.newPath {
+			if _, ok, err := current(dst); err != nil {
+				return "", err
+			} else if ok {
+				return "", fmt.Errorf("%s: rename target already exists", fd.newPath)
+			}
+			src, _ := safeJoin(e.RootDir, fd.oldPath)
+			stage(src, &staged{delete: true})
+		}
+		stage(dst, &staged{content: []byte(out)})
+	}
+
+	for _, p := range order {
+		s := results[p]
+		if s.delete {
+			if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return "", err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(p, s.content, 0o644); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("applied %d hunk(s) to %d file(s)", hunks, len(files)), nil
+}
+
+type unifiedFileDiff struct {
+	oldPath string // "" for a created file
+	newPath string // "" for a deleted file
+	// rename is set by git "rename from"/"rename to" headers or a/ and b/
+	// prefixes on both sides; only then do differing paths move the file.
+	rename bool
+	hunks  []unifiedHunk
+}
+
+func (d unifiedFileDiff) displayName() string {
+	if d.newPath != "" {
+		return d.newPath
+	}
+	return d.oldPath
+}
+
+type unifiedHunk struct {
+	header   string
+	oldStart int
+	oldCount int
+	lines    []string // each prefixed with ' ', '-', or '+'
+	// oldNoEOL/newNoEOL record "\ No newline at end of file" markers.
+	oldNoEOL bool
+	newNoEOL bool
+}
+
+var unifiedHunkHeaderRE = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parseUnifiedDiff splits a unified diff into per-file hunks. Lines outside
+// file sections (git "diff --git"/"index" headers, commentary) are ignored,
+// apart from git's "rename from"/"rename to" headers.
+func parseUnifiedDiff(diff string) ([]unifiedFileDiff, error) {
+	lines := strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n")
+	var files []unifiedFileDiff
+	var renameFrom, renameTo bool
+	for i := 0; i < len(lines); {
+		if !strings.HasPrefix(lines[i], "--- ") || i+1 >= len(lines) || !strings.HasPrefix(lines[i+1], "+++ ") {
+			switch l := lines[i]; {
+			case strings.HasPrefix(l, "diff --git "):
+				renameFrom, renameTo = false, false
+			case strings.HasPrefix(l, "rename from "):
+				renameFrom = true
+			case strings.HasPrefix(l, "rename to "):
+				renameTo = true
+			}
+			i++
+			continue
+		}
+		oldRaw, newRaw := unifiedDiffPath(lines[i][4:], ""), unifiedDiffPath(lines[i+1][4:], "")
+		fd := unifiedFileDiff{
+			oldPath: unifiedDiffPath(lines[i][4:], "a/"),
+			newPath: unifiedDiffPath(lines[i+1][4:], "b/"),
+			rename:  renameFrom && renameTo || strings.HasPrefix(oldRaw, "a/") && strings.HasPrefix(newRaw, "b/"),
+		}
+		renameFrom, renameTo = false, false
+		if fd.oldPath == "" && fd.newPath == "" {
+			return nil, fmt.Errorf("patch line %d: both sides are /dev/null", i+1)
+		}
+		i += 2
+		for i < len(lines) && strings.HasPrefix(lines[i], "@@") {
+			h, next, err := parseUnifiedHunk(lines, i)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", fd.displayName(), err)
+			}
+			fd.hunks = append(fd.hunks, h)
+			i = next
+		}
+		if len(fd.hunks) == 0 {
+			return nil, fmt.Errorf("%s: no hunks", fd.displayName())
+		}
+		files = append(files, fd)
+	}
+	return files, nil
+}
+
+// unifiedDiffPath strips the optional timestamp and the a/ or b/ prefix from a
+// ---/+++ header value. It returns "" for /dev/null.
+func unifiedDiffPath(raw string, prefix string) string {
+	if tab := strings.IndexByte(raw, '\t'); tab >= 0 {
+		raw = raw[:tab]
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(raw, prefix)
+}
+
+func parseUnifiedHunk(lines []string, i int) (unifiedHunk, int, error) {
+	m := unifiedHunkHeaderRE.FindStringSubmatch(lines[i])
+	if m == nil {
+		return unifiedHunk{}, 0, fmt.Errorf("patch line %d: malformed hunk header %q", i+1, lines[i])
+	}
+	count := func(s string) int {
+		if s == "" {
+			return 1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	h := unifiedHunk{header: lines[i], oldCount: count(m[2])}
+	h.oldStart, _ = strconv.Atoi(m[1])
+	oldLeft, newLeft := h.oldCount, count(m[4])
+	i++
+	for i < len(lines) {
+		l := lines[i]
+		if strings.HasPrefix(l, `\`) {
+			// "\ No newline at end of file" applies to the line before it.
+			if n := len(h.lines); n > 0 {
+				switch h.lines[n-1][0] {
+				case '-':
+					h.oldNoEOL = true
+				case '+':
+					h.newNoEOL = true
+				default:
+					h.oldNoEOL, h.newNoEOL = true, true
+				}
+			}
+			i++
+			continue
+		}
+		if oldLeft <= 0 && newLeft <= 0 {
+			break
+		}
+		if l == "" {
+			// Some tools drop the leading space on blank context lines.
+			l = " "
+		}
+		switch l[0] {
+		case ' ':
+			oldLeft--
+			newLeft--
+		case '-':
+			oldLeft--
+		case '+':
+			newLeft--
+		default:
+			return unifiedHunk{}, 0, fmt.Errorf("patch line %d: unexpected line in hunk %s: %q", i+1, h.header, l)
+		}
+		h.lines = append(h.lines, l)
+		i++
+	}
+	if oldLeft != 0 || newLeft != 0 {
+		return unifiedHunk{}, 0, fmt.Errorf("hunk %s is truncated", h.header)
+	}
+	return h, i, nil
+}
+
+// applyUnifiedHunks applies hunks in order to src. Each hunk is located at its
+// header position shifted by the net line delta of earlier hunks, or at the
+// nearest exact match past the previous hunk. A CRLF source is matched with
+// its line endings stripped and written back with CRLF.
+func applyUnifiedHunks(name string, src string, hunks []unifiedHunk) (string, error) {
+	crlf := usesCRLF([]byte(src))
+	var lines []string
+	if src != "" {
+		lines = strings.Split(strings.TrimSuffix(strings.ReplaceAll(src, "\r\n", "\n"), "\n"), "\n")
+	}
+	noEOL := src != "" && !strings.HasSuffix(src, "\n")
+
+	out := make([]string, 0, len(lines))
+	pos, delta := 0, 0
+	for n, h := range hunks {
+		var old, repl []string
+		for _, l := range h.lines {
+			if crlf {
+				l = strings.TrimSuffix(l, "\r")
+			}
+			switch l[0] {
+			case ' ':
+				old = append(old, l[1:])
+				repl = append(repl, l[1:])
+			case '-':
+				old = append(old, l[1:])
+			case '+':
+				repl = append(repl, l[1:])
+			}
+		}
+		want := h.oldStart - 1 + delta
+		if h.oldCount == 0 {
+			// Pure insertions name the line they follow.
+			want = h.oldStart + delta
+		}
+		at := findHunk(lines, old, pos, want)
+		if at < 0 {
+			return "", fmt.Errorf("hunk %d of %s (%s) does not apply: context does not match", n+1, name, h.header)
+		}
+		out = append(out, lines[pos:at]...)
+		out = append(out, repl...)
+		pos = at + len(old)
+		delta += len(repl) - len(old)
+		if pos == len(lines) {
+			if h.oldNoEOL != noEOL && len(old) > 0 {
+				return "", fmt.Errorf("hunk %d of %s (%s) does not apply: trailing newline mismatch", n+1, name, h.header)
+			}
+			noEOL = h.newNoEOL
+		}
+	}
+	out = append(out, lines[pos:]...)
+	if len(out) == 0 {
+		return "", nil
+	}
+	eol := "\n"
+	if crlf {
+		eol = "\r\n"
+	}
+	text := strings.Join(out, eol)
+	if !noEOL {
+		text += eol
+	}
+	return text, nil
+}
+
+// findHunk returns the index at or after from where old matches lines exactly,
+// preferring the candidate closest to want, or -1.
+func findHunk(lines []string, old []string, from int, want int) int {
+	matches := func(at int) bool {
+		if at < from || at+len(old) > len(lines) {
+			return false
+		}
+		for i, l := range old {
+			if lines[at+i] != l {
+				return false
+			}
+		}
+		return true
+	}
+	for d := 0; want-d >= from || want+d <= len(lines); d++ {
+		if matches(want - d) {
+			return want - d
+		}
+		if d > 0 && matches(want+d) {
+			return want + d
+		}
+	}
+	return -1
+}
@@ -0,0 +1,186 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalExecutionEnvironment_ApplyPatch_MultiFileUnifiedDiff(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "gone.txt"), []byte("bye\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "tail.txt"), []byte("x\ny"), 0o644)
+
+	// The second a.txt hunk's header is off by one; it still applies by context.
+	diff := `diff --git a/a.txt b/a.txt
+index 1111111..2222222 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,3 @@
+ one
+-two
++TWO
+ three
+@@ -6,2 +6,3 @@
+ seven
++seven-and-a-half
+ eight
+--- /dev/null
++++ b/sub/new.txt
+@@ -0,0 +1,2 @@
++hello
++world
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+--- a/tail.txt
++++ b/tail.txt
+@@ -1,2 +1,2 @@
+ x
+-y
+\ No newline at end of file
++z
+`
+	env := NewLocalExecutionEnvironment(dir)
+	out, err := env.ApplyPatch(diff)
+	if err != nil {
+		t.Fatalf("ApplyPatch: %v", err)
+	}
+	if out != "applied 5 hunk(s) to 4 file(s)" {
+		t.Fatalf("output: %q", out)
+	}
+	for name, want := range map[string]string{
+		"a.txt":       "one\nTWO\nthree\nfour\nfive\nsix\nseven\nseven-and-a-half\neight\n",
+		"sub/new.txt": "hello\nworld\n",
+		"tail.txt":    "x\nz\n",
+	} {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if string(b) != want {
+			t.Fatalf("%s: got %q want %q", name, b, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "gone.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected gone.txt to be deleted, stat err=%v", err)
+	}
+}
+
+func TestLocalExecutionEnvironment_ApplyPatch_MismatchIsAtomic(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\ntwo\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "b.txt"), []byte("alpha\nbeta\n"), 0o644)
+
+	diff := `--- a/a.txt
++++ b/a.txt
+@@ -1,2 +1,2 @@
+ one
+-two
++TWO
+--- a/b.txt
++++ b/b.txt
+@@ -1,2 +1,2 @@
+ alpha
+-gamma
++GAMMA
+`
+	env := NewLocalExecutionEnvironment(dir)
+	_, err := env.ApplyPatch(diff)
+	if err == nil || !strings.Contains(err.Error(), "hunk 1 of b.txt") {
+		t.Fatalf("expected failing hunk to be reported, got %v", err)
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, "a.txt"))
+	if string(b) != "one\ntwo\n" {
+		t.Fatalf("a.txt modified despite failed patch: %q", b)
+	}
+}
+
+func TestLocalExecutionEnvironment_ApplyPatch_RejectsPathsOutsideRoot(t *testing.T) {
+	dir := t.TempDir()
+	diff := `--- /dev/null
++++ b/../escape.txt
+@@ -0,0 +1 @@
++nope
+`
+	env := NewLocalExecutionEnvironment(dir)
+	if _, err := env.ApplyPatch(diff); err == nil || !strings.Contains(err.Error(), "path traversal") {
+		t.Fatalf("expected path traversal error, got %v", err)
+	}
+}
+
+func TestLocalExecutionEnvironment_ApplyPatch_PreservesCRLF(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\r\ntwo\r\nthree\r\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "b.txt"), []byte("one\r\ntwo\r\n"), 0o644)
+
+	// b.txt's hunk carries the file's CR bytes, as git diff emits them.
+	diff := "--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,3 @@\n one\n-two\n+TWO\n three\n" +
+		"--- a/b.txt\n+++ b/b.txt\n@@ -1,2 +1,3 @@\n one\r\n two\r\n+three\r\n"
+	env := NewLocalExecutionEnvironment(dir)
+	if _, err := env.ApplyPatch(diff); err != nil {
+		t.Fatalf("ApplyPatch: %v", err)
+	}
+	for name, want := range map[string]string{
+		"a.txt": "one\r\nTWO\r\nthree\r\n",
+		"b.txt": "one\r\ntwo\r\nthree\r\n",
+	} {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Fatalf("%s: got %q want %q", name, b, want)
+		}
+	}
+}
+
+func TestLocalExecutionEnvironment_ApplyPatch_PlainDiffPatchesNewPathInPlace(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "foo.orig"), []byte("one\ntwo\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "foo"), []byte("one\ntwo\n"), 0o644)
+
+	// diff -u foo.orig foo: the old side is a scratch copy, not a rename source.
+	diff := "--- foo.orig\t2026-01-01 00:00:00\n+++ foo\t2026-01-01 00:00:01\n@@ -1,2 +1,2 @@\n one\n-two\n+TWO\n"
+	env := NewLocalExecutionEnvironment(dir)
+	if _, err := env.ApplyPatch(diff); err != nil {
+		t.Fatalf("ApplyPatch: %v", err)
+	}
+	for name, want := range map[string]string{"foo.orig": "one\ntwo\n", "foo": "one\nTWO\n"} {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(b) != want {
+			t.Fatalf("%s: got %q (%v) want %q", name, b, err, want)
+		}
+	}
+}
+
+func TestLocalExecutionEnvironment_ApplyPatch_Renames(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "old.txt"), []byte("one\ntwo\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "taken.txt"), []byte("keep\n"), 0o644)
+	env := NewLocalExecutionEnvironment(dir)
+
+	// A rename onto an existing file is refused and changes nothing.
+	onto := "--- a/old.txt\n+++ b/taken.txt\n@@ -1,2 +1,2 @@\n one\n-two\n+TWO\n"
+	if _, err := env.ApplyPatch(onto); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected rename target error, got %v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "taken.txt")); string(b) != "keep\n" {
+		t.Fatalf("taken.txt overwritten: %q", b)
+	}
+
+	// git diff --no-prefix marks the rename with explicit headers.
+	rename := "diff --git old.txt new.txt\nsimilarity index 50%\nrename from old.txt\nrename to new.txt\n--- old.txt\n+++ new.txt\n@@ -1,2 +1,2 @@\n one\n-two\n+TWO\n"
+	if _, err := env.ApplyPatch(rename); err != nil {
+		t.Fatalf("ApplyPatch rename: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.txt")); !os.IsNotExist(err) {
+		t.Fatalf("old.txt not removed: %v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "new.txt")); string(b) != "one\nTWO\n" {
+		t.Fatalf("new.txt: %q", b)
+	}
+}
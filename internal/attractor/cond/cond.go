@@ -2,77 +2,206 @@ package cond
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/danshapiro/kilroy/internal/attractor/runtime"
 )
 
-// Evaluate evaluates a minimal AND-only condition language used on edges.
+// Clause is one comparison in a condition. Op is empty for a bare key, which
+// is truthy when its value is non-empty and not "false"/"0"/"no".
+type Clause struct {
+	Key     string
+	Op      string
+	Literal string
+}
+
+// Comparison operators, longest first so "==" is not read as "=".
+var operators = []string{"==", "!=", ">=", "<=", "=", ">", "<"}
+
+// IsOrdering reports whether op compares numerically.
+func IsOrdering(op string) bool {
+	return op == ">" || op == ">=" || op == "<" || op == "<="
+}
+
+// Parse splits a condition into OR-ed groups of AND-ed clauses.
+//
+// Grammar (extends attractor-spec.md Section 10):
 //
-// Grammar (per attractor-spec.md Section 10):
+//	ConditionExpr ::= AndExpr ( '||' AndExpr )*
+//	AndExpr       ::= Clause ( '&&' Clause )*
+//	Clause        ::= Key ( Operator Literal )?
+//	Key           ::= 'outcome' | 'preferred_label' | 'notes' | 'failure_reason'
+//	                | 'exit_code' | 'context.' Path | Path
+//	Operator      ::= '=' | '==' | '!=' | '>' | '>=' | '<' | '<=' | 'contains'
+//	Literal       ::= bare text | 'single-quoted' | "double-quoted"
 //
-//	ConditionExpr ::= Clause ( '&&' Clause )*
-//	Clause        ::= Key Operator Literal
-//	Key           ::= 'outcome' | 'preferred_label' | 'context.' Path
-//	Operator      ::= '=' | '!='
+// && binds tighter than ||. Operators and separators inside quotes are literal.
+func Parse(condition string) ([][]Clause, error) {
+	var groups [][]Clause
+	for _, orPart := range splitOutsideQuotes(condition, "||") {
+		var group []Clause
+		for _, raw := range splitOutsideQuotes(orPart, "&&") {
+			raw = strings.TrimSpace(raw)
+			if raw == "" {
+				continue
+			}
+			c, err := parseClause(raw)
+			if err != nil {
+				return nil, err
+			}
+			group = append(group, c)
+		}
+		if len(group) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+// Evaluate evaluates an edge condition (see Parse for the grammar) against a
+// stage outcome and the run context.
 //
-// Missing keys resolve to empty string. Comparisons are exact string comparisons.
+// Missing keys resolve to empty string, so comparisons against unknown
+// variables are false rather than errors, and an empty literal (context.x=)
+// tests for an unset or empty key. = and != are exact string
+// comparisons; ordering operators compare numerically and are false when
+// either side is not a number; contains is a substring test.
 func Evaluate(condition string, outcome runtime.Outcome, ctx *runtime.Context) (bool, error) {
 	condition = strings.TrimSpace(condition)
 	if condition == "" {
 		return true, nil
 	}
-	clauses := strings.Split(condition, "&&")
-	for _, clause := range clauses {
-		clause = strings.TrimSpace(clause)
-		if clause == "" {
+	groups, err := Parse(condition)
+	if err != nil {
+		return false, err
+	}
+	if len(groups) == 0 {
+		return true, nil
+	}
+	for _, group := range groups {
+		ok := true
+		for _, c := range group {
+			if !evalClause(c, outcome, ctx) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func parseClause(clause string) (Clause, error) {
+	key, op, lit, found := cutOperator(clause)
+	if !found {
+		return Clause{Key: clause}, nil
+	}
+	key = strings.TrimSpace(key)
+	lit = strings.TrimSpace(lit)
+	if key == "" {
+		return Clause{}, fmt.Errorf("invalid clause: %q", clause)
+	}
+	if n := len(lit); n >= 2 && (lit[0] == '\'' || lit[0] == '"') {
+		if lit[n-1] != lit[0] {
+			return Clause{}, fmt.Errorf("invalid clause %q: unterminated quote", clause)
+		}
+		lit = lit[1 : n-1]
+	}
+	return Clause{Key: key, Op: op, Literal: lit}, nil
+}
+
+// cutOperator finds the first operator outside quotes, including the word
+// "contains" when surrounded by spaces.
+func cutOperator(clause string) (key, op, lit string, found bool) {
+	var quote byte
+	for i := 0; i < len(clause); i++ {
+		ch := clause[i]
+		if quote != 0 {
+			if ch == quote {
+				quote = 0
+			}
 			continue
 		}
-		ok, err := evalClause(clause, outcome, ctx)
-		if err != nil {
-			return false, err
+		if ch == '\'' || ch == '"' {
+			quote = ch
+			continue
 		}
-		if !ok {
-			return false, nil
+		if ch == ' ' && strings.HasPrefix(clause[i:], " contains ") {
+			return clause[:i], "contains", clause[i+len(" contains "):], true
+		}
+		for _, o := range operators {
+			if strings.HasPrefix(clause[i:], o) {
+				return clause[:i], o, clause[i+len(o):], true
+			}
 		}
 	}
-	return true, nil
+	return clause, "", "", false
 }
 
-func evalClause(clause string, outcome runtime.Outcome, ctx *runtime.Context) (bool, error) {
-	if strings.Contains(clause, "!=") {
-		parts := strings.SplitN(clause, "!=", 2)
-		if len(parts) != 2 {
-			return false, fmt.Errorf("invalid clause: %q", clause)
-		}
-		k := strings.TrimSpace(parts[0])
-		want := strings.TrimSpace(parts[1])
-		got := resolveKey(k, outcome, ctx)
-		want = canonicalizeCompareValue(k, want)
-		return got != want, nil
-	}
-	if strings.Contains(clause, "=") {
-		parts := strings.SplitN(clause, "=", 2)
-		if len(parts) != 2 {
-			return false, fmt.Errorf("invalid clause: %q", clause)
-		}
-		k := strings.TrimSpace(parts[0])
-		want := strings.TrimSpace(parts[1])
-		got := resolveKey(k, outcome, ctx)
-		want = canonicalizeCompareValue(k, want)
-		return got == want, nil
-	}
-	// Bare key: truthy if non-empty and not "false"/"0" (best-effort).
-	got := resolveKey(strings.TrimSpace(clause), outcome, ctx)
-	if got == "" {
-		return false, nil
-	}
-	switch strings.ToLower(got) {
-	case "false", "0", "no":
-		return false, nil
-	default:
-		return true, nil
+func splitOutsideQuotes(s string, sep string) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if quote != 0 {
+			if ch == quote {
+				quote = 0
+			}
+			continue
+		}
+		if ch == '\'' || ch == '"' {
+			quote = ch
+			continue
+		}
+		if strings.HasPrefix(s[i:], sep) {
+			parts = append(parts, s[start:i])
+			i += len(sep) - 1
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func evalClause(c Clause, outcome runtime.Outcome, ctx *runtime.Context) bool {
+	got := resolveKey(c.Key, outcome, ctx)
+	switch c.Op {
+	case "":
+		if got == "" {
+			return false
+		}
+		switch strings.ToLower(got) {
+		case "false", "0", "no":
+			return false
+		default:
+			return true
+		}
+	case "=", "==":
+		return got == canonicalizeCompareValue(c.Key, c.Literal)
+	case "!=":
+		return got != canonicalizeCompareValue(c.Key, c.Literal)
+	case "contains":
+		return strings.Contains(got, c.Literal)
+	}
+	a, errA := strconv.ParseFloat(strings.TrimSpace(got), 64)
+	b, errB := strconv.ParseFloat(c.Literal, 64)
+	if errA != nil || errB != nil {
+		return false
 	}
+	switch c.Op {
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	}
+	return false
 }
 
 func resolveKey(key string, outcome runtime.Outcome, ctx *runtime.Context) string {
@@ -85,6 +214,16 @@ func resolveKey(key string, outcome runtime.Outcome, ctx *runtime.Context) strin
 		return string(co.Status)
 	case "preferred_label":
 		return outcome.PreferredLabel
+	case "notes":
+		return outcome.Notes
+	case "failure_reason":
+		return outcome.FailureReason
+	case "exit_code":
+		// Tool nodes record their exit code as tool.exit_code.
+		if v, ok := outcome.ContextUpdates["tool.exit_code"]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+		key = "tool.exit_code"
 	}
 	if strings.HasPrefix(key, "context.") {
 		if ctx != nil {
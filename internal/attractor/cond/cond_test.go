@@ -98,3 +98,79 @@ func TestEvaluate_OutcomeAliasesMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluate_ExpressionOperators(t *testing.T) {
+	ctx := runtime.NewContext()
+	ctx.Set("attempts", 3)
+	ctx.Set("tool.exit_code", 0)
+	out := runtime.Outcome{
+		Status:         runtime.StatusFail,
+		Notes:          "suite is flaky today",
+		FailureReason:  "exit status 2",
+		ContextUpdates: map[string]any{"tool.exit_code": 2},
+	}
+
+	cases := []struct {
+		cond string
+		want bool
+	}{
+		{"exit_code > 1", true},
+		{"exit_code >= 2", true},
+		{"exit_code < 2", false},
+		{"exit_code == 2", true},
+		{"context.attempts <= 3", true},
+		{"attempts > 10", false},
+		{"notes contains 'flaky'", true},
+		{`notes contains "stable"`, false},
+		{"failure_reason contains status", true},
+		{"outcome=success || notes contains 'flaky'", true},
+		{"outcome=fail && exit_code > 5 || attempts=3", true},
+		{"outcome=fail && exit_code > 5", false},
+		{"missing > 1", false},
+		{"missing contains x", false},
+		{"notes contains 'a && b' || outcome=fail", true},
+	}
+	for _, tc := range cases {
+		got, err := Evaluate(tc.cond, out, ctx)
+		if err != nil {
+			t.Fatalf("Evaluate(%q) error: %v", tc.cond, err)
+		}
+		if got != tc.want {
+			t.Fatalf("Evaluate(%q)=%v, want %v", tc.cond, got, tc.want)
+		}
+	}
+}
+
+func TestEvaluate_EmptyLiteralIsEmptyString(t *testing.T) {
+	ctx := runtime.NewContext()
+	ctx.Set("context.set", "x")
+	out := runtime.Outcome{Status: runtime.StatusSuccess}
+
+	cases := []struct {
+		cond string
+		want bool
+	}{
+		{"context.unset=", true},
+		{"context.unset!=", false},
+		{"context.set=", false},
+		{"context.set!=", true},
+		{"context.unset=''", true},
+	}
+	for _, tc := range cases {
+		got, err := Evaluate(tc.cond, out, ctx)
+		if err != nil {
+			t.Fatalf("Evaluate(%q) error: %v", tc.cond, err)
+		}
+		if got != tc.want {
+			t.Fatalf("Evaluate(%q)=%v, want %v", tc.cond, got, tc.want)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, c := range []string{"notes contains 'open", "=fail"} {
+		if _, err := Parse(c); err == nil {
+			t.Fatalf("Parse(%q): expected error", c)
+		}
+	}
+}
@@ -2,8 +2,9 @@ package dot
 
 import "fmt"
 
-// stripComments removes // and /* */ comments from DOT source, while preserving comment-like
-// sequences inside double-quoted strings.
+// stripComments blanks out // and /* */ comments from DOT source, while preserving comment-like
+// sequences inside double-quoted strings. Comments are replaced by spaces (keeping their
+// newlines) so byte offsets and line numbers still match the original source.
 func stripComments(src []byte) ([]byte, error) {
 	out := make([]byte, 0, len(src))
 	inString := false
@@ -39,15 +40,16 @@ func stripComments(src []byte) ([]byte, error) {
 		if ch == '/' && i+1 < len(src) {
 			next := src[i+1]
 			if next == '/' {
-				// Line comment: skip until newline (but keep the newline).
-				i += 2
+				// Line comment: blank until newline (but keep the newline).
 				for i < len(src) && src[i] != '\n' {
+					out = append(out, ' ')
 					i++
 				}
 				continue
 			}
 			if next == '*' {
-				// Block comment: skip until closing */.
+				// Block comment: blank until closing */, keeping newlines.
+				start := i
 				i += 2
 				for i+1 < len(src) && !(src[i] == '*' && src[i+1] == '/') {
 					i++
@@ -56,6 +58,13 @@ func stripComments(src []byte) ([]byte, error) {
 					return nil, fmt.Errorf("dot: unterminated block comment")
 				}
 				i += 2
+				for _, c := range src[start:i] {
+					if c == '\n' {
+						out = append(out, '\n')
+					} else {
+						out = append(out, ' ')
+					}
+				}
 				continue
 			}
 		}
@@ -2,6 +2,7 @@ package dot
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/danshapiro/kilroy/internal/attractor/model"
@@ -16,7 +17,8 @@ func Parse(dotSource []byte) (*model.Graph, error) {
 		return nil, err
 	}
 	p := &parser{
-		lx: newLexer(clean),
+		lx:         newLexer(clean),
+		lineStarts: lineStarts(clean),
 	}
 	if err := p.read(); err != nil {
 		return nil, err
@@ -28,6 +30,24 @@ type parser struct {
 	lx   *lexer
 	peek token
 	has  bool
+	// lineStarts holds the byte offset of each line's first byte.
+	lineStarts []int
+}
+
+func lineStarts(src []byte) []int {
+	starts := []int{0}
+	for i, ch := range src {
+		if ch == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// position converts a byte offset in the source into a model.Pos.
+func (p *parser) position(offset int) model.Pos {
+	line := sort.Search(len(p.lineStarts), func(i int) bool { return p.lineStarts[i] > offset })
+	return model.Pos{Offset: offset, Line: line, Column: offset - p.lineStarts[line-1] + 1}
 }
 
 func (p *parser) read() error {
@@ -253,6 +273,7 @@ func (p *parser) parseStatements(g *model.Graph, sc *scope) error {
 				// Edge statement.
 				from := tok.lit
 				chain := []string{from}
+				chainPos := []int{tok.pos}
 				for {
 					// consume ->
 					if _, err := p.next(); err != nil {
@@ -266,6 +287,7 @@ func (p *parser) parseStatements(g *model.Graph, sc *scope) error {
 						return fmt.Errorf("dot parse: expected edge target identifier, got %q at %d", toTok.lit, toTok.pos)
 					}
 					chain = append(chain, toTok.lit)
+					chainPos = append(chainPos, toTok.pos)
 
 					if err := p.read(); err != nil {
 						return err
@@ -289,6 +311,7 @@ func (p *parser) parseStatements(g *model.Graph, sc *scope) error {
 
 				for i := 0; i+1 < len(chain); i++ {
 					e := model.NewEdge(chain[i], chain[i+1])
+					e.Pos = p.position(chainPos[i])
 					// Defaults first, then explicit attrs.
 					for k, v := range sc.edgeDefaults {
 						e.Attrs[k] = v
@@ -317,6 +340,7 @@ func (p *parser) parseStatements(g *model.Graph, sc *scope) error {
 
 			n := model.NewNode(tok.lit)
 			n.Order = len(g.Nodes)
+			n.Pos = p.position(tok.pos)
 			for k, v := range sc.nodeDefaults {
 				n.Attrs[k] = v
 			}
@@ -145,6 +145,42 @@ digraph G {
 	}
 }
 
+func TestParse_RecordsSourcePositions(t *testing.T) {
+	src := []byte(`digraph G {
+  /* a block comment
+     spanning lines */ start [shape=Mdiamond]
+  // a line comment
+  exit [shape=Msquare]
+  start -> mid -> exit
+  mid [label="x"]
+}`)
+	g, err := Parse(src)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	for id, want := range map[string]model.Pos{
+		"start": {Offset: 56, Line: 3, Column: 24},
+		"exit":  {Offset: 101, Line: 5, Column: 3},
+		"mid":   {Offset: 147, Line: 7, Column: 3},
+	} {
+		if got := g.Nodes[id].Pos; got != want {
+			t.Fatalf("%s pos: got %+v want %+v", id, got, want)
+		}
+		if string(src[g.Nodes[id].Pos.Offset:][:len(id)]) != id {
+			t.Fatalf("%s offset does not point at its identifier", id)
+		}
+	}
+	if len(g.Edges) != 2 {
+		t.Fatalf("edges: %d", len(g.Edges))
+	}
+	if got := g.Edges[0].Pos.String(); got != "6:3" {
+		t.Fatalf("start -> mid pos: got %q", got)
+	}
+	if got := g.Edges[1].Pos.String(); got != "6:12" {
+		t.Fatalf("mid -> exit pos: got %q", got)
+	}
+}
+
 func contains(xs []string, want string) bool {
 	for _, x := range xs {
 		if x == want {
@@ -87,6 +87,78 @@ echo '{"type":"done","text":"ok"}'
 	}
 }
 
+func TestAnthropicCLIContract_PathEnvWrapperAndExtraArgs(t *testing.T) {
+	repo := initTestRepo(t)
+	logsRoot := t.TempDir()
+	catalog := writeCatalogForPreflight(t, `{
+  "data": [
+    {"id": "anthropic/claude-sonnet-4-20250514"}
+  ]
+}`)
+	cxdbSrv := newCXDBTestServer(t)
+
+	wrapper := filepath.Join(t.TempDir(), "claude-wrapper")
+	if err := os.WriteFile(wrapper, []byte(`#!/usr/bin/env bash
+set -euo pipefail
+if [[ "${1:-}" == "--help" ]]; then
+cat <<'EOF'
+Usage: claude -p --dangerously-skip-permissions --output-format stream-json --verbose --model MODEL
+EOF
+exit 0
+fi
+cat > status.json <<'JSON'
+{"status":"success","notes":"ok"}
+JSON
+echo '{"type":"done","text":"ok"}'
+`), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KILROY_CLAUDE_PATH", wrapper)
+
+	cfg := &RunConfigFile{Version: 1}
+	cfg.Repo.Path = repo
+	cfg.CXDB.BinaryAddr = cxdbSrv.BinaryAddr()
+	cfg.CXDB.HTTPBaseURL = cxdbSrv.URL()
+	cfg.LLM.CLIProfile = "test_shim"
+	cfg.LLM.Providers = map[string]ProviderConfig{
+		"anthropic": {Backend: BackendCLI, ExtraArgs: []string{"--max-turns", "7"}},
+	}
+	cfg.ModelDB.OpenRouterModelInfoPath = catalog
+	cfg.ModelDB.OpenRouterModelInfoUpdatePolicy = "pinned"
+	cfg.Git.RunBranchPrefix = "attractor/run"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	res, err := RunWithConfig(ctx, singleProviderDot("anthropic", "claude-sonnet-4-20250514"), cfg, RunOptions{RunID: "anthropic-wrapper", LogsRoot: logsRoot, AllowTestShim: true})
+	if err != nil {
+		t.Fatalf("RunWithConfig: %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(res.LogsRoot, "a", "cli_invocation.json"))
+	if err != nil {
+		t.Fatalf("read cli_invocation.json: %v", err)
+	}
+	var inv map[string]any
+	if err := json.Unmarshal(b, &inv); err != nil {
+		t.Fatalf("decode cli_invocation.json: %v", err)
+	}
+	if got := anyToString(inv["executable"]); got != wrapper {
+		t.Fatalf("executable: got %q want %q", got, wrapper)
+	}
+	argvAny, _ := inv["argv"].([]any)
+	argv := make([]string, 0, len(argvAny))
+	for _, v := range argvAny {
+		argv = append(argv, anyToString(v))
+	}
+	joined := strings.Join(argv, " ")
+	if !strings.Contains(joined, "--max-turns 7") {
+		t.Fatalf("expected extra args in argv, got %v", argv)
+	}
+	if !strings.HasPrefix(joined, "-p <prompt>") {
+		t.Fatalf("prompt should still follow -p, got %v", argv)
+	}
+}
+
 func TestAnthropicCLIContract_PreflightFailsWhenVerboseCapabilityMissing(t *testing.T) {
 	repo := initTestRepo(t)
 	catalog := writeCatalogForPreflight(t, `{
@@ -34,12 +34,13 @@ func newAPIClientFromProviderRuntimes(runtimes map[string]ProviderRuntime) (*llm
 			c.Register(google.NewWithProvider(key, apiKey, resolveBuiltInBaseURLOverride(key, rt.API.DefaultBaseURL)))
 		case providerspec.ProtocolOpenAIChatCompletions:
 			c.Register(openaicompat.NewAdapter(openaicompat.Config{
-				Provider:     key,
-				APIKey:       apiKey,
-				BaseURL:      resolveBuiltInBaseURLOverride(key, rt.API.DefaultBaseURL),
-				Path:         rt.API.DefaultPath,
-				OptionsKey:   rt.API.ProviderOptionsKey,
-				ExtraHeaders: rt.APIHeaders(),
+				Provider:      key,
+				APIKey:        apiKey,
+				BaseURL:       resolveBuiltInBaseURLOverride(key, rt.API.DefaultBaseURL),
+				Path:          rt.API.DefaultPath,
+				OptionsKey:    rt.API.ProviderOptionsKey,
+				ExtraHeaders:  rt.APIHeaders(),
+				MetadataField: rt.API.MetadataField,
 			}))
 		default:
 			return nil, fmt.Errorf("unsupported api protocol %q for provider %s", rt.API.Protocol, key)
@@ -164,3 +164,95 @@ func writeTarGz(dstPath string, srcDir string, include tarFilter) error {
 	}
 	return os.Rename(tmp, dstPath)
 }
+
+// includeInLogsArchive extends includeInRunArchive for operator archives of a
+// whole logs root: files that only describe a live process (pid lock, the
+// overwritten live.json heartbeat, lock and temp files) are left out.
+func includeInLogsArchive(rel string, d fs.DirEntry) bool {
+	if !includeInRunArchive(rel, d) {
+		return false
+	}
+	switch rel {
+	case "run.pid", "live.json":
+		return false
+	}
+	return !strings.HasSuffix(rel, ".lock") && !strings.HasSuffix(rel, ".tmp")
+}
+
+// ArchiveLogsRoot packages a finished run's logs root as a single tar.gz at
+// outPath. The git worktree is not included.
+func ArchiveLogsRoot(logsRoot string, outPath string) error {
+	st, err := os.Stat(logsRoot)
+	if err != nil {
+		return err
+	}
+	if !st.IsDir() {
+		return fmt.Errorf("logs root is not a directory: %s", logsRoot)
+	}
+	absRoot, err := filepath.Abs(logsRoot)
+	if err != nil {
+		return err
+	}
+	absOut, err := filepath.Abs(outPath)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(absRoot, absOut); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("archive output must be outside the logs root: %s", outPath)
+	}
+	return writeTarGz(absOut, absRoot, includeInLogsArchive)
+}
+
+// ExtractLogsArchive unpacks an archive written by ArchiveLogsRoot into
+// destDir. Entries that would escape destDir are rejected; symlinks and other
+// special files are skipped.
+func ExtractLogsArchive(archivePath string, destDir string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", archivePath, err)
+	}
+	defer func() { _ = gz.Close() }()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read %s: %w", archivePath, err)
+		}
+		name := filepath.FromSlash(hdr.Name)
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) || filepath.Clean(name) != name {
+			return fmt.Errorf("archive entry escapes destination: %s", hdr.Name)
+		}
+		dst := filepath.Join(destDir, name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(dst, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, hdr.FileInfo().Mode().Perm())
+			if err != nil {
+				return err
+			}
+			_, copyErr := io.Copy(out, tr)
+			closeErr := out.Close()
+			if copyErr != nil {
+				return copyErr
+			}
+			if closeErr != nil {
+				return closeErr
+			}
+		}
+	}
+}
@@ -18,10 +18,15 @@ type BackoffConfig struct {
 	BackoffFactor  float64
 	MaxDelayMS     int
 	Jitter         bool
+	// JitterFraction is the ±fraction (0..1] applied when Jitter is set.
+	// Zero means the spec's ±50%.
+	JitterFraction float64
 }
 
+const defaultJitterFraction = 0.5
+
 func defaultBackoffConfig() BackoffConfig {
-	// Spec defaults: 200ms / factor 2.0 / cap 60s / jitter on.
+	// Spec defaults: 200ms / factor 2.0 / cap 60s / jitter on (±50%).
 	// Jitter is deterministic (SHA-256 seeded from runID:nodeID:attempt), so
 	// identical inputs always produce identical delays — replay determinism is preserved.
 	return BackoffConfig{
@@ -29,6 +34,7 @@ func defaultBackoffConfig() BackoffConfig {
 		BackoffFactor:  2.0,
 		MaxDelayMS:     60_000,
 		Jitter:         true,
+		JitterFraction: defaultJitterFraction,
 	}
 }
 
@@ -60,7 +66,16 @@ func backoffConfigFor(g *model.Graph, n *model.Node) BackoffConfig {
 		cfg.MaxDelayMS = parseInt(v, cfg.MaxDelayMS)
 	}
 	if v := strings.TrimSpace(get("retry.backoff.jitter")); v != "" {
-		cfg.Jitter = parseBool(v, cfg.Jitter)
+		// A number is the jitter fraction (0 disables it); true/false keep
+		// the boolean form with the default fraction.
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			if f >= 0 && f <= 1 {
+				cfg.Jitter = f > 0
+				cfg.JitterFraction = f
+			}
+		} else {
+			cfg.Jitter = parseBool(v, cfg.Jitter)
+		}
 	}
 
 	// Sanity.
@@ -91,10 +106,18 @@ func DelayForAttempt(attempt int, cfg BackoffConfig, jitterSeed string) time.Dur
 		baseMS = math.Min(baseMS, float64(cfg.MaxDelayMS))
 	}
 
-	// Apply jitter after capping (matches spec pseudocode).
+	// Apply jitter after capping (matches spec pseudocode), then cap again so
+	// jitter never pushes a delay past max_delay_ms.
 	if cfg.Jitter {
-		m := 0.5 + jitterUnit(jitterSeed) // [0.5, 1.5]
+		f := cfg.JitterFraction
+		if f <= 0 || f > 1 {
+			f = defaultJitterFraction
+		}
+		m := 1 - f + 2*f*jitterUnit(jitterSeed) // [1-f, 1+f]
 		baseMS *= m
+		if cfg.MaxDelayMS > 0 {
+			baseMS = math.Min(baseMS, float64(cfg.MaxDelayMS))
+		}
 	}
 
 	if baseMS < 0 {
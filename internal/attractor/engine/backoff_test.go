@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -97,3 +98,44 @@ func TestBackoffConfigFor_ParsesGraphAndNodeOverrides(t *testing.T) {
 		t.Fatalf("expected jittered 25ms delay within [12ms, 38ms], got %v", d)
 	}
 }
+
+func TestDelayForAttempt_JitterFraction_RangeAndCap(t *testing.T) {
+	cfg := BackoffConfig{
+		InitialDelayMS: 1000,
+		BackoffFactor:  1.0,
+		MaxDelayMS:     1100,
+		Jitter:         true,
+		JitterFraction: 0.2,
+	}
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 50; i++ {
+		d := DelayForAttempt(1, cfg, fmt.Sprintf("seed-%d", i))
+		if d < 800*time.Millisecond || d > 1100*time.Millisecond {
+			t.Fatalf("seed %d: got %v want within [800ms, 1100ms] (±20%%, capped)", i, d)
+		}
+		seen[d] = true
+	}
+	if len(seen) < 2 {
+		t.Fatalf("expected jittered delays to differ across seeds")
+	}
+}
+
+func TestBackoffConfigFor_JitterFraction(t *testing.T) {
+	g := model.NewGraph("g")
+	n := model.NewNode("n")
+	if cfg := backoffConfigFor(g, n); !cfg.Jitter || cfg.JitterFraction != 0.5 {
+		t.Fatalf("default jitter: %+v", cfg)
+	}
+	g.Attrs["retry.backoff.jitter"] = "0.25"
+	if cfg := backoffConfigFor(g, n); !cfg.Jitter || cfg.JitterFraction != 0.25 {
+		t.Fatalf("fraction: %+v", cfg)
+	}
+	g.Attrs["retry.backoff.jitter"] = "0"
+	if cfg := backoffConfigFor(g, n); cfg.Jitter {
+		t.Fatalf("zero fraction should disable jitter: %+v", cfg)
+	}
+	g.Attrs["retry.backoff.jitter"] = "1.5"
+	if cfg := backoffConfigFor(g, n); !cfg.Jitter || cfg.JitterFraction != 0.5 {
+		t.Fatalf("out-of-range fraction should keep the default: %+v", cfg)
+	}
+}
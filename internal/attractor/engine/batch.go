@@ -0,0 +1,272 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+// BatchInput is one line of a batch inputs file (JSON lines). Every field is
+// optional: an empty RunID is generated, an empty Graph falls back to the
+// batch default graph.
+type BatchInput struct {
+	RunID   string            `json:"run_id,omitempty"`
+	Graph   string            `json:"graph,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Context map[string]any    `json:"context,omitempty"`
+}
+
+// BatchOptions controls how RunBatch schedules and places runs.
+type BatchOptions struct {
+	// LogsRoot is the parent directory for all runs; each run is written to
+	// <LogsRoot>/<run_id>. When empty, each run uses the default logs root.
+	LogsRoot string
+
+	// DefaultGraphPath is used for inputs that do not name a graph.
+	DefaultGraphPath string
+
+	// Concurrency bounds the number of runs executing at once. Values < 1 mean 1.
+	Concurrency int
+
+	// Overrides are applied to every run (AllowTestShim, DisableCXDB,
+	// ForceModels, ProgressSink, ...). RunID, LogsRoot and Labels are taken
+	// from each input instead; Overrides.InitialContext provides defaults that
+	// each input's context overrides key by key.
+	Overrides RunOptions
+}
+
+// BatchRunResult is the outcome of a single batch input.
+type BatchRunResult struct {
+	RunID          string `json:"run_id"`
+	Graph          string `json:"graph"`
+	LogsRoot       string `json:"logs_root,omitempty"`
+	Status         string `json:"status"`
+	FinalCommitSHA string `json:"final_commit_sha,omitempty"`
+	Error          string `json:"error,omitempty"`
+	DurationMS     int64  `json:"duration_ms"`
+}
+
+// BatchSummary aggregates all batch run results in input order. Canceled
+// counts runs cut short or never started because the batch was canceled;
+// they are not counted as Failed.
+type BatchSummary struct {
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Canceled  int              `json:"canceled"`
+	Runs      []BatchRunResult `json:"runs"`
+}
+
+// OK reports whether every run in the batch succeeded.
+func (s *BatchSummary) OK() bool {
+	return s != nil && s.Failed == 0 && s.Succeeded == s.Total
+}
+
+// LoadBatchInputs parses a JSON-lines batch file. Blank lines and lines
+// starting with '#' are skipped. Relative graph paths are resolved against the
+// directory containing the batch file.
+func LoadBatchInputs(path string) ([]BatchInput, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	baseDir := filepath.Dir(path)
+	var inputs []BatchInput
+	seen := map[string]int{}
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var in BatchInput
+		dec := json.NewDecoder(strings.NewReader(line))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&in); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		in.RunID = strings.TrimSpace(in.RunID)
+		in.Graph = strings.TrimSpace(in.Graph)
+		if in.RunID != "" {
+			if prev, dup := seen[in.RunID]; dup {
+				return nil, fmt.Errorf("%s:%d: duplicate run_id %q (first seen on line %d)", path, lineNo, in.RunID, prev)
+			}
+			seen[in.RunID] = lineNo
+		}
+		if in.Graph != "" && !filepath.IsAbs(in.Graph) {
+			in.Graph = filepath.Join(baseDir, in.Graph)
+		}
+		inputs = append(inputs, in)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%s: no batch inputs", path)
+	}
+	return inputs, nil
+}
+
+// RunBatch executes each input through RunWithConfig with bounded concurrency
+// and returns a summary in input order. Individual run failures are recorded in
+// the summary rather than returned; the error return is reserved for problems
+// that prevent the batch from being scheduled at all. When opts.LogsRoot is set,
+// the summary is also written to <LogsRoot>/batch_summary.json.
+func RunBatch(ctx context.Context, cfg *RunConfigFile, inputs []BatchInput, opts BatchOptions) (*BatchSummary, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config is nil")
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no batch inputs")
+	}
+	// Resolve run ids up front so logs roots are known before any run starts
+	// and generated ids can be checked for collisions with explicit ones.
+	runIDs := make([]string, len(inputs))
+	seen := map[string]bool{}
+	for i, in := range inputs {
+		id := strings.TrimSpace(in.RunID)
+		if id == "" {
+			gen, err := NewRunID()
+			if err != nil {
+				return nil, err
+			}
+			id = gen
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("duplicate batch run_id %q", id)
+		}
+		seen[id] = true
+		if strings.TrimSpace(inputs[i].Graph) == "" && strings.TrimSpace(opts.DefaultGraphPath) == "" {
+			return nil, fmt.Errorf("batch input %d (%s) has no graph and no default graph was provided", i+1, id)
+		}
+		runIDs[i] = id
+	}
+	if root := strings.TrimSpace(opts.LogsRoot); root != "" {
+		if err := os.MkdirAll(root, 0o755); err != nil {
+			return nil, fmt.Errorf("cannot create batch logs directory %s: %w", root, err)
+		}
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]BatchRunResult, len(inputs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range inputs {
+		graphPath := strings.TrimSpace(inputs[i].Graph)
+		if graphPath == "" {
+			graphPath = strings.TrimSpace(opts.DefaultGraphPath)
+		}
+		results[i] = BatchRunResult{RunID: runIDs[i], Graph: graphPath}
+		if strings.TrimSpace(opts.LogsRoot) != "" {
+			results[i].LogsRoot = filepath.Join(opts.LogsRoot, runIDs[i])
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Status = string(runtime.FinalCanceled)
+			results[i].Error = fmt.Sprintf("not started: %v", runContextError(ctx))
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = runBatchInput(ctx, cfg, inputs[i], results[i], opts.Overrides)
+		}(i)
+	}
+	wg.Wait()
+
+	summary := &BatchSummary{Total: len(results), Runs: results}
+	for _, r := range results {
+		switch r.Status {
+		case string(runtime.FinalSuccess):
+			summary.Succeeded++
+		case string(runtime.FinalCanceled):
+			summary.Canceled++
+		default:
+			summary.Failed++
+		}
+	}
+	if root := strings.TrimSpace(opts.LogsRoot); root != "" {
+		if err := writeJSON(filepath.Join(root, "batch_summary.json"), summary); err != nil {
+			return summary, err
+		}
+	}
+	return summary, nil
+}
+
+func runBatchInput(ctx context.Context, cfg *RunConfigFile, in BatchInput, base BatchRunResult, overrides RunOptions) (res BatchRunResult) {
+	res = base
+	started := time.Now()
+	defer func() { res.DurationMS = time.Since(started).Milliseconds() }()
+
+	dotSource, err := os.ReadFile(res.Graph)
+	if err != nil {
+		res.Status = "fail"
+		res.Error = err.Error()
+		return res
+	}
+	// RunWithConfig normalizes the config in place; give each run its own copy
+	// so concurrent runs never share mutable state.
+	runCfg, err := cloneRunConfig(cfg)
+	if err != nil {
+		res.Status = "fail"
+		res.Error = err.Error()
+		return res
+	}
+	opts := overrides
+	opts.RunID = res.RunID
+	opts.LogsRoot = res.LogsRoot
+	opts.WorktreeDir = ""
+	opts.Labels = in.Labels
+	opts.GraphPath = res.Graph
+	opts.InitialContext = map[string]any{}
+	for k, v := range overrides.InitialContext {
+		opts.InitialContext[k] = v
+	}
+	for k, v := range in.Context {
+		opts.InitialContext[k] = v
+	}
+
+	out, err := RunWithConfig(ctx, dotSource, runCfg, opts)
+	if err != nil {
+		res.Status = "fail"
+		if ctx.Err() != nil {
+			res.Status = string(runtime.FinalCanceled)
+		}
+		res.Error = err.Error()
+		return res
+	}
+	res.LogsRoot = out.LogsRoot
+	res.FinalCommitSHA = out.FinalCommitSHA
+	res.Status = string(out.FinalStatus)
+	return res
+}
+
+func cloneRunConfig(cfg *RunConfigFile) (*RunConfigFile, error) {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("clone run config: %w", err)
+	}
+	var out RunConfigFile
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("clone run config: %w", err)
+	}
+	return &out, nil
+}
@@ -0,0 +1,206 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestLoadBatchInputs_ParsesLinesAndResolvesGraphPaths(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "inputs.jsonl")
+	content := strings.Join([]string{
+		`{"run_id":"a","graph":"g.dot","labels":{"team":"core"},"context":{"seed":"x"}}`,
+		``,
+		`# comment`,
+		`{"run_id":"b"}`,
+	}, "\n")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	inputs, err := LoadBatchInputs(path)
+	if err != nil {
+		t.Fatalf("LoadBatchInputs: %v", err)
+	}
+	if len(inputs) != 2 {
+		t.Fatalf("inputs=%d want 2", len(inputs))
+	}
+	if got, want := inputs[0].Graph, filepath.Join(dir, "g.dot"); got != want {
+		t.Fatalf("graph=%q want %q", got, want)
+	}
+	if inputs[0].Labels["team"] != "core" || inputs[0].Context["seed"] != "x" {
+		t.Fatalf("labels/context not parsed: %+v", inputs[0])
+	}
+	if inputs[1].Graph != "" {
+		t.Fatalf("expected empty graph for second input, got %q", inputs[1].Graph)
+	}
+}
+
+func TestLoadBatchInputs_RejectsDuplicateRunIDsAndBadLines(t *testing.T) {
+	cases := map[string]string{
+		"duplicate": "{\"run_id\":\"a\"}\n{\"run_id\":\"a\"}\n",
+		"malformed": "{\"run_id\":\n",
+		"unknown":   "{\"run_id\":\"a\",\"grpah\":\"x.dot\"}\n",
+		"empty":     "\n\n",
+	}
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "inputs.jsonl")
+			_ = os.WriteFile(path, []byte(content), 0o644)
+			if _, err := LoadBatchInputs(path); err == nil {
+				t.Fatalf("expected error for %s input", name)
+			}
+		})
+	}
+}
+
+func TestRunBatch_RunsTwoInputsAndWritesSummary(t *testing.T) {
+	repo := initTestRepo(t)
+	pinned := writePinnedCatalog(t)
+	cxdbSrv := newCXDBTestServer(t)
+	batchRoot := t.TempDir()
+
+	graphPath := filepath.Join(t.TempDir(), "g.dot")
+	_ = os.WriteFile(graphPath, []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  start -> exit
+}
+`), 0o644)
+
+	cfg := &RunConfigFile{}
+	cfg.Version = 1
+	cfg.Repo.Path = repo
+	cfg.CXDB.BinaryAddr = cxdbSrv.BinaryAddr()
+	cfg.CXDB.HTTPBaseURL = cxdbSrv.URL()
+	cfg.ModelDB.OpenRouterModelInfoPath = pinned
+	cfg.ModelDB.OpenRouterModelInfoUpdatePolicy = "pinned"
+
+	inputs := []BatchInput{
+		{RunID: "batch-a", Labels: map[string]string{"input": "a"}, Context: map[string]any{"seed": "alpha"}},
+		{RunID: "batch-b", Graph: graphPath, Labels: map[string]string{"input": "b"}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+	summary, err := RunBatch(ctx, cfg, inputs, BatchOptions{
+		LogsRoot:         batchRoot,
+		DefaultGraphPath: graphPath,
+		Concurrency:      2,
+	})
+	if err != nil {
+		t.Fatalf("RunBatch: %v", err)
+	}
+	if !summary.OK() || summary.Total != 2 || summary.Succeeded != 2 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	for i, want := range []string{"batch-a", "batch-b"} {
+		r := summary.Runs[i]
+		if r.RunID != want {
+			t.Fatalf("runs[%d].RunID=%q want %q", i, r.RunID, want)
+		}
+		if got, wantRoot := r.LogsRoot, filepath.Join(batchRoot, want); got != wantRoot {
+			t.Fatalf("runs[%d].LogsRoot=%q want %q", i, got, wantRoot)
+		}
+		if r.DurationMS <= 0 {
+			t.Fatalf("runs[%d].DurationMS=%d want > 0", i, r.DurationMS)
+		}
+		final := mustReadFinalOutcome(t, filepath.Join(r.LogsRoot, "final.json"))
+		if final.Status != runtime.FinalSuccess {
+			t.Fatalf("%s final status=%q want success", want, final.Status)
+		}
+	}
+
+	b, err := os.ReadFile(filepath.Join(batchRoot, "batch-a", "manifest.json"))
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	var manifest map[string]any
+	_ = json.Unmarshal(b, &manifest)
+	labels, _ := manifest["labels"].(map[string]any)
+	if labels["input"] != "a" {
+		t.Fatalf("manifest labels=%v want input=a", manifest["labels"])
+	}
+
+	cp, err := runtime.LoadCheckpoint(filepath.Join(batchRoot, "batch-a", "checkpoint.json"))
+	if err != nil {
+		t.Fatalf("load checkpoint: %v", err)
+	}
+	if got := cp.ContextValues["seed"]; got != "alpha" {
+		t.Fatalf("seeded context value=%v want alpha", got)
+	}
+
+	b, err = os.ReadFile(filepath.Join(batchRoot, "batch_summary.json"))
+	if err != nil {
+		t.Fatalf("expected batch_summary.json: %v", err)
+	}
+	var written BatchSummary
+	if err := json.Unmarshal(b, &written); err != nil {
+		t.Fatalf("decode batch_summary.json: %v", err)
+	}
+	if len(written.Runs) != 2 || written.Runs[0].DurationMS <= 0 || written.Runs[1].DurationMS <= 0 {
+		t.Fatalf("batch_summary.json durations: %+v", written.Runs)
+	}
+}
+
+func TestRunBatch_RecordsFailedRun(t *testing.T) {
+	repo := initTestRepo(t)
+	pinned := writePinnedCatalog(t)
+	cxdbSrv := newCXDBTestServer(t)
+
+	cfg := &RunConfigFile{}
+	cfg.Version = 1
+	cfg.Repo.Path = repo
+	cfg.CXDB.BinaryAddr = cxdbSrv.BinaryAddr()
+	cfg.CXDB.HTTPBaseURL = cxdbSrv.URL()
+	cfg.ModelDB.OpenRouterModelInfoPath = pinned
+	cfg.ModelDB.OpenRouterModelInfoUpdatePolicy = "pinned"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	summary, err := RunBatch(ctx, cfg, []BatchInput{{RunID: "missing-graph", Graph: filepath.Join(t.TempDir(), "nope.dot")}}, BatchOptions{
+		LogsRoot: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("RunBatch: %v", err)
+	}
+	if summary.OK() || summary.Failed != 1 || summary.Runs[0].Error == "" {
+		t.Fatalf("expected one failed run with error, got %+v", summary)
+	}
+}
+
+func TestRunBatch_CanceledBatchCountsRunsAsCanceled(t *testing.T) {
+	cfg := &RunConfigFile{}
+	cfg.Version = 1
+	cfg.Repo.Path = initTestRepo(t)
+	graph := filepath.Join(t.TempDir(), "g.dot")
+	if err := os.WriteFile(graph, catalogPinningDot, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Either input may still be admitted after cancel; both must end canceled.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	summary, err := RunBatch(ctx, cfg, []BatchInput{{RunID: "a"}, {RunID: "b"}}, BatchOptions{
+		LogsRoot:         t.TempDir(),
+		DefaultGraphPath: graph,
+	})
+	if err != nil {
+		t.Fatalf("RunBatch: %v", err)
+	}
+	if summary.OK() || summary.Canceled != 2 || summary.Failed != 0 {
+		t.Fatalf("expected two canceled runs, got %+v", summary)
+	}
+	for _, r := range summary.Runs {
+		if r.Status != string(runtime.FinalCanceled) {
+			t.Fatalf("run %s status=%q want canceled", r.RunID, r.Status)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func driftedCatalogConfig(t *testing.T) *RunConfigFile {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[{"id":"openai/gpt-5.2"},{"id":"openai/gpt-6"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	cxdbSrv := newCXDBTestServer(t)
+
+	cfg := &RunConfigFile{}
+	cfg.Version = 1
+	cfg.Repo.Path = initTestRepo(t)
+	cfg.CXDB.BinaryAddr = cxdbSrv.BinaryAddr()
+	cfg.CXDB.HTTPBaseURL = cxdbSrv.URL()
+	cfg.ModelDB.OpenRouterModelInfoPath = writePinnedCatalog(t)
+	cfg.ModelDB.OpenRouterModelInfoUpdatePolicy = "on_run_start"
+	cfg.ModelDB.OpenRouterModelInfoURL = srv.URL
+	return cfg
+}
+
+var catalogPinningDot = []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  start -> exit
+}
+`)
+
+func TestRunWithConfig_CatalogDrift_RecordedInFinalJSON(t *testing.T) {
+	cfg := driftedCatalogConfig(t)
+	logsRoot := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	res, err := RunWithConfig(ctx, catalogPinningDot, cfg, RunOptions{RunID: "catalog-drift-warn", LogsRoot: logsRoot})
+	if err != nil {
+		t.Fatalf("RunWithConfig: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status=%q want success", res.FinalStatus)
+	}
+	final := mustReadFinalOutcome(t, filepath.Join(logsRoot, "final.json"))
+	drift := final.ModelCatalogDrift
+	if drift == nil || drift.PinnedSHA256 == "" || drift.EffectiveSHA256 == "" || drift.PinnedSHA256 == drift.EffectiveSHA256 {
+		t.Fatalf("expected catalog drift in final.json, got %+v", drift)
+	}
+	if !strings.HasPrefix(drift.Source, "http://") {
+		t.Fatalf("drift source=%q want fetch url", drift.Source)
+	}
+}
+
+func TestRunWithConfig_RequirePinnedCatalog_FailsOnDrift(t *testing.T) {
+	cfg := driftedCatalogConfig(t)
+	logsRoot := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, err := RunWithConfig(ctx, catalogPinningDot, cfg, RunOptions{RunID: "catalog-drift-fatal", LogsRoot: logsRoot, RequirePinnedCatalog: true})
+	if err == nil || !strings.Contains(err.Error(), "model catalog drift") {
+		t.Fatalf("expected catalog drift error, got %v", err)
+	}
+	final := mustReadFinalOutcome(t, filepath.Join(logsRoot, "final.json"))
+	if final.Status != runtime.FinalFail || final.RunID != "catalog-drift-fatal" {
+		t.Fatalf("final=%+v want fail for catalog-drift-fatal", final)
+	}
+	if final.ModelCatalogDrift == nil || !strings.Contains(final.FailureReason, final.ModelCatalogDrift.PinnedSHA256) {
+		t.Fatalf("expected drift details in final.json, got %+v", final)
+	}
+	if _, err := os.Stat(filepath.Join(logsRoot, "checkpoint.json")); err == nil {
+		t.Fatalf("run should not have started")
+	}
+}
+
+func TestRunWithConfig_RequirePinnedCatalog_PassesWhenPinned(t *testing.T) {
+	cfg := driftedCatalogConfig(t)
+	cfg.ModelDB.OpenRouterModelInfoUpdatePolicy = "pinned"
+	logsRoot := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := RunWithConfig(ctx, catalogPinningDot, cfg, RunOptions{RunID: "catalog-pinned", LogsRoot: logsRoot, RequirePinnedCatalog: true}); err != nil {
+		t.Fatalf("RunWithConfig: %v", err)
+	}
+	if final := mustReadFinalOutcome(t, filepath.Join(logsRoot, "final.json")); final.ModelCatalogDrift != nil {
+		t.Fatalf("unexpected drift for pinned catalog: %+v", final.ModelCatalogDrift)
+	}
+}
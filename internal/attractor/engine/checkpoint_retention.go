@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danshapiro/kilroy/internal/attractor/gitutil"
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+)
+
+// Checkpoint retention keeps long cyclic runs from growing the run branch by
+// one commit per node forever. Once more than 2N checkpoint commits follow
+// the run's base commit, all but the newest N are squashed into a single
+// commit. The newest checkpoint keeps its tree (only its SHA changes), and
+// checkpoint.json records the rewritten SHA, so resume is unaffected.
+
+// keepCheckpoints returns the effective retention: the smaller positive value
+// of the graph's keep_checkpoints attribute and RunOptions.KeepCheckpoints.
+// 0 keeps every checkpoint.
+func keepCheckpoints(g *model.Graph, opt int) int {
+	graphKeep := 0
+	if g != nil {
+		graphKeep = parseInt(g.Attrs["keep_checkpoints"], 0)
+	}
+	switch {
+	case graphKeep > 0 && opt > 0:
+		return min(graphKeep, opt)
+	case graphKeep > 0:
+		return graphKeep
+	case opt > 0:
+		return opt
+	default:
+		return 0
+	}
+}
+
+// pruneCheckpoints applies the retention policy after the checkpoint commit
+// sha and returns the (possibly rewritten) HEAD SHA. Pruning is best-effort:
+// on failure it warns and leaves the branch as it was.
+func (e *Engine) pruneCheckpoints(nodeID, sha string) string {
+	keep := keepCheckpoints(e.Graph, e.Options.KeepCheckpoints)
+	// Parallel branch engines have no base: their commits reach the run
+	// branch by fast-forward at fan-in and are pruned there.
+	if keep < 1 || strings.TrimSpace(e.baseSHA) == "" {
+		return sha
+	}
+	count, err := gitutil.CountCommits(e.WorktreeDir, e.baseSHA+"..HEAD")
+	if err != nil || count <= 2*keep {
+		return sha
+	}
+	msg := fmt.Sprintf("attractor(%s): squashed checkpoints before %s", e.Options.RunID, nodeID)
+	mu := e.sharedGitLock()
+	mu.Lock()
+	head, squashed, err := gitutil.SquashOldCommits(e.WorktreeDir, e.baseSHA, keep, msg)
+	mu.Unlock()
+	if err != nil {
+		e.Warn(fmt.Sprintf("checkpoint retention: %v; keeping all checkpoints", err))
+		return sha
+	}
+	if head == "" {
+		return sha
+	}
+	e.appendProgress(map[string]any{
+		"event":        "checkpoints_pruned",
+		"node_id":      nodeID,
+		"pruned_count": len(squashed),
+		"pruned_shas":  squashed,
+		"kept":         keep,
+		"previous_sha": sha,
+		"head_sha":     head,
+	})
+	return head
+}
@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestRun_KeepCheckpointsSquashesOldCheckpointCommits(t *testing.T) {
+	repo := initTestRepo(t)
+	logsRoot := t.TempDir()
+	base := strings.TrimSpace(runCmdOut(t, repo, "git", "rev-parse", "HEAD"))
+	dot := []byte(`
+digraph G {
+  graph [keep_checkpoints=2]
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  a [shape=parallelogram, tool_command="n=$(cat n.txt 2>/dev/null || echo 0); n=$((n+1)); echo $n > n.txt; test $n -lt 8"]
+  start -> a
+  a -> a [condition="outcome=success"]
+  a -> exit [condition="outcome=fail"]
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	res, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunID: "keepcp", LogsRoot: logsRoot})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	var pruned []map[string]any
+	for _, ev := range readProgressEvents(t, filepath.Join(logsRoot, "progress.ndjson")) {
+		if ev["event"] == "checkpoints_pruned" {
+			pruned = append(pruned, ev)
+		}
+	}
+	if len(pruned) == 0 {
+		t.Fatal("expected checkpoints_pruned events")
+	}
+	if shas, _ := pruned[0]["pruned_shas"].([]any); len(shas) < 2 || pruned[0]["kept"] != float64(2) {
+		t.Fatalf("checkpoints_pruned event: %v", pruned[0])
+	}
+
+	head := strings.TrimSpace(runCmdOut(t, repo, "git", "rev-parse", res.RunBranch))
+	count, _ := strconv.Atoi(strings.TrimSpace(runCmdOut(t, repo, "git", "rev-list", "--count", base+".."+head)))
+	if count > 4 {
+		t.Fatalf("run branch has %d checkpoint commits after base, want at most 2*keep_checkpoints", count)
+	}
+	// Squashing rewrites history but not content: the head tree still matches
+	// the worktree.
+	wantN, err := os.ReadFile(filepath.Join(res.WorktreeDir, "n.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(runCmdOut(t, repo, "git", "show", head+":n.txt")); got != strings.TrimSpace(string(wantN)) {
+		t.Fatalf("n.txt at run branch head = %q want %q", got, wantN)
+	}
+
+	// checkpoint.json must name a commit on the rewritten branch so resume
+	// restores the latest state.
+	cp, err := runtime.LoadCheckpoint(filepath.Join(logsRoot, "checkpoint.json"))
+	if err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	runCmd(t, repo, "git", "merge-base", "--is-ancestor", cp.GitCommitSHA, head)
+	if got := strings.TrimSpace(runCmdOut(t, repo, "git", "show", cp.GitCommitSHA+":n.txt")); got != strings.TrimSpace(string(wantN)) {
+		t.Fatalf("n.txt at checkpoint = %q want %q", got, wantN)
+	}
+}
+
+func TestKeepCheckpoints_SmallerPositiveWins(t *testing.T) {
+	for _, tc := range []struct {
+		graph string
+		opt   int
+		want  int
+	}{
+		{"", 0, 0},
+		{"", 5, 5},
+		{"3", 0, 3},
+		{"3", 5, 3},
+		{"9", 5, 5},
+		{"-1", 0, 0},
+	} {
+		g := model.NewGraph("G")
+		g.Attrs["keep_checkpoints"] = tc.graph
+		if got := keepCheckpoints(g, tc.opt); got != tc.want {
+			t.Fatalf("keep_checkpoints=%q opt=%d: got %d want %d", tc.graph, tc.opt, got, tc.want)
+		}
+	}
+}
@@ -158,10 +158,16 @@ func extractToolCalls(msg *cliMessage) []cliToolCall {
 	return calls
 }
 
-// parseCLIOutputStream reads NDJSON lines from r and emits CXDB turns for each
-// assistant/user message. Designed to run as a goroutine; returns when r is closed.
+// parseCLIOutputStream reads NDJSON lines from r, emits CXDB turns for each
+// assistant/user message, and records assistant token usage in the run
+// metrics. Designed to run as a goroutine; returns when r is closed.
+//
+// The CLI emits one assistant event per content block, each repeating the
+// message's usage, so usage is counted once per message id: only the growth
+// over what that id already reported is recorded.
 func parseCLIOutputStream(ctx context.Context, eng *Engine, nodeID string, r io.Reader) {
 	callMap := map[string]string{}
+	usageByMessage := map[string]cliUsage{}
 	scanner := bufio.NewScanner(r)
 	scanner.Buffer(make([]byte, 0, 256*1024), 4*1024*1024)
 	for scanner.Scan() {
@@ -176,8 +182,20 @@ func parseCLIOutputStream(ctx context.Context, eng *Engine, nodeID string, r io.
 		if ev == nil {
 			continue
 		}
+		if ev.Type == "assistant" && ev.Message != nil && ev.Message.Usage != nil {
+			u := *ev.Message.Usage
+			if id := ev.Message.ID; id != "" {
+				prev := usageByMessage[id]
+				usageByMessage[id] = cliUsage{InputTokens: max(prev.InputTokens, u.InputTokens), OutputTokens: max(prev.OutputTokens, u.OutputTokens)}
+				u = cliUsage{InputTokens: max(0, u.InputTokens-prev.InputTokens), OutputTokens: max(0, u.OutputTokens-prev.OutputTokens)}
+			}
+			eng.recordNodeTokens(nodeID, u.InputTokens, u.OutputTokens, 0)
+		}
 		emitCXDBCLIStreamEvent(ctx, eng, nodeID, ev, callMap)
 	}
+	// Keep draining after a scan error (e.g. an oversized line) so the tee
+	// never blocks the CLI's stdout.
+	_, _ = io.Copy(io.Discard, r)
 }
 
 // extractToolResults returns all tool_result blocks from a user message.
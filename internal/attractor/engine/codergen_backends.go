@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+)
+
+// CodergenBackendFactory creates a named codergen backend. It is called at
+// most once per run (and once per parallel branch), the first time a node
+// selects the backend.
+type CodergenBackendFactory func() (CodergenBackend, error)
+
+var (
+	codergenBackendsMu sync.RWMutex
+	codergenBackends   = map[string]CodergenBackendFactory{}
+)
+
+// RegisterCodergenBackend makes a backend selectable with
+// codergen_backend="<name>" on a codergen node, or on the graph to apply it to
+// every codergen node without its own setting. Registering a name again
+// replaces the earlier factory. Nodes using a registered backend do not need
+// llm_provider.
+func RegisterCodergenBackend(name string, factory CodergenBackendFactory) {
+	name = strings.TrimSpace(name)
+	if name == "" || factory == nil {
+		panic("engine: RegisterCodergenBackend requires a name and a factory")
+	}
+	codergenBackendsMu.Lock()
+	defer codergenBackendsMu.Unlock()
+	codergenBackends[name] = factory
+}
+
+// RegisteredCodergenBackends returns the registered backend names, sorted.
+func RegisteredCodergenBackends() []string {
+	codergenBackendsMu.RLock()
+	defer codergenBackendsMu.RUnlock()
+	names := make([]string, 0, len(codergenBackends))
+	for name := range codergenBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupCodergenBackend(name string) (CodergenBackendFactory, bool) {
+	codergenBackendsMu.RLock()
+	defer codergenBackendsMu.RUnlock()
+	f, ok := codergenBackends[name]
+	return f, ok
+}
+
+// codergenBackendName returns the backend a node selects: its own
+// codergen_backend attribute, else the graph's. Empty means the engine's
+// default backend.
+func codergenBackendName(g *model.Graph, n *model.Node) string {
+	if n != nil {
+		if v := strings.TrimSpace(n.Attr("codergen_backend", "")); v != "" {
+			return v
+		}
+	}
+	if g != nil {
+		return strings.TrimSpace(g.Attrs["codergen_backend"])
+	}
+	return ""
+}
+
+// checkCodergenBackendNames fails fast when the graph selects a backend that
+// has not been registered.
+func checkCodergenBackendNames(g *model.Graph) error {
+	if g == nil {
+		return nil
+	}
+	var unknown []string
+	seen := map[string]bool{}
+	check := func(name string) {
+		if name == "" || seen[name] {
+			return
+		}
+		seen[name] = true
+		if _, ok := lookupCodergenBackend(name); !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	check(strings.TrimSpace(g.Attrs["codergen_backend"]))
+	for _, n := range g.Nodes {
+		if n != nil {
+			check(strings.TrimSpace(n.Attr("codergen_backend", "")))
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("unknown codergen_backend %s (registered: %s)", strings.Join(unknown, ", "), strings.Join(RegisteredCodergenBackends(), ", "))
+}
+
+// codergenBackendFor resolves the backend for a codergen node, creating named
+// backends on first use.
+func (e *Engine) codergenBackendFor(node *model.Node) (CodergenBackend, error) {
+	var g *model.Graph
+	if e != nil {
+		g = e.Graph
+	}
+	name := codergenBackendName(g, node)
+	if name == "" {
+		if e != nil && e.CodergenBackend != nil {
+			return e.CodergenBackend, nil
+		}
+		return &SimulatedCodergenBackend{}, nil
+	}
+	factory, ok := lookupCodergenBackend(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown codergen_backend %q", name)
+	}
+	if e == nil {
+		return factory()
+	}
+	e.namedBackendsMu.Lock()
+	defer e.namedBackendsMu.Unlock()
+	if b, ok := e.namedBackends[name]; ok {
+		return b, nil
+	}
+	b, err := factory()
+	if err != nil {
+		return nil, fmt.Errorf("codergen_backend %q: %w", name, err)
+	}
+	if b == nil {
+		return nil, fmt.Errorf("codergen_backend %q: factory returned nil", name)
+	}
+	if e.namedBackends == nil {
+		e.namedBackends = map[string]CodergenBackend{}
+	}
+	e.namedBackends[name] = b
+	return b, nil
+}
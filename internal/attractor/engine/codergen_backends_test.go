@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+// fakeCodergenBackend records the prompts it receives and always succeeds.
+type fakeCodergenBackend struct {
+	mu      sync.Mutex
+	prompts map[string]string
+}
+
+func (b *fakeCodergenBackend) Run(ctx context.Context, exec *Execution, node *model.Node, prompt string) (string, *runtime.Outcome, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.prompts == nil {
+		b.prompts = map[string]string{}
+	}
+	b.prompts[node.ID] = prompt
+	return "fake response for " + node.ID, &runtime.Outcome{Status: runtime.StatusSuccess}, nil
+}
+
+func TestRun_UsesCodergenBackendFromRunOptions(t *testing.T) {
+	dot := []byte(`digraph G {
+  graph [goal="test"]
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  impl [shape=box, llm_provider=openai, llm_model=gpt-5.2, prompt="implement $goal"]
+  start -> impl -> exit
+}`)
+	backend := &fakeCodergenBackend{}
+	logsRoot := t.TempDir()
+	res, err := Run(context.Background(), dot, RunOptions{RepoPath: initTestRepo(t), LogsRoot: logsRoot, CodergenBackend: backend})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: %s", res.FinalStatus)
+	}
+	if !strings.Contains(backend.prompts["impl"], "implement test") {
+		t.Fatalf("prompt: %q", backend.prompts["impl"])
+	}
+	b, err := os.ReadFile(filepath.Join(logsRoot, "impl", "response.md"))
+	if err != nil || string(b) != "fake response for impl" {
+		t.Fatalf("response.md: %q err=%v", b, err)
+	}
+}
+
+func TestRun_CodergenBackendSelectedByRegisteredName(t *testing.T) {
+	backend := &fakeCodergenBackend{}
+	var created atomic.Int32
+	RegisterCodergenBackend("test-in-house", func() (CodergenBackend, error) {
+		created.Add(1)
+		return backend, nil
+	})
+
+	// No llm_provider: nodes on a registered backend skip provider routing.
+	dot := []byte(`digraph G {
+  graph [goal="test", codergen_backend="test-in-house"]
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  a [shape=box, prompt="first"]
+  b [shape=box, prompt="second"]
+  start -> a -> b -> exit
+}`)
+	res, err := Run(context.Background(), dot, RunOptions{RepoPath: initTestRepo(t), LogsRoot: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: %s", res.FinalStatus)
+	}
+	if len(backend.prompts) != 2 || created.Load() != 1 {
+		t.Fatalf("prompts=%v factory calls=%d", backend.prompts, created.Load())
+	}
+
+	unknown := []byte(strings.Replace(string(dot), `codergen_backend="test-in-house"`, `codergen_backend="nope"`, 1))
+	if _, err := Run(context.Background(), unknown, RunOptions{RepoPath: initTestRepo(t), LogsRoot: t.TempDir()}); err == nil || !strings.Contains(err.Error(), `unknown codergen_backend nope`) {
+		t.Fatalf("expected unknown backend error, got %v", err)
+	}
+}
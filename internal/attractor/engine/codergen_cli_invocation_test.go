@@ -8,7 +8,10 @@ import (
 )
 
 func TestDefaultCLIInvocation_GoogleGeminiNonInteractive(t *testing.T) {
-	exe, args := defaultCLIInvocation("google", "gemini-3-flash-preview", "/tmp/worktree")
+	exe, args, err := defaultCLIInvocation("google", "gemini-3-flash-preview", "/tmp/worktree")
+	if err != nil {
+		t.Fatalf("defaultCLIInvocation: %v", err)
+	}
 	if exe == "" {
 		t.Fatalf("expected non-empty executable for google")
 	}
@@ -33,7 +36,10 @@ func TestDefaultCLIInvocation_GoogleGeminiNonInteractive(t *testing.T) {
 }
 
 func TestDefaultCLIInvocation_AnthropicNormalizesDotsToHyphens(t *testing.T) {
-	exe, args := defaultCLIInvocation("anthropic", "claude-sonnet-4.5", "/tmp/worktree")
+	exe, args, err := defaultCLIInvocation("anthropic", "claude-sonnet-4.5", "/tmp/worktree")
+	if err != nil {
+		t.Fatalf("defaultCLIInvocation: %v", err)
+	}
 	if exe == "" {
 		t.Fatalf("expected non-empty executable for anthropic")
 	}
@@ -67,7 +73,10 @@ func TestDefaultCLIInvocation_StripsProviderPrefixFromModelID(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, args := defaultCLIInvocation(tt.provider, tt.modelID, "/tmp/worktree")
+			_, args, err := defaultCLIInvocation(tt.provider, tt.modelID, "/tmp/worktree")
+			if err != nil {
+				t.Fatalf("defaultCLIInvocation: %v", err)
+			}
 			for i := 0; i < len(args)-1; i++ {
 				if args[i] == "--model" {
 					if args[i+1] != tt.wantModel {
@@ -82,7 +91,10 @@ func TestDefaultCLIInvocation_StripsProviderPrefixFromModelID(t *testing.T) {
 }
 
 func TestDefaultCLIInvocation_AnthropicIncludesVerboseForStreamJSON(t *testing.T) {
-	exe, args := defaultCLIInvocation("anthropic", "claude-sonnet-4", "/tmp/worktree")
+	exe, args, err := defaultCLIInvocation("anthropic", "claude-sonnet-4", "/tmp/worktree")
+	if err != nil {
+		t.Fatalf("defaultCLIInvocation: %v", err)
+	}
 	if exe == "" {
 		t.Fatalf("expected non-empty executable for anthropic")
 	}
@@ -98,14 +110,20 @@ func TestDefaultCLIInvocation_AnthropicIncludesVerboseForStreamJSON(t *testing.T
 }
 
 func TestDefaultCLIInvocation_AnthropicSkipsPermissions(t *testing.T) {
-	_, args := defaultCLIInvocation("anthropic", "claude-sonnet-4-5", "/tmp/worktree")
+	_, args, err := defaultCLIInvocation("anthropic", "claude-sonnet-4-5", "/tmp/worktree")
+	if err != nil {
+		t.Fatalf("defaultCLIInvocation: %v", err)
+	}
 	if !hasArg(args, "--dangerously-skip-permissions") {
 		t.Fatalf("expected --dangerously-skip-permissions for headless CLI mode; args=%v", args)
 	}
 }
 
 func TestDefaultCLIInvocation_OpenAI_DoesNotUseDeprecatedAskForApproval(t *testing.T) {
-	exe, args := defaultCLIInvocation("openai", "gpt-5.3-codex", "/tmp/worktree")
+	exe, args, err := defaultCLIInvocation("openai", "gpt-5.3-codex", "/tmp/worktree")
+	if err != nil {
+		t.Fatalf("defaultCLIInvocation: %v", err)
+	}
 	if exe == "" {
 		t.Fatalf("expected non-empty executable for openai")
 	}
@@ -117,6 +135,62 @@ func TestDefaultCLIInvocation_OpenAI_DoesNotUseDeprecatedAskForApproval(t *testi
 	}
 }
 
+func TestDefaultCLIInvocation_AdditionalCodingAgentsNonInteractive(t *testing.T) {
+	tests := []struct {
+		provider string
+		wantExe  string
+		wantArgs []string
+	}{
+		{"codex", "codex", []string{"exec", "--json", "--sandbox"}},
+		{"aider", "aider", []string{"--yes-always", "--no-auto-commits", "--message"}},
+		{"opencode", "opencode", []string{"run", "--format", "json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			exe, args, err := defaultCLIInvocation(tt.provider, "anthropic/claude-sonnet-4-5", "/tmp/worktree")
+			if err != nil {
+				t.Fatalf("defaultCLIInvocation: %v", err)
+			}
+			if exe != tt.wantExe {
+				t.Fatalf("exe=%q want %q", exe, tt.wantExe)
+			}
+			// Spec/metaspec: CLI adapters must not block on interactive approvals.
+			for _, want := range tt.wantArgs {
+				if !hasArg(args, want) {
+					t.Fatalf("expected %s in args; args=%v", want, args)
+				}
+			}
+			// Agents that route across vendors keep the provider/model form.
+			if !hasArg(args, "anthropic/claude-sonnet-4-5") {
+				t.Fatalf("expected model argument in args; args=%v", args)
+			}
+		})
+	}
+}
+
+func TestDefaultCLIInvocation_AiderPromptFollowsMessageFlag(t *testing.T) {
+	_, args, err := defaultCLIInvocation("aider", "gpt-4o", "/tmp/worktree")
+	if err != nil {
+		t.Fatalf("defaultCLIInvocation: %v", err)
+	}
+	got := insertPromptArg(args, "do the thing")
+	if n := len(got); n < 2 || got[n-2] != "--message" || got[n-1] != "do the thing" {
+		t.Fatalf("expected trailing --message <prompt>; args=%v", got)
+	}
+}
+
+func TestDefaultCLIInvocation_UnknownProviderErrors(t *testing.T) {
+	exe, args, err := defaultCLIInvocation("kimi", "kimi-k2.5", "/tmp/worktree")
+	if err == nil {
+		t.Fatalf("expected error for provider without a cli contract; exe=%q args=%v", exe, args)
+	}
+	for _, want := range []string{"no cli invocation mapping for provider kimi", "aider", "opencode"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error missing %q: %v", want, err)
+		}
+	}
+}
+
 func TestBuildCodexIsolatedEnv_ConfiguresCodexScopedOverrides(t *testing.T) {
 	home := t.TempDir()
 	if err := os.MkdirAll(filepath.Join(home, ".codex"), 0o755); err != nil {
@@ -376,3 +376,80 @@ func TestShouldFailoverLLMError_GetwdBootstrapErrorDoesNotFailover(t *testing.T)
 		t.Fatalf("getwd bootstrap errors should not trigger failover")
 	}
 }
+
+// hangAdapter blocks every call until its context ends, like a provider that
+// accepted the request and never answered.
+type hangAdapter struct{ name string }
+
+func (a *hangAdapter) Name() string { return a.name }
+func (a *hangAdapter) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	<-ctx.Done()
+	return llm.Response{}, llm.WrapContextError(a.name, ctx.Err())
+}
+func (a *hangAdapter) Stream(ctx context.Context, req llm.Request) (llm.Stream, error) {
+	<-ctx.Done()
+	return nil, llm.WrapContextError(a.name, ctx.Err())
+}
+
+func TestCodergenRouter_RunAPI_CallTimeoutIsTransient(t *testing.T) {
+	for _, mode := range []string{"one_shot", "agent_loop"} {
+		t.Run(mode, func(t *testing.T) {
+			r := NewCodergenRouterWithRuntimes(&RunConfigFile{}, nil, map[string]ProviderRuntime{
+				"openai": {Key: "openai", Backend: BackendAPI, API: providerspec.APISpec{Protocol: providerspec.ProtocolOpenAIResponses}},
+			})
+			r.apiClientFactory = func(map[string]ProviderRuntime) (*llm.Client, error) {
+				c := llm.NewClient()
+				c.Register(&hangAdapter{name: "openai"})
+				return c, nil
+			}
+			execCtx := &Execution{
+				LogsRoot:    t.TempDir(),
+				WorktreeDir: t.TempDir(),
+				Engine:      &Engine{Options: RunOptions{CodergenCallTimeout: 50 * time.Millisecond}},
+			}
+			node := &model.Node{ID: "stage-a", Attrs: map[string]string{"codergen_mode": mode}}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			started := time.Now()
+			_, _, err := r.runAPI(ctx, execCtx, node, "openai", "gpt-5.2", "hi")
+			if err == nil {
+				t.Fatal("expected the hung call to time out")
+			}
+			if elapsed := time.Since(started); elapsed > 5*time.Second {
+				t.Fatalf("call took %s; per-call timeout not applied", elapsed)
+			}
+			fc, sig := classifyAPIError(err)
+			if fc != failureClassTransientInfra || sig != "api_transient|openai|call_timeout" {
+				t.Fatalf("classifyAPIError = (%q, %q), want transient call_timeout; err=%v", fc, sig, err)
+			}
+		})
+	}
+}
+
+func TestCodergenCallTimeout_OptionOverridesEnv(t *testing.T) {
+	t.Setenv("KILROY_CODERGEN_CALL_TIMEOUT_MS", "1500")
+	if got := codergenCallTimeout(nil); got != 1500*time.Millisecond {
+		t.Fatalf("env timeout = %s, want 1.5s", got)
+	}
+	execCtx := &Execution{Engine: &Engine{Options: RunOptions{CodergenCallTimeout: time.Minute}}}
+	if got := codergenCallTimeout(execCtx); got != time.Minute {
+		t.Fatalf("option timeout = %s, want 1m", got)
+	}
+	t.Setenv("KILROY_CODERGEN_CALL_TIMEOUT_MS", "")
+	if got := codergenCallTimeout(nil); got != 0 {
+		t.Fatalf("default timeout = %s, want none", got)
+	}
+}
+
+func TestWrapCodergenCallTimeout_IgnoresStageDeadline(t *testing.T) {
+	timeoutErr := llm.NewRequestTimeoutError("openai", "context deadline exceeded")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := wrapCodergenCallTimeout(ctx, timeoutErr, "openai", time.Second); err != timeoutErr {
+		t.Fatalf("stage context done: got %v, want the error unchanged", err)
+	}
+	if err := wrapCodergenCallTimeout(context.Background(), timeoutErr, "openai", 0); err != timeoutErr {
+		t.Fatalf("no timeout configured: got %v, want the error unchanged", err)
+	}
+}
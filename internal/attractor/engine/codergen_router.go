@@ -24,6 +24,7 @@ import (
 	"github.com/danshapiro/kilroy/internal/attractor/runtime"
 	"github.com/danshapiro/kilroy/internal/llm"
 	"github.com/danshapiro/kilroy/internal/llmclient"
+	"github.com/danshapiro/kilroy/internal/providerspec"
 )
 
 // anthropicVersionDotRe matches dots between digits in model version numbers
@@ -174,6 +175,7 @@ func (r *CodergenRouter) runAPI(ctx context.Context, execCtx *Execution, node *m
 	if reasoning != "" {
 		reasoningPtr = &reasoning
 	}
+	callTimeout := codergenCallTimeout(execCtx)
 
 	switch mode {
 	case "one_shot":
@@ -183,20 +185,29 @@ func (r *CodergenRouter) runAPI(ctx context.Context, execCtx *Execution, node *m
 				Model:           mid,
 				Messages:        []llm.Message{llm.User(prompt)},
 				ReasoningEffort: reasoningPtr,
+				Metadata:        requestMetadataForRun(execCtx),
 			}
 			if err := writeJSON(filepath.Join(stageDir, "api_request.json"), req); err != nil {
 				warnEngine(execCtx, fmt.Sprintf("write api_request.json: %v", err))
 			}
 			policy := attractorLLMRetryPolicy(execCtx, node.ID, prov, mid)
 			resp, err := llm.Retry(ctx, policy, nil, nil, func() (llm.Response, error) {
-				return client.Complete(ctx, req)
+				if callTimeout <= 0 {
+					return client.Complete(ctx, req)
+				}
+				callCtx, cancel := context.WithTimeout(ctx, callTimeout)
+				defer cancel()
+				return client.Complete(callCtx, req)
 			})
 			if err != nil {
-				return "", err
+				return "", wrapCodergenCallTimeout(ctx, err, prov, callTimeout)
 			}
 			if err := writeJSON(filepath.Join(stageDir, "api_response.json"), resp.Raw); err != nil {
 				warnEngine(execCtx, fmt.Sprintf("write api_response.json: %v", err))
 			}
+			if execCtx != nil && execCtx.Engine != nil {
+				execCtx.Engine.recordNodeTokens(node.ID, int64(resp.Usage.InputTokens), int64(resp.Usage.OutputTokens), int64(resp.Usage.TotalTokens))
+			}
 			return resp.Text(), nil
 		})
 		if err != nil {
@@ -214,11 +225,15 @@ func (r *CodergenRouter) runAPI(ctx context.Context, execCtx *Execution, node *m
 		for k, v := range contract.EnvVars {
 			stageEnv[k] = v
 		}
+		nodeEnv, allowEnv := nodeEnvVars(node)
+		for k, v := range nodeEnv {
+			stageEnv[k] = v
+		}
 		for k, v := range buildStageRuntimeEnv(execCtx, node.ID) {
 			stageEnv[k] = v
 		}
-		overrides := buildAgentLoopOverrides(execCtx.WorktreeDir, stageEnv)
-		env := agent.NewLocalExecutionEnvironmentWithPolicy(execCtx.WorktreeDir, overrides, []string{"CLAUDECODE"})
+		env := newAgentLoopEnvironment(execCtx.WorktreeDir, stageEnv)
+		env.AllowEnvKeys = allowEnv
 		text, used, err := r.withFailoverText(ctx, execCtx, node, client, provider, modelID, func(prov string, mid string) (string, error) {
 			var profile agent.ProviderProfile
 			var profileErr error
@@ -230,7 +245,9 @@ func (r *CodergenRouter) runAPI(ctx context.Context, execCtx *Execution, node *m
 			if profileErr != nil {
 				return "", profileErr
 			}
-			sessCfg := agent.SessionConfig{}
+			sessCfg := agent.SessionConfig{
+				RequestMetadata: requestMetadataForRun(execCtx),
+			}
 			if reasoning != "" {
 				sessCfg.ReasoningEffort = reasoning
 			}
@@ -255,6 +272,7 @@ func (r *CodergenRouter) runAPI(ctx context.Context, execCtx *Execution, node *m
 			// Give lots of room for transient LLM errors before failing the stage.
 			policy := attractorLLMRetryPolicy(execCtx, node.ID, prov, mid)
 			sessCfg.LLMRetryPolicy = &policy
+			sessCfg.LLMCallTimeout = callTimeout
 			// Spec §9.7: wire pre-hook filter so tool calls can be skipped by
 			// tool_hooks.pre scripts (non-zero exit = skip the tool call).
 			sessCfg.ToolCallFilter = func(toolName, callID, argsJSON string) string {
@@ -294,6 +312,9 @@ func (r *CodergenRouter) runAPI(ctx context.Context, execCtx *Execution, node *m
 					if execCtx != nil && execCtx.Engine != nil {
 						executeToolHookForEvent(ctx, execCtx, node, ev, stageDir)
 					}
+					if u, ok := ev.Data["usage"].(llm.Usage); ok && ev.Kind == agent.EventAssistantTextEnd && execCtx != nil && execCtx.Engine != nil {
+						execCtx.Engine.recordNodeTokens(node.ID, int64(u.InputTokens), int64(u.OutputTokens), int64(u.TotalTokens))
+					}
 					eventsMu.Lock()
 					events = append(events, ev)
 					eventsMu.Unlock()
@@ -351,7 +372,7 @@ func (r *CodergenRouter) runAPI(ctx context.Context, execCtx *Execution, node *m
 			}
 			eventsMu.Unlock()
 			if runErr != nil {
-				return text, runErr
+				return text, wrapCodergenCallTimeout(ctx, runErr, prov, callTimeout)
 			}
 			return text, nil
 		})
@@ -927,6 +948,10 @@ func (r *CodergenRouter) runCLI(ctx context.Context, execCtx *Execution, node *m
 	for k, v := range contract.EnvVars {
 		stageEnv[k] = v
 	}
+	nodeEnv, _ := nodeEnvVars(node)
+	for k, v := range nodeEnv {
+		stageEnv[k] = v
+	}
 	for k, v := range buildStageRuntimeEnv(execCtx, node.ID) {
 		stageEnv[k] = v
 	}
@@ -957,9 +982,9 @@ func (r *CodergenRouter) runCLI(ctx context.Context, execCtx *Execution, node *m
 		return "", classifiedFailure(err, ""), nil
 	}
 
-	defaultExe, args := defaultCLIInvocation(provider, modelID, execCtx.WorktreeDir)
-	if defaultExe == "" {
-		return "", classifiedFailure(fmt.Errorf("no cli invocation mapping for provider %s", provider), ""), nil
+	_, args, err := defaultCLIInvocation(provider, modelID, execCtx.WorktreeDir)
+	if err != nil {
+		return "", classifiedFailure(err, ""), nil
 	}
 	runOpts := RunOptions{}
 	if execCtx != nil && execCtx.Engine != nil {
@@ -969,6 +994,7 @@ func (r *CodergenRouter) runCLI(ctx context.Context, execCtx *Execution, node *m
 	if err != nil {
 		return "", classifiedFailure(err, ""), nil
 	}
+	args = append(args, providerExtraArgs(r.cfg, provider)...)
 	codexSemantics := usesCodexCLISemantics(providerKey, exe)
 
 	// Build the base env once — used by codex initial + retries and non-codex paths.
@@ -1010,8 +1036,7 @@ func (r *CodergenRouter) runCLI(ctx context.Context, execCtx *Execution, node *m
 	actualArgs := args
 	recordedArgs := args
 	promptMode := "stdin"
-	switch normalizeProviderKey(provider) {
-	case "anthropic", "google":
+	if spec := defaultCLISpecForProvider(provider); spec != nil && spec.PromptMode == "arg" {
 		promptMode = "arg"
 		actualArgs = insertPromptArg(args, prompt)
 		recordedArgs = insertPromptArg(args, "<prompt>")
@@ -1107,10 +1132,11 @@ func (r *CodergenRouter) runCLI(ctx context.Context, execCtx *Execution, node *m
 		}
 		defer func() { _ = stderrFile.Close() }()
 		// Tee stdout through a parser goroutine to decompose CLI conversation
-		// turns into individual CXDB events in real time.
+		// turns into individual CXDB events (when enabled) and record token
+		// usage in real time.
 		var streamPW *io.PipeWriter
 		var streamDone chan struct{}
-		if !codexSemantics && execCtx != nil && execCtx.Engine != nil && execCtx.Engine.CXDB != nil {
+		if !codexSemantics && execCtx != nil && execCtx.Engine != nil {
 			pr, pw := io.Pipe()
 			streamPW = pw
 			streamDone = make(chan struct{})
@@ -1394,7 +1420,7 @@ func insertPromptArg(args []string, prompt string) []string {
 	out := []string{}
 	for i := 0; i < len(args); i++ {
 		out = append(out, args[i])
-		if args[i] == "-p" || args[i] == "--print" || args[i] == "--prompt" {
+		if args[i] == "-p" || args[i] == "--print" || args[i] == "--prompt" || args[i] == "--message" {
 			out = append(out, prompt)
 			// Only insert once.
 			out = append(out, args[i+1:]...)
@@ -1623,6 +1649,48 @@ func codergenHeartbeatInterval() time.Duration {
 	return d
 }
 
+// codergenCallTimeout resolves the per-request timeout for API codergen LLM
+// calls: RunOptions.CodergenCallTimeout, else KILROY_CODERGEN_CALL_TIMEOUT_MS,
+// else zero (no limit).
+func codergenCallTimeout(execCtx *Execution) time.Duration {
+	if execCtx != nil && execCtx.Engine != nil && execCtx.Engine.Options.CodergenCallTimeout > 0 {
+		return execCtx.Engine.Options.CodergenCallTimeout
+	}
+	if ms := parseInt(strings.TrimSpace(os.Getenv("KILROY_CODERGEN_CALL_TIMEOUT_MS")), 0); ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return 0
+}
+
+// codergenCallTimeoutError reports an API codergen LLM call that ran past the
+// per-call timeout. classifyAPIError treats it as transient_infra, unlike a
+// plain request timeout, so the node is retried.
+type codergenCallTimeoutError struct {
+	provider string
+	timeout  time.Duration
+	err      error
+}
+
+func (e *codergenCallTimeoutError) Error() string {
+	return fmt.Sprintf("%s llm call exceeded the %s codergen call timeout: %v", e.provider, e.timeout, e.err)
+}
+
+func (e *codergenCallTimeoutError) Unwrap() error { return e.err }
+
+// wrapCodergenCallTimeout marks err as a per-call timeout when one is set,
+// err is a timeout, and the stage context itself is still live (so the
+// deadline that fired was the per-call one, not the stage's or the run's).
+func wrapCodergenCallTimeout(ctx context.Context, err error, provider string, timeout time.Duration) error {
+	if err == nil || timeout <= 0 || ctx.Err() != nil {
+		return err
+	}
+	var rte *llm.RequestTimeoutError
+	if !errors.Is(err, context.DeadlineExceeded) && !errors.As(err, &rte) {
+		return err
+	}
+	return &codergenCallTimeoutError{provider: provider, timeout: timeout, err: err}
+}
+
 func codexStateDBMaxRetries() int {
 	v := strings.TrimSpace(os.Getenv("KILROY_CODEX_STATE_DB_MAX_RETRIES"))
 	if v == "" {
@@ -1837,10 +1905,13 @@ func usesCodexCLISemantics(providerKey string, exe string) bool {
 	return base == "codex" || strings.HasPrefix(base, "codex.")
 }
 
-func defaultCLIInvocation(provider string, modelID string, worktreeDir string) (exe string, args []string) {
+// defaultCLIInvocation builds the headless, non-interactive invocation for a
+// provider's builtin CLI contract (codex, claude, gemini, aider, opencode).
+// Providers without one get an error naming the providers that have one.
+func defaultCLIInvocation(provider string, modelID string, worktreeDir string) (exe string, args []string, err error) {
 	spec := defaultCLISpecForProvider(provider)
 	if spec == nil {
-		return "", nil
+		return "", nil, fmt.Errorf("no cli invocation mapping for provider %s (cli providers: %s)", provider, strings.Join(providerspec.CLIProviders(), ", "))
 	}
 	// Strip the "provider/" prefix from OpenRouter-format model IDs
 	// (e.g. "anthropic/claude-sonnet-4.5" → "claude-sonnet-4.5").
@@ -1854,7 +1925,7 @@ func defaultCLIInvocation(provider string, modelID string, worktreeDir string) (
 		modelID = anthropicVersionDotRe.ReplaceAllString(modelID, "${1}-${2}")
 	}
 	exe, args = materializeCLIInvocation(*spec, modelID, worktreeDir, "")
-	return exe, args
+	return exe, args, nil
 }
 
 func hasArg(args []string, want string) bool {
@@ -2028,3 +2099,17 @@ func warnEngine(execCtx *Execution, msg string) {
 	}
 	execCtx.Engine.Warn(msg)
 }
+
+// requestMetadataForRun returns the run labels as provider request metadata so
+// upstream usage can be attributed to the run. The map is copied because the
+// session and adapters hold onto it for the lifetime of the stage.
+func requestMetadataForRun(execCtx *Execution) map[string]string {
+	if execCtx == nil || execCtx.Engine == nil || len(execCtx.Engine.Options.Labels) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(execCtx.Engine.Options.Labels))
+	for k, v := range execCtx.Engine.Options.Labels {
+		out[k] = v
+	}
+	return out
+}
@@ -3,9 +3,11 @@ package engine
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/danshapiro/kilroy/internal/providerspec"
 
@@ -27,20 +29,27 @@ type ProviderAPIConfig struct {
 	ProviderOptionsKey string            `json:"provider_options_key,omitempty" yaml:"provider_options_key,omitempty"`
 	ProfileFamily      string            `json:"profile_family,omitempty" yaml:"profile_family,omitempty"`
 	Headers            map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	MetadataField      string            `json:"metadata_field,omitempty" yaml:"metadata_field,omitempty"`
 }
 
 type ProviderConfig struct {
 	Backend    BackendKind       `json:"backend" yaml:"backend"`
 	Executable string            `json:"executable,omitempty" yaml:"executable,omitempty"`
+	ExtraArgs  []string          `json:"extra_args,omitempty" yaml:"extra_args,omitempty"`
 	API        ProviderAPIConfig `json:"api,omitempty" yaml:"api,omitempty"`
 	Failover   []string          `json:"failover,omitempty" yaml:"failover,omitempty"`
 }
 
 type RuntimePolicyConfig struct {
-	StageTimeoutMS       *int `json:"stage_timeout_ms,omitempty" yaml:"stage_timeout_ms,omitempty"`
-	StallTimeoutMS       *int `json:"stall_timeout_ms,omitempty" yaml:"stall_timeout_ms,omitempty"`
-	StallCheckIntervalMS *int `json:"stall_check_interval_ms,omitempty" yaml:"stall_check_interval_ms,omitempty"`
-	MaxLLMRetries        *int `json:"max_llm_retries,omitempty" yaml:"max_llm_retries,omitempty"`
+	StageTimeoutMS       *int   `json:"stage_timeout_ms,omitempty" yaml:"stage_timeout_ms,omitempty"`
+	StallTimeoutMS       *int   `json:"stall_timeout_ms,omitempty" yaml:"stall_timeout_ms,omitempty"`
+	StallCheckIntervalMS *int   `json:"stall_check_interval_ms,omitempty" yaml:"stall_check_interval_ms,omitempty"`
+	RunTimeoutMS         *int   `json:"run_timeout_ms,omitempty" yaml:"run_timeout_ms,omitempty"`
+	MaxLLMRetries        *int   `json:"max_llm_retries,omitempty" yaml:"max_llm_retries,omitempty"`
+	StallAction          string `json:"stall_action,omitempty" yaml:"stall_action,omitempty"`
+	ProgressMaxBytes     *int64 `json:"progress_max_bytes,omitempty" yaml:"progress_max_bytes,omitempty"`
+	ProgressMaxRolls     *int   `json:"progress_max_rolls,omitempty" yaml:"progress_max_rolls,omitempty"`
+	MinFreeBytes         int64  `json:"min_free_bytes,omitempty" yaml:"min_free_bytes,omitempty"`
 }
 
 type PromptProbeConfig struct {
@@ -56,6 +65,22 @@ type PreflightConfig struct {
 	PromptProbes PromptProbeConfig `json:"prompt_probes,omitempty" yaml:"prompt_probes,omitempty"`
 }
 
+// WebhookInterviewerConfig routes human gate decisions to an external HTTP
+// service; see WebhookInterviewer. Header values may reference environment
+// variables ($VAR) so tokens stay out of the config file.
+type WebhookInterviewerConfig struct {
+	URL            string            `json:"url,omitempty" yaml:"url,omitempty"`
+	Headers        map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	TimeoutMS      int               `json:"timeout_ms,omitempty" yaml:"timeout_ms,omitempty"`
+	PollIntervalMS int               `json:"poll_interval_ms,omitempty" yaml:"poll_interval_ms,omitempty"`
+	DefaultAction  string            `json:"default_action,omitempty" yaml:"default_action,omitempty"`
+	MaxRetries     *int              `json:"max_retries,omitempty" yaml:"max_retries,omitempty"`
+}
+
+type InterviewerConfig struct {
+	Webhook WebhookInterviewerConfig `json:"webhook,omitempty" yaml:"webhook,omitempty"`
+}
+
 type RunConfigFile struct {
 	Version int `json:"version" yaml:"version"`
 
@@ -106,6 +131,7 @@ type RunConfigFile struct {
 
 	RuntimePolicy RuntimePolicyConfig `json:"runtime_policy,omitempty" yaml:"runtime_policy,omitempty"`
 	Preflight     PreflightConfig     `json:"preflight,omitempty" yaml:"preflight,omitempty"`
+	Interviewer   InterviewerConfig   `json:"interviewer,omitempty" yaml:"interviewer,omitempty"`
 }
 
 func LoadRunConfigFile(path string) (*RunConfigFile, error) {
@@ -210,8 +236,34 @@ func applyConfigDefaults(cfg *RunConfigFile) {
 		v := 6
 		cfg.RuntimePolicy.MaxLLMRetries = &v
 	}
+	if cfg.RuntimePolicy.ProgressMaxBytes == nil {
+		v := int64(256 << 20)
+		cfg.RuntimePolicy.ProgressMaxBytes = &v
+	}
+	if cfg.RuntimePolicy.ProgressMaxRolls == nil {
+		v := DefaultProgressMaxRolls
+		cfg.RuntimePolicy.ProgressMaxRolls = &v
+	}
 
 	cfg.Preflight.PromptProbes.Transports = trimNonEmpty(cfg.Preflight.PromptProbes.Transports)
+
+	if wh := &cfg.Interviewer.Webhook; strings.TrimSpace(wh.URL) != "" {
+		wh.URL = strings.TrimSpace(wh.URL)
+		if wh.TimeoutMS == 0 {
+			wh.TimeoutMS = int(DefaultWebhookInterviewerTimeout / time.Millisecond)
+		}
+		if wh.PollIntervalMS == 0 {
+			wh.PollIntervalMS = 5000
+		}
+		wh.DefaultAction = strings.ToLower(strings.TrimSpace(wh.DefaultAction))
+		if wh.DefaultAction == "" {
+			wh.DefaultAction = WebhookDefaultDeny
+		}
+		if wh.MaxRetries == nil {
+			v := 3
+			wh.MaxRetries = &v
+		}
+	}
 }
 
 func validateConfig(cfg *RunConfigFile) error {
@@ -273,8 +325,8 @@ func validateConfig(cfg *RunConfigFile) error {
 		default:
 			return fmt.Errorf("invalid backend for provider %q: %q (want api|cli)", prov, pc.Backend)
 		}
-		if strings.EqualFold(cfg.LLM.CLIProfile, "real") && strings.TrimSpace(pc.Executable) != "" {
-			return fmt.Errorf("llm.providers.%s.executable is only allowed when llm.cli_profile=test_shim", prov)
+		if len(pc.ExtraArgs) > 0 && pc.Backend != BackendCLI {
+			return fmt.Errorf("llm.providers.%s.extra_args requires backend=cli", prov)
 		}
 	}
 	if cfg.RuntimePolicy.StageTimeoutMS != nil && *cfg.RuntimePolicy.StageTimeoutMS < 0 {
@@ -283,12 +335,29 @@ func validateConfig(cfg *RunConfigFile) error {
 	if cfg.RuntimePolicy.StallTimeoutMS != nil && *cfg.RuntimePolicy.StallTimeoutMS < 0 {
 		return fmt.Errorf("runtime_policy.stall_timeout_ms must be >= 0")
 	}
+	if cfg.RuntimePolicy.RunTimeoutMS != nil && *cfg.RuntimePolicy.RunTimeoutMS < 0 {
+		return fmt.Errorf("runtime_policy.run_timeout_ms must be >= 0")
+	}
 	if cfg.RuntimePolicy.StallCheckIntervalMS != nil && *cfg.RuntimePolicy.StallCheckIntervalMS < 0 {
 		return fmt.Errorf("runtime_policy.stall_check_interval_ms must be >= 0")
 	}
+	switch strings.ToLower(strings.TrimSpace(cfg.RuntimePolicy.StallAction)) {
+	case "", StallActionAbort, StallActionFailNode:
+	default:
+		return fmt.Errorf("runtime_policy.stall_action must be %q or %q", StallActionAbort, StallActionFailNode)
+	}
 	if cfg.RuntimePolicy.MaxLLMRetries != nil && *cfg.RuntimePolicy.MaxLLMRetries < 0 {
 		return fmt.Errorf("runtime_policy.max_llm_retries must be >= 0")
 	}
+	if cfg.RuntimePolicy.ProgressMaxBytes != nil && *cfg.RuntimePolicy.ProgressMaxBytes < 0 {
+		return fmt.Errorf("runtime_policy.progress_max_bytes must be >= 0")
+	}
+	if cfg.RuntimePolicy.MinFreeBytes < 0 {
+		return fmt.Errorf("runtime_policy.min_free_bytes must be >= 0")
+	}
+	if cfg.RuntimePolicy.ProgressMaxRolls != nil && *cfg.RuntimePolicy.ProgressMaxRolls < 1 {
+		return fmt.Errorf("runtime_policy.progress_max_rolls must be >= 1")
+	}
 	if cfg.RuntimePolicy.StallTimeoutMS != nil && cfg.RuntimePolicy.StallCheckIntervalMS != nil {
 		if *cfg.RuntimePolicy.StallTimeoutMS > 0 && *cfg.RuntimePolicy.StallCheckIntervalMS == 0 {
 			return fmt.Errorf("runtime_policy.stall_check_interval_ms must be > 0 when stall_timeout_ms > 0")
@@ -319,6 +388,26 @@ func validateConfig(cfg *RunConfigFile) error {
 		}
 		cfg.Preflight.PromptProbes.Transports = normalized
 	}
+	if wh := cfg.Interviewer.Webhook; strings.TrimSpace(wh.URL) != "" {
+		u, err := url.Parse(wh.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("interviewer.webhook.url must be an http(s) URL: %q", wh.URL)
+		}
+		if wh.TimeoutMS < 0 {
+			return fmt.Errorf("interviewer.webhook.timeout_ms must be >= 0")
+		}
+		if wh.PollIntervalMS < 0 {
+			return fmt.Errorf("interviewer.webhook.poll_interval_ms must be >= 0")
+		}
+		if wh.MaxRetries != nil && *wh.MaxRetries < 0 {
+			return fmt.Errorf("interviewer.webhook.max_retries must be >= 0")
+		}
+		switch strings.ToLower(strings.TrimSpace(wh.DefaultAction)) {
+		case "", WebhookDefaultApprove, WebhookDefaultDeny, WebhookDefaultTimeout:
+		default:
+			return fmt.Errorf("interviewer.webhook.default_action must be %q, %q, or %q", WebhookDefaultApprove, WebhookDefaultDeny, WebhookDefaultTimeout)
+		}
+	}
 	return nil
 }
 
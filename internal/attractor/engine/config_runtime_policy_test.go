@@ -15,6 +15,12 @@ func TestRuntimePolicy_DefaultsAndValidation(t *testing.T) {
 	if cfg.RuntimePolicy.MaxLLMRetries == nil || *cfg.RuntimePolicy.MaxLLMRetries != 6 {
 		t.Fatalf("expected default max_llm_retries=6")
 	}
+	if cfg.RuntimePolicy.ProgressMaxBytes == nil || *cfg.RuntimePolicy.ProgressMaxBytes != 256<<20 {
+		t.Fatalf("expected default progress_max_bytes=268435456")
+	}
+	if cfg.RuntimePolicy.ProgressMaxRolls == nil || *cfg.RuntimePolicy.ProgressMaxRolls != DefaultProgressMaxRolls {
+		t.Fatalf("expected default progress_max_rolls=%d", DefaultProgressMaxRolls)
+	}
 
 	cfg.Version = 1
 	cfg.Repo.Path = "/tmp/repo"
@@ -33,6 +39,22 @@ func TestRuntimePolicy_DefaultsAndValidation(t *testing.T) {
 	if err := validateConfig(cfg); err == nil {
 		t.Fatal("expected validation error for negative max_llm_retries")
 	}
+	cfg.RuntimePolicy.MaxLLMRetries = &zero
+
+	cfg.RuntimePolicy.StallAction = StallActionFailNode
+	if err := validateConfig(cfg); err != nil {
+		t.Fatalf("stall_action=fail-node should be valid: %v", err)
+	}
+	cfg.RuntimePolicy.StallAction = "restart"
+	if err := validateConfig(cfg); err == nil {
+		t.Fatal("expected validation error for unknown stall_action")
+	}
+	cfg.RuntimePolicy.StallAction = ""
+
+	cfg.RuntimePolicy.ProgressMaxRolls = &zero
+	if err := validateConfig(cfg); err == nil {
+		t.Fatal("expected validation error for progress_max_rolls=0")
+	}
 }
 
 func TestApplyConfigDefaults_CheckpointExcludeGlobs(t *testing.T) {
@@ -370,7 +370,7 @@ modeldb:
 	}
 }
 
-func TestLoadRunConfigFile_ExecutableOverrideRequiresTestShim(t *testing.T) {
+func TestLoadRunConfigFile_ExecutableOverrideAllowedInRealProfile(t *testing.T) {
 	dir := t.TempDir()
 	yml := filepath.Join(dir, "run.yaml")
 	if err := os.WriteFile(yml, []byte(`
@@ -391,12 +391,47 @@ modeldb:
 `), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	_, err := LoadRunConfigFile(yml)
-	if err == nil {
-		t.Fatalf("expected executable override validation error")
+	cfg, err := LoadRunConfigFile(yml)
+	if err != nil {
+		t.Fatalf("LoadRunConfigFile: %v", err)
 	}
-	if !strings.Contains(err.Error(), "llm.providers.openai.executable") || !strings.Contains(err.Error(), "test_shim") {
-		t.Fatalf("unexpected error: %v", err)
+	if got := cfg.LLM.Providers["openai"].Executable; got != "/tmp/fake/codex" {
+		t.Fatalf("executable: got %q want %q", got, "/tmp/fake/codex")
+	}
+}
+
+func TestLoadRunConfigFile_ExtraArgs(t *testing.T) {
+	dir := t.TempDir()
+	write := func(backend string) string {
+		yml := filepath.Join(dir, backend+".yaml")
+		if err := os.WriteFile(yml, []byte(`
+version: 1
+repo:
+  path: /tmp/repo
+cxdb:
+  binary_addr: 127.0.0.1:9009
+  http_base_url: http://127.0.0.1:9010
+llm:
+  providers:
+    anthropic:
+      backend: `+backend+`
+      extra_args: ["--append-system-prompt", "be brief"]
+modeldb:
+  openrouter_model_info_path: /tmp/catalog.json
+`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return yml
+	}
+	cfg, err := LoadRunConfigFile(write("cli"))
+	if err != nil {
+		t.Fatalf("LoadRunConfigFile: %v", err)
+	}
+	if got := providerExtraArgs(cfg, "anthropic"); strings.Join(got, "|") != "--append-system-prompt|be brief" {
+		t.Fatalf("extra args: %v", got)
+	}
+	if _, err := LoadRunConfigFile(write("api")); err == nil || !strings.Contains(err.Error(), "llm.providers.anthropic.extra_args requires backend=cli") {
+		t.Fatalf("expected extra_args backend error, got %v", err)
 	}
 }
 
@@ -463,3 +498,49 @@ preflight:
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestLoadRunConfigFile_InterviewerWebhookDefaultsAndValidation(t *testing.T) {
+	dir := t.TempDir()
+	base := `
+version: 1
+repo:
+  path: /tmp/repo
+cxdb:
+  binary_addr: 127.0.0.1:9009
+  http_base_url: http://127.0.0.1:9010
+llm:
+  providers:
+    openai:
+      backend: api
+modeldb:
+  openrouter_model_info_path: /tmp/catalog.json
+interviewer:
+  webhook:
+`
+	yml := filepath.Join(dir, "run.yaml")
+	if err := os.WriteFile(yml, []byte(base+"    url: \" https://approvals.example.com/hook \"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadRunConfigFile(yml)
+	if err != nil {
+		t.Fatalf("LoadRunConfigFile: %v", err)
+	}
+	wh := cfg.Interviewer.Webhook
+	if wh.URL != "https://approvals.example.com/hook" || wh.TimeoutMS != 600000 || wh.PollIntervalMS != 5000 ||
+		wh.DefaultAction != WebhookDefaultDeny || wh.MaxRetries == nil || *wh.MaxRetries != 3 {
+		t.Fatalf("webhook defaults: %+v", wh)
+	}
+
+	for _, tc := range []struct{ extra, want string }{
+		{"    url: ftp://example.com\n", "interviewer.webhook.url"},
+		{"    url: https://example.com\n    default_action: maybe\n", "interviewer.webhook.default_action"},
+		{"    url: https://example.com\n    timeout_ms: -1\n", "interviewer.webhook.timeout_ms"},
+	} {
+		if err := os.WriteFile(yml, []byte(base+tc.extra), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadRunConfigFile(yml); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%q: expected %s error, got: %v", tc.extra, tc.want, err)
+		}
+	}
+}
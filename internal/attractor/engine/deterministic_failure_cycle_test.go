@@ -372,3 +372,72 @@ func (h *signatureHintCycleFixtureHandler) Execute(ctx context.Context, exec *Ex
 		},
 	}, nil
 }
+
+func TestRun_DeterministicFailureCycle_ThresholdAndPathInError(t *testing.T) {
+	repo := initTestRepo(t)
+	dot := []byte(`
+digraph G {
+  graph [default_max_retry=0, deterministic_cycle_threshold=2, loop_restart_signature_limit=5]
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  implement [shape=parallelogram, tool_command="exit 1"]
+  verify [shape=parallelogram, tool_command="exit 1"]
+  check [shape=diamond]
+  start -> implement
+  implement -> verify
+  verify -> check
+  check -> implement [condition="outcome=fail", label="retry"]
+  check -> exit [condition="outcome=success"]
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	logsRoot := t.TempDir()
+	_, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunID: "detcyclethreshold", LogsRoot: logsRoot})
+	if err == nil {
+		t.Fatal("expected deterministic failure cycle error")
+	}
+	msg := err.Error()
+	for _, want := range []string{`signature "implement|deterministic|`, "limit 2", "via implement -> verify -> check -> implement"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("error %q missing %q", msg, want)
+		}
+	}
+	var breaker map[string]any
+	for _, ev := range readProgressEvents(t, filepath.Join(logsRoot, "progress.ndjson")) {
+		if ev["event"] == "deterministic_failure_cycle_breaker" {
+			breaker = ev
+		}
+	}
+	if breaker == nil {
+		t.Fatal("expected deterministic_failure_cycle_breaker event")
+	}
+	if got, _ := breaker["signature_count"].(float64); got != 2 {
+		t.Fatalf("signature_count=%v want 2", breaker["signature_count"])
+	}
+	if breaker["node_path"] != "implement -> verify -> check -> implement" {
+		t.Fatalf("node_path=%v", breaker["node_path"])
+	}
+}
+
+func TestDeterministicCycleThreshold_FallsBackToSignatureLimit(t *testing.T) {
+	cases := []struct {
+		attrs map[string]string
+		want  int
+	}{
+		{nil, defaultLoopRestartSignatureLimit},
+		{map[string]string{"loop_restart_signature_limit": "4"}, 4},
+		{map[string]string{"loop_restart_signature_limit": "4", "deterministic_cycle_threshold": "6"}, 6},
+		{map[string]string{"deterministic_cycle_threshold": "0"}, defaultLoopRestartSignatureLimit},
+		{map[string]string{"deterministic_cycle_threshold": "abc", "loop_restart_signature_limit": "5"}, 5},
+	}
+	for _, tc := range cases {
+		g := model.NewGraph("G")
+		for k, v := range tc.attrs {
+			g.Attrs[k] = v
+		}
+		if got := deterministicCycleThreshold(g); got != tc.want {
+			t.Fatalf("attrs=%v: got %d want %d", tc.attrs, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// checkFreeSpace fails when the filesystem holding the worktree or the logs
+// root has less than RunOptions.MinFreeBytes available. It is a no-op when no
+// minimum is set or the platform cannot report free space.
+func (e *Engine) checkFreeSpace() error {
+	if e == nil || e.Options.MinFreeBytes <= 0 {
+		return nil
+	}
+	need := uint64(e.Options.MinFreeBytes)
+	seen := map[string]bool{}
+	for _, dir := range []string{e.WorktreeDir, e.LogsRoot} {
+		dir = existingAncestor(dir)
+		if dir == "" || seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		free, ok, err := freeDiskBytes(dir)
+		if err != nil {
+			return fmt.Errorf("check free disk space under %s: %w", dir, err)
+		}
+		if ok && free < need {
+			return fmt.Errorf("insufficient disk space under %s: %s free, need at least %s (min_free_bytes)", dir, formatBytes(free), formatBytes(need))
+		}
+	}
+	return nil
+}
+
+// existingAncestor returns dir or its nearest existing parent, so free space
+// can be checked before the worktree is created.
+func existingAncestor(dir string) string {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return ""
+	}
+	dir = filepath.Clean(dir)
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
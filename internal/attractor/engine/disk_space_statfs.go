@@ -0,0 +1,15 @@
+//go:build linux || darwin
+
+package engine
+
+import "golang.org/x/sys/unix"
+
+// freeDiskBytes reports the bytes available to unprivileged users on the
+// filesystem holding path.
+func freeDiskBytes(path string) (uint64, bool, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, false, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), true, nil
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun_MinFreeBytesAbortsBeforeAnyNode(t *testing.T) {
+	if _, ok, _ := freeDiskBytes(t.TempDir()); !ok {
+		t.Skip("free disk space is not reported on this platform")
+	}
+	repo := initTestRepo(t)
+	logsRoot := t.TempDir()
+	dot := []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  a [shape=parallelogram, tool_command="echo ran > ran.txt"]
+  start -> a -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, err := Run(ctx, dot, RunOptions{
+		RepoPath:     repo,
+		RunID:        "diskfull",
+		LogsRoot:     logsRoot,
+		MinFreeBytes: 1 << 62,
+	})
+	if err == nil || !strings.Contains(err.Error(), "insufficient disk space") || !strings.Contains(err.Error(), "min_free_bytes") {
+		t.Fatalf("expected insufficient disk space error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(logsRoot, "worktree")); !os.IsNotExist(err) {
+		t.Fatalf("worktree should not be created: %v", err)
+	}
+}
+
+func TestCheckFreeSpace_DisabledOrSatisfied(t *testing.T) {
+	dir := t.TempDir()
+	e := &Engine{LogsRoot: dir, WorktreeDir: filepath.Join(dir, "not", "yet", "created")}
+	if err := e.checkFreeSpace(); err != nil {
+		t.Fatalf("no minimum: %v", err)
+	}
+	e.Options.MinFreeBytes = 1
+	if err := e.checkFreeSpace(); err != nil {
+		t.Fatalf("1 byte minimum: %v", err)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[uint64]string{512: "512 B", 1536: "1.5 KiB", 5 << 30: "5.0 GiB"} {
+		if got := formatBytes(n); got != want {
+			t.Fatalf("formatBytes(%d)=%q want %q", n, got, want)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package engine
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/danshapiro/kilroy/internal/attractor/dot"
 	"github.com/danshapiro/kilroy/internal/attractor/runtime"
@@ -477,3 +480,34 @@ digraph G {
 		t.Fatalf("got %q, want c (suggested next ID match)", edges[0].To)
 	}
 }
+
+func TestRun_RoutesOnToolExitCodeExpression(t *testing.T) {
+	repo := initTestRepo(t)
+	dot := []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  check [shape=parallelogram, tool_command="echo flaky run; exit 3"]
+  soft [shape=parallelogram, tool_command="echo soft > route.txt"]
+  hard [shape=parallelogram, tool_command="echo hard > route.txt"]
+  start -> check
+  check -> hard [condition="exit_code > 5"]
+  check -> soft [condition="exit_code > 1 && context.tool.output contains 'flaky'"]
+  check -> exit [condition="outcome=success"]
+  soft -> exit
+  hard -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	res, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunID: "exit-code-route", LogsRoot: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: got %q want success", res.FinalStatus)
+	}
+	if got := strings.TrimSpace(runCmdOut(t, repo, "git", "show", res.FinalCommitSHA+":route.txt")); got != "soft" {
+		t.Fatalf("route: got %q want soft", got)
+	}
+}
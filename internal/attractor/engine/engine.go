@@ -2,6 +2,10 @@ package engine
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -11,12 +15,14 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/danshapiro/kilroy/internal/attractor/cond"
 	"github.com/danshapiro/kilroy/internal/attractor/dot"
 	"github.com/danshapiro/kilroy/internal/attractor/gitutil"
 	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/procutil"
 	"github.com/danshapiro/kilroy/internal/attractor/runtime"
 	"github.com/danshapiro/kilroy/internal/attractor/style"
 	"github.com/danshapiro/kilroy/internal/attractor/validate"
@@ -38,6 +44,11 @@ type RunOptions struct {
 	// Git branch prefix defaults to "attractor/run".
 	RunBranchPrefix string
 
+	// Optional explicit branch for checkpoint commits. When set it replaces the
+	// {RunBranchPrefix}/{RunID} branch name, e.g. to give runs started from a
+	// detached HEAD a stable, named branch. Parallel branches still use the prefix.
+	CheckpointBranch string
+
 	// If true (default), refuse to start when repo has uncommitted changes.
 	RequireClean bool
 
@@ -48,6 +59,11 @@ type RunOptions struct {
 	// Allows explicit opt-in for test-shim CLI execution profile.
 	AllowTestShim bool
 
+	// When true, fail the run before preflight if the effective model
+	// catalog differs from the pinned snapshot. Drift is otherwise only a
+	// warning; either way it is recorded in final.json.
+	RequirePinnedCatalog bool
+
 	// When true, skip CXDB startup entirely. eng.CXDB remains nil;
 	// all downstream consumers already nil-check before use.
 	DisableCXDB bool
@@ -65,25 +81,137 @@ type RunOptions struct {
 	StallTimeout       time.Duration
 	StallCheckInterval time.Duration
 
+	// StallAction selects what the stall watchdog does: StallActionAbort
+	// (default) cancels the run; StallActionFailNode terminates only the
+	// running node and records a transient_infra failure so retry and routing
+	// can recover.
+	StallAction string
+
+	// Optional wall-clock cap for the whole run, independent of the stall
+	// watchdog. The graph attribute run_timeout_ms sets the same cap; when both
+	// are set the smaller wins. On expiry the active node is terminated and the
+	// run fails with reason "run timeout".
+	RunTimeout time.Duration
+
+	// Optional checkpoint retention: once the run branch holds more than 2N
+	// checkpoint commits, all but the newest N are squashed into one. The
+	// graph attribute keep_checkpoints sets the same limit; when both are set
+	// the smaller wins. 0 keeps every checkpoint.
+	KeepCheckpoints int
+
+	// Optional minimum free disk space, in bytes, on the filesystems holding
+	// the worktree and the logs root. The run fails before executing any node,
+	// or before a checkpoint commit, when less is available. 0 disables the
+	// check, which is also skipped where free space cannot be determined.
+	MinFreeBytes int64
+
+	// When true, tool commands (tool_command nodes) run without network
+	// access; the graph attribute no_network=true does the same. On Linux
+	// each command runs in its own network namespace; elsewhere, unless
+	// KILROY_NO_NETWORK_WRAPPER names a sandbox command to run it under, the
+	// setting only produces a warning.
+	NoNetwork bool
+
+	// Optional classifier for failed node attempts. Defaults to
+	// DefaultFailureClassifier when nil.
+	FailureClassifier FailureClassifier
+
 	// Optional cap for LLM retries in codergen routing.
 	// Pointer preserves explicit zero versus unset semantics from config.
 	MaxLLMRetries *int
 
+	// Optional preflight prompt probe policy: per-attempt timeout, retries
+	// after a timeout or transient error, and the backoff between attempts.
+	// Set values override the run config's preflight.prompt_probes, which in
+	// turn overrides the KILROY_PREFLIGHT_API_PROMPT_PROBE_* env vars.
+	// Non-positive durations and a nil PromptProbeRetries mean unset.
+	PromptProbeTimeout   time.Duration
+	PromptProbeRetries   *int
+	PromptProbeBaseDelay time.Duration
+	PromptProbeMaxDelay  time.Duration
+
+	// Optional per-request timeout for API codergen LLM calls, in both
+	// one_shot and agent_loop mode. A call that runs past it fails the node
+	// as transient_infra, so node retries handle a hung provider instead of
+	// the stall watchdog. Non-positive means KILROY_CODERGEN_CALL_TIMEOUT_MS,
+	// and no limit when that is unset too.
+	CodergenCallTimeout time.Duration
+
 	// Optional callback invoked for every progress event (same data written to
 	// progress.ndjson). The map is a deep-copied snapshot safe for concurrent
 	// use by the caller. Used by the HTTP server to fan events to SSE clients.
+	// Further sinks can be added with Engine.AddProgressSink.
 	ProgressSink func(map[string]any)
 
+	// Case-insensitive name substrings that mark a progress event value as a
+	// secret to mask with "***" before it reaches progress.ndjson, live.json,
+	// or a sink. Nil means agent.DefaultDenyEnvPatterns; an empty non-nil
+	// slice disables redaction.
+	ProgressRedactPatterns []string
+
+	// Size cap for progress.ndjson. When an append would push the file past
+	// it, the file is rolled to progress.ndjson.1 (older rolls shift up, and
+	// at most ProgressMaxRolls are kept) and a fresh file is started. Zero
+	// disables rotation. ProgressMaxRolls < 1 means DefaultProgressMaxRolls.
+	ProgressMaxBytes int64
+	ProgressMaxRolls int
+
 	// Optional interviewer for human-in-the-loop gates. Defaults to
 	// AutoApproveInterviewer when nil.
 	Interviewer Interviewer
 
+	// Optional backend for codergen nodes that do not select a registered one
+	// with codergen_backend. Replaces the simulated backend (Run) or the
+	// provider router (RunWithConfig).
+	CodergenBackend CodergenBackend
+
 	// Optional callback invoked after the engine is fully initialized but
 	// before the main loop starts. Allows callers to capture an engine
 	// reference for context inspection, etc.
 	OnEngineReady func(e *Engine)
+
+	// Optional free-form run labels (e.g. team, project, batch input name).
+	// Recorded in manifest.json for attribution; they do not affect execution.
+	Labels map[string]string
+
+	// Optional path of the graph file the run was started from. Recorded in
+	// manifest.json as graph_path; the DOT source itself is always kept as
+	// graph.dot and identified by graph_sha256.
+	GraphPath string
+
+	// Optional directory for node memoization entries (nodes with cache_key).
+	// Defaults to .node_cache beside LogsRoot, so runs sharing a parent logs
+	// directory share cache entries; keys include the repository path and
+	// graph, so other repos and graphs never hit them.
+	NodeCacheDir string
+
+	// Optional context values seeded before the start node executes. Seeds are
+	// applied after graph attributes, so they can be read by conditions and
+	// prompts, and are re-applied after a loop_restart context reset.
+	InitialContext map[string]any
 }
 
+// DefaultProgressMaxRolls is how many rolled progress.ndjson files are kept
+// when RunOptions.ProgressMaxRolls is unset.
+const DefaultProgressMaxRolls = 3
+
+// Stall watchdog actions for RunOptions.StallAction.
+const (
+	StallActionAbort    = "abort"
+	StallActionFailNode = "fail-node"
+)
+
+// stallWarningPercent is how far into StallTimeout, in percent of idle time,
+// the stall watchdog emits its one-shot stall_warning event.
+const stallWarningPercent = 75
+
+// errStallNodeFailed is the cancel cause for a node attempt terminated by the
+// stall watchdog in StallActionFailNode mode.
+var errStallNodeFailed = errors.New("stall watchdog terminated node")
+
+// errRunTimeout is the cancel cause when the run exceeds its run timeout.
+var errRunTimeout = errors.New("run timeout")
+
 func (o *RunOptions) applyDefaults() error {
 	if o.RunBranchPrefix == "" {
 		o.RunBranchPrefix = "attractor/run"
@@ -103,6 +231,9 @@ func (o *RunOptions) applyDefaults() error {
 	if o.WorktreeDir == "" {
 		o.WorktreeDir = filepath.Join(o.LogsRoot, "worktree")
 	}
+	if o.NodeCacheDir == "" {
+		o.NodeCacheDir = filepath.Join(filepath.Dir(o.LogsRoot), ".node_cache")
+	}
 	// Runtime policy defaults (aligned with run config defaults).
 	if o.StageTimeout < 0 {
 		o.StageTimeout = 0
@@ -113,6 +244,17 @@ func (o *RunOptions) applyDefaults() error {
 	if o.StallCheckInterval < 0 {
 		o.StallCheckInterval = 0
 	}
+	if o.RunTimeout < 0 {
+		o.RunTimeout = 0
+	}
+	switch strings.ToLower(strings.TrimSpace(o.StallAction)) {
+	case "", StallActionAbort:
+		o.StallAction = StallActionAbort
+	case StallActionFailNode:
+		o.StallAction = StallActionFailNode
+	default:
+		return fmt.Errorf("invalid stall action %q (want %s or %s)", o.StallAction, StallActionAbort, StallActionFailNode)
+	}
 	if o.MaxLLMRetries == nil {
 		v := 6
 		o.MaxLLMRetries = &v
@@ -143,7 +285,8 @@ type Engine struct {
 
 	Registry *HandlerRegistry
 
-	// Backend for codergen nodes (until provider routing is wired in).
+	// Default backend for codergen nodes; nodes may select a registered one
+	// with codergen_backend (see RegisterCodergenBackend).
 	CodergenBackend CodergenBackend
 
 	Interviewer Interviewer
@@ -159,6 +302,7 @@ type Engine struct {
 	ModelCatalogSHA    string
 	ModelCatalogSource string
 	ModelCatalogPath   string
+	ModelCatalogDrift  *runtime.CatalogDrift
 
 	warningsMu sync.Mutex
 	Warnings   []string
@@ -177,10 +321,48 @@ type Engine struct {
 	// resetting would defeat the breaker in impl-succeeds/verify-fails cycles.
 	loopFailureSignatures map[string]int
 
+	// startedAt is when the run began (the manifest's started_at), and
+	// completedNodes the node path of the latest checkpoint; both feed the
+	// summary in final.json.
+	startedAt      time.Time
+	completedNodes []string
+
+	// totalSteps counts node executions for graph [max_total_steps=N]. It
+	// survives loop restarts and resume, and is shared with parallel branch
+	// and manager_loop child engines so their executions count too. Use
+	// sharedSteps; it is created on first use.
+	totalSteps *stepCounter
+
 	progressMu sync.Mutex
 	// Guarded by progressMu.
 	lastProgressAt time.Time
-	progressSink   func(map[string]any)
+	// Called in order for every progress event; see AddProgressSink.
+	progressSinks []func(map[string]any)
+	// Built from Options.ProgressRedactPatterns on first use.
+	progressRedactor *progressRedactor
+	// Per-node durations, attempts, and token usage for metrics.json; shared
+	// with parallel branch and manager_loop child engines (see
+	// sharedMetrics).
+	metrics *runMetrics
+
+	namedBackendsMu sync.Mutex
+	// Guarded by namedBackendsMu: backends created from registered factories
+	// for codergen_backend, keyed by name.
+	namedBackends map[string]CodergenBackend
+
+	stageMu sync.Mutex
+	// Guarded by stageMu: the node attempt currently executing and its cancel
+	// func, used by the stall watchdog in StallActionFailNode mode.
+	activeStageNodeID string
+	activeStageCancel context.CancelCauseFunc
+	// Run-wide named locks for the lock="name" node attribute; shared with
+	// branch and child engines. Lazily created under stageMu.
+	nodeLocks *nodeLocks
+	// Run-wide lock serializing git commits and ref/worktree mutations. Branch
+	// engines have their own worktrees but share the repository's refs and
+	// object store with the parent, so it is shared with branch and child
+	// engines. Lazily created under stageMu.
+	gitLock *sync.Mutex
 
 	// Fidelity/session resolution state.
 	incomingEdge          *model.Edge // edge used to reach the current node (nil for start)
@@ -229,13 +411,23 @@ type Result struct {
 
 type PrepareOptions struct {
 	Transforms []Transform
-	// RepoPath is the repository root directory. When set, prompt_file attributes
-	// on nodes are resolved relative to this path before other transforms run.
+	// RepoPath is the repository root directory. When set, prompt_file
+	// attributes on nodes are resolved relative to this path before other
+	// transforms run.
 	RepoPath string
+	// GraphPath is the file dotSource was read from, if any. include paths
+	// are resolved relative to its directory (nested includes relative to the
+	// including file); without it, relative to RepoPath, else the working
+	// directory.
+	GraphPath string
 	// KnownTypes is an optional list of handler type strings. When non-empty,
 	// the TypeKnownRule lint rule is added to validation so that nodes with
 	// explicit type= attributes not in this set produce a warning.
 	KnownTypes []string
+	// Inputs, when non-nil, are the run's initial context values. Each key the
+	// graph lists in requires="a,b" must be present, or validation reports a
+	// required_inputs error. Nil skips the check (static validation, resume).
+	Inputs map[string]any
 }
 
 // Prepare parses/transforms/validates a graph.
@@ -257,6 +449,22 @@ func PrepareWithOptions(dotSource []byte, opts PrepareOptions) (*model.Graph, []
 		return nil, nil, err
 	}
 
+	// Inline include="sub.dot" nodes first so every transform and lint rule
+	// sees the flattened graph.
+	includeDir := opts.RepoPath
+	if strings.TrimSpace(opts.GraphPath) != "" {
+		includeDir = filepath.Dir(opts.GraphPath)
+	}
+	g, includeDiags := expandIncludes(g, includeDir)
+	if len(includeDiags) > 0 {
+		validate.Locate(g, includeDiags)
+		var errs []string
+		for _, d := range includeDiags {
+			errs = append(errs, d.Rule+": "+d.Message)
+		}
+		return g, includeDiags, fmt.Errorf("validation failed: %s", strings.Join(errs, "; "))
+	}
+
 	// Built-in transforms: prompt_file resolution, stylesheet, $goal expansion.
 	// prompt_file runs first so loaded content gets stylesheet defaults and $goal expansion.
 	if opts.RepoPath != "" {
@@ -294,6 +502,13 @@ func PrepareWithOptions(dotSource []byte, opts PrepareOptions) (*model.Graph, []
 	if len(opts.KnownTypes) > 0 {
 		extraRules = append(extraRules, validate.NewTypeKnownRule(opts.KnownTypes))
 	}
+	if opts.Inputs != nil {
+		provided := make([]string, 0, len(opts.Inputs))
+		for k := range opts.Inputs {
+			provided = append(provided, k)
+		}
+		extraRules = append(extraRules, validate.NewRequiredInputsRule(provided))
+	}
 	diags := validate.Validate(g, extraRules...)
 	var errs []string
 	for _, d := range diags {
@@ -315,15 +530,23 @@ func Run(ctx context.Context, dotSource []byte, opts RunOptions) (*Result, error
 	reg := NewDefaultRegistry()
 	g, _, err := PrepareWithOptions(dotSource, PrepareOptions{
 		RepoPath:   opts.RepoPath,
+		GraphPath:  opts.GraphPath,
 		KnownTypes: reg.KnownTypes(),
+		Inputs:     runInputs(opts.InitialContext),
 	})
 	if err != nil {
 		return nil, err
 	}
+	if err := checkCodergenBackendNames(g); err != nil {
+		return nil, err
+	}
 
 	eng := newBaseEngine(g, dotSource, opts)
 	eng.Registry = reg
 	eng.CodergenBackend = &SimulatedCodergenBackend{}
+	if opts.CodergenBackend != nil {
+		eng.CodergenBackend = opts.CodergenBackend
+	}
 
 	return eng.run(ctx)
 }
@@ -331,6 +554,25 @@ func Run(ctx context.Context, dotSource []byte, opts RunOptions) (*Result, error
 func (e *Engine) run(ctx context.Context) (res *Result, err error) {
 	runCtx, cancelRun := context.WithCancelCause(ctx)
 	defer cancelRun(nil)
+	// The run timeout is a child of runCtx so a stall abort still cancels it,
+	// and the stall watchdog below watches the timed context so it stops once
+	// the timeout has fired instead of cancelling a second time.
+	if timeout := runTimeout(e.Graph, e.Options.RunTimeout); timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		runCtx, cancelTimeout = context.WithTimeoutCause(runCtx, timeout, errRunTimeout)
+		defer cancelTimeout()
+		timedCtx := runCtx
+		stop := context.AfterFunc(timedCtx, func() {
+			if errors.Is(context.Cause(timedCtx), errRunTimeout) {
+				e.appendProgress(map[string]any{
+					"event":          "run_timeout",
+					"node_id":        e.Context.GetString("current_node", ""),
+					"run_timeout_ms": timeout.Milliseconds(),
+				})
+			}
+		})
+		defer stop()
+	}
 
 	defer func() {
 		if err != nil {
@@ -344,6 +586,10 @@ func (e *Engine) run(ctx context.Context) (res *Result, err error) {
 	if !gitutil.IsRepo(e.Options.RepoPath) {
 		return nil, fmt.Errorf("not a git repo: %s", e.Options.RepoPath)
 	}
+	detached, err := checkRepoGitState(e.Options.RepoPath)
+	if err != nil {
+		return nil, err
+	}
 	if e.Options.RequireClean {
 		clean, err := gitutil.IsClean(e.Options.RepoPath)
 		if err != nil {
@@ -359,9 +605,15 @@ func (e *Engine) run(ctx context.Context) (res *Result, err error) {
 		return nil, err
 	}
 	e.baseSHA = baseSHA
+	if detached {
+		e.Warn(fmt.Sprintf("repo HEAD is detached at %s; checkpoints will be committed to branch %s", baseSHA, e.RunBranch))
+	}
 	if err := os.MkdirAll(e.LogsRoot, 0o755); err != nil {
 		return nil, err
 	}
+	if err := e.checkFreeSpace(); err != nil {
+		return nil, err
+	}
 	// Record PID so attractor status can detect a running process.
 	_ = os.WriteFile(filepath.Join(e.LogsRoot, "run.pid"), []byte(strconv.Itoa(os.Getpid())), 0o644)
 	// Snapshot the run config for repeatability and resume.
@@ -399,13 +651,14 @@ func (e *Engine) run(ctx context.Context) (res *Result, err error) {
 	}
 	e.Context.Set("graph.goal", e.Graph.Attrs["goal"])
 	e.Context.Set("base_sha", baseSHA)
+	e.seedInitialContext()
 
 	// Expand $base_sha in prompts now that the base SHA is known.
 	// ($goal was already expanded at parse/prepare time.)
 	expandBaseSHA(e.Graph, baseSHA)
 
 	// Run pre-pipeline setup commands (e.g., npm install) in the worktree.
-	if err := e.executeSetupCommands(ctx); err != nil {
+	if err := e.executeSetupCommands(runCtx); err != nil {
 		return nil, fmt.Errorf("setup commands failed: %w", err)
 	}
 
@@ -437,6 +690,9 @@ func (e *Engine) run(ctx context.Context) (res *Result, err error) {
 func (e *Engine) runLoop(ctx context.Context, current string, completed []string, nodeRetries map[string]int, nodeOutcomes map[string]runtime.Outcome) (*Result, error) {
 	nodeVisits := map[string]int{}
 	visitLimit := maxNodeVisits(e.Graph)
+	// failureSeenAt maps a failure signature to its last index in completed,
+	// so the cycle breaker can report the path that led back to it.
+	failureSeenAt := map[string]int{}
 	for {
 		if err := runContextError(ctx); err != nil {
 			return nil, err
@@ -551,12 +807,29 @@ func (e *Engine) runLoop(ctx context.Context, current string, completed []string
 			}, nil
 		}
 
-		e.cxdbStageStarted(ctx, node)
-		out, err := e.executeWithRetry(ctx, node, nodeRetries)
-		if err != nil {
+		if err := e.countStep(node.ID); err != nil {
 			return nil, err
 		}
+		e.cxdbStageStarted(ctx, node)
+		out, skipped := e.skipIfOutcome(node)
+		cacheKey := ""
+		cacheHit := false
+		if !skipped {
+			cacheKey = e.nodeCacheKey(node)
+			out, cacheHit = e.loadNodeCache(node, cacheKey)
+		}
+		if !skipped && !cacheHit {
+			var err error
+			out, err = e.executeWithRetry(ctx, node, nodeRetries)
+			if err != nil {
+				return nil, err
+			}
+		}
 		e.cxdbStageFinished(ctx, node, out)
+		if !skipped && !cacheHit {
+			e.captureNodeArtifacts(node, out)
+			e.storeNodeCache(node, cacheKey, out)
+		}
 		if err := runContextError(ctx); err != nil {
 			return nil, err
 		}
@@ -587,7 +860,12 @@ func (e *Engine) runLoop(ctx context.Context, current string, completed []string
 				}
 				e.loopFailureSignatures[sig]++
 				count := e.loopFailureSignatures[sig]
-				limit := loopRestartSignatureLimit(e.Graph)
+				limit := deterministicCycleThreshold(e.Graph)
+				from, seen := failureSeenAt[sig]
+				if !seen {
+					from = -1
+				}
+				failureSeenAt[sig] = len(completed) - 1
 				e.appendProgress(map[string]any{
 					"event":           "deterministic_failure_cycle_check",
 					"node_id":         node.ID,
@@ -598,9 +876,10 @@ func (e *Engine) runLoop(ctx context.Context, current string, completed []string
 					"failure_reason":  out.FailureReason,
 				})
 				if count >= limit {
+					path := failureCyclePath(completed, from)
 					reason := fmt.Sprintf(
-						"run aborted: deterministic failure cycle detected — signature %q repeated %d times (limit %d); likely a persistent provider or auth error",
-						sig, count, limit,
+						"run aborted: deterministic failure cycle detected — signature %q repeated %d times (limit %d) via %s; likely a persistent provider or auth error",
+						sig, count, limit, path,
 					)
 					e.appendProgress(map[string]any{
 						"event":           "deterministic_failure_cycle_breaker",
@@ -608,6 +887,7 @@ func (e *Engine) runLoop(ctx context.Context, current string, completed []string
 						"signature":       sig,
 						"signature_count": count,
 						"signature_limit": limit,
+						"node_path":       path,
 					})
 					return nil, fmt.Errorf("%s", reason)
 				}
@@ -653,7 +933,7 @@ func (e *Engine) runLoop(ctx context.Context, current string, completed []string
 						Engine:      e,
 						Artifacts:   e.Artifacts,
 					}
-					results, baseSHA, dispatchErr := dispatchParallelBranches(ctx, exec, node.ID, allEdges, joinID)
+					results, baseSHA, dispatchErr := dispatchImplicitParallelBranches(ctx, exec, node.ID, allEdges, joinID)
 					if dispatchErr != nil {
 						return nil, dispatchErr
 					}
@@ -661,10 +941,7 @@ func (e *Engine) runLoop(ctx context.Context, current string, completed []string
 					_ = os.MkdirAll(stageDir, 0o755)
 					_ = writeJSON(filepath.Join(stageDir, "parallel_results.json"), results)
 
-					e.Context.ApplyUpdates(map[string]any{
-						"parallel.join_node": joinID,
-						"parallel.results":   results,
-					})
+					e.Context.ApplyUpdates(parallelContextUpdates(joinID, results))
 					e.appendProgress(map[string]any{
 						"event":       "implicit_fan_out",
 						"source_node": node.ID,
@@ -830,7 +1107,7 @@ func (e *Engine) loopRestart(ctx context.Context, targetNodeID string, fromNodeI
 	}
 
 	e.restartCount++
-	maxRestarts := parseInt(e.Graph.Attrs["max_restarts"], 50)
+	maxRestarts := maxLoopRestarts(e.Graph)
 	if e.restartCount > maxRestarts {
 		return nil, fmt.Errorf("loop_restart limit exceeded (%d restarts, max %d)", e.restartCount, maxRestarts)
 	}
@@ -853,6 +1130,13 @@ func (e *Engine) loopRestart(ctx context.Context, targetNodeID string, fromNodeI
 		"retry_budget_reset": true,
 		"persist_keys":       persistKeyNames,
 	})
+	e.appendProgress(map[string]any{
+		"event":       "stage_loop_restart",
+		"node_id":     fromNodeID,
+		"target_node": targetNodeID,
+		"loop_count":  e.restartCount,
+		"max_loops":   maxRestarts,
+	})
 
 	// Switch to fresh logs; worktree stays the same.
 	e.LogsRoot = newLogsRoot
@@ -886,6 +1170,7 @@ func (e *Engine) loopRestart(ctx context.Context, targetNodeID string, fromNodeI
 	}
 	e.Context.Set("graph.goal", e.Graph.Attrs["goal"])
 	e.Context.Set("base_sha", e.baseSHA)
+	e.seedInitialContext()
 
 	// Restore persisted context keys from the previous iteration.
 	for k, v := range persistedValues {
@@ -905,6 +1190,33 @@ func (e *Engine) loopRestart(ctx context.Context, targetNodeID string, fromNodeI
 	return e.runLoop(ctx, targetNodeID, nil, map[string]int{}, map[string]runtime.Outcome{})
 }
 
+// runInputs returns the initial context for the required-inputs check; it is
+// never nil, so a run with no seeds still reports missing inputs.
+func runInputs(initial map[string]any) map[string]any {
+	out := make(map[string]any, len(initial))
+	for k, v := range initial {
+		if k = strings.TrimSpace(k); k != "" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// seedInitialContext applies RunOptions.InitialContext on top of the current
+// context. Keys are trimmed; empty keys are ignored.
+func (e *Engine) seedInitialContext() {
+	if e == nil || e.Context == nil {
+		return
+	}
+	for k, v := range e.Options.InitialContext {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		e.Context.Set(k, v)
+	}
+}
+
 // snapshotPersistKeys extracts context values that should survive a loop_restart
 // context reset. Keys are specified via the graph-level loop_restart_persist_keys
 // attribute as a comma-separated list (e.g., "completed_features,skipped_features").
@@ -933,6 +1245,30 @@ func (e *Engine) snapshotPersistKeys() map[string]any {
 }
 
 func (e *Engine) executeNode(ctx context.Context, node *model.Node) (runtime.Outcome, error) {
+	stageCtx, cancelStage := context.WithCancelCause(ctx)
+	defer cancelStage(nil)
+	e.setActiveStage(node.ID, cancelStage)
+	defer e.setActiveStage("", nil)
+	ctx = stageCtx
+
+	// Nodes sharing a lock name run one at a time across the whole run. The
+	// wait happens before the stage timeout starts so queued nodes are not
+	// charged for it.
+	if names := nodeLockNames(node); len(names) > 0 {
+		waitStart := time.Now()
+		release, err := e.sharedNodeLocks().acquire(ctx, names)
+		if err != nil {
+			return runtime.Outcome{Status: runtime.StatusFail, FailureReason: fmt.Sprintf("waiting for node lock %s: %v", strings.Join(names, ","), err)}, err
+		}
+		defer release()
+		e.appendProgress(map[string]any{
+			"event":   "node_lock_acquired",
+			"node_id": node.ID,
+			"locks":   names,
+			"wait_ms": time.Since(waitStart).Milliseconds(),
+		})
+	}
+
 	// Effective timeout uses the smaller positive timeout between node timeout
 	// and global StageTimeout.
 	if timeout := effectiveStageTimeout(node, e.Options.StageTimeout); timeout > 0 {
@@ -1006,6 +1342,30 @@ func (e *Engine) executeNode(ctx context.Context, node *model.Node) (runtime.Out
 			out.FailureReason = cause.Error()
 		}
 	}
+	if errors.Is(context.Cause(stageCtx), errStallNodeFailed) {
+		if out.Status != runtime.StatusFail && out.Status != runtime.StatusRetry {
+			out.Status = runtime.StatusFail
+			out.FailureReason = context.Cause(stageCtx).Error()
+		}
+		if out.Meta == nil {
+			out.Meta = map[string]any{}
+		}
+		out.Meta["failure_class"] = failureClassTransientInfra
+	}
+	// node_timeout_ms expiry is a transient infrastructure failure so the node's
+	// retry policy applies, unlike a handler that fails on its own. The run
+	// timeout also surfaces as DeadlineExceeded but ends the run instead.
+	if nodeTimeout := nodeTimeoutMS(node); nodeTimeout > 0 && ctx.Err() == context.DeadlineExceeded &&
+		!errors.Is(context.Cause(ctx), errRunTimeout) &&
+		effectiveStageTimeout(node, e.Options.StageTimeout) == nodeTimeout &&
+		(out.Status == runtime.StatusFail || out.Status == runtime.StatusRetry) {
+		out.Status = runtime.StatusFail
+		out.FailureReason = "node timeout"
+		if out.Meta == nil {
+			out.Meta = map[string]any{}
+		}
+		out.Meta["failure_class"] = failureClassTransientInfra
+	}
 	// Enrich timeout outcomes with diagnostic metadata so downstream consumers
 	// know the node timed out (vs. crashed) and what state the worktree was left in.
 	// This runs after status.json is read so it applies regardless of handler path.
@@ -1081,16 +1441,17 @@ func (e *Engine) executeWithRetry(ctx context.Context, node *model.Node, retries
 			"node_id": node.ID,
 			"attempt": 1,
 			"max":     1,
+			"step":    e.stepCount(),
 		})
 		out, _ := e.executeNode(ctx, node)
-		e.appendProgress(map[string]any{
+		e.appendProgress(withToolResultFields(map[string]any{
 			"event":          "stage_attempt_end",
 			"node_id":        node.ID,
 			"attempt":        1,
 			"max":            1,
 			"status":         string(out.Status),
 			"failure_reason": out.FailureReason,
-		})
+		}, out))
 		return out, nil
 	}
 
@@ -1128,16 +1489,17 @@ func (e *Engine) executeWithRetry(ctx context.Context, node *model.Node, retries
 			"node_id": node.ID,
 			"attempt": attempt,
 			"max":     maxAttempts,
+			"step":    e.stepCount(),
 		})
 		out, _ := e.executeNode(ctx, node)
-		e.appendProgress(map[string]any{
+		e.appendProgress(withToolResultFields(map[string]any{
 			"event":          "stage_attempt_end",
 			"node_id":        node.ID,
 			"attempt":        attempt,
 			"max":            maxAttempts,
 			"status":         string(out.Status),
 			"failure_reason": out.FailureReason,
-		})
+		}, out))
 		if ctx.Err() != nil {
 			co := canceledOutcomeForRetry(ctx, out)
 			fo, _ := co.Canonicalize()
@@ -1159,16 +1521,31 @@ func (e *Engine) executeWithRetry(ctx context.Context, node *model.Node, retries
 			return out, nil
 		}
 
-		failureClass := classifyFailureClass(out)
+		failureClass := e.classifyAttempt(&out)
+		// retry_on_exit_codes overrides classification from the exit code:
+		// listed codes retry, any other code fails fast as deterministic.
+		exitCodeClass, exitCodePolicy := exitCodeFailureClass(node, out)
+		if exitCodePolicy {
+			failureClass = exitCodeClass
+			if out.Meta == nil {
+				out.Meta = map[string]any{}
+			}
+			if out.ContextUpdates == nil {
+				out.ContextUpdates = map[string]any{}
+			}
+			out.Meta["failure_class"] = failureClass
+			out.ContextUpdates["failure_class"] = failureClass
+		}
 		// Spec §9.6: emit StageFailed CXDB event on failure.
 		willRetry := false // updated below if retry is possible
 		canRetry := false
 		if attempt < maxAttempts {
 			// Tool command nodes (shape=parallelogram) always retry when
-			// max_retries is set — the user explicitly opted in. LLM/API
-			// nodes use failure classification to gate retries.
+			// max_retries is set — the user explicitly opted in — unless
+			// retry_on_exit_codes narrowed that. LLM/API nodes use failure
+			// classification to gate retries.
 			isToolNode := strings.TrimSpace(node.Attr("tool_command", "")) != ""
-			if isToolNode {
+			if isToolNode && !exitCodePolicy {
 				canRetry = out.Status == runtime.StatusFail || out.Status == runtime.StatusRetry
 			} else if shouldRetryOutcome(out, failureClass) {
 				canRetry = true
@@ -1319,11 +1696,20 @@ func (e *Engine) checkpoint(nodeID string, out runtime.Outcome, completed []stri
 		}
 	}
 	if sha == "" {
+		if err := checkWorktreeGitState(e.WorktreeDir, e.RunBranch); err != nil {
+			return "", fmt.Errorf("checkpoint %s: %w", nodeID, err)
+		}
+		if err := e.checkFreeSpace(); err != nil {
+			return "", fmt.Errorf("checkpoint %s: %w", nodeID, err)
+		}
 		var err error
 		sha, err = e.commitAllowEmptyCheckpoint(msg)
 		if err != nil {
 			return "", err
 		}
+		// A handler-provided SHA may be the base of parallel branches that
+		// are fast-forwarded later, so only engine-made commits are pruned.
+		sha = e.pruneCheckpoints(nodeID, sha)
 	} else {
 		head, err := gitutil.HeadSHA(e.WorktreeDir)
 		if err != nil {
@@ -1337,6 +1723,7 @@ func (e *Engine) checkpoint(nodeID string, out runtime.Outcome, completed []stri
 	cp.Timestamp = time.Now().UTC()
 	cp.CurrentNode = nodeID
 	cp.CompletedNodes = append([]string{}, completed...)
+	e.completedNodes = cp.CompletedNodes
 	cp.NodeRetries = copyStringIntMap(retries)
 	cp.ContextValues = e.Context.SnapshotValues()
 	cp.Logs = e.Context.SnapshotLogs()
@@ -1352,6 +1739,9 @@ func (e *Engine) checkpoint(nodeID string, out runtime.Outcome, completed []stri
 	if len(e.loopFailureSignatures) > 0 {
 		cp.Extra["loop_failure_signatures"] = copyStringIntMap(e.loopFailureSignatures)
 	}
+	if n := e.stepCount(); n > 0 {
+		cp.Extra["total_steps"] = n
+	}
 	if strings.TrimSpace(e.lastResolvedFidelity) != "" {
 		cp.Extra["last_fidelity"] = e.lastResolvedFidelity
 		if strings.TrimSpace(e.lastResolvedThreadKey) != "" {
@@ -1375,28 +1765,51 @@ func (e *Engine) commitAllowEmptyCheckpoint(message string) (string, error) {
 	if e == nil {
 		return "", fmt.Errorf("engine is nil")
 	}
+	mu := e.sharedGitLock()
+	mu.Lock()
+	defer mu.Unlock()
 	return gitutil.CommitAllowEmptyWithExcludes(e.WorktreeDir, message, e.checkpointExcludeGlobs())
 }
 
+// sharedGitLock returns the run-wide git lock, creating it on first use for
+// engines built without one.
+func (e *Engine) sharedGitLock() *sync.Mutex {
+	e.stageMu.Lock()
+	defer e.stageMu.Unlock()
+	if e.gitLock == nil {
+		e.gitLock = &sync.Mutex{}
+	}
+	return e.gitLock
+}
+
 func (e *Engine) writeManifest(baseSHA string) error {
+	now := time.Now().UTC()
+	if e.startedAt.IsZero() {
+		e.startedAt = now
+	}
 	manifest := map[string]any{
-		"run_id":     e.Options.RunID,
-		"graph_name": e.Graph.Name,
-		"goal":       e.Graph.Attrs["goal"],
-		"base_sha":   baseSHA,
-		"run_branch": e.RunBranch,
-		"logs_root":  e.LogsRoot,
-		"worktree":   e.WorktreeDir,
-		"graph_dot":  filepath.Join(e.LogsRoot, "graph.dot"),
-		"started_at": time.Now().UTC().Format(time.RFC3339Nano),
-		"repo_path":  e.Options.RepoPath,
-		"kilroy_v1":  true,
+		"run_id":            e.Options.RunID,
+		"graph_name":        e.Graph.Name,
+		"goal":              e.Graph.Attrs["goal"],
+		"base_sha":          baseSHA,
+		"run_branch":        e.RunBranch,
+		"checkpoint_branch": strings.TrimSpace(e.Options.CheckpointBranch),
+		"logs_root":         e.LogsRoot,
+		"worktree":          e.WorktreeDir,
+		"graph_dot":         filepath.Join(e.LogsRoot, "graph.dot"),
+		"graph_path":        strings.TrimSpace(e.Options.GraphPath),
+		"graph_sha256":      sha256Hex(e.DotSource),
+		"started_at":        now.Format(time.RFC3339Nano),
+		"node_count":        len(e.Graph.Nodes),
+		"repo_path":         e.Options.RepoPath,
+		"kilroy_v1":         true,
 		"run_config_path": func() string {
 			if e.RunConfig == nil {
 				return ""
 			}
 			return filepath.Join(e.LogsRoot, "run_config.json")
 		}(),
+		"run_config_sha256": runConfigSHA256(e.RunConfig),
 		"modeldb": map[string]any{
 			"openrouter_model_info_path":   e.ModelCatalogPath,
 			"openrouter_model_info_sha256": e.ModelCatalogSHA,
@@ -1414,12 +1827,26 @@ func (e *Engine) writeManifest(baseSHA string) error {
 			}
 		}(),
 	}
+	// pid_start_time lets status tell a live run from a recycled pid.
+	manifest["pid"] = os.Getpid()
+	if start, err := procutil.ReadPIDStartTime(os.Getpid()); err == nil {
+		manifest["pid_start_time"] = start
+	}
 	if ws := e.warningsCopy(); len(ws) > 0 {
 		manifest["warnings"] = ws
 	}
 	if len(e.Options.ForceModels) > 0 {
 		manifest["force_models"] = copyStringStringMap(e.Options.ForceModels)
 	}
+	if len(e.Options.Labels) > 0 {
+		manifest["labels"] = copyStringStringMap(e.Options.Labels)
+	}
+	if exes := providerExecutableOverrides(e.RunConfig, e.Options); len(exes) > 0 {
+		manifest["provider_executables"] = exes
+	}
+	if e.Options.NoNetwork {
+		manifest["no_network"] = true
+	}
 	return writeJSON(filepath.Join(e.LogsRoot, "manifest.json"), manifest)
 }
 
@@ -1445,10 +1872,23 @@ func (e *Engine) persistFatalOutcome(ctx context.Context, runErr error) {
 		sha = strings.TrimSpace(e.baseSHA)
 	}
 
+	// ctx is the caller's context: once it is done the run was canceled from
+	// outside (run timeouts and stall aborts cancel only the run's own child
+	// context), so the outcome is recorded as canceled rather than failed.
+	status := runtime.FinalFail
+	if ctx.Err() != nil {
+		status = runtime.FinalCanceled
+		e.appendProgress(map[string]any{
+			"event":   "run_canceled",
+			"node_id": nodeID,
+			"reason":  reason,
+		})
+	}
+
 	failedTurnID, _ := e.cxdbRunFailed(ctx, nodeID, sha, reason)
 	final := runtime.FinalOutcome{
 		Timestamp:         time.Now().UTC(),
-		Status:            runtime.FinalFail,
+		Status:            status,
 		RunID:             e.Options.RunID,
 		FinalGitCommitSHA: sha,
 		FailureReason:     reason,
@@ -1478,6 +1918,9 @@ func (e *Engine) persistTerminalOutcome(ctx context.Context, final runtime.Final
 		final.CXDBHeadTurnID = strings.TrimSpace(e.CXDB.HeadTurnID)
 	}
 
+	e.finishMetrics(string(final.Status))
+	e.summarizeFinal(&final)
+
 	primaryPath := ""
 	for _, p := range e.finalOutcomePaths() {
 		if err := final.Save(p); err != nil {
@@ -1558,6 +2001,23 @@ func (e *Engine) gitPushIfConfigured() {
 	})
 }
 
+// summarizeFinal fills the run summary fields of final that the caller left
+// unset.
+func (e *Engine) summarizeFinal(final *runtime.FinalOutcome) {
+	if len(final.CompletedNodes) == 0 {
+		final.CompletedNodes = append([]string{}, e.completedNodes...)
+	}
+	if final.DurationMS == 0 && !e.startedAt.IsZero() {
+		final.DurationMS = final.Timestamp.Sub(e.startedAt).Milliseconds()
+	}
+	if final.Attempts == 0 && final.TotalTokens == 0 {
+		e.sharedMetrics().summarize(final)
+	}
+	if final.ModelCatalogDrift == nil {
+		final.ModelCatalogDrift = e.ModelCatalogDrift
+	}
+}
+
 func (e *Engine) finalOutcomePaths() []string {
 	if e == nil {
 		return nil
@@ -1587,7 +2047,32 @@ func effectiveStageTimeout(node *model.Node, global time.Duration) time.Duration
 	if node != nil {
 		nodeTimeout = parseDuration(node.Attr("timeout", ""), 0)
 	}
-	return minPositiveDuration(nodeTimeout, global)
+	return minPositiveDuration(minPositiveDuration(nodeTimeout, nodeTimeoutMS(node)), global)
+}
+
+// nodeTimeoutMS returns the node_timeout_ms attribute as a duration, or 0 when
+// unset. Prepare rejects malformed values, so parse errors are treated as unset.
+func nodeTimeoutMS(node *model.Node) time.Duration {
+	if node == nil {
+		return 0
+	}
+	ms, err := strconv.Atoi(strings.TrimSpace(node.Attr("node_timeout_ms", "")))
+	if err != nil || ms <= 0 {
+		return 0
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// runTimeout returns the effective run timeout: the smaller positive value of
+// the graph's run_timeout_ms attribute and the RunOptions cap.
+func runTimeout(g *model.Graph, opt time.Duration) time.Duration {
+	var graphTimeout time.Duration
+	if g != nil {
+		if ms, err := strconv.Atoi(strings.TrimSpace(g.Attrs["run_timeout_ms"])); err == nil && ms > 0 {
+			graphTimeout = time.Duration(ms) * time.Millisecond
+		}
+	}
+	return minPositiveDuration(graphTimeout, opt)
 }
 
 func minPositiveDuration(a, b time.Duration) time.Duration {
@@ -1616,6 +2101,9 @@ func (e *Engine) runStallWatchdog(ctx context.Context, cancel context.CancelCaus
 	ticker := time.NewTicker(checkEvery)
 	defer ticker.Stop()
 
+	// warnedAt is the progress time the last stall_warning was issued for, so
+	// the warning fires once per idle stretch.
+	var warnedAt time.Time
 	for {
 		select {
 		case <-ctx.Done():
@@ -1628,8 +2116,37 @@ func (e *Engine) runStallWatchdog(ctx context.Context, cancel context.CancelCaus
 			}
 			idle := time.Since(last)
 			if idle < stallTimeout {
+				// Heartbeats and warnings are not activity: they must not
+				// reset the idle timer they report on.
+				e.appendWatchdogProgress(map[string]any{
+					"event":      "stall_heartbeat",
+					"node_id":    e.activeStageID(),
+					"idle_ms":    idle.Milliseconds(),
+					"timeout_ms": stallTimeout.Milliseconds(),
+				})
+				if idle >= stallTimeout*stallWarningPercent/100 && !warnedAt.Equal(last) {
+					warnedAt = last
+					e.appendWatchdogProgress(map[string]any{
+						"event":      "stall_warning",
+						"node_id":    e.activeStageID(),
+						"idle_ms":    idle.Milliseconds(),
+						"timeout_ms": stallTimeout.Milliseconds(),
+					})
+				}
 				continue
 			}
+			if e.Options.StallAction == StallActionFailNode {
+				cause := fmt.Errorf("%w after %s with no progress", errStallNodeFailed, stallTimeout)
+				if nodeID := e.cancelActiveStage(cause); nodeID != "" {
+					e.appendProgress(map[string]any{
+						"event":            "stall_watchdog_node_failed",
+						"node_id":          nodeID,
+						"stall_timeout_ms": stallTimeout.Milliseconds(),
+						"idle_ms":          idle.Milliseconds(),
+					})
+					continue
+				}
+			}
 			e.appendProgress(map[string]any{
 				"event":            "stall_watchdog_timeout",
 				"stall_timeout_ms": stallTimeout.Milliseconds(),
@@ -1641,10 +2158,114 @@ func (e *Engine) runStallWatchdog(ctx context.Context, cancel context.CancelCaus
 	}
 }
 
+// stepCounter is the run-wide node execution count. limit is the top-level
+// graph's max_total_steps, so branch and child engines, whose graphs may
+// differ, enforce the run's cap.
+type stepCounter struct {
+	n     atomic.Int64
+	limit int
+}
+
+// sharedSteps returns the engine's step counter, creating it with this
+// engine's graph limit on first use.
+func (e *Engine) sharedSteps() *stepCounter {
+	e.stageMu.Lock()
+	defer e.stageMu.Unlock()
+	if e.totalSteps == nil {
+		e.totalSteps = &stepCounter{limit: maxTotalSteps(e.Graph)}
+	}
+	return e.totalSteps
+}
+
+// sharedMetrics returns the engine's metrics accumulator, creating it on first
+// use with this engine as the owner whose logs root metrics.json is written to.
+func (e *Engine) sharedMetrics() *runMetrics {
+	e.stageMu.Lock()
+	defer e.stageMu.Unlock()
+	if e.metrics == nil {
+		e.metrics = &runMetrics{owner: e}
+	}
+	return e.metrics
+}
+
+// stepCount returns the node executions counted so far.
+func (e *Engine) stepCount() int {
+	return int(e.sharedSteps().n.Load())
+}
+
+// countStep counts one node execution against graph [max_total_steps=N] and
+// fails once N executions have already happened, before the next one starts.
+func (e *Engine) countStep(nodeID string) error {
+	steps := e.sharedSteps()
+	for {
+		n := steps.n.Load()
+		if steps.limit > 0 && n >= int64(steps.limit) {
+			e.appendProgress(map[string]any{
+				"event":      "max_total_steps_exceeded",
+				"node_id":    nodeID,
+				"step_count": n,
+				"step_limit": steps.limit,
+			})
+			return fmt.Errorf("run aborted: %d node executions reached max_total_steps=%d before node %q", n, steps.limit, nodeID)
+		}
+		if steps.n.CompareAndSwap(n, n+1) {
+			return nil
+		}
+	}
+}
+
+// activeStageID returns the node whose attempt is running, or "".
+func (e *Engine) activeStageID() string {
+	e.stageMu.Lock()
+	defer e.stageMu.Unlock()
+	return e.activeStageNodeID
+}
+
+func (e *Engine) setActiveStage(nodeID string, cancel context.CancelCauseFunc) {
+	e.stageMu.Lock()
+	e.activeStageNodeID = nodeID
+	e.activeStageCancel = cancel
+	e.stageMu.Unlock()
+}
+
+// cancelActiveStage cancels the running node attempt with cause and returns
+// its node id, or "" when no node is executing.
+func (e *Engine) cancelActiveStage(cause error) string {
+	e.stageMu.Lock()
+	defer e.stageMu.Unlock()
+	if e.activeStageCancel == nil {
+		return ""
+	}
+	e.activeStageCancel(cause)
+	return e.activeStageNodeID
+}
+
 func writeJSON(path string, v any) error {
 	return runtime.WriteJSONAtomicFile(path, v)
 }
 
+// sha256Hex returns the hex SHA-256 of b, or "" when b is empty.
+func sha256Hex(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// runConfigSHA256 identifies a run config by the SHA-256 of its JSON
+// encoding, so equal configs hash equally regardless of source formatting.
+func runConfigSHA256(cfg *RunConfigFile) string {
+	if cfg == nil {
+		return ""
+	}
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return ""
+	}
+	return sha256Hex(b)
+}
+
 func copyStringIntMap(in map[string]int) map[string]int {
 	out := make(map[string]int, len(in))
 	for k, v := range in {
@@ -1,6 +1,8 @@
 package engine
 
 import (
+	"strings"
+
 	"github.com/danshapiro/kilroy/internal/attractor/model"
 	"github.com/danshapiro/kilroy/internal/attractor/runtime"
 )
@@ -16,13 +18,17 @@ func newBaseEngine(g *model.Graph, dotSource []byte, opts RunOptions) *Engine {
 		Registry:    NewDefaultRegistry(),
 		Interviewer: &AutoApproveInterviewer{},
 		Artifacts:   NewArtifactStore(opts.LogsRoot, DefaultFileBackingThreshold),
+		nodeLocks:   newNodeLocks(),
 	}
 	if opts.ProgressSink != nil {
-		e.progressSink = opts.ProgressSink
+		e.progressSinks = []func(map[string]any){opts.ProgressSink}
 	}
 	if opts.Interviewer != nil {
 		e.Interviewer = opts.Interviewer
 	}
 	e.RunBranch = buildRunBranch(opts.RunBranchPrefix, opts.RunID)
+	if b := strings.TrimSpace(opts.CheckpointBranch); b != "" {
+		e.RunBranch = b
+	}
 	return e
 }
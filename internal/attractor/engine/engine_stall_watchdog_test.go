@@ -2,11 +2,14 @@ package engine
 
 import (
 	"context"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
 )
 
 func TestRun_StallWatchdog(t *testing.T) {
@@ -110,3 +113,116 @@ func TestRun_StallWatchdog_ParallelBranchProgressKeepsParentAlive(t *testing.T)
 		t.Fatalf("expected no stall watchdog timeout, got %v", err)
 	}
 }
+
+func TestRun_StallWatchdogFailNode_RetriesStalledNode(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("requires sleep binary")
+	}
+	marker := filepath.Join(t.TempDir(), "attempted")
+	dot := []byte(`digraph G {
+  graph [retry.backoff.initial_delay_ms=10, retry.backoff.max_delay_ms=10]
+  start [shape=Mdiamond]
+  wait [shape=parallelogram, max_retries=2, tool_command="if [ -f '` + marker + `' ]; then exit 0; fi; touch '` + marker + `'; sleep 30"]
+  exit [shape=Msquare]
+  start -> wait
+  wait -> exit [condition="outcome=success"]
+}`)
+	repo := initTestRepo(t)
+	opts := RunOptions{
+		RepoPath:           repo,
+		StallTimeout:       300 * time.Millisecond,
+		StallCheckInterval: 25 * time.Millisecond,
+		StallAction:        StallActionFailNode,
+	}
+
+	start := time.Now()
+	res, err := Run(context.Background(), dot, opts)
+	if err != nil {
+		t.Fatalf("expected stalled node to be retried, got run error: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: got %q want success", res.FinalStatus)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("expected stalled process group to be killed promptly; elapsed=%s", elapsed)
+	}
+
+	b, err := os.ReadFile(filepath.Join(res.LogsRoot, "progress.ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress := string(b)
+	if !strings.Contains(progress, `"event":"stall_watchdog_node_failed"`) {
+		t.Fatalf("expected stall_watchdog_node_failed event in progress:\n%s", progress)
+	}
+	if strings.Contains(progress, `"event":"stall_watchdog_timeout"`) {
+		t.Fatalf("did not expect run-level stall timeout in fail-node mode:\n%s", progress)
+	}
+	if !strings.Contains(progress, `"event":"stage_retry_sleep"`) {
+		t.Fatalf("expected the stalled node to retry:\n%s", progress)
+	}
+}
+
+func TestRun_StallWatchdogEmitsHeartbeatAndOneWarning(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("requires sleep binary")
+	}
+	logsRoot := filepath.Join(t.TempDir(), "logs")
+	dot := []byte(`digraph G {
+  start [shape=Mdiamond]
+  wait [shape=parallelogram, tool_command="sleep 2"]
+  exit [shape=Msquare]
+  start -> wait
+  wait -> exit [condition="outcome=success"]
+}`)
+	repo := initTestRepo(t)
+	opts := RunOptions{
+		RepoPath:           repo,
+		LogsRoot:           logsRoot,
+		StallTimeout:       200 * time.Millisecond,
+		StallCheckInterval: 20 * time.Millisecond,
+	}
+	if _, err := Run(context.Background(), dot, opts); err == nil {
+		t.Fatal("expected stall watchdog timeout")
+	}
+
+	var heartbeats, warnings int
+	tripped := false
+	for _, ev := range readProgressEvents(t, filepath.Join(logsRoot, "progress.ndjson")) {
+		switch ev["event"] {
+		case "stall_heartbeat", "stall_warning":
+			if tripped {
+				t.Fatalf("%v emitted after the watchdog tripped", ev["event"])
+			}
+			if got, _ := ev["timeout_ms"].(float64); got != 200 {
+				t.Fatalf("%v timeout_ms=%v want 200", ev["event"], ev["timeout_ms"])
+			}
+			idle, _ := ev["idle_ms"].(float64)
+			if idle >= 200 {
+				t.Fatalf("%v idle_ms=%v should be under the timeout", ev["event"], idle)
+			}
+			if ev["event"] == "stall_warning" {
+				warnings++
+				if idle < 150 {
+					t.Fatalf("stall_warning idle_ms=%v before 75%% of the timeout", idle)
+				}
+				if ev["node_id"] != "wait" {
+					t.Fatalf("stall_warning node_id=%v want wait", ev["node_id"])
+				}
+			} else {
+				heartbeats++
+			}
+		case "stall_watchdog_timeout":
+			tripped = true
+		}
+	}
+	if !tripped {
+		t.Fatal("expected stall_watchdog_timeout event")
+	}
+	if heartbeats == 0 {
+		t.Fatal("expected stall_heartbeat events while the node was idle")
+	}
+	if warnings != 1 {
+		t.Fatalf("stall_warning count=%d want 1", warnings)
+	}
+}
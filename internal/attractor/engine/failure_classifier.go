@@ -0,0 +1,44 @@
+package engine
+
+import "github.com/danshapiro/kilroy/internal/attractor/runtime"
+
+// FailureClassifier assigns a failure class to a failed node attempt. The
+// engine calls it after every failed attempt, before deciding whether to
+// retry, and records the result as failure_class in the outcome Meta, where
+// retry gating, routing, and the deterministic failure cycle breaker read it.
+//
+// Classify returns one of "transient_infra", "deterministic", "canceled",
+// "budget_exhausted", "compilation_loop", or "structural"; unknown values are
+// treated as deterministic. Returning "" defers to DefaultFailureClassifier.
+type FailureClassifier interface {
+	Classify(out runtime.Outcome) string
+}
+
+// DefaultFailureClassifier is the built-in classifier: it honors a
+// failure_class the handler already set and otherwise matches the failure
+// reason against known transient, budget, and structural hints.
+type DefaultFailureClassifier struct{}
+
+func (DefaultFailureClassifier) Classify(out runtime.Outcome) string {
+	return classifyFailureClass(out)
+}
+
+// classifyAttempt runs the configured classifier over a failed attempt and
+// tags out with the resulting class.
+func (e *Engine) classifyAttempt(out *runtime.Outcome) string {
+	var cls string
+	if c := e.Options.FailureClassifier; c != nil {
+		cls = normalizedFailureClass(c.Classify(*out))
+	}
+	if cls == "" {
+		cls = classifyFailureClass(*out)
+	}
+	if cls == "" {
+		return ""
+	}
+	if out.Meta == nil {
+		out.Meta = map[string]any{}
+	}
+	out.Meta["failure_class"] = cls
+	return cls
+}
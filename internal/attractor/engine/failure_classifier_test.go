@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+// flakyCodergenBackend fails its first fails calls with reason, then succeeds.
+type flakyCodergenBackend struct {
+	mu     sync.Mutex
+	fails  int
+	reason string
+	calls  int
+}
+
+func (b *flakyCodergenBackend) Run(ctx context.Context, exec *Execution, node *model.Node, prompt string) (string, *runtime.Outcome, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls++
+	if b.calls <= b.fails {
+		return "", &runtime.Outcome{Status: runtime.StatusFail, FailureReason: b.reason}, nil
+	}
+	return "ok", &runtime.Outcome{Status: runtime.StatusSuccess}, nil
+}
+
+// stderrClassifier marks provider quota messages as transient and defers
+// everything else to the default rules.
+type stderrClassifier struct{}
+
+func (stderrClassifier) Classify(out runtime.Outcome) string {
+	if strings.Contains(out.FailureReason, "quota bucket empty") {
+		return failureClassTransientInfra
+	}
+	return ""
+}
+
+func TestRun_FailureClassifier_DrivesRetryDecision(t *testing.T) {
+	dot := []byte(`digraph G {
+  graph [goal="test"]
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  impl [shape=box, max_retries=2, llm_provider=openai, llm_model=gpt-5.2, prompt="implement"]
+  start -> impl -> exit
+}`)
+
+	// The default classifier treats the reason as deterministic: no retry.
+	backend := &flakyCodergenBackend{fails: 1, reason: "quota bucket empty"}
+	_, _ = Run(context.Background(), dot, RunOptions{RepoPath: initTestRepo(t), LogsRoot: t.TempDir(), CodergenBackend: backend})
+	if backend.calls != 1 {
+		t.Fatalf("default classifier: got %d calls want 1", backend.calls)
+	}
+
+	backend = &flakyCodergenBackend{fails: 1, reason: "quota bucket empty"}
+	res, err := Run(context.Background(), dot, RunOptions{RepoPath: initTestRepo(t), LogsRoot: t.TempDir(), CodergenBackend: backend, FailureClassifier: stderrClassifier{}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess || backend.calls != 2 {
+		t.Fatalf("custom classifier: final=%s calls=%d, want success after 2 calls", res.FinalStatus, backend.calls)
+	}
+}
+
+func TestClassifyAttempt_TagsOutcomeMeta(t *testing.T) {
+	e := &Engine{Options: RunOptions{FailureClassifier: stderrClassifier{}}}
+
+	out := runtime.Outcome{Status: runtime.StatusFail, FailureReason: "quota bucket empty"}
+	if got := e.classifyAttempt(&out); got != failureClassTransientInfra || out.Meta["failure_class"] != failureClassTransientInfra {
+		t.Fatalf("custom class: got %q meta=%v", got, out.Meta)
+	}
+	// An empty answer falls back to DefaultFailureClassifier.
+	out = runtime.Outcome{Status: runtime.StatusFail, FailureReason: "connection refused"}
+	if got := e.classifyAttempt(&out); got != failureClassTransientInfra {
+		t.Fatalf("fallback class: got %q", got)
+	}
+	if got := classifyFailureClass(out); got != failureClassTransientInfra {
+		t.Fatalf("tagged outcome re-classified as %q", got)
+	}
+}
@@ -1,6 +1,13 @@
 package engine
 
-import "github.com/danshapiro/kilroy/internal/attractor/runtime"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
 
 // retryableFailureClasses lists failure classes that should trigger automatic retries.
 // transient_infra: temporary infrastructure issues (API timeouts, rate limits)
@@ -25,3 +32,65 @@ func isEscalatableFailureClass(failureClass string) bool {
 	cls := normalizedFailureClassOrDefault(failureClass)
 	return cls == failureClassBudgetExhausted || cls == failureClassCompilationLoop
 }
+
+// retryOnExitCodes parses the retry_on_exit_codes node attribute
+// (comma-separated integers). ok is false when the attribute is unset.
+func retryOnExitCodes(node *model.Node) (codes map[int]bool, ok bool, err error) {
+	raw := strings.TrimSpace(node.Attr("retry_on_exit_codes", ""))
+	if raw == "" {
+		return nil, false, nil
+	}
+	codes = map[int]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false, fmt.Errorf("retry_on_exit_codes: invalid exit code %q", part)
+		}
+		codes[n] = true
+	}
+	return codes, true, nil
+}
+
+// outcomeExitCode returns the process exit code a handler recorded in
+// out.Meta["exit_code"].
+func outcomeExitCode(out runtime.Outcome) (int, bool) {
+	if out.Meta == nil {
+		return 0, false
+	}
+	raw, ok := out.Meta["exit_code"]
+	if !ok || raw == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(fmt.Sprint(raw)))
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// exitCodeFailureClass applies a node's retry_on_exit_codes policy to a failed
+// outcome: listed exit codes are transient_infra (retryable), any other exit
+// code is deterministic. ok is false when the node has no policy or the
+// outcome carries no exit code (e.g. timeouts and cancellations), in which
+// case the usual retry rules apply.
+func exitCodeFailureClass(node *model.Node, out runtime.Outcome) (class string, ok bool) {
+	if out.Status != runtime.StatusFail && out.Status != runtime.StatusRetry {
+		return "", false
+	}
+	codes, set, err := retryOnExitCodes(node)
+	if err != nil || !set {
+		return "", false
+	}
+	code, hasCode := outcomeExitCode(out)
+	if !hasCode {
+		return "", false
+	}
+	if codes[code] {
+		return failureClassTransientInfra, true
+	}
+	return failureClassDeterministic, true
+}
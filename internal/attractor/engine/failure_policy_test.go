@@ -3,6 +3,7 @@ package engine
 import (
 	"testing"
 
+	"github.com/danshapiro/kilroy/internal/attractor/model"
 	"github.com/danshapiro/kilroy/internal/attractor/runtime"
 )
 
@@ -90,3 +91,28 @@ func TestShouldRetryOutcome_NonFailureStatusesNeverRetry(t *testing.T) {
 		}
 	}
 }
+
+func TestExitCodeFailureClass_RetryOnExitCodes(t *testing.T) {
+	node := model.NewNode("t")
+	node.Attrs["retry_on_exit_codes"] = "2, 124"
+	fail := func(code any) runtime.Outcome {
+		return runtime.Outcome{Status: runtime.StatusFail, FailureReason: "exit status", Meta: map[string]any{"exit_code": code}}
+	}
+
+	if cls, ok := exitCodeFailureClass(node, fail(124)); !ok || cls != failureClassTransientInfra {
+		t.Fatalf("listed code: got %q ok=%v", cls, ok)
+	}
+	// status.json round-trips numbers as float64.
+	if cls, ok := exitCodeFailureClass(node, fail(float64(2))); !ok || cls != failureClassTransientInfra {
+		t.Fatalf("listed code from JSON: got %q ok=%v", cls, ok)
+	}
+	if cls, ok := exitCodeFailureClass(node, fail(1)); !ok || cls != failureClassDeterministic {
+		t.Fatalf("unlisted code: got %q ok=%v", cls, ok)
+	}
+	if _, ok := exitCodeFailureClass(node, runtime.Outcome{Status: runtime.StatusFail, FailureReason: "timed out"}); ok {
+		t.Fatalf("outcome without exit code should keep the usual policy")
+	}
+	if _, ok := exitCodeFailureClass(model.NewNode("u"), fail(2)); ok {
+		t.Fatalf("node without retry_on_exit_codes should keep the usual policy")
+	}
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/danshapiro/kilroy/internal/attractor/gitutil"
+)
+
+// checkRepoGitState refuses to start a run when the repo is in the middle of a
+// rebase, merge, cherry-pick, or revert. Those states make later branch and
+// checkpoint commands fail with git errors that are hard to trace back to the
+// repo. It reports whether HEAD is detached; that is safe because checkpoints
+// always commit to the run's own branch, but callers surface it as a warning.
+func checkRepoGitState(repoPath string) (detached bool, err error) {
+	op, err := gitutil.InProgressOperation(repoPath)
+	if err != nil {
+		return false, err
+	}
+	if op != "" {
+		return false, fmt.Errorf("repo has a %s in progress; finish or abort it (git %s --continue / --abort) before starting a run", op, op)
+	}
+	branch, err := gitutil.CurrentBranch(repoPath)
+	if err != nil {
+		return false, err
+	}
+	return branch == "", nil
+}
+
+// checkWorktreeGitState verifies the run worktree can take a checkpoint commit:
+// no unfinished git operation and HEAD still on a branch. Stages that leave the
+// worktree mid-rebase or detached would otherwise produce commits that never
+// land on the checkpoint branch.
+func checkWorktreeGitState(worktreeDir, branch string) error {
+	op, err := gitutil.InProgressOperation(worktreeDir)
+	if err != nil {
+		return err
+	}
+	if op != "" {
+		return fmt.Errorf("worktree has a %s in progress; cannot commit checkpoint to %s", op, branch)
+	}
+	got, err := gitutil.CurrentBranch(worktreeDir)
+	if err != nil {
+		return err
+	}
+	if got == "" {
+		return fmt.Errorf("worktree HEAD is detached; expected checkpoint branch %s", branch)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/gitutil"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestRun_DetachedRepoHEAD_CheckpointsToConfiguredBranch(t *testing.T) {
+	repo := initTestRepo(t)
+	runCmd(t, repo, "git", "checkout", "--detach")
+	baseSHA, err := gitutil.HeadSHA(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dot := []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  t [shape=parallelogram, tool_command="echo ok > ok.txt"]
+  start -> t -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	res, err := Run(ctx, dot, RunOptions{
+		RepoPath:         repo,
+		RunID:            "detached",
+		LogsRoot:         t.TempDir(),
+		CheckpointBranch: "kilroy/checkpoints",
+	})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: got %q want success", res.FinalStatus)
+	}
+
+	tip := strings.TrimSpace(runCmdOut(t, repo, "git", "rev-parse", "kilroy/checkpoints"))
+	if tip != res.FinalCommitSHA {
+		t.Fatalf("checkpoint branch tip=%s want final commit %s", tip, res.FinalCommitSHA)
+	}
+	// The repo itself stays detached at its original commit.
+	if head, _ := gitutil.HeadSHA(repo); head != baseSHA {
+		t.Fatalf("repo HEAD moved: got %s want %s", head, baseSHA)
+	}
+
+	b, err := os.ReadFile(filepath.Join(res.LogsRoot, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.RunBranch != "kilroy/checkpoints" || m.CheckpointBranch != "kilroy/checkpoints" {
+		t.Fatalf("manifest branches: run_branch=%q checkpoint_branch=%q", m.RunBranch, m.CheckpointBranch)
+	}
+}
+
+func TestRun_RefusesRepoWithMergeInProgress(t *testing.T) {
+	repo := initTestRepo(t)
+	head, err := gitutil.HeadSHA(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".git", "MERGE_HEAD"), []byte(head+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dot := []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  start -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, err = Run(ctx, dot, RunOptions{RepoPath: repo, RunID: "mid-merge", LogsRoot: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "merge in progress") {
+		t.Fatalf("expected merge-in-progress error, got %v", err)
+	}
+}
+
+func TestRun_StageThatDetachesWorktree_FailsCheckpointClearly(t *testing.T) {
+	repo := initTestRepo(t)
+
+	dot := []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  t [shape=parallelogram, tool_command="git checkout --detach"]
+  start -> t -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	_, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunID: "detach-stage", LogsRoot: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "worktree HEAD is detached") {
+		t.Fatalf("expected detached worktree checkpoint error, got %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+)
+
+// RenderOptions controls RenderDOT.
+type RenderOptions struct {
+	// Annotate rewrites labels for display: edge labels gain the condition,
+	// weight, and loop_restart flag, node labels gain max_retries, and
+	// retry_target / fallback_retry_target are drawn as dashed edges. The
+	// result is meant for Graphviz, not for running: edge labels take part in
+	// routing, so annotated output does not execute the same way.
+	Annotate bool
+}
+
+// RenderDOT writes a prepared graph back out as DOT. Nodes follow declaration
+// order, edges follow declaration order (chains already expanded), and
+// attributes are sorted, so the output is stable and shows the attributes the
+// engine resolved (stylesheet models, expanded $goal, prompt_file contents).
+func RenderDOT(g *model.Graph, opts RenderOptions) []byte {
+	var b bytes.Buffer
+	if g == nil {
+		return b.Bytes()
+	}
+	fmt.Fprintf(&b, "digraph %s {\n", dotID(g.Name))
+	if len(g.Attrs) > 0 {
+		fmt.Fprintf(&b, "  graph [%s];\n", dotAttrList(g.Attrs))
+	}
+
+	nodes := make([]*model.Node, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		if n != nil {
+			nodes = append(nodes, n)
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].Order != nodes[j].Order {
+			return nodes[i].Order < nodes[j].Order
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+	for _, n := range nodes {
+		attrs := n.Attrs
+		if opts.Annotate {
+			attrs = annotatedNodeAttrs(n)
+		}
+		if len(attrs) == 0 {
+			fmt.Fprintf(&b, "  %s;\n", dotID(n.ID))
+			continue
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotID(n.ID), dotAttrList(attrs))
+	}
+
+	for _, e := range g.Edges {
+		if e == nil {
+			continue
+		}
+		attrs := e.Attrs
+		if opts.Annotate {
+			attrs = annotatedEdgeAttrs(e)
+		}
+		if len(attrs) == 0 {
+			fmt.Fprintf(&b, "  %s -> %s;\n", dotID(e.From), dotID(e.To))
+			continue
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotID(e.From), dotID(e.To), dotAttrList(attrs))
+	}
+
+	if opts.Annotate {
+		for _, n := range nodes {
+			for _, key := range []string{"retry_target", "fallback_retry_target"} {
+				if t := strings.TrimSpace(n.Attr(key, "")); t != "" {
+					fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotID(n.ID), dotID(t),
+						dotAttrList(map[string]string{"label": key, "style": "dashed", "constraint": "false"}))
+				}
+			}
+		}
+	}
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+func annotatedNodeAttrs(n *model.Node) map[string]string {
+	attrs := copyStringMap(n.Attrs)
+	if r := strings.TrimSpace(n.Attr("max_retries", "")); r != "" {
+		attrs["label"] = n.Label() + "\nmax_retries=" + r
+	}
+	return attrs
+}
+
+func annotatedEdgeAttrs(e *model.Edge) map[string]string {
+	attrs := copyStringMap(e.Attrs)
+	var parts []string
+	if l := strings.TrimSpace(e.Label()); l != "" {
+		parts = append(parts, l)
+	}
+	if c := strings.TrimSpace(e.Condition()); c != "" {
+		parts = append(parts, "if "+c)
+	}
+	if w := strings.TrimSpace(e.Attr("weight", "")); w != "" {
+		parts = append(parts, "weight="+w)
+	}
+	if strings.EqualFold(e.Attr("loop_restart", "false"), "true") {
+		parts = append(parts, "loop_restart")
+	}
+	if len(parts) > 0 {
+		attrs["label"] = strings.Join(parts, "\n")
+	}
+	return attrs
+}
+
+func dotAttrList(attrs map[string]string) string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+dotQuote(attrs[k]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// dotID writes a graph, node, or edge-endpoint ID bare when it is a plain
+// identifier and quoted otherwise.
+func dotID(s string) string {
+	if dotBareIDRE.MatchString(s) {
+		return s
+	}
+	return dotQuote(s)
+}
+
+var dotBareIDRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// dotQuote quotes s as a DOT string using the escapes the parser understands.
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
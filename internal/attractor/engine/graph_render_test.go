@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/danshapiro/kilroy/internal/attractor/dot"
+)
+
+const renderTestGraph = `digraph P {
+  graph [goal="ship \"it\""]
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  impl [shape=parallelogram, tool_command="make test", max_retries=2, retry_target=impl]
+  fix [shape=parallelogram, tool_command="make fix"]
+  start -> impl
+  impl -> exit [condition="outcome=success", weight=5]
+  impl -> fix [condition="outcome=fail", label="broken"]
+  fix -> impl [loop_restart=true]
+}`
+
+func TestRenderDOT_RoundTripsPreparedGraph(t *testing.T) {
+	g, _, err := Prepare([]byte(renderTestGraph))
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	out := RenderDOT(g, RenderOptions{})
+	g2, err := dot.Parse(out)
+	if err != nil {
+		t.Fatalf("rendered DOT does not parse: %v\n%s", err, out)
+	}
+	if !reflect.DeepEqual(g.Attrs, g2.Attrs) {
+		t.Fatalf("graph attrs differ:\n got %v\nwant %v", g2.Attrs, g.Attrs)
+	}
+	for id, n := range g.Nodes {
+		n2, ok := g2.Nodes[id]
+		if !ok {
+			t.Fatalf("node %s missing after round trip", id)
+		}
+		if !reflect.DeepEqual(n.Attrs, n2.Attrs) {
+			t.Fatalf("node %s attrs differ:\n got %v\nwant %v", id, n2.Attrs, n.Attrs)
+		}
+	}
+	if len(g2.Edges) != len(g.Edges) {
+		t.Fatalf("edges: got %d want %d", len(g2.Edges), len(g.Edges))
+	}
+	for i, e := range g.Edges {
+		e2 := g2.Edges[i]
+		if e.From != e2.From || e.To != e2.To || !reflect.DeepEqual(e.Attrs, e2.Attrs) {
+			t.Fatalf("edge %d differs: got %s->%s %v want %s->%s %v", i, e2.From, e2.To, e2.Attrs, e.From, e.To, e.Attrs)
+		}
+	}
+	if !reflect.DeepEqual(out, RenderDOT(g, RenderOptions{})) {
+		t.Fatal("RenderDOT output is not stable")
+	}
+}
+
+func TestRenderDOT_AnnotateShowsRunAttributes(t *testing.T) {
+	g, _, err := Prepare([]byte(renderTestGraph))
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	out := string(RenderDOT(g, RenderOptions{Annotate: true}))
+	for _, want := range []string{
+		`impl -> exit [condition="outcome=success", label="if outcome=success\nweight=5", weight="5"];`,
+		`label="broken\nif outcome=fail"`,
+		`label="loop_restart"`,
+		`label="impl\nmax_retries=2"`,
+		`impl -> impl [constraint="false", label="retry_target", style="dashed"];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("annotated output missing %s:\n%s", want, out)
+		}
+	}
+	if _, err := dot.Parse([]byte(out)); err != nil {
+		t.Fatalf("annotated DOT does not parse: %v", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -194,6 +195,14 @@ func (h *ConditionalHandler) Execute(ctx context.Context, exec *Execution, node
 	}, nil
 }
 
+// CodergenBackend runs the LLM work for a codergen node. Run receives the
+// fully prepared prompt (also written to {logs_root}/<node_id>/prompt.md) and
+// returns the response text, written to response.md, and optionally an
+// outcome. With a nil outcome the stage must produce a status.json, unless
+// the node sets auto_status=true. A returned error fails the stage and
+// is classified like a provider error: rate limits, timeouts, and 5xx-style
+// messages are retried; everything else is deterministic. Run is called
+// concurrently from parallel branches, and should honor ctx cancellation.
 type CodergenBackend interface {
 	Run(ctx context.Context, exec *Execution, node *model.Node, prompt string) (string, *runtime.Outcome, error)
 }
@@ -320,9 +329,14 @@ func (h *CodergenHandler) Execute(ctx context.Context, exec *Execution, node *mo
 		exec.Engine.cxdbPrompt(ctx, node.ID, promptText)
 	}
 
-	backend := exec.Engine.CodergenBackend
-	if backend == nil {
-		backend = &SimulatedCodergenBackend{}
+	backend, err := exec.Engine.codergenBackendFor(node)
+	if err != nil {
+		return runtime.Outcome{
+			Status:         runtime.StatusFail,
+			FailureReason:  err.Error(),
+			Meta:           map[string]any{"failure_class": failureClassDeterministic},
+			ContextUpdates: map[string]any{"failure_class": failureClassDeterministic},
+		}, nil
 	}
 	resp, out, err := backend.Run(ctx, exec, node, promptText)
 	if err != nil {
@@ -450,6 +464,10 @@ func (h *WaitHumanHandler) Execute(ctx context.Context, exec *Execution, node *m
 		Text:    node.Attr("question", node.Label()),
 		Options: options,
 		Stage:   node.ID,
+		Metadata: map[string]any{
+			"run_id":  exec.Engine.Options.RunID,
+			"context": humanGateContextSummary(exec.Context, exec.Engine.redactor()),
+		},
 	}
 	interviewer := exec.Engine.Interviewer
 	if interviewer == nil {
@@ -501,15 +519,20 @@ func (h *WaitHumanHandler) Execute(ctx context.Context, exec *Execution, node *m
 	// Spec §9.6: emit InterviewCompleted CXDB event.
 	exec.Engine.cxdbInterviewCompleted(ctx, node.ID, ans.Value, interviewDurationMS)
 
+	updates := map[string]any{
+		"human.gate.selected": selected.To,
+		"human.gate.label":    selected.Label,
+	}
+	// Interactive interviewers may attach edited text to a decision.
+	if text := strings.TrimSpace(ans.Text); text != "" {
+		updates["human.gate.text"] = text
+	}
 	return runtime.Outcome{
 		Status:           runtime.StatusSuccess,
 		SuggestedNextIDs: []string{selected.To},
 		PreferredLabel:   selected.Label,
-		ContextUpdates: map[string]any{
-			"human.gate.selected": selected.To,
-			"human.gate.label":    selected.Label,
-		},
-		Notes: "human gate selected",
+		ContextUpdates:   updates,
+		Notes:            "human gate selected",
 	}, nil
 }
 
@@ -521,6 +544,19 @@ func (h *ToolHandler) Execute(ctx context.Context, execCtx *Execution, node *mod
 	if cmdStr == "" {
 		return runtime.Outcome{Status: runtime.StatusFail, FailureReason: "no tool_command specified"}, nil
 	}
+	cmdStr, missing := expandToolCommand(cmdStr, execCtx.Context)
+	if len(missing) > 0 {
+		return runtime.Outcome{
+			Status:         runtime.StatusFail,
+			FailureReason:  fmt.Sprintf("tool_command references unresolved context variable(s): %s", strings.Join(missing, ", ")),
+			Meta:           map[string]any{"failure_class": failureClassDeterministic},
+			ContextUpdates: map[string]any{"failure_class": failureClassDeterministic},
+		}, nil
+	}
+	capture, captureOn, err := toolCaptureFor(node)
+	if err != nil {
+		return runtime.Outcome{Status: runtime.StatusFail, FailureReason: err.Error()}, nil
+	}
 	timeout := parseDuration(node.Attr("timeout", ""), 0)
 	if timeout <= 0 {
 		timeout = 10 * time.Second
@@ -543,23 +579,47 @@ func (h *ToolHandler) Execute(ctx context.Context, execCtx *Execution, node *mod
 		}
 	}
 
+	nodeEnv, _ := nodeEnvVars(node)
+	nodeEnvKeys := make([]string, 0, len(nodeEnv))
+	for k := range nodeEnv {
+		nodeEnvKeys = append(nodeEnvKeys, k)
+	}
+	sort.Strings(nodeEnvKeys)
+	// Use a non-login, non-interactive shell to avoid sourcing user dotfiles.
+	argv, network, networkWarning := toolCommandArgv(cmdStr, toolNoNetwork(execCtx))
+	if networkWarning != "" {
+		warnEngine(execCtx, fmt.Sprintf("node %s: %s", node.ID, networkWarning))
+	}
 	if err := writeJSON(filepath.Join(stageDir, "tool_invocation.json"), map[string]any{
-		"tool": "bash",
-		// Use a non-login, non-interactive shell to avoid sourcing user dotfiles.
-		"argv":        []string{"bash", "-c", cmdStr},
+		"tool":        "bash",
+		"argv":        argv,
 		"command":     cmdStr,
 		"working_dir": execCtx.WorktreeDir,
 		"timeout_ms":  timeout.Milliseconds(),
 		"env_mode":    "base",
+		"network":     network,
+		// Keys only: node env values may be secrets.
+		"node_env_keys": nodeEnvKeys,
 	}); err != nil {
 		warnEngine(execCtx, fmt.Sprintf("write tool_invocation.json: %v", err))
 	}
 
 	cctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-	cmd := exec.CommandContext(cctx, "bash", "-c", cmdStr)
+	cmd := exec.CommandContext(cctx, argv[0], argv[1:]...)
 	cmd.Dir = execCtx.WorktreeDir
-	cmd.Env = buildBaseNodeEnv(execCtx.WorktreeDir)
+	cmd.Env = mergeEnvWithOverrides(buildBaseNodeEnv(execCtx.WorktreeDir), nodeEnv)
+	// Run in its own process group so cancellation (stage timeout, stall
+	// watchdog, parallel join cancellation) kills the whole tree rather than
+	// just bash.
+	setProcessGroupAttr(cmd)
+	if network == toolNetworkNamespace {
+		setNoNetworkAttr(cmd)
+	}
+	cmd.Cancel = func() error {
+		return forceKillPIDTree(cmd.Process.Pid)
+	}
+	cmd.WaitDelay = 3 * time.Second
 	// Avoid hanging on interactive reads; tool_command doesn't provide a way to supply stdin.
 	cmd.Stdin = strings.NewReader("")
 	stdoutPath := filepath.Join(stageDir, "stdout.log")
@@ -583,29 +643,34 @@ func (h *ToolHandler) Execute(ctx context.Context, execCtx *Execution, node *mod
 	exitCode := -1
 	if cmd.ProcessState != nil {
 		exitCode = cmd.ProcessState.ExitCode()
+	} else if runErr != nil && network == toolNetworkNamespace {
+		// Fail closed: never fall back to running with network.
+		runErr = fmt.Errorf("no_network: cannot start tool_command in a network namespace (set %s to use a sandbox wrapper): %w", noNetworkWrapperEnv, runErr)
 	}
-	if cctx.Err() == context.DeadlineExceeded {
-		if err := writeJSON(filepath.Join(stageDir, "tool_timing.json"), map[string]any{
-			"duration_ms": dur.Milliseconds(),
-			"exit_code":   exitCode,
-			"timed_out":   true,
-		}); err != nil {
-			warnEngine(execCtx, fmt.Sprintf("write tool_timing.json: %v", err))
-		}
-		_ = writeDiffPatch(stageDir, execCtx.WorktreeDir)
-		return runtime.Outcome{
-			Status:        runtime.StatusFail,
-			FailureReason: fmt.Sprintf("tool_command timed out after %s", timeout),
-		}, nil
-	}
-
+	timedOut := cctx.Err() == context.DeadlineExceeded
 	if err := writeJSON(filepath.Join(stageDir, "tool_timing.json"), map[string]any{
 		"duration_ms": dur.Milliseconds(),
 		"exit_code":   exitCode,
-		"timed_out":   false,
+		"timed_out":   timedOut,
 	}); err != nil {
 		warnEngine(execCtx, fmt.Sprintf("write tool_timing.json: %v", err))
 	}
+	// The raw result goes into every outcome's Meta for classifiers,
+	// retry_on_exit_codes, and progress events. A timed-out command was
+	// killed, so it carries no exit code.
+	meta := map[string]any{
+		"duration_ms": dur.Milliseconds(),
+		"timed_out":   timedOut,
+	}
+	if timedOut {
+		_ = writeDiffPatch(stageDir, execCtx.WorktreeDir)
+		return runtime.Outcome{
+			Status:        runtime.StatusFail,
+			FailureReason: fmt.Sprintf("tool_command timed out after %s", timeout),
+			Meta:          meta,
+		}, nil
+	}
+	meta["exit_code"] = exitCode
 
 	// Capture diff for debug-by-default. This is stable because we checkpoint after each node.
 	_ = writeDiffPatch(stageDir, execCtx.WorktreeDir)
@@ -620,6 +685,19 @@ func (h *ToolHandler) Execute(ctx context.Context, execCtx *Execution, node *mod
 	}
 	combined := append(append([]byte{}, stdoutBytes...), stderrBytes...)
 	combinedStr := string(combined)
+	updates := map[string]any{
+		"tool.output":    truncate(combinedStr, 8_000),
+		"tool.exit_code": exitCode,
+	}
+	var captureErr error
+	if captureOn {
+		var captured map[string]any
+		if captured, captureErr = capture.contextUpdates(stdoutBytes, stderrBytes); captureErr == nil {
+			for k, v := range captured {
+				updates[k] = v
+			}
+		}
+	}
 	if runErr != nil {
 		if execCtx != nil && execCtx.Engine != nil && execCtx.Engine.CXDB != nil {
 			if _, _, err := execCtx.Engine.CXDB.Append(ctx, "com.kilroy.attractor.ToolResult", 1, map[string]any{
@@ -634,11 +712,10 @@ func (h *ToolHandler) Execute(ctx context.Context, execCtx *Execution, node *mod
 			}
 		}
 		return runtime.Outcome{
-			Status:        runtime.StatusFail,
-			FailureReason: runErr.Error(),
-			ContextUpdates: map[string]any{
-				"tool.output": truncate(combinedStr, 8_000),
-			},
+			Status:         runtime.StatusFail,
+			FailureReason:  runErr.Error(),
+			ContextUpdates: updates,
+			Meta:           meta,
 		}, nil
 	}
 	if execCtx != nil && execCtx.Engine != nil && execCtx.Engine.CXDB != nil {
@@ -653,15 +730,44 @@ func (h *ToolHandler) Execute(ctx context.Context, execCtx *Execution, node *mod
 			execCtx.Engine.Warn(fmt.Sprintf("cxdb append ToolResult failed (node=%s call_id=%s): %v", node.ID, callID, err))
 		}
 	}
+	if captureErr != nil {
+		updates["failure_class"] = failureClassDeterministic
+		meta["failure_class"] = failureClassDeterministic
+		return runtime.Outcome{
+			Status:         runtime.StatusFail,
+			FailureReason:  captureErr.Error(),
+			Meta:           meta,
+			ContextUpdates: updates,
+		}, nil
+	}
 	return runtime.Outcome{
-		Status: runtime.StatusSuccess,
-		ContextUpdates: map[string]any{
-			"tool.output": truncate(combinedStr, 8_000),
-		},
-		Notes: "tool completed",
+		Status:         runtime.StatusSuccess,
+		ContextUpdates: updates,
+		Meta:           meta,
+		Notes:          "tool completed",
 	}, nil
 }
 
+// humanGateContextSummary returns the scalar run context values, with long
+// strings truncated, for interviewers that show or forward context. Values are
+// masked with the same rules as progress events, since webhook interviewers
+// send them off the machine.
+func humanGateContextSummary(ctx *runtime.Context, redactor *progressRedactor) map[string]any {
+	out := map[string]any{}
+	if ctx == nil {
+		return out
+	}
+	for k, v := range ctx.SnapshotValues() {
+		switch tv := v.(type) {
+		case string:
+			out[k] = truncate(tv, 500)
+		case bool, int, int64, float64:
+			out[k] = tv
+		}
+	}
+	return redactor.redactEvent(out)
+}
+
 func truncate(s string, n int) string {
 	if n <= 0 || len(s) <= n {
 		return s
@@ -0,0 +1,230 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/danshapiro/kilroy/internal/attractor/dot"
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/validate"
+)
+
+// expandIncludes inlines nodes carrying include="path/to/sub.dot". Each
+// included file is parsed, has its own includes expanded, and is validated on
+// its own; its nodes are then copied into g under the including node's ID
+// ("<id>.<sub_id>"). The including node becomes a pass-through entry that
+// leads to the sub-graph's first stages, and each sub-graph exit becomes a
+// pass-through carrying the including node's outgoing edges, so edge
+// conditions after the include still see the last inlined stage's outcome.
+// Graph attributes of included files are ignored.
+//
+// Paths are resolved relative to baseDir (the graph file's directory, else
+// the repository root when known); paths in an included file are resolved
+// relative to that file's directory. Problems, including circular includes, are returned as diagnostics.
+func expandIncludes(g *model.Graph, baseDir string) (*model.Graph, []validate.Diagnostic) {
+	return inlineIncludes(g, baseDir, nil)
+}
+
+func inlineIncludes(g *model.Graph, baseDir string, stack []string) (*model.Graph, []validate.Diagnostic) {
+	var includeIDs []string
+	for id, n := range g.Nodes {
+		if n != nil && strings.TrimSpace(n.Attrs["include"]) != "" {
+			includeIDs = append(includeIDs, id)
+		}
+	}
+	if len(includeIDs) == 0 {
+		return g, nil
+	}
+	sort.Slice(includeIDs, func(i, j int) bool { return g.Nodes[includeIDs[i]].Order < g.Nodes[includeIDs[j]].Order })
+
+	var diags []validate.Diagnostic
+	fail := func(rule, nodeID, format string, args ...any) {
+		diags = append(diags, validate.Diagnostic{
+			Rule:     rule,
+			Severity: validate.SeverityError,
+			Message:  fmt.Sprintf(format, args...),
+			NodeID:   nodeID,
+		})
+	}
+	subs := map[string]*model.Graph{}
+	for _, id := range includeIDs {
+		n := g.Nodes[id]
+		rel := strings.TrimSpace(n.Attrs["include"])
+		if isTerminal(n) || findStartNodeID(g) == id {
+			fail("include_valid", id, "include is not allowed on start or exit node %q", id)
+			continue
+		}
+		path := rel
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			abs = path
+		}
+		if i := slices.Index(stack, abs); i >= 0 {
+			chain := append(append([]string{}, stack[i:]...), abs)
+			for k := range chain {
+				chain[k] = filepath.Base(chain[k])
+			}
+			fail("include_cycle", id, "circular include: %s", strings.Join(chain, " -> "))
+			continue
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fail("include_valid", id, "include %q: %v", rel, err)
+			continue
+		}
+		sub, err := dot.Parse(src)
+		if err != nil {
+			fail("include_valid", id, "include %q: %v", rel, err)
+			continue
+		}
+		setSourceFile(sub, rel)
+		sub, subDiags := inlineIncludes(sub, filepath.Dir(abs), append(stack, abs))
+		if len(subDiags) > 0 {
+			for _, d := range subDiags {
+				d.Message = fmt.Sprintf("include %q: %s", rel, d.Message)
+				d.NodeID = id
+				diags = append(diags, d)
+			}
+			continue
+		}
+		valid := true
+		for _, d := range validate.Validate(sub) {
+			if d.Severity == validate.SeverityError {
+				if pos := d.Pos(); pos.IsValid() {
+					fail("include_valid", id, "include %q: %s: %s: %s", rel, pos, d.Rule, d.Message)
+				} else {
+					fail("include_valid", id, "include %q: %s: %s", rel, d.Rule, d.Message)
+				}
+				valid = false
+			}
+		}
+		if valid {
+			subs[id] = sub
+		}
+	}
+	if len(diags) > 0 {
+		return g, diags
+	}
+	return mergeIncludes(g, subs), nil
+}
+
+// mergeIncludes rebuilds g with each included sub-graph inlined in place of
+// its including node, keeping declaration order stable.
+func mergeIncludes(g *model.Graph, subs map[string]*model.Graph) *model.Graph {
+	out := model.NewGraph(g.Name)
+	out.Attrs = g.Attrs
+	add := func(n *model.Node) {
+		n.Order = len(out.Nodes)
+		_ = out.AddNode(n)
+	}
+	passThrough := func(id, label string) *model.Node {
+		n := model.NewNode(id)
+		n.Attrs["shape"] = "diamond"
+		n.Attrs["label"] = label
+		return n
+	}
+
+	// exits maps an including node ID to the IDs its outgoing edges now
+	// leave from.
+	exits := map[string][]string{}
+	for _, n := range nodesInOrder(g) {
+		sub, ok := subs[n.ID]
+		if !ok {
+			add(n)
+			continue
+		}
+		startID := findStartNodeID(sub)
+		mapID := func(id string) string {
+			if id == startID {
+				return n.ID
+			}
+			return n.ID + "." + id
+		}
+		entry := passThrough(n.ID, n.Label())
+		entry.Pos = n.Pos
+		add(entry)
+		for _, sn := range nodesInOrder(sub) {
+			switch {
+			case sn.ID == startID:
+				continue
+			case isTerminal(sn):
+				add(passThrough(mapID(sn.ID), n.Label()+" done"))
+				exits[n.ID] = append(exits[n.ID], mapID(sn.ID))
+			default:
+				cp := model.NewNode(mapID(sn.ID))
+				for k, v := range sn.Attrs {
+					cp.Attrs[k] = v
+				}
+				for _, key := range []string{"retry_target", "fallback_retry_target"} {
+					if t := strings.TrimSpace(cp.Attrs[key]); t != "" && sub.Nodes[t] != nil {
+						cp.Attrs[key] = mapID(t)
+					}
+				}
+				cp.Classes = append([]string{}, sn.Classes...)
+				cp.Pos = sn.Pos
+				add(cp)
+			}
+		}
+		for _, e := range sub.Edges {
+			_ = out.AddEdge(copyEdge(e, mapID(e.From), mapID(e.To)))
+		}
+	}
+	for _, e := range g.Edges {
+		froms, ok := exits[e.From]
+		if !ok {
+			_ = out.AddEdge(copyEdge(e, e.From, e.To))
+			continue
+		}
+		for _, from := range froms {
+			_ = out.AddEdge(copyEdge(e, from, e.To))
+		}
+	}
+	return out
+}
+
+func nodesInOrder(g *model.Graph) []*model.Node {
+	nodes := make([]*model.Node, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		if n != nil {
+			nodes = append(nodes, n)
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].Order != nodes[j].Order {
+			return nodes[i].Order < nodes[j].Order
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+	return nodes
+}
+
+func copyEdge(e *model.Edge, from, to string) *model.Edge {
+	cp := model.NewEdge(from, to)
+	for k, v := range e.Attrs {
+		cp.Attrs[k] = v
+	}
+	cp.Pos = e.Pos
+	return cp
+}
+
+// setSourceFile records file as the source of g's parsed nodes and edges that
+// do not already name one (nested includes name their own file).
+func setSourceFile(g *model.Graph, file string) {
+	for _, n := range g.Nodes {
+		if n != nil && n.Pos.IsValid() && n.Pos.File == "" {
+			n.Pos.File = file
+		}
+	}
+	for _, e := range g.Edges {
+		if e != nil && e.Pos.IsValid() && e.Pos.File == "" {
+			e.Pos.File = file
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestPrepare_IncludePathsResolveAgainstTheIncludingFile(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(dir, "pipelines", "parts"), 0o755)
+	_ = os.WriteFile(filepath.Join(dir, "pipelines", "parts", "build.dot"), []byte(`
+digraph build {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  lint [include="lint.dot"]
+  start -> lint -> exit
+}
+`), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "pipelines", "parts", "lint.dot"), []byte(`
+digraph lint {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  vet [shape=parallelogram, tool_command="go vet ./..."]
+  start -> vet -> exit
+}
+`), 0o644)
+	graph := []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  build [include="parts/build.dot"]
+  start -> build -> exit
+}
+`)
+
+	// The graph file's directory wins over the repository root, and the
+	// nested include is found next to build.dot.
+	g, _, err := PrepareWithOptions(graph, PrepareOptions{RepoPath: dir, GraphPath: filepath.Join(dir, "pipelines", "main.dot")})
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	if g.Nodes["build.lint.vet"] == nil {
+		t.Fatalf("nested include not inlined; nodes=%v", g.AllNodeIDs())
+	}
+
+	// Without a graph file, top-level includes are relative to the repository.
+	if _, _, err := PrepareWithOptions(graph, PrepareOptions{RepoPath: filepath.Join(dir, "pipelines")}); err != nil {
+		t.Fatalf("Prepare without graph path: %v", err)
+	}
+}
+
+func TestPrepare_IncludeInlinesNamespacedSubgraph(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "build.dot"), []byte(`
+digraph build {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  compile [shape=parallelogram, tool_command="echo compile", retry_target=compile]
+  test    [shape=parallelogram, tool_command="echo test"]
+  start -> compile -> test -> exit
+}
+`), 0o644)
+
+	g, _, err := PrepareWithOptions([]byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  compile [shape=parallelogram, tool_command="echo outer"]
+  build [include="build.dot"]
+  start -> compile -> build
+  build -> exit [condition="outcome=success"]
+  build -> compile [condition="outcome=fail"]
+}
+`), PrepareOptions{RepoPath: dir})
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	for _, id := range []string{"compile", "build", "build.compile", "build.test", "build.exit"} {
+		if g.Nodes[id] == nil {
+			t.Fatalf("missing node %q; nodes=%v", id, g.AllNodeIDs())
+		}
+	}
+	if g.Nodes["build.start"] != nil {
+		t.Fatalf("sub-graph start should become the including node")
+	}
+	if got := g.Nodes["build.compile"].Attrs["retry_target"]; got != "build.compile" {
+		t.Fatalf("retry_target not namespaced: %q", got)
+	}
+	if out := g.Outgoing("build"); len(out) != 1 || out[0].To != "build.compile" {
+		t.Fatalf("entry edges: %+v", out)
+	}
+	if out := g.Outgoing("build.exit"); len(out) != 2 {
+		t.Fatalf("exit should carry the including node's edges: %+v", out)
+	}
+	if got := g.Nodes["build.compile"].Pos.String(); got != "build.dot:5:3" {
+		t.Fatalf("inlined node position: got %q want build.dot:5:3", got)
+	}
+	if got := g.Nodes["build"].Pos.String(); got != "6:3" {
+		t.Fatalf("including node position: got %q want 6:3", got)
+	}
+}
+
+func TestPrepare_IncludeCycleIsDiagnostic(t *testing.T) {
+	dir := t.TempDir()
+	sub := func(name, includes string) {
+		_ = os.WriteFile(filepath.Join(dir, name), []byte(`
+digraph S {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  inner [include="`+includes+`"]
+  start -> inner -> exit
+}
+`), 0o644)
+	}
+	sub("a.dot", "b.dot")
+	sub("b.dot", "a.dot")
+
+	_, diags, err := PrepareWithOptions([]byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  a [include="a.dot"]
+  start -> a -> exit
+}
+`), PrepareOptions{RepoPath: dir})
+	if err == nil || !strings.Contains(err.Error(), "include_cycle") {
+		t.Fatalf("expected include_cycle error, got %v", err)
+	}
+	found := false
+	for _, d := range diags {
+		if d.Rule == "include_cycle" && d.NodeID == "a" && strings.Contains(d.Message, "a.dot -> b.dot -> a.dot") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("include_cycle diagnostic missing: %+v", diags)
+	}
+}
+
+func TestRun_IncludedSubgraphExecutes(t *testing.T) {
+	repo := initTestRepo(t)
+	_ = os.WriteFile(filepath.Join(repo, "sub.dot"), []byte(`
+digraph sub {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  write [shape=parallelogram, tool_command="echo inner > inner.txt"]
+  start -> write -> exit
+}
+`), 0o644)
+	runCmd(t, repo, "git", "add", "-A")
+	runCmd(t, repo, "git", "commit", "-m", "add sub")
+
+	dot := []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  inc  [include="sub.dot"]
+  after [shape=parallelogram, tool_command="test -f inner.txt && echo after > after.txt"]
+  start -> inc -> after -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	res, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunID: "include", LogsRoot: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: %s", res.FinalStatus)
+	}
+	if _, err := os.Stat(filepath.Join(res.LogsRoot, "inc.write", "status.json")); err != nil {
+		t.Fatalf("inlined stage did not run: %v", err)
+	}
+}
@@ -233,6 +233,163 @@ func (i *ConsoleInterviewer) readLineWithTimeout(f *os.File, timeout time.Durati
 	}
 }
 
+// DefaultStdinInterviewerTimeout bounds StdinInterviewer questions that do
+// not carry their own timeout.
+const DefaultStdinInterviewerTimeout = 10 * time.Minute
+
+// StdinInterviewer is the human-in-the-loop interviewer behind
+// `attractor run --interactive`. Each question takes one decision from In:
+// "approve", "deny", or "edit" (which then reads replacement text); select
+// questions also accept an option key or target node directly. Approve picks
+// the first option (or YES), deny answers NO or skips the gate, and a question
+// left unanswered past its timeout is denied rather than retried.
+type StdinInterviewer struct {
+	In  *os.File
+	Out *os.File
+	// Timeout applies to questions without TimeoutSeconds; 0 means
+	// DefaultStdinInterviewerTimeout.
+	Timeout time.Duration
+
+	// askMu keeps prompts from concurrent branches from interleaving.
+	askMu   sync.Mutex
+	console ConsoleInterviewer
+}
+
+func (i *StdinInterviewer) Ask(q Question) Answer {
+	i.askMu.Lock()
+	defer i.askMu.Unlock()
+	in, out := i.In, i.Out
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+	timeout := time.Duration(q.TimeoutSeconds * float64(time.Second))
+	if timeout <= 0 {
+		timeout = i.Timeout
+	}
+	if timeout <= 0 {
+		timeout = DefaultStdinInterviewerTimeout
+	}
+
+	_, _ = fmt.Fprintf(out, "\n[%s] %s\n", q.Stage, strings.TrimSpace(q.Text))
+	for _, o := range q.Options {
+		_, _ = fmt.Fprintf(out, "  [%s] %s\n", o.Key, o.Label)
+	}
+	if q.Type == QuestionFreeText {
+		_, _ = fmt.Fprint(out, "text (or deny)> ")
+	} else {
+		_, _ = fmt.Fprint(out, "approve/deny/edit> ")
+	}
+	line, ok := i.console.readLineWithTimeout(in, timeout)
+	if !ok {
+		_, _ = fmt.Fprintf(out, "\n[%s] no answer within %s; denying\n", q.Stage, timeout)
+		return denyAnswer(q)
+	}
+	line = strings.TrimSpace(line)
+
+	if q.Type == QuestionFreeText {
+		if line == "" || strings.EqualFold(line, "deny") {
+			return denyAnswer(q)
+		}
+		return Answer{Text: line}
+	}
+	switch strings.ToLower(line) {
+	case "":
+		return denyAnswer(q)
+	case "edit", "e":
+		_, _ = fmt.Fprint(out, "edit> ")
+		text, ok := i.console.readLineWithTimeout(in, timeout)
+		if !ok {
+			_, _ = fmt.Fprintf(out, "\n[%s] no edit within %s; denying\n", q.Stage, timeout)
+			return denyAnswer(q)
+		}
+		return approveAnswer(q, strings.TrimSpace(text))
+	}
+	if ans, ok := decisionAnswer(q, line, ""); ok {
+		return ans
+	}
+	_, _ = fmt.Fprintf(out, "[%s] unrecognized answer %q; denying\n", q.Stage, line)
+	return denyAnswer(q)
+}
+
+func (i *StdinInterviewer) AskMultiple(questions []Question) []Answer {
+	answers := make([]Answer, len(questions))
+	for idx, q := range questions {
+		answers[idx] = i.Ask(q)
+	}
+	return answers
+}
+
+func (i *StdinInterviewer) Inform(message string, stage string) {
+	out := i.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	_, _ = fmt.Fprintf(out, "\n[%s] %s\n", stage, message)
+}
+
+// decisionAnswer maps an approve/deny decision, or option keys naming the
+// choice directly (comma-separated for multi-select), to an Answer. It reports
+// false when the decision matches nothing.
+func decisionAnswer(q Question, decision string, text string) (Answer, bool) {
+	decision = strings.TrimSpace(decision)
+	switch strings.ToLower(decision) {
+	case "approve", "approved", "a", "y", "yes":
+		return approveAnswer(q, text), true
+	case "deny", "denied", "d", "n", "no":
+		return denyAnswer(q), true
+	}
+	switch q.Type {
+	case QuestionMultiSelect:
+		var vals []string
+		for _, p := range strings.Split(decision, ",") {
+			if v := strings.TrimSpace(p); v != "" {
+				vals = append(vals, v)
+			}
+		}
+		if len(vals) > 0 {
+			return Answer{Values: vals, Text: text}, true
+		}
+	case QuestionSingleSelect:
+		for idx := range q.Options {
+			o := q.Options[idx]
+			if strings.EqualFold(o.Key, decision) || strings.EqualFold(o.To, decision) {
+				return Answer{Value: o.Key, SelectedOption: &o, Text: text}, true
+			}
+		}
+	}
+	return Answer{}, false
+}
+
+// approveAnswer picks the first option (or YES), carrying optional edited text.
+func approveAnswer(q Question, text string) Answer {
+	switch q.Type {
+	case QuestionMultiSelect:
+		vals := make([]string, 0, len(q.Options))
+		for _, o := range q.Options {
+			vals = append(vals, o.Key)
+		}
+		return Answer{Values: vals, Text: text}
+	case QuestionYesNo, QuestionConfirm:
+		return Answer{Value: "YES", Text: text}
+	}
+	if len(q.Options) > 0 {
+		o := q.Options[0]
+		return Answer{Value: o.Key, SelectedOption: &o, Text: text}
+	}
+	return Answer{Value: "YES", Text: text}
+}
+
+// denyAnswer answers NO to yes/no questions and skips everything else.
+func denyAnswer(q Question) Answer {
+	if q.Type == QuestionYesNo || q.Type == QuestionConfirm {
+		return Answer{Value: "NO"}
+	}
+	return Answer{Skipped: true}
+}
+
 type CallbackInterviewer struct {
 	Fn func(Question) Answer
 }
@@ -651,3 +651,85 @@ func TestConsoleInterviewer_TimeoutThenImmediateInput(t *testing.T) {
 		t.Fatalf("second Ask took %v — should have been near-instant from pendingResult", elapsed)
 	}
 }
+
+func newStdinInterviewerForTest(t *testing.T, input string) (*StdinInterviewer, func() string) {
+	t.Helper()
+	rIn, wIn, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = rIn.Close() })
+	go func() {
+		_, _ = wIn.Write([]byte(input))
+		_ = wIn.Close()
+	}()
+	out, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = out.Close() })
+	read := func() string {
+		b, _ := os.ReadFile(out.Name())
+		return string(b)
+	}
+	return &StdinInterviewer{In: rIn, Out: out}, read
+}
+
+func TestStdinInterviewer_Decisions(t *testing.T) {
+	gate := Question{
+		Type:  QuestionSingleSelect,
+		Text:  "ship it?",
+		Stage: "gate",
+		Options: []Option{
+			{Key: "A", Label: "Approve", To: "deploy"},
+			{Key: "R", Label: "Rework", To: "impl"},
+		},
+	}
+	for _, tc := range []struct {
+		name  string
+		input string
+		q     Question
+		want  Answer
+	}{
+		{"approve picks first option", "approve\n", gate, Answer{Value: "A"}},
+		{"option target", "impl\n", gate, Answer{Value: "R"}},
+		{"deny skips gate", "deny\n", gate, Answer{Skipped: true}},
+		{"edit attaches text", "edit\nuse the staging cluster\n", gate, Answer{Value: "A", Text: "use the staging cluster"}},
+		{"confirm deny is NO", "d\n", Question{Type: QuestionConfirm, Text: "ok?", Stage: "s"}, Answer{Value: "NO"}},
+		{"unknown answer denies", "maybe\n", gate, Answer{Skipped: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			i, out := newStdinInterviewerForTest(t, tc.input)
+			ans := i.Ask(tc.q)
+			if ans.Value != tc.want.Value || ans.Text != tc.want.Text || ans.Skipped != tc.want.Skipped || ans.TimedOut {
+				t.Fatalf("answer: got %+v want %+v", ans, tc.want)
+			}
+			if !strings.Contains(out(), "approve/deny/edit> ") {
+				t.Fatalf("missing decision prompt; got:\n%s", out())
+			}
+		})
+	}
+}
+
+func TestStdinInterviewer_TimeoutDenies(t *testing.T) {
+	rIn, wIn, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = rIn.Close(); _ = wIn.Close() }()
+	out, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = out.Close() }()
+
+	i := &StdinInterviewer{In: rIn, Out: out, Timeout: 50 * time.Millisecond}
+	ans := i.Ask(Question{Type: QuestionYesNo, Text: "proceed?", Stage: "s"})
+	if ans.Value != "NO" || ans.TimedOut {
+		t.Fatalf("answer: got %+v want NO", ans)
+	}
+	b, _ := os.ReadFile(out.Name())
+	if !strings.Contains(string(b), "denying") {
+		t.Fatalf("expected timeout note; got:\n%s", b)
+	}
+}
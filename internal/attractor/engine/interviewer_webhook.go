@@ -0,0 +1,278 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultWebhookInterviewerTimeout bounds how long a WebhookInterviewer waits
+// for a decision when neither the config nor the question sets a timeout.
+const DefaultWebhookInterviewerTimeout = 10 * time.Minute
+
+// Actions a WebhookInterviewer takes when no decision arrives in time or the
+// webhook keeps failing. "timeout" reports a timed-out answer so the gate's
+// human.default_choice (or a retry) applies.
+const (
+	WebhookDefaultApprove = "approve"
+	WebhookDefaultDeny    = "deny"
+	WebhookDefaultTimeout = "timeout"
+)
+
+// WebhookInterviewer routes human gate decisions to an external service for
+// CI-driven runs. Each question is POSTed to URL as JSON (decision_id, run_id,
+// node_id, question, options, proposed_action, and a context summary). The
+// service answers with {"decision": "approve"|"deny"|<option key>, "text": ...}
+// or, while a human has not decided yet, with 202 Accepted or
+// {"status": "pending", "poll_url": ...}. Pending decisions are polled with GET
+// on poll_url, or by re-POSTing the same decision_id when none is given.
+// 5xx responses and transport errors are retried with backoff; when the
+// timeout passes or the webhook fails for good, DefaultAction applies.
+type WebhookInterviewer struct {
+	URL           string
+	Headers       map[string]string
+	Timeout       time.Duration
+	PollInterval  time.Duration
+	DefaultAction string
+	MaxRetries    int
+	Client        *http.Client
+}
+
+// NewWebhookInterviewer builds a WebhookInterviewer from run config.
+func NewWebhookInterviewer(cfg WebhookInterviewerConfig) *WebhookInterviewer {
+	w := &WebhookInterviewer{
+		URL:           strings.TrimSpace(cfg.URL),
+		Headers:       map[string]string{},
+		Timeout:       time.Duration(cfg.TimeoutMS) * time.Millisecond,
+		PollInterval:  time.Duration(cfg.PollIntervalMS) * time.Millisecond,
+		DefaultAction: cfg.DefaultAction,
+		MaxRetries:    3,
+	}
+	for k, v := range cfg.Headers {
+		w.Headers[k] = os.ExpandEnv(v)
+	}
+	if cfg.MaxRetries != nil {
+		w.MaxRetries = *cfg.MaxRetries
+	}
+	return w
+}
+
+type webhookOption struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	To    string `json:"to,omitempty"`
+}
+
+type webhookDecisionRequest struct {
+	DecisionID     string          `json:"decision_id"`
+	RunID          string          `json:"run_id,omitempty"`
+	NodeID         string          `json:"node_id"`
+	Question       string          `json:"question"`
+	Type           string          `json:"type"`
+	Options        []webhookOption `json:"options,omitempty"`
+	ProposedAction string          `json:"proposed_action"`
+	DefaultAction  string          `json:"default_action"`
+	Deadline       string          `json:"deadline"`
+	Context        map[string]any  `json:"context,omitempty"`
+}
+
+type webhookDecisionResponse struct {
+	Status   string `json:"status"`
+	Decision string `json:"decision"`
+	Text     string `json:"text"`
+	PollURL  string `json:"poll_url"`
+}
+
+func (w *WebhookInterviewer) Ask(q Question) Answer {
+	timeout := time.Duration(q.TimeoutSeconds * float64(time.Second))
+	if timeout <= 0 {
+		timeout = w.Timeout
+	}
+	if timeout <= 0 {
+		timeout = DefaultWebhookInterviewerTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	req := webhookDecisionRequest{
+		DecisionID:     fmt.Sprintf("%s:%d", q.Stage, time.Now().UnixNano()),
+		NodeID:         q.Stage,
+		Question:       strings.TrimSpace(q.Text),
+		Type:           string(q.Type),
+		ProposedAction: WebhookDefaultApprove,
+		DefaultAction:  w.defaultAction(),
+		Deadline:       deadline.UTC().Format(time.RFC3339),
+	}
+	if runID, ok := q.Metadata["run_id"].(string); ok {
+		req.RunID = runID
+		req.DecisionID = runID + ":" + req.DecisionID
+	}
+	if summary, ok := q.Metadata["context"].(map[string]any); ok {
+		req.Context = summary
+	}
+	for _, o := range q.Options {
+		req.Options = append(req.Options, webhookOption{Key: o.Key, Label: o.Label, To: o.To})
+	}
+	if len(q.Options) > 0 {
+		req.ProposedAction = q.Options[0].Key
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return w.defaultAnswer(q)
+	}
+
+	method, target := http.MethodPost, w.URL
+	for {
+		resp, err := w.send(ctx, method, target, body)
+		if err != nil {
+			return w.defaultAnswer(q)
+		}
+		if resp != nil && resp.Decision != "" && !strings.EqualFold(resp.Status, "pending") {
+			if ans, ok := decisionAnswer(q, resp.Decision, strings.TrimSpace(resp.Text)); ok {
+				return ans
+			}
+			return w.defaultAnswer(q)
+		}
+		if resp != nil && strings.TrimSpace(resp.PollURL) != "" {
+			if u, err := resolveWebhookURL(w.URL, resp.PollURL); err == nil {
+				method, target = http.MethodGet, u
+			}
+		}
+		if !sleepCtx(ctx, w.pollInterval()) {
+			return w.defaultAnswer(q)
+		}
+	}
+}
+
+func (w *WebhookInterviewer) AskMultiple(questions []Question) []Answer {
+	answers := make([]Answer, len(questions))
+	for idx, q := range questions {
+		answers[idx] = w.Ask(q)
+	}
+	return answers
+}
+
+// Inform is a no-op: the webhook only receives questions.
+func (w *WebhookInterviewer) Inform(message string, stage string) {}
+
+// send performs one logical request, retrying transport errors and 5xx
+// responses. A nil response with nil error means the decision is pending.
+func (w *WebhookInterviewer) send(ctx context.Context, method, target string, body []byte) (*webhookDecisionResponse, error) {
+	backoff := BackoffConfig{InitialDelayMS: 500, BackoffFactor: 2.0, MaxDelayMS: 10_000}
+	var lastErr error
+	for attempt := 0; attempt <= w.MaxRetries; attempt++ {
+		if attempt > 0 && !sleepCtx(ctx, DelayForAttempt(attempt, backoff, "")) {
+			break
+		}
+		resp, retryable, err := w.do(ctx, method, target, body)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+		if !retryable {
+			return nil, err
+		}
+	}
+	if lastErr == nil {
+		lastErr = ctx.Err()
+	}
+	return nil, lastErr
+}
+
+func (w *WebhookInterviewer) do(ctx context.Context, method, target string, body []byte) (*webhookDecisionResponse, bool, error) {
+	var rd io.Reader
+	if method != http.MethodGet {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, rd)
+	if err != nil {
+		return nil, false, err
+	}
+	if rd != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	switch {
+	case resp.StatusCode >= 500:
+		return nil, true, fmt.Errorf("webhook %s %s: %s", method, target, resp.Status)
+	case resp.StatusCode >= 300:
+		return nil, false, fmt.Errorf("webhook %s %s: %s", method, target, resp.Status)
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil, false, nil
+	}
+	var out webhookDecisionResponse
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, false, fmt.Errorf("webhook %s %s: decode response: %w", method, target, err)
+	}
+	return &out, false, nil
+}
+
+func (w *WebhookInterviewer) defaultAction() string {
+	if a := strings.ToLower(strings.TrimSpace(w.DefaultAction)); a != "" {
+		return a
+	}
+	return WebhookDefaultDeny
+}
+
+func (w *WebhookInterviewer) defaultAnswer(q Question) Answer {
+	switch w.defaultAction() {
+	case WebhookDefaultApprove:
+		return approveAnswer(q, "")
+	case WebhookDefaultTimeout:
+		return Answer{TimedOut: true}
+	}
+	return denyAnswer(q)
+}
+
+func (w *WebhookInterviewer) pollInterval() time.Duration {
+	if w.PollInterval > 0 {
+		return w.PollInterval
+	}
+	return 5 * time.Second
+}
+
+func resolveWebhookURL(base, ref string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	r, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(r).String(), nil
+}
+
+// sleepCtx waits for d and reports false if ctx ended first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
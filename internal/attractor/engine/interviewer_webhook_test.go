@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func webhookGateQuestion() Question {
+	return Question{
+		Type:  QuestionSingleSelect,
+		Text:  "Ship it?",
+		Stage: "review",
+		Options: []Option{
+			{Key: "A", Label: "[A] Approve", To: "ship"},
+			{Key: "F", Label: "[F] Fix", To: "fix"},
+		},
+		Metadata: map[string]any{
+			"run_id":  "run-1",
+			"context": map[string]any{"graph.goal": "demo"},
+		},
+	}
+}
+
+func TestWebhookInterviewer_PostsQuestionAndUsesDecision(t *testing.T) {
+	var got webhookDecisionRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("unexpected request: %s auth=%q", r.Method, r.Header.Get("Authorization"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"decision":"F","text":"needs tests"}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("KILROY_TEST_WEBHOOK_TOKEN", "secret")
+	wi := NewWebhookInterviewer(WebhookInterviewerConfig{
+		URL:     srv.URL,
+		Headers: map[string]string{"Authorization": "Bearer $KILROY_TEST_WEBHOOK_TOKEN"},
+	})
+	ans := wi.Ask(webhookGateQuestion())
+	if ans.SelectedOption == nil || ans.SelectedOption.To != "fix" || ans.Text != "needs tests" {
+		t.Fatalf("answer: %+v", ans)
+	}
+	if got.NodeID != "review" || got.RunID != "run-1" || got.ProposedAction != "A" || len(got.Options) != 2 ||
+		got.Context["graph.goal"] != "demo" || got.DefaultAction != WebhookDefaultDeny {
+		t.Fatalf("request payload: %+v", got)
+	}
+}
+
+func TestWebhookInterviewer_Retries5xxAndPollsPendingDecision(t *testing.T) {
+	var posts, polls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hook":
+			if posts.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"status":"pending","poll_url":"/decisions/1"}`))
+		case "/decisions/1":
+			if polls.Add(1) < 2 {
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			_, _ = w.Write([]byte(`{"decision":"approve"}`))
+		}
+	}))
+	defer srv.Close()
+
+	wi := &WebhookInterviewer{URL: srv.URL + "/hook", PollInterval: 10 * time.Millisecond, MaxRetries: 2, Timeout: 30 * time.Second}
+	ans := wi.Ask(webhookGateQuestion())
+	if ans.Value != "A" || ans.SelectedOption == nil || ans.SelectedOption.To != "ship" {
+		t.Fatalf("answer: %+v", ans)
+	}
+	if posts.Load() != 2 || polls.Load() != 2 {
+		t.Fatalf("posts=%d polls=%d, want 2 and 2", posts.Load(), polls.Load())
+	}
+}
+
+func TestWebhookInterviewer_TimeoutAndFailureUseDefaultAction(t *testing.T) {
+	pending := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer pending.Close()
+
+	wi := &WebhookInterviewer{URL: pending.URL, Timeout: 100 * time.Millisecond, PollInterval: 10 * time.Millisecond, DefaultAction: WebhookDefaultApprove}
+	if ans := wi.Ask(webhookGateQuestion()); ans.Value != "A" {
+		t.Fatalf("approve default: %+v", ans)
+	}
+	wi.DefaultAction = WebhookDefaultTimeout
+	if ans := wi.Ask(webhookGateQuestion()); !ans.TimedOut {
+		t.Fatalf("timeout default: %+v", ans)
+	}
+
+	var calls atomic.Int32
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer broken.Close()
+	wi = &WebhookInterviewer{URL: broken.URL, MaxRetries: 3}
+	if ans := wi.Ask(webhookGateQuestion()); !ans.Skipped {
+		t.Fatalf("deny default: %+v", ans)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("4xx should not be retried; calls=%d", calls.Load())
+	}
+}
+
+func TestHumanGateContextSummary_MasksSecrets(t *testing.T) {
+	ctx := runtime.NewContext()
+	ctx.Set("graph.goal", "demo")
+	ctx.Set("deploy.api_token", "tok-1234567890")
+	ctx.Set("last_output", "exported GITHUB_TOKEN=ghp_abcdefghij and moved on")
+	ctx.Set("attempts", 2)
+
+	got := humanGateContextSummary(ctx, newProgressRedactor(nil))
+	if got["graph.goal"] != "demo" || got["attempts"] != 2 {
+		t.Fatalf("plain values changed: %v", got)
+	}
+	if got["deploy.api_token"] != redactedValue {
+		t.Fatalf("deploy.api_token = %v, want masked", got["deploy.api_token"])
+	}
+	if s, _ := got["last_output"].(string); strings.Contains(s, "ghp_abcdefghij") {
+		t.Fatalf("last_output leaks the token: %q", s)
+	}
+}
@@ -17,6 +17,7 @@ const (
 	failureClassCompilationLoop      = "compilation_loop"
 	failureClassStructural           = "structural"
 	defaultLoopRestartSignatureLimit = 3
+	defaultMaxLoopRestarts           = 50
 	// 0 disables visit-count cycle breaking unless max_node_visits is explicitly set.
 	defaultMaxNodeVisits = 0
 )
@@ -204,6 +205,44 @@ func loopRestartSignatureLimit(g *model.Graph) int {
 	return limit
 }
 
+// deterministicCycleThreshold is how many times the deterministic failure
+// cycle breaker tolerates the same failure signature before aborting. The
+// graph's deterministic_cycle_threshold wins; otherwise the breaker shares
+// loop_restart_signature_limit with the loop_restart circuit breaker.
+func deterministicCycleThreshold(g *model.Graph) int {
+	if g != nil {
+		if n := parseInt(g.Attrs["deterministic_cycle_threshold"], 0); n >= 1 {
+			return n
+		}
+	}
+	return loopRestartSignatureLimit(g)
+}
+
+// failureCyclePath renders the nodes completed since index from (the previous
+// failure with the same signature) through the current node, which is the
+// last entry of completed.
+func failureCyclePath(completed []string, from int) string {
+	if from < 0 || from >= len(completed) {
+		from = len(completed) - 1
+	}
+	if from < 0 {
+		return ""
+	}
+	return strings.Join(completed[from:], " -> ")
+}
+
+// maxLoopRestarts bounds loop_restart iterations. The graph-level max_loops
+// attribute wins; max_restarts is the older name for the same limit.
+func maxLoopRestarts(g *model.Graph) int {
+	if g == nil {
+		return defaultMaxLoopRestarts
+	}
+	if raw := strings.TrimSpace(g.Attrs["max_loops"]); raw != "" {
+		return parseInt(raw, defaultMaxLoopRestarts)
+	}
+	return parseInt(g.Attrs["max_restarts"], defaultMaxLoopRestarts)
+}
+
 func maxNodeVisits(g *model.Graph) int {
 	if g == nil {
 		return defaultMaxNodeVisits
@@ -215,6 +254,19 @@ func maxNodeVisits(g *model.Graph) int {
 	return limit
 }
 
+// maxTotalSteps caps node executions across the whole run (graph attribute
+// max_total_steps). 0, the default, means no cap.
+func maxTotalSteps(g *model.Graph) int {
+	if g == nil {
+		return 0
+	}
+	limit := parseInt(g.Attrs["max_total_steps"], 0)
+	if limit < 1 {
+		return 0
+	}
+	return limit
+}
+
 func restartFailureSignature(nodeID string, out runtime.Outcome, failureClass string) string {
 	if !isFailureLoopRestartOutcome(out) {
 		return ""
@@ -202,6 +202,21 @@ digraph G {
 	if !foundLoopRestart {
 		t.Fatalf("expected loop_restart event in base progress log")
 	}
+	// The main loop reports restarts with the same stage_loop_restart event
+	// that subgraph restarts emit.
+	foundStageLoopRestart := false
+	for _, ev := range baseProgress {
+		if strings.TrimSpace(fmt.Sprint(ev["event"])) != "stage_loop_restart" {
+			continue
+		}
+		foundStageLoopRestart = true
+		if ev["node_id"] != "check" || ev["target_node"] != "work" || progressIntValue(ev["loop_count"]) != 1 || progressIntValue(ev["max_loops"]) != 3 {
+			t.Fatalf("unexpected stage_loop_restart event: %#v", ev)
+		}
+	}
+	if !foundStageLoopRestart {
+		t.Fatalf("expected stage_loop_restart event in base progress log")
+	}
 
 	restartProgress := readProgressEvents(t, filepath.Join(logsRoot, "restart-1", "progress.ndjson"))
 	retryCount := -1
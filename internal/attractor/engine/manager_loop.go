@@ -208,7 +208,8 @@ func runChildPipeline(ctx context.Context, exec *Execution, childDotfile string,
 		repoPath = exec.Engine.Options.RepoPath
 	}
 	childGraph, _, err := PrepareWithOptions(dotSource, PrepareOptions{
-		RepoPath: repoPath,
+		RepoPath:  repoPath,
+		GraphPath: dotPath,
 	})
 	if err != nil {
 		return childResult{
@@ -245,6 +246,10 @@ func runChildPipeline(ctx context.Context, exec *Execution, childDotfile string,
 		ModelCatalogSHA:    exec.Engine.ModelCatalogSHA,
 		ModelCatalogSource: exec.Engine.ModelCatalogSource,
 		ModelCatalogPath:   exec.Engine.ModelCatalogPath,
+		nodeLocks:          exec.Engine.sharedNodeLocks(),
+		gitLock:            exec.Engine.sharedGitLock(),
+		totalSteps:         exec.Engine.sharedSteps(),
+		metrics:            exec.Engine.sharedMetrics(),
 	}
 
 	res, err := runSubgraphUntil(ctx, childEng, startID, exitID)
@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runstate"
+)
+
+func TestRunWithConfig_ManifestRecordsGraphIdentity(t *testing.T) {
+	cxdbSrv := newCXDBTestServer(t)
+	cfg := &RunConfigFile{}
+	cfg.Version = 1
+	cfg.Repo.Path = initTestRepo(t)
+	cfg.CXDB.BinaryAddr = cxdbSrv.BinaryAddr()
+	cfg.CXDB.HTTPBaseURL = cxdbSrv.URL()
+	cfg.ModelDB.OpenRouterModelInfoPath = writePinnedCatalog(t)
+	cfg.ModelDB.OpenRouterModelInfoUpdatePolicy = "pinned"
+
+	logsRoot := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	opts := RunOptions{RunID: "manifest-identity", LogsRoot: logsRoot, GraphPath: "/graphs/pipeline.dot"}
+	if _, err := RunWithConfig(ctx, catalogPinningDot, cfg, opts); err != nil {
+		t.Fatalf("RunWithConfig: %v", err)
+	}
+
+	m, err := runstate.LoadManifest(logsRoot)
+	if err != nil {
+		t.Fatalf("LoadManifest: %v", err)
+	}
+	sum := sha256.Sum256(catalogPinningDot)
+	if m.RunID != "manifest-identity" || m.GraphName != "G" || m.GraphPath != "/graphs/pipeline.dot" {
+		t.Fatalf("manifest identity: %+v", m)
+	}
+	if m.GraphSHA256 != hex.EncodeToString(sum[:]) {
+		t.Fatalf("graph_sha256=%q want sha256 of the DOT source", m.GraphSHA256)
+	}
+	if m.RunConfigSHA256 != runConfigSHA256(cfg) || m.RunConfigSHA256 == "" {
+		t.Fatalf("run_config_sha256=%q want %q", m.RunConfigSHA256, runConfigSHA256(cfg))
+	}
+	if m.NodeCount != 2 || m.Started().IsZero() || m.ModelDB.SHA256 == "" {
+		t.Fatalf("manifest: node_count=%d started_at=%q modeldb=%+v", m.NodeCount, m.StartedAt, m.ModelDB)
+	}
+}
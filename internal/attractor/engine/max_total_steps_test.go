@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestRun_MaxTotalStepsAbortsEndlessLoop(t *testing.T) {
+	repo := initTestRepo(t)
+	logsRoot := t.TempDir()
+	dot := []byte(`
+digraph G {
+  graph [max_total_steps=5]
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  a [shape=parallelogram, tool_command="echo a"]
+  b [shape=parallelogram, tool_command="echo b"]
+  start -> a -> b
+  b -> a [condition="outcome=success"]
+  b -> exit [condition="outcome=fail"]
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunID: "maxsteps", LogsRoot: logsRoot})
+	if err == nil || !strings.Contains(err.Error(), "max_total_steps=5") {
+		t.Fatalf("expected max_total_steps error, got %v", err)
+	}
+
+	var exceeded map[string]any
+	lastStep := 0.0
+	for _, ev := range readProgressEvents(t, filepath.Join(logsRoot, "progress.ndjson")) {
+		switch ev["event"] {
+		case "stage_attempt_start":
+			lastStep, _ = ev["step"].(float64)
+		case "max_total_steps_exceeded":
+			exceeded = ev
+		}
+	}
+	if lastStep != 5 {
+		t.Fatalf("last stage_attempt_start step=%v want 5", lastStep)
+	}
+	if exceeded == nil {
+		t.Fatal("expected max_total_steps_exceeded event")
+	}
+	if exceeded["node_id"] != "a" || exceeded["step_count"] != float64(5) || exceeded["step_limit"] != float64(5) {
+		t.Fatalf("max_total_steps_exceeded event: %v", exceeded)
+	}
+
+	cp, err := runtime.LoadCheckpoint(filepath.Join(logsRoot, "checkpoint.json"))
+	if err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	if got, _ := anyToNonNegativeInt(cp.Extra["total_steps"]); got != 5 {
+		t.Fatalf("checkpoint total_steps=%v want 5", cp.Extra["total_steps"])
+	}
+}
+
+func TestMaxTotalSteps_DefaultsToUnlimited(t *testing.T) {
+	for raw, want := range map[string]int{"": 0, "0": 0, "-3": 0, "abc": 0, "12": 12} {
+		g := model.NewGraph("G")
+		g.Attrs["max_total_steps"] = raw
+		if got := maxTotalSteps(g); got != want {
+			t.Fatalf("max_total_steps=%q: got %d want %d", raw, got, want)
+		}
+	}
+}
+
+func TestRun_MaxTotalStepsCountsParallelBranchSteps(t *testing.T) {
+	repo := initTestRepo(t)
+	dot := []byte(`
+digraph G {
+  graph [max_total_steps=6]
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  par   [shape=component]
+  a1 [shape=parallelogram, tool_command="echo a1"]
+  a2 [shape=parallelogram, tool_command="echo a2"]
+  b1 [shape=parallelogram, tool_command="echo b1"]
+  b2 [shape=parallelogram, tool_command="echo b2"]
+  join [shape=tripleoctagon]
+  start -> par
+  par -> a1 -> a2 -> join
+  par -> b1 -> b2 -> join
+  join -> exit
+}
+`)
+	// start and par take 2 steps and the branches 4 more, so join would be
+	// the 7th execution. Counted per branch, each branch only reached 4.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunID: "maxsteps-par", LogsRoot: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "max_total_steps=6") || !strings.Contains(err.Error(), `"join"`) {
+		t.Fatalf("expected max_total_steps error before join, got %v", err)
+	}
+}
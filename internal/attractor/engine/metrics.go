@@ -0,0 +1,194 @@
+package engine
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+// NodeMetrics summarizes one node's execution in metrics.json. Wall time is
+// the sum of its attempts; token counts are zero for nodes that made no LLM
+// calls or whose backend does not report usage.
+type NodeMetrics struct {
+	NodeID       string `json:"node_id"`
+	Attempts     int    `json:"attempts"`
+	Retries      int    `json:"retries,omitempty"`
+	WallTimeMS   int64  `json:"wall_time_ms"`
+	Status       string `json:"status,omitempty"`
+	InputTokens  int64  `json:"input_tokens,omitempty"`
+	OutputTokens int64  `json:"output_tokens,omitempty"`
+	TotalTokens  int64  `json:"total_tokens,omitempty"`
+}
+
+// RunMetrics is the contents of {logs_root}/metrics.json. It is rewritten
+// after every attempt, so an interrupted run still has data for the nodes that
+// finished; FinalStatus is set once the run reaches a terminal outcome.
+type RunMetrics struct {
+	RunID       string        `json:"run_id"`
+	UpdatedAt   string        `json:"updated_at"`
+	FinalStatus string        `json:"final_status,omitempty"`
+	Nodes       []NodeMetrics `json:"nodes"`
+}
+
+// runMetrics accumulates NodeMetrics from stage_attempt_start/end progress
+// events and token usage reported by codergen backends. Parallel branch and
+// manager_loop child engines share their parent's accumulator, so their nodes
+// count toward the run; owner is the top-level engine, whose logs root holds
+// metrics.json.
+type runMetrics struct {
+	mu          sync.Mutex
+	order       []string
+	nodes       map[string]*NodeMetrics
+	started     map[string]time.Time
+	finalStatus string
+
+	owner   *Engine
+	writeMu sync.Mutex
+}
+
+func (m *runMetrics) node(id string) *NodeMetrics {
+	if m.nodes == nil {
+		m.nodes = map[string]*NodeMetrics{}
+		m.started = map[string]time.Time{}
+	}
+	n, ok := m.nodes[id]
+	if !ok {
+		n = &NodeMetrics{NodeID: id}
+		m.nodes[id] = n
+		m.order = append(m.order, id)
+	}
+	return n
+}
+
+// observe updates metrics from a progress event and reports whether anything
+// worth persisting changed.
+func (m *runMetrics) observe(ev map[string]any, at time.Time) bool {
+	id := strings.TrimSpace(eventFieldString(ev, "node_id"))
+	if id == "" {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch eventFieldString(ev, "event") {
+	case "stage_attempt_start":
+		n := m.node(id)
+		n.Attempts++
+		if attempt, ok := ev["attempt"].(int); ok && attempt > 1 {
+			n.Retries++
+		}
+		m.started[id] = at
+		return false
+	case "stage_attempt_end":
+		n := m.node(id)
+		if start, ok := m.started[id]; ok {
+			n.WallTimeMS += at.Sub(start).Milliseconds()
+			delete(m.started, id)
+		}
+		n.Status = eventFieldString(ev, "status")
+		return true
+	}
+	return false
+}
+
+func (m *runMetrics) addTokens(nodeID string, input, output, total int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := m.node(nodeID)
+	n.InputTokens += input
+	n.OutputTokens += output
+	if total <= 0 {
+		total = input + output
+	}
+	n.TotalTokens += total
+}
+
+func (m *runMetrics) snapshot(runID string) RunMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := RunMetrics{
+		RunID:       runID,
+		UpdatedAt:   time.Now().UTC().Format(time.RFC3339Nano),
+		FinalStatus: m.finalStatus,
+		Nodes:       make([]NodeMetrics, 0, len(m.order)),
+	}
+	for _, id := range m.order {
+		out.Nodes = append(out.Nodes, *m.nodes[id])
+	}
+	return out
+}
+
+// restore seeds the accumulator from a metrics.json written by an earlier
+// process, so a resumed run keeps counting where it left off.
+func (m *runMetrics) restore(prev RunMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, n := range prev.Nodes {
+		if strings.TrimSpace(n.NodeID) == "" {
+			continue
+		}
+		cp := n
+		m.node(n.NodeID)
+		m.nodes[n.NodeID] = &cp
+	}
+}
+
+// summarize adds the run-wide totals of the per-node metrics to final.
+func (m *runMetrics) summarize(final *runtime.FinalOutcome) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range m.order {
+		n := m.nodes[id]
+		final.Attempts += n.Attempts
+		final.Retries += n.Retries
+		final.InputTokens += n.InputTokens
+		final.OutputTokens += n.OutputTokens
+		final.TotalTokens += n.TotalTokens
+	}
+}
+
+// recordNodeTokens adds LLM token usage to a node's metrics.
+func (e *Engine) recordNodeTokens(nodeID string, input, output, total int64) {
+	if e == nil || (input == 0 && output == 0 && total == 0) {
+		return
+	}
+	e.sharedMetrics().addTokens(nodeID, input, output, total)
+	e.writeMetrics()
+}
+
+// finishMetrics records the terminal status and writes the final metrics.json.
+func (e *Engine) finishMetrics(status string) {
+	if e == nil {
+		return
+	}
+	m := e.sharedMetrics()
+	m.mu.Lock()
+	m.finalStatus = status
+	m.mu.Unlock()
+	e.writeMetrics()
+}
+
+// writeMetrics persists metrics.json next to the owning engine's
+// progress.ndjson, and also under its original logs root after a loop_restart
+// switched LogsRoot.
+func (e *Engine) writeMetrics() {
+	m := e.sharedMetrics()
+	owner := m.owner
+	if owner == nil {
+		owner = e
+	}
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	snap := m.snapshot(owner.Options.RunID)
+	seen := map[string]bool{}
+	for _, root := range []string{owner.LogsRoot, owner.baseLogsRoot} {
+		root = strings.TrimSpace(root)
+		if root == "" || seen[root] {
+			continue
+		}
+		seen[root] = true
+		_ = writeJSON(filepath.Join(root, "metrics.json"), snap)
+	}
+}
@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func readRunMetrics(t *testing.T, path string) RunMetrics {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	var m RunMetrics
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("decode %s: %v", path, err)
+	}
+	return m
+}
+
+func TestRun_WritesMetricsJSONWithAttemptsAndStatus(t *testing.T) {
+	repo := t.TempDir()
+	runCmd(t, repo, "git", "init")
+	runCmd(t, repo, "git", "config", "user.name", "tester")
+	runCmd(t, repo, "git", "config", "user.email", "tester@example.com")
+	_ = os.WriteFile(filepath.Join(repo, "README.md"), []byte("hello\n"), 0o644)
+	runCmd(t, repo, "git", "add", "-A")
+	runCmd(t, repo, "git", "commit", "-m", "init")
+
+	marker := filepath.Join(t.TempDir(), "flaky")
+	dot := []byte(`
+digraph G {
+  graph [goal="metrics", default_max_retry=2]
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  slow  [shape=parallelogram, tool_command="sleep 0.2"]
+  flaky [shape=parallelogram, tool_command="test -f ` + marker + ` || { touch ` + marker + `; exit 1; }"]
+  start -> slow -> flaky -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	res, err := Run(ctx, dot, RunOptions{RepoPath: repo})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: got %q want %q", res.FinalStatus, runtime.FinalSuccess)
+	}
+
+	m := readRunMetrics(t, filepath.Join(res.LogsRoot, "metrics.json"))
+	if m.FinalStatus != string(runtime.FinalSuccess) || m.RunID != res.RunID {
+		t.Fatalf("metrics header: %+v", m)
+	}
+	byID := map[string]NodeMetrics{}
+	for _, n := range m.Nodes {
+		byID[n.NodeID] = n
+	}
+	if n := byID["slow"]; n.Attempts != 1 || n.Status != string(runtime.StatusSuccess) || n.WallTimeMS < 150 {
+		t.Fatalf("slow metrics: %+v", n)
+	}
+	if n := byID["flaky"]; n.Attempts != 2 || n.Retries != 1 || n.Status != string(runtime.StatusSuccess) {
+		t.Fatalf("flaky metrics: %+v", n)
+	}
+
+	final := mustReadFinalOutcome(t, filepath.Join(res.LogsRoot, "final.json"))
+	if got := strings.Join(final.CompletedNodes, ","); got != "start,slow,flaky,exit" {
+		t.Fatalf("final completed_nodes: %q", got)
+	}
+	attempts := 0
+	for _, n := range m.Nodes {
+		attempts += n.Attempts
+	}
+	if final.Attempts != attempts || final.Retries != 1 {
+		t.Fatalf("final attempts/retries: %d/%d want %d/1", final.Attempts, final.Retries, attempts)
+	}
+	if final.DurationMS < 150 {
+		t.Fatalf("final duration_ms: %d", final.DurationMS)
+	}
+}
+
+func TestRun_MetricsIncludeParallelBranchNodes(t *testing.T) {
+	repo := initTestRepo(t)
+	dot := []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  par   [shape=component]
+  a [shape=parallelogram, tool_command="echo a"]
+  b [shape=parallelogram, tool_command="echo b"]
+  join [shape=tripleoctagon]
+  start -> par
+  par -> a -> join
+  par -> b -> join
+  join -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	res, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunID: "metrics-par", LogsRoot: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	// Branch engines share the parent's accumulator, so their nodes land in
+	// the run-level metrics.json rather than only in per-branch logs.
+	m := readRunMetrics(t, filepath.Join(res.LogsRoot, "metrics.json"))
+	byID := map[string]NodeMetrics{}
+	attempts := 0
+	for _, n := range m.Nodes {
+		byID[n.NodeID] = n
+		attempts += n.Attempts
+	}
+	for _, id := range []string{"a", "b"} {
+		if n := byID[id]; n.Attempts != 1 || n.Status != string(runtime.StatusSuccess) {
+			t.Fatalf("%s metrics: %+v (all: %+v)", id, n, m.Nodes)
+		}
+	}
+	final := mustReadFinalOutcome(t, filepath.Join(res.LogsRoot, "final.json"))
+	if final.Attempts != attempts {
+		t.Fatalf("final attempts: got %d want %d", final.Attempts, attempts)
+	}
+}
+
+func TestRunMetrics_SummarizeTotalsAndRestore(t *testing.T) {
+	var prev runMetrics
+	prev.observe(map[string]any{"event": "stage_attempt_start", "node_id": "a", "attempt": 1}, time.Now())
+	prev.observe(map[string]any{"event": "stage_attempt_start", "node_id": "a", "attempt": 2}, time.Now())
+	prev.addTokens("a", 100, 20, 0)
+
+	// A resumed run starts from the earlier process's metrics.json.
+	var m runMetrics
+	m.restore(prev.snapshot("r1"))
+	m.observe(map[string]any{"event": "stage_attempt_start", "node_id": "b", "attempt": 1}, time.Now())
+	m.addTokens("b", 10, 5, 15)
+
+	var final runtime.FinalOutcome
+	m.summarize(&final)
+	if final.Attempts != 3 || final.Retries != 1 {
+		t.Fatalf("attempts/retries: %d/%d want 3/1", final.Attempts, final.Retries)
+	}
+	if final.InputTokens != 110 || final.OutputTokens != 25 || final.TotalTokens != 135 {
+		t.Fatalf("tokens: %d/%d/%d", final.InputTokens, final.OutputTokens, final.TotalTokens)
+	}
+}
+
+func TestParseCLIOutputStream_CountsEachMessageUsageOnce(t *testing.T) {
+	logs := t.TempDir()
+	eng := &Engine{LogsRoot: logs, Options: RunOptions{RunID: "r1"}}
+	// One message split across events (text, then tool_use) repeats its usage;
+	// the last event may report more output tokens than the first.
+	stream := strings.Join([]string{
+		`{"type":"assistant","message":{"id":"msg_1","role":"assistant","content":[{"type":"text","text":"reading"}],"usage":{"input_tokens":100,"output_tokens":10}}}`,
+		`{"type":"assistant","message":{"id":"msg_1","role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Read","input":{}}],"usage":{"input_tokens":100,"output_tokens":12}}}`,
+		`{"type":"user","message":{"role":"user","content":[]}}`,
+		`{"type":"assistant","message":{"id":"msg_2","role":"assistant","content":[{"type":"text","text":"done"}],"usage":{"input_tokens":50,"output_tokens":5}}}`,
+	}, "\n")
+	parseCLIOutputStream(context.Background(), eng, "impl", strings.NewReader(stream))
+
+	m := readRunMetrics(t, filepath.Join(logs, "metrics.json"))
+	if len(m.Nodes) != 1 {
+		t.Fatalf("nodes: %+v", m.Nodes)
+	}
+	if n := m.Nodes[0]; n.InputTokens != 150 || n.OutputTokens != 17 || n.TotalTokens != 167 {
+		t.Fatalf("impl metrics: %+v", n)
+	}
+}
+
+func TestParseCLIOutputStream_RecordsTokenUsageInMetrics(t *testing.T) {
+	logs := t.TempDir()
+	eng := &Engine{LogsRoot: logs, Options: RunOptions{RunID: "r1"}}
+	stream := strings.Join([]string{
+		`{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"hi"}],"usage":{"input_tokens":100,"output_tokens":20}}}`,
+		`{"type":"user","message":{"role":"user","content":[]}}`,
+		`{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"done"}],"usage":{"input_tokens":50,"output_tokens":5}}}`,
+	}, "\n")
+	parseCLIOutputStream(context.Background(), eng, "impl", strings.NewReader(stream))
+
+	m := readRunMetrics(t, filepath.Join(logs, "metrics.json"))
+	if len(m.Nodes) != 1 {
+		t.Fatalf("nodes: %+v", m.Nodes)
+	}
+	if n := m.Nodes[0]; n.NodeID != "impl" || n.InputTokens != 150 || n.OutputTokens != 25 || n.TotalTokens != 175 {
+		t.Fatalf("impl metrics: %+v", n)
+	}
+}
@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danshapiro/kilroy/internal/agent"
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+const (
+	// nodeArtifactsMaxTotalBytes caps how much a single node may copy into
+	// {logs_root}/artifacts/<node_id>/.
+	nodeArtifactsMaxTotalBytes = 50 << 20
+	// nodeArtifactsMaxBinaryBytes is the largest binary file that is copied;
+	// text files are limited only by the total cap.
+	nodeArtifactsMaxBinaryBytes = 1 << 20
+)
+
+// nodeArtifactPatterns parses the comma-separated artifacts attribute into
+// glob patterns relative to the worktree.
+func nodeArtifactPatterns(node *model.Node) []string {
+	if node == nil {
+		return nil
+	}
+	var patterns []string
+	for _, p := range strings.Split(node.Attr("artifacts", ""), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// worktreeRelEscapes reports whether a path relative to the worktree points
+// outside it, as a glob like "../x" or an absolute pattern would produce.
+func worktreeRelEscapes(rel string) bool {
+	return rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel)
+}
+
+// captureNodeArtifacts copies worktree files matching the node's artifacts
+// globs into {logs_root}/artifacts/<node_id>/, preserving their relative
+// paths. It runs only for successful outcomes and never fails the run:
+// problems (bad patterns, oversized files, the total cap) become warnings.
+func (e *Engine) captureNodeArtifacts(node *model.Node, out runtime.Outcome) {
+	patterns := nodeArtifactPatterns(node)
+	if len(patterns) == 0 || strings.TrimSpace(e.WorktreeDir) == "" {
+		return
+	}
+	if out.Status != runtime.StatusSuccess && out.Status != runtime.StatusPartialSuccess {
+		return
+	}
+
+	env := agent.NewLocalExecutionEnvironment(e.WorktreeDir)
+	seen := map[string]bool{}
+	var rels []string
+	for _, pattern := range patterns {
+		matches, _, err := env.Glob(pattern, "", 0)
+		if err != nil {
+			e.Warn(fmt.Sprintf("artifacts: node %s: pattern %q: %v", node.ID, pattern, err))
+			continue
+		}
+		for _, m := range matches {
+			rel, err := filepath.Rel(e.WorktreeDir, m)
+			if err != nil || worktreeRelEscapes(rel) || rel == ".git" || strings.HasPrefix(rel, ".git"+string(filepath.Separator)) || seen[rel] {
+				continue
+			}
+			seen[rel] = true
+			rels = append(rels, rel)
+		}
+	}
+	sort.Strings(rels)
+
+	destRoot := filepath.Join(e.LogsRoot, "artifacts", node.ID)
+	var copied, skipped []string
+	var total int64
+	for _, rel := range rels {
+		src := filepath.Join(e.WorktreeDir, rel)
+		fi, err := os.Stat(src)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		if total+fi.Size() > nodeArtifactsMaxTotalBytes {
+			e.Warn(fmt.Sprintf("artifacts: node %s: skipping %s (%d bytes): total size cap of %d bytes reached", node.ID, rel, fi.Size(), nodeArtifactsMaxTotalBytes))
+			skipped = append(skipped, rel)
+			continue
+		}
+		if fi.Size() > nodeArtifactsMaxBinaryBytes && isBinaryFile(src) {
+			e.Warn(fmt.Sprintf("artifacts: node %s: skipping binary %s (%d bytes > %d)", node.ID, rel, fi.Size(), nodeArtifactsMaxBinaryBytes))
+			skipped = append(skipped, rel)
+			continue
+		}
+		dst := filepath.Join(destRoot, rel)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			e.Warn(fmt.Sprintf("artifacts: node %s: %v", node.ID, err))
+			skipped = append(skipped, rel)
+			continue
+		}
+		if err := copyFileContents(src, dst); err != nil {
+			e.Warn(fmt.Sprintf("artifacts: node %s: copy %s: %v", node.ID, rel, err))
+			skipped = append(skipped, rel)
+			continue
+		}
+		total += fi.Size()
+		copied = append(copied, rel)
+	}
+
+	e.appendProgress(map[string]any{
+		"event":       "artifacts_captured",
+		"node_id":     node.ID,
+		"patterns":    patterns,
+		"files":       copied,
+		"skipped":     skipped,
+		"total_bytes": total,
+		"dir":         destRoot,
+	})
+}
+
+// isBinaryFile reports whether the first 8000 bytes of path contain a NUL,
+// the same heuristic git uses.
+func isBinaryFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	buf := make([]byte, 8000)
+	n, _ := io.ReadFull(f, buf)
+	return bytes.IndexByte(buf[:n], 0) >= 0
+}
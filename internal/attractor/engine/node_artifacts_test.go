@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestRun_NodeArtifacts_CopiesMatchesAndSkipsLargeBinaries(t *testing.T) {
+	repo := t.TempDir()
+	runCmd(t, repo, "git", "init")
+	runCmd(t, repo, "git", "config", "user.name", "tester")
+	runCmd(t, repo, "git", "config", "user.email", "tester@example.com")
+	_ = os.WriteFile(filepath.Join(repo, "README.md"), []byte("hello\n"), 0o644)
+	runCmd(t, repo, "git", "add", "-A")
+	runCmd(t, repo, "git", "commit", "-m", "init")
+
+	dot := []byte(`
+digraph G {
+  graph [goal="artifacts"]
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  build [shape=parallelogram, artifacts="out/**/*.txt, big.bin, missing/*", tool_command="mkdir -p out/sub && echo report > out/report.txt && echo nested > out/sub/n.txt && echo log > out/x.log && head -c 2000000 /dev/zero > big.bin"]
+  start -> build -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	res, err := Run(ctx, dot, RunOptions{RepoPath: repo})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: got %q want %q", res.FinalStatus, runtime.FinalSuccess)
+	}
+
+	dir := filepath.Join(res.LogsRoot, "artifacts", "build")
+	for rel, want := range map[string]string{
+		"out/report.txt": "report\n",
+		"out/sub/n.txt":  "nested\n",
+	} {
+		b, err := os.ReadFile(filepath.Join(dir, rel))
+		if err != nil {
+			t.Fatalf("read artifact %s: %v", rel, err)
+		}
+		if string(b) != want {
+			t.Fatalf("%s: got %q want %q", rel, b, want)
+		}
+	}
+	for _, rel := range []string{"out/x.log", "big.bin"} {
+		if _, err := os.Stat(filepath.Join(dir, rel)); !os.IsNotExist(err) {
+			t.Fatalf("%s should not be captured, stat err=%v", rel, err)
+		}
+	}
+
+	var captured map[string]any
+	for _, ev := range readProgressEvents(t, filepath.Join(res.LogsRoot, "progress.ndjson")) {
+		if ev["event"] == "artifacts_captured" && ev["node_id"] == "build" {
+			captured = ev
+		}
+	}
+	if captured == nil {
+		t.Fatalf("missing artifacts_captured progress event")
+	}
+	if files, _ := captured["files"].([]any); len(files) != 2 {
+		t.Fatalf("captured files: %+v", captured["files"])
+	}
+	if skipped, _ := captured["skipped"].([]any); len(skipped) != 1 || skipped[0] != "big.bin" {
+		t.Fatalf("skipped files: %+v", captured["skipped"])
+	}
+	warned := false
+	for _, w := range res.Warnings {
+		if strings.Contains(w, "skipping binary big.bin") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Fatalf("expected a warning for big.bin, got %v", res.Warnings)
+	}
+}
+
+func TestWorktreeRelEscapes(t *testing.T) {
+	cases := map[string]bool{
+		"out/result.txt":   false,
+		"..foo/bar":        false,
+		"..":               true,
+		"../secret":        true,
+		"../../etc/passwd": true,
+		"/etc/passwd":      true,
+	}
+	for rel, want := range cases {
+		if got := worktreeRelEscapes(filepath.FromSlash(rel)); got != want {
+			t.Errorf("worktreeRelEscapes(%q) = %v, want %v", rel, got, want)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danshapiro/kilroy/internal/agent"
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+// Node memoization is opt-in per node: a node with a cache_key attribute
+// (comma-separated worktree globs naming its input files) is keyed by a hash
+// of the repository path, the graph source, the node ID, those files'
+// contents, its attributes, and its expanded tool_command. A
+// successful outcome is stored under RunOptions.NodeCacheDir together with the
+// files matching the node's artifacts globs; a later visit with the same key
+// restores those files into the worktree instead of executing the node.
+// Worktree changes outside the artifacts globs are not restored, so cached
+// nodes should declare their outputs as artifacts.
+
+// nodeCacheGlobs parses the comma-separated cache_key attribute.
+func nodeCacheGlobs(node *model.Node) []string {
+	if node == nil {
+		return nil
+	}
+	var globs []string
+	for _, g := range strings.Split(node.Attr("cache_key", ""), ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			globs = append(globs, g)
+		}
+	}
+	return globs
+}
+
+// nodeCacheKey returns the memoization key for node, or "" when the node does
+// not opt in or its inputs cannot be read (which disables caching for this
+// visit rather than failing the run).
+func (e *Engine) nodeCacheKey(node *model.Node) string {
+	globs := nodeCacheGlobs(node)
+	if len(globs) == 0 || strings.TrimSpace(e.WorktreeDir) == "" || strings.TrimSpace(e.Options.NodeCacheDir) == "" {
+		return ""
+	}
+
+	// The default cache directory is shared by every run under the same logs
+	// parent, so scope entries to this repository and graph.
+	repo := e.Options.RepoPath
+	if abs, err := filepath.Abs(repo); err == nil {
+		repo = abs
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "repo\x00%s\x00graph\x00%s\x00", repo, sha256Hex(e.DotSource))
+	fmt.Fprintf(h, "node\x00%s\x00", node.ID)
+	attrKeys := make([]string, 0, len(node.Attrs))
+	for k := range node.Attrs {
+		attrKeys = append(attrKeys, k)
+	}
+	sort.Strings(attrKeys)
+	for _, k := range attrKeys {
+		fmt.Fprintf(h, "attr\x00%s\x00%s\x00", k, node.Attrs[k])
+	}
+	// Context placeholders change what the command does without changing the
+	// attribute, so hash the command as it would run.
+	if cmd := node.Attr("tool_command", ""); cmd != "" {
+		expanded, _ := expandToolCommand(cmd, e.Context)
+		fmt.Fprintf(h, "command\x00%s\x00", expanded)
+	}
+
+	rels, err := e.worktreeGlobMatches(globs)
+	if err != nil {
+		e.Warn(fmt.Sprintf("cache: node %s: %v; caching disabled for this visit", node.ID, err))
+		return ""
+	}
+	for _, rel := range rels {
+		f, err := os.Open(filepath.Join(e.WorktreeDir, rel))
+		if err != nil {
+			e.Warn(fmt.Sprintf("cache: node %s: %v; caching disabled for this visit", node.ID, err))
+			return ""
+		}
+		fmt.Fprintf(h, "file\x00%s\x00", filepath.ToSlash(rel))
+		_, err = io.Copy(h, f)
+		_ = f.Close()
+		if err != nil {
+			e.Warn(fmt.Sprintf("cache: node %s: %v; caching disabled for this visit", node.ID, err))
+			return ""
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// worktreeGlobMatches returns the sorted regular files in the worktree (outside
+// .git) matching any of globs, relative to the worktree.
+func (e *Engine) worktreeGlobMatches(globs []string) ([]string, error) {
+	env := agent.NewLocalExecutionEnvironment(e.WorktreeDir)
+	seen := map[string]bool{}
+	var rels []string
+	for _, g := range globs {
+		matches, _, err := env.Glob(g, "", 0)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", g, err)
+		}
+		for _, m := range matches {
+			rel, err := filepath.Rel(e.WorktreeDir, m)
+			if err != nil || worktreeRelEscapes(rel) || rel == ".git" || strings.HasPrefix(rel, ".git"+string(filepath.Separator)) || seen[rel] {
+				continue
+			}
+			if fi, err := os.Stat(m); err != nil || !fi.Mode().IsRegular() {
+				continue
+			}
+			seen[rel] = true
+			rels = append(rels, rel)
+		}
+	}
+	sort.Strings(rels)
+	return rels, nil
+}
+
+func (e *Engine) nodeCacheEntryDir(key string) string {
+	return filepath.Join(e.Options.NodeCacheDir, key)
+}
+
+// loadNodeCache returns the stored outcome for key after restoring its files
+// into the worktree and the node's artifacts directory. It reports false on a
+// miss or any problem reading the entry.
+func (e *Engine) loadNodeCache(node *model.Node, key string) (runtime.Outcome, bool) {
+	if key == "" {
+		return runtime.Outcome{}, false
+	}
+	entry := e.nodeCacheEntryDir(key)
+	b, err := os.ReadFile(filepath.Join(entry, "outcome.json"))
+	if err != nil {
+		return runtime.Outcome{}, false
+	}
+	out, err := runtime.DecodeOutcomeJSON(b)
+	if err != nil {
+		e.Warn(fmt.Sprintf("cache: node %s: decode cached outcome: %v", node.ID, err))
+		return runtime.Outcome{}, false
+	}
+
+	filesRoot := filepath.Join(entry, "files")
+	var restored []string
+	err = filepath.WalkDir(filesRoot, func(p string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if os.IsNotExist(walkErr) && p == filesRoot {
+				return filepath.SkipDir
+			}
+			return walkErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(filesRoot, p)
+		if err != nil {
+			return err
+		}
+		for _, dst := range []string{filepath.Join(e.WorktreeDir, rel), filepath.Join(e.LogsRoot, "artifacts", node.ID, rel)} {
+			if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+				return err
+			}
+			if err := copyFileContents(p, dst); err != nil {
+				return err
+			}
+		}
+		restored = append(restored, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		e.Warn(fmt.Sprintf("cache: node %s: restore cached files: %v; executing instead", node.ID, err))
+		return runtime.Outcome{}, false
+	}
+
+	stageDir := filepath.Join(e.LogsRoot, node.ID)
+	if err := os.MkdirAll(stageDir, 0o755); err == nil {
+		_ = writeJSON(filepath.Join(stageDir, "status.json"), out)
+	}
+	e.appendProgress(map[string]any{
+		"event":     "stage_cache_hit",
+		"node_id":   node.ID,
+		"cache_key": key,
+		"status":    string(out.Status),
+		"files":     restored,
+	})
+	return out, true
+}
+
+// storeNodeCache records a successful outcome and the node's artifacts files
+// under key. Failures only warn: the run does not depend on the cache.
+func (e *Engine) storeNodeCache(node *model.Node, key string, out runtime.Outcome) {
+	if key == "" || (out.Status != runtime.StatusSuccess && out.Status != runtime.StatusPartialSuccess) {
+		return
+	}
+	rels, err := e.worktreeGlobMatches(nodeArtifactPatterns(node))
+	if err != nil {
+		e.Warn(fmt.Sprintf("cache: node %s: %v; not cached", node.ID, err))
+		return
+	}
+
+	// Build the entry in a temporary directory and rename it into place so a
+	// concurrent reader never sees a partial entry.
+	if err := os.MkdirAll(e.Options.NodeCacheDir, 0o755); err != nil {
+		e.Warn(fmt.Sprintf("cache: node %s: %v; not cached", node.ID, err))
+		return
+	}
+	tmp, err := os.MkdirTemp(e.Options.NodeCacheDir, ".tmp-"+key[:12]+"-")
+	if err != nil {
+		e.Warn(fmt.Sprintf("cache: node %s: %v; not cached", node.ID, err))
+		return
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+	for _, rel := range rels {
+		dst := filepath.Join(tmp, "files", rel)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			e.Warn(fmt.Sprintf("cache: node %s: %v; not cached", node.ID, err))
+			return
+		}
+		if err := copyFileContents(filepath.Join(e.WorktreeDir, rel), dst); err != nil {
+			e.Warn(fmt.Sprintf("cache: node %s: copy %s: %v; not cached", node.ID, rel, err))
+			return
+		}
+	}
+	if err := writeJSON(filepath.Join(tmp, "outcome.json"), out); err != nil {
+		e.Warn(fmt.Sprintf("cache: node %s: %v; not cached", node.ID, err))
+		return
+	}
+	entry := e.nodeCacheEntryDir(key)
+	_ = os.RemoveAll(entry)
+	if err := os.Rename(tmp, entry); err != nil {
+		e.Warn(fmt.Sprintf("cache: node %s: %v; not cached", node.ID, err))
+		return
+	}
+	e.appendProgress(map[string]any{
+		"event":     "node_cache_stored",
+		"node_id":   node.ID,
+		"cache_key": key,
+		"files":     rels,
+	})
+}
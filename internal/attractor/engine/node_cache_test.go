@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestRun_NodeCache_HitsOnUnchangedInputsAndMissesOnChange(t *testing.T) {
+	repo := t.TempDir()
+	runCmd(t, repo, "git", "init")
+	runCmd(t, repo, "git", "config", "user.name", "tester")
+	runCmd(t, repo, "git", "config", "user.email", "tester@example.com")
+	_ = os.WriteFile(filepath.Join(repo, "input.txt"), []byte("v1\n"), 0o644)
+	runCmd(t, repo, "git", "add", "-A")
+	runCmd(t, repo, "git", "commit", "-m", "init")
+
+	counter := filepath.Join(t.TempDir(), "count")
+	dot := []byte(`
+digraph G {
+  graph [goal="cache"]
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  build [shape=parallelogram, cache_key="input.txt", artifacts="out/result.txt", tool_command="echo x >> ` + counter + ` && mkdir -p out && cat input.txt > out/result.txt"]
+  start -> build -> exit
+}
+`)
+	runs := t.TempDir()
+	run := func(id string) *Result {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+		res, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunID: id, LogsRoot: filepath.Join(runs, id)})
+		if err != nil {
+			t.Fatalf("Run(%s) error: %v", id, err)
+		}
+		if res.FinalStatus != runtime.FinalSuccess {
+			t.Fatalf("Run(%s) final status: got %q want %q", id, res.FinalStatus, runtime.FinalSuccess)
+		}
+		return res
+	}
+	executions := func() int {
+		b, _ := os.ReadFile(counter)
+		return strings.Count(string(b), "x")
+	}
+	cacheHit := func(res *Result) bool {
+		for _, ev := range readProgressEvents(t, filepath.Join(res.LogsRoot, "progress.ndjson")) {
+			if ev["event"] == "stage_cache_hit" && ev["node_id"] == "build" {
+				return true
+			}
+		}
+		return false
+	}
+
+	first := run("cache-1")
+	if executions() != 1 || cacheHit(first) {
+		t.Fatalf("first run: executions=%d hit=%v, want 1/false", executions(), cacheHit(first))
+	}
+
+	second := run("cache-2")
+	if executions() != 1 || !cacheHit(second) {
+		t.Fatalf("second run: executions=%d hit=%v, want 1/true", executions(), cacheHit(second))
+	}
+	b, err := os.ReadFile(filepath.Join(second.LogsRoot, "artifacts", "build", "out", "result.txt"))
+	if err != nil || string(b) != "v1\n" {
+		t.Fatalf("restored artifact: %q err=%v", b, err)
+	}
+
+	_ = os.WriteFile(filepath.Join(repo, "input.txt"), []byte("v2\n"), 0o644)
+	runCmd(t, repo, "git", "commit", "-am", "change input")
+	third := run("cache-3")
+	if executions() != 2 || cacheHit(third) {
+		t.Fatalf("third run: executions=%d hit=%v, want 2/false", executions(), cacheHit(third))
+	}
+}
+
+func TestRun_NodeCache_ScopedToGraphAndHonoredInParallelBranches(t *testing.T) {
+	repo := t.TempDir()
+	runCmd(t, repo, "git", "init")
+	runCmd(t, repo, "git", "config", "user.name", "tester")
+	runCmd(t, repo, "git", "config", "user.email", "tester@example.com")
+	_ = os.WriteFile(filepath.Join(repo, "input.txt"), []byte("v1\n"), 0o644)
+	runCmd(t, repo, "git", "add", "-A")
+	runCmd(t, repo, "git", "commit", "-m", "init")
+
+	counter := filepath.Join(t.TempDir(), "count")
+	graph := func(goal string) []byte {
+		return []byte(`
+digraph G {
+  graph [goal="` + goal + `"]
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  par   [shape=component]
+  a     [shape=parallelogram, cache_key="input.txt", tool_command="echo x >> ` + counter + `"]
+  b     [shape=parallelogram, cache_key="input.txt", tool_command="echo x >> ` + counter + `"]
+  join  [shape=tripleoctagon]
+  start -> par
+  par -> a
+  par -> b
+  a -> join
+  b -> join
+  join -> exit
+}
+`)
+	}
+	runs := t.TempDir()
+	run := func(id string, dot []byte) {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+		res, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunID: id, LogsRoot: filepath.Join(runs, id)})
+		if err != nil {
+			t.Fatalf("Run(%s) error: %v", id, err)
+		}
+		if res.FinalStatus != runtime.FinalSuccess {
+			t.Fatalf("Run(%s) final status: got %q want %q", id, res.FinalStatus, runtime.FinalSuccess)
+		}
+	}
+	executions := func() int {
+		b, _ := os.ReadFile(counter)
+		return strings.Count(string(b), "x")
+	}
+
+	run("branch-1", graph("cache"))
+	if got := executions(); got != 2 {
+		t.Fatalf("first run executions: got %d want 2", got)
+	}
+	run("branch-2", graph("cache"))
+	if got := executions(); got != 2 {
+		t.Fatalf("second run executions: got %d want 2 (branch nodes should hit the cache)", got)
+	}
+	run("branch-3", graph("other graph"))
+	if got := executions(); got != 4 {
+		t.Fatalf("other graph executions: got %d want 4 (entries must not cross graphs)", got)
+	}
+}
@@ -3,7 +3,11 @@ package engine
 import (
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/danshapiro/kilroy/internal/agent"
+	"github.com/danshapiro/kilroy/internal/attractor/model"
 )
 
 const (
@@ -122,28 +126,74 @@ func stripEnvKey(env []string, key string) []string {
 	return out
 }
 
+// nodeEnvVars returns the node's own environment variables, from the env
+// attribute ("KEY=value;KEY2=value2") and env.KEY attributes, which win over
+// env for the same key. These override the inherited base environment for this
+// node only. Keys that look like secrets (agent.IsSensitiveEnvKey) are dropped
+// unless listed in the comma-separated env_allow attribute; the returned allow
+// list lets filtering environments keep them too.
+func nodeEnvVars(node *model.Node) (map[string]string, []string) {
+	out := map[string]string{}
+	if node == nil {
+		return out, nil
+	}
+	for _, entry := range strings.Split(node.Attr("env", ""), ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if k = strings.TrimSpace(k); ok && k != "" {
+			out[k] = v
+		}
+	}
+	for attr, v := range node.Attrs {
+		if k, ok := strings.CutPrefix(attr, "env."); ok && strings.TrimSpace(k) != "" {
+			out[strings.TrimSpace(k)] = v
+		}
+	}
+
+	allowed := map[string]bool{}
+	var allow []string
+	for _, k := range strings.Split(node.Attr("env_allow", ""), ",") {
+		if k = strings.TrimSpace(k); k != "" && !allowed[k] {
+			allowed[k] = true
+			allow = append(allow, k)
+		}
+	}
+	for k := range out {
+		if agent.IsSensitiveEnvKey(k) && !allowed[k] {
+			delete(out, k)
+		}
+	}
+	sort.Strings(allow)
+	return out, allow
+}
+
 // buildStageRuntimeEnv returns stable per-stage environment variables that
 // help codergen/tool nodes find their run-local state (logs, worktree, etc.).
 func buildStageRuntimeEnv(execCtx *Execution, nodeID string) map[string]string {
-	out := map[string]string{}
 	if execCtx == nil {
-		return out
+		return map[string]string{}
 	}
+	runID := ""
 	if execCtx.Engine != nil {
-		if runID := strings.TrimSpace(execCtx.Engine.Options.RunID); runID != "" {
-			out[runIDEnvKey] = runID
-		}
+		runID = execCtx.Engine.Options.RunID
+	}
+	return stageRuntimeEnv(runID, execCtx.LogsRoot, execCtx.WorktreeDir, nodeID)
+}
+
+func stageRuntimeEnv(runID, logsRoot, worktreeDir, nodeID string) map[string]string {
+	out := map[string]string{}
+	if runID := strings.TrimSpace(runID); runID != "" {
+		out[runIDEnvKey] = runID
 	}
 	if id := strings.TrimSpace(nodeID); id != "" {
 		out[nodeIDEnvKey] = id
 	}
-	if logsRoot := strings.TrimSpace(execCtx.LogsRoot); logsRoot != "" {
+	if logsRoot := strings.TrimSpace(logsRoot); logsRoot != "" {
 		out[logsRootEnvKey] = logsRoot
 		if id := strings.TrimSpace(nodeID); id != "" {
 			out[stageLogsDirEnvKey] = filepath.Join(logsRoot, id)
 		}
 	}
-	if worktree := strings.TrimSpace(execCtx.WorktreeDir); worktree != "" {
+	if worktree := strings.TrimSpace(worktreeDir); worktree != "" {
 		out[worktreeDirEnvKey] = worktree
 	}
 	return out
@@ -203,3 +253,35 @@ func buildAgentLoopOverrides(worktreeDir string, contractEnv map[string]string)
 	}
 	return out
 }
+
+// agentLoopStripEnvKeys are removed from every agent_loop tool environment.
+var agentLoopStripEnvKeys = []string{"CLAUDECODE"}
+
+// newAgentLoopEnvironment builds the tool execution environment used by
+// agent_loop stages: base-node toolchain invariants plus stageEnv, with the
+// engine's strip policy applied.
+func newAgentLoopEnvironment(worktreeDir string, stageEnv map[string]string) *agent.LocalExecutionEnvironment {
+	overrides := buildAgentLoopOverrides(worktreeDir, stageEnv)
+	return agent.NewLocalExecutionEnvironmentWithPolicy(worktreeDir, overrides, agentLoopStripEnvKeys)
+}
+
+// NewLocalExecutionEnvironmentForRun returns a LocalExecutionEnvironment with
+// the same BaseEnv and StripEnvKeys the engine gives an agent_loop stage of
+// the run described by opts. The worktree defaults to {LogsRoot}/worktree when
+// opts.WorktreeDir is empty. extraEnv is layered on top, like a provider
+// contract's env vars. Embedders and tests can use this to run tools outside a
+// full run with identical environment policy.
+func NewLocalExecutionEnvironmentForRun(opts RunOptions, nodeID string, extraEnv map[string]string) *agent.LocalExecutionEnvironment {
+	worktree := strings.TrimSpace(opts.WorktreeDir)
+	if worktree == "" && strings.TrimSpace(opts.LogsRoot) != "" {
+		worktree = filepath.Join(opts.LogsRoot, "worktree")
+	}
+	stageEnv := map[string]string{}
+	for k, v := range extraEnv {
+		stageEnv[k] = v
+	}
+	for k, v := range stageRuntimeEnv(opts.RunID, opts.LogsRoot, worktree, nodeID) {
+		stageEnv[k] = v
+	}
+	return newAgentLoopEnvironment(worktree, stageEnv)
+}
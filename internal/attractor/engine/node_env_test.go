@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
 )
 
 func TestBuildBaseNodeEnv_PreservesToolchainPaths(t *testing.T) {
@@ -44,7 +47,9 @@ func TestBuildBaseNodeEnv_InfersGoPathsFromHOME(t *testing.T) {
 	// toolchain resolution.
 	home := t.TempDir()
 	t.Setenv("HOME", home)
+	t.Setenv("GOPATH", "")
 	os.Unsetenv("GOPATH")
+	t.Setenv("GOMODCACHE", "")
 	os.Unsetenv("GOMODCACHE")
 
 	worktree := t.TempDir()
@@ -65,6 +70,7 @@ func TestBuildBaseNodeEnv_GoModCacheUsesFirstGOPATHEntry(t *testing.T) {
 	second := t.TempDir()
 	multiPath := first + string(filepath.ListSeparator) + second
 	t.Setenv("GOPATH", multiPath)
+	t.Setenv("GOMODCACHE", "")
 	os.Unsetenv("GOMODCACHE")
 
 	worktree := t.TempDir()
@@ -111,7 +117,9 @@ func TestBuildBaseNodeEnv_InfersToolchainPathsFromHOME(t *testing.T) {
 	// buildBaseNodeEnv should set them explicitly so downstream HOME overrides don't break them.
 	home := t.TempDir()
 	t.Setenv("HOME", home)
+	t.Setenv("CARGO_HOME", "")
 	os.Unsetenv("CARGO_HOME")
+	t.Setenv("RUSTUP_HOME", "")
 	os.Unsetenv("RUSTUP_HOME")
 
 	worktree := t.TempDir()
@@ -174,6 +182,56 @@ func TestToolHandler_UsesBaseNodeEnv(t *testing.T) {
 	}
 }
 
+func TestNodeEnvVars_ParsesAttrsAndFiltersSecrets(t *testing.T) {
+	n := model.NewNode("n")
+	n.Attrs["env"] = "FOO=bar; BAZ=one=two;DEPLOY_TOKEN=t;OTHER_SECRET=s;malformed"
+	n.Attrs["env.FOO"] = "override"
+	n.Attrs["env.EMPTY"] = ""
+	n.Attrs["env_allow"] = "DEPLOY_TOKEN"
+
+	env, allow := nodeEnvVars(n)
+	want := map[string]string{"FOO": "override", "BAZ": "one=two", "DEPLOY_TOKEN": "t", "EMPTY": ""}
+	if !reflect.DeepEqual(env, want) {
+		t.Fatalf("env: got %v want %v", env, want)
+	}
+	if !reflect.DeepEqual(allow, []string{"DEPLOY_TOKEN"}) {
+		t.Fatalf("allow: got %v", allow)
+	}
+}
+
+func TestToolHandler_NodeEnvOverridesBaseEnvForThatNodeOnly(t *testing.T) {
+	t.Setenv("KILROY_TEST_NODE_ENV", "inherited")
+
+	dot := []byte(`digraph G {
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  a [shape=parallelogram, env="KILROY_TEST_NODE_ENV=from-node;DEPLOY_TOKEN=tok;STRAY_SECRET=nope", env_allow="DEPLOY_TOKEN", tool_command="echo v=$KILROY_TEST_NODE_ENV t=$DEPLOY_TOKEN s=$STRAY_SECRET"]
+  b [shape=parallelogram, tool_command="echo v=$KILROY_TEST_NODE_ENV t=$DEPLOY_TOKEN"]
+  start -> a -> b -> exit
+}`)
+	repo := initTestRepo(t)
+	logsRoot := t.TempDir()
+	result, err := Run(context.Background(), dot, RunOptions{RepoPath: repo, LogsRoot: logsRoot})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.FinalStatus != "success" {
+		t.Fatalf("expected success, got %s", result.FinalStatus)
+	}
+	for node, want := range map[string]string{
+		"a": "v=from-node t=tok s=",
+		"b": "v=inherited t=",
+	} {
+		b, err := os.ReadFile(filepath.Join(logsRoot, node, "stdout.log"))
+		if err != nil {
+			t.Fatalf("read %s stdout: %v", node, err)
+		}
+		if got := strings.TrimSpace(string(b)); got != want {
+			t.Fatalf("%s stdout: got %q want %q", node, got, want)
+		}
+	}
+}
+
 func TestBuildCodexIsolatedEnv_PreservesToolchainPaths(t *testing.T) {
 	home := t.TempDir()
 	cargoHome := filepath.Join(home, ".cargo")
@@ -264,3 +322,56 @@ func TestBuildCodexIsolatedEnvWithName_RetryPreservesToolchainPaths(t *testing.T
 		}
 	}
 }
+
+func TestNewLocalExecutionEnvironmentForRun_MatchesEngineAgentLoopPolicy(t *testing.T) {
+	t.Setenv("CLAUDECODE", "1")
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CARGO_HOME", "")
+	os.Unsetenv("CARGO_HOME")
+
+	logsRoot := t.TempDir()
+	opts := RunOptions{RunID: "env-helper", LogsRoot: logsRoot}
+	env := NewLocalExecutionEnvironmentForRun(opts, "impl", map[string]string{"CONTRACT_VAR": "x"})
+
+	worktree := filepath.Join(logsRoot, "worktree")
+	if env.RootDir != worktree {
+		t.Fatalf("RootDir: got %q want %q", env.RootDir, worktree)
+	}
+	if len(env.StripEnvKeys) != 1 || env.StripEnvKeys[0] != "CLAUDECODE" {
+		t.Fatalf("StripEnvKeys: got %v want [CLAUDECODE]", env.StripEnvKeys)
+	}
+	for k, want := range map[string]string{
+		"CARGO_HOME":       filepath.Join(home, ".cargo"),
+		"CARGO_TARGET_DIR": defaultCargoTargetDir(worktree),
+		runIDEnvKey:        "env-helper",
+		nodeIDEnvKey:       "impl",
+		logsRootEnvKey:     logsRoot,
+		stageLogsDirEnvKey: filepath.Join(logsRoot, "impl"),
+		worktreeDirEnvKey:  worktree,
+		"CONTRACT_VAR":     "x",
+	} {
+		if got := env.BaseEnv[k]; got != want {
+			t.Fatalf("BaseEnv[%s]: got %q want %q", k, got, want)
+		}
+	}
+
+	// The engine path (Execution-based) must produce the identical environment.
+	execCtx := &Execution{LogsRoot: logsRoot, WorktreeDir: worktree, Engine: &Engine{Options: opts}}
+	stageEnv := map[string]string{"CONTRACT_VAR": "x"}
+	for k, v := range buildStageRuntimeEnv(execCtx, "impl") {
+		stageEnv[k] = v
+	}
+	engineEnv := newAgentLoopEnvironment(worktree, stageEnv)
+	if !reflect.DeepEqual(engineEnv.BaseEnv, env.BaseEnv) {
+		t.Fatalf("BaseEnv mismatch:\nhelper=%v\nengine=%v", env.BaseEnv, engineEnv.BaseEnv)
+	}
+
+	res, err := env.ExecCommand(context.Background(), "echo ${CLAUDECODE:-unset}", 5000, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("ExecCommand: %v", err)
+	}
+	if got := strings.TrimSpace(res.Stdout); got != "unset" {
+		t.Fatalf("CLAUDECODE should be stripped from tool env, got %q", got)
+	}
+}
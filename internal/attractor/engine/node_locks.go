@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+)
+
+// nodeLocks serializes nodes that share a lock="name" attribute across the
+// whole run. One instance is shared by the top-level engine and every parallel
+// branch and manager child engine it spawns.
+type nodeLocks struct {
+	mu    sync.Mutex
+	locks map[string]chan struct{}
+}
+
+func newNodeLocks() *nodeLocks {
+	return &nodeLocks{locks: map[string]chan struct{}{}}
+}
+
+func (l *nodeLocks) lock(name string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ch, ok := l.locks[name]
+	if !ok {
+		ch = make(chan struct{}, 1)
+		l.locks[name] = ch
+	}
+	return ch
+}
+
+// acquire takes every named lock, in sorted order so nodes that list several
+// locks cannot deadlock each other, and returns a func releasing them. It gives
+// up and releases anything already held when ctx is done.
+func (l *nodeLocks) acquire(ctx context.Context, names []string) (func(), error) {
+	held := make([]chan struct{}, 0, len(names))
+	release := func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			<-held[i]
+		}
+	}
+	for _, name := range names {
+		ch := l.lock(name)
+		select {
+		case ch <- struct{}{}:
+			held = append(held, ch)
+		case <-ctx.Done():
+			release()
+			return nil, context.Cause(ctx)
+		}
+	}
+	return release, nil
+}
+
+// nodeLockNames parses the comma-separated lock attribute into sorted, unique
+// lock names.
+func nodeLockNames(node *model.Node) []string {
+	if node == nil {
+		return nil
+	}
+	raw := strings.TrimSpace(node.Attr("lock", ""))
+	if raw == "" {
+		return nil
+	}
+	seen := map[string]bool{}
+	var names []string
+	for _, part := range strings.Split(raw, ",") {
+		name := strings.TrimSpace(part)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sharedNodeLocks returns the run-wide lock set, creating it on first use for
+// engines built without newBaseEngine.
+func (e *Engine) sharedNodeLocks() *nodeLocks {
+	e.stageMu.Lock()
+	defer e.stageMu.Unlock()
+	if e.nodeLocks == nil {
+		e.nodeLocks = newNodeLocks()
+	}
+	return e.nodeLocks
+}
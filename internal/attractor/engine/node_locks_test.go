@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+)
+
+func TestNodeLockNames_SortedAndDeduplicated(t *testing.T) {
+	n := model.NewNode("a")
+	n.Attrs["lock"] = " db, cache ,db,, "
+	if got, want := nodeLockNames(n), []string{"cache", "db"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("nodeLockNames: got %v want %v", got, want)
+	}
+	if got := nodeLockNames(model.NewNode("b")); got != nil {
+		t.Fatalf("expected no locks, got %v", got)
+	}
+}
+
+func TestNodeLocks_AcquireHonorsContextCancel(t *testing.T) {
+	locks := newNodeLocks()
+	release, err := locks.acquire(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := locks.acquire(ctx, []string{"b"}); err == nil {
+		t.Fatal("expected acquire to fail while lock is held")
+	}
+	release()
+	release2, err := locks.acquire(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release2()
+}
+
+func TestRun_ParallelBranchesSharingLock_DoNotOverlap(t *testing.T) {
+	repo := initTestRepo(t)
+	logPath := filepath.Join(t.TempDir(), "intervals.log")
+	step := func(name string) string {
+		return `echo ` + name + ` start $(date +%s%N) >> '` + logPath + `'; sleep 0.3; echo ` + name + ` end $(date +%s%N) >> '` + logPath + `'`
+	}
+
+	dot := []byte(`
+digraph P {
+  graph [goal="lock test"]
+  start [shape=Mdiamond]
+  par [shape=component]
+  a [shape=parallelogram, lock="shared", tool_command="` + step("a") + `"]
+  b [shape=parallelogram, lock="shared", tool_command="` + step("b") + `"]
+  join [shape=tripleoctagon]
+  exit [shape=Msquare]
+
+  start -> par
+  par -> a
+  par -> b
+  a -> join
+  b -> join
+  join -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	if _, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunID: "node-lock", LogsRoot: t.TempDir()}); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	b, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type interval struct{ start, end int64 }
+	spans := map[string]*interval{}
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		f := strings.Fields(line)
+		if len(f) != 3 {
+			t.Fatalf("unexpected log line %q", line)
+		}
+		ts, err := strconv.ParseInt(f[2], 10, 64)
+		if err != nil {
+			t.Fatalf("parse timestamp %q: %v", line, err)
+		}
+		if spans[f[0]] == nil {
+			spans[f[0]] = &interval{}
+		}
+		if f[1] == "start" {
+			spans[f[0]].start = ts
+		} else {
+			spans[f[0]].end = ts
+		}
+	}
+	a, bb := spans["a"], spans["b"]
+	if a == nil || bb == nil || a.end == 0 || bb.end == 0 {
+		t.Fatalf("expected both branches to run, log:\n%s", b)
+	}
+	if a.start < bb.end && bb.start < a.end {
+		t.Fatalf("locked nodes overlapped: a=%+v b=%+v", *a, *bb)
+	}
+}
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestEffectiveStageTimeout_NodeTimeoutMS(t *testing.T) {
+	n := model.NewNode("a")
+	n.Attrs["node_timeout_ms"] = "1500"
+	if got := effectiveStageTimeout(n, 0); got != 1500*time.Millisecond {
+		t.Fatalf("node_timeout_ms only: got %s", got)
+	}
+	n.Attrs["timeout"] = "1s"
+	if got := effectiveStageTimeout(n, 0); got != time.Second {
+		t.Fatalf("smaller timeout attr wins: got %s", got)
+	}
+	if got := effectiveStageTimeout(n, 500*time.Millisecond); got != 500*time.Millisecond {
+		t.Fatalf("smaller global cap wins: got %s", got)
+	}
+}
+
+func TestRun_NodeTimeoutMS_KillsToolAndRetries(t *testing.T) {
+	repo := initTestRepo(t)
+	mark := filepath.Join(t.TempDir(), "first-attempt")
+
+	// The first attempt hangs past node_timeout_ms; the retry finishes at once.
+	dot := []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  t [shape=parallelogram, max_retries=1, node_timeout_ms=300, tool_command="if [ -f ` + mark + ` ]; then echo ok > ok.txt; else touch ` + mark + `; sleep 30; fi"]
+  start -> t -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	started := time.Now()
+	res, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunID: "node-timeout-retry", LogsRoot: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: got %q want success", res.FinalStatus)
+	}
+	if elapsed := time.Since(started); elapsed > 20*time.Second {
+		t.Fatalf("timed-out tool was not killed promptly: run took %s", elapsed)
+	}
+	b, err := os.ReadFile(filepath.Join(res.LogsRoot, "progress.ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "node timeout") {
+		t.Fatalf("expected a node timeout attempt in progress log:\n%s", b)
+	}
+}
+
+func TestRun_NodeTimeoutMS_FailsAsTransientInfra(t *testing.T) {
+	repo := initTestRepo(t)
+
+	dot := []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  t [shape=parallelogram, node_timeout_ms=200, tool_command="sleep 30"]
+  start -> t -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	logsRoot := t.TempDir()
+	_, _ = Run(ctx, dot, RunOptions{RepoPath: repo, RunID: "node-timeout-fail", LogsRoot: logsRoot})
+
+	b, err := os.ReadFile(filepath.Join(logsRoot, "t", "status.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := runtime.DecodeOutcomeJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Status != runtime.StatusFail || out.FailureReason != "node timeout" {
+		t.Fatalf("outcome: status=%q reason=%q", out.Status, out.FailureReason)
+	}
+	if got := classifyFailureClass(out); got != failureClassTransientInfra {
+		t.Fatalf("failure class: got %q want %q", got, failureClassTransientInfra)
+	}
+}
@@ -100,15 +100,17 @@ func (h *ParallelHandler) Execute(ctx context.Context, exec *Execution, node *mo
 		return runtime.Outcome{Status: runtime.StatusFail, FailureReason: err.Error()}, nil
 	}
 
-	// Spec §4.8: read join_policy and error_policy from node attributes.
-	jp, ep := parallelPolicies(node)
+	// Spec §4.8: read join_policy and error_policy from node attributes. A
+	// join attribute on the fan-in node applies when join_policy is unset.
+	policyNode := joinPolicyNode(node, exec.Graph.Nodes[joinID])
+	jp, ep := parallelPolicies(policyNode)
 
 	// Spec §9.6: emit ParallelStarted CXDB event.
 	parallelStart := time.Now()
 	exec.Engine.cxdbParallelStarted(ctx, node.ID, len(branches),
 		string(jp), string(ep))
 
-	results, baseSHA, err := dispatchParallelBranchesWithPolicy(ctx, exec, node.ID, branches, joinID, jp, ep, node)
+	results, baseSHA, err := dispatchParallelBranchesWithPolicy(ctx, exec, node.ID, branches, joinID, jp, ep, policyNode)
 	if err != nil {
 		return runtime.Outcome{Status: runtime.StatusFail, FailureReason: err.Error()}, err
 	}
@@ -130,7 +132,7 @@ func (h *ParallelHandler) Execute(ctx context.Context, exec *Execution, node *mo
 	filteredResults := filterResultsByErrorPolicy(ep, results)
 
 	// Spec §4.8: evaluate join_policy to determine aggregate outcome.
-	policyOutcome := evaluateJoinPolicy(jp, node, filteredResults)
+	policyOutcome := evaluateJoinPolicy(jp, policyNode, filteredResults)
 
 	// Use filtered results for context propagation to fan-in handler.
 	contextResults := filteredResults
@@ -140,19 +142,49 @@ func (h *ParallelHandler) Execute(ctx context.Context, exec *Execution, node *mo
 	_ = writeJSON(filepath.Join(stageDir, "parallel_results.json"), results)
 
 	return runtime.Outcome{
-		Status:        policyOutcome.Status,
-		Notes:         fmt.Sprintf("parallel fan-out complete (%d branches), join=%s; %s", len(results), joinID, policyOutcome.Notes),
-		FailureReason: policyOutcome.FailureReason,
-		ContextUpdates: map[string]any{
-			"parallel.join_node": joinID,
-			"parallel.results":   contextResults,
-		},
+		Status:         policyOutcome.Status,
+		Notes:          fmt.Sprintf("parallel fan-out complete (%d branches), join=%s; %s", len(results), joinID, policyOutcome.Notes),
+		FailureReason:  policyOutcome.FailureReason,
+		ContextUpdates: parallelContextUpdates(joinID, contextResults),
 		Meta: map[string]any{
 			"kilroy.git_checkpoint_sha": baseSHA,
 		},
 	}, nil
 }
 
+// parallelContextUpdates is what a finished fan-out publishes for its join
+// node: the join node ID, the full results under parallel.results, and a flat
+// per-branch view that conditions and {{...}} tool_command placeholders can
+// address directly:
+//
+//	parallel.branch_ids                  branch keys, sorted
+//	parallel.branch.<key>.outcome        the branch's final status
+//	parallel.branch.<key>.notes          its final notes
+//	parallel.branch.<key>.failure_reason its failure reason ("" on success)
+//	parallel.branch.<key>.last_node      the last node it ran
+//	parallel.branch.<key>.head_sha       its head commit
+//
+// results arrive sorted by branch key whatever order the branches finished
+// in, so the keys, and parallel.results, are the same on every run.
+func parallelContextUpdates(joinID string, results []parallelBranchResult) map[string]any {
+	ids := make([]string, 0, len(results))
+	updates := map[string]any{
+		"parallel.join_node": joinID,
+		"parallel.results":   results,
+	}
+	for _, r := range results {
+		prefix := "parallel.branch." + r.BranchKey + "."
+		ids = append(ids, r.BranchKey)
+		updates[prefix+"outcome"] = string(r.Outcome.Status)
+		updates[prefix+"notes"] = r.Outcome.Notes
+		updates[prefix+"failure_reason"] = r.Outcome.FailureReason
+		updates[prefix+"last_node"] = r.LastNodeID
+		updates[prefix+"head_sha"] = r.HeadSHA
+	}
+	updates["parallel.branch_ids"] = ids
+	return updates
+}
+
 // dispatchParallelBranches runs branches in parallel and returns the results.
 // It creates a checkpoint commit, spawns worktrees for each branch, runs subgraphs,
 // and collects results. This is the shared core used by both explicit ParallelHandler
@@ -190,9 +222,10 @@ func dispatchParallelBranches(
 		maxParallel = 4
 	}
 
-	// git ref/worktree mutations are not concurrency-safe. Serialize setup operations,
-	// then run branch execution concurrently.
-	var gitMu sync.Mutex
+	// git ref/worktree mutations are not concurrency-safe. Branch setup and
+	// every checkpoint commit take the run-wide git lock; the branches
+	// themselves run concurrently in their own worktrees.
+	gitMu := exec.Engine.sharedGitLock()
 
 	type job struct {
 		idx  int
@@ -211,7 +244,7 @@ func dispatchParallelBranches(
 			if e == nil {
 				continue
 			}
-			res := h.runBranch(ctx, exec, sourceNode, baseSHA, joinID, j.idx, e, &gitMu)
+			res := h.runBranch(ctx, exec, sourceNode, baseSHA, joinID, j.idx, e, gitMu)
 			results[j.idx] = res
 		}
 	}
@@ -241,6 +274,30 @@ func dispatchParallelBranches(
 	return results, baseSHA, nil
 }
 
+// dispatchImplicitParallelBranches dispatches an implicit (edge-topology)
+// fan-out. When the convergence node declares a join attribute, its policy
+// decides when the fan-out is done and cancels the remaining branches;
+// otherwise every branch runs to completion.
+func dispatchImplicitParallelBranches(
+	ctx context.Context,
+	exec *Execution,
+	sourceNodeID string,
+	branches []*model.Edge,
+	joinID string,
+) ([]parallelBranchResult, string, error) {
+	joinNode := exec.Graph.Nodes[joinID]
+	if joinNode == nil || strings.TrimSpace(joinNode.Attr("join", "")) == "" {
+		return dispatchParallelBranches(ctx, exec, sourceNodeID, branches, joinID)
+	}
+	sourceNode := exec.Graph.Nodes[sourceNodeID]
+	if sourceNode == nil {
+		sourceNode = &model.Node{ID: sourceNodeID, Attrs: map[string]string{}}
+	}
+	policyNode := joinPolicyNode(sourceNode, joinNode)
+	jp, ep := parallelPolicies(policyNode)
+	return dispatchParallelBranchesWithPolicy(ctx, exec, sourceNodeID, branches, joinID, jp, ep, policyNode)
+}
+
 func (h *ParallelHandler) runBranch(ctx context.Context, exec *Execution, parallelNode *model.Node, baseSHA, joinID string, idx int, edge *model.Edge, gitMu *sync.Mutex) parallelBranchResult {
 	key := sanitizeRefComponent(edge.To)
 	if key == "" {
@@ -398,13 +455,17 @@ func (h *ParallelHandler) runBranch(ctx context.Context, exec *Execution, parall
 		ModelCatalogSHA:    exec.Engine.ModelCatalogSHA,
 		ModelCatalogSource: exec.Engine.ModelCatalogSource,
 		ModelCatalogPath:   exec.Engine.ModelCatalogPath,
+		nodeLocks:          exec.Engine.sharedNodeLocks(),
+		gitLock:            exec.Engine.sharedGitLock(),
+		totalSteps:         exec.Engine.sharedSteps(),
+		metrics:            exec.Engine.sharedMetrics(),
 	}
 	if exec.Engine.CXDB != nil {
 		if fork, err := exec.Engine.CXDB.ForkFromHead(ctx); err == nil {
 			branchEng.CXDB = fork
 		}
 	}
-	branchEng.progressSink = func(ev map[string]any) {
+	branchEng.AddProgressSink(func(ev map[string]any) {
 		eventName := eventFieldString(ev, "event")
 		if eventName == "" {
 			return
@@ -432,7 +493,7 @@ func (h *ParallelHandler) runBranch(ctx context.Context, exec *Execution, parall
 			extra["branch_to_node"] = toNode
 		}
 		emitBranchProgress(eventName, extra)
-	}
+	})
 	emitBranchProgress("branch_subgraph_start", nil)
 	// Spec §9.6: emit ParallelBranchStarted CXDB event.
 	branchStart := time.Now()
@@ -521,6 +582,10 @@ func (h *FanInHandler) Execute(ctx context.Context, exec *Execution, node *model
 		}, nil
 	}
 
+	if strings.EqualFold(strings.TrimSpace(node.Attr("merge", "")), "all") {
+		return mergeAllBranchHeads(exec, results, winner)
+	}
+
 	// Fast-forward the main run branch to the winner head.
 	if strings.TrimSpace(winner.HeadSHA) != "" {
 		if err := gitutil.FastForwardFFOnly(exec.WorktreeDir, winner.HeadSHA); err != nil {
@@ -558,6 +623,68 @@ func (h *FanInHandler) Execute(ctx context.Context, exec *Execution, node *model
 	}, nil
 }
 
+// mergeAllBranchHeads implements merge="all" on a fan-in node: instead of
+// fast-forwarding to the winner, every successful branch head is merged into
+// the run branch in branch key order, so the result does not depend on which
+// branch finished first. A conflict restores the pre-merge HEAD and fails the
+// node naming the branch and paths.
+func mergeAllBranchHeads(exec *Execution, results []parallelBranchResult, winner parallelBranchResult) (runtime.Outcome, error) {
+	ordered := append([]parallelBranchResult(nil), results...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].BranchKey < ordered[j].BranchKey })
+
+	runID := ""
+	if exec.Engine != nil {
+		runID = exec.Engine.Options.RunID
+		mu := exec.Engine.sharedGitLock()
+		mu.Lock()
+		defer mu.Unlock()
+	}
+	before, err := gitutil.HeadSHA(exec.WorktreeDir)
+	if err != nil {
+		return runtime.Outcome{Status: runtime.StatusFail, FailureReason: err.Error()}, nil
+	}
+	merged := []string{}
+	for _, r := range ordered {
+		if r.Outcome.Status != runtime.StatusSuccess && r.Outcome.Status != runtime.StatusPartialSuccess {
+			continue
+		}
+		if strings.TrimSpace(r.HeadSHA) == "" {
+			continue
+		}
+		msg := fmt.Sprintf("attractor(%s): merge parallel branch %s", runID, r.BranchKey)
+		conflicts, err := gitutil.MergeOrAbort(exec.WorktreeDir, r.HeadSHA, msg)
+		if err != nil {
+			_ = gitutil.ResetHard(exec.WorktreeDir, before)
+			reason := fmt.Sprintf("fan-in merge of branch %s failed: %v", r.BranchKey, err)
+			if len(conflicts) > 0 {
+				reason = fmt.Sprintf("fan-in merge of branch %s conflicts in %s", r.BranchKey, strings.Join(conflicts, ", "))
+			}
+			return runtime.Outcome{
+				Status:        runtime.StatusFail,
+				FailureReason: reason,
+				Meta:          map[string]any{"failure_class": failureClassDeterministic},
+				ContextUpdates: map[string]any{
+					"failure_class": failureClassDeterministic,
+				},
+			}, nil
+		}
+		merged = append(merged, r.BranchKey)
+	}
+
+	return runtime.Outcome{
+		Status: runtime.StatusSuccess,
+		Notes:  fmt.Sprintf("fan-in merged %d branch(es): %s", len(merged), strings.Join(merged, ", ")),
+		ContextUpdates: map[string]any{
+			"parallel.fan_in.best_id":                winner.BranchKey,
+			"parallel.fan_in.best_outcome":           winner.Outcome,
+			"parallel.fan_in.best_head_sha":          winner.HeadSHA,
+			"parallel.fan_in.best_cxdb_context_id":   winner.CXDBContextID,
+			"parallel.fan_in.best_cxdb_head_turn_id": winner.CXDBHeadTurnID,
+			"parallel.fan_in.merged_ids":             merged,
+		},
+	}, nil
+}
+
 // ManagerLoopHandler is defined in manager_loop.go.
 type ManagerLoopHandler struct{}
 
@@ -0,0 +1,243 @@
+//go:build !windows
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestRun_FanInJoinAny_CancelsSlowBranchAndKillsItsProcesses(t *testing.T) {
+	repo := t.TempDir()
+	runCmd(t, repo, "git", "init")
+	runCmd(t, repo, "git", "config", "user.name", "tester")
+	runCmd(t, repo, "git", "config", "user.email", "tester@example.com")
+	_ = os.WriteFile(filepath.Join(repo, "README.md"), []byte("hello\n"), 0o644)
+	runCmd(t, repo, "git", "add", "-A")
+	runCmd(t, repo, "git", "commit", "-m", "init")
+
+	pidFile := filepath.Join(t.TempDir(), "slow.pid")
+	dot := []byte(fmt.Sprintf(`
+digraph P {
+  graph [goal="join any"]
+  start [shape=Mdiamond]
+  par [shape=component]
+  fast [shape=parallelogram, tool_command="sleep 1; echo fast > fast.txt"]
+  slow [shape=parallelogram, tool_command="sleep 300 & echo $! > %s; wait"]
+  join [shape=tripleoctagon, join="any"]
+  exit [shape=Msquare]
+
+  start -> par
+  par -> fast
+  par -> slow
+  fast -> join
+  slow -> join
+  join -> exit
+}
+`, pidFile))
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	started := time.Now()
+	res, err := Run(ctx, dot, RunOptions{RepoPath: repo})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: got %q want %q", res.FinalStatus, runtime.FinalSuccess)
+	}
+	if elapsed := time.Since(started); elapsed > 30*time.Second {
+		t.Fatalf("run took %s; slow branch was not canceled", elapsed)
+	}
+
+	// The slow branch's background sleep shares its process group and must be gone.
+	waitForPIDToExit(t, mustReadPIDFile(t, pidFile), 5*time.Second)
+
+	files := runCmdOut(t, repo, "git", "ls-tree", "-r", "--name-only", res.FinalCommitSHA)
+	if !strings.Contains(files, "fast.txt") {
+		t.Fatalf("winner not fast-forwarded; files:\n%s", files)
+	}
+
+	var resolved map[string]any
+	for _, ev := range readProgressEvents(t, filepath.Join(res.LogsRoot, "progress.ndjson")) {
+		if ev["event"] == "parallel_join_resolved" {
+			resolved = ev
+		}
+	}
+	if resolved == nil {
+		t.Fatalf("missing parallel_join_resolved progress event")
+	}
+	if resolved["join_policy"] != string(joinFirstSuccess) || resolved["terminated"] != true {
+		t.Fatalf("parallel_join_resolved: %+v", resolved)
+	}
+	if got := fmt.Sprint(resolved["winners"]); got != "[fast]" {
+		t.Fatalf("winners: got %s want [fast]", got)
+	}
+	if got := fmt.Sprint(resolved["canceled"]); got != "[slow]" {
+		t.Fatalf("canceled: got %s want [slow]", got)
+	}
+}
+
+func TestRun_ParallelPublishesPerBranchOutcomesToContext(t *testing.T) {
+	repo := initTestRepo(t)
+	report := filepath.Join(t.TempDir(), "report.txt")
+	dot := []byte(fmt.Sprintf(`
+digraph P {
+  graph [goal="branch outcomes"]
+  start [shape=Mdiamond]
+  par [shape=component]
+  good [shape=parallelogram, tool_command="echo good > good.txt"]
+  bad [shape=parallelogram, tool_command="exit 3"]
+  join [shape=tripleoctagon]
+  report [shape=parallelogram, tool_command="echo {{parallel.branch.good.outcome}} {{parallel.branch.bad.outcome}} {{parallel.branch.bad.last_node}} > %s"]
+  exit [shape=Msquare]
+
+  start -> par
+  par -> good
+  par -> bad
+  good -> join
+  bad -> join
+  join -> report
+  report -> exit [condition="context.parallel.branch.bad.outcome=fail"]
+}
+`, report))
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	res, err := Run(ctx, dot, RunOptions{RepoPath: repo})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: got %q want %q", res.FinalStatus, runtime.FinalSuccess)
+	}
+	b, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(b)); got != "success fail bad" {
+		t.Fatalf("report: got %q want %q", got, "success fail bad")
+	}
+}
+
+func TestParallelContextUpdates_SortedBranchIDsAndFlatKeys(t *testing.T) {
+	updates := parallelContextUpdates("join", []parallelBranchResult{
+		{BranchKey: "a", LastNodeID: "a", HeadSHA: "111", Outcome: runtime.Outcome{Status: runtime.StatusSuccess, Notes: "done"}},
+		{BranchKey: "b", LastNodeID: "b2", Outcome: runtime.Outcome{Status: runtime.StatusFail, FailureReason: "boom"}},
+	})
+	if got := updates["parallel.branch_ids"]; !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("branch_ids: %v", got)
+	}
+	for k, want := range map[string]string{
+		"parallel.join_node":               "join",
+		"parallel.branch.a.outcome":        "success",
+		"parallel.branch.a.notes":          "done",
+		"parallel.branch.a.head_sha":       "111",
+		"parallel.branch.b.outcome":        "fail",
+		"parallel.branch.b.failure_reason": "boom",
+		"parallel.branch.b.last_node":      "b2",
+	} {
+		if got := updates[k]; got != want {
+			t.Fatalf("%s: got %v want %q", k, got, want)
+		}
+	}
+}
+
+func TestRun_FanInMergeAll_KeepsEveryBranchsChanges(t *testing.T) {
+	repo := t.TempDir()
+	runCmd(t, repo, "git", "init")
+	runCmd(t, repo, "git", "config", "user.name", "tester")
+	runCmd(t, repo, "git", "config", "user.email", "tester@example.com")
+	_ = os.WriteFile(filepath.Join(repo, "README.md"), []byte("hello\n"), 0o644)
+	runCmd(t, repo, "git", "add", "-A")
+	runCmd(t, repo, "git", "commit", "-m", "init")
+
+	// Both branches write and checkpoint at the same time, twice each.
+	dot := []byte(`
+digraph P {
+  graph [goal="merge all"]
+  start [shape=Mdiamond]
+  par [shape=component]
+  a1 [shape=parallelogram, tool_command="echo a1 > a1.txt"]
+  a2 [shape=parallelogram, tool_command="echo a2 > a2.txt"]
+  b1 [shape=parallelogram, tool_command="echo b1 > b1.txt"]
+  b2 [shape=parallelogram, tool_command="echo b2 > b2.txt"]
+  join [shape=tripleoctagon, merge="all"]
+  check [shape=parallelogram, tool_command="test -f a1.txt && test -f a2.txt && test -f b1.txt && test -f b2.txt"]
+  exit [shape=Msquare]
+
+  start -> par
+  par -> a1 -> a2 -> join
+  par -> b1 -> b2 -> join
+  join -> check -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	res, err := Run(ctx, dot, RunOptions{RepoPath: repo})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: got %q want %q", res.FinalStatus, runtime.FinalSuccess)
+	}
+	files := runCmdOut(t, repo, "git", "ls-tree", "-r", "--name-only", res.FinalCommitSHA)
+	for _, f := range []string{"a1.txt", "a2.txt", "b1.txt", "b2.txt"} {
+		if !strings.Contains(files, f) {
+			t.Fatalf("%s lost at the join; files:\n%s", f, files)
+		}
+	}
+	b, err := os.ReadFile(filepath.Join(res.LogsRoot, "join", "status.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := runtime.DecodeOutcomeJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(out.ContextUpdates["parallel.fan_in.merged_ids"]); got != "[a1 b1]" {
+		t.Fatalf("merged_ids = %s, want [a1 b1]", got)
+	}
+}
+
+func TestRun_FanInMergeAll_ConflictFailsJoin(t *testing.T) {
+	repo := t.TempDir()
+	runCmd(t, repo, "git", "init")
+	runCmd(t, repo, "git", "config", "user.name", "tester")
+	runCmd(t, repo, "git", "config", "user.email", "tester@example.com")
+	_ = os.WriteFile(filepath.Join(repo, "README.md"), []byte("hello\n"), 0o644)
+	runCmd(t, repo, "git", "add", "-A")
+	runCmd(t, repo, "git", "commit", "-m", "init")
+
+	dot := []byte(`
+digraph P {
+  graph [goal="merge conflict"]
+  start [shape=Mdiamond]
+  par [shape=component]
+  a [shape=parallelogram, tool_command="echo a > shared.txt"]
+  b [shape=parallelogram, tool_command="echo b > shared.txt"]
+  join [shape=tripleoctagon, merge="all"]
+  exit [shape=Msquare]
+
+  start -> par
+  par -> a -> join
+  par -> b -> join
+  join -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	res, err := Run(ctx, dot, RunOptions{RepoPath: repo})
+	if err == nil {
+		t.Fatalf("expected the conflicting join to fail the run, got %+v", res)
+	}
+	if !strings.Contains(err.Error(), "fan-in merge of branch b conflicts in shared.txt") {
+		t.Fatalf("error = %v", err)
+	}
+}
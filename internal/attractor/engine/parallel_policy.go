@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -53,6 +54,48 @@ func parseErrorPolicy(s string) errorPolicy {
 	}
 }
 
+// parseFanInJoin parses the join attribute of a fan-in node: "all" waits for
+// every branch, "any" proceeds on the first success, and "quorum:N" proceeds
+// once N branches succeed. It returns the equivalent join policy and k.
+func parseFanInJoin(s string) (joinPolicy, int, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	switch v {
+	case "all":
+		return joinWaitAll, 0, nil
+	case "any":
+		return joinFirstSuccess, 1, nil
+	}
+	if rest, ok := strings.CutPrefix(v, "quorum:"); ok {
+		if n, err := strconv.Atoi(strings.TrimSpace(rest)); err == nil && n > 0 {
+			return joinKOfN, n, nil
+		}
+	}
+	return "", 0, fmt.Errorf("invalid join %q (want all, any, or quorum:N)", s)
+}
+
+// joinPolicyNode returns the node whose attributes drive join evaluation for
+// a fan-out. An explicit join_policy on the fan-out node wins; otherwise a
+// join attribute on the fan-in node is translated into join_policy (and k).
+func joinPolicyNode(node, joinNode *model.Node) *model.Node {
+	if node == nil || joinNode == nil || strings.TrimSpace(node.Attr("join_policy", "")) != "" {
+		return node
+	}
+	jp, k, err := parseFanInJoin(joinNode.Attr("join", ""))
+	if err != nil {
+		return node
+	}
+	cp := *node
+	cp.Attrs = make(map[string]string, len(node.Attrs)+2)
+	for key, v := range node.Attrs {
+		cp.Attrs[key] = v
+	}
+	cp.Attrs["join_policy"] = string(jp)
+	if jp == joinKOfN {
+		cp.Attrs["k"] = strconv.Itoa(k)
+	}
+	return &cp
+}
+
 // parallelPolicies extracts join_policy and error_policy from node attributes.
 func parallelPolicies(node *model.Node) (joinPolicy, errorPolicy) {
 	jp := parseJoinPolicy(node.Attr("join_policy", ""))
@@ -280,7 +323,7 @@ func dispatchParallelBranchesStreaming(
 		maxParallel = 4
 	}
 
-	var gitMu sync.Mutex
+	gitMu := exec.Engine.sharedGitLock()
 
 	type indexedResult struct {
 		idx    int
@@ -309,7 +352,7 @@ func dispatchParallelBranchesStreaming(
 			if e == nil {
 				continue
 			}
-			res := h.runBranch(cancelCtx, exec, sourceNode, baseSHA, joinID, j.idx, e, &gitMu)
+			res := h.runBranch(cancelCtx, exec, sourceNode, baseSHA, joinID, j.idx, e, gitMu)
 			resultCh <- indexedResult{idx: j.idx, result: res}
 		}
 	}
@@ -348,16 +391,27 @@ func dispatchParallelBranchesStreaming(
 	total := len(branches)
 	received := 0
 	terminated := false
+	var winners, canceled []string
 
 	for ir := range resultCh {
 		results[ir.idx] = ir.result
 		received++
 
-		if ir.result.Outcome.Status == runtime.StatusSuccess || ir.result.Outcome.Status == runtime.StatusPartialSuccess {
+		succeeded := ir.result.Outcome.Status == runtime.StatusSuccess || ir.result.Outcome.Status == runtime.StatusPartialSuccess
+		if succeeded {
 			successSoFar++
 		} else if ir.result.Outcome.Status == runtime.StatusFail {
 			failSoFar++
 		}
+		if terminated {
+			// Branches still running at cancellation finish with the
+			// context error; only a success that raced the cancel counts.
+			if !succeeded {
+				canceled = append(canceled, ir.result.BranchKey)
+			}
+		} else if succeeded {
+			winners = append(winners, ir.result.BranchKey)
+		}
 
 		if !terminated {
 			shouldCancel, reason := earlyTerminationCheck(jp, ep, node, ir.result, successSoFar, failSoFar, total)
@@ -377,6 +431,30 @@ func dispatchParallelBranchesStreaming(
 		}
 	}
 
+	if terminated {
+		// Branches never handed to a worker are canceled too.
+		for idx, e := range branches {
+			if results[idx].BranchKey == "" && e != nil {
+				key := sanitizeRefComponent(e.To)
+				if key == "" {
+					key = fmt.Sprintf("branch-%d", idx+1)
+				}
+				canceled = append(canceled, key)
+			}
+		}
+	}
+	sort.Strings(winners)
+	sort.Strings(canceled)
+	exec.Engine.appendProgress(map[string]any{
+		"event":       "parallel_join_resolved",
+		"node_id":     sourceNodeID,
+		"join_node":   joinID,
+		"join_policy": string(jp),
+		"terminated":  terminated,
+		"winners":     winners,
+		"canceled":    canceled,
+	})
+
 	// Filter out zero-value results from cancelled/unscheduled branches.
 	// When early termination fires, some branches never run — their slots
 	// in the pre-allocated results slice stay at the zero value (BranchKey=="").
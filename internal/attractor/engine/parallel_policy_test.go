@@ -57,6 +57,50 @@ func TestParseErrorPolicy(t *testing.T) {
 	}
 }
 
+// --- parseFanInJoin / joinPolicyNode ---
+
+func TestParseFanInJoin(t *testing.T) {
+	tests := []struct {
+		input string
+		want  joinPolicy
+		k     int
+		ok    bool
+	}{
+		{"all", joinWaitAll, 0, true},
+		{"ANY", joinFirstSuccess, 1, true},
+		{"quorum:2", joinKOfN, 2, true},
+		{" quorum: 3 ", joinKOfN, 3, true},
+		{"quorum:0", "", 0, false},
+		{"quorum", "", 0, false},
+		{"most", "", 0, false},
+	}
+	for _, tc := range tests {
+		got, k, err := parseFanInJoin(tc.input)
+		if (err == nil) != tc.ok || got != tc.want || k != tc.k {
+			t.Errorf("parseFanInJoin(%q) = (%q, %d, %v), want (%q, %d, ok=%v)", tc.input, got, k, err, tc.want, tc.k, tc.ok)
+		}
+	}
+}
+
+func TestJoinPolicyNode_TranslatesFanInJoin(t *testing.T) {
+	par := &model.Node{ID: "par", Attrs: map[string]string{"max_parallel": "2"}}
+	join := &model.Node{ID: "join", Attrs: map[string]string{"join": "quorum:2"}}
+	pn := joinPolicyNode(par, join)
+	jp, _ := parallelPolicies(pn)
+	if jp != joinKOfN || pn.Attr("k", "") != "2" || pn.Attr("max_parallel", "") != "2" {
+		t.Fatalf("policy node attrs: %+v", pn.Attrs)
+	}
+	if _, ok := par.Attrs["join_policy"]; ok {
+		t.Fatalf("fan-out node attrs were mutated: %+v", par.Attrs)
+	}
+
+	// An explicit join_policy on the fan-out node wins.
+	par.Attrs["join_policy"] = "wait_all"
+	if got := joinPolicyNode(par, join); got != par {
+		t.Fatalf("expected fan-out node to be used as-is, got %+v", got.Attrs)
+	}
+}
+
 // --- parallelPolicies ---
 
 func TestParallelPolicies(t *testing.T) {
@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+)
+
+// PlanNode is one node of an ExecutionPlan with its resolved handler type.
+type PlanNode struct {
+	ID    string            `json:"id"`
+	Type  string            `json:"type"`
+	Attrs map[string]string `json:"attrs"`
+}
+
+// PlanEdge is one edge of an ExecutionPlan. Condition is empty for
+// unconditional edges.
+type PlanEdge struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Condition string `json:"condition,omitempty"`
+	Label     string `json:"label,omitempty"`
+}
+
+// ExecutionPlan is a static view of a prepared graph for dry runs. Nodes are
+// the set reachable from the start node, in breadth-first order following
+// edges in declaration order; Unreachable lists the rest by declaration order.
+// Which edges a run actually takes depends on outcomes, so this is the set of
+// nodes that may execute, not a schedule.
+type ExecutionPlan struct {
+	StartNodeID string     `json:"start_node_id"`
+	Nodes       []PlanNode `json:"nodes"`
+	Edges       []PlanEdge `json:"edges"`
+	Unreachable []string   `json:"unreachable,omitempty"`
+}
+
+// BuildExecutionPlan walks a prepared graph without executing anything.
+func BuildExecutionPlan(g *model.Graph) *ExecutionPlan {
+	plan := &ExecutionPlan{StartNodeID: findStartNodeID(g)}
+	seen := map[string]bool{}
+	queue := []string{}
+	if plan.StartNodeID != "" {
+		seen[plan.StartNodeID] = true
+		queue = append(queue, plan.StartNodeID)
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		plan.Nodes = append(plan.Nodes, planNode(g.Nodes[id], id))
+		for _, e := range g.Outgoing(id) {
+			if e == nil || seen[e.To] {
+				continue
+			}
+			if _, ok := g.Nodes[e.To]; !ok {
+				continue
+			}
+			seen[e.To] = true
+			queue = append(queue, e.To)
+		}
+	}
+
+	for _, e := range g.Edges {
+		if e == nil {
+			continue
+		}
+		plan.Edges = append(plan.Edges, PlanEdge{
+			From:      e.From,
+			To:        e.To,
+			Condition: strings.TrimSpace(e.Condition()),
+			Label:     strings.TrimSpace(e.Label()),
+		})
+	}
+
+	var rest []*model.Node
+	for id, n := range g.Nodes {
+		if !seen[id] && n != nil {
+			rest = append(rest, n)
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool {
+		if rest[i].Order != rest[j].Order {
+			return rest[i].Order < rest[j].Order
+		}
+		return rest[i].ID < rest[j].ID
+	})
+	for _, n := range rest {
+		plan.Unreachable = append(plan.Unreachable, n.ID)
+	}
+	return plan
+}
+
+func planNode(n *model.Node, id string) PlanNode {
+	pn := PlanNode{ID: id, Attrs: map[string]string{}}
+	if n == nil {
+		return pn
+	}
+	pn.Type = strings.TrimSpace(n.TypeOverride())
+	if pn.Type == "" {
+		pn.Type = shapeToType(n.Shape())
+	}
+	for k, v := range n.Attrs {
+		pn.Attrs[k] = v
+	}
+	return pn
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/danshapiro/kilroy/internal/attractor/dot"
+)
+
+func TestBuildExecutionPlan_ReachableOrderTypesAndUnreachable(t *testing.T) {
+	g, err := dot.Parse([]byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  a [shape=box, prompt="x"]
+  b [shape=parallelogram, tool_command="true", type="wait.human"]
+  orphan [shape=parallelogram, tool_command="true"]
+  start -> a -> b -> exit
+  a -> exit [condition="outcome=fail"]
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := BuildExecutionPlan(g)
+
+	var ids, types []string
+	for _, n := range plan.Nodes {
+		ids = append(ids, n.ID)
+		types = append(types, n.Type)
+	}
+	if want := []string{"start", "a", "b", "exit"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("node order: got %v want %v", ids, want)
+	}
+	if want := []string{"start", "codergen", "wait.human", "exit"}; !reflect.DeepEqual(types, want) {
+		t.Fatalf("node types: got %v want %v", types, want)
+	}
+	if !reflect.DeepEqual(plan.Unreachable, []string{"orphan"}) {
+		t.Fatalf("unreachable: got %v", plan.Unreachable)
+	}
+	if len(plan.Edges) != 4 || plan.Edges[3] != (PlanEdge{From: "a", To: "exit", Condition: "outcome=fail"}) {
+		t.Fatalf("edges: %+v", plan.Edges)
+	}
+}
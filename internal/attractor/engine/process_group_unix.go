@@ -33,6 +33,8 @@ func terminateProcessGroup(cmd *exec.Cmd) error {
 	return nil
 }
 
+// forceKillProcessGroup SIGKILLs cmd's process group, then any recorded
+// descendants that had left the group.
 func forceKillProcessGroup(cmd *exec.Cmd) error {
 	if cmd == nil || cmd.Process == nil {
 		return nil
@@ -44,6 +46,8 @@ func forceKillProcessGroup(cmd *exec.Cmd) error {
 		}
 		return err
 	}
+	stragglers := snapshotDescendants(cmd.Process.Pid)
+	defer reapStragglers(stragglers)
 	if err := syscall.Kill(-pgid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
 		return err
 	}
@@ -51,5 +55,7 @@ func forceKillProcessGroup(cmd *exec.Cmd) error {
 }
 
 func forceKillPIDTree(pid int) error {
+	stragglers := snapshotDescendants(pid)
+	defer reapStragglers(stragglers)
 	return syscall.Kill(-pid, syscall.SIGKILL)
 }
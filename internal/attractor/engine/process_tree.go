@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"os"
+
+	"github.com/danshapiro/kilroy/internal/attractor/procutil"
+)
+
+// treeProcess is a descendant recorded before a process-group kill, with its
+// start time so a reused pid is not signaled later.
+type treeProcess struct {
+	pid        int
+	startTime  uint64
+	startKnown bool
+}
+
+// snapshotDescendants records the descendants of pid. It must run before the
+// tree is killed: once a parent exits, its children are re-parented and can no
+// longer be found from pid. Errors yield an empty snapshot.
+func snapshotDescendants(pid int) []treeProcess {
+	pids, err := procutil.DescendantPIDs(pid)
+	if err != nil {
+		return nil
+	}
+	out := make([]treeProcess, 0, len(pids))
+	for _, p := range pids {
+		tp := treeProcess{pid: p}
+		if start, err := procutil.ReadPIDStartTime(p); err == nil {
+			tp.startTime, tp.startKnown = start, true
+		}
+		out = append(out, tp)
+	}
+	return out
+}
+
+// reapStragglers force-kills recorded descendants that survived a
+// process-group kill, for example children that called setsid or were started
+// without inheriting the group. Processes whose start time changed are skipped.
+func reapStragglers(procs []treeProcess) {
+	for _, tp := range procs {
+		if !procutil.PIDAlive(tp.pid) {
+			continue
+		}
+		if tp.startKnown {
+			start, err := procutil.ReadPIDStartTime(tp.pid)
+			if err != nil || start != tp.startTime {
+				continue
+			}
+		}
+		if p, err := os.FindProcess(tp.pid); err == nil {
+			_ = p.Kill()
+		}
+	}
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+
+package engine
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/procutil"
+)
+
+func TestForceKillProcessGroup_ReapsDescendantOutsideGroup(t *testing.T) {
+	if !procutil.ProcFSAvailable() {
+		t.Skip("requires procfs")
+	}
+	if _, err := exec.LookPath("setsid"); err != nil {
+		t.Skip("requires setsid")
+	}
+	pidFile := filepath.Join(t.TempDir(), "escaped.pid")
+	// The grandchild moves to its own session, so a group kill misses it.
+	cmd := exec.Command("sh", "-c", `setsid sleep 60 & echo $! > "$1"; wait`, "sh", pidFile)
+	setProcessGroupAttr(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	escaped := mustReadPIDFile(t, pidFile)
+	t.Cleanup(func() { _ = forceKillPIDTree(escaped) })
+
+	if err := forceKillProcessGroup(cmd); err != nil {
+		t.Fatalf("forceKillProcessGroup: %v", err)
+	}
+	_ = cmd.Wait()
+	waitForPIDToExit(t, escaped, 2*time.Second)
+}
@@ -2,11 +2,14 @@ package engine
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
 )
 
 // appendProgress writes compact, machine-readable progress events under logs_root.
@@ -17,10 +20,45 @@ import (
 //
 // This is best-effort: progress logging must never block or fail a run.
 func (e *Engine) appendProgress(ev map[string]any) {
+	e.writeProgress(ev, true)
+}
+
+// withToolResultFields copies a tool command's exit_code, timed_out, and
+// duration_ms from out.Meta into a stage_attempt_end event, when present.
+func withToolResultFields(ev map[string]any, out runtime.Outcome) map[string]any {
+	for _, k := range []string{"exit_code", "timed_out", "duration_ms"} {
+		if v, ok := out.Meta[k]; ok {
+			ev[k] = v
+		}
+	}
+	return ev
+}
+
+// appendWatchdogProgress records a stall watchdog event in progress.ndjson
+// and the sink without counting as activity: it leaves the stall idle timer
+// and live.json (the last real event) untouched.
+func (e *Engine) appendWatchdogProgress(ev map[string]any) {
+	e.writeProgress(ev, false)
+}
+
+// AddProgressSink registers fn to receive every subsequent progress event,
+// after RunOptions.ProgressSink and any sinks added earlier. Each sink gets
+// its own deep copy of the event. Sinks are called synchronously, so they
+// should hand slow work off to a goroutine; a sink that panics is recovered
+// and does not affect the run or the other sinks.
+func (e *Engine) AddProgressSink(fn func(map[string]any)) {
+	if e == nil || fn == nil {
+		return
+	}
+	e.progressMu.Lock()
+	e.progressSinks = append(e.progressSinks, fn)
+	e.progressMu.Unlock()
+}
+
+func (e *Engine) writeProgress(ev map[string]any, activity bool) {
 	if e == nil {
 		return
 	}
-	sink := e.progressSink
 	logsRoot := strings.TrimSpace(e.LogsRoot)
 	if ev == nil {
 		ev = map[string]any{}
@@ -32,11 +70,14 @@ func (e *Engine) appendProgress(ev map[string]any) {
 	if _, ok := ev["run_id"]; !ok && strings.TrimSpace(e.Options.RunID) != "" {
 		ev["run_id"] = e.Options.RunID
 	}
-	sinkEvent := copyMap(ev)
+
+	redactor := e.redactor()
+	e.progressMu.Lock()
+	sinks := e.progressSinks
+	e.progressMu.Unlock()
+	ev = redactor.redactEvent(ev)
 	if logsRoot == "" {
-		if sink != nil {
-			sink(sinkEvent)
-		}
+		deliverProgress(sinks, ev)
 		return
 	}
 
@@ -47,21 +88,60 @@ func (e *Engine) appendProgress(ev map[string]any) {
 
 	e.progressMu.Lock()
 	defer e.progressMu.Unlock()
-	e.lastProgressAt = now
+	if activity {
+		e.lastProgressAt = now
+	}
 
 	// Append to progress.ndjson.
 	// Intentionally open/close on each event so writes are immediately flushed
 	// and resilient to abrupt process termination.
-	if f, err := os.OpenFile(filepath.Join(logsRoot, "progress.ndjson"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644); err == nil {
+	progressPath := filepath.Join(logsRoot, "progress.ndjson")
+	rotateProgressLog(progressPath, int64(len(b)+1), e.Options.ProgressMaxBytes, e.Options.ProgressMaxRolls)
+	if f, err := os.OpenFile(progressPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644); err == nil {
 		_, _ = f.Write(append(b, '\n'))
 		_ = f.Close()
 	}
 
-	// Overwrite live.json with the last event.
-	_ = os.WriteFile(filepath.Join(logsRoot, "live.json"), append(b, '\n'), 0o644)
-	if sink != nil {
-		sink(sinkEvent)
+	if activity {
+		// Overwrite live.json with the last event.
+		_ = os.WriteFile(filepath.Join(logsRoot, "live.json"), append(b, '\n'), 0o644)
+		if e.sharedMetrics().observe(ev, now) {
+			e.writeMetrics()
+		}
 	}
+	deliverProgress(e.progressSinks, ev)
+}
+
+// rotateProgressLog rolls path to path.1, shifting older rolls up and
+// dropping those beyond rolls, when appending incoming bytes would push a
+// non-empty file past maxBytes. Errors are ignored like other progress writes.
+func rotateProgressLog(path string, incoming, maxBytes int64, rolls int) {
+	if maxBytes <= 0 {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() == 0 || info.Size()+incoming <= maxBytes {
+		return
+	}
+	if rolls < 1 {
+		rolls = DefaultProgressMaxRolls
+	}
+	_ = os.Remove(fmt.Sprintf("%s.%d", path, rolls))
+	for i := rolls - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+	}
+	_ = os.Rename(path, path+".1")
+}
+
+func deliverProgress(sinks []func(map[string]any), ev map[string]any) {
+	for _, sink := range sinks {
+		callProgressSink(sink, copyMap(ev))
+	}
+}
+
+func callProgressSink(sink func(map[string]any), ev map[string]any) {
+	defer func() { _ = recover() }()
+	sink(ev)
 }
 
 func (e *Engine) setLastProgressTime(ts time.Time) {
@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/danshapiro/kilroy/internal/agent"
+)
+
+// redactedValue replaces secrets in progress events.
+const redactedValue = "***"
+
+// minRedactedEnvValueLen keeps short environment values (e.g. "1", "true")
+// from being masked wherever they happen to appear in an event.
+const minRedactedEnvValueLen = 8
+
+// assignmentPattern matches NAME=value and NAME: value pairs in free text,
+// with the value optionally quoted.
+var assignmentPattern = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_.-]*)(\s*[=:]\s*)("[^"]*"|'[^']*'|[^\s,;&|]+)`)
+
+// progressRedactor masks secrets in progress events using the same name
+// heuristics that keep credentials out of tool environments: a value is
+// masked when its map key or NAME=value assignment name matches a pattern,
+// and the values of matching process environment variables are masked
+// wherever they appear.
+type progressRedactor struct {
+	patterns  []string
+	envValues []string
+}
+
+func newProgressRedactor(patterns []string) *progressRedactor {
+	if patterns == nil {
+		patterns = agent.DefaultDenyEnvPatterns
+	}
+	r := &progressRedactor{}
+	for _, p := range patterns {
+		if p = strings.ToUpper(strings.TrimSpace(p)); p != "" {
+			r.patterns = append(r.patterns, p)
+		}
+	}
+	if len(r.patterns) == 0 {
+		return r
+	}
+	for _, kv := range os.Environ() {
+		k, v, ok := strings.Cut(kv, "=")
+		if ok && len(v) >= minRedactedEnvValueLen && r.sensitive(k) {
+			r.envValues = append(r.envValues, v)
+		}
+	}
+	// Longest first so a value containing another is masked whole.
+	sort.Slice(r.envValues, func(i, j int) bool { return len(r.envValues[i]) > len(r.envValues[j]) })
+	return r
+}
+
+// redactor returns the engine's redactor, built from
+// Options.ProgressRedactPatterns on first use.
+func (e *Engine) redactor() *progressRedactor {
+	e.progressMu.Lock()
+	defer e.progressMu.Unlock()
+	if e.progressRedactor == nil {
+		e.progressRedactor = newProgressRedactor(e.Options.ProgressRedactPatterns)
+	}
+	return e.progressRedactor
+}
+
+func (r *progressRedactor) enabled() bool {
+	return r != nil && len(r.patterns) > 0
+}
+
+func (r *progressRedactor) sensitive(name string) bool {
+	un := strings.ToUpper(name)
+	for _, p := range r.patterns {
+		if strings.Contains(un, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactEvent returns a copy of ev with secrets masked. ev is not modified.
+func (r *progressRedactor) redactEvent(ev map[string]any) map[string]any {
+	if !r.enabled() {
+		return ev
+	}
+	return r.redactMap(ev)
+}
+
+func (r *progressRedactor) redactMap(in map[string]any) map[string]any {
+	out := make(map[string]any, len(in))
+	for k, v := range in {
+		// Only strings are masked: numbers under matching keys are counts
+		// such as input_tokens, not secrets.
+		if str, ok := v.(string); ok && str != "" && r.sensitive(k) {
+			out[k] = redactedValue
+			continue
+		}
+		out[k] = r.redactValue(v)
+	}
+	return out
+}
+
+func (r *progressRedactor) redactValue(v any) any {
+	switch typed := v.(type) {
+	case string:
+		return r.redactString(typed)
+	case map[string]any:
+		return r.redactMap(typed)
+	case map[string]string:
+		out := make(map[string]string, len(typed))
+		for k, s := range typed {
+			if r.sensitive(k) && s != "" {
+				out[k] = redactedValue
+			} else {
+				out[k] = r.redactString(s)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(typed))
+		for i := range typed {
+			out[i] = r.redactValue(typed[i])
+		}
+		return out
+	case []string:
+		out := make([]string, len(typed))
+		for i := range typed {
+			out[i] = r.redactString(typed[i])
+		}
+		return out
+	}
+	return v
+}
+
+func (r *progressRedactor) redactString(s string) string {
+	for _, v := range r.envValues {
+		s = strings.ReplaceAll(s, v, redactedValue)
+	}
+	return assignmentPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := assignmentPattern.FindStringSubmatch(m)
+		if !r.sensitive(sub[1]) || isDigits(sub[3]) {
+			return m
+		}
+		return sub[1] + sub[2] + redactedValue
+	})
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
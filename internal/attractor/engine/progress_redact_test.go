@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProgressRedaction_MasksSecretsInFileAndSink(t *testing.T) {
+	t.Setenv("KILROY_TEST_API_KEY", "sk-live-abcdef123456")
+	dir := t.TempDir()
+	var got map[string]any
+	e := &Engine{
+		LogsRoot: dir,
+		Options: RunOptions{
+			RunID:        "r1",
+			ProgressSink: func(ev map[string]any) { got = ev },
+		},
+	}
+	e.progressSinks = []func(map[string]any){e.Options.ProgressSink}
+
+	ev := map[string]any{
+		"event":        "tool_command",
+		"command":      "GITHUB_TOKEN=ghp_secretvalue make deploy DB_PASSWORD: \"p@ss w0rd\"",
+		"message":      "calling api with sk-live-abcdef123456",
+		"env":          map[string]string{"SERVICE_SECRET": "s3cr3t", "HOME": "/home/u"},
+		"args":         []string{"--api", "STRIPE_API_KEY=rk_123abc"},
+		"input_tokens": 42,
+		"note":         "input_tokens=1200 used",
+	}
+	e.appendProgress(ev)
+
+	if ev["command"] == nil || !strings.Contains(ev["command"].(string), "ghp_secretvalue") {
+		t.Fatalf("caller's event map should not be modified: %#v", ev)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "progress.ndjson"))
+	if err != nil {
+		t.Fatalf("read progress.ndjson: %v", err)
+	}
+	live, err := os.ReadFile(filepath.Join(dir, "live.json"))
+	if err != nil {
+		t.Fatalf("read live.json: %v", err)
+	}
+	for name, text := range map[string]string{"progress.ndjson": string(b), "live.json": string(live)} {
+		for _, secret := range []string{"ghp_secretvalue", "p@ss w0rd", "sk-live-abcdef123456", "s3cr3t", "rk_123abc"} {
+			if strings.Contains(text, secret) {
+				t.Fatalf("%s leaks %q: %s", name, secret, text)
+			}
+		}
+		if !strings.Contains(text, "/home/u") || !strings.Contains(text, "input_tokens=1200") {
+			t.Fatalf("%s over-redacted: %s", name, text)
+		}
+	}
+
+	if got == nil {
+		t.Fatal("sink did not receive the event")
+	}
+	if cmd := got["command"].(string); !strings.HasPrefix(cmd, "GITHUB_TOKEN=*** make deploy") || strings.Contains(cmd, "p@ss") {
+		t.Fatalf("sink command: %q", cmd)
+	}
+	if msg := got["message"].(string); msg != "calling api with ***" {
+		t.Fatalf("sink message: %q", msg)
+	}
+	if env := got["env"].(map[string]string); env["SERVICE_SECRET"] != "***" || env["HOME"] != "/home/u" {
+		t.Fatalf("sink env: %#v", env)
+	}
+	if args := got["args"].([]string); args[1] != "STRIPE_API_KEY=***" {
+		t.Fatalf("sink args: %#v", args)
+	}
+	if got["input_tokens"] != 42 {
+		t.Fatalf("token counts should not be redacted: %#v", got["input_tokens"])
+	}
+}
+
+func TestProgressRedaction_CustomAndDisabledPatterns(t *testing.T) {
+	var got []map[string]any
+	sink := func(ev map[string]any) { got = append(got, ev) }
+
+	custom := &Engine{Options: RunOptions{ProgressRedactPatterns: []string{"session"}}}
+	custom.AddProgressSink(sink)
+	custom.appendProgress(map[string]any{"event": "x", "detail": "SESSION_ID=abc123 API_KEY=k1"})
+
+	off := &Engine{Options: RunOptions{ProgressRedactPatterns: []string{}}}
+	off.AddProgressSink(sink)
+	off.appendProgress(map[string]any{"event": "x", "detail": "API_KEY=k1"})
+
+	if len(got) != 2 {
+		t.Fatalf("events: %#v", got)
+	}
+	if d := got[0]["detail"]; d != "SESSION_ID=*** API_KEY=k1" {
+		t.Fatalf("custom patterns: %q", d)
+	}
+	if d := got[1]["detail"]; d != "API_KEY=k1" {
+		t.Fatalf("disabled redaction: %q", d)
+	}
+}
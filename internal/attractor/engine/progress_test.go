@@ -74,6 +74,84 @@ func TestEngine_appendProgress_WritesNDJSONAndLiveSnapshot(t *testing.T) {
 	}
 }
 
+func TestEngine_AddProgressSink_FansOutToEverySink(t *testing.T) {
+	var first, second []string
+	e := &Engine{
+		LogsRoot: t.TempDir(),
+		Options:  RunOptions{RunID: "r1"},
+	}
+	e.progressSinks = []func(map[string]any){func(ev map[string]any) {
+		first = append(first, ev["event"].(string))
+		ev["event"] = "mutated"
+	}}
+	e.AddProgressSink(func(map[string]any) { panic("sink failure") })
+	e.AddProgressSink(func(ev map[string]any) {
+		second = append(second, ev["event"].(string))
+	})
+
+	e.appendProgress(map[string]any{"event": "one"})
+	e.appendWatchdogProgress(map[string]any{"event": "two"})
+
+	want := []string{"one", "two"}
+	if strings.Join(first, ",") != strings.Join(want, ",") {
+		t.Fatalf("first sink events: got %v want %v", first, want)
+	}
+	// The second sink still runs after a panicking sink and sees its own copy
+	// of the event, unaffected by the first sink's mutation.
+	if strings.Join(second, ",") != strings.Join(want, ",") {
+		t.Fatalf("second sink events: got %v want %v", second, want)
+	}
+}
+
+func TestEngine_appendProgress_RotatesAtSizeCap(t *testing.T) {
+	dir := t.TempDir()
+	e := &Engine{
+		LogsRoot: dir,
+		Options:  RunOptions{RunID: "r1", ProgressMaxBytes: 300, ProgressMaxRolls: 2},
+	}
+	for i := 0; i < 20; i++ {
+		e.appendProgress(map[string]any{"event": "tick", "n": i})
+	}
+
+	path := filepath.Join(dir, "progress.ndjson")
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("stat %s: %v", p, err)
+		}
+		if info.Size() > 300 {
+			t.Fatalf("%s is %d bytes, over the 300 byte cap", p, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected at most 2 rolls, stat .3: %v", err)
+	}
+
+	// The newest event is the last line of the active file, and events
+	// continue in order from the newest roll into the active file.
+	lastOf := func(p string) map[string]any {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("read %s: %v", p, err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		var ev map[string]any
+		if err := json.Unmarshal([]byte(lines[len(lines)-1]), &ev); err != nil {
+			t.Fatalf("decode %s: %v", p, err)
+		}
+		return ev
+	}
+	if n := lastOf(path)["n"]; n != float64(19) {
+		t.Fatalf("last event n=%v, want 19", n)
+	}
+	b, _ := os.ReadFile(path)
+	var first map[string]any
+	_ = json.Unmarshal([]byte(strings.SplitN(string(b), "\n", 2)[0]), &first)
+	if rolled := lastOf(path + ".1")["n"].(float64); first["n"] != rolled+1 {
+		t.Fatalf("active file starts at n=%v, newest roll ends at n=%v", first["n"], rolled)
+	}
+}
+
 func TestProgressIncludesStatusIngestionDecisionEvent(t *testing.T) {
 	events := runStatusIngestionProgressFixture(t)
 	if !hasEvent(events, "status_ingestion_decision") {
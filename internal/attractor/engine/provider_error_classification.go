@@ -218,6 +218,14 @@ func classifyAPIError(err error) (failureClass string, failureSignature string)
 	provider := "api"
 	detail := "unknown"
 
+	var callTimeoutErr *codergenCallTimeoutError
+	if errors.As(err, &callTimeoutErr) {
+		if p := strings.TrimSpace(callTimeoutErr.provider); p != "" {
+			provider = p
+		}
+		return failureClassTransientInfra, fmt.Sprintf("api_transient|%s|call_timeout", provider)
+	}
+
 	var abortErr *llm.AbortError
 	if errors.As(err, &abortErr) {
 		if p := strings.TrimSpace(abortErr.Provider()); p != "" {
@@ -12,6 +12,7 @@ import (
 const (
 	executableSourceDefault          = "default"
 	executableSourceConfigExecutable = "config.executable"
+	executableSourceEnv              = "env"
 )
 
 type providerExecutableResolution struct {
@@ -38,10 +39,16 @@ func resolveProviderExecutable(cfg *RunConfigFile, provider string, opts RunOpti
 	switch profile {
 	case "real":
 		if overrides := configuredProviderPathOverrides(); len(overrides) > 0 {
-			return providerExecutableResolution{}, fmt.Errorf("llm.cli_profile=real forbids provider path overrides via %s (unset overrides or use llm.cli_profile=test_shim with --allow-test-shim)", strings.Join(overrides, ", "))
+			return providerExecutableResolution{}, fmt.Errorf("llm.cli_profile=real forbids provider path overrides via %s; these env vars are honored only under llm.cli_profile=test_shim (unset them, set llm.providers.<provider>.executable for a nonstandard install, or use llm.cli_profile=test_shim with --allow-test-shim)", strings.Join(overrides, ", "))
 		}
+		// An executable set in the run config is an explicit choice (e.g. a
+		// wrapper script on a nonstandard install), unlike an ambient env var,
+		// so the real profile honors it; the run manifest records it.
 		if providerCfg, _, exists := providerConfigFor(cfg, provider); exists && strings.TrimSpace(providerCfg.Executable) != "" {
-			return providerExecutableResolution{}, fmt.Errorf("llm.providers.%s.executable is only allowed when llm.cli_profile=test_shim", normalizeProviderKey(provider))
+			return providerExecutableResolution{
+				Executable: strings.TrimSpace(providerCfg.Executable),
+				Source:     executableSourceConfigExecutable,
+			}, nil
 		}
 		return providerExecutableResolution{Executable: defaultExe, Source: executableSourceDefault}, nil
 	case "test_shim":
@@ -49,18 +56,50 @@ func resolveProviderExecutable(cfg *RunConfigFile, provider string, opts RunOpti
 			return providerExecutableResolution{}, fmt.Errorf("llm.cli_profile=test_shim requires --allow-test-shim")
 		}
 		providerCfg, providerKey, exists := providerConfigFor(cfg, provider)
-		if !exists || strings.TrimSpace(providerCfg.Executable) == "" {
-			return providerExecutableResolution{}, fmt.Errorf("llm.providers.%s.executable is required when llm.cli_profile=test_shim", providerKey)
+		if exists && strings.TrimSpace(providerCfg.Executable) != "" {
+			return providerExecutableResolution{
+				Executable: strings.TrimSpace(providerCfg.Executable),
+				Source:     executableSourceConfigExecutable,
+			}, nil
 		}
-		return providerExecutableResolution{
-			Executable: strings.TrimSpace(providerCfg.Executable),
-			Source:     executableSourceConfigExecutable,
-		}, nil
+		// The provider's path env var (e.g. KILROY_CLAUDE_PATH) can point at
+		// a wrapper script instead of writing it into the run config.
+		envKey := providerPathOverrideEnvKey(provider)
+		if envKey != "" {
+			if v := strings.TrimSpace(os.Getenv(envKey)); v != "" {
+				return providerExecutableResolution{Executable: v, Source: executableSourceEnv}, nil
+			}
+			return providerExecutableResolution{}, fmt.Errorf("llm.providers.%s.executable (or %s) is required when llm.cli_profile=test_shim", providerKey, envKey)
+		}
+		return providerExecutableResolution{}, fmt.Errorf("llm.providers.%s.executable is required when llm.cli_profile=test_shim", providerKey)
 	default:
 		return providerExecutableResolution{}, fmt.Errorf("invalid llm.cli_profile: %q (want real|test_shim)", profile)
 	}
 }
 
+// providerExecutableOverrides returns, per CLI provider, the executable that
+// replaces its canonical binary and where it came from, for the run manifest.
+func providerExecutableOverrides(cfg *RunConfigFile, opts RunOptions) map[string]any {
+	if cfg == nil {
+		return nil
+	}
+	out := map[string]any{}
+	for k, pc := range cfg.LLM.Providers {
+		if pc.Backend != BackendCLI {
+			continue
+		}
+		resolution, err := resolveProviderExecutable(cfg, k, opts)
+		if err != nil || resolution.Source == executableSourceDefault {
+			continue
+		}
+		out[normalizeProviderKey(k)] = map[string]any{
+			"executable": resolution.Executable,
+			"source":     resolution.Source,
+		}
+	}
+	return out
+}
+
 func validateRunCLIProfilePolicy(cfg *RunConfigFile, opts RunOptions, runUsesCLIProviders bool) error {
 	switch normalizedCLIProfile(cfg) {
 	case "real":
@@ -68,7 +107,7 @@ func validateRunCLIProfilePolicy(cfg *RunConfigFile, opts RunOptions, runUsesCLI
 			return nil
 		}
 		if overrides := configuredProviderPathOverrides(); len(overrides) > 0 {
-			return fmt.Errorf("preflight: llm.cli_profile=real forbids provider path overrides via %s (unset overrides or use llm.cli_profile=test_shim with --allow-test-shim)", strings.Join(overrides, ", "))
+			return fmt.Errorf("preflight: llm.cli_profile=real forbids provider path overrides via %s; these env vars are honored only under llm.cli_profile=test_shim (unset them, set llm.providers.<provider>.executable for a nonstandard install, or use llm.cli_profile=test_shim with --allow-test-shim)", strings.Join(overrides, ", "))
 		}
 		return nil
 	case "test_shim":
@@ -109,10 +148,10 @@ func providerConfigFor(cfg *RunConfigFile, provider string) (ProviderConfig, str
 }
 
 func configuredProviderPathOverrides() []string {
-	keys := []string{"KILROY_CODEX_PATH", "KILROY_CLAUDE_PATH", "KILROY_GEMINI_PATH"}
 	var set []string
-	for _, key := range keys {
-		if strings.TrimSpace(os.Getenv(key)) != "" {
+	for _, provider := range providerspec.CLIProviders() {
+		key := providerPathOverrideEnvKey(provider)
+		if key != "" && strings.TrimSpace(os.Getenv(key)) != "" {
 			set = append(set, key)
 		}
 	}
@@ -148,9 +187,27 @@ func providerPathOverrideEnvKey(provider string) string {
 		return "KILROY_CLAUDE_PATH"
 	case "google":
 		return "KILROY_GEMINI_PATH"
-	default:
+	}
+	if defaultCLISpecForProvider(provider) == nil {
 		return ""
 	}
+	return "KILROY_" + strings.ToUpper(normalizeProviderKey(provider)) + "_PATH"
+}
+
+// providerExtraArgs returns llm.providers.<provider>.extra_args, which are
+// appended to the provider's builtin CLI invocation.
+func providerExtraArgs(cfg *RunConfigFile, provider string) []string {
+	providerCfg, _, exists := providerConfigFor(cfg, provider)
+	if !exists {
+		return nil
+	}
+	var out []string
+	for _, arg := range providerCfg.ExtraArgs {
+		if arg = strings.TrimSpace(arg); arg != "" {
+			out = append(out, arg)
+		}
+	}
+	return out
 }
 
 func materializeCLIInvocation(spec providerspec.CLISpec, modelID, worktree, prompt string) (string, []string) {
@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -23,6 +24,9 @@ func TestResolveProviderExecutable_RealRejectsEnvOverrides(t *testing.T) {
 	if !strings.Contains(err.Error(), "KILROY_CODEX_PATH") {
 		t.Fatalf("expected env key in error, got %v", err)
 	}
+	if !strings.Contains(err.Error(), "honored only under llm.cli_profile=test_shim") {
+		t.Fatalf("expected error to say env overrides are test_shim only, got %v", err)
+	}
 }
 
 func TestResolveProviderExecutable_RealReturnsCanonicalDefaults(t *testing.T) {
@@ -56,6 +60,31 @@ func TestResolveProviderExecutable_RealReturnsCanonicalDefaults(t *testing.T) {
 	}
 }
 
+func TestResolveProviderExecutable_RealHonorsConfigExecutable(t *testing.T) {
+	cfg := &RunConfigFile{}
+	cfg.LLM.CLIProfile = "real"
+	cfg.LLM.Providers = map[string]ProviderConfig{
+		"openai":    {Backend: BackendCLI, Executable: "/opt/wrappers/codex"},
+		"anthropic": {Backend: BackendCLI},
+	}
+
+	got, err := resolveProviderExecutable(cfg, "openai", RunOptions{})
+	if err != nil {
+		t.Fatalf("resolveProviderExecutable: %v", err)
+	}
+	if got.Executable != "/opt/wrappers/codex" || got.Source != executableSourceConfigExecutable {
+		t.Fatalf("resolution: got %+v", got)
+	}
+
+	overrides := providerExecutableOverrides(cfg, RunOptions{})
+	want := map[string]any{
+		"openai": map[string]any{"executable": "/opt/wrappers/codex", "source": executableSourceConfigExecutable},
+	}
+	if !reflect.DeepEqual(overrides, want) {
+		t.Fatalf("manifest overrides: got %#v want %#v", overrides, want)
+	}
+}
+
 func TestResolveProviderExecutable_TestShimRequiresAllowFlag(t *testing.T) {
 	cfg := &RunConfigFile{}
 	cfg.LLM.CLIProfile = "test_shim"
@@ -88,6 +117,46 @@ func TestResolveProviderExecutable_TestShimRequiresExplicitExecutable(t *testing
 	}
 }
 
+func TestResolveProviderExecutable_TestShimUsesProviderPathEnv(t *testing.T) {
+	t.Setenv("KILROY_CLAUDE_PATH", "/opt/wrappers/claude")
+	t.Setenv("KILROY_AIDER_PATH", "/opt/wrappers/aider")
+
+	cfg := &RunConfigFile{}
+	cfg.LLM.CLIProfile = "test_shim"
+	cfg.LLM.Providers = map[string]ProviderConfig{
+		"anthropic": {Backend: BackendCLI},
+		"aider":     {Backend: BackendCLI},
+		"openai":    {Backend: BackendCLI, Executable: "/tmp/fake/codex"},
+	}
+
+	tests := []struct {
+		provider   string
+		wantExe    string
+		wantSource string
+	}{
+		{provider: "anthropic", wantExe: "/opt/wrappers/claude", wantSource: executableSourceEnv},
+		{provider: "aider", wantExe: "/opt/wrappers/aider", wantSource: executableSourceEnv},
+		{provider: "openai", wantExe: "/tmp/fake/codex", wantSource: executableSourceConfigExecutable},
+	}
+	for _, tc := range tests {
+		t.Run(tc.provider, func(t *testing.T) {
+			got, err := resolveProviderExecutable(cfg, tc.provider, RunOptions{AllowTestShim: true})
+			if err != nil {
+				t.Fatalf("resolveProviderExecutable: %v", err)
+			}
+			if got.Executable != tc.wantExe || got.Source != tc.wantSource {
+				t.Fatalf("resolution=%+v want executable=%q source=%q", got, tc.wantExe, tc.wantSource)
+			}
+		})
+	}
+
+	cfg.LLM.CLIProfile = "real"
+	_, err := ResolveProviderExecutable(cfg, "anthropic", RunOptions{})
+	if err == nil || !strings.Contains(err.Error(), "KILROY_AIDER_PATH") || !strings.Contains(err.Error(), "KILROY_CLAUDE_PATH") {
+		t.Fatalf("expected real profile to reject provider path env overrides, got %v", err)
+	}
+}
+
 func TestDefaultCLIInvocation_UsesSpecTemplate(t *testing.T) {
 	spec := providerspec.CLISpec{
 		DefaultExecutable:  "mycli",
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/danshapiro/kilroy/internal/attractor/model"
@@ -67,6 +68,14 @@ type preflightAPIPromptProbeTarget struct {
 	Request   llm.Request
 }
 
+// preflightAPIPromptProbeJob is one concurrently run prompt probe and its
+// outcome.
+type preflightAPIPromptProbeJob struct {
+	target preflightAPIPromptProbeTarget
+	probe  preflightAPIPromptProbeResult
+	err    error
+}
+
 type preflightAPIPromptProbeResult struct {
 	Text       string
 	Transport  string
@@ -247,8 +256,9 @@ func runProviderAPIPreflight(ctx context.Context, g *model.Graph, runtimes map[s
 		})
 		return fmt.Errorf("preflight: %w", err)
 	}
-	policy := preflightAPIPromptProbePolicyFromConfig(cfg)
+	policy := preflightAPIPromptProbePolicyFromConfig(cfg, opts)
 
+	var jobs []preflightAPIPromptProbeJob
 	for _, provider := range providers {
 		if !available[provider] {
 			report.addCheck(providerPreflightCheck{
@@ -286,51 +296,48 @@ func runProviderAPIPreflight(ctx context.Context, g *model.Graph, runtimes map[s
 			continue
 		}
 		for _, target := range targets {
-			probe, probeErr := runProviderAPIPromptProbeTargetWithPolicy(ctx, client, target, policy)
-			effectiveTransport := strings.TrimSpace(probe.Transport)
-			if effectiveTransport == "" {
-				effectiveTransport = strings.TrimSpace(target.Transport)
-			}
-			if effectiveTransport == "" {
-				effectiveTransport = preflightAPIPromptProbeTransportComplete
-			}
-			if probeErr != nil {
-				status := preflightStatusFail
-				if effectiveTransport == preflightAPIPromptProbeTransportStream && !explicitTransports {
-					// Default transport coverage should not block startup when a
-					// provider lacks a reliable stream preflight path.
-					status = preflightStatusWarn
-				}
-				details := map[string]any{
-					"backend":   "api",
-					"model":     target.Model,
-					"mode":      target.Mode,
-					"transport": effectiveTransport,
-				}
-				if probe.MaxTokens > 0 {
-					details["max_tokens"] = probe.MaxTokens
-				}
-				if strings.TrimSpace(probe.PolicyHint) != "" {
-					details["policy_reason"] = probe.PolicyHint
-				}
-				report.addCheck(providerPreflightCheck{
-					Name:     "provider_prompt_probe",
-					Provider: provider,
-					Status:   status,
-					Message:  fmt.Sprintf("prompt probe failed for model %s (mode=%s transport=%s): %v", target.Model, target.Mode, effectiveTransport, probeErr),
-					Details:  details,
-				})
-				if status == preflightStatusFail {
-					return fmt.Errorf("preflight: provider %s api prompt probe failed for model %s (mode=%s transport=%s): %w", provider, target.Model, target.Mode, effectiveTransport, probeErr)
-				}
-				continue
+			jobs = append(jobs, preflightAPIPromptProbeJob{target: target})
+		}
+	}
+
+	// Probe every provider/model target concurrently, each under the same
+	// timeout/retry policy, so a run with several providers pays for the
+	// slowest probe rather than the sum of them.
+	var wg sync.WaitGroup
+	for i := range jobs {
+		wg.Add(1)
+		go func(job *preflightAPIPromptProbeJob) {
+			defer wg.Done()
+			job.probe, job.err = runProviderAPIPromptProbeTargetWithPolicy(ctx, client, job.target, policy)
+		}(&jobs[i])
+	}
+	wg.Wait()
+
+	// Report in target order and fail with every failed probe, not just the
+	// first, so all unreachable or misconfigured providers surface at once.
+	var failures []error
+	for _, job := range jobs {
+		target, probe, probeErr := job.target, job.probe, job.err
+		provider := target.Provider
+		effectiveTransport := strings.TrimSpace(probe.Transport)
+		if effectiveTransport == "" {
+			effectiveTransport = strings.TrimSpace(target.Transport)
+		}
+		if effectiveTransport == "" {
+			effectiveTransport = preflightAPIPromptProbeTransportComplete
+		}
+		if probeErr != nil {
+			status := preflightStatusFail
+			if effectiveTransport == preflightAPIPromptProbeTransportStream && !explicitTransports {
+				// Default transport coverage should not block startup when a
+				// provider lacks a reliable stream preflight path.
+				status = preflightStatusWarn
 			}
 			details := map[string]any{
-				"backend":          "api",
-				"model":            target.Model,
-				"mode":             target.Mode,
-				"transport":        effectiveTransport,
-				"response_preview": truncate(strings.TrimSpace(probe.Text), 64),
+				"backend":   "api",
+				"model":     target.Model,
+				"mode":      target.Mode,
+				"transport": effectiveTransport,
 			}
 			if probe.MaxTokens > 0 {
 				details["max_tokens"] = probe.MaxTokens
@@ -341,13 +348,44 @@ func runProviderAPIPreflight(ctx context.Context, g *model.Graph, runtimes map[s
 			report.addCheck(providerPreflightCheck{
 				Name:     "provider_prompt_probe",
 				Provider: provider,
-				Status:   preflightStatusPass,
-				Message:  fmt.Sprintf("prompt probe succeeded for model %s (mode=%s transport=%s)", target.Model, target.Mode, effectiveTransport),
+				Status:   status,
+				Message:  fmt.Sprintf("prompt probe failed for model %s (mode=%s transport=%s): %v", target.Model, target.Mode, effectiveTransport, probeErr),
 				Details:  details,
 			})
+			if status == preflightStatusFail {
+				failures = append(failures, fmt.Errorf("preflight: provider %s api prompt probe failed for model %s (mode=%s transport=%s): %w", provider, target.Model, target.Mode, effectiveTransport, probeErr))
+			}
+			continue
+		}
+		details := map[string]any{
+			"backend":          "api",
+			"model":            target.Model,
+			"mode":             target.Mode,
+			"transport":        effectiveTransport,
+			"response_preview": truncate(strings.TrimSpace(probe.Text), 64),
 		}
+		if probe.MaxTokens > 0 {
+			details["max_tokens"] = probe.MaxTokens
+		}
+		if strings.TrimSpace(probe.PolicyHint) != "" {
+			details["policy_reason"] = probe.PolicyHint
+		}
+		report.addCheck(providerPreflightCheck{
+			Name:     "provider_prompt_probe",
+			Provider: provider,
+			Status:   preflightStatusPass,
+			Message:  fmt.Sprintf("prompt probe succeeded for model %s (mode=%s transport=%s)", target.Model, target.Mode, effectiveTransport),
+			Details:  details,
+		})
+	}
+	switch len(failures) {
+	case 0:
+		return nil
+	case 1:
+		return failures[0]
+	default:
+		return fmt.Errorf("preflight: %d api prompt probes failed:\n%w", len(failures), errors.Join(failures...))
 	}
-	return nil
 }
 
 func runProviderAPIPromptProbe(ctx context.Context, client *llm.Client, provider string, modelID string) (string, error) {
@@ -453,10 +491,12 @@ func preflightAPIPromptProbePolicyFromEnv() preflightAPIPromptProbePolicy {
 	return p
 }
 
-func preflightAPIPromptProbePolicyFromConfig(cfg *RunConfigFile) preflightAPIPromptProbePolicy {
+// preflightAPIPromptProbePolicyFromConfig layers the run config and then the
+// RunOptions probe fields over the env policy.
+func preflightAPIPromptProbePolicyFromConfig(cfg *RunConfigFile, opts RunOptions) preflightAPIPromptProbePolicy {
 	p := preflightAPIPromptProbePolicyFromEnv()
 	if cfg == nil {
-		return p
+		return p.withOptions(opts)
 	}
 	if v := cfg.Preflight.PromptProbes.TimeoutMS; v != nil && *v > 0 {
 		p.Timeout = time.Duration(*v) * time.Millisecond
@@ -470,6 +510,24 @@ func preflightAPIPromptProbePolicyFromConfig(cfg *RunConfigFile) preflightAPIPro
 	if v := cfg.Preflight.PromptProbes.MaxDelayMS; v != nil && *v > 0 {
 		p.MaxDelay = time.Duration(*v) * time.Millisecond
 	}
+	return p.withOptions(opts)
+}
+
+// withOptions overrides p with the RunOptions probe fields that are set,
+// keeping the max delay no smaller than the base delay.
+func (p preflightAPIPromptProbePolicy) withOptions(opts RunOptions) preflightAPIPromptProbePolicy {
+	if opts.PromptProbeTimeout > 0 {
+		p.Timeout = opts.PromptProbeTimeout
+	}
+	if opts.PromptProbeRetries != nil && *opts.PromptProbeRetries >= 0 {
+		p.Retries = *opts.PromptProbeRetries
+	}
+	if opts.PromptProbeBaseDelay > 0 {
+		p.BaseDelay = opts.PromptProbeBaseDelay
+	}
+	if opts.PromptProbeMaxDelay > 0 {
+		p.MaxDelay = opts.PromptProbeMaxDelay
+	}
 	if p.MaxDelay < p.BaseDelay {
 		p.MaxDelay = p.BaseDelay
 	}
@@ -931,7 +989,7 @@ type preflightCLIPromptProbePolicy struct {
 	MaxDelay  time.Duration
 }
 
-func preflightCLIPromptProbePolicyFromConfig(cfg *RunConfigFile) preflightCLIPromptProbePolicy {
+func preflightCLIPromptProbePolicyFromConfig(cfg *RunConfigFile, opts RunOptions) preflightCLIPromptProbePolicy {
 	p := preflightCLIPromptProbePolicy{
 		Timeout:   defaultPreflightAPIPromptProbeTimeout,
 		Retries:   0,
@@ -939,7 +997,7 @@ func preflightCLIPromptProbePolicyFromConfig(cfg *RunConfigFile) preflightCLIPro
 		MaxDelay:  defaultPreflightAPIPromptProbeMaxDelay,
 	}
 	if cfg == nil {
-		return p
+		return preflightCLIPromptProbePolicy(preflightAPIPromptProbePolicy(p).withOptions(opts))
 	}
 	if v := cfg.Preflight.PromptProbes.TimeoutMS; v != nil && *v > 0 {
 		p.Timeout = time.Duration(*v) * time.Millisecond
@@ -953,7 +1011,7 @@ func preflightCLIPromptProbePolicyFromConfig(cfg *RunConfigFile) preflightCLIPro
 	if v := cfg.Preflight.PromptProbes.MaxDelayMS; v != nil && *v > 0 {
 		p.MaxDelay = time.Duration(*v) * time.Millisecond
 	}
-	return p
+	return preflightCLIPromptProbePolicy(preflightAPIPromptProbePolicy(p).withOptions(opts))
 }
 
 func preflightCLIPromptProbeBackoff(policy preflightCLIPromptProbePolicy, retryAttempt int) time.Duration {
@@ -982,7 +1040,7 @@ func runProviderCLIPromptProbe(ctx context.Context, provider string, modelID str
 	if strings.TrimSpace(modelID) == "" {
 		return "", fmt.Errorf("model id is empty")
 	}
-	policy := preflightCLIPromptProbePolicyFromConfig(cfg)
+	policy := preflightCLIPromptProbePolicyFromConfig(cfg, opts)
 	if policy.Timeout <= 0 {
 		policy.Timeout = defaultPreflightAPIPromptProbeTimeout
 	}
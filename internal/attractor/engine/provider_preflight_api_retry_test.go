@@ -283,3 +283,40 @@ func TestRunProviderAPIPromptProbe_NonKimiUsesCompleteTransport(t *testing.T) {
 		t.Fatalf("captured max_tokens=%#v want 16", captured.MaxTokens)
 	}
 }
+
+func TestPreflightAPIPromptProbePolicy_RunOptionsOverrideConfigAndEnv(t *testing.T) {
+	t.Setenv("KILROY_PREFLIGHT_API_PROMPT_PROBE_TIMEOUT_MS", "100")
+	t.Setenv("KILROY_PREFLIGHT_API_PROMPT_PROBE_RETRIES", "2")
+	t.Setenv("KILROY_PREFLIGHT_API_PROMPT_PROBE_BASE_DELAY_MS", "10")
+	t.Setenv("KILROY_PREFLIGHT_API_PROMPT_PROBE_MAX_DELAY_MS", "20")
+
+	cfg := &RunConfigFile{}
+	cfgTimeout := 300
+	cfg.Preflight.PromptProbes.TimeoutMS = &cfgTimeout
+
+	// Env supplies what neither config nor options set.
+	p := preflightAPIPromptProbePolicyFromConfig(cfg, RunOptions{})
+	want := preflightAPIPromptProbePolicy{Timeout: 300 * time.Millisecond, Retries: 2, BaseDelay: 10 * time.Millisecond, MaxDelay: 20 * time.Millisecond}
+	if p != want {
+		t.Fatalf("policy without options = %+v, want %+v", p, want)
+	}
+
+	// An explicit zero retries disables retries; a base delay above the
+	// inherited max raises the max.
+	zero := 0
+	p = preflightAPIPromptProbePolicyFromConfig(cfg, RunOptions{
+		PromptProbeTimeout:   2 * time.Second,
+		PromptProbeRetries:   &zero,
+		PromptProbeBaseDelay: 50 * time.Millisecond,
+	})
+	want = preflightAPIPromptProbePolicy{Timeout: 2 * time.Second, Retries: 0, BaseDelay: 50 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	if p != want {
+		t.Fatalf("policy with options = %+v, want %+v", p, want)
+	}
+
+	retries := 4
+	cli := preflightCLIPromptProbePolicyFromConfig(nil, RunOptions{PromptProbeRetries: &retries, PromptProbeMaxDelay: time.Second})
+	if cli.Retries != 4 || cli.MaxDelay != time.Second || cli.Timeout != defaultPreflightAPIPromptProbeTimeout {
+		t.Fatalf("cli policy = %+v", cli)
+	}
+}
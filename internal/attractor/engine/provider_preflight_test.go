@@ -794,6 +794,102 @@ func TestRunWithConfig_PreflightPromptProbe_APIAgentLoopShape_UsesTools(t *testi
 	}
 }
 
+func TestRunWithConfig_PreflightPromptProbe_ProbesProvidersConcurrentlyAndReportsAllFailures(t *testing.T) {
+	t.Setenv("KILROY_PREFLIGHT_PROMPT_PROBES", "on")
+	t.Setenv("KILROY_PREFLIGHT_API_PROMPT_PROBE_TRANSPORTS", "complete")
+	t.Setenv("KILROY_PREFLIGHT_API_PROMPT_PROBE_RETRIES", "0")
+
+	repo := initTestRepo(t)
+	catalog := writeCatalogForPreflight(t, `{
+  "data": [
+    {"id": "openai/gpt-5.2"},
+    {"id": "zai/glm-4.7"}
+  ]
+}`)
+
+	// Each probe waits for the other to arrive, so both can only be seen
+	// in flight together when the probes run concurrently.
+	var arrivals atomic.Int32
+	bothArrived := make(chan struct{})
+	var overlapped atomic.Bool
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		_ = r.Body.Close()
+		if arrivals.Add(1) == 2 {
+			close(bothArrived)
+		}
+		select {
+		case <-bothArrived:
+			overlapped.Store(true)
+		case <-time.After(2 * time.Second):
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"type":"authentication_error","message":"invalid api key"}}`))
+	}))
+	t.Cleanup(apiSrv.Close)
+
+	t.Setenv("OPENAI_API_KEY", "k-openai")
+	t.Setenv("OPENAI_BASE_URL", apiSrv.URL)
+	t.Setenv("ZAI_API_KEY", "k-zai")
+
+	cfg := testPreflightConfigForProviders(repo, catalog, map[string]BackendKind{})
+	cfg.LLM.CLIProfile = "real"
+	cfg.LLM.Providers["openai"] = ProviderConfig{Backend: BackendAPI, Failover: []string{}}
+	cfg.LLM.Providers["zai"] = ProviderConfig{
+		Backend:  BackendAPI,
+		Failover: []string{},
+		API: ProviderAPIConfig{
+			Protocol:      "openai_chat_completions",
+			BaseURL:       apiSrv.URL,
+			Path:          "/api/coding/paas/v4/chat/completions",
+			APIKeyEnv:     "ZAI_API_KEY",
+			ProfileFamily: "openai",
+		},
+	}
+
+	dot := []byte(`
+digraph G {
+  graph [goal="test"]
+  start [shape=Mdiamond]
+  a [shape=box, llm_provider="openai", llm_model="gpt-5.2", prompt="x"]
+  b [shape=box, llm_provider="zai", llm_model="glm-4.7", prompt="x"]
+  exit [shape=Msquare]
+  start -> a -> b -> exit
+}
+`)
+	logsRoot := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := RunWithConfig(ctx, dot, cfg, RunOptions{RunID: "preflight-api-concurrent", LogsRoot: logsRoot})
+	if err == nil {
+		t.Fatalf("expected preflight failure, got nil")
+	}
+	for _, want := range []string{
+		"preflight: 2 api prompt probes failed",
+		"provider openai api prompt probe failed for model gpt-5.2",
+		"provider zai api prompt probe failed for model glm-4.7",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error missing %q: %v", want, err)
+		}
+	}
+	if !overlapped.Load() {
+		t.Fatalf("expected openai and zai prompt probes to run concurrently")
+	}
+
+	report := mustReadPreflightReport(t, logsRoot)
+	failed := map[string]bool{}
+	for _, check := range report.Checks {
+		if check.Name == "provider_prompt_probe" && check.Status == "fail" {
+			failed[check.Provider] = true
+		}
+	}
+	if !failed["openai"] || !failed["zai"] {
+		t.Fatalf("expected failed prompt probe checks for openai and zai, got %v", failed)
+	}
+}
+
 func TestRunWithConfig_PreflightPromptProbe_APIOneShotShape_DoesNotUseTools(t *testing.T) {
 	t.Setenv("KILROY_PREFLIGHT_PROMPT_PROBES", "on")
 	t.Setenv("KILROY_PREFLIGHT_API_PROMPT_PROBE_TRANSPORTS", "complete")
@@ -74,6 +74,9 @@ func resolveProviderRuntimes(cfg *RunConfigFile) (map[string]ProviderRuntime, er
 		if v := strings.TrimSpace(pc.API.ProfileFamily); v != "" {
 			rt.API.ProfileFamily = v
 		}
+		if v := strings.TrimSpace(pc.API.MetadataField); v != "" {
+			rt.API.MetadataField = v
+		}
 		rt.APIHeadersMap = cloneStringMap(pc.API.Headers)
 		rt.ProfileFamily = rt.API.ProfileFamily
 		// Preserve explicit empty failover overrides:
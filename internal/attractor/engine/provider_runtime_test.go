@@ -112,3 +112,25 @@ func TestResolveProviderRuntimes_RejectsCanonicalAliasCollisions(t *testing.T) {
 		t.Fatalf("expected canonical collision error, got %v", err)
 	}
 }
+
+func TestResolveProviderRuntimes_AppliesMetadataFieldOverride(t *testing.T) {
+	cfg := &RunConfigFile{}
+	cfg.LLM.Providers = map[string]ProviderConfig{
+		"zai": {
+			Backend: BackendAPI,
+			API: ProviderAPIConfig{
+				Protocol:      "openai_chat_completions",
+				APIKeyEnv:     "ZAI_API_KEY",
+				MetadataField: " metadata ",
+			},
+		},
+	}
+
+	rt, err := resolveProviderRuntimes(cfg)
+	if err != nil {
+		t.Fatalf("resolveProviderRuntimes: %v", err)
+	}
+	if got := rt["zai"].API.MetadataField; got != "metadata" {
+		t.Fatalf("zai metadata field=%q want %q", got, "metadata")
+	}
+}
@@ -20,11 +20,16 @@ import (
 var restartSuffixRE = regexp.MustCompile(`^restart-(\d+)$`)
 
 type manifest struct {
-	RunID         string            `json:"run_id"`
-	RepoPath      string            `json:"repo_path"`
-	RunBranch     string            `json:"run_branch"`
-	RunConfigPath string            `json:"run_config_path"`
-	ForceModels   map[string]string `json:"force_models"`
+	RunID            string            `json:"run_id"`
+	RepoPath         string            `json:"repo_path"`
+	RunBranch        string            `json:"run_branch"`
+	CheckpointBranch string            `json:"checkpoint_branch"`
+	RunConfigPath    string            `json:"run_config_path"`
+	GraphPath        string            `json:"graph_path"`
+	ForceModels      map[string]string `json:"force_models"`
+	NoNetwork        bool              `json:"no_network"`
+	Labels           map[string]string `json:"labels"`
+	StartedAt        string            `json:"started_at"`
 
 	ModelDB struct {
 		OpenRouterModelInfoPath   string `json:"openrouter_model_info_path"`
@@ -82,9 +87,13 @@ func resumeFromLogsRoot(ctx context.Context, logsRoot string, ov ResumeOverrides
 		if strings.TrimSpace(logsRoot) == "" || strings.TrimSpace(runID) == "" {
 			return
 		}
+		status := runtime.FinalFail
+		if ctx.Err() != nil {
+			status = runtime.FinalCanceled
+		}
 		final := runtime.FinalOutcome{
 			Timestamp:         time.Now().UTC(),
-			Status:            runtime.FinalFail,
+			Status:            status,
 			RunID:             runID,
 			FinalGitCommitSHA: strings.TrimSpace(checkpointSHA),
 			FailureReason:     strings.TrimSpace(err.Error()),
@@ -112,7 +121,9 @@ func resumeFromLogsRoot(ctx context.Context, logsRoot string, ov ResumeOverrides
 	if err != nil {
 		return nil, err
 	}
-	g, _, err := Prepare(dotSource)
+	// Resolve include= paths against the original graph file (or the run's
+	// repository), as the original run did.
+	g, _, err := PrepareWithOptions(dotSource, PrepareOptions{RepoPath: m.RepoPath, GraphPath: m.GraphPath})
 	if err != nil {
 		return nil, err
 	}
@@ -195,13 +206,17 @@ func resumeFromLogsRoot(ctx context.Context, logsRoot string, ov ResumeOverrides
 
 	prefix := deriveRunBranchPrefix(m, cfg)
 	opts := RunOptions{
-		RepoPath:        m.RepoPath,
-		RunID:           m.RunID,
-		LogsRoot:        logsRoot,
-		WorktreeDir:     filepath.Join(logsRoot, "worktree"),
-		RunBranchPrefix: prefix,
-		RequireClean:    resolveRequireClean(cfg),
-		ForceModels:     normalizeForceModels(copyStringStringMap(m.ForceModels)),
+		RepoPath:         m.RepoPath,
+		RunID:            m.RunID,
+		LogsRoot:         logsRoot,
+		WorktreeDir:      filepath.Join(logsRoot, "worktree"),
+		RunBranchPrefix:  prefix,
+		CheckpointBranch: m.CheckpointBranch,
+		RequireClean:     resolveRequireClean(cfg),
+		ForceModels:      normalizeForceModels(copyStringStringMap(m.ForceModels)),
+		GraphPath:        m.GraphPath,
+		NoNetwork:        m.NoNetwork,
+		Labels:           copyStringStringMap(m.Labels),
 	}
 	if err := opts.applyDefaults(); err != nil {
 		return nil, err
@@ -235,6 +250,18 @@ func resumeFromLogsRoot(ctx context.Context, logsRoot string, ov ResumeOverrides
 	eng.baseLogsRoot, eng.restartCount = restoreRestartState(logsRoot, cp)
 	eng.restartFailureSignatures = restoreRestartFailureSignatures(cp)
 	eng.loopFailureSignatures = restoreLoopFailureSignatures(cp)
+	eng.completedNodes = append([]string{}, cp.CompletedNodes...)
+	if ts, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(m.StartedAt)); err == nil {
+		eng.startedAt = ts
+	}
+	var prevMetrics RunMetrics
+	if b, err := os.ReadFile(filepath.Join(logsRoot, "metrics.json")); err == nil && json.Unmarshal(b, &prevMetrics) == nil {
+		eng.sharedMetrics().restore(prevMetrics)
+	}
+	if cp != nil && cp.Extra != nil {
+		n, _ := anyToNonNegativeInt(cp.Extra["total_steps"])
+		eng.sharedSteps().n.Store(int64(n))
+	}
 	eng.baseSHA = cp.GitCommitSHA
 	eng.lastCheckpointSHA = cp.GitCommitSHA
 	if cp != nil && cp.Extra != nil {
@@ -340,7 +367,7 @@ func resumeFromLogsRoot(ctx context.Context, logsRoot string, ov ResumeOverrides
 				Engine:      eng,
 				Artifacts:   eng.Artifacts,
 			}
-			results, baseSHA, dispatchErr := dispatchParallelBranches(ctx, exec, lastNodeID, allEdges, joinID)
+			results, baseSHA, dispatchErr := dispatchImplicitParallelBranches(ctx, exec, lastNodeID, allEdges, joinID)
 			if dispatchErr != nil {
 				return nil, dispatchErr
 			}
@@ -348,10 +375,7 @@ func resumeFromLogsRoot(ctx context.Context, logsRoot string, ov ResumeOverrides
 			_ = os.MkdirAll(stageDir, 0o755)
 			_ = writeJSON(filepath.Join(stageDir, "parallel_results.json"), results)
 
-			eng.Context.ApplyUpdates(map[string]any{
-				"parallel.join_node": joinID,
-				"parallel.results":   results,
-			})
+			eng.Context.ApplyUpdates(parallelContextUpdates(joinID, results))
 			eng.appendProgress(map[string]any{
 				"event":       "implicit_fan_out",
 				"source_node": lastNodeID,
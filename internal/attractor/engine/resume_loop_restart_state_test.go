@@ -2,6 +2,7 @@ package engine
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -38,7 +39,7 @@ digraph G {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
-	res, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunBranchPrefix: "attractor/run"})
+	res, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunBranchPrefix: "attractor/run", Labels: map[string]string{"team": "core"}})
 	if err != nil {
 		t.Fatalf("Run: %v", err)
 	}
@@ -70,4 +71,17 @@ digraph G {
 	if _, err := os.Stat("restart-1"); err == nil {
 		t.Fatalf("unexpected relative restart-1 dir in process CWD")
 	}
+
+	// The resumed engine rewrites manifest.json on restart; labels carry over.
+	b, err := os.ReadFile(filepath.Join(restartDir, "manifest.json"))
+	if err != nil {
+		t.Fatalf("read restart manifest: %v", err)
+	}
+	var m manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("decode restart manifest: %v", err)
+	}
+	if m.Labels["team"] != "core" {
+		t.Fatalf("restart manifest labels=%v want team=core", m.Labels)
+	}
 }
@@ -188,6 +188,72 @@ digraph G {
 	}
 }
 
+func TestResume_AfterRunKilledMidGraph_ContinuesToCompletion(t *testing.T) {
+	repo := initTestRepo(t)
+	marks := t.TempDir()
+	started := filepath.Join(marks, "started")
+	release := filepath.Join(marks, "release")
+
+	// b blocks until the release file exists, so the first run is killed inside it.
+	dot := []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  a [shape=parallelogram, tool_command="echo a > a.txt"]
+  b [shape=parallelogram, tool_command="if [ -f ` + release + ` ]; then echo b > b.txt; else touch ` + started + `; sleep 60; fi"]
+  start -> a -> b -> exit
+}
+`)
+	logsRoot := t.TempDir()
+	runCtx, kill := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := Run(runCtx, dot, RunOptions{RepoPath: repo, RunID: "killed", LogsRoot: logsRoot})
+		done <- err
+	}()
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		if _, err := os.Stat(started); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			kill()
+			t.Fatalf("node b never started")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	kill()
+	if err := <-done; err == nil {
+		t.Fatalf("expected killed run to fail")
+	}
+	cp, err := runtime.LoadCheckpoint(filepath.Join(logsRoot, "checkpoint.json"))
+	if err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	if cp.CurrentNode != "a" {
+		t.Fatalf("checkpoint current node: got %q want a", cp.CurrentNode)
+	}
+
+	if err := os.WriteFile(release, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	res, err := Resume(ctx, logsRoot)
+	if err != nil {
+		t.Fatalf("Resume() error: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: got %q want %q", res.FinalStatus, runtime.FinalSuccess)
+	}
+	for _, f := range []string{"a.txt", "b.txt"} {
+		if out := runCmdOut(t, repo, "git", "show", res.FinalCommitSHA+":"+f); strings.TrimSpace(out) == "" {
+			t.Fatalf("%s missing from final commit", f)
+		}
+	}
+
+}
+
 func TestResumeFromBranch_FindsLogsRootAndReturnsResult(t *testing.T) {
 	t.Setenv("XDG_STATE_HOME", t.TempDir())
 
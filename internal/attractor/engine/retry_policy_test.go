@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/danshapiro/kilroy/internal/attractor/model"
 	"github.com/danshapiro/kilroy/internal/attractor/runtime"
 )
 
@@ -188,3 +189,116 @@ digraph G {
 	}
 }
 
+
+func TestRun_RetryOnExitCodes_RetriesListedCodesOnly(t *testing.T) {
+	repo := initTestRepo(t)
+	counts := t.TempDir()
+
+	dot := []byte(`
+digraph G {
+  graph [goal="test"]
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  flaky [shape=parallelogram, max_retries=2, retry_on_exit_codes="2,124", tool_command="echo x >> ` + counts + `/flaky; exit 124"]
+  broken [shape=parallelogram, max_retries=2, retry_on_exit_codes="2,124", tool_command="echo x >> ` + counts + `/broken; exit 1"]
+  start -> flaky
+  flaky -> exit [condition="outcome=success"]
+  flaky -> broken [condition="outcome=fail"]
+  broken -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	logsRoot := t.TempDir()
+	_, _ = Run(ctx, dot, RunOptions{RepoPath: repo, RunID: "retry-exit-codes", LogsRoot: logsRoot})
+
+	attempts := func(name string) int {
+		b, _ := os.ReadFile(filepath.Join(counts, name))
+		return len(b) / 2
+	}
+	if got := attempts("flaky"); got != 3 {
+		t.Fatalf("flaky attempts: got %d want 3 (listed exit code retries)", got)
+	}
+	if got := attempts("broken"); got != 1 {
+		t.Fatalf("broken attempts: got %d want 1 (unlisted exit code fails fast)", got)
+	}
+
+	b, err := os.ReadFile(filepath.Join(logsRoot, "broken", "status.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := runtime.DecodeOutcomeJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, ok := outcomeExitCode(out); !ok || code != 1 {
+		t.Fatalf("exit_code meta: got %v ok=%v", out.Meta["exit_code"], ok)
+	}
+	if got := classifyFailureClass(out); got != failureClassDeterministic {
+		t.Fatalf("failure class: got %q want %q", got, failureClassDeterministic)
+	}
+}
+
+func TestRun_FailingToolOutcomeCarriesExitResult(t *testing.T) {
+	repo := initTestRepo(t)
+	dot := []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  t [shape=parallelogram, max_retries=0, tool_command="exit 3"]
+  start -> t -> exit
+}
+`)
+	logsRoot := t.TempDir()
+	_, _ = Run(context.Background(), dot, RunOptions{RepoPath: repo, RunID: "tool-exit-meta", LogsRoot: logsRoot})
+
+	b, err := os.ReadFile(filepath.Join(logsRoot, "t", "status.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := runtime.DecodeOutcomeJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, ok := outcomeExitCode(out); !ok || code != 3 {
+		t.Fatalf("exit_code meta: got %v ok=%v", out.Meta["exit_code"], ok)
+	}
+	if out.Meta["timed_out"] != false || out.Meta["duration_ms"] == nil {
+		t.Fatalf("meta: %v", out.Meta)
+	}
+
+	var found bool
+	for _, ev := range readProgressEvents(t, filepath.Join(logsRoot, "progress.ndjson")) {
+		if ev["event"] == "stage_attempt_end" && ev["node_id"] == "t" {
+			found = true
+			if ev["exit_code"] != float64(3) || ev["duration_ms"] == nil {
+				t.Fatalf("stage_attempt_end: %v", ev)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("no stage_attempt_end event for t")
+	}
+}
+
+func TestToolHandler_TimedOutOutcomeHasNoExitCode(t *testing.T) {
+	dir := t.TempDir()
+	node := model.NewNode("t")
+	node.Attrs["tool_command"] = "sleep 5"
+	node.Attrs["timeout"] = "1s"
+	if err := os.MkdirAll(filepath.Join(dir, "t"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	exec := &Execution{Context: runtime.NewContext(), LogsRoot: dir, WorktreeDir: dir}
+
+	out, err := (&ToolHandler{}).Execute(context.Background(), exec, node)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if out.Status != runtime.StatusFail || out.Meta["timed_out"] != true {
+		t.Fatalf("outcome: %+v", out)
+	}
+	if _, ok := outcomeExitCode(out); ok {
+		t.Fatalf("timed-out outcome carries an exit code: %v", out.Meta)
+	}
+}
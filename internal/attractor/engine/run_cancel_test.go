@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestRun_CallerCancel_WritesCanceledFinalOutcome(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("requires sleep binary")
+	}
+	dot := []byte(`digraph G {
+  graph [default_max_retry=0]
+  start [shape=Mdiamond]
+  wait [shape=parallelogram, tool_command="sleep 5"]
+  exit [shape=Msquare]
+  start -> wait
+  wait -> exit
+}`)
+	repo := initTestRepo(t)
+	logsRoot := t.TempDir()
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	time.AfterFunc(300*time.Millisecond, func() { cancel(errors.New("stopped by signal terminated")) })
+
+	start := time.Now()
+	_, err := Run(ctx, dot, RunOptions{RepoPath: repo, LogsRoot: logsRoot})
+	if err == nil {
+		t.Fatal("expected an error from a canceled run")
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Fatalf("cancel did not interrupt the tool node; took %v", elapsed)
+	}
+	final := mustReadFinalOutcome(t, filepath.Join(logsRoot, "final.json"))
+	if final.Status != runtime.FinalCanceled {
+		t.Fatalf("final status=%q want %q", final.Status, runtime.FinalCanceled)
+	}
+	if !strings.Contains(final.FailureReason, "stopped by signal terminated") {
+		t.Fatalf("failure_reason=%q want the cancel cause", final.FailureReason)
+	}
+}
+
+func TestRun_RunTimeout_IsFailNotCanceled(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("requires sleep binary")
+	}
+	dot := []byte(`digraph G {
+  graph [default_max_retry=0]
+  start [shape=Mdiamond]
+  wait [shape=parallelogram, tool_command="sleep 5"]
+  exit [shape=Msquare]
+  start -> wait
+  wait -> exit
+}`)
+	repo := initTestRepo(t)
+	logsRoot := t.TempDir()
+	_, _ = Run(context.Background(), dot, RunOptions{RepoPath: repo, LogsRoot: logsRoot, RunTimeout: 300 * time.Millisecond})
+	final := mustReadFinalOutcome(t, filepath.Join(logsRoot, "final.json"))
+	if final.Status != runtime.FinalFail {
+		t.Fatalf("final status=%q want %q for a run timeout", final.Status, runtime.FinalFail)
+	}
+}
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestRun_InitialContextFeedsToolTemplatesAndConditions(t *testing.T) {
+	dot := []byte(`digraph G {
+  graph [goal="test", requires="ticket,target"]
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  write [shape=parallelogram, tool_command="echo {{ticket}} > ticket.txt"]
+  prod [shape=parallelogram, tool_command="touch prod.txt"]
+  staging [shape=parallelogram, tool_command="touch staging.txt"]
+  start -> write
+  write -> prod [condition="context.target=prod"]
+  write -> staging
+  prod -> exit
+  staging -> exit
+}`)
+	repo := initTestRepo(t)
+	res, err := Run(context.Background(), dot, RunOptions{
+		RepoPath:       repo,
+		LogsRoot:       t.TempDir(),
+		InitialContext: map[string]any{"ticket": "KIL-42", "target": "prod"},
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: %s", res.FinalStatus)
+	}
+	files := runCmdOut(t, repo, "git", "ls-tree", "-r", "--name-only", res.FinalCommitSHA)
+	if !strings.Contains(files, "prod.txt") || strings.Contains(files, "staging.txt") {
+		t.Fatalf("expected prod branch; files:\n%s", files)
+	}
+	if got := strings.TrimSpace(runCmdOut(t, repo, "git", "show", res.FinalCommitSHA+":ticket.txt")); got != "KIL-42" {
+		t.Fatalf("ticket.txt: %q", got)
+	}
+
+	_, err = Run(context.Background(), dot, RunOptions{
+		RepoPath:       repo,
+		LogsRoot:       t.TempDir(),
+		InitialContext: map[string]any{"ticket": "KIL-42"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "required_inputs") || !strings.Contains(err.Error(), `"target"`) {
+		t.Fatalf("expected required_inputs error for target, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestRunTimeout_SmallerOfGraphAndOption(t *testing.T) {
+	g := model.NewGraph("G")
+	if got := runTimeout(g, 0); got != 0 {
+		t.Fatalf("unset: got %s", got)
+	}
+	g.Attrs["run_timeout_ms"] = "1500"
+	if got := runTimeout(g, 0); got != 1500*time.Millisecond {
+		t.Fatalf("graph only: got %s", got)
+	}
+	if got := runTimeout(g, time.Second); got != time.Second {
+		t.Fatalf("smaller option wins: got %s", got)
+	}
+	if got := runTimeout(g, time.Minute); got != 1500*time.Millisecond {
+		t.Fatalf("smaller graph attr wins: got %s", got)
+	}
+}
+
+func TestRun_RunTimeoutMS_KillsToolAndFailsRun(t *testing.T) {
+	repo := initTestRepo(t)
+
+	dot := []byte(`
+digraph G {
+  graph [run_timeout_ms=500]
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  t [shape=parallelogram, max_retries=3, tool_command="sleep 30"]
+  start -> t -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	logsRoot := t.TempDir()
+	started := time.Now()
+	// The stall watchdog is armed too; it must stop once the run timeout fires.
+	_, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunID: "run-timeout", LogsRoot: logsRoot, StallTimeout: 2 * time.Second, StallCheckInterval: 100 * time.Millisecond})
+	if err == nil {
+		t.Fatalf("expected run timeout error")
+	}
+	if elapsed := time.Since(started); elapsed > 20*time.Second {
+		t.Fatalf("tool was not killed promptly: run took %s", elapsed)
+	}
+
+	final := mustReadFinalOutcome(t, filepath.Join(logsRoot, "final.json"))
+	if final.Status != runtime.FinalFail || final.FailureReason != "run timeout" {
+		t.Fatalf("final: status=%q reason=%q", final.Status, final.FailureReason)
+	}
+	b, err := os.ReadFile(filepath.Join(logsRoot, "progress.ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(b), `"event":"run_timeout"`) != 1 || strings.Contains(string(b), "stall_watchdog") {
+		t.Fatalf("expected one run_timeout event and no stall watchdog cancel:\n%s", b)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/danshapiro/kilroy/internal/attractor/gitutil"
 	"github.com/danshapiro/kilroy/internal/attractor/model"
 	"github.com/danshapiro/kilroy/internal/attractor/modeldb"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
 	"github.com/danshapiro/kilroy/internal/cxdb"
 )
 
@@ -28,11 +30,16 @@ func RunWithConfig(ctx context.Context, dotSource []byte, cfg *RunConfigFile, ov
 	// Prepare graph (parse + transforms + validate).
 	g, _, err := PrepareWithOptions(dotSource, PrepareOptions{
 		RepoPath:   cfg.Repo.Path,
+		GraphPath:  overrides.GraphPath,
 		KnownTypes: reg.KnownTypes(),
+		Inputs:     runInputs(overrides.InitialContext),
 	})
 	if err != nil {
 		return nil, err
 	}
+	if err := checkCodergenBackendNames(g); err != nil {
+		return nil, err
+	}
 
 	// Ensure backend is specified for each provider used by the graph.
 	// Use the handler registry to identify nodes that require an LLM provider
@@ -45,6 +52,9 @@ func RunWithConfig(ctx context.Context, dotSource []byte, cfg *RunConfigFile, ov
 		if pr, ok := reg.Resolve(n).(ProviderRequiringHandler); !ok || !pr.RequiresProvider() {
 			continue
 		}
+		if codergenBackendName(g, n) != "" {
+			continue // registered backends do not go through provider routing
+		}
 		p := strings.TrimSpace(n.Attr("llm_provider", ""))
 		if p == "" {
 			continue // validation already fails, but keep defensive
@@ -77,7 +87,16 @@ func RunWithConfig(ctx context.Context, dotSource []byte, cfg *RunConfigFile, ov
 		StallCheckInterval: durationFromOptionalMSOrDisabled(
 			cfg.RuntimePolicy.StallCheckIntervalMS,
 		),
+		RunTimeout:    durationFromOptionalMSOrDisabled(cfg.RuntimePolicy.RunTimeoutMS),
 		MaxLLMRetries: copyOptionalInt(cfg.RuntimePolicy.MaxLLMRetries),
+		StallAction:   cfg.RuntimePolicy.StallAction,
+		MinFreeBytes:  cfg.RuntimePolicy.MinFreeBytes,
+	}
+	if v := cfg.RuntimePolicy.ProgressMaxBytes; v != nil {
+		opts.ProgressMaxBytes = *v
+	}
+	if v := cfg.RuntimePolicy.ProgressMaxRolls; v != nil {
+		opts.ProgressMaxRolls = *v
 	}
 	// Allow select overrides.
 	if overrides.RunID != "" {
@@ -92,11 +111,32 @@ func RunWithConfig(ctx context.Context, dotSource []byte, cfg *RunConfigFile, ov
 	if overrides.RunBranchPrefix != "" {
 		opts.RunBranchPrefix = overrides.RunBranchPrefix
 	}
+	opts.CheckpointBranch = overrides.CheckpointBranch
 	opts.AllowTestShim = overrides.AllowTestShim
+	opts.RequirePinnedCatalog = overrides.RequirePinnedCatalog
 	opts.ForceModels = normalizeForceModels(overrides.ForceModels)
 	opts.ProgressSink = overrides.ProgressSink
+	opts.KeepCheckpoints = overrides.KeepCheckpoints
+	opts.PromptProbeTimeout = overrides.PromptProbeTimeout
+	opts.PromptProbeRetries = copyOptionalInt(overrides.PromptProbeRetries)
+	opts.PromptProbeBaseDelay = overrides.PromptProbeBaseDelay
+	opts.PromptProbeMaxDelay = overrides.PromptProbeMaxDelay
+	opts.CodergenCallTimeout = overrides.CodergenCallTimeout
+	opts.NoNetwork = overrides.NoNetwork
+	if overrides.MinFreeBytes > 0 {
+		opts.MinFreeBytes = overrides.MinFreeBytes
+	}
+	opts.ProgressRedactPatterns = overrides.ProgressRedactPatterns
 	opts.Interviewer = overrides.Interviewer
+	opts.CodergenBackend = overrides.CodergenBackend
+	opts.FailureClassifier = overrides.FailureClassifier
+	if opts.Interviewer == nil && cfg.Interviewer.Webhook.URL != "" {
+		opts.Interviewer = NewWebhookInterviewer(cfg.Interviewer.Webhook)
+	}
 	opts.OnEngineReady = overrides.OnEngineReady
+	opts.Labels = overrides.Labels
+	opts.GraphPath = overrides.GraphPath
+	opts.InitialContext = overrides.InitialContext
 
 	if err := opts.applyDefaults(); err != nil {
 		return nil, err
@@ -130,6 +170,9 @@ func RunWithConfig(ctx context.Context, dotSource []byte, cfg *RunConfigFile, ov
 	if _, err := gitutil.HeadSHA(opts.RepoPath); err != nil {
 		return nil, fmt.Errorf("repo has no commits or HEAD is unresolvable: %w", err)
 	}
+	if _, err := checkRepoGitState(opts.RepoPath); err != nil {
+		return nil, err
+	}
 	// Ensure the logs directory is writable before expensive preflight work.
 	// Several preflight steps write into LogsRoot, but an outright unwritable
 	// path would surface as a confusing mid-preflight error instead of a clear
@@ -168,6 +211,26 @@ func RunWithConfig(ctx context.Context, dotSource []byte, cfg *RunConfigFile, ov
 	if err != nil {
 		return nil, err
 	}
+	var catalogDrift *runtime.CatalogDrift
+	if resolved.Drifted() {
+		catalogDrift = &runtime.CatalogDrift{
+			PinnedSHA256:    resolved.PinnedSHA256,
+			EffectiveSHA256: resolved.SHA256,
+			Source:          resolved.Source,
+		}
+		if opts.RequirePinnedCatalog {
+			err := fmt.Errorf("model catalog drift: effective catalog (sha256=%s from %s) does not match pinned snapshot (sha256=%s) and --require-pinned-catalog is set", resolved.SHA256, resolved.Source, resolved.PinnedSHA256)
+			final := runtime.FinalOutcome{
+				Timestamp:         time.Now().UTC(),
+				Status:            runtime.FinalFail,
+				RunID:             opts.RunID,
+				FailureReason:     err.Error(),
+				ModelCatalogDrift: catalogDrift,
+			}
+			_ = final.Save(filepath.Join(opts.LogsRoot, "final.json"))
+			return nil, err
+		}
+	}
 	catalog, err := loadCatalogForRun(resolved.SnapshotPath)
 	if err != nil {
 		return nil, err
@@ -228,10 +291,14 @@ func RunWithConfig(ctx context.Context, dotSource []byte, cfg *RunConfigFile, ov
 	eng.RunConfig = cfg
 	eng.Context = NewContextWithGraphAttrs(g)
 	eng.CodergenBackend = NewCodergenRouterWithRuntimes(cfg, catalog, runtimes)
+	if opts.CodergenBackend != nil {
+		eng.CodergenBackend = opts.CodergenBackend
+	}
 	eng.CXDB = sink
 	eng.ModelCatalogSHA = catalog.SHA256
 	eng.ModelCatalogSource = resolved.Source
 	eng.ModelCatalogPath = resolved.SnapshotPath
+	eng.ModelCatalogDrift = catalogDrift
 	if strings.TrimSpace(resolved.Warning) != "" {
 		eng.Warn(resolved.Warning)
 		eng.Context.AppendLog(resolved.Warning)
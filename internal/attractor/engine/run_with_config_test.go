@@ -72,6 +72,43 @@ digraph G {
 	}
 }
 
+func TestRunWithConfig_PassesFailureClassifierToEngine(t *testing.T) {
+	repo := initTestRepo(t)
+	cxdbSrv := newCXDBTestServer(t)
+
+	dot := []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  start -> exit
+}
+`)
+	cfg := &RunConfigFile{}
+	cfg.Version = 1
+	cfg.Repo.Path = repo
+	cfg.CXDB.BinaryAddr = cxdbSrv.BinaryAddr()
+	cfg.CXDB.HTTPBaseURL = cxdbSrv.URL()
+	cfg.ModelDB.OpenRouterModelInfoPath = writePinnedCatalog(t)
+	cfg.ModelDB.OpenRouterModelInfoUpdatePolicy = "pinned"
+	cfg.Git.RunBranchPrefix = "attractor/run"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	var got FailureClassifier
+	_, err := RunWithConfig(ctx, dot, cfg, RunOptions{
+		RunID:             "classifier",
+		LogsRoot:          t.TempDir(),
+		FailureClassifier: stderrClassifier{},
+		OnEngineReady:     func(e *Engine) { got = e.Options.FailureClassifier },
+	})
+	if err != nil {
+		t.Fatalf("RunWithConfig: %v", err)
+	}
+	if _, ok := got.(stderrClassifier); !ok {
+		t.Fatalf("engine FailureClassifier = %#v, want the override", got)
+	}
+}
+
 func TestRunWithConfig_RejectsTestShimWithoutAllowFlag(t *testing.T) {
 	cfg := &RunConfigFile{}
 	cfg.LLM.CLIProfile = "test_shim"
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danshapiro/kilroy/internal/attractor/cond"
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+// skipIfOutcome evaluates node's skip_if condition (edge-condition syntax)
+// against the run context; outcome, preferred_label, and failure_reason refer
+// to the previous stage. When it holds, the node is not executed: its
+// status.json records "skipped", a stage_skipped event is emitted, and the
+// returned outcome is a success so edge selection proceeds as if it had run.
+func (e *Engine) skipIfOutcome(node *model.Node) (runtime.Outcome, bool) {
+	expr := strings.TrimSpace(node.Attr("skip_if", ""))
+	if expr == "" || isTerminal(node) {
+		return runtime.Outcome{}, false
+	}
+	prev := runtime.Outcome{
+		Status:         runtime.StageStatus(e.Context.GetString("outcome", "")),
+		PreferredLabel: e.Context.GetString("preferred_label", ""),
+		FailureReason:  e.Context.GetString("failure_reason", ""),
+	}
+	ok, err := cond.Evaluate(expr, prev, e.Context)
+	if err != nil {
+		e.Warn(fmt.Sprintf("skip_if on node %s: %v; executing node", node.ID, err))
+		return runtime.Outcome{}, false
+	}
+	if !ok {
+		return runtime.Outcome{}, false
+	}
+
+	notes := fmt.Sprintf("skipped: skip_if %q", expr)
+	stageDir := filepath.Join(e.LogsRoot, node.ID)
+	if err := os.MkdirAll(stageDir, 0o755); err == nil {
+		_ = writeJSON(filepath.Join(stageDir, "status.json"), runtime.Outcome{
+			Status:           runtime.StatusSkipped,
+			Notes:            notes,
+			ContextUpdates:   map[string]any{},
+			SuggestedNextIDs: []string{},
+		})
+	}
+	e.appendProgress(map[string]any{
+		"event":   "stage_skipped",
+		"node_id": node.ID,
+		"skip_if": expr,
+	})
+	return runtime.Outcome{
+		Status:           runtime.StatusSuccess,
+		Notes:            notes,
+		Meta:             map[string]any{"skipped": true},
+		ContextUpdates:   map[string]any{},
+		SuggestedNextIDs: []string{},
+	}, true
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestRun_SkipIf_BypassesNodeAndRoutesAsSuccess(t *testing.T) {
+	repo := initTestRepo(t)
+
+	dot := []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  deploy [shape=parallelogram, skip_if="env=dev", tool_command="echo deployed > deployed.txt"]
+  notify [shape=parallelogram, tool_command="echo notified > notified.txt"]
+  start -> deploy
+  deploy -> notify [condition="outcome=success"]
+  deploy -> exit [condition="outcome=fail"]
+  notify -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	res, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunID: "skip-if", LogsRoot: t.TempDir(), InitialContext: map[string]any{"env": "dev"}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: %s", res.FinalStatus)
+	}
+	if _, err := os.Stat(filepath.Join(res.WorktreeDir, "deployed.txt")); !os.IsNotExist(err) {
+		t.Fatalf("skipped node's tool_command ran: stat err=%v", err)
+	}
+	if _, err := os.Stat(filepath.Join(res.WorktreeDir, "notified.txt")); err != nil {
+		t.Fatalf("success edge after skipped node not taken: %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(res.LogsRoot, "deploy", "status.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := runtime.DecodeOutcomeJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Status != runtime.StatusSkipped {
+		t.Fatalf("deploy status: got %q want %q", out.Status, runtime.StatusSkipped)
+	}
+	skipped := false
+	for _, ev := range readProgressEvents(t, filepath.Join(res.LogsRoot, "progress.ndjson")) {
+		if ev["event"] == "stage_skipped" && ev["node_id"] == "deploy" {
+			skipped = true
+		}
+	}
+	if !skipped {
+		t.Fatalf("missing stage_skipped progress event")
+	}
+}
+
+func TestRun_SkipIf_FalseExecutesNode(t *testing.T) {
+	repo := initTestRepo(t)
+
+	dot := []byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  deploy [shape=parallelogram, skip_if="env=dev", tool_command="echo deployed > deployed.txt"]
+  start -> deploy -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	res, err := Run(ctx, dot, RunOptions{RepoPath: repo, RunID: "skip-if-false", LogsRoot: t.TempDir(), InitialContext: map[string]any{"env": "prod"}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(res.WorktreeDir, "deployed.txt")); err != nil {
+		t.Fatalf("node should run when skip_if is false: %v", err)
+	}
+}
@@ -25,7 +25,10 @@ func runSubgraphUntil(ctx context.Context, eng *Engine, startNodeID, stopNodeID
 	completed := []string{}
 	nodeRetries := map[string]int{}
 	nodeVisits := map[string]int{}
+	// failureSeenAt maps a failure signature to its last index in completed.
+	failureSeenAt := map[string]int{}
 	visitLimit := maxNodeVisits(eng.Graph)
+	loops := 0
 
 	var lastNode string
 	var lastOutcome runtime.Outcome
@@ -92,12 +95,29 @@ func runSubgraphUntil(ctx context.Context, eng *Engine, startNodeID, stopNodeID
 		// for subgraph/branch execution, matching the main loop (engine.go).
 		eng.Context.Set(fmt.Sprintf("internal.retry_count.%s", current), nodeRetries[current])
 
-		eng.cxdbStageStarted(ctx, node)
-		out, err := eng.executeWithRetry(ctx, node, nodeRetries)
-		if err != nil {
+		if err := eng.countStep(node.ID); err != nil {
 			return parallelBranchResult{}, err
 		}
+		eng.cxdbStageStarted(ctx, node)
+		out, skipped := eng.skipIfOutcome(node)
+		cacheKey := ""
+		cacheHit := false
+		if !skipped {
+			cacheKey = eng.nodeCacheKey(node)
+			out, cacheHit = eng.loadNodeCache(node, cacheKey)
+		}
+		if !skipped && !cacheHit {
+			var err error
+			out, err = eng.executeWithRetry(ctx, node, nodeRetries)
+			if err != nil {
+				return parallelBranchResult{}, err
+			}
+		}
 		eng.cxdbStageFinished(ctx, node, out)
+		if !skipped && !cacheHit {
+			eng.captureNodeArtifacts(node, out)
+			eng.storeNodeCache(node, cacheKey, out)
+		}
 		if err := ctx.Err(); err != nil {
 			return canceledReturn(node.ID, out, err)
 		}
@@ -140,7 +160,12 @@ func runSubgraphUntil(ctx context.Context, eng *Engine, startNodeID, stopNodeID
 				}
 				eng.loopFailureSignatures[sig]++
 				count := eng.loopFailureSignatures[sig]
-				limit := loopRestartSignatureLimit(eng.Graph)
+				limit := deterministicCycleThreshold(eng.Graph)
+				from, seen := failureSeenAt[sig]
+				if !seen {
+					from = -1
+				}
+				failureSeenAt[sig] = len(completed) - 1
 				eng.appendProgress(map[string]any{
 					"event":           "subgraph_deterministic_failure_cycle_check",
 					"node_id":         node.ID,
@@ -151,19 +176,21 @@ func runSubgraphUntil(ctx context.Context, eng *Engine, startNodeID, stopNodeID
 					"failure_reason":  out.FailureReason,
 				})
 				if count >= limit {
+					path := failureCyclePath(completed, from)
 					eng.appendProgress(map[string]any{
 						"event":           "subgraph_deterministic_failure_cycle_breaker",
 						"node_id":         node.ID,
 						"signature":       sig,
 						"signature_count": count,
 						"signature_limit": limit,
+						"node_path":       path,
 					})
 					return parallelBranchResult{
 						HeadSHA:    headSHA,
 						LastNodeID: lastNode,
 						Outcome:    out,
 						Completed:  completed,
-					}, fmt.Errorf("deterministic failure cycle detected in subgraph: %s", sig)
+					}, fmt.Errorf("deterministic failure cycle detected in subgraph: %s (repeated %d times, limit %d) via %s", sig, count, limit, path)
 				}
 			}
 		}
@@ -200,12 +227,43 @@ func runSubgraphUntil(ctx context.Context, eng *Engine, startNodeID, stopNodeID
 				Completed:  completed,
 			}, nil
 		}
-		if strings.EqualFold(next.Attr("loop_restart", "false"), "true") {
-			return parallelBranchResult{}, fmt.Errorf("loop_restart not supported in v1")
-		}
 		if err := ctx.Err(); err != nil {
 			return canceledReturn(node.ID, lastOutcome, err)
 		}
+		if strings.EqualFold(next.Attr("loop_restart", "false"), "true") {
+			// Unlike the top-level loopRestart, a branch keeps its context and
+			// logs root: it re-enters the edge target with fresh retry and
+			// visit budgets, bounded by max_loops.
+			if isFailureLoopRestartOutcome(out) && !strings.EqualFold(strings.TrimSpace(failureClass), failureClassTransientInfra) {
+				eng.appendProgress(map[string]any{
+					"event":          "loop_restart_blocked",
+					"target_node":    next.To,
+					"node_id":        node.ID,
+					"failure_class":  normalizedFailureClassOrDefault(failureClass),
+					"failure_reason": out.FailureReason,
+					"subgraph":       true,
+				})
+				return buildResult(out), fmt.Errorf("loop_restart blocked: failure_class=%s (requires %s), node=%s, failure_reason=%s",
+					normalizedFailureClassOrDefault(failureClass), failureClassTransientInfra, node.ID, strings.TrimSpace(out.FailureReason))
+			}
+			loops++
+			maxLoops := maxLoopRestarts(eng.Graph)
+			if loops > maxLoops {
+				return buildResult(out), fmt.Errorf("loop_restart limit exceeded (%d restarts, max %d)", loops, maxLoops)
+			}
+			eng.appendProgress(map[string]any{
+				"event":       "stage_loop_restart",
+				"node_id":     node.ID,
+				"target_node": next.To,
+				"loop_count":  loops,
+				"max_loops":   maxLoops,
+				"subgraph":    true,
+			})
+			eng.Context.Set("loop_restart.iteration_count", loops)
+			eng.Context.Set("loop_restart.from_node", node.ID)
+			nodeRetries = map[string]int{}
+			nodeVisits = map[string]int{}
+		}
 		current = next.To
 	}
 }
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestRun_LoopRestartInsideParallelBranch_RepeatsUntilConditionHolds(t *testing.T) {
+	repo := t.TempDir()
+	runCmd(t, repo, "git", "init")
+	runCmd(t, repo, "git", "config", "user.name", "tester")
+	runCmd(t, repo, "git", "config", "user.email", "tester@example.com")
+	_ = os.WriteFile(filepath.Join(repo, "README.md"), []byte("hello\n"), 0o644)
+	runCmd(t, repo, "git", "add", "-A")
+	runCmd(t, repo, "git", "commit", "-m", "init")
+
+	counter := filepath.Join(t.TempDir(), "count")
+	dot := []byte(fmt.Sprintf(`
+digraph G {
+  graph [goal="branch loop", max_loops=5]
+  start [shape=Mdiamond]
+  par [shape=component]
+  a [shape=parallelogram, tool_command="echo x >> %s; echo passes=$(wc -l < %s | tr -d ' ')"]
+  b [shape=parallelogram, tool_command="true"]
+  join [shape=tripleoctagon]
+  exit [shape=Msquare]
+
+  start -> par
+  par -> a
+  par -> b
+  a -> join [condition="tool.output contains passes=3"]
+  a -> a [loop_restart=true]
+  b -> join
+  join -> exit
+}
+`, counter, counter))
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	res, err := Run(ctx, dot, RunOptions{RepoPath: repo})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: got %q want %q", res.FinalStatus, runtime.FinalSuccess)
+	}
+
+	// Branch events reach the parent progress stream as branch_progress relays.
+	restarts := 0
+	for _, ev := range readProgressEvents(t, filepath.Join(res.LogsRoot, "progress.ndjson")) {
+		if ev["event"] == "branch_progress" && ev["branch_event"] == "stage_loop_restart" && ev["branch_node_id"] == "a" {
+			restarts++
+		}
+	}
+	if restarts != 2 {
+		t.Fatalf("stage_loop_restart events for a: got %d want 2", restarts)
+	}
+	b, err := os.ReadFile(counter)
+	if err != nil {
+		t.Fatalf("read counter: %v", err)
+	}
+	if got := strings.Count(string(b), "x"); got != 3 {
+		t.Fatalf("branch node runs: got %d want 3", got)
+	}
+}
+
+func TestMaxLoopRestarts_PrefersMaxLoops(t *testing.T) {
+	g := &model.Graph{Attrs: map[string]string{}}
+	if got := maxLoopRestarts(g); got != defaultMaxLoopRestarts {
+		t.Fatalf("default: got %d want %d", got, defaultMaxLoopRestarts)
+	}
+	g.Attrs["max_restarts"] = "7"
+	if got := maxLoopRestarts(g); got != 7 {
+		t.Fatalf("max_restarts: got %d want 7", got)
+	}
+	g.Attrs["max_loops"] = "2"
+	if got := maxLoopRestarts(g); got != 2 {
+		t.Fatalf("max_loops: got %d want 2", got)
+	}
+}
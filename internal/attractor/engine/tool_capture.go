@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+)
+
+// defaultToolCaptureMaxBytes caps each captured stream when the node does not
+// set capture_max_bytes.
+const defaultToolCaptureMaxBytes = 64 << 10
+
+// toolCapture is a tool node's capture_output configuration.
+type toolCapture struct {
+	Mode      string // last_line | full | json
+	StdoutVar string
+	StderrVar string
+	MaxBytes  int
+}
+
+// toolCaptureFor parses capture_output, capture_var, capture_stderr_var, and
+// capture_max_bytes. ok is false when the node does not capture output.
+func toolCaptureFor(node *model.Node) (toolCapture, bool, error) {
+	mode := strings.ToLower(strings.TrimSpace(node.Attr("capture_output", "")))
+	if mode == "" {
+		return toolCapture{}, false, nil
+	}
+	switch mode {
+	case "last_line", "full", "json":
+	default:
+		return toolCapture{}, false, fmt.Errorf("capture_output must be last_line, full, or json, got %q", mode)
+	}
+	c := toolCapture{
+		Mode:      mode,
+		StdoutVar: strings.TrimSpace(node.Attr("capture_var", "tool_stdout")),
+		StderrVar: strings.TrimSpace(node.Attr("capture_stderr_var", "tool_stderr")),
+		MaxBytes:  defaultToolCaptureMaxBytes,
+	}
+	if raw := strings.TrimSpace(node.Attr("capture_max_bytes", "")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return toolCapture{}, false, fmt.Errorf("capture_max_bytes must be a positive integer, got %q", raw)
+		}
+		c.MaxBytes = n
+	}
+	return c, true, nil
+}
+
+// contextUpdates converts captured stdout/stderr into context updates.
+// last_line and full keep the tail of the stream when it exceeds MaxBytes and
+// record <var>.truncated / <var>.original_bytes so the checkpoint shows the
+// value is partial. json parses stdout and also sets <var>.<key> for the
+// top-level scalar fields of an object; stdout over the cap or invalid JSON is
+// an error because a partial document cannot be parsed.
+func (c toolCapture) contextUpdates(stdout, stderr []byte) (map[string]any, error) {
+	updates := map[string]any{}
+	if c.Mode == "json" {
+		if len(stdout) > c.MaxBytes {
+			return nil, fmt.Errorf("capture_output=json: stdout is %d bytes, over capture_max_bytes=%d", len(stdout), c.MaxBytes)
+		}
+		var v any
+		if err := json.Unmarshal(stdout, &v); err != nil {
+			return nil, fmt.Errorf("capture_output=json: stdout is not valid JSON: %v", err)
+		}
+		updates[c.StdoutVar] = v
+		if obj, ok := v.(map[string]any); ok {
+			for k, fv := range obj {
+				switch fv.(type) {
+				case string, bool, float64, nil:
+					updates[c.StdoutVar+"."+k] = fv
+				}
+			}
+		}
+		c.setCaptured(updates, c.StderrVar, string(stderr))
+		return updates, nil
+	}
+	out, errOut := string(stdout), string(stderr)
+	if c.Mode == "last_line" {
+		out, errOut = lastLine(out), lastLine(errOut)
+	}
+	c.setCaptured(updates, c.StdoutVar, out)
+	c.setCaptured(updates, c.StderrVar, errOut)
+	return updates, nil
+}
+
+func (c toolCapture) setCaptured(updates map[string]any, key, s string) {
+	if key == "" {
+		return
+	}
+	updates[key+".truncated"] = len(s) > c.MaxBytes
+	if len(s) > c.MaxBytes {
+		updates[key+".original_bytes"] = len(s)
+		s = tailBytes(s, c.MaxBytes)
+	}
+	updates[key] = s
+}
+
+// lastLine returns the last non-empty line of s without its line ending.
+func lastLine(s string) string {
+	s = strings.TrimRight(s, "\r\n")
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimRight(s, "\r")
+}
+
+// tailBytes returns at most the last n bytes of s, starting on a rune boundary.
+func tailBytes(s string, n int) string {
+	s = s[len(s)-n:]
+	for len(s) > 0 && !utf8.RuneStart(s[0]) {
+		s = s[1:]
+	}
+	return s
+}
@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestToolCapture_ContextUpdates(t *testing.T) {
+	n := model.NewNode("t")
+	n.Attrs["capture_output"] = "last_line"
+	n.Attrs["capture_max_bytes"] = "4"
+	c, ok, err := toolCaptureFor(n)
+	if err != nil || !ok {
+		t.Fatalf("toolCaptureFor: ok=%v err=%v", ok, err)
+	}
+	got, err := c.contextUpdates([]byte("building\nALL PASS\n"), []byte("warn\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["tool_stdout"] != "PASS" || got["tool_stdout.truncated"] != true || got["tool_stdout.original_bytes"] != 8 {
+		t.Fatalf("stdout capture: %+v", got)
+	}
+	if got["tool_stderr"] != "warn" || got["tool_stderr.truncated"] != false {
+		t.Fatalf("stderr capture: %+v", got)
+	}
+
+	n.Attrs["capture_output"] = "json"
+	n.Attrs["capture_var"] = "report"
+	n.Attrs["capture_max_bytes"] = "1024"
+	c, _, _ = toolCaptureFor(n)
+	got, err = c.contextUpdates([]byte(`{"status":"ok","failed":0,"items":[1]}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["report.status"] != "ok" || got["report.failed"] != float64(0) {
+		t.Fatalf("json capture: %+v", got)
+	}
+	if _, ok := got["report.items"]; ok {
+		t.Fatalf("non-scalar field flattened: %+v", got)
+	}
+	if _, err := c.contextUpdates([]byte("not json"), nil); err == nil {
+		t.Fatal("expected invalid JSON error")
+	}
+
+	n.Attrs["capture_output"] = "lines"
+	if _, _, err := toolCaptureFor(n); err == nil {
+		t.Fatal("expected invalid capture_output error")
+	}
+}
+
+func TestRun_CaptureOutputDrivesEdgeConditionAndIsCheckpointed(t *testing.T) {
+	dot := []byte(`digraph G {
+  graph [goal="test"]
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  test [shape=parallelogram, tool_command="echo running; echo 'suite PASS'", capture_output="last_line"]
+  passed [shape=parallelogram, tool_command="echo passed"]
+  failed [shape=parallelogram, tool_command="echo failed"]
+  start -> test
+  test -> passed [condition="tool_stdout contains 'PASS'"]
+  test -> failed
+  passed -> exit
+  failed -> exit
+}`)
+	repo := initTestRepo(t)
+	logsRoot := t.TempDir()
+	res, err := Run(context.Background(), dot, RunOptions{RepoPath: repo, LogsRoot: logsRoot})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: %s", res.FinalStatus)
+	}
+	cp, err := runtime.LoadCheckpoint(filepath.Join(logsRoot, "checkpoint.json"))
+	if err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	if !strings.Contains(strings.Join(cp.CompletedNodes, ","), "passed") {
+		t.Fatalf("expected passed branch; completed=%v", cp.CompletedNodes)
+	}
+	if cp.ContextValues["tool_stdout"] != "suite PASS" || cp.ContextValues["tool_stdout.truncated"] != false {
+		t.Fatalf("checkpoint context: tool_stdout=%v truncated=%v", cp.ContextValues["tool_stdout"], cp.ContextValues["tool_stdout.truncated"])
+	}
+}
@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/danshapiro/kilroy/internal/agent"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+// toolCommandVarRE matches {{var}} placeholders in tool_command. Names must
+// start with a letter or underscore, so Go-template text such as
+// `docker inspect --format '{{.Id}}'` is left alone.
+var toolCommandVarRE = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.\-]*)\s*\}\}`)
+
+// expandToolCommand substitutes {{var}} placeholders with shell-escaped values
+// from the run context. A "context." prefix is optional, matching edge
+// conditions. It returns the sorted names that did not resolve; callers must
+// not run the command when any are missing.
+func expandToolCommand(cmd string, ctx *runtime.Context) (string, []string) {
+	missing := map[string]bool{}
+	out := toolCommandVarRE.ReplaceAllStringFunc(cmd, func(m string) string {
+		name := toolCommandVarRE.FindStringSubmatch(m)[1]
+		v, ok := lookupToolCommandVar(ctx, name)
+		if !ok {
+			missing[name] = true
+			return m
+		}
+		return agent.ShellEscape(v)
+	})
+	if len(missing) == 0 {
+		return out, nil
+	}
+	names := make([]string, 0, len(missing))
+	for n := range missing {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return out, names
+}
+
+func lookupToolCommandVar(ctx *runtime.Context, name string) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	for _, key := range []string{name, strings.TrimPrefix(name, "context.")} {
+		if v, ok := ctx.Get(key); ok && v != nil {
+			return fmt.Sprint(v), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestExpandToolCommand_SubstitutesEscapedContextValues(t *testing.T) {
+	ctx := runtime.NewContext()
+	ctx.Set("branch", "feature/x")
+	ctx.Set("outcome", "success")
+	ctx.Set("evil", "a; rm -rf / #")
+	ctx.Set("count", 3)
+
+	got, missing := expandToolCommand("git push origin {{branch}} && echo {{ context.outcome }} {{count}} {{evil}} --format '{{.Id}}'", ctx)
+	if len(missing) != 0 {
+		t.Fatalf("missing: %v", missing)
+	}
+	want := "git push origin feature/x && echo success 3 'a; rm -rf / #' --format '{{.Id}}'"
+	if got != want {
+		t.Fatalf("expanded:\n got %s\nwant %s", got, want)
+	}
+
+	_, missing = expandToolCommand("echo {{nope}} {{branch}} {{also.missing}} {{nope}}", ctx)
+	if !reflect.DeepEqual(missing, []string{"also.missing", "nope"}) {
+		t.Fatalf("missing: got %v", missing)
+	}
+}
+
+func TestToolHandler_UnresolvedTemplateVariableFailsWithoutRunning(t *testing.T) {
+	dir := t.TempDir()
+	node := model.NewNode("t")
+	node.Attrs["tool_command"] = "touch ran.txt && echo {{missing_var}}"
+	exec := &Execution{Context: runtime.NewContext(), LogsRoot: dir, WorktreeDir: dir}
+
+	out, err := (&ToolHandler{}).Execute(context.Background(), exec, node)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if out.Status != runtime.StatusFail || !strings.Contains(out.FailureReason, "missing_var") {
+		t.Fatalf("outcome: %+v", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ran.txt")); !os.IsNotExist(err) {
+		t.Fatalf("command ran despite unresolved variable (stat err=%v)", err)
+	}
+}
+
+func TestRun_ToolCommandTemplateUsesContextWithoutInjection(t *testing.T) {
+	dot := []byte(`digraph G {
+  graph [goal="ship it; touch pwned.txt"]
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  say [shape=parallelogram, tool_command="printf '%s' {{graph.goal}} > goal.txt"]
+  start -> say -> exit
+}`)
+	repo := initTestRepo(t)
+	res, err := Run(context.Background(), dot, RunOptions{RepoPath: repo, LogsRoot: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("final status: %s", res.FinalStatus)
+	}
+	files := runCmdOut(t, repo, "git", "ls-tree", "-r", "--name-only", res.FinalCommitSHA)
+	if strings.Contains(files, "pwned.txt") {
+		t.Fatalf("context value was interpreted by the shell; files:\n%s", files)
+	}
+	goal := runCmdOut(t, repo, "git", "show", res.FinalCommitSHA+":goal.txt")
+	if goal != "ship it; touch pwned.txt" {
+		t.Fatalf("goal.txt: %q", goal)
+	}
+}
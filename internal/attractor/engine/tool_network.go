@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	goruntime "runtime"
+	"strings"
+)
+
+// noNetworkWrapperEnv names a sandbox command to run tool commands under when
+// no_network is on, in place of the built-in network namespace. Its value is
+// split on whitespace and prefixed to `bash -c <tool_command>`, e.g.
+// "firejail --quiet --net=none".
+const noNetworkWrapperEnv = "KILROY_NO_NETWORK_WRAPPER"
+
+// Tool command network isolation modes, recorded in tool_invocation.json.
+const (
+	toolNetworkWrapper   = "wrapper"
+	toolNetworkNamespace = "namespace"
+)
+
+// toolNoNetwork reports whether tool commands must run without network
+// access: RunOptions.NoNetwork or the graph attribute no_network=true.
+func toolNoNetwork(execCtx *Execution) bool {
+	if execCtx == nil {
+		return false
+	}
+	if execCtx.Engine != nil && execCtx.Engine.Options.NoNetwork {
+		return true
+	}
+	return execCtx.Graph != nil && parseBool(execCtx.Graph.Attrs["no_network"], false)
+}
+
+// toolCommandArgv returns the argv that runs cmdStr and the network isolation
+// applied to it: none when network is allowed, else the configured wrapper,
+// else a fresh network namespace where the platform supports one. When
+// neither is available the command runs with network and warning says so.
+func toolCommandArgv(cmdStr string, noNetwork bool) (argv []string, network string, warning string) {
+	argv = []string{"bash", "-c", cmdStr}
+	if !noNetwork {
+		return argv, "", ""
+	}
+	if wrapper := strings.Fields(os.Getenv(noNetworkWrapperEnv)); len(wrapper) > 0 {
+		return append(wrapper, argv...), toolNetworkWrapper, ""
+	}
+	if noNetworkNamespaceSupported {
+		return argv, toolNetworkNamespace, ""
+	}
+	return argv, "", fmt.Sprintf("no_network is not supported on %s without %s; tool_command runs with network access", goruntime.GOOS, noNetworkWrapperEnv)
+}
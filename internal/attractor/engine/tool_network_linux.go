@@ -0,0 +1,30 @@
+//go:build linux
+
+package engine
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+const noNetworkNamespaceSupported = true
+
+// setNoNetworkAttr starts cmd in a new network namespace, which has only a
+// loopback device that is down. It must follow setProcessGroupAttr. Without
+// root it also creates a user namespace mapping the caller's own uid and gid,
+// so files the command writes keep their usual owner.
+func setNoNetworkAttr(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWNET
+	if os.Geteuid() == 0 {
+		return
+	}
+	uid, gid := os.Getuid(), os.Getgid()
+	cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWUSER
+	cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}}
+	cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}}
+	cmd.SysProcAttr.GidMappingsEnableSetgroups = false
+}
@@ -0,0 +1,73 @@
+//go:build linux
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestToolHandler_NoNetworkBlocksConnections(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = c.Close()
+		}
+	}()
+	connect := fmt.Sprintf("exec 3<>/dev/tcp/127.0.0.1/%d", ln.Addr().(*net.TCPAddr).Port)
+
+	run := func(t *testing.T, g *model.Graph, eng *Engine) runtime.Outcome {
+		t.Helper()
+		dir := t.TempDir()
+		node := model.NewNode("t")
+		node.Attrs["tool_command"] = connect
+		if err := os.MkdirAll(filepath.Join(dir, "t"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		out, err := (&ToolHandler{}).Execute(context.Background(), &Execution{Graph: g, Engine: eng, Context: runtime.NewContext(), LogsRoot: dir, WorktreeDir: dir}, node)
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+		if strings.Contains(out.FailureReason, "cannot start tool_command in a network namespace") {
+			t.Skipf("network namespaces unavailable: %s", out.FailureReason)
+		}
+		return out
+	}
+
+	if out := run(t, model.NewGraph("G"), nil); out.Status != runtime.StatusSuccess {
+		t.Fatalf("connection with network allowed: %+v", out)
+	}
+	for name, tc := range map[string]struct {
+		g   *model.Graph
+		eng *Engine
+	}{
+		"graph attribute": {g: func() *model.Graph { g := model.NewGraph("G"); g.Attrs["no_network"] = "true"; return g }()},
+		"run option":      {g: model.NewGraph("G"), eng: &Engine{Options: RunOptions{NoNetwork: true}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			out := run(t, tc.g, tc.eng)
+			if out.Status != runtime.StatusFail {
+				t.Fatalf("connection succeeded under no_network: %+v", out)
+			}
+			if code, ok := outcomeExitCode(out); !ok || code != 1 {
+				t.Fatalf("want bash's connect failure (exit 1), got %+v", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package engine
+
+import "os/exec"
+
+const noNetworkNamespaceSupported = false
+
+// setNoNetworkAttr is never called where network namespaces are unsupported.
+func setNoNetworkAttr(*exec.Cmd) {}
@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+	"github.com/danshapiro/kilroy/internal/attractor/runtime"
+)
+
+func TestToolHandler_NoNetworkUsesConfiguredWrapper(t *testing.T) {
+	t.Setenv(noNetworkWrapperEnv, "env KILROY_WRAPPED=1")
+	dir := t.TempDir()
+	g := model.NewGraph("G")
+	g.Attrs["no_network"] = "true"
+	node := model.NewNode("t")
+	node.Attrs["tool_command"] = `test "$KILROY_WRAPPED" = 1`
+	if err := os.MkdirAll(filepath.Join(dir, "t"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := (&ToolHandler{}).Execute(context.Background(), &Execution{Graph: g, Context: runtime.NewContext(), LogsRoot: dir, WorktreeDir: dir}, node)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if out.Status != runtime.StatusSuccess {
+		t.Fatalf("command did not run under the wrapper: %+v", out)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "t", "tool_invocation.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inv struct {
+		Argv    []string `json:"argv"`
+		Network string   `json:"network"`
+	}
+	if err := json.Unmarshal(b, &inv); err != nil {
+		t.Fatal(err)
+	}
+	if inv.Network != toolNetworkWrapper || len(inv.Argv) != 5 || inv.Argv[0] != "env" {
+		t.Fatalf("tool_invocation.json: %s", b)
+	}
+}
+
+func TestResume_RestoresNoNetworkFromManifest(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv(noNetworkWrapperEnv, "env KILROY_WRAPPED=1")
+
+	repo := t.TempDir()
+	runCmd(t, repo, "git", "init")
+	runCmd(t, repo, "git", "config", "user.name", "tester")
+	runCmd(t, repo, "git", "config", "user.email", "tester@example.com")
+	_ = os.WriteFile(filepath.Join(repo, "README.md"), []byte("hello\n"), 0o644)
+	runCmd(t, repo, "git", "add", "-A")
+	runCmd(t, repo, "git", "commit", "-m", "init")
+
+	dot := []byte(`
+digraph G {
+  graph [goal="offline"]
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  a [shape=parallelogram, tool_command="test \"$KILROY_WRAPPED\" = 1"]
+  b [shape=parallelogram, tool_command="test \"$KILROY_WRAPPED\" = 1"]
+  start -> a -> b -> exit
+}
+`)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	res, err := Run(ctx, dot, RunOptions{RepoPath: repo, NoNetwork: true})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.FinalStatus != runtime.FinalSuccess {
+		t.Fatalf("Run final status: %q", res.FinalStatus)
+	}
+
+	b, err := os.ReadFile(filepath.Join(res.LogsRoot, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if !m.NoNetwork {
+		t.Fatalf("manifest no_network not recorded: %s", b)
+	}
+
+	cpPath := filepath.Join(res.LogsRoot, "checkpoint.json")
+	cp, err := runtime.LoadCheckpoint(cpPath)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	cp.CurrentNode = "a"
+	cp.CompletedNodes = []string{"start", "a"}
+	if err := cp.Save(cpPath); err != nil {
+		t.Fatalf("Save checkpoint: %v", err)
+	}
+
+	res2, err := Resume(ctx, res.LogsRoot)
+	if err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	b, err = os.ReadFile(filepath.Join(res2.LogsRoot, "b", "tool_invocation.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inv struct {
+		Network string `json:"network"`
+	}
+	if err := json.Unmarshal(b, &inv); err != nil {
+		t.Fatal(err)
+	}
+	if inv.Network != toolNetworkWrapper {
+		t.Fatalf("resumed node b network: got %q want %q", inv.Network, toolNetworkWrapper)
+	}
+}
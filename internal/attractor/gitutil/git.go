@@ -2,8 +2,11 @@ package gitutil
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 )
 
@@ -60,6 +63,49 @@ func HeadSHA(dir string) (string, error) {
 	return strings.TrimSpace(out), nil
 }
 
+// CurrentBranch returns the short name of the checked out branch, or "" when
+// HEAD is detached.
+func CurrentBranch(dir string) (string, error) {
+	out, _, err := runGit(dir, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		var ce *CommandError
+		if errors.As(err, &ce) {
+			var ee *exec.ExitError
+			// symbolic-ref --quiet exits 1 with no output when HEAD is detached.
+			if errors.As(ce.Err, &ee) && ee.ExitCode() == 1 && strings.TrimSpace(ce.Stderr) == "" {
+				return "", nil
+			}
+		}
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// InProgressOperation reports an unfinished rebase, merge, cherry-pick, or
+// revert in dir, or "" when none is in progress.
+func InProgressOperation(dir string) (string, error) {
+	markers := []struct {
+		path string
+		op   string
+	}{
+		{"rebase-merge", "rebase"},
+		{"rebase-apply", "rebase"},
+		{"MERGE_HEAD", "merge"},
+		{"CHERRY_PICK_HEAD", "cherry-pick"},
+		{"REVERT_HEAD", "revert"},
+	}
+	for _, m := range markers {
+		out, _, err := runGit(dir, "rev-parse", "--path-format=absolute", "--git-path", m.path)
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(strings.TrimSpace(out)); err == nil {
+			return m.op, nil
+		}
+	}
+	return "", nil
+}
+
 func StatusPorcelain(dir string) (string, error) {
 	out, _, err := runGit(dir, "status", "--porcelain")
 	if err != nil {
@@ -142,9 +188,7 @@ func commitAllowEmpty(worktreeDir, message string) (string, error) {
 	if err != nil {
 		// If identity is missing, retry once with an explicit fallback committer identity
 		// (without mutating repo config).
-		if strings.Contains(err.Error(), "Author identity unknown") ||
-			strings.Contains(err.Error(), "Please tell me who you are") ||
-			strings.Contains(err.Error(), "unable to auto-detect email address") {
+		if isMissingIdentity(err) {
 			_, _, err = runGit(
 				worktreeDir,
 				"-c", "user.name=kilroy-attractor",
@@ -159,6 +203,88 @@ func commitAllowEmpty(worktreeDir, message string) (string, error) {
 	return HeadSHA(worktreeDir)
 }
 
+// CountCommits returns the number of commits in revRange (e.g. "base..HEAD").
+func CountCommits(dir, revRange string) (int, error) {
+	out, _, err := runGit(dir, "rev-list", "--count", revRange)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(out))
+}
+
+// SquashOldCommits collapses the oldest commits after base on the branch
+// checked out in worktreeDir into one commit with the given message, keeping
+// the newest keep commits. The kept commits are recreated with their original
+// trees and messages on top of the squashed one, so HEAD's tree (and therefore
+// the index and worktree) is unchanged. It returns the new HEAD and the SHAs
+// that were squashed, oldest first; both are empty when there are fewer than
+// two commits to squash or the range contains a merge commit.
+func SquashOldCommits(worktreeDir, base string, keep int, message string) (string, []string, error) {
+	if keep < 1 {
+		keep = 1
+	}
+	head, err := HeadSHA(worktreeDir)
+	if err != nil {
+		return "", nil, err
+	}
+	out, _, err := runGit(worktreeDir, "rev-list", "--reverse", "--parents", base+".."+head)
+	if err != nil {
+		return "", nil, err
+	}
+	var commits []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			// Merges (or a root commit) make the range non-linear.
+			return "", nil, nil
+		}
+		commits = append(commits, fields[0])
+	}
+	if len(commits)-keep < 2 {
+		return "", nil, nil
+	}
+	squashed := commits[:len(commits)-keep]
+
+	newHead, err := commitTree(worktreeDir, squashed[len(squashed)-1]+"^{tree}", base, message)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, c := range commits[len(squashed):] {
+		msg, _, err := runGit(worktreeDir, "log", "-1", "--format=%B", c)
+		if err != nil {
+			return "", nil, err
+		}
+		if newHead, err = commitTree(worktreeDir, c+"^{tree}", newHead, strings.TrimSpace(msg)); err != nil {
+			return "", nil, err
+		}
+	}
+	// HEAD is a symbolic ref to the branch, so this moves the branch; the old
+	// value guards against a concurrent commit.
+	if _, _, err := runGit(worktreeDir, "update-ref", "-m", "squash old commits", "HEAD", newHead, head); err != nil {
+		return "", nil, err
+	}
+	return newHead, append([]string{}, squashed...), nil
+}
+
+func commitTree(dir, tree, parent, message string) (string, error) {
+	out, _, err := runGit(dir, "commit-tree", tree, "-p", parent, "-m", message)
+	if err != nil && isMissingIdentity(err) {
+		out, _, err = runGit(
+			dir,
+			"-c", "user.name=kilroy-attractor",
+			"-c", "user.email=kilroy-attractor@local",
+			"commit-tree", tree, "-p", parent, "-m", message,
+		)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
 // PushBranch pushes a branch to the specified remote.
 // It is a best-effort operation; failures are returned but should not abort a run.
 func PushBranch(repoDir, remote, branch string) error {
@@ -177,6 +303,37 @@ func FastForwardFFOnly(worktreeDir, otherRef string) error {
 	return MergeFastForwardOnly(worktreeDir, otherRef)
 }
 
+// MergeOrAbort merges otherRef into the checked out branch, fast-forwarding
+// when possible and otherwise creating a merge commit with message. On a
+// conflict the merge is aborted, leaving the worktree as it was, and the
+// conflicting paths are returned with the error.
+func MergeOrAbort(worktreeDir, otherRef, message string) ([]string, error) {
+	args := []string{"merge", "--no-edit", "-m", message, otherRef}
+	_, _, err := runGit(worktreeDir, args...)
+	if err != nil && isMissingIdentity(err) {
+		_, _, err = runGit(worktreeDir, append([]string{
+			"-c", "user.name=kilroy-attractor",
+			"-c", "user.email=kilroy-attractor@local",
+		}, args...)...)
+	}
+	if err == nil {
+		return nil, nil
+	}
+	out, _, diffErr := runGit(worktreeDir, "diff", "--name-only", "--diff-filter=U")
+	var conflicts []string
+	if diffErr == nil {
+		for _, line := range strings.Split(out, "\n") {
+			if trimmed := strings.TrimSpace(line); trimmed != "" {
+				conflicts = append(conflicts, trimmed)
+			}
+		}
+	}
+	if op, _ := InProgressOperation(worktreeDir); op == "merge" {
+		_, _, _ = runGit(worktreeDir, "merge", "--abort")
+	}
+	return conflicts, err
+}
+
 // DiffNameOnly returns file paths changed between baseRef and HEAD in the given directory.
 func DiffNameOnly(dir, baseRef string) ([]string, error) {
 	out, _, err := runGit(dir, "diff", "--name-only", baseRef)
@@ -192,6 +349,12 @@ func DiffNameOnly(dir, baseRef string) ([]string, error) {
 	return files, nil
 }
 
+func isMissingIdentity(err error) bool {
+	return strings.Contains(err.Error(), "Author identity unknown") ||
+		strings.Contains(err.Error(), "Please tell me who you are") ||
+		strings.Contains(err.Error(), "unable to auto-detect email address")
+}
+
 func ensureUserIdentity(worktreeDir string) error {
 	name, _, err := runGit(worktreeDir, "config", "--get", "user.name")
 	if err != nil {
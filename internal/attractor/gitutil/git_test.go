@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -89,3 +90,172 @@ func TestDiffNameOnly_NoChanges(t *testing.T) {
 		t.Errorf("DiffNameOnly with no changes = %v, want []", files)
 	}
 }
+
+func TestCurrentBranch_ReturnsEmptyWhenDetached(t *testing.T) {
+	dir := initTestRepo(t)
+
+	branch, err := CurrentBranch(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branch != "main" {
+		t.Fatalf("branch=%q want main", branch)
+	}
+
+	if out, err := exec.Command("git", "-C", dir, "checkout", "--detach").CombinedOutput(); err != nil {
+		t.Fatalf("git checkout --detach: %v\n%s", err, out)
+	}
+	branch, err = CurrentBranch(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if branch != "" {
+		t.Fatalf("branch=%q want empty for detached HEAD", branch)
+	}
+}
+
+func TestInProgressOperation_DetectsMerge(t *testing.T) {
+	dir := initTestRepo(t)
+
+	op, err := InProgressOperation(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op != "" {
+		t.Fatalf("op=%q want empty for clean repo", op)
+	}
+
+	head, err := HeadSHA(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".git", "MERGE_HEAD"), []byte(head+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	op, err = InProgressOperation(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op != "merge" {
+		t.Fatalf("op=%q want merge", op)
+	}
+}
+
+func TestSquashOldCommits_KeepsNewestCommitsAndHeadTree(t *testing.T) {
+	dir := initTestRepo(t)
+	base, err := HeadSHA(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var original []string
+	for i := 1; i <= 5; i++ {
+		if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte{byte('0' + i)}, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		sha, err := CommitAllowEmpty(dir, "step "+string(rune('0'+i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		original = append(original, sha)
+	}
+	treeOf := func(rev string) string {
+		out, _, err := runGit(dir, "rev-parse", rev+"^{tree}")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	oldTree := treeOf("HEAD")
+
+	head, squashed, err := SquashOldCommits(dir, base, 2, "squashed")
+	if err != nil {
+		t.Fatalf("SquashOldCommits: %v", err)
+	}
+	if len(squashed) != 3 || squashed[0] != original[0] || squashed[2] != original[2] {
+		t.Fatalf("squashed=%v want the first 3 of %v", squashed, original)
+	}
+	if got, _ := HeadSHA(dir); got != head {
+		t.Fatalf("HEAD=%s want %s", got, head)
+	}
+	if treeOf("HEAD") != oldTree {
+		t.Fatal("HEAD tree changed")
+	}
+	if clean, err := IsClean(dir); err != nil || !clean {
+		t.Fatalf("worktree not clean after squash: clean=%v err=%v", clean, err)
+	}
+	out, _, err := runGit(dir, "log", "--format=%s", base+"..HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.ReplaceAll(strings.TrimSpace(out), "\n", "|"); got != "step 5|step 4|squashed" {
+		t.Fatalf("history=%q", out)
+	}
+	if treeOf("HEAD~2") != treeOf(original[2]) {
+		t.Fatal("squashed commit should carry the tree of the newest squashed commit")
+	}
+
+	// Nothing left to squash: one commit beyond the kept ones is not enough.
+	if head, squashed, err := SquashOldCommits(dir, base, 2, "again"); err != nil || head != "" || squashed != nil {
+		t.Fatalf("second squash: head=%q squashed=%v err=%v", head, squashed, err)
+	}
+}
+
+func TestMergeOrAbort_MergesAndAbortsOnConflict(t *testing.T) {
+	dir := initTestRepo(t)
+	base, err := HeadSHA(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitOn := func(branch, file, content string) string {
+		t.Helper()
+		if err := CreateBranchAt(dir, branch, base); err != nil {
+			t.Fatal(err)
+		}
+		if err := CheckoutBranch(dir, branch); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		sha, err := CommitAllowEmpty(dir, branch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sha
+	}
+	a := commitOn("a", "a.txt", "a")
+	b := commitOn("b", "b.txt", "b")
+	c := commitOn("c", "a.txt", "conflicting")
+	if err := CheckoutBranch(dir, "main"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sha := range []string{a, b} {
+		if conflicts, err := MergeOrAbort(dir, sha, "merge "+sha); err != nil {
+			t.Fatalf("merge %s: %v (conflicts %v)", sha, err, conflicts)
+		}
+	}
+	for _, f := range []string{"a.txt", "b.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Fatalf("%s missing after merge: %v", f, err)
+		}
+	}
+	head, _ := HeadSHA(dir)
+
+	conflicts, err := MergeOrAbort(dir, c, "merge c")
+	if err == nil {
+		t.Fatal("expected conflict error")
+	}
+	if strings.Join(conflicts, ",") != "a.txt" {
+		t.Fatalf("conflicts = %v, want [a.txt]", conflicts)
+	}
+	if op, _ := InProgressOperation(dir); op != "" {
+		t.Fatalf("merge left in progress: %q", op)
+	}
+	if clean, _ := IsClean(dir); !clean {
+		t.Fatal("worktree not clean after aborted merge")
+	}
+	if got, _ := HeadSHA(dir); got != head {
+		t.Fatalf("HEAD moved to %s after aborted merge, want %s", got, head)
+	}
+}
@@ -0,0 +1,198 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/danshapiro/kilroy/internal/llm"
+	"github.com/danshapiro/kilroy/internal/llm/providers/openaicompat"
+)
+
+//go:embed ingest_api_prompt.tmpl
+var ingestAPIPromptTmpl string
+
+var ingestAPIPrompt = template.Must(template.New("ingest_api").Parse(ingestAPIPromptTmpl))
+
+// Ingest backends.
+const (
+	BackendCLI = "cli" // Claude Code CLI (default)
+	BackendAPI = "api" // OpenAI-compatible chat completions via llm.Client
+)
+
+// apiProvider is the provider name the API backend registers its adapter
+// under.
+const apiProvider = "ingest"
+
+// maxAPIToolReadBytes caps how much of a repo file one read_file call returns.
+const maxAPIToolReadBytes = 64 << 10
+
+func buildAPIPrompt(requirements string) string {
+	var buf bytes.Buffer
+	_ = ingestAPIPrompt.Execute(&buf, struct {
+		Requirements string
+	}{requirements})
+	return buf.String()
+}
+
+// newAPIClient registers an openaicompat adapter for the ingest endpoint.
+// KILROY_INGEST_API_KEY (falling back to OPENAI_API_KEY) authenticates;
+// KILROY_INGEST_BASE_URL and KILROY_INGEST_API_PATH select the endpoint and
+// default to OpenAI's chat completions API.
+func newAPIClient() (*llm.Client, error) {
+	apiKey := envOr("KILROY_INGEST_API_KEY", os.Getenv("OPENAI_API_KEY"))
+	if apiKey == "" {
+		return nil, fmt.Errorf("api backend requires KILROY_INGEST_API_KEY or OPENAI_API_KEY")
+	}
+	c := llm.NewClient()
+	c.Register(openaicompat.NewAdapter(openaicompat.Config{
+		Provider:   apiProvider,
+		APIKey:     apiKey,
+		BaseURL:    envOr("KILROY_INGEST_BASE_URL", "https://api.openai.com"),
+		Path:       envOr("KILROY_INGEST_API_PATH", "/v1/chat/completions"),
+		OptionsKey: "openai",
+	}))
+	return c, nil
+}
+
+// runAPI performs ingestion through an LLM API instead of the claude CLI. The
+// skill file is the system prompt; the model can inspect the repo with
+// read-only list_dir/read_file tools for up to MaxTurns rounds and must answer
+// with the digraph, which is extracted from its final reply. A reply without a
+// digraph gets a follow-up asking for only the digraph.
+func runAPI(ctx context.Context, opts Options) (*Result, error) {
+	skill, err := readSkill(opts.SkillPath)
+	if err != nil {
+		return nil, fmt.Errorf("skill file not found: %s: %w", opts.SkillPath, err)
+	}
+	client := opts.Client
+	if client == nil {
+		client, err = newAPIClient()
+		if err != nil {
+			return nil, err
+		}
+	}
+	maxTurns := opts.MaxTurns
+	if maxTurns <= 0 {
+		maxTurns = 15
+	}
+	system := string(skill)
+	prompt := buildAPIPrompt(opts.Requirements)
+	var lastReply string
+	return runWithExtractRetries(opts, func(retry bool) (string, error) {
+		gen := llm.GenerateOptions{
+			Client: client,
+			Model:  opts.Model,
+			System: &system,
+		}
+		if retry {
+			// Follow up on the previous reply without tools so the model
+			// answers directly.
+			gen.Messages = []llm.Message{llm.User(prompt)}
+			if strings.TrimSpace(lastReply) != "" {
+				gen.Messages = append(gen.Messages, llm.Assistant(lastReply))
+			}
+			gen.Messages = append(gen.Messages, llm.User(apiExtractRetryPrompt))
+		} else {
+			gen.Prompt = &prompt
+			gen.Tools = repoTools(opts.RepoPath)
+			gen.MaxToolRounds = &maxTurns
+		}
+		res, err := llm.Generate(ctx, gen)
+		if err != nil {
+			return "", fmt.Errorf("ingest api request failed: %w", err)
+		}
+		lastReply = res.Text
+		dotContent, err := ExtractDigraph(res.Text)
+		if err != nil {
+			return "", &extractError{fmt.Errorf("model reply did not contain a digraph: %w", err)}
+		}
+		return dotContent, nil
+	})
+}
+
+// repoTools gives the model read-only access to the repository, mirroring the
+// --add-dir access the CLI backend grants. Paths are relative to repoPath and
+// may not escape it.
+func repoTools(repoPath string) []llm.Tool {
+	if strings.TrimSpace(repoPath) == "" {
+		return nil
+	}
+	pathSchema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{"type": "string", "description": "Path relative to the repository root."},
+		},
+		"required": []any{"path"},
+	}
+	return []llm.Tool{
+		{
+			Definition: llm.ToolDefinition{
+				Name:        "list_dir",
+				Description: "List the entries of a directory in the repository. Directories end with /.",
+				Parameters:  pathSchema,
+			},
+			Execute: func(ctx context.Context, args any) (any, error) {
+				p, err := repoToolPath(repoPath, args)
+				if err != nil {
+					return nil, err
+				}
+				entries, err := os.ReadDir(p)
+				if err != nil {
+					return nil, err
+				}
+				names := make([]string, 0, len(entries))
+				for _, e := range entries {
+					name := e.Name()
+					if e.IsDir() {
+						name += "/"
+					}
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				return strings.Join(names, "\n"), nil
+			},
+		},
+		{
+			Definition: llm.ToolDefinition{
+				Name:        "read_file",
+				Description: "Read a text file in the repository.",
+				Parameters:  pathSchema,
+			},
+			Execute: func(ctx context.Context, args any) (any, error) {
+				p, err := repoToolPath(repoPath, args)
+				if err != nil {
+					return nil, err
+				}
+				b, err := os.ReadFile(p)
+				if err != nil {
+					return nil, err
+				}
+				if len(b) > maxAPIToolReadBytes {
+					return string(b[:maxAPIToolReadBytes]) + "\n[truncated]", nil
+				}
+				return string(b), nil
+			},
+		},
+	}
+}
+
+func repoToolPath(repoPath string, args any) (string, error) {
+	m, _ := args.(map[string]any)
+	rel, _ := m["path"].(string)
+	root, err := filepath.Abs(repoPath)
+	if err != nil {
+		return "", err
+	}
+	p := filepath.Join(root, filepath.FromSlash(strings.TrimSpace(rel)))
+	if r, err := filepath.Rel(root, p); err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q is outside the repository", rel)
+	}
+	return p, nil
+}
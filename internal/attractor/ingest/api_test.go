@@ -0,0 +1,116 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const apiTestDigraph = `digraph G {
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  t [shape=parallelogram, tool_command="echo hi"]
+  start -> t -> exit
+}`
+
+func TestRun_APIBackend_UsesToolsAndExtractsDigraph(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte("repo readme marker"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	skillPath := filepath.Join(t.TempDir(), "SKILL.md")
+	if err := os.WriteFile(skillPath, []byte("skill body marker"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer k" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		_ = json.Unmarshal(b, &body)
+		mu.Lock()
+		bodies = append(bodies, body)
+		n := len(bodies)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if n == 1 {
+			_, _ = w.Write([]byte(`{"id":"c1","model":"m","choices":[{"finish_reason":"tool_calls","message":{"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"read_file","arguments":"{\"path\":\"README.md\"}"}}]}}]}`))
+			return
+		}
+		reply, _ := json.Marshal("Here is the pipeline:\n```dot\n" + apiTestDigraph + "\n```\n")
+		_, _ = w.Write([]byte(`{"id":"c2","model":"m","choices":[{"finish_reason":"stop","message":{"role":"assistant","content":` + string(reply) + `}}]}`))
+	}))
+	defer srv.Close()
+	t.Setenv("KILROY_INGEST_API_KEY", "k")
+	t.Setenv("KILROY_INGEST_BASE_URL", srv.URL)
+
+	res, err := Run(context.Background(), Options{
+		Backend:      BackendAPI,
+		Requirements: "Line one\n\nLine two",
+		SkillPath:    skillPath,
+		Model:        "m",
+		RepoPath:     repo,
+		Validate:     true,
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if strings.TrimSpace(res.DotContent) != apiTestDigraph {
+		t.Fatalf("DotContent = %q", res.DotContent)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("requests = %d, want 2", len(bodies))
+	}
+	first, _ := json.Marshal(bodies[0])
+	for _, want := range []string{"skill body marker", `Line one\n\nLine two`, `"read_file"`, `"list_dir"`} {
+		if !strings.Contains(string(first), want) {
+			t.Fatalf("first request missing %s: %s", want, first)
+		}
+	}
+	second, _ := json.Marshal(bodies[1])
+	if !strings.Contains(string(second), "repo readme marker") {
+		t.Fatalf("second request should carry the read_file result: %s", second)
+	}
+}
+
+func TestRun_APIBackend_RequiresAPIKey(t *testing.T) {
+	skillPath := filepath.Join(t.TempDir(), "SKILL.md")
+	_ = os.WriteFile(skillPath, []byte("skill"), 0o644)
+	t.Setenv("KILROY_INGEST_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	_, err := Run(context.Background(), Options{Backend: BackendAPI, SkillPath: skillPath, Model: "m", Requirements: "x"})
+	if err == nil || !strings.Contains(err.Error(), "KILROY_INGEST_API_KEY") {
+		t.Fatalf("expected missing key error, got %v", err)
+	}
+}
+
+func TestRun_UnknownBackend(t *testing.T) {
+	_, err := Run(context.Background(), Options{Backend: "carrier-pigeon"})
+	if err == nil || !strings.Contains(err.Error(), "unknown ingest backend") {
+		t.Fatalf("expected unknown backend error, got %v", err)
+	}
+}
+
+func TestRepoToolPath_StaysInsideRepo(t *testing.T) {
+	repo := t.TempDir()
+	if _, err := repoToolPath(repo, map[string]any{"path": "docs/a.md"}); err != nil {
+		t.Fatalf("in-repo path rejected: %v", err)
+	}
+	for _, p := range []string{"../secret", "docs/../../secret"} {
+		if _, err := repoToolPath(repo, map[string]any{"path": p}); err == nil {
+			t.Fatalf("path %q should be rejected", p)
+		}
+	}
+}
@@ -7,65 +7,159 @@ import (
 
 // ExtractDigraph extracts a DOT digraph block from LLM output text.
 // It handles: raw digraph, markdown-fenced digraphs, leading/trailing commentary.
-// It uses brace counting (respecting quoted strings) to find the matching closing brace.
+// Fenced code blocks are searched first, so prose that mentions "digraph"
+// before the fence does not capture the match. The block ends at the brace
+// that balances the first `digraph <id> {` header (respecting quoted strings
+// and comments); anything after it is dropped.
 func ExtractDigraph(text string) (string, error) {
 	text = strings.TrimSpace(text)
 	if text == "" {
 		return "", fmt.Errorf("empty input")
 	}
-
-	// Find "digraph" keyword.
-	idx := strings.Index(text, "digraph")
-	if idx == -1 {
-		return "", fmt.Errorf("no digraph found in output")
+	for _, body := range fencedBlocks(text) {
+		if dot, err := extractFirstDigraph(body); err == nil {
+			return dot, nil
+		}
 	}
+	return extractFirstDigraph(text)
+}
 
-	// Start from the digraph keyword.
-	sub := text[idx:]
-
-	// Find the opening brace.
-	openIdx := strings.Index(sub, "{")
-	if openIdx == -1 {
-		return "", fmt.Errorf("digraph has no opening brace")
+// fencedBlocks returns the bodies of markdown ``` code blocks in order. An
+// unterminated fence runs to the end of the text.
+func fencedBlocks(text string) []string {
+	var blocks []string
+	var body []string
+	inFence := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			if inFence {
+				blocks = append(blocks, strings.Join(body, "\n"))
+				body = nil
+			}
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			body = append(body, line)
+		}
 	}
+	if inFence && len(body) > 0 {
+		blocks = append(blocks, strings.Join(body, "\n"))
+	}
+	return blocks
+}
 
-	// Count braces to find the matching close, respecting quoted strings.
-	depth := 0
-	inQuote := false
-	escape := false
-	closeIdx := -1
-
-	for i := openIdx; i < len(sub); i++ {
-		ch := sub[i]
-		if escape {
-			escape = false
-			continue
+// extractFirstDigraph returns the first `digraph [id] { ... }` block in text.
+// Occurrences of the word that are not followed by a graph header (such as
+// "Here is the digraph:") are skipped.
+func extractFirstDigraph(text string) (string, error) {
+	sawKeyword := false
+	for from := 0; ; {
+		rel := strings.Index(text[from:], "digraph")
+		if rel == -1 {
+			break
 		}
-		if ch == '\\' && inQuote {
-			escape = true
+		idx := from + rel
+		from = idx + len("digraph")
+		if idx > 0 && isDOTIDByte(text[idx-1]) {
 			continue
 		}
-		if ch == '"' {
-			inQuote = !inQuote
+		sawKeyword = true
+		openIdx, ok := digraphHeaderEnd(text, from)
+		if !ok {
 			continue
 		}
+		closeIdx := matchingBrace(text, openIdx)
+		if closeIdx == -1 {
+			return "", fmt.Errorf("unmatched braces in digraph")
+		}
+		return text[idx : closeIdx+1], nil
+	}
+	if sawKeyword {
+		return "", fmt.Errorf("digraph has no opening brace")
+	}
+	return "", fmt.Errorf("no digraph found in output")
+}
+
+// digraphHeaderEnd checks that text[i:] continues a digraph header, i.e. an
+// optional identifier or quoted name followed by "{", and returns the index of
+// that brace.
+func digraphHeaderEnd(text string, i int) (int, bool) {
+	if i < len(text) && isDOTIDByte(text[i]) {
+		return 0, false
+	}
+	i = skipSpace(text, i)
+	if i < len(text) && text[i] == '"' {
+		i++
+		for i < len(text) && text[i] != '"' {
+			if text[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		i++
+	} else {
+		for i < len(text) && isDOTIDByte(text[i]) {
+			i++
+		}
+	}
+	i = skipSpace(text, i)
+	if i < len(text) && text[i] == '{' {
+		return i, true
+	}
+	return 0, false
+}
+
+// matchingBrace returns the index of the brace closing the one at openIdx,
+// ignoring braces inside quoted strings and // , /* */ and # comments, or -1.
+func matchingBrace(text string, openIdx int) int {
+	depth := 0
+	inQuote := false
+	for i := openIdx; i < len(text); i++ {
+		ch := text[i]
 		if inQuote {
+			if ch == '\\' {
+				i++
+			} else if ch == '"' {
+				inQuote = false
+			}
 			continue
 		}
-		if ch == '{' {
+		switch {
+		case ch == '"':
+			inQuote = true
+		case ch == '/' && i+1 < len(text) && text[i+1] == '/',
+			ch == '#' && (i == 0 || text[i-1] == '\n'):
+			if nl := strings.IndexByte(text[i:], '\n'); nl != -1 {
+				i += nl
+			} else {
+				i = len(text)
+			}
+		case ch == '/' && i+1 < len(text) && text[i+1] == '*':
+			if end := strings.Index(text[i+2:], "*/"); end != -1 {
+				i += end + 3
+			} else {
+				i = len(text)
+			}
+		case ch == '{':
 			depth++
-		} else if ch == '}' {
+		case ch == '}':
 			depth--
 			if depth == 0 {
-				closeIdx = i
-				break
+				return i
 			}
 		}
 	}
+	return -1
+}
 
-	if closeIdx == -1 {
-		return "", fmt.Errorf("unmatched braces in digraph")
+func skipSpace(text string, i int) int {
+	for i < len(text) && (text[i] == ' ' || text[i] == '\t' || text[i] == '\n' || text[i] == '\r') {
+		i++
 	}
+	return i
+}
 
-	return sub[:closeIdx+1], nil
+func isDOTIDByte(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
 }
@@ -0,0 +1,58 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Follow-up instructions sent when an attempt produced no digraph.
+const (
+	cliExtractRetryPrompt = "IMPORTANT: your previous attempt did not produce a DOT digraph in pipeline.dot. " +
+		"Write ONLY the digraph to pipeline.dot: no prose, no markdown fences, nothing before `digraph` or after the closing brace."
+	apiExtractRetryPrompt = "Your reply did not contain a DOT digraph. " +
+		"Output ONLY the digraph: no prose and no markdown fences, starting with `digraph` and ending with the closing brace."
+)
+
+// extractError marks a failure to get a digraph out of the model's output,
+// which a firmer follow-up prompt may fix. Other errors (the CLI failing, the
+// API rejecting the request) are not retried.
+type extractError struct{ err error }
+
+func (e *extractError) Error() string { return e.err.Error() }
+func (e *extractError) Unwrap() error { return e.err }
+
+// runWithExtractRetries calls attempt until it yields a digraph, re-prompting
+// up to opts.MaxExtractRetries times after an extractError. Each failed
+// attempt is recorded in the result's warnings. When retries run out the first
+// error is returned.
+func runWithExtractRetries(opts Options, attempt func(retry bool) (string, error)) (*Result, error) {
+	retries := opts.MaxExtractRetries
+	if retries == 0 {
+		retries = 1
+	}
+	if retries < 0 {
+		retries = 0
+	}
+
+	var warnings []string
+	var firstErr error
+	for i := 0; i <= retries; i++ {
+		dotContent, err := attempt(i > 0)
+		if err == nil {
+			res, err := finishResult(dotContent, opts)
+			if res != nil {
+				res.Warnings = append(warnings, res.Warnings...)
+			}
+			return res, err
+		}
+		var xe *extractError
+		if !errors.As(err, &xe) {
+			return nil, err
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		warnings = append(warnings, fmt.Sprintf("attempt %d/%d produced no digraph: %v", i+1, retries+1, err))
+	}
+	return nil, firstErr
+}
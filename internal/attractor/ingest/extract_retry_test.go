@@ -0,0 +1,124 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func chatReply(t *testing.T, w http.ResponseWriter, text string) {
+	t.Helper()
+	content, _ := json.Marshal(text)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"id":"c","model":"m","choices":[{"finish_reason":"stop","message":{"role":"assistant","content":` + string(content) + `}}]}`))
+}
+
+func TestRun_APIBackend_RetriesWhenReplyHasNoDigraph(t *testing.T) {
+	skillPath := filepath.Join(t.TempDir(), "SKILL.md")
+	_ = os.WriteFile(skillPath, []byte("skill"), 0o644)
+
+	var calls atomic.Int32
+	var retryBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if calls.Add(1) == 1 {
+			chatReply(t, w, "I would design a pipeline with a build step and a test step.")
+			return
+		}
+		retryBody = string(b)
+		chatReply(t, w, apiTestDigraph)
+	}))
+	defer srv.Close()
+	t.Setenv("KILROY_INGEST_API_KEY", "k")
+	t.Setenv("KILROY_INGEST_BASE_URL", srv.URL)
+
+	res, err := Run(context.Background(), Options{Backend: BackendAPI, SkillPath: skillPath, Model: "m", Requirements: "x"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if strings.TrimSpace(res.DotContent) != apiTestDigraph {
+		t.Fatalf("DotContent = %q", res.DotContent)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("calls = %d, want 2", calls.Load())
+	}
+	if !strings.Contains(retryBody, "Output ONLY the digraph") || !strings.Contains(retryBody, "build step and a test step") {
+		t.Fatalf("retry request should follow up on the previous reply: %s", retryBody)
+	}
+	if len(res.Warnings) != 1 || !strings.Contains(res.Warnings[0], "attempt 1/2 produced no digraph") {
+		t.Fatalf("Warnings = %v", res.Warnings)
+	}
+}
+
+func TestRun_APIBackend_GivesUpAfterRetries(t *testing.T) {
+	skillPath := filepath.Join(t.TempDir(), "SKILL.md")
+	_ = os.WriteFile(skillPath, []byte("skill"), 0o644)
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		chatReply(t, w, "no graph here")
+	}))
+	defer srv.Close()
+	t.Setenv("KILROY_INGEST_API_KEY", "k")
+	t.Setenv("KILROY_INGEST_BASE_URL", srv.URL)
+
+	_, err := Run(context.Background(), Options{Backend: BackendAPI, SkillPath: skillPath, Model: "m", Requirements: "x", MaxExtractRetries: 2})
+	if err == nil || !strings.Contains(err.Error(), "did not contain a digraph") {
+		t.Fatalf("expected extraction error, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("calls = %d, want 3", calls.Load())
+	}
+
+	calls.Store(0)
+	if _, err := Run(context.Background(), Options{Backend: BackendAPI, SkillPath: skillPath, Model: "m", Requirements: "x", MaxExtractRetries: -1}); err == nil {
+		t.Fatal("expected error with retries disabled")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("calls with retries disabled = %d, want 1", calls.Load())
+	}
+}
+
+func TestRun_CLIBackend_RetriesWithFirmerPrompt(t *testing.T) {
+	tmpDir := t.TempDir()
+	counter := filepath.Join(tmpDir, "count")
+	dotFile := filepath.Join(tmpDir, "good.dot")
+	if err := os.WriteFile(dotFile, []byte(apiTestDigraph), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// First run writes prose; the retry (whose prompt mentions "ONLY the
+	// digraph") writes the graph.
+	script := "#!/bin/sh\necho x >> '" + counter + "'\n" +
+		"for a in \"$@\"; do case \"$a\" in *'ONLY the digraph'*) cp '" + dotFile + "' ./pipeline.dot; exit 0;; esac; done\n" +
+		"echo 'Here is my plan in words.' > ./pipeline.dot\n"
+	mock := filepath.Join(tmpDir, "claude")
+	if err := os.WriteFile(mock, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	skillPath := filepath.Join(tmpDir, "SKILL.md")
+	_ = os.WriteFile(skillPath, []byte("# Test Skill\n"), 0o644)
+	t.Setenv("KILROY_CLAUDE_PATH", mock)
+
+	res, err := Run(context.Background(), Options{Requirements: "Build something", SkillPath: skillPath, Model: "m"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.DotContent != apiTestDigraph {
+		t.Fatalf("DotContent = %q", res.DotContent)
+	}
+	b, _ := os.ReadFile(counter)
+	if n := strings.Count(string(b), "x"); n != 2 {
+		t.Fatalf("claude invocations = %d, want 2", n)
+	}
+	if len(res.Warnings) != 1 || !strings.Contains(res.Warnings[0], "no digraph found") {
+		t.Fatalf("Warnings = %v", res.Warnings)
+	}
+}
@@ -1,6 +1,7 @@
 package ingest
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -86,3 +87,49 @@ func TestExtractDigraph(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractDigraph_Exact(t *testing.T) {
+	const graph = "digraph foo {\n    start [shape=Mdiamond]\n    exit [shape=Msquare]\n    start -> exit\n}"
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"fenced", "```dot\n" + graph + "\n```"},
+		{"fenced with prose mentioning digraph", "Here is the digraph you asked for:\n\n```dot\n" + graph + "\n```\n\nLet me know if the digraph needs changes."},
+		{"prefixed prose mentioning digraph", "Here is the digraph:\n" + graph},
+		{"suffixed text with braces", graph + "\n\nNote: the } closes the graph; use {goal} to customize."},
+		{"suffixed second block", graph + "\n\ndigraph other { a -> b }"},
+		{"unlabeled fence", "```\n" + graph + "\n```\nDone."},
+		{"unterminated fence", "```dot\n" + graph + "\n"},
+		{"graphviz fence after other code", "```bash\nkilroy attractor run {args}\n```\n```graphviz\n" + graph + "\n```"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractDigraph(tt.input)
+			if err != nil {
+				t.Fatalf("ExtractDigraph: %v", err)
+			}
+			if got != graph {
+				t.Fatalf("got %q, want %q", got, graph)
+			}
+		})
+	}
+}
+
+func TestExtractDigraph_IgnoresBracesInComments(t *testing.T) {
+	input := "digraph foo {\n  // closing } here is a comment\n  /* { */\n# }\n  a -> b\n}\ntrailing"
+	got, err := ExtractDigraph(input)
+	if err != nil {
+		t.Fatalf("ExtractDigraph: %v", err)
+	}
+	if !strings.HasSuffix(got, "a -> b\n}") {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestExtractDigraph_KeywordWithoutGraph(t *testing.T) {
+	_, err := ExtractDigraph("I could not produce a digraph for these requirements.")
+	if err == nil || !strings.Contains(err.Error(), "no opening brace") {
+		t.Fatalf("expected no-opening-brace error, got %v", err)
+	}
+}
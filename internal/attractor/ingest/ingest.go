@@ -2,16 +2,20 @@ package ingest
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	_ "embed"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"text/template"
 
 	"github.com/danshapiro/kilroy/internal/attractor/engine"
+	"github.com/danshapiro/kilroy/internal/llm"
 )
 
 //go:embed ingest_prompt.tmpl
@@ -24,11 +28,36 @@ const outputFilename = "pipeline.dot"
 // Options configures an ingestion run.
 type Options struct {
 	Requirements string // The English requirements text.
-	SkillPath    string // Path to the SKILL.md file.
+	SkillPath    string // Path to the SKILL.md file; a .gz path is decompressed.
 	Model        string // LLM model ID.
 	RepoPath     string // Repository root (working directory for claude).
 	Validate     bool   // Whether to validate the .dot output.
 	MaxTurns     int    // Max turns for claude (default 15).
+	Backend      string // BackendCLI (default) or BackendAPI.
+
+	// NonInteractive runs claude in print mode (-p) with stdin closed, so
+	// concurrent CLI ingests never share the terminal. Stdout is then always
+	// streamed to Progress.
+	NonInteractive bool
+
+	// MaxExtractRetries is how many times to re-prompt when the model's output
+	// contains no digraph (0 means the default of 1; negative disables).
+	MaxExtractRetries int
+
+	// AutoRepair retries a graph that fails validation after mechanical fixes
+	// for common model mistakes (see repairDOT). Each fix is reported in
+	// Result.Warnings; if the fixed graph is still invalid the original error
+	// is returned. It has no effect unless Validate is set.
+	AutoRepair bool
+
+	// Client is used by BackendAPI; nil builds one from the environment.
+	Client *llm.Client
+
+	// Progress receives Claude's stdout line by line as the CLI backend runs
+	// (without the trailing newline); nil writes the lines to stderr, so
+	// stdout is left for the generated graph. When Progress is nil and stdout
+	// is a terminal, Claude's interactive UI gets stdout directly instead.
+	Progress func(line string)
 }
 
 // Result contains the output of an ingestion run.
@@ -46,18 +75,49 @@ func buildPrompt(requirements string) string {
 	return buf.String()
 }
 
+// readSkill returns the skill file's text, gunzipping it when the path ends
+// in .gz so large skills can ship compressed.
+func readSkill(path string) ([]byte, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".gz") {
+		return os.ReadFile(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	defer zr.Close()
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
 func buildCLIArgs(opts Options) (string, []string, string, error) {
+	return buildCLIArgsWithPrompt(opts, buildPrompt(opts.Requirements))
+}
+
+func buildCLIArgsWithPrompt(opts Options, prompt string) (string, []string, string, error) {
 	exe := envOr("KILROY_CLAUDE_PATH", "claude")
 	maxTurns := opts.MaxTurns
 	if maxTurns <= 0 {
 		maxTurns = 15
 	}
 
-	args := []string{
+	var args []string
+	if opts.NonInteractive {
+		args = append(args, "-p")
+	}
+	args = append(args,
 		"--model", opts.Model,
 		"--max-turns", fmt.Sprintf("%d", maxTurns),
 		"--dangerously-skip-permissions",
-	}
+	)
 
 	// Give Claude read access to the repo without running inside it.
 	// Resolve to absolute because Claude runs from a temp dir.
@@ -70,7 +130,7 @@ func buildCLIArgs(opts Options) (string, []string, string, error) {
 	}
 
 	if opts.SkillPath != "" {
-		skillContent, err := os.ReadFile(opts.SkillPath)
+		skillContent, err := readSkill(opts.SkillPath)
 		if err != nil {
 			return "", nil, "", fmt.Errorf("reading skill file: %w", err)
 		}
@@ -86,48 +146,179 @@ func buildCLIArgs(opts Options) (string, []string, string, error) {
 	}
 
 	// The prompt is appended last as a positional argument.
-	args = append(args, buildPrompt(opts.Requirements))
+	args = append(args, prompt)
 
 	return exe, args, tmpDir, nil
 }
 
-// Run executes the ingestion: invokes Claude Code interactively with the skill
-// and requirements. Claude writes the .dot file to pipeline.dot in its working
-// directory, which is read back after the session ends.
+// Run executes the ingestion. The CLI backend invokes Claude Code
+// interactively with the skill and requirements; Claude writes the .dot file
+// to pipeline.dot in its working directory, which is read back after the
+// session ends. Claude's stdout is streamed to opts.Progress meanwhile (see
+// runCLIOnce). The API backend runs the same skill through an LLM API (see
+// runAPI).
 func Run(ctx context.Context, opts Options) (*Result, error) {
+	switch strings.ToLower(strings.TrimSpace(opts.Backend)) {
+	case "", BackendCLI:
+	case BackendAPI:
+		return runAPI(ctx, opts)
+	default:
+		return nil, fmt.Errorf("unknown ingest backend %q (want %s or %s)", opts.Backend, BackendCLI, BackendAPI)
+	}
+
 	// Verify skill file exists.
 	if _, err := os.Stat(opts.SkillPath); err != nil {
 		return nil, fmt.Errorf("skill file not found: %s: %w", opts.SkillPath, err)
 	}
 
-	exe, args, tmpDir, err := buildCLIArgs(opts)
+	prompt := buildPrompt(opts.Requirements)
+	return runWithExtractRetries(opts, func(retry bool) (string, error) {
+		if retry {
+			return runCLIOnce(ctx, opts, prompt+"\n"+cliExtractRetryPrompt)
+		}
+		return runCLIOnce(ctx, opts, prompt)
+	})
+}
+
+// stdoutIsTerminal reports whether the process's stdout is a terminal.
+var stdoutIsTerminal = func() bool {
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// runCLIOnce runs one claude session and returns the pipeline.dot it wrote.
+// Claude's stdout is streamed to opts.Progress as it arrives, unless it is
+// left attached to a terminal for Claude's interactive UI (which a line
+// splitter would garble); when the session leaves no usable pipeline.dot, a
+// digraph printed to streamed stdout is used instead.
+// Either way ExtractDigraph decides what counts as a digraph. No digraph in
+// either place is an extractError.
+func runCLIOnce(ctx context.Context, opts Options, prompt string) (string, error) {
+	exe, args, tmpDir, err := buildCLIArgsWithPrompt(opts, prompt)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	defer os.RemoveAll(tmpDir)
 
 	cmd := exec.CommandContext(ctx, exe, args...)
 	cmd.Dir = tmpDir
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
+	if !opts.NonInteractive {
+		cmd.Stdin = os.Stdin
+	}
 	cmd.Stderr = os.Stderr
+	var out *stdoutStream
+	if !opts.NonInteractive && opts.Progress == nil && stdoutIsTerminal() {
+		cmd.Stdout = os.Stdout
+	} else {
+		out = newStdoutStream(opts.Progress)
+		cmd.Stdout = out
+	}
 
-	if err = cmd.Run(); err != nil {
-		return nil, fmt.Errorf("claude exited with error: %v", err)
+	err = cmd.Run()
+	if out != nil {
+		out.Flush()
+	}
+	if err != nil {
+		return "", fmt.Errorf("claude exited with error: %v", err)
 	}
 
 	// Read the .dot file Claude wrote.
 	dotPath := filepath.Join(tmpDir, outputFilename)
 	dotBytes, err := os.ReadFile(dotPath)
 	if err != nil {
-		return nil, fmt.Errorf("claude did not write %s: %w", outputFilename, err)
+		err = fmt.Errorf("claude did not write %s: %w", outputFilename, err)
+	} else if dotContent := strings.TrimSpace(string(dotBytes)); dotContent == "" {
+		err = fmt.Errorf("%s is empty", outputFilename)
+	} else if _, xerr := ExtractDigraph(dotContent); xerr != nil {
+		err = fmt.Errorf("%s: %w", outputFilename, xerr)
+	} else {
+		return dotContent, nil
 	}
+	if out != nil && out.SawDigraph() {
+		if dot, xerr := ExtractDigraph(out.Transcript()); xerr == nil {
+			return dot, nil
+		}
+	}
+	return "", &extractError{err}
+}
 
-	dotContent := strings.TrimSpace(string(dotBytes))
-	if dotContent == "" {
-		return nil, fmt.Errorf("%s is empty", outputFilename)
+// stdoutStream is the claude process's stdout: it forwards each complete line
+// to progress as it arrives, keeps the whole transcript, and notes when a
+// complete digraph has formed in it.
+type stdoutStream struct {
+	progress func(line string)
+
+	mu         sync.Mutex
+	transcript bytes.Buffer
+	pending    []byte
+	sawDigraph bool
+}
+
+func newStdoutStream(progress func(line string)) *stdoutStream {
+	if progress == nil {
+		progress = func(line string) { fmt.Fprintln(os.Stderr, line) }
 	}
+	return &stdoutStream{progress: progress}
+}
 
+func (s *stdoutStream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transcript.Write(p)
+	s.pending = append(s.pending, p...)
+	for {
+		i := bytes.IndexByte(s.pending, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimSuffix(string(s.pending[:i]), "\r")
+		s.pending = s.pending[i+1:]
+		s.emit(line)
+	}
+	return len(p), nil
+}
+
+// Flush forwards a trailing line that did not end in a newline.
+func (s *stdoutStream) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) > 0 {
+		line := string(s.pending)
+		s.pending = nil
+		s.emit(line)
+	}
+}
+
+// emit forwards line and, until one is found, checks whether the transcript
+// now holds a complete digraph. Only a line that closes a brace can complete
+// one, so other lines skip the scan.
+func (s *stdoutStream) emit(line string) {
+	s.progress(line)
+	if s.sawDigraph || !strings.Contains(line, "}") {
+		return
+	}
+	if _, err := ExtractDigraph(s.transcript.String()); err == nil {
+		s.sawDigraph = true
+	}
+}
+
+// SawDigraph reports whether a complete digraph has appeared on stdout.
+func (s *stdoutStream) SawDigraph() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sawDigraph
+}
+
+// Transcript returns everything written to stdout so far.
+func (s *stdoutStream) Transcript() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.transcript.String()
+}
+
+// finishResult wraps the generated graph in a Result, validating it (and
+// auto-repairing it) when requested.
+func finishResult(dotContent string, opts Options) (*Result, error) {
 	result := &Result{
 		DotContent: dotContent,
 	}
@@ -135,11 +326,27 @@ func Run(ctx context.Context, opts Options) (*Result, error) {
 	// Optionally validate.
 	if opts.Validate {
 		_, diags, err := engine.Prepare([]byte(dotContent))
+		if err != nil && opts.AutoRepair {
+			if repaired, repairs := repairDOT(dotContent); len(repairs) > 0 {
+				if _, rdiags, rerr := engine.Prepare([]byte(repaired)); rerr == nil {
+					result.DotContent = repaired
+					for _, r := range repairs {
+						result.Warnings = append(result.Warnings, "auto-repair: "+r)
+					}
+					diags, err = rdiags, nil
+				}
+			}
+		}
 		if err != nil {
 			return result, fmt.Errorf("generated .dot failed validation: %w", err)
 		}
 		for _, d := range diags {
-			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s (%s)", d.Severity, d.Message, d.Rule))
+			warning := fmt.Sprintf("%s: %s (%s)", d.Severity, d.Message, d.Rule)
+			if pos := d.Pos(); pos.IsValid() {
+				// Name the offending statement so authors can find it in the .dot.
+				warning = fmt.Sprintf("%s: line %d:%d: %s (%s)", d.Severity, pos.Line, pos.Column, d.Message, d.Rule)
+			}
+			result.Warnings = append(result.Warnings, warning)
 		}
 	}
 
@@ -1,9 +1,12 @@
 package ingest
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -111,6 +114,44 @@ func TestBuildCLIArgs(t *testing.T) {
 	}
 }
 
+func TestBuildCLIArgs_DecompressesGzippedSkill(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte("gzipped skill content"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	skillPath := filepath.Join(t.TempDir(), "SKILL.md.gz")
+	if err := os.WriteFile(skillPath, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, args, tmpDir, err := buildCLIArgs(Options{Model: "m", SkillPath: skillPath, Requirements: "r"})
+	if err != nil {
+		t.Fatalf("buildCLIArgs: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	for i, a := range args {
+		if a == "--append-system-prompt" {
+			if i+1 >= len(args) || args[i+1] != "gzipped skill content" {
+				t.Fatalf("--append-system-prompt value = %q, want the decompressed skill", args[i+1:])
+			}
+			return
+		}
+	}
+	t.Fatalf("--append-system-prompt missing from %v", args)
+}
+
+func TestReadSkill_RejectsCorruptGzip(t *testing.T) {
+	skillPath := filepath.Join(t.TempDir(), "SKILL.md.gz")
+	if err := os.WriteFile(skillPath, []byte("not gzip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSkill(skillPath); err == nil || !strings.Contains(err.Error(), skillPath) {
+		t.Fatalf("readSkill err = %v, want an error naming the file", err)
+	}
+}
+
 func TestRunIngestRequiresSkill(t *testing.T) {
 	_, err := Run(context.Background(), Options{
 		Requirements: "Build something",
@@ -122,6 +163,83 @@ func TestRunIngestRequiresSkill(t *testing.T) {
 	}
 }
 
+func TestRunCLI_StreamsStdoutToProgress(t *testing.T) {
+	tmpDir := t.TempDir()
+	dotFile := filepath.Join(tmpDir, "good.dot")
+	if err := os.WriteFile(dotFile, []byte(apiTestDigraph), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The trailing line has no newline and must still be forwarded.
+	script := "#!/bin/sh\necho 'reading the repo'\necho 'writing pipeline.dot'\n" +
+		"cp '" + dotFile + "' ./pipeline.dot\nprintf done\n"
+	mock := filepath.Join(tmpDir, "claude")
+	if err := os.WriteFile(mock, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	skillPath := filepath.Join(tmpDir, "SKILL.md")
+	_ = os.WriteFile(skillPath, []byte("# Test Skill\n"), 0o644)
+	t.Setenv("KILROY_CLAUDE_PATH", mock)
+
+	var lines []string
+	res, err := Run(context.Background(), Options{
+		Requirements: "Build something",
+		SkillPath:    skillPath,
+		Model:        "m",
+		Progress:     func(line string) { lines = append(lines, line) },
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.DotContent != apiTestDigraph {
+		t.Fatalf("DotContent = %q", res.DotContent)
+	}
+	if got, want := strings.Join(lines, "|"), "reading the repo|writing pipeline.dot|done"; got != want {
+		t.Fatalf("progress lines = %q, want %q", got, want)
+	}
+}
+
+func TestRunCLI_UsesDigraphFromStdoutWhenFileMissing(t *testing.T) {
+	tmpDir := t.TempDir()
+	dotFile := filepath.Join(tmpDir, "good.dot")
+	if err := os.WriteFile(dotFile, []byte(apiTestDigraph), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\necho 'Here is the pipeline:'\necho '```dot'\ncat '" + dotFile + "'\necho\necho '```'\n"
+	mock := filepath.Join(tmpDir, "claude")
+	if err := os.WriteFile(mock, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	skillPath := filepath.Join(tmpDir, "SKILL.md")
+	_ = os.WriteFile(skillPath, []byte("# Test Skill\n"), 0o644)
+	t.Setenv("KILROY_CLAUDE_PATH", mock)
+
+	res, err := Run(context.Background(), Options{
+		Requirements:      "Build something",
+		SkillPath:         skillPath,
+		Model:             "m",
+		MaxExtractRetries: -1,
+		Progress:          func(string) {},
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.DotContent != apiTestDigraph {
+		t.Fatalf("DotContent = %q", res.DotContent)
+	}
+}
+
+func TestStdoutStream_SawDigraphOnceClosed(t *testing.T) {
+	s := newStdoutStream(func(string) {})
+	_, _ = s.Write([]byte("digraph G {\n  a -> b\n"))
+	if s.SawDigraph() {
+		t.Fatal("SawDigraph before the closing brace")
+	}
+	_, _ = s.Write([]byte("}\n"))
+	if !s.SawDigraph() {
+		t.Fatal("SawDigraph = false after the closing brace")
+	}
+}
+
 func assertContains(t *testing.T, slice []string, want string) {
 	t.Helper()
 	for _, s := range slice {
@@ -141,3 +259,89 @@ func assertNotContains(t *testing.T, slice []string, unwanted string) {
 		}
 	}
 }
+
+func TestRunCLI_NonInteractiveUsesPrintModeWithoutStdin(t *testing.T) {
+	tmpDir := t.TempDir()
+	dotFile := filepath.Join(tmpDir, "good.dot")
+	if err := os.WriteFile(dotFile, []byte(apiTestDigraph), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\n[ \"$1\" = -p ] && echo print-mode\nif read line; then echo stdin-attached; fi\n" +
+		"cp '" + dotFile + "' ./pipeline.dot\n"
+	mock := filepath.Join(tmpDir, "claude")
+	if err := os.WriteFile(mock, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	skillPath := filepath.Join(tmpDir, "SKILL.md")
+	_ = os.WriteFile(skillPath, []byte("# Test Skill\n"), 0o644)
+	t.Setenv("KILROY_CLAUDE_PATH", mock)
+
+	// Give the test process a stdin with data on it; claude must not see it.
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.WriteString("typed by the user\n")
+	_ = w.Close()
+	oldStdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = oldStdin; _ = r.Close() }()
+
+	var lines []string
+	_, err = Run(context.Background(), Options{
+		Requirements:   "Build something",
+		SkillPath:      skillPath,
+		Model:          "m",
+		NonInteractive: true,
+		Progress:       func(line string) { lines = append(lines, line) },
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := strings.Join(lines, "|"); got != "print-mode" {
+		t.Fatalf("progress lines = %q, want %q", got, "print-mode")
+	}
+}
+
+func TestRunCLI_LeavesTerminalStdoutAttached(t *testing.T) {
+	tmpDir := t.TempDir()
+	dotFile := filepath.Join(tmpDir, "good.dot")
+	if err := os.WriteFile(dotFile, []byte(apiTestDigraph), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\nprintf '\\033[2Jpartial'\ncp '" + dotFile + "' ./pipeline.dot\n"
+	mock := filepath.Join(tmpDir, "claude")
+	if err := os.WriteFile(mock, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	skillPath := filepath.Join(tmpDir, "SKILL.md")
+	_ = os.WriteFile(skillPath, []byte("# Test Skill\n"), 0o644)
+	t.Setenv("KILROY_CLAUDE_PATH", mock)
+
+	// Stand in for the terminal with a file and check Claude wrote to it
+	// byte for byte, with no line splitting in between.
+	term, err := os.Create(filepath.Join(tmpDir, "tty"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer term.Close()
+	oldStdout, oldIsTerminal := os.Stdout, stdoutIsTerminal
+	os.Stdout, stdoutIsTerminal = term, func() bool { return true }
+	defer func() { os.Stdout, stdoutIsTerminal = oldStdout, oldIsTerminal }()
+
+	res, err := Run(context.Background(), Options{
+		Requirements: "Build something",
+		SkillPath:    skillPath,
+		Model:        "m",
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.DotContent != apiTestDigraph {
+		t.Fatalf("DotContent = %q", res.DotContent)
+	}
+	b, _ := os.ReadFile(term.Name())
+	if string(b) != "\033[2Jpartial" {
+		t.Fatalf("terminal got %q", b)
+	}
+}
@@ -0,0 +1,335 @@
+package ingest
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/danshapiro/kilroy/internal/attractor/dot"
+	"github.com/danshapiro/kilroy/internal/attractor/model"
+)
+
+// repairRule rewrites DOT source to fix one kind of mistake models commonly
+// make, returning the new source and one description per fix applied.
+type repairRule func(src string) (string, []string)
+
+// repairRules run in order: the syntax fixes first, so the sentinel rule can
+// parse the graph.
+var repairRules = []repairRule{
+	repairStrayCommas,
+	repairUnquotedValues,
+	repairSentinels,
+}
+
+// repairDOT applies every repair rule to src and returns the result with the
+// repairs made. The output is not validated; callers re-run validation and
+// keep the original when the repaired graph is still invalid.
+func repairDOT(src string) (string, []string) {
+	var repairs []string
+	for _, rule := range repairRules {
+		var fixed []string
+		src, fixed = rule(src)
+		repairs = append(repairs, fixed...)
+	}
+	return src, repairs
+}
+
+// plainValueRE matches attribute values the DOT parser reads correctly
+// without quotes.
+var plainValueRE = regexp.MustCompile(`^[A-Za-z0-9_.:/-]+$`)
+
+// attrKeyRE matches the start of the next `key=` in an attribute block.
+var attrKeyRE = regexp.MustCompile(`^\s*[A-Za-z_][A-Za-z0-9_.]*\s*=`)
+
+// repairUnquotedValues quotes attribute values inside [...] that the parser
+// would reject or silently mangle without quotes, e.g. `label=Run the tests`
+// (read as "Runthetests") or `condition=outcome=fail`. A value runs to the
+// closing bracket or to a comma that starts the next key=value pair.
+func repairUnquotedValues(src string) (string, []string) {
+	var out strings.Builder
+	var repairs []string
+	s := dotScanner{src: src}
+	for s.i < len(src) {
+		if s.skipNonCode(&out) {
+			continue
+		}
+		ch := src[s.i]
+		out.WriteByte(ch)
+		s.i++
+		switch ch {
+		case '[':
+			s.depth++
+		case ']':
+			if s.depth > 0 {
+				s.depth--
+			}
+		case '=':
+			if s.depth == 0 {
+				continue
+			}
+			start := s.i
+			for start < len(src) && (src[start] == ' ' || src[start] == '\t') {
+				start++
+			}
+			if start >= len(src) || src[start] == '"' || src[start] == '<' {
+				continue
+			}
+			end := unquotedValueEnd(src, start)
+			raw := strings.TrimSpace(src[start:end])
+			if raw == "" || plainValueRE.MatchString(raw) {
+				continue
+			}
+			out.WriteString(src[s.i:start])
+			out.WriteString(dotQuote(raw))
+			out.WriteString(src[start+len(strings.TrimRight(src[start:end], " \t\r\n")) : end])
+			repairs = append(repairs, fmt.Sprintf("quoted unquoted attribute value %s", dotQuote(raw)))
+			s.i = end
+		}
+	}
+	return out.String(), repairs
+}
+
+// unquotedValueEnd returns the offset just past an unquoted attribute value
+// starting at start: the closing bracket, or a comma or newline followed by
+// the next key=. Double-quoted runs inside the value are skipped.
+func unquotedValueEnd(src string, start int) int {
+	inQuote := false
+	for i := start; i < len(src); i++ {
+		switch ch := src[i]; {
+		case inQuote:
+			if ch == '\\' {
+				i++
+			} else if ch == '"' {
+				inQuote = false
+			}
+		case ch == '"':
+			inQuote = true
+		case ch == ']':
+			return i
+		case ch == ',' || ch == '\n':
+			if attrKeyRE.MatchString(src[i+1:]) {
+				return i
+			}
+		}
+	}
+	return len(src)
+}
+
+// repairStrayCommas removes commas the parser rejects: repeated commas inside
+// an attribute block, and commas outside attribute blocks, which models use
+// to separate or end statements (`a [x=1], b [y=2]`, `a -> b,`). The latter
+// become semicolons. A comma right before `]` is already accepted and kept.
+func repairStrayCommas(src string) (string, []string) {
+	var out strings.Builder
+	var dropped, replaced int
+	s := dotScanner{src: src}
+	for s.i < len(src) {
+		if s.skipNonCode(&out) {
+			continue
+		}
+		ch := src[s.i]
+		s.i++
+		switch ch {
+		case '[':
+			s.depth++
+		case ']':
+			if s.depth > 0 {
+				s.depth--
+			}
+		case ',':
+			next := nextCodeByte(src, s.i)
+			switch {
+			case next == ',' || (s.depth == 0 && next == ';'):
+				dropped++
+				continue
+			case s.depth == 0:
+				replaced++
+				out.WriteByte(';')
+				continue
+			}
+		}
+		out.WriteByte(ch)
+	}
+	var repairs []string
+	if dropped > 0 {
+		repairs = append(repairs, fmt.Sprintf("removed %d repeated comma(s)", dropped))
+	}
+	if replaced > 0 {
+		repairs = append(repairs, fmt.Sprintf("replaced %d comma(s) between statements with semicolons", replaced))
+	}
+	return out.String(), repairs
+}
+
+// repairSentinels adds the start and exit nodes validation requires when the
+// graph has none: `start [shape=Mdiamond]` with an edge to every node nothing
+// leads to, and `exit [shape=Msquare]` with an edge from every node that
+// leads nowhere. Sources that do not parse are left alone.
+func repairSentinels(src string) (string, []string) {
+	g, err := dot.Parse([]byte(src))
+	if err != nil || len(g.Nodes) == 0 {
+		return src, nil
+	}
+	closing := lastCodeBrace(src)
+	if closing < 0 {
+		return src, nil
+	}
+	nodes := make([]*model.Node, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		if n != nil {
+			nodes = append(nodes, n)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Order < nodes[j].Order })
+
+	var hasStart, hasExit bool
+	for _, n := range nodes {
+		switch shape := n.Shape(); {
+		case shape == "Mdiamond" || shape == "circle" || strings.EqualFold(n.ID, "start"):
+			hasStart = true
+		case shape == "Msquare" || shape == "doublecircle" || strings.EqualFold(n.ID, "exit") || strings.EqualFold(n.ID, "end"):
+			hasExit = true
+		}
+	}
+	if hasStart && hasExit {
+		return src, nil
+	}
+
+	var add strings.Builder
+	var repairs []string
+	if !hasStart {
+		var roots []string
+		for _, n := range nodes {
+			if len(g.Incoming(n.ID)) == 0 {
+				roots = append(roots, n.ID)
+			}
+		}
+		if len(roots) == 0 {
+			roots = []string{nodes[0].ID}
+		}
+		add.WriteString("  start [shape=Mdiamond]\n")
+		for _, id := range roots {
+			fmt.Fprintf(&add, "  start -> %s\n", dotID(id))
+		}
+		repairs = append(repairs, fmt.Sprintf("added start node leading to %s", strings.Join(roots, ", ")))
+	}
+	if !hasExit {
+		var sinks []string
+		for _, n := range nodes {
+			if len(g.Outgoing(n.ID)) == 0 {
+				sinks = append(sinks, n.ID)
+			}
+		}
+		add.WriteString("  exit [shape=Msquare]\n")
+		for _, id := range sinks {
+			fmt.Fprintf(&add, "  %s -> exit\n", dotID(id))
+		}
+		if len(sinks) == 0 {
+			repairs = append(repairs, "added exit node")
+		} else {
+			repairs = append(repairs, fmt.Sprintf("added exit node reached from %s", strings.Join(sinks, ", ")))
+		}
+	}
+	prefix := src[:closing]
+	if !strings.HasSuffix(prefix, "\n") {
+		prefix += "\n"
+	}
+	return prefix + add.String() + src[closing:], repairs
+}
+
+// dotScanner walks DOT source tracking attribute-block depth and copying
+// strings and comments through untouched.
+type dotScanner struct {
+	src   string
+	i     int
+	depth int
+}
+
+// skipNonCode copies a quoted string or comment starting at s.i to out and
+// reports whether it did.
+func (s *dotScanner) skipNonCode(out *strings.Builder) bool {
+	end := nonCodeEnd(s.src, s.i)
+	if end == s.i {
+		return false
+	}
+	out.WriteString(s.src[s.i:end])
+	s.i = end
+	return true
+}
+
+// nonCodeEnd returns the end of the quoted string or comment starting at i,
+// or i when none starts there.
+func nonCodeEnd(src string, i int) int {
+	switch {
+	case src[i] == '"':
+		for j := i + 1; j < len(src); j++ {
+			if src[j] == '\\' {
+				j++
+			} else if src[j] == '"' {
+				return j + 1
+			}
+		}
+		return len(src)
+	case strings.HasPrefix(src[i:], "//") || src[i] == '#':
+		if j := strings.IndexByte(src[i:], '\n'); j >= 0 {
+			return i + j
+		}
+		return len(src)
+	case strings.HasPrefix(src[i:], "/*"):
+		if j := strings.Index(src[i+2:], "*/"); j >= 0 {
+			return i + 2 + j + 2
+		}
+		return len(src)
+	}
+	return i
+}
+
+// nextCodeByte returns the next byte at or after i that is not whitespace or
+// part of a comment, or 0 at the end of src.
+func nextCodeByte(src string, i int) byte {
+	for i < len(src) {
+		switch src[i] {
+		case ' ', '\t', '\r', '\n':
+			i++
+			continue
+		case '"':
+			return '"'
+		}
+		end := nonCodeEnd(src, i)
+		if end == i {
+			return src[i]
+		}
+		i = end
+	}
+	return 0
+}
+
+// lastCodeBrace returns the offset of the last `}` outside strings and
+// comments, i.e. the one closing the graph, or -1.
+func lastCodeBrace(src string) int {
+	last := -1
+	for i := 0; i < len(src); {
+		if end := nonCodeEnd(src, i); end != i {
+			i = end
+			continue
+		}
+		if src[i] == '}' {
+			last = i
+		}
+		i++
+	}
+	return last
+}
+
+// dotQuote renders s as a double-quoted DOT string.
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// dotID renders a node ID for an edge statement, quoting it when needed.
+func dotID(id string) string {
+	if plainValueRE.MatchString(id) && !strings.ContainsAny(id, ".:/-") {
+		return id
+	}
+	return dotQuote(id)
+}
@@ -0,0 +1,148 @@
+package ingest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danshapiro/kilroy/internal/attractor/dot"
+)
+
+func TestRepairUnquotedValues(t *testing.T) {
+	src := `digraph G {
+  a [shape=parallelogram, tool_command=go test ./..., label=Run "all" tests]
+  a -> b [label=on fail, condition=outcome=fail]
+  b [prompt="already, quoted", shape=box]
+}`
+	got, repairs := repairUnquotedValues(src)
+	for _, want := range []string{
+		`tool_command="go test ./..."`,
+		`label="Run \"all\" tests"`,
+		`label="on fail"`,
+		`condition="outcome=fail"`,
+		`shape=parallelogram,`,
+		`prompt="already, quoted", shape=box`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("repaired source missing %s:\n%s", want, got)
+		}
+	}
+	if len(repairs) != 4 {
+		t.Fatalf("repairs = %v, want 4", repairs)
+	}
+	g, err := dot.Parse([]byte(got))
+	if err != nil {
+		t.Fatalf("repaired source does not parse: %v", err)
+	}
+	if v := g.Nodes["a"].Attrs["tool_command"]; v != "go test ./..." {
+		t.Fatalf("tool_command = %q", v)
+	}
+}
+
+func TestRepairUnquotedValues_LeavesValidSourceAlone(t *testing.T) {
+	src := `digraph G { a [shape=box, max_retries=-1, label="x y"] }`
+	if got, repairs := repairUnquotedValues(src); got != src || len(repairs) != 0 {
+		t.Fatalf("got %q, repairs %v", got, repairs)
+	}
+}
+
+func TestRepairStrayCommas(t *testing.T) {
+	src := `digraph G {
+  rankdir=LR,
+  a [shape=box,, label="a, b"], b [shape=box,]
+  a -> b,
+}`
+	got, repairs := repairStrayCommas(src)
+	if _, err := dot.Parse([]byte(src)); err == nil {
+		t.Fatal("test source should not parse before repair")
+	}
+	if _, err := dot.Parse([]byte(got)); err != nil {
+		t.Fatalf("repaired source does not parse: %v\n%s", err, got)
+	}
+	if !strings.Contains(got, `label="a, b"`) || !strings.Contains(got, `[shape=box,]`) {
+		t.Fatalf("repair touched commas it should keep:\n%s", got)
+	}
+	want := []string{"removed 1 repeated comma(s)", "replaced 3 comma(s) between statements with semicolons"}
+	if strings.Join(repairs, "|") != strings.Join(want, "|") {
+		t.Fatalf("repairs = %v, want %v", repairs, want)
+	}
+}
+
+func TestRepairSentinels(t *testing.T) {
+	src := `digraph G {
+  a [shape=parallelogram, tool_command="echo a"]
+  b [shape=parallelogram, tool_command="echo b"]
+  c [shape=parallelogram, tool_command="echo c"]
+  a -> b
+}`
+	got, repairs := repairSentinels(src)
+	g, err := dot.Parse([]byte(got))
+	if err != nil {
+		t.Fatalf("repaired source does not parse: %v\n%s", err, got)
+	}
+	if g.Nodes["start"].Shape() != "Mdiamond" || g.Nodes["exit"].Shape() != "Msquare" {
+		t.Fatalf("sentinels not added:\n%s", got)
+	}
+	var edges []string
+	for _, e := range g.Edges {
+		edges = append(edges, e.From+"->"+e.To)
+	}
+	if got, want := strings.Join(edges, " "), "a->b start->a start->c b->exit c->exit"; got != want {
+		t.Fatalf("edges = %q, want %q", got, want)
+	}
+	want := []string{"added start node leading to a, c", "added exit node reached from b, c"}
+	if strings.Join(repairs, "|") != strings.Join(want, "|") {
+		t.Fatalf("repairs = %v, want %v", repairs, want)
+	}
+}
+
+func TestRepairSentinels_KeepsExistingSentinels(t *testing.T) {
+	src := `digraph G { begin [shape=Mdiamond]; done [shape=Msquare]; begin -> done }`
+	if got, repairs := repairSentinels(src); got != src || len(repairs) != 0 {
+		t.Fatalf("got %q, repairs %v", got, repairs)
+	}
+}
+
+func TestFinishResult_AutoRepair(t *testing.T) {
+	broken := `digraph G {
+  a [shape=parallelogram, tool_command=echo hi],
+  a -> b
+  b [shape=parallelogram, tool_command="echo b"]
+}`
+	if _, err := finishResult(broken, Options{Validate: true}); err == nil {
+		t.Fatal("expected validation error without AutoRepair")
+	}
+	res, err := finishResult(broken, Options{Validate: true, AutoRepair: true})
+	if err != nil {
+		t.Fatalf("finishResult: %v", err)
+	}
+	if !strings.Contains(res.DotContent, `tool_command="echo hi"`) || !strings.Contains(res.DotContent, "start -> a") {
+		t.Fatalf("DotContent not repaired:\n%s", res.DotContent)
+	}
+	var repairs int
+	for _, w := range res.Warnings {
+		if strings.HasPrefix(w, "auto-repair: ") {
+			repairs++
+		}
+	}
+	if repairs != 4 {
+		t.Fatalf("Warnings = %v, want 4 auto-repair entries", res.Warnings)
+	}
+}
+
+func TestFinishResult_AutoRepairKeepsOriginalErrorWhenStillInvalid(t *testing.T) {
+	// Two start nodes is not something repair can fix.
+	broken := `digraph G {
+  s1 [shape=Mdiamond]
+  s2 [shape=Mdiamond]
+  a [shape=parallelogram, tool_command=echo hi]
+  s1 -> a
+  s2 -> a
+}`
+	res, err := finishResult(broken, Options{Validate: true, AutoRepair: true})
+	if err == nil || !strings.Contains(err.Error(), "start_node") {
+		t.Fatalf("err = %v, want the original start_node error", err)
+	}
+	if res.DotContent != broken || len(res.Warnings) != 0 {
+		t.Fatalf("result changed: %+v", res)
+	}
+}
@@ -88,6 +88,32 @@ type Node struct {
 	Attrs   map[string]string
 	Classes []string
 	Order   int // first-seen declaration order (stable)
+	Pos     Pos // first node statement in the DOT source; zero when synthesized
+}
+
+// Pos locates a statement in DOT source. Offset is a byte offset; Line and
+// Column are 1-based, with Column counted in bytes. File is empty for the
+// top-level graph and names the source for nodes and edges inlined from
+// another file. The zero Pos is unknown.
+type Pos struct {
+	File   string
+	Offset int
+	Line   int
+	Column int
+}
+
+// IsValid reports whether p holds a source location.
+func (p Pos) IsValid() bool { return p.Line > 0 }
+
+// String formats p as "file:line:col", or "line:col" without a file.
+func (p Pos) String() string {
+	if !p.IsValid() {
+		return ""
+	}
+	if p.File != "" {
+		return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
 }
 
 func NewNode(id string) *Node {
@@ -156,6 +182,7 @@ type Edge struct {
 	To    string
 	Attrs map[string]string
 	Order int // declaration order (stable)
+	Pos   Pos // the edge's source node in its edge statement; zero when synthesized
 }
 
 func NewEdge(from, to string) *Edge {
@@ -85,6 +85,7 @@ func ResolveModelCatalog(ctx context.Context, pinnedPath string, logsRoot string
 		SnapshotPath: dstPath,
 		Source:       source,
 		SHA256:       sha,
+		PinnedSHA256: pinnedSHA,
 		Warning:      warn,
 	}, nil
 }
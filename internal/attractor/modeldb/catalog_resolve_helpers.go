@@ -24,9 +24,17 @@ type ResolvedCatalog struct {
 	SnapshotPath string
 	Source       string
 	SHA256       string
+	// PinnedSHA256 is the digest of the pinned catalog file, empty when it
+	// could not be read.
+	PinnedSHA256 string
 	Warning      string
 }
 
+// Drifted reports whether the effective catalog differs from the pinned one.
+func (r *ResolvedCatalog) Drifted() bool {
+	return r != nil && r.PinnedSHA256 != "" && r.SHA256 != r.PinnedSHA256
+}
+
 func fetchBytes(ctx context.Context, url string, timeout time.Duration) ([]byte, error) {
 	cctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
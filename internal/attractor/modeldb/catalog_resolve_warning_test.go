@@ -31,6 +31,9 @@ func TestResolveModelCatalog_OnRunStartFetch_WarnsWhenDifferentFromPinned(t *tes
 	if strings.TrimSpace(res.Warning) == "" {
 		t.Fatalf("expected warning when fetched differs from pinned; got empty warning")
 	}
+	if !res.Drifted() || res.PinnedSHA256 == "" {
+		t.Fatalf("expected drift: pinned=%q effective=%q", res.PinnedSHA256, res.SHA256)
+	}
 }
 
 func TestResolveModelCatalog_OnRunStartFetch_NoWarningWhenIdenticalToPinned(t *testing.T) {
@@ -54,6 +57,9 @@ func TestResolveModelCatalog_OnRunStartFetch_NoWarningWhenIdenticalToPinned(t *t
 	if strings.TrimSpace(res.Warning) != "" {
 		t.Fatalf("expected no warning when fetched equals pinned; got %q", res.Warning)
 	}
+	if res.Drifted() {
+		t.Fatalf("unexpected drift: pinned=%q effective=%q", res.PinnedSHA256, res.SHA256)
+	}
 }
 
 func TestResolveModelCatalog_OnRunStartFetch_FallsBackToPinnedWithWarningOnFailure(t *testing.T) {
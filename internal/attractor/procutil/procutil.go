@@ -15,10 +15,11 @@ func ProcFSAvailable() bool {
 	return err == nil
 }
 
-// PIDZombie checks whether a PID is in a zombie/dead state.
+// PIDZombie checks whether a PID is in a zombie/dead state. Without procfs it
+// uses sysctl on Darwin and ps elsewhere.
 func PIDZombie(pid int) bool {
 	if !ProcFSAvailable() {
-		return pidZombieFromPS(pid)
+		return pidZombieNoProcFS(pid)
 	}
 	state, _, err := readProcStat(pid)
 	if err != nil {
@@ -27,17 +28,16 @@ func PIDZombie(pid int) bool {
 	return state == 'Z' || state == 'X'
 }
 
-// ReadPIDStartTime returns the kernel process start-time tick value from
-// /proc/<pid>/stat field 22 (1-indexed).
+// ReadPIDStartTime returns a process start-time value used to tell a process
+// apart from a later one that reuses its pid. The value is only comparable with
+// other values read on the same host: it is the /proc/<pid>/stat starttime
+// ticks on procfs systems, the kinfo_proc start time in microseconds on Darwin,
+// and the process creation FILETIME on Windows.
 func ReadPIDStartTime(pid int) (uint64, error) {
 	if pid <= 0 {
 		return 0, fmt.Errorf("invalid pid %d", pid)
 	}
-	_, startTime, err := readProcStat(pid)
-	if err != nil {
-		return 0, err
-	}
-	return startTime, nil
+	return readPIDStartTime(pid)
 }
 
 func readProcStat(pid int) (byte, uint64, error) {
@@ -0,0 +1,107 @@
+package procutil
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func requireStartTime(t *testing.T) {
+	t.Helper()
+	if !PIDStartTimeSupported() {
+		t.Skip("process start time is not available on this platform")
+	}
+}
+
+func TestReadPIDStartTime_StableForSameProcess(t *testing.T) {
+	requireStartTime(t)
+	first, err := ReadPIDStartTime(os.Getpid())
+	if err != nil {
+		t.Fatalf("ReadPIDStartTime: %v", err)
+	}
+	if first == 0 {
+		t.Fatal("start time is zero")
+	}
+	second, err := ReadPIDStartTime(os.Getpid())
+	if err != nil {
+		t.Fatalf("ReadPIDStartTime: %v", err)
+	}
+	if first != second {
+		t.Fatalf("start time changed: %d then %d", first, second)
+	}
+}
+
+func TestReadPIDStartTime_ChildStartsNoEarlierThanParent(t *testing.T) {
+	requireStartTime(t)
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := exec.Command(exe, "-test.run=^$")
+	if err := child.Start(); err != nil {
+		t.Fatalf("start child: %v", err)
+	}
+	defer func() { _ = child.Wait() }()
+
+	self, err := ReadPIDStartTime(os.Getpid())
+	if err != nil {
+		t.Fatalf("ReadPIDStartTime(self): %v", err)
+	}
+	got, err := ReadPIDStartTime(child.Process.Pid)
+	if err != nil {
+		// The child may already have exited and been reaped.
+		t.Skipf("child exited before its start time was read: %v", err)
+	}
+	if got < self {
+		t.Fatalf("child start time %d is earlier than parent %d", got, self)
+	}
+}
+
+func TestReadPIDStartTime_RejectsInvalidPID(t *testing.T) {
+	if _, err := ReadPIDStartTime(0); err == nil {
+		t.Fatal("expected error for pid 0")
+	}
+	if _, err := ReadPIDStartTime(-1); err == nil {
+		t.Fatal("expected error for negative pid")
+	}
+}
+
+func TestParseProcStatLine_StartTime(t *testing.T) {
+	line := "1234 (kilroy (attractor)) S 1 1234 1234 0 -1 4194560 100 0 0 0 1 2 0 0 20 0 5 0 987654 0 0"
+	state, start, err := parseProcStatLine(line)
+	if err != nil {
+		t.Fatalf("parseProcStatLine: %v", err)
+	}
+	if state != 'S' || start != 987654 {
+		t.Fatalf("state=%q start=%d want S 987654", state, start)
+	}
+}
+
+func TestPIDZombie_UnreapedChild(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("zombies are a unix concept")
+	}
+	child := exec.Command("sh", "-c", "exit 0")
+	if err := child.Start(); err != nil {
+		t.Fatalf("start child: %v", err)
+	}
+	defer func() { _ = child.Wait() }()
+
+	// The child exits at once but stays a zombie until Wait reaps it.
+	pid := child.Process.Pid
+	deadline := time.Now().Add(5 * time.Second)
+	for !PIDZombie(pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("pid %d never reported as a zombie", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if PIDAlive(pid) {
+		t.Fatalf("PIDAlive(%d) = true for a zombie", pid)
+	}
+	if PIDZombie(os.Getpid()) {
+		t.Fatal("PIDZombie(self) = true")
+	}
+}
@@ -0,0 +1,28 @@
+//go:build darwin
+
+package procutil
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// PIDStartTimeSupported reports whether ReadPIDStartTime can work here.
+func PIDStartTimeSupported() bool {
+	return true
+}
+
+// readPIDStartTime reads p_starttime from the kern.proc.pid kinfo_proc record
+// as microseconds since the epoch.
+func readPIDStartTime(pid int) (uint64, error) {
+	kp, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil {
+		return 0, err
+	}
+	if int(kp.Proc.P_pid) != pid {
+		return 0, fmt.Errorf("pid %d not found", pid)
+	}
+	tv := kp.Proc.P_starttime
+	return uint64(tv.Sec)*1_000_000 + uint64(tv.Usec), nil
+}
@@ -0,0 +1,18 @@
+//go:build !darwin && !windows
+
+package procutil
+
+// PIDStartTimeSupported reports whether ReadPIDStartTime can work here. Outside
+// Darwin and Windows it needs procfs.
+func PIDStartTimeSupported() bool {
+	return ProcFSAvailable()
+}
+
+// readPIDStartTime reads field 22 (1-indexed) of /proc/<pid>/stat.
+func readPIDStartTime(pid int) (uint64, error) {
+	_, startTime, err := readProcStat(pid)
+	if err != nil {
+		return 0, err
+	}
+	return startTime, nil
+}
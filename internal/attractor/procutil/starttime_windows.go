@@ -0,0 +1,26 @@
+//go:build windows
+
+package procutil
+
+import "syscall"
+
+// PIDStartTimeSupported reports whether ReadPIDStartTime can work here.
+func PIDStartTimeSupported() bool {
+	return true
+}
+
+// readPIDStartTime returns the process creation FILETIME from
+// GetProcessTimes.
+func readPIDStartTime(pid int) (uint64, error) {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return 0, err
+	}
+	defer syscall.CloseHandle(h)
+
+	var creation, exit, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return 0, err
+	}
+	return uint64(creation.HighDateTime)<<32 | uint64(creation.LowDateTime), nil
+}
@@ -0,0 +1,90 @@
+package procutil
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Children returns the pids whose parent is pid, in ascending order.
+func Children(pid int) ([]int, error) {
+	if pid <= 0 {
+		return nil, fmt.Errorf("invalid pid %d", pid)
+	}
+	parents, err := processParents()
+	if err != nil {
+		return nil, err
+	}
+	return childrenOf(parents, pid), nil
+}
+
+// DescendantPIDs returns every pid below pid in the process tree (children,
+// grandchildren, ...), in ascending order. The tree is read once, so processes
+// that fork or exit while it is being read may be missed.
+func DescendantPIDs(pid int) ([]int, error) {
+	if pid <= 0 {
+		return nil, fmt.Errorf("invalid pid %d", pid)
+	}
+	parents, err := processParents()
+	if err != nil {
+		return nil, err
+	}
+	return descendantsOf(parents, pid), nil
+}
+
+// childrenOf returns the keys of parents (pid -> ppid) whose value is pid.
+func childrenOf(parents map[int]int, pid int) []int {
+	var out []int
+	for child, ppid := range parents {
+		if ppid == pid && child != pid {
+			out = append(out, child)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+func descendantsOf(parents map[int]int, pid int) []int {
+	byParent := map[int][]int{}
+	for child, ppid := range parents {
+		if child != ppid {
+			byParent[ppid] = append(byParent[ppid], child)
+		}
+	}
+	seen := map[int]bool{pid: true}
+	var out []int
+	queue := []int{pid}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, child := range byParent[cur] {
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			out = append(out, child)
+			queue = append(queue, child)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+// parsePSParents parses `ps -A -o pid=,ppid=` output into pid -> ppid.
+func parsePSParents(out string) map[int]int {
+	parents := map[int]int{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		parents[pid] = ppid
+	}
+	return parents
+}
@@ -0,0 +1,99 @@
+package procutil
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDescendantsOf_WalksTreeAndIgnoresSelfParent(t *testing.T) {
+	parents := map[int]int{
+		0:  0,
+		1:  0,
+		10: 1,
+		11: 10,
+		12: 10,
+		13: 11,
+		20: 1,
+	}
+	if got, want := descendantsOf(parents, 10), []int{11, 12, 13}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("descendants=%v want %v", got, want)
+	}
+	if got, want := childrenOf(parents, 10), []int{11, 12}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("children=%v want %v", got, want)
+	}
+	if got := descendantsOf(parents, 13); len(got) != 0 {
+		t.Fatalf("descendants of leaf=%v want none", got)
+	}
+}
+
+func TestParsePSParents(t *testing.T) {
+	got := parsePSParents("    1     0\n  412     1\nbogus line here\n  413   412\n")
+	want := map[int]int{1: 0, 412: 1, 413: 412}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parents=%v want %v", got, want)
+	}
+}
+
+func TestDescendantPIDs_FindsGrandchild(t *testing.T) {
+	if !ProcFSAvailable() {
+		t.Skip("requires procfs")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("requires sh")
+	}
+	pidFile := t.TempDir() + "/grandchild.pid"
+	cmd := exec.Command("sh", "-c", `sleep 60 & echo $! > "$1"; wait`, "sh", pidFile)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	grandchild := 0
+	deadline := time.Now().Add(5 * time.Second)
+	for grandchild == 0 && time.Now().Before(deadline) {
+		if b, err := os.ReadFile(pidFile); err == nil {
+			grandchild, _ = strconv.Atoi(strings.TrimSpace(string(b)))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if grandchild == 0 {
+		t.Fatal("grandchild pid was not written")
+	}
+	t.Cleanup(func() {
+		if p, err := os.FindProcess(grandchild); err == nil {
+			_ = p.Kill()
+		}
+	})
+
+	children, err := Children(os.Getpid())
+	if err != nil {
+		t.Fatalf("Children: %v", err)
+	}
+	if !containsPID(children, cmd.Process.Pid) || containsPID(children, grandchild) {
+		t.Fatalf("children=%v want sh %d but not sleep %d", children, cmd.Process.Pid, grandchild)
+	}
+	desc, err := DescendantPIDs(os.Getpid())
+	if err != nil {
+		t.Fatalf("DescendantPIDs: %v", err)
+	}
+	if !containsPID(desc, cmd.Process.Pid) || !containsPID(desc, grandchild) {
+		t.Fatalf("descendants=%v want sh %d and sleep %d", desc, cmd.Process.Pid, grandchild)
+	}
+}
+
+func containsPID(pids []int, pid int) bool {
+	for _, p := range pids {
+		if p == pid {
+			return true
+		}
+	}
+	return false
+}
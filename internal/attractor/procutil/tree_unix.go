@@ -0,0 +1,59 @@
+//go:build !windows
+
+package procutil
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// processParents maps every visible pid to its parent pid, from procfs when
+// available and from ps otherwise.
+func processParents() (map[int]int, error) {
+	if !ProcFSAvailable() {
+		out, err := exec.Command("ps", "-A", "-o", "pid=,ppid=").Output()
+		if err != nil {
+			return nil, fmt.Errorf("list processes: %w", err)
+		}
+		return parsePSParents(string(out)), nil
+	}
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	parents := map[int]int{}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || pid <= 0 {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join("/proc", e.Name(), "stat"))
+		if err != nil {
+			// The process exited while we were scanning.
+			continue
+		}
+		ppid, err := parseProcStatPPID(string(b))
+		if err != nil {
+			continue
+		}
+		parents[pid] = ppid
+	}
+	return parents, nil
+}
+
+func parseProcStatPPID(line string) (int, error) {
+	closeIdx := strings.LastIndexByte(line, ')')
+	if closeIdx < 0 || closeIdx+2 >= len(line) {
+		return 0, fmt.Errorf("malformed stat record")
+	}
+	// fields[0] is state (field 3); ppid is field 4.
+	fields := strings.Fields(line[closeIdx+2:])
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("malformed stat fields")
+	}
+	return strconv.Atoi(fields[1])
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package procutil
+
+import (
+	"errors"
+	"syscall"
+	"unsafe"
+)
+
+// processParents maps every visible pid to its parent pid from a toolhelp
+// process snapshot.
+func processParents() (map[int]int, error) {
+	snap, err := syscall.CreateToolhelp32Snapshot(syscall.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.CloseHandle(snap)
+
+	parents := map[int]int{}
+	var entry syscall.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	for err = syscall.Process32First(snap, &entry); err == nil; err = syscall.Process32Next(snap, &entry) {
+		parents[int(entry.ProcessID)] = int(entry.ParentProcessID)
+	}
+	if !errors.Is(err, syscall.ERROR_NO_MORE_FILES) {
+		return nil, err
+	}
+	return parents, nil
+}
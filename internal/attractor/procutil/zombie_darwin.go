@@ -0,0 +1,31 @@
+//go:build darwin
+
+package procutil
+
+import "golang.org/x/sys/unix"
+
+// sZomb is SZOMB from <sys/proc.h>: the process has exited but has not been
+// reaped by its parent. Darwin has no separate dead state.
+const sZomb = 5
+
+// pidZombieNoProcFS reads the process state from the kern.proc.pid kinfo_proc
+// record, falling back to ps when sysctl fails.
+func pidZombieNoProcFS(pid int) bool {
+	zombie, err := pidZombieFromSysctl(pid)
+	if err != nil {
+		return pidZombieFromPS(pid)
+	}
+	return zombie
+}
+
+func pidZombieFromSysctl(pid int) (bool, error) {
+	kp, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil {
+		return false, err
+	}
+	if int(kp.Proc.P_pid) != pid {
+		// No such process: gone, not a zombie.
+		return false, nil
+	}
+	return kp.Proc.P_stat == sZomb, nil
+}
@@ -0,0 +1,8 @@
+//go:build !darwin
+
+package procutil
+
+// pidZombieNoProcFS asks ps for the process state.
+func pidZombieNoProcFS(pid int) bool {
+	return pidZombieFromPS(pid)
+}
@@ -0,0 +1,85 @@
+package runstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Manifest is manifest.json, written atomically by the engine when a run
+// starts (and again in each loop_restart logs directory). It identifies the
+// run's inputs: GraphSHA256 is the SHA-256 of the DOT source (kept as
+// GraphDot), RunConfigSHA256 the SHA-256 of the JSON-encoded run config, and
+// ModelDB.SHA256 the digest of the model catalog snapshot. Fields are empty in
+// manifests written before they existed.
+type Manifest struct {
+	RunID string `json:"run_id"`
+	// StartedAt is RFC 3339; Started parses it.
+	StartedAt string `json:"started_at"`
+
+	GraphName   string `json:"graph_name,omitempty"`
+	Goal        string `json:"goal,omitempty"`
+	GraphPath   string `json:"graph_path,omitempty"`
+	GraphDot    string `json:"graph_dot,omitempty"`
+	GraphSHA256 string `json:"graph_sha256,omitempty"`
+	NodeCount   int    `json:"node_count,omitempty"`
+
+	RunConfigPath   string `json:"run_config_path,omitempty"`
+	RunConfigSHA256 string `json:"run_config_sha256,omitempty"`
+
+	RepoPath         string `json:"repo_path,omitempty"`
+	BaseSHA          string `json:"base_sha,omitempty"`
+	RunBranch        string `json:"run_branch,omitempty"`
+	CheckpointBranch string `json:"checkpoint_branch,omitempty"`
+	LogsRoot         string `json:"logs_root,omitempty"`
+	Worktree         string `json:"worktree,omitempty"`
+
+	PID          int    `json:"pid,omitempty"`
+	PIDStartTime uint64 `json:"pid_start_time,omitempty"`
+
+	ModelDB ManifestModelDB `json:"modeldb"`
+	CXDB    ManifestCXDB    `json:"cxdb"`
+
+	ForceModels map[string]string `json:"force_models,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Warnings    []string          `json:"warnings,omitempty"`
+}
+
+// ManifestModelDB describes the model catalog snapshot the run resolved.
+type ManifestModelDB struct {
+	Path   string `json:"openrouter_model_info_path,omitempty"`
+	SHA256 string `json:"openrouter_model_info_sha256,omitempty"`
+	Source string `json:"openrouter_model_info_source,omitempty"`
+}
+
+// ManifestCXDB locates the run's CXDB context; it is empty for runs without
+// CXDB.
+type ManifestCXDB struct {
+	HTTPBaseURL      string `json:"http_base_url,omitempty"`
+	ContextID        string `json:"context_id,omitempty"`
+	HeadTurnID       string `json:"head_turn_id,omitempty"`
+	RegistryBundleID string `json:"registry_bundle_id,omitempty"`
+}
+
+// LoadManifest reads {logsRoot}/manifest.json. A missing manifest returns an
+// error matching os.ErrNotExist.
+func LoadManifest(logsRoot string) (*Manifest, error) {
+	path := filepath.Join(logsRoot, "manifest.json")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Started returns StartedAt as a time, or the zero time when it is missing or
+// malformed.
+func (m *Manifest) Started() time.Time {
+	return parseEventTime(m.StartedAt)
+}
@@ -0,0 +1,48 @@
+package runstate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadManifest_ReadsGraphIdentity(t *testing.T) {
+	root := t.TempDir()
+	_ = os.WriteFile(filepath.Join(root, "manifest.json"), []byte(`{
+  "run_id": "r1",
+  "graph_name": "pipeline",
+  "graph_path": "/graphs/pipeline.dot",
+  "graph_sha256": "abc",
+  "run_config_sha256": "def",
+  "started_at": "2026-01-01T00:00:00Z",
+  "node_count": 4,
+  "modeldb": {"openrouter_model_info_sha256": "123"}
+}`), 0o644)
+
+	m, err := LoadManifest(root)
+	if err != nil {
+		t.Fatalf("LoadManifest: %v", err)
+	}
+	if m.RunID != "r1" || m.GraphPath != "/graphs/pipeline.dot" || m.GraphSHA256 != "abc" || m.RunConfigSHA256 != "def" || m.NodeCount != 4 || m.ModelDB.SHA256 != "123" {
+		t.Fatalf("manifest=%+v", m)
+	}
+	if want := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC); !m.Started().Equal(want) {
+		t.Fatalf("started=%s want %s", m.Started(), want)
+	}
+
+	s, err := LoadSnapshot(root)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if s.GraphName != "pipeline" || s.GraphPath != "/graphs/pipeline.dot" || s.GraphSHA256 != "abc" {
+		t.Fatalf("snapshot graph identity: %+v", s)
+	}
+}
+
+func TestLoadManifest_MissingFileIsNotExist(t *testing.T) {
+	if _, err := LoadManifest(t.TempDir()); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("err=%v want os.ErrNotExist", err)
+	}
+}
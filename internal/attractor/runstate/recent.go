@@ -0,0 +1,182 @@
+package runstate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultRecentEvents is how many stage events LoadSnapshot keeps in
+// Snapshot.RecentEvents.
+const DefaultRecentEvents = 10
+
+// tailChunkSize is how much of progress.ndjson is read per step when walking
+// backwards from the end of the file.
+const tailChunkSize = 64 * 1024
+
+// ReadRecentEvents returns up to n of the most recent stage_* events other than
+// heartbeats from logsRoot/progress.ndjson (and its rolled files), oldest
+// first. The files are read backwards from the end, so the cost depends on n
+// rather than the length of the run. Lines that do not decode (for example a
+// partially written last line) are skipped.
+func ReadRecentEvents(logsRoot string, n int) ([]EventSummary, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	var newestFirst []EventSummary
+	err := scanProgressBackwards(logsRoot, func(line []byte) bool {
+		if ev, ok := decodeStageEvent(line); ok {
+			newestFirst = append(newestFirst, ev)
+		}
+		return len(newestFirst) < n
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]EventSummary, len(newestFirst))
+	for i, ev := range newestFirst {
+		out[len(newestFirst)-1-i] = ev
+	}
+	return out, nil
+}
+
+// ReadStallStatus reports the stall watchdog's view of a run: when the newest
+// non-blank line of logsRoot/progress.ndjson is a stall_heartbeat or
+// stall_warning event, it returns that event's idle_ms and timeout_ms. Any
+// other newest event means the run made progress since, and ok is false.
+func ReadStallStatus(logsRoot string) (idleMS, timeoutMS int64, ok bool, err error) {
+	err = scanProgressBackwards(logsRoot, func(line []byte) bool {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			return true
+		}
+		var ev map[string]any
+		if json.Unmarshal(line, &ev) != nil {
+			// A partially written last line; look at the one before it.
+			return true
+		}
+		switch eventString(ev["event"]) {
+		case "stall_heartbeat", "stall_warning":
+			idleMS, _ = eventInt(ev["idle_ms"])
+			timeoutMS, _ = eventInt(ev["timeout_ms"])
+			ok = timeoutMS > 0
+		}
+		return false
+	})
+	if err != nil || !ok {
+		return 0, 0, false, err
+	}
+	return idleMS, timeoutMS, true, nil
+}
+
+// ProgressLogPaths returns logsRoot/progress.ndjson preceded by its existing
+// rolled files (progress.ndjson.N ... progress.ndjson.1), oldest first. The
+// engine rolls the active file once it reaches the configured size cap.
+func ProgressLogPaths(logsRoot string) []string {
+	active := filepath.Join(logsRoot, "progress.ndjson")
+	var rolled []string
+	for i := 1; ; i++ {
+		p := fmt.Sprintf("%s.%d", active, i)
+		if _, err := os.Stat(p); err != nil {
+			break
+		}
+		rolled = append(rolled, p)
+	}
+	paths := make([]string, 0, len(rolled)+1)
+	for i := len(rolled) - 1; i >= 0; i-- {
+		paths = append(paths, rolled[i])
+	}
+	return append(paths, active)
+}
+
+// scanProgressBackwards calls fn with each line of logsRoot/progress.ndjson and
+// then its rolled files, newest first, until fn returns false or the oldest
+// file's start is reached. Files are read backwards in tailChunkSize steps, so
+// the cost depends on how far fn looks rather than the length of the run.
+// Missing files are not an error.
+func scanProgressBackwards(logsRoot string, fn func(line []byte) bool) error {
+	paths := ProgressLogPaths(logsRoot)
+	for i := len(paths) - 1; i >= 0; i-- {
+		more, err := scanFileBackwards(paths[i], fn)
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanFileBackwards is scanProgressBackwards for a single file. It reports
+// whether fn asked for more lines.
+func scanFileBackwards(path string, fn func(line []byte) bool) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return true, nil
+		}
+		return false, err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	offset := info.Size()
+	// carry holds the start of a line whose beginning lies in an earlier chunk.
+	var carry []byte
+	for offset > 0 {
+		size := int64(tailChunkSize)
+		if offset < size {
+			size = offset
+		}
+		offset -= size
+		buf := make([]byte, size, size+int64(len(carry)))
+		if _, err := f.ReadAt(buf, offset); err != nil && !errors.Is(err, io.EOF) {
+			return false, err
+		}
+		buf = append(buf, carry...)
+
+		lines := bytes.Split(buf, []byte("\n"))
+		// The first piece may be cut mid-line unless we reached the file start.
+		if offset > 0 {
+			carry = lines[0]
+			lines = lines[1:]
+		} else {
+			carry = nil
+		}
+		for i := len(lines) - 1; i >= 0; i-- {
+			if !fn(lines[i]) {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+func decodeStageEvent(line []byte) (EventSummary, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return EventSummary{}, false
+	}
+	var ev map[string]any
+	if err := json.Unmarshal(line, &ev); err != nil {
+		return EventSummary{}, false
+	}
+	name := eventString(ev["event"])
+	// Heartbeats repeat while a stage runs and would crowd out transitions.
+	if !strings.HasPrefix(name, "stage_") || name == "stage_heartbeat" {
+		return EventSummary{}, false
+	}
+	return EventSummary{
+		NodeID: eventString(ev["node_id"]),
+		Event:  name,
+		TS:     parseEventTime(ev["ts"]),
+	}, true
+}
@@ -0,0 +1,111 @@
+package runstate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadRecentEvents_KeepsLastStageEventsOldestFirst(t *testing.T) {
+	root := t.TempDir()
+	var b strings.Builder
+	// Enough lines to span several read chunks.
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&b, `{"ts":"2026-01-01T00:00:00Z","event":"stage_attempt_end","node_id":"n%d"}`+"\n", i)
+		fmt.Fprintf(&b, `{"ts":"2026-01-01T00:00:00Z","event":"stage_heartbeat","node_id":"n%d"}`+"\n", i)
+		fmt.Fprintf(&b, `{"ts":"2026-01-01T00:00:00Z","event":"edge_selected","node_id":"n%d"}`+"\n", i)
+	}
+	b.WriteString(`{"ts":"2026-01-01T00:00:00Z","event":"stage_att`) // partial trailing line
+	_ = os.WriteFile(filepath.Join(root, "progress.ndjson"), []byte(b.String()), 0o644)
+
+	got, err := ReadRecentEvents(root, 3)
+	if err != nil {
+		t.Fatalf("ReadRecentEvents: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("len=%d want 3: %+v", len(got), got)
+	}
+	for i, want := range []string{"n4997", "n4998", "n4999"} {
+		if got[i].NodeID != want || got[i].Event != "stage_attempt_end" {
+			t.Fatalf("events[%d]=%+v want node %s stage_attempt_end", i, got[i], want)
+		}
+	}
+}
+
+func TestReadRecentEvents_FewerEventsThanLimit(t *testing.T) {
+	root := t.TempDir()
+	progress := `{"ts":"2026-01-01T00:00:05Z","event":"stage_attempt_start","node_id":"start"}` + "\n" +
+		`{"ts":"2026-01-01T00:00:10Z","event":"stage_attempt_end","node_id":"start"}` + "\n"
+	_ = os.WriteFile(filepath.Join(root, "progress.ndjson"), []byte(progress), 0o644)
+
+	s, err := LoadSnapshot(root)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if len(s.RecentEvents) != 2 {
+		t.Fatalf("recent_events=%+v want 2 entries", s.RecentEvents)
+	}
+	if s.RecentEvents[0].Event != "stage_attempt_start" || s.RecentEvents[1].Event != "stage_attempt_end" {
+		t.Fatalf("recent_events order=%+v", s.RecentEvents)
+	}
+	if s.RecentEvents[1].TS.IsZero() {
+		t.Fatal("expected ts to be parsed")
+	}
+}
+
+func TestLoadSnapshot_StallStatusFromNewestWatchdogEvent(t *testing.T) {
+	root := t.TempDir()
+	progress := `{"ts":"2026-01-01T00:00:05Z","event":"stage_attempt_start","node_id":"wait"}` + "\n" +
+		`{"ts":"2026-01-01T00:00:50Z","event":"stall_heartbeat","node_id":"wait","idle_ms":45000,"timeout_ms":60000}` + "\n" +
+		`{"ts":"2026-01-01T00:00:50Z","event":"stall_warning","node_id":"wait","idle_ms":45010,"timeout_ms":60000}` + "\n"
+	path := filepath.Join(root, "progress.ndjson")
+	_ = os.WriteFile(path, []byte(progress), 0o644)
+
+	s, err := LoadSnapshot(root)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if s.StallIdleMS != 45010 || s.StallTimeoutMS != 60000 {
+		t.Fatalf("stall idle/timeout=%d/%d want 45010/60000", s.StallIdleMS, s.StallTimeoutMS)
+	}
+
+	// Progress after the warning clears the stall view.
+	progress += `{"ts":"2026-01-01T00:00:55Z","event":"stage_attempt_end","node_id":"wait"}` + "\n"
+	_ = os.WriteFile(path, []byte(progress), 0o644)
+	if s, err = LoadSnapshot(root); err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if s.StallIdleMS != 0 || s.StallTimeoutMS != 0 {
+		t.Fatalf("stall idle/timeout=%d/%d want cleared", s.StallIdleMS, s.StallTimeoutMS)
+	}
+}
+
+func TestLoadSnapshot_ReadsAcrossRotatedProgressFiles(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "progress.ndjson")
+	_ = os.WriteFile(path+".2", []byte(`{"ts":"2026-01-01T00:00:05Z","event":"stage_attempt_start","node_id":"a"}`+"\n"), 0o644)
+	_ = os.WriteFile(path+".1", []byte(`{"ts":"2026-01-01T00:00:10Z","event":"stage_attempt_end","node_id":"a"}`+"\n"+
+		`{"ts":"2026-01-01T00:00:11Z","event":"stage_attempt_start","node_id":"b"}`+"\n"), 0o644)
+	// The active file was just rotated and is still empty.
+	_ = os.WriteFile(path, nil, 0o644)
+
+	if got := ProgressLogPaths(root); len(got) != 3 || got[0] != path+".2" || got[2] != path {
+		t.Fatalf("ProgressLogPaths=%v", got)
+	}
+
+	s, err := LoadSnapshot(root)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if s.LastEvent != "stage_attempt_start" || s.CurrentNodeID != "b" {
+		t.Fatalf("last event=%q node=%q, want stage_attempt_start on b", s.LastEvent, s.CurrentNodeID)
+	}
+	if want := "2026-01-01T00:00:05Z"; s.StartedAt.UTC().Format("2006-01-02T15:04:05Z") != want {
+		t.Fatalf("started_at=%v want %s from the oldest roll", s.StartedAt, want)
+	}
+	if len(s.RecentEvents) != 3 || s.RecentEvents[0].NodeID != "a" || s.RecentEvents[2].NodeID != "b" {
+		t.Fatalf("recent_events=%+v", s.RecentEvents)
+	}
+}
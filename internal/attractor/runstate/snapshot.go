@@ -2,6 +2,7 @@ package runstate
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,10 +15,25 @@ import (
 	"github.com/danshapiro/kilroy/internal/attractor/procutil"
 )
 
+type checkpointDoc struct {
+	CompletedNodes []string `json:"completed_nodes"`
+}
+
+// finalOutcomeDoc is the part of final.json the snapshot reads; other fields
+// are ignored, and the summary fields are absent from older runs.
 type finalOutcomeDoc struct {
+	Timestamp     string `json:"timestamp"`
 	Status        string `json:"status"`
 	RunID         string `json:"run_id"`
 	FailureReason string `json:"failure_reason"`
+
+	CompletedNodes []string `json:"completed_nodes"`
+	DurationMS     int64    `json:"duration_ms"`
+	Attempts       int      `json:"attempts"`
+	Retries        int      `json:"retries"`
+	InputTokens    int64    `json:"input_tokens"`
+	OutputTokens   int64    `json:"output_tokens"`
+	TotalTokens    int64    `json:"total_tokens"`
 }
 
 // LoadSnapshot reads run artifacts in logsRoot and returns a compact run snapshot.
@@ -32,10 +48,11 @@ func LoadSnapshot(logsRoot string) (*Snapshot, error) {
 		State:    StateUnknown,
 	}
 
-	if err := applyFinalOutcome(s); err != nil {
+	finishedAt, err := applyFinalOutcome(s)
+	if err != nil {
 		return nil, err
 	}
-	terminal := s.State == StateSuccess || s.State == StateFail
+	terminal := s.IsTerminal()
 
 	// terminal final.json is authoritative for status/current node; live/progress
 	// are best-effort activity feeds and must not override terminal state.
@@ -45,29 +62,109 @@ func LoadSnapshot(logsRoot string) (*Snapshot, error) {
 		}
 	}
 
+	if !terminal {
+		if s.StallIdleMS, s.StallTimeoutMS, _, err = ReadStallStatus(root); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := applyPIDFile(s, terminal); err != nil {
 		return nil, err
 	}
-	if s.State == StateUnknown && s.PIDAlive {
-		s.State = StateRunning
+	// The manifest, checkpoint, and first progress event only add detail;
+	// when one is malformed its fields are left empty rather than failing
+	// the whole snapshot.
+	m := readManifest(root)
+	if !terminal {
+		applyLiveness(s, m)
+	}
+	s.GraphName, s.GraphPath, s.GraphSHA256 = m.GraphName, m.GraphPath, m.GraphSHA256
+	applyTiming(s, m, finishedAt)
+	applyProgress(s, m)
+	if s.RecentEvents, err = ReadRecentEvents(root, DefaultRecentEvents); err != nil {
+		return nil, err
 	}
 
 	return s, nil
 }
 
-func applyFinalOutcome(s *Snapshot) error {
+// applyTiming fills StartedAt and, unless final.json recorded the run's
+// duration, Elapsed. finishedAt is final.json's timestamp (zero while the run
+// has not finished).
+func applyTiming(s *Snapshot, m Manifest, finishedAt time.Time) {
+	s.StartedAt = m.Started()
+	if s.StartedAt.IsZero() {
+		if first, found, err := readFirstProgressEvent(s.LogsRoot); err == nil && found {
+			s.StartedAt = parseEventTime(first["ts"])
+		}
+	}
+	if s.StartedAt.IsZero() {
+		return
+	}
+	end := finishedAt
+	if end.IsZero() {
+		if s.State == StateOrphaned && !s.LastEventAt.IsZero() {
+			end = s.LastEventAt
+		} else {
+			end = time.Now()
+		}
+	}
+	if d := end.Sub(s.StartedAt); d > 0 && s.Elapsed == 0 {
+		s.Elapsed = d
+	}
+}
+
+// applyProgress fills the node counts from checkpoint.json and the manifest.
+// A missing or unreadable checkpoint leaves CompletedNodes at zero.
+func applyProgress(s *Snapshot, m Manifest) {
+	var cp checkpointDoc
+	if b, err := os.ReadFile(filepath.Join(s.LogsRoot, "checkpoint.json")); err == nil && json.Unmarshal(b, &cp) == nil {
+		seen := map[string]bool{}
+		for _, id := range cp.CompletedNodes {
+			seen[id] = true
+		}
+		s.CompletedNodes = len(seen)
+	}
+	if m.NodeCount <= 0 {
+		return
+	}
+	s.TotalNodes = m.NodeCount
+	switch {
+	case s.State == StateSuccess:
+		// Branches not taken never complete; a finished run is done.
+		s.Progress = 1
+	case s.CompletedNodes >= s.TotalNodes:
+		s.Progress = 1
+	default:
+		s.Progress = float64(s.CompletedNodes) / float64(s.TotalNodes)
+	}
+}
+
+// readManifest is LoadManifest read best-effort: a missing or malformed
+// manifest reads as empty.
+func readManifest(logsRoot string) Manifest {
+	m, err := LoadManifest(logsRoot)
+	if err != nil {
+		return Manifest{}
+	}
+	return *m
+}
+
+// applyFinalOutcome applies final.json and returns its timestamp (zero when
+// the run has not finished).
+func applyFinalOutcome(s *Snapshot) (time.Time, error) {
 	path := filepath.Join(s.LogsRoot, "final.json")
 	b, err := os.ReadFile(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return nil
+			return time.Time{}, nil
 		}
-		return err
+		return time.Time{}, err
 	}
 
 	var doc finalOutcomeDoc
 	if err := json.Unmarshal(b, &doc); err != nil {
-		return fmt.Errorf("decode %s: %w", path, err)
+		return time.Time{}, fmt.Errorf("decode %s: %w", path, err)
 	}
 
 	if rid := strings.TrimSpace(doc.RunID); rid != "" {
@@ -76,13 +173,22 @@ func applyFinalOutcome(s *Snapshot) error {
 	switch strings.ToLower(strings.TrimSpace(doc.Status)) {
 	case string(StateSuccess):
 		s.State = StateSuccess
-	case string(StateFail):
-		s.State = StateFail
+	case string(StateFail), string(StateCanceled):
+		s.State = State(strings.ToLower(strings.TrimSpace(doc.Status)))
 		if reason := strings.TrimSpace(doc.FailureReason); reason != "" {
 			s.FailureReason = reason
 		}
 	}
-	return nil
+	s.CompletedNodeIDs = doc.CompletedNodes
+	s.Attempts = doc.Attempts
+	s.Retries = doc.Retries
+	s.InputTokens = doc.InputTokens
+	s.OutputTokens = doc.OutputTokens
+	s.TotalTokens = doc.TotalTokens
+	if doc.DurationMS > 0 {
+		s.Elapsed = time.Duration(doc.DurationMS) * time.Millisecond
+	}
+	return parseEventTime(doc.Timestamp), nil
 }
 
 func applyLiveOrProgress(s *Snapshot) error {
@@ -91,7 +197,7 @@ func applyLiveOrProgress(s *Snapshot) error {
 		return err
 	}
 	if !found {
-		live, found, err = readLastProgressEvent(filepath.Join(s.LogsRoot, "progress.ndjson"))
+		live, found, err = readLastProgressEvent(s.LogsRoot)
 		if err != nil {
 			return err
 		}
@@ -114,6 +220,29 @@ func applyLiveOrProgress(s *Snapshot) error {
 	return nil
 }
 
+// applyLiveness infers the state of a run without a terminal final.json from
+// its pid. A pid whose start time no longer matches the one recorded in the
+// manifest belongs to another process, so the run is orphaned even though the
+// pid is alive.
+func applyLiveness(s *Snapshot, m Manifest) {
+	if s.PID <= 0 {
+		return
+	}
+	if !s.PIDAlive {
+		s.State = StateOrphaned
+		s.StateReason = fmt.Sprintf("run process (pid %d) is no longer alive and final.json was not written", s.PID)
+		return
+	}
+	if m.PID == s.PID && m.PIDStartTime > 0 {
+		if start, err := procutil.ReadPIDStartTime(s.PID); err == nil && start != m.PIDStartTime {
+			s.State = StateOrphaned
+			s.StateReason = fmt.Sprintf("run process (pid %d) was replaced by another process reusing the pid and final.json was not written", s.PID)
+			return
+		}
+	}
+	s.State = StateRunning
+}
+
 func applyPIDFile(s *Snapshot, terminalState bool) error {
 	path := filepath.Join(s.LogsRoot, "run.pid")
 	b, err := os.ReadFile(path)
@@ -161,7 +290,41 @@ func readLiveEvent(path string) (map[string]any, bool, error) {
 	return ev, true, nil
 }
 
-func readLastProgressEvent(path string) (map[string]any, bool, error) {
+// readLastProgressEvent returns the newest non-blank line of the progress log,
+// looking into the rolled files when the active one was just rotated.
+func readLastProgressEvent(logsRoot string) (map[string]any, bool, error) {
+	var last []byte
+	err := scanProgressBackwards(logsRoot, func(line []byte) bool {
+		if line = bytes.TrimSpace(line); len(line) == 0 {
+			return true
+		}
+		last = line
+		return false
+	})
+	if err != nil || last == nil {
+		return nil, false, err
+	}
+
+	var ev map[string]any
+	if err := json.Unmarshal(last, &ev); err != nil {
+		return nil, false, fmt.Errorf("decode progress event: %w", err)
+	}
+	return ev, true, nil
+}
+
+// readFirstProgressEvent returns the oldest event still on disk, from the
+// oldest rolled file when the log has been rotated.
+func readFirstProgressEvent(logsRoot string) (map[string]any, bool, error) {
+	for _, path := range ProgressLogPaths(logsRoot) {
+		ev, found, err := readFirstEventInFile(path)
+		if err != nil || found {
+			return ev, found, err
+		}
+	}
+	return nil, false, nil
+}
+
+func readFirstEventInFile(path string) (map[string]any, bool, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -173,26 +336,19 @@ func readLastProgressEvent(path string) (map[string]any, bool, error) {
 
 	sc := bufio.NewScanner(f)
 	sc.Buffer(make([]byte, 0, 64*1024), 2*1024*1024)
-
-	last := ""
 	for sc.Scan() {
 		line := strings.TrimSpace(sc.Text())
-		if line != "" {
-			last = line
+		if line == "" {
+			continue
 		}
+		var ev map[string]any
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			// A torn or corrupt line carries no usable timestamp.
+			continue
+		}
+		return ev, true, nil
 	}
-	if err := sc.Err(); err != nil {
-		return nil, false, err
-	}
-	if last == "" {
-		return nil, false, nil
-	}
-
-	var ev map[string]any
-	if err := json.Unmarshal([]byte(last), &ev); err != nil {
-		return nil, false, fmt.Errorf("decode %s: %w", path, err)
-	}
-	return ev, true, nil
+	return nil, false, sc.Err()
 }
 
 func eventString(v any) string {
@@ -206,6 +362,15 @@ func eventString(v any) string {
 	}
 }
 
+// eventInt reads a JSON number (decoded as float64) as an int64.
+func eventInt(v any) (int64, bool) {
+	f, ok := v.(float64)
+	if !ok {
+		return 0, false
+	}
+	return int64(f), true
+}
+
 func parseEventTime(v any) time.Time {
 	raw := eventString(v)
 	if raw == "" {
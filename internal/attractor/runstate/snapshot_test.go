@@ -1,10 +1,15 @@
 package runstate
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/danshapiro/kilroy/internal/attractor/procutil"
 )
 
 func TestLoadSnapshot_FinalStateWinsAndIgnoresLiveForStateAndNode(t *testing.T) {
@@ -30,6 +35,49 @@ func TestLoadSnapshot_FinalStateWinsAndIgnoresLiveForStateAndNode(t *testing.T)
 	}
 }
 
+func TestLoadSnapshot_CanceledFinalIsTerminal(t *testing.T) {
+	root := t.TempDir()
+	_ = os.WriteFile(filepath.Join(root, "final.json"), []byte(`{"status":"canceled","run_id":"r1","failure_reason":"stopped by signal terminated"}`), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "run.pid"), []byte(strconv.Itoa(os.Getpid())), 0o644)
+
+	s, err := LoadSnapshot(root)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if s.State != StateCanceled {
+		t.Fatalf("state=%q want %q", s.State, StateCanceled)
+	}
+	if s.FailureReason != "stopped by signal terminated" {
+		t.Fatalf("failure_reason=%q", s.FailureReason)
+	}
+}
+
+func TestSnapshot_StatePredicates(t *testing.T) {
+	for _, tc := range []struct {
+		state                        State
+		terminal, running, succeeded bool
+	}{
+		{StateSuccess, true, false, true},
+		{StateFail, true, false, false},
+		{StateCanceled, true, false, false},
+		{StateRunning, false, true, false},
+		{StateOrphaned, false, false, false},
+		{StateUnknown, false, false, false},
+	} {
+		s := &Snapshot{State: tc.state}
+		if s.IsTerminal() != tc.terminal || s.IsRunning() != tc.running || s.Succeeded() != tc.succeeded {
+			t.Errorf("%s: IsTerminal=%v IsRunning=%v Succeeded=%v", tc.state, s.IsTerminal(), s.IsRunning(), s.Succeeded())
+		}
+		if tc.state.IsTerminal() != tc.terminal || tc.state.String() != string(tc.state) {
+			t.Errorf("%s: State.IsTerminal=%v String=%q", tc.state, tc.state.IsTerminal(), tc.state.String())
+		}
+	}
+	var nilSnapshot *Snapshot
+	if nilSnapshot.IsTerminal() || nilSnapshot.IsRunning() || nilSnapshot.Succeeded() {
+		t.Fatal("nil snapshot reports a state")
+	}
+}
+
 func TestLoadSnapshot_InfersRunningFromAlivePID(t *testing.T) {
 	root := t.TempDir()
 	_ = os.WriteFile(filepath.Join(root, "run.pid"), []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644)
@@ -78,3 +126,150 @@ func TestLoadSnapshot_TerminalStateIgnoresMalformedPIDFile(t *testing.T) {
 		t.Fatal("pid_alive=true want false for malformed pid file")
 	}
 }
+
+func TestLoadSnapshot_ElapsedAndProgressFromManifestAndCheckpoint(t *testing.T) {
+	root := t.TempDir()
+	_ = os.WriteFile(filepath.Join(root, "manifest.json"), []byte(`{"started_at":"2026-01-01T00:00:00Z","node_count":4}`), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "checkpoint.json"), []byte(`{"completed_nodes":["start","impl","impl"]}`), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "final.json"), []byte(`{"status":"fail","run_id":"r1","timestamp":"2026-01-01T00:01:30Z"}`), 0o644)
+
+	s, err := LoadSnapshot(root)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if want := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC); !s.StartedAt.Equal(want) {
+		t.Fatalf("started_at=%s want %s", s.StartedAt, want)
+	}
+	if s.Elapsed != 90*time.Second {
+		t.Fatalf("elapsed=%s want 1m30s", s.Elapsed)
+	}
+	if s.CompletedNodes != 2 || s.TotalNodes != 4 {
+		t.Fatalf("completed=%d total=%d want 2/4", s.CompletedNodes, s.TotalNodes)
+	}
+	if s.Progress != 0.5 {
+		t.Fatalf("progress=%v want 0.5", s.Progress)
+	}
+}
+
+func TestLoadSnapshot_StartedAtFallsBackToFirstProgressEvent(t *testing.T) {
+	root := t.TempDir()
+	progress := `{"ts":"2026-01-01T00:00:05Z","event":"stage_attempt_start","node_id":"start"}` + "\n" +
+		`{"ts":"2026-01-01T00:00:10Z","event":"stage_attempt_end","node_id":"start"}` + "\n"
+	_ = os.WriteFile(filepath.Join(root, "progress.ndjson"), []byte(progress), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "final.json"), []byte(`{"status":"success","timestamp":"2026-01-01T00:00:20Z"}`), 0o644)
+
+	s, err := LoadSnapshot(root)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if want := time.Date(2026, 1, 1, 0, 0, 5, 0, time.UTC); !s.StartedAt.Equal(want) {
+		t.Fatalf("started_at=%s want %s", s.StartedAt, want)
+	}
+	if s.Elapsed != 15*time.Second {
+		t.Fatalf("elapsed=%s want 15s", s.Elapsed)
+	}
+	if s.TotalNodes != 0 || s.Progress != 0 {
+		t.Fatalf("total=%d progress=%v want unset without a manifest node count", s.TotalNodes, s.Progress)
+	}
+}
+
+func TestLoadSnapshot_MalformedManifestCheckpointAndProgressAreSkipped(t *testing.T) {
+	root := t.TempDir()
+	_ = os.WriteFile(filepath.Join(root, "manifest.json"), []byte(`{"started_at":`), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "checkpoint.json"), []byte(`{"completed_nodes":[`), 0o644)
+	progress := `{"ts":"2026-01-01T00:00:0` + "\n" +
+		`{"ts":"2026-01-01T00:00:10Z","event":"stage_attempt_end","node_id":"start"}` + "\n"
+	_ = os.WriteFile(filepath.Join(root, "progress.ndjson"), []byte(progress), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "final.json"), []byte(`{"status":"success","run_id":"r1","timestamp":"2026-01-01T00:00:20Z"}`), 0o644)
+
+	s, err := LoadSnapshot(root)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if s.State != StateSuccess || s.RunID != "r1" {
+		t.Fatalf("state=%s run_id=%q want success/r1", s.State, s.RunID)
+	}
+	if want := time.Date(2026, 1, 1, 0, 0, 10, 0, time.UTC); !s.StartedAt.Equal(want) {
+		t.Fatalf("started_at=%s want %s (first decodable event)", s.StartedAt, want)
+	}
+	if s.GraphName != "" || s.CompletedNodes != 0 || s.TotalNodes != 0 {
+		t.Fatalf("graph=%q completed=%d total=%d want empty", s.GraphName, s.CompletedNodes, s.TotalNodes)
+	}
+}
+
+func TestLoadSnapshot_DeadPIDWithoutFinalIsOrphaned(t *testing.T) {
+	root := t.TempDir()
+	_ = os.WriteFile(filepath.Join(root, "run.pid"), []byte("999999999"), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "live.json"), []byte(`{"event":"stage_attempt_start","node_id":"impl"}`), 0o644)
+
+	s, err := LoadSnapshot(root)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if s.State != StateOrphaned {
+		t.Fatalf("state=%q want %q", s.State, StateOrphaned)
+	}
+	if !strings.Contains(s.StateReason, "999999999") {
+		t.Fatalf("state_reason=%q want it to name the pid", s.StateReason)
+	}
+	if s.CurrentNodeID != "impl" {
+		t.Fatalf("current_node_id=%q want impl", s.CurrentNodeID)
+	}
+}
+
+func TestLoadSnapshot_ReusedPIDIsOrphaned(t *testing.T) {
+	if !procutil.PIDStartTimeSupported() {
+		t.Skip("process start time is not available on this platform")
+	}
+	pid := os.Getpid()
+	start, err := procutil.ReadPIDStartTime(pid)
+	if err != nil {
+		t.Fatalf("ReadPIDStartTime: %v", err)
+	}
+	root := t.TempDir()
+	_ = os.WriteFile(filepath.Join(root, "run.pid"), []byte(strconv.Itoa(pid)), 0o644)
+
+	manifest := fmt.Sprintf(`{"pid":%d,"pid_start_time":%d}`, pid, start)
+	_ = os.WriteFile(filepath.Join(root, "manifest.json"), []byte(manifest), 0o644)
+	s, err := LoadSnapshot(root)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if s.State != StateRunning {
+		t.Fatalf("state=%q want %q when the start time matches", s.State, StateRunning)
+	}
+
+	manifest = fmt.Sprintf(`{"pid":%d,"pid_start_time":%d}`, pid, start+1)
+	_ = os.WriteFile(filepath.Join(root, "manifest.json"), []byte(manifest), 0o644)
+	s, err = LoadSnapshot(root)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if s.State != StateOrphaned || !s.PIDAlive {
+		t.Fatalf("state=%q pid_alive=%t want orphaned with a live (reused) pid", s.State, s.PIDAlive)
+	}
+}
+
+func TestLoadSnapshot_FinalSummaryFields(t *testing.T) {
+	root := t.TempDir()
+	final := `{"timestamp":"2026-01-01T00:10:00Z","status":"success","run_id":"r1","final_git_commit_sha":"abc",` +
+		`"completed_nodes":["start","a","exit"],"duration_ms":90000,"attempts":4,"retries":1,` +
+		`"input_tokens":100,"output_tokens":20,"total_tokens":120,"some_future_field":{"x":1}}`
+	_ = os.WriteFile(filepath.Join(root, "final.json"), []byte(final), 0o644)
+	_ = os.WriteFile(filepath.Join(root, "manifest.json"), []byte(`{"started_at":"2026-01-01T00:00:00Z"}`), 0o644)
+
+	s, err := LoadSnapshot(root)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if s.State != StateSuccess || strings.Join(s.CompletedNodeIDs, ",") != "start,a,exit" {
+		t.Fatalf("snapshot: %+v", s)
+	}
+	if s.Attempts != 4 || s.Retries != 1 || s.InputTokens != 100 || s.OutputTokens != 20 || s.TotalTokens != 120 {
+		t.Fatalf("summary: %+v", s)
+	}
+	// duration_ms wins over the manifest start to final timestamp span.
+	if s.Elapsed != 90*time.Second {
+		t.Fatalf("elapsed=%s want 1m30s", s.Elapsed)
+	}
+}
@@ -9,8 +9,24 @@ const (
 	StateRunning State = "running"
 	StateSuccess State = "success"
 	StateFail    State = "fail"
+	// StateCanceled means final.json records a run canceled before it
+	// finished, typically by `attractor stop` or a signal.
+	StateCanceled State = "canceled"
+	// StateOrphaned means run.pid names a process that is gone (or was
+	// replaced by an unrelated process reusing the pid) and the run never
+	// wrote a terminal final.json.
+	StateOrphaned State = "orphaned"
 )
 
+func (s State) String() string { return string(s) }
+
+// IsTerminal reports whether s is a final.json outcome: success, fail, or
+// canceled. Orphaned runs are not terminal; they never finished and can be
+// resumed.
+func (s State) IsTerminal() bool {
+	return s == StateSuccess || s == StateFail || s == StateCanceled
+}
+
 type Snapshot struct {
 	LogsRoot      string    `json:"logs_root"`
 	RunID         string    `json:"run_id,omitempty"`
@@ -19,6 +35,74 @@ type Snapshot struct {
 	LastEvent     string    `json:"last_event,omitempty"`
 	LastEventAt   time.Time `json:"last_event_at,omitempty"`
 	FailureReason string    `json:"failure_reason,omitempty"`
-	PID           int       `json:"pid,omitempty"`
+	PID           int       `json:"pid"`
 	PIDAlive      bool      `json:"pid_alive"`
+	// StateReason explains how State was inferred when it is not read from
+	// final.json; it is set for StateOrphaned.
+	StateReason string `json:"state_reason,omitempty"`
+
+	// StartedAt comes from manifest.json, or the first progress event when the
+	// manifest has no start time. Elapsed is final.json's duration_ms when
+	// recorded; otherwise it runs from StartedAt to final.json's timestamp for
+	// finished runs, the last event for dead runs, and now otherwise.
+	StartedAt time.Time     `json:"started_at,omitempty"`
+	Elapsed   time.Duration `json:"elapsed_ns,omitempty"`
+
+	// Graph identity from manifest.json: the graph's name, the DOT file the
+	// run was started from, and the SHA-256 of its source. GraphPath and
+	// GraphSHA256 are empty for runs whose manifest predates them.
+	GraphName   string `json:"graph_name,omitempty"`
+	GraphPath   string `json:"graph_path,omitempty"`
+	GraphSHA256 string `json:"graph_sha256,omitempty"`
+
+	// CompletedNodes counts distinct nodes in checkpoint.json; TotalNodes is the
+	// graph node count recorded in the manifest. Progress is their ratio in
+	// [0, 1] and is only set when TotalNodes is known; loops and unvisited
+	// branches make it a coarse estimate.
+	CompletedNodes int     `json:"completed_nodes,omitempty"`
+	TotalNodes     int     `json:"total_nodes,omitempty"`
+	Progress       float64 `json:"progress,omitempty"`
+
+	// The run summary from final.json, for finished runs whose engine wrote
+	// one: the checkpointed node path in execution order, attempt and retry
+	// totals across all nodes, and aggregate LLM token usage.
+	CompletedNodeIDs []string `json:"completed_node_ids,omitempty"`
+	Attempts         int      `json:"attempts,omitempty"`
+	Retries          int      `json:"retries,omitempty"`
+	InputTokens      int64    `json:"input_tokens,omitempty"`
+	OutputTokens     int64    `json:"output_tokens,omitempty"`
+	TotalTokens      int64    `json:"total_tokens,omitempty"`
+
+	// StallIdleMS and StallTimeoutMS come from the newest progress event when it
+	// is a stall watchdog heartbeat or warning: how long the run has gone
+	// without progress and how long the watchdog allows. Both are zero once the
+	// run makes progress again, and for finished runs.
+	StallIdleMS    int64 `json:"stall_idle_ms,omitempty"`
+	StallTimeoutMS int64 `json:"stall_timeout_ms,omitempty"`
+
+	// RecentEvents holds the last DefaultRecentEvents stage_* events from
+	// progress.ndjson, oldest first.
+	RecentEvents []EventSummary `json:"recent_events,omitempty"`
+}
+
+// IsTerminal reports whether the run finished (see State.IsTerminal).
+func (s *Snapshot) IsTerminal() bool {
+	return s != nil && s.State.IsTerminal()
+}
+
+// IsRunning reports whether the run's process is alive and still working.
+func (s *Snapshot) IsRunning() bool {
+	return s != nil && s.State == StateRunning
+}
+
+// Succeeded reports whether the run finished with status success.
+func (s *Snapshot) Succeeded() bool {
+	return s != nil && s.State == StateSuccess
+}
+
+// EventSummary is one stage transition from progress.ndjson.
+type EventSummary struct {
+	NodeID string    `json:"node_id"`
+	Event  string    `json:"event"`
+	TS     time.Time `json:"ts"`
 }
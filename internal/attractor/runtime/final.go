@@ -10,6 +10,9 @@ type FinalStatus string
 const (
 	FinalSuccess FinalStatus = "success"
 	FinalFail    FinalStatus = "fail"
+	// FinalCanceled means the run's caller canceled it before it finished,
+	// for example `attractor run` receiving SIGTERM or SIGINT.
+	FinalCanceled FinalStatus = "canceled"
 )
 
 type FinalOutcome struct {
@@ -23,6 +26,31 @@ type FinalOutcome struct {
 
 	CXDBContextID  string `json:"cxdb_context_id"`
 	CXDBHeadTurnID string `json:"cxdb_head_turn_id"`
+
+	// Run summary. CompletedNodes is the checkpointed node path in execution
+	// order (loops repeat nodes); DurationMS runs from the start recorded in
+	// manifest.json. Attempts, Retries, and the token totals sum the per-node
+	// figures in metrics.json. All are omitted when unknown, and readers must
+	// tolerate final.json files written before they existed.
+	CompletedNodes []string `json:"completed_nodes,omitempty"`
+	DurationMS     int64    `json:"duration_ms,omitempty"`
+	Attempts       int      `json:"attempts,omitempty"`
+	Retries        int      `json:"retries,omitempty"`
+	InputTokens    int64    `json:"input_tokens,omitempty"`
+	OutputTokens   int64    `json:"output_tokens,omitempty"`
+	TotalTokens    int64    `json:"total_tokens,omitempty"`
+
+	// ModelCatalogDrift is set when the run's effective model catalog
+	// differs from the pinned snapshot.
+	ModelCatalogDrift *CatalogDrift `json:"model_catalog_drift,omitempty"`
+}
+
+// CatalogDrift records a mismatch between the pinned and effective model
+// catalogs.
+type CatalogDrift struct {
+	PinnedSHA256    string `json:"pinned_sha256"`
+	EffectiveSHA256 string `json:"effective_sha256"`
+	Source          string `json:"source,omitempty"`
 }
 
 func (fo *FinalOutcome) Save(path string) error {
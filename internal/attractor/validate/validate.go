@@ -2,7 +2,9 @@ package validate
 
 import (
 	"fmt"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/danshapiro/kilroy/internal/attractor/cond"
@@ -27,6 +29,48 @@ type Diagnostic struct {
 	EdgeFrom string   `json:"edge_from,omitempty"`
 	EdgeTo   string   `json:"edge_to,omitempty"`
 	Fix      string   `json:"fix,omitempty"`
+	// File, Line, and Column locate the offending node or edge statement in
+	// the DOT source (see model.Pos); Line is 0 when the location is unknown.
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+// Pos returns the diagnostic's source location.
+func (d Diagnostic) Pos() model.Pos {
+	return model.Pos{File: d.File, Line: d.Line, Column: d.Column}
+}
+
+// Locate fills in the source location of diagnostics that name a node or edge
+// but carry no location yet, using the positions the DOT parser recorded on g.
+// An edge diagnostic is placed at the first edge between its endpoints.
+func Locate(g *model.Graph, diags []Diagnostic) {
+	if g == nil {
+		return
+	}
+	for i := range diags {
+		d := &diags[i]
+		if d.Line > 0 {
+			continue
+		}
+		var pos model.Pos
+		switch {
+		case d.EdgeFrom != "" && d.EdgeTo != "":
+			for _, e := range g.Outgoing(d.EdgeFrom) {
+				if e != nil && e.To == d.EdgeTo {
+					pos = e.Pos
+					break
+				}
+			}
+		case d.NodeID != "":
+			if n := g.Nodes[d.NodeID]; n != nil {
+				pos = n.Pos
+			}
+		}
+		if pos.IsValid() {
+			d.File, d.Line, d.Column = pos.File, pos.Line, pos.Column
+		}
+	}
 }
 
 // LintRule is the interface for custom lint rules that can be passed to
@@ -65,6 +109,13 @@ func Validate(g *model.Graph, extraRules ...LintRule) []Diagnostic {
 	diags = append(diags, lintLoopRestartFailureClassGuard(g)...)
 	diags = append(diags, lintFailLoopFailureClassGuard(g)...)
 	diags = append(diags, lintEscalationModelsSyntax(g)...)
+	diags = append(diags, lintNodeTimeoutMS(g)...)
+	diags = append(diags, lintRetryOnExitCodes(g)...)
+	diags = append(diags, lintSkipIfSyntax(g)...)
+	diags = append(diags, lintFanInJoin(g)...)
+	diags = append(diags, lintFanInMerge(g)...)
+	diags = append(diags, lintCaptureOutput(g)...)
+	diags = append(diags, lintWorktreeGlobs(g)...)
 	diags = append(diags, lintAllConditionalEdges(g)...)
 
 	// Run custom lint rules (spec §7.3: extra_rules appended after built-in rules).
@@ -73,6 +124,7 @@ func Validate(g *model.Graph, extraRules ...LintRule) []Diagnostic {
 			diags = append(diags, rule.Apply(g)...)
 		}
 	}
+	Locate(g, diags)
 	return diags
 }
 
@@ -317,46 +369,50 @@ func lintConditionSyntax(g *model.Graph) []Diagnostic {
 	return diags
 }
 
-func validateConditionSyntax(condExpr string) error {
-	clauses := strings.Split(condExpr, "&&")
-	for _, clause := range clauses {
-		clause = strings.TrimSpace(clause)
-		if clause == "" {
+// lintSkipIfSyntax checks node skip_if expressions with the edge condition
+// grammar.
+func lintSkipIfSyntax(g *model.Graph) []Diagnostic {
+	var diags []Diagnostic
+	for id, n := range g.Nodes {
+		if n == nil {
 			continue
 		}
-		// Disallow known future operators so mistakes don't silently "parse" as bare keys.
-		if strings.ContainsAny(clause, "<>|") {
-			return fmt.Errorf("invalid condition operator in clause %q", clause)
-		}
-		if strings.Contains(clause, "!=") {
-			parts := strings.SplitN(clause, "!=", 2)
-			if len(parts) != 2 {
-				return fmt.Errorf("invalid condition clause %q", clause)
-			}
-			if err := validateCondKey(strings.TrimSpace(parts[0])); err != nil {
-				return err
-			}
-			if strings.TrimSpace(parts[1]) == "" {
-				return fmt.Errorf("invalid condition clause %q: missing literal", clause)
-			}
+		c := strings.TrimSpace(n.Attr("skip_if", ""))
+		if c == "" {
 			continue
 		}
-		if strings.Contains(clause, "=") {
-			parts := strings.SplitN(clause, "=", 2)
-			if len(parts) != 2 {
-				return fmt.Errorf("invalid condition clause %q", clause)
-			}
-			if err := validateCondKey(strings.TrimSpace(parts[0])); err != nil {
+		if err := validateConditionSyntax(c); err != nil {
+			diags = append(diags, Diagnostic{
+				Rule:     "skip_if_syntax",
+				Severity: SeverityError,
+				Message:  err.Error(),
+				NodeID:   id,
+			})
+		}
+	}
+	return diags
+}
+
+func validateConditionSyntax(condExpr string) error {
+	groups, err := cond.Parse(condExpr)
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		for _, c := range group {
+			if err := validateCondKey(c.Key); err != nil {
 				return err
 			}
-			if strings.TrimSpace(parts[1]) == "" {
-				return fmt.Errorf("invalid condition clause %q: missing literal", clause)
+			if c.Op != "" && c.Literal == "" {
+				return fmt.Errorf("invalid condition clause %q: missing literal", c.Key+c.Op)
+			}
+			// Ordering against a non-number is always false at runtime, which
+			// almost always means a typo such as outcome>success.
+			if cond.IsOrdering(c.Op) {
+				if _, err := strconv.ParseFloat(c.Literal, 64); err != nil {
+					return fmt.Errorf("invalid condition clause %q: operator %s requires a numeric literal", c.Key+c.Op+c.Literal, c.Op)
+				}
 			}
-			continue
-		}
-		// Bare key: allow but validate key shape.
-		if err := validateCondKey(strings.TrimSpace(clause)); err != nil {
-			return err
 		}
 	}
 	return nil
@@ -677,6 +733,10 @@ func lintLLMProviderPresent(g *model.Graph) []Diagnostic {
 		if n.Shape() != "box" {
 			continue
 		}
+		// Nodes routed to a registered codergen backend bypass provider routing.
+		if strings.TrimSpace(n.Attr("codergen_backend", "")) != "" || strings.TrimSpace(g.Attrs["codergen_backend"]) != "" {
+			continue
+		}
 		if strings.TrimSpace(n.Attr("llm_provider", "")) == "" {
 			diags = append(diags, Diagnostic{
 				Rule:     "llm_provider_required",
@@ -1100,6 +1160,231 @@ func (r *TypeKnownRule) Apply(g *model.Graph) []Diagnostic {
 	return diags
 }
 
+// RequiredInputsRule reports graph inputs declared with requires="a,b" that
+// the run does not provide. Like TypeKnownRule it is supplied by the engine,
+// which knows the run's initial context; static validation skips it.
+type RequiredInputsRule struct {
+	Provided map[string]bool
+}
+
+func NewRequiredInputsRule(provided []string) *RequiredInputsRule {
+	m := make(map[string]bool, len(provided))
+	for _, k := range provided {
+		m[strings.TrimSpace(k)] = true
+	}
+	return &RequiredInputsRule{Provided: m}
+}
+
+func (r *RequiredInputsRule) Name() string { return "required_inputs" }
+
+func (r *RequiredInputsRule) Apply(g *model.Graph) []Diagnostic {
+	var diags []Diagnostic
+	for _, key := range RequiredInputs(g) {
+		if r.Provided[key] {
+			continue
+		}
+		diags = append(diags, Diagnostic{
+			Rule:     "required_inputs",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("graph requires input %q but it was not set", key),
+			Fix:      fmt.Sprintf("pass --set %s=<value> (or set it in the run's initial context)", key),
+		})
+	}
+	return diags
+}
+
+// RequiredInputs returns the trimmed, de-duplicated keys of the graph's
+// requires attribute in declaration order.
+func RequiredInputs(g *model.Graph) []string {
+	if g == nil {
+		return nil
+	}
+	var keys []string
+	seen := map[string]bool{}
+	for _, k := range strings.Split(g.Attrs["requires"], ",") {
+		k = strings.TrimSpace(k)
+		if k == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// lintNodeTimeoutMS rejects node_timeout_ms values the engine cannot use as a
+// per-attempt deadline.
+func lintNodeTimeoutMS(g *model.Graph) []Diagnostic {
+	var diags []Diagnostic
+	for id, n := range g.Nodes {
+		if n == nil {
+			continue
+		}
+		raw := strings.TrimSpace(n.Attr("node_timeout_ms", ""))
+		if raw == "" {
+			continue
+		}
+		if ms, err := strconv.Atoi(raw); err != nil || ms <= 0 {
+			diags = append(diags, Diagnostic{
+				Rule:     "node_timeout_ms_valid",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("node_timeout_ms must be a positive integer number of milliseconds, got %q", raw),
+				NodeID:   id,
+			})
+		}
+	}
+	return diags
+}
+
+// lintRetryOnExitCodes rejects retry_on_exit_codes lists with non-integer
+// entries.
+func lintRetryOnExitCodes(g *model.Graph) []Diagnostic {
+	var diags []Diagnostic
+	for id, n := range g.Nodes {
+		if n == nil {
+			continue
+		}
+		raw := strings.TrimSpace(n.Attr("retry_on_exit_codes", ""))
+		if raw == "" {
+			continue
+		}
+		for _, part := range strings.Split(raw, ",") {
+			if _, err := strconv.Atoi(strings.TrimSpace(part)); err != nil {
+				diags = append(diags, Diagnostic{
+					Rule:     "retry_on_exit_codes_valid",
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("retry_on_exit_codes must be a comma-separated list of integer exit codes, got %q", raw),
+					NodeID:   id,
+				})
+				break
+			}
+		}
+	}
+	return diags
+}
+
+// lintFanInJoin rejects join values other than all, any, and quorum:N with a
+// positive N.
+func lintFanInJoin(g *model.Graph) []Diagnostic {
+	var diags []Diagnostic
+	for id, n := range g.Nodes {
+		if n == nil {
+			continue
+		}
+		raw := strings.TrimSpace(n.Attr("join", ""))
+		if raw == "" {
+			continue
+		}
+		v := strings.ToLower(raw)
+		if v == "all" || v == "any" {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(v, "quorum:"); ok {
+			if k, err := strconv.Atoi(strings.TrimSpace(rest)); err == nil && k > 0 {
+				continue
+			}
+		}
+		diags = append(diags, Diagnostic{
+			Rule:     "join_valid",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("join must be all, any, or quorum:N with N > 0, got %q", raw),
+			NodeID:   id,
+		})
+	}
+	return diags
+}
+
+// lintFanInMerge rejects fan-in merge values other than winner and all.
+func lintFanInMerge(g *model.Graph) []Diagnostic {
+	var diags []Diagnostic
+	for id, n := range g.Nodes {
+		if n == nil {
+			continue
+		}
+		raw := strings.TrimSpace(n.Attr("merge", ""))
+		if raw == "" {
+			continue
+		}
+		if v := strings.ToLower(raw); v == "winner" || v == "all" {
+			continue
+		}
+		diags = append(diags, Diagnostic{
+			Rule:     "merge_valid",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("merge must be winner or all, got %q", raw),
+			NodeID:   id,
+		})
+	}
+	return diags
+}
+
+// lintCaptureOutput checks the tool node capture_output mode and its byte cap.
+func lintCaptureOutput(g *model.Graph) []Diagnostic {
+	var diags []Diagnostic
+	for id, n := range g.Nodes {
+		if n == nil {
+			continue
+		}
+		if raw := strings.TrimSpace(n.Attr("capture_output", "")); raw != "" {
+			switch strings.ToLower(raw) {
+			case "last_line", "full", "json":
+			default:
+				diags = append(diags, Diagnostic{
+					Rule:     "capture_output_valid",
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("capture_output must be last_line, full, or json, got %q", raw),
+					NodeID:   id,
+				})
+			}
+		}
+		if raw := strings.TrimSpace(n.Attr("capture_max_bytes", "")); raw != "" {
+			if v, err := strconv.Atoi(raw); err != nil || v <= 0 {
+				diags = append(diags, Diagnostic{
+					Rule:     "capture_output_valid",
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("capture_max_bytes must be a positive integer, got %q", raw),
+					NodeID:   id,
+				})
+			}
+		}
+	}
+	return diags
+}
+
+// lintWorktreeGlobs rejects artifacts and cache_key patterns that are absolute
+// or climb out of the worktree with "..".
+func lintWorktreeGlobs(g *model.Graph) []Diagnostic {
+	var diags []Diagnostic
+	for id, n := range g.Nodes {
+		if n == nil {
+			continue
+		}
+		for _, attr := range []string{"artifacts", "cache_key"} {
+			for _, p := range strings.Split(n.Attr(attr, ""), ",") {
+				p = strings.TrimSpace(p)
+				if p == "" {
+					continue
+				}
+				escapes := strings.HasPrefix(p, "/") || strings.HasPrefix(p, `\`) || filepath.IsAbs(p)
+				for _, seg := range strings.FieldsFunc(p, func(r rune) bool { return r == '/' || r == '\\' }) {
+					if seg == ".." {
+						escapes = true
+					}
+				}
+				if escapes {
+					diags = append(diags, Diagnostic{
+						Rule:     "worktree_glob_relative",
+						Severity: SeverityError,
+						Message:  fmt.Sprintf("%s pattern %q must be relative to the worktree (no absolute paths or ..)", attr, p),
+						NodeID:   id,
+					})
+				}
+			}
+		}
+	}
+	return diags
+}
+
 // lintAllConditionalEdges warns when a non-terminal node has outgoing edges but
 // all are conditional (no unconditional fallback). This creates a routing gap:
 // if no condition matches at runtime, the engine has no edge to follow.
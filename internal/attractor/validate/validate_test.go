@@ -380,6 +380,227 @@ digraph G {
 	}
 }
 
+func TestValidate_ConditionExpressions_AcceptedAndOrderingNeedsNumber(t *testing.T) {
+	g, err := dot.Parse([]byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  a [shape=parallelogram, tool_command="true"]
+  start -> a
+  a -> exit [condition="exit_code > 1 || notes contains 'flaky'"]
+  a -> exit [condition="outcome=success && context.attempts <= 3"]
+}
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	for _, d := range Validate(g) {
+		if d.Rule == "condition_syntax" {
+			t.Fatalf("unexpected condition_syntax diagnostic: %+v", d)
+		}
+	}
+
+	g, err = dot.Parse([]byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  a [shape=parallelogram, tool_command="true"]
+  start -> a -> exit
+  a -> exit [condition="exit_code >= high"]
+}
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	assertHasRule(t, Validate(g), "condition_syntax", SeverityError)
+}
+
+func TestValidate_NodeTimeoutMS_RejectsNonPositiveOrNonInteger(t *testing.T) {
+	g, err := dot.Parse([]byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  a [shape=parallelogram, tool_command="true", node_timeout_ms="1.5s"]
+  b [shape=parallelogram, tool_command="true", node_timeout_ms=250]
+  start -> a -> b -> exit
+}
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	diags := Validate(g)
+	assertHasRule(t, diags, "node_timeout_ms_valid", SeverityError)
+	for _, d := range diags {
+		if d.Rule == "node_timeout_ms_valid" && d.NodeID != "a" {
+			t.Fatalf("unexpected node_timeout_ms_valid diagnostic: %+v", d)
+		}
+	}
+}
+
+func TestValidate_RetryOnExitCodes_RejectsNonIntegers(t *testing.T) {
+	g, err := dot.Parse([]byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  a [shape=parallelogram, tool_command="true", retry_on_exit_codes="2,timeout"]
+  b [shape=parallelogram, tool_command="true", retry_on_exit_codes="2, 124"]
+  start -> a -> b -> exit
+}
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	diags := Validate(g)
+	assertHasRule(t, diags, "retry_on_exit_codes_valid", SeverityError)
+	for _, d := range diags {
+		if d.Rule == "retry_on_exit_codes_valid" && d.NodeID != "a" {
+			t.Fatalf("unexpected retry_on_exit_codes_valid diagnostic: %+v", d)
+		}
+	}
+}
+
+func TestValidate_SkipIfSyntax(t *testing.T) {
+	g, err := dot.Parse([]byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  a [shape=parallelogram, tool_command="true", skip_if="env="]
+  b [shape=parallelogram, tool_command="true", skip_if="env=dev"]
+  start -> a -> b -> exit
+}
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	diags := Validate(g)
+	assertHasRule(t, diags, "skip_if_syntax", SeverityError)
+	for _, d := range diags {
+		if d.Rule == "skip_if_syntax" && d.NodeID != "a" {
+			t.Fatalf("unexpected skip_if_syntax diagnostic: %+v", d)
+		}
+	}
+}
+
+func TestValidate_FanInJoin_RejectsUnknownValues(t *testing.T) {
+	g, err := dot.Parse([]byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  par [shape=component]
+  a [shape=parallelogram, tool_command="true"]
+  b [shape=parallelogram, tool_command="true"]
+  join [shape=tripleoctagon, join="quorum:0"]
+  ok [shape=tripleoctagon, join="quorum:2"]
+  start -> par
+  par -> a
+  par -> b
+  a -> join
+  b -> join
+  join -> ok -> exit
+}
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	diags := Validate(g)
+	assertHasRule(t, diags, "join_valid", SeverityError)
+	for _, d := range diags {
+		if d.Rule == "join_valid" && d.NodeID != "join" {
+			t.Fatalf("unexpected join_valid diagnostic: %+v", d)
+		}
+	}
+}
+
+func TestValidate_FanInMerge_RejectsUnknownValues(t *testing.T) {
+	g, err := dot.Parse([]byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  par [shape=component]
+  a [shape=parallelogram, tool_command="true"]
+  b [shape=parallelogram, tool_command="true"]
+  join [shape=tripleoctagon, merge="octopus"]
+  ok [shape=tripleoctagon, merge="all"]
+  start -> par
+  par -> a
+  par -> b
+  a -> join
+  b -> join
+  join -> ok -> exit
+}
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	diags := Validate(g)
+	assertHasRule(t, diags, "merge_valid", SeverityError)
+	for _, d := range diags {
+		if d.Rule == "merge_valid" && d.NodeID != "join" {
+			t.Fatalf("unexpected merge_valid diagnostic: %+v", d)
+		}
+	}
+}
+
+func TestValidate_CaptureOutput_RejectsUnknownModeAndBadCap(t *testing.T) {
+	g, err := dot.Parse([]byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  a [shape=parallelogram, tool_command="true", capture_output="lines"]
+  b [shape=parallelogram, tool_command="true", capture_output="json", capture_max_bytes="0"]
+  c [shape=parallelogram, tool_command="true", capture_output="last_line", capture_max_bytes="1024"]
+  start -> a -> b -> c -> exit
+}
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	diags := Validate(g)
+	assertHasRule(t, diags, "capture_output_valid", SeverityError)
+	n := 0
+	for _, d := range diags {
+		if d.Rule == "capture_output_valid" {
+			n++
+			if d.NodeID == "c" {
+				t.Fatalf("unexpected capture_output_valid diagnostic: %+v", d)
+			}
+		}
+	}
+	if n != 2 {
+		t.Fatalf("capture_output_valid diagnostics: got %d want 2", n)
+	}
+}
+
+func TestValidate_WorktreeGlobs_RejectsEscapingPatterns(t *testing.T) {
+	g, err := dot.Parse([]byte(`
+digraph G {
+  start [shape=Mdiamond]
+  exit  [shape=Msquare]
+  a [shape=parallelogram, tool_command="true", artifacts="out/*.txt, ../secrets/*"]
+  b [shape=parallelogram, tool_command="true", cache_key="/etc/passwd"]
+  c [shape=parallelogram, tool_command="true", artifacts="out/**", cache_key="go.mod,internal/**/*.go"]
+  start -> a -> b -> c -> exit
+}
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	diags := Validate(g)
+	assertHasRule(t, diags, "worktree_glob_relative", SeverityError)
+	n := 0
+	for _, d := range diags {
+		if d.Rule == "worktree_glob_relative" {
+			n++
+			if d.NodeID == "c" {
+				t.Fatalf("unexpected worktree_glob_relative diagnostic: %+v", d)
+			}
+		}
+	}
+	if n != 2 {
+		t.Fatalf("worktree_glob_relative diagnostics: got %d want 2", n)
+	}
+}
+
 func TestValidate_EscalationModelsSyntax_MissingColon(t *testing.T) {
 	g, err := dot.Parse([]byte(`
 digraph G {
@@ -764,3 +985,40 @@ digraph G {
 	}
 	t.Fatal("expected exit_no_outgoing diagnostic for exit2")
 }
+
+func TestValidate_DiagnosticsCarrySourcePositions(t *testing.T) {
+	g, err := dot.Parse([]byte(`digraph G {
+  start [shape=Mdiamond]
+  exit [shape=Msquare]
+  a [shape=parallelogram]
+  start -> a
+  a -> exit [condition="outcome="]
+}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	var nodeDiag, edgeDiag *Diagnostic
+	diags := Validate(g)
+	for i, d := range diags {
+		switch {
+		case d.Rule == "tool_command_required" && d.NodeID == "a":
+			nodeDiag = &diags[i]
+		case d.Rule == "condition_syntax":
+			edgeDiag = &diags[i]
+		}
+	}
+	if nodeDiag == nil || nodeDiag.Line != 4 || nodeDiag.Column != 3 {
+		t.Fatalf("node diagnostic position: %+v", nodeDiag)
+	}
+	if edgeDiag == nil || edgeDiag.Pos().String() != "6:3" {
+		t.Fatalf("edge diagnostic position: %+v", edgeDiag)
+	}
+
+	// Diagnostics without a node or edge, or for synthesized nodes, stay unlocated.
+	g.Nodes["synth"] = model.NewNode("synth")
+	d := []Diagnostic{{Rule: "x"}, {Rule: "y", NodeID: "synth"}}
+	Locate(g, d)
+	if d[0].Line != 0 || d[1].Line != 0 {
+		t.Fatalf("unexpected positions: %+v", d)
+	}
+}
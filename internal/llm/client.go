@@ -2,7 +2,7 @@ package llm
 
 import (
 	"context"
-	"fmt"
+	"sort"
 
 	"github.com/danshapiro/kilroy/internal/providerspec"
 )
@@ -37,7 +37,8 @@ func (c *Client) SetDefaultProvider(name string) {
 	c.defaultProvider = name
 }
 
-func (c *Client) ProviderNames() []string {
+// Providers returns the names of the registered adapters, sorted.
+func (c *Client) Providers() []string {
 	if c == nil || len(c.providers) == 0 {
 		return nil
 	}
@@ -45,9 +46,15 @@ func (c *Client) ProviderNames() []string {
 	for k := range c.providers {
 		out = append(out, k)
 	}
+	sort.Strings(out)
 	return out
 }
 
+// ProviderNames is Providers.
+func (c *Client) ProviderNames() []string {
+	return c.Providers()
+}
+
 func (c *Client) Complete(ctx context.Context, req Request) (Response, error) {
 	if err := req.Validate(); err != nil {
 		return Response{}, err
@@ -62,7 +69,7 @@ func (c *Client) Complete(ctx context.Context, req Request) (Response, error) {
 	prov = normalizeProviderName(prov)
 	adapter, ok := c.providers[prov]
 	if !ok {
-		return Response{}, &ConfigurationError{Message: fmt.Sprintf("unknown provider: %s", prov)}
+		return Response{}, &UnknownProviderError{Name: prov, Registered: c.Providers()}
 	}
 	req.Provider = prov
 
@@ -87,7 +94,7 @@ func (c *Client) Stream(ctx context.Context, req Request) (Stream, error) {
 	prov = normalizeProviderName(prov)
 	adapter, ok := c.providers[prov]
 	if !ok {
-		return nil, &ConfigurationError{Message: fmt.Sprintf("unknown provider: %s", prov)}
+		return nil, &UnknownProviderError{Name: prov, Registered: c.Providers()}
 	}
 	req.Provider = prov
 
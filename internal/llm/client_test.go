@@ -99,6 +99,31 @@ func TestClient_UnknownProviderError(t *testing.T) {
 	}
 }
 
+func TestClient_UnknownProviderError_NamesMissingAndRegisteredProviders(t *testing.T) {
+	c := NewClient()
+	c.Register(&fakeAdapter{name: "zai"})
+	c.Register(&fakeAdapter{name: "anthropic"})
+	if got := strings.Join(c.Providers(), ","); got != "anthropic,zai" {
+		t.Fatalf("Providers() = %q, want anthropic,zai", got)
+	}
+	req := Request{Provider: "kimi", Model: "m", Messages: []Message{User("hi")}}
+
+	_, completeErr := c.Complete(context.Background(), req)
+	_, streamErr := c.Stream(context.Background(), req)
+	for _, err := range []error{completeErr, streamErr} {
+		var upe *UnknownProviderError
+		if !errors.As(err, &upe) {
+			t.Fatalf("expected UnknownProviderError, got %T (%v)", err, err)
+		}
+		if upe.Name != "kimi" || strings.Join(upe.Registered, ",") != "anthropic,zai" {
+			t.Fatalf("UnknownProviderError = %+v", upe)
+		}
+		if msg := err.Error(); !strings.Contains(msg, "unknown provider: kimi") || !strings.Contains(msg, "registered: anthropic, zai") {
+			t.Fatalf("error = %q", msg)
+		}
+	}
+}
+
 func TestClient_NoProviderConfiguredError(t *testing.T) {
 	c := NewClient()
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
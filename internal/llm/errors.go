@@ -30,6 +30,38 @@ func (e *ConfigurationError) StatusCode() int            { return 0 }
 func (e *ConfigurationError) Retryable() bool            { return false }
 func (e *ConfigurationError) RetryAfter() *time.Duration { return nil }
 
+// UnknownProviderError is returned by Client.Complete and Client.Stream when
+// no adapter is registered under the requested provider name. It lists the
+// registered providers, so a graph naming kimi against a client that only
+// registered zai says so, and it unwraps to a *ConfigurationError.
+type UnknownProviderError struct {
+	// Name is the requested provider after alias normalization.
+	Name string
+	// Registered is the client's registered providers, sorted.
+	Registered []string
+}
+
+func (e *UnknownProviderError) Error() string {
+	return (&ConfigurationError{Message: e.message()}).Error()
+}
+
+func (e *UnknownProviderError) message() string {
+	registered := "none"
+	if len(e.Registered) > 0 {
+		registered = strings.Join(e.Registered, ", ")
+	}
+	return fmt.Sprintf("unknown provider: %s (registered: %s)", e.Name, registered)
+}
+
+func (e *UnknownProviderError) Unwrap() error {
+	return &ConfigurationError{Message: e.message()}
+}
+
+func (e *UnknownProviderError) Provider() string           { return e.Name }
+func (e *UnknownProviderError) StatusCode() int            { return 0 }
+func (e *UnknownProviderError) Retryable() bool            { return false }
+func (e *UnknownProviderError) RetryAfter() *time.Duration { return nil }
+
 type httpErrorBase struct {
 	provider    string
 	statusCode  int
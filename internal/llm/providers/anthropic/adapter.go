@@ -157,6 +157,9 @@ func (a *Adapter) Complete(ctx context.Context, req llm.Request) (llm.Response,
 		}
 		body["tools"] = tools
 	}
+	if md := anthropicMetadata(req.Metadata); md != nil {
+		body["metadata"] = md
+	}
 	if req.ProviderOptions != nil {
 		if ov, ok := req.ProviderOptions["anthropic"].(map[string]any); ok {
 			for k, v := range ov {
@@ -332,6 +335,9 @@ func (a *Adapter) Stream(ctx context.Context, req llm.Request) (llm.Stream, erro
 		}
 		body["tools"] = tools
 	}
+	if md := anthropicMetadata(req.Metadata); md != nil {
+		body["metadata"] = md
+	}
 	if req.ProviderOptions != nil {
 		if ov, ok := req.ProviderOptions["anthropic"].(map[string]any); ok {
 			for k, v := range ov {
@@ -1234,3 +1240,13 @@ var versionDotRe = regexp.MustCompile(`(\d)\.(\d)`)
 func nativeModelID(id string) string {
 	return versionDotRe.ReplaceAllString(id, "${1}-${2}")
 }
+
+// anthropicMetadata maps unified request metadata onto the Messages API
+// metadata object, which only accepts user_id. Other keys are dropped.
+func anthropicMetadata(md map[string]string) map[string]any {
+	userID := strings.TrimSpace(md["user_id"])
+	if userID == "" {
+		return nil
+	}
+	return map[string]any{"user_id": userID}
+}
@@ -2026,3 +2026,52 @@ func writeAnthropicStreamOK(w http.ResponseWriter, text string) {
 	write("message_delta", `{"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`)
 	write("message_stop", `{}`)
 }
+
+func TestAdapter_Complete_MapsMetadataUserID(t *testing.T) {
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		gotBody = nil
+		_ = json.Unmarshal(b, &gotBody)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+  "id": "msg_1",
+  "model": "claude-sonnet-4-5",
+  "content": [{"type":"text","text":"ok"}],
+  "stop_reason": "end_turn",
+  "usage": {"input_tokens": 1, "output_tokens": 2}
+}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	a := &Adapter{APIKey: "k", BaseURL: srv.URL, Client: srv.Client()}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err := a.Complete(ctx, llm.Request{
+		Model:    "claude-sonnet-4-5",
+		Messages: []llm.Message{llm.User("hi")},
+		Metadata: map[string]string{"user_id": "team-core", "project": "kilroy"},
+	})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	md, ok := gotBody["metadata"].(map[string]any)
+	if !ok || md["user_id"] != "team-core" || len(md) != 1 {
+		t.Fatalf("metadata: got %#v, want only user_id", gotBody["metadata"])
+	}
+
+	_, err = a.Complete(ctx, llm.Request{
+		Model:    "claude-sonnet-4-5",
+		Messages: []llm.Message{llm.User("hi")},
+		Metadata: map[string]string{"project": "kilroy"},
+	})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if _, present := gotBody["metadata"]; present {
+		t.Fatalf("metadata without user_id should be dropped: %#v", gotBody["metadata"])
+	}
+}
@@ -22,6 +22,12 @@ type Config struct {
 	Path         string
 	OptionsKey   string
 	ExtraHeaders map[string]string
+
+	// MetadataField names the top-level request body field that
+	// llm.Request.Metadata is serialized into (e.g. "metadata"). Many
+	// OpenAI-compatible endpoints reject unknown fields, so metadata is
+	// dropped unless the endpoint is known to accept it.
+	MetadataField string
 }
 
 type Adapter struct {
@@ -34,6 +40,7 @@ const defaultRequestTimeout = 10 * time.Minute
 func NewAdapter(cfg Config) *Adapter {
 	cfg.Provider = strings.ToLower(strings.TrimSpace(cfg.Provider))
 	cfg.BaseURL = strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	cfg.MetadataField = strings.TrimSpace(cfg.MetadataField)
 	if strings.TrimSpace(cfg.Path) == "" {
 		cfg.Path = "/v1/chat/completions"
 	}
@@ -55,7 +62,9 @@ func (a *Adapter) Complete(ctx context.Context, req llm.Request) (llm.Response,
 	requestCtx, cancel := withDefaultRequestDeadline(ctx)
 	defer cancel()
 
-	body, err := toChatCompletionsBody(req, a.cfg.OptionsKey, chatCompletionsBodyOptions{})
+	body, err := toChatCompletionsBody(req, a.cfg.OptionsKey, chatCompletionsBodyOptions{
+		MetadataField: a.cfg.MetadataField,
+	})
 	if err != nil {
 		return llm.Response{}, err
 	}
@@ -87,8 +96,9 @@ func (a *Adapter) Stream(ctx context.Context, req llm.Request) (llm.Stream, erro
 		baseCancel()
 	}
 	body, err := toChatCompletionsBody(req, a.cfg.OptionsKey, chatCompletionsBodyOptions{
-		Stream:       true,
-		IncludeUsage: true,
+		Stream:        true,
+		IncludeUsage:  true,
+		MetadataField: a.cfg.MetadataField,
 	})
 	if err != nil {
 		cancelAll()
@@ -176,8 +186,9 @@ func (a *Adapter) Stream(ctx context.Context, req llm.Request) (llm.Stream, erro
 }
 
 type chatCompletionsBodyOptions struct {
-	Stream       bool
-	IncludeUsage bool
+	Stream        bool
+	IncludeUsage  bool
+	MetadataField string
 }
 
 func toChatCompletionsBody(req llm.Request, optionsKey string, opts chatCompletionsBodyOptions) ([]byte, error) {
@@ -194,6 +205,9 @@ func toChatCompletionsBody(req llm.Request, optionsKey string, opts chatCompleti
 	if req.ReasoningEffort != nil && *req.ReasoningEffort != "" {
 		body["reasoning_effort"] = *req.ReasoningEffort
 	}
+	if opts.MetadataField != "" && len(req.Metadata) > 0 {
+		body[opts.MetadataField] = req.Metadata
+	}
 	if req.ProviderOptions != nil {
 		if ov, ok := req.ProviderOptions[optionsKey].(map[string]any); ok {
 			for k, v := range ov {
@@ -486,3 +486,38 @@ func TestWithDefaultRequestDeadline_PreservesExistingDeadline(t *testing.T) {
 		t.Fatalf("deadline changed: got %v want %v", deadline, origDeadline)
 	}
 }
+
+func TestAdapter_Complete_SerializesMetadataWhenFieldConfigured(t *testing.T) {
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		_, _ = w.Write([]byte(`{"id":"c1","model":"m","choices":[{"finish_reason":"stop","message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer srv.Close()
+
+	req := llm.Request{
+		Provider: "zai",
+		Model:    "glm-4.7",
+		Messages: []llm.Message{llm.User("hi")},
+		Metadata: map[string]string{"team": "core", "run_id": "r1"},
+	}
+
+	a := NewAdapter(Config{Provider: "zai", APIKey: "k", BaseURL: srv.URL, MetadataField: "metadata"})
+	if _, err := a.Complete(context.Background(), req); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	md, ok := gotBody["metadata"].(map[string]any)
+	if !ok || md["team"] != "core" || md["run_id"] != "r1" {
+		t.Fatalf("metadata: got %#v", gotBody["metadata"])
+	}
+
+	// Without a configured field, metadata is dropped silently.
+	gotBody = nil
+	a = NewAdapter(Config{Provider: "zai", APIKey: "k", BaseURL: srv.URL})
+	if _, err := a.Complete(context.Background(), req); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if _, present := gotBody["metadata"]; present {
+		t.Fatalf("metadata should be omitted when MetadataField is empty: %#v", gotBody)
+	}
+}
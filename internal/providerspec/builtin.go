@@ -1,8 +1,11 @@
 package providerspec
 
+import "sort"
+
 var builtinSpecs = map[string]Spec{
 	"openai": {
-		Key: "openai",
+		Key:     "openai",
+		Aliases: []string{"codex"},
 		API: &APISpec{
 			Protocol:           ProtocolOpenAIResponses,
 			DefaultBaseURL:     "https://api.openai.com",
@@ -99,6 +102,29 @@ var builtinSpecs = map[string]Spec{
 		},
 		Failover: []string{"zai"},
 	},
+	// aider and opencode are CLI-only coding agents. Their models are passed
+	// through in the agent's own provider/model form (e.g.
+	// anthropic/claude-sonnet-4-5).
+	"aider": {
+		Key: "aider",
+		CLI: &CLISpec{
+			DefaultExecutable:  "aider",
+			InvocationTemplate: []string{"--yes-always", "--no-auto-commits", "--no-pretty", "--no-stream", "--model", "{{model}}", "--message", "{{prompt}}"},
+			PromptMode:         "arg",
+			HelpProbeArgs:      []string{"--help"},
+			CapabilityAll:      []string{"--message", "--yes-always", "--no-auto-commits"},
+		},
+	},
+	"opencode": {
+		Key: "opencode",
+		CLI: &CLISpec{
+			DefaultExecutable:  "opencode",
+			InvocationTemplate: []string{"run", "--format", "json", "--model", "{{model}}", "{{prompt}}"},
+			PromptMode:         "arg",
+			HelpProbeArgs:      []string{"run", "--help"},
+			CapabilityAll:      []string{"--model", "--format"},
+		},
+	},
 	"minimax": {
 		Key:     "minimax",
 		Aliases: []string{"minimax-ai"},
@@ -122,6 +148,19 @@ func Builtin(key string) (Spec, bool) {
 	return cloneSpec(s), true
 }
 
+// CLIProviders returns the sorted keys of builtin providers with a CLI
+// contract.
+func CLIProviders() []string {
+	var out []string
+	for key, spec := range builtinSpecs {
+		if spec.CLI != nil {
+			out = append(out, key)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
 func Builtins() map[string]Spec {
 	out := make(map[string]Spec, len(builtinSpecs))
 	for key, spec := range builtinSpecs {
@@ -21,6 +21,9 @@ type APISpec struct {
 	DefaultAPIKeyEnv   string
 	ProviderOptionsKey string
 	ProfileFamily      string
+	// MetadataField is the request body field that receives request metadata
+	// (cost-attribution tags) for chat-completions endpoints. Empty drops it.
+	MetadataField string
 }
 
 type CLISpec struct {
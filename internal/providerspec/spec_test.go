@@ -1,6 +1,9 @@
 package providerspec
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestBuiltinSpecsIncludeCoreAndNewProviders(t *testing.T) {
 	s := Builtins()
@@ -118,3 +121,24 @@ func TestBuiltinFailoverDefaultsAreSingleHop(t *testing.T) {
 		}
 	}
 }
+
+func TestBuiltinCLIOnlyCodingAgents(t *testing.T) {
+	for _, key := range []string{"aider", "opencode"} {
+		spec, ok := Builtin(key)
+		if !ok {
+			t.Fatalf("expected %s builtin", key)
+		}
+		if spec.API != nil {
+			t.Fatalf("%s should be cli-only", key)
+		}
+		if spec.CLI == nil || spec.CLI.DefaultExecutable != key {
+			t.Fatalf("%s cli spec: %+v", key, spec.CLI)
+		}
+	}
+	if got := CanonicalProviderKey("codex"); got != "openai" {
+		t.Fatalf("codex alias: got %q want %q", got, "openai")
+	}
+	if got, want := CLIProviders(), []string{"aider", "anthropic", "google", "openai", "opencode"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("CLIProviders=%v want %v", got, want)
+	}
+}
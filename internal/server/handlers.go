@@ -114,6 +114,9 @@ func (s *Server) handleSubmitPipeline(w http.ResponseWriter, r *http.Request) {
 		}
 
 		res, err := engine.RunWithConfig(ctx, dotSource, cfg, overrides)
+		if ctx.Err() != nil {
+			ps.markCanceled()
+		}
 		ps.SetResult(res, err)
 	}()
 
@@ -19,11 +19,12 @@ type PipelineState struct {
 	StartedAt   time.Time
 	LogsRoot    string
 
-	mu     sync.Mutex
-	eng    *engine.Engine
-	result *engine.Result
-	err    error
-	done   bool
+	mu       sync.Mutex
+	eng      *engine.Engine
+	result   *engine.Result
+	err      error
+	done     bool
+	canceled bool
 }
 
 // SetEngine stores a reference to the live engine (for context inspection).
@@ -42,6 +43,15 @@ func (ps *PipelineState) SetResult(res *engine.Result, err error) {
 	ps.done = true
 }
 
+// markCanceled records that the pipeline's context was canceled (via the
+// cancel endpoint or server shutdown), so a run error reports as canceled
+// rather than failed. Call it before SetResult.
+func (ps *PipelineState) markCanceled() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.canceled = true
+}
+
 // Status returns the current pipeline status for the HTTP API.
 func (ps *PipelineState) Status() PipelineStatus {
 	ps.mu.Lock()
@@ -55,6 +65,9 @@ func (ps *PipelineState) Status() PipelineStatus {
 	if ps.done {
 		if ps.err != nil {
 			status.State = string(runtime.FinalFail)
+			if ps.canceled {
+				status.State = string(runtime.FinalCanceled)
+			}
 			status.FailureReason = ps.err.Error()
 		} else if ps.result != nil {
 			status.State = string(ps.result.FinalStatus)
@@ -105,3 +105,16 @@ func TestPipelineState_Status(t *testing.T) {
 		t.Fatalf("unexpected failure reason: %s", status.FailureReason)
 	}
 }
+
+func TestPipelineState_StatusCanceled(t *testing.T) {
+	ps := &PipelineState{RunID: "test-run"}
+	ps.markCanceled()
+	ps.SetResult(nil, fmt.Errorf("canceled via HTTP API"))
+	status := ps.Status()
+	if status.State != "canceled" {
+		t.Fatalf("expected canceled, got %s", status.State)
+	}
+	if status.FailureReason != "canceled via HTTP API" {
+		t.Fatalf("unexpected failure reason: %s", status.FailureReason)
+	}
+}